@@ -2,10 +2,23 @@
  * Bootstrap script - fetches vehicle info from frame number and updates .env
  *
  * Run with: deno task bootstrap
+ *
+ * If the frame number lookup fails, a built-in preset can be picked instead.
+ * To skip the lookup:
+ *   deno task bootstrap --preset pd6w-swb-chamonix
+ *   deno task bootstrap --list-presets
  */
 
 import { load as loadCheerio } from "cheerio";
 import { load as loadEnv } from "@std/dotenv";
+import {
+  findPreset,
+  matchTrims,
+  PRESET_FRAMES,
+  type TrimOption,
+  VEHICLE_PRESETS,
+  type VehiclePreset,
+} from "../src/presets.ts";
 
 const ENV_PATH = "../.env";
 
@@ -256,7 +269,109 @@ async function fetchVehicleInfo(frameNo: string): Promise<{
   };
 }
 
+/**
+ * Lists a frame's complectations from its epc-data page, which links each
+ * one as /delica_space_gear/{frame_name}/{trim_code}/
+ */
+async function fetchTrims(frameName: string): Promise<TrimOption[]> {
+  const baseUrl = "https://mitsubishi.epc-data.com";
+  const frameUrl = `${baseUrl}/delica_space_gear/${frameName}/`;
+
+  console.log(`Fetching complectations from ${frameUrl}`);
+  const response = await fetchWithRetry(frameUrl, { referer: `${baseUrl}/delica_space_gear/` });
+  const $ = loadCheerio(await response.text());
+
+  const trims: TrimOption[] = [];
+  const seen = new Set<string>();
+  const pattern = new RegExp(`/delica_space_gear/${frameName}/([^/]+)/?$`);
+  $('a[href*="/delica_space_gear/"]').each((_, el) => {
+    const match = ($(el).attr("href") || "").match(pattern);
+    if (match && !seen.has(match[1])) {
+      seen.add(match[1]);
+      trims.push({ trimCode: match[1], name: $(el).text().trim() });
+    }
+  });
+  return trims;
+}
+
+function printPresets(): void {
+  console.log("Vehicle presets:\n");
+  for (const { frameName, description } of PRESET_FRAMES) {
+    console.log(`  ${frameName.toUpperCase()} - ${description}`);
+    for (const preset of VEHICLE_PRESETS.filter((p) => p.frameName === frameName)) {
+      console.log(`    ${preset.id}`);
+    }
+  }
+}
+
+/** Asks for a preset by number or id. Returns undefined if none is picked. */
+function choosePreset(): VehiclePreset | undefined {
+  console.log("\nPick the closest preset instead:\n");
+  VEHICLE_PRESETS.forEach((preset, i) => {
+    console.log(`  ${String(i + 1).padStart(2)}) ${preset.label}`);
+  });
+  const answer = prompt("\nPreset number or id (blank to skip):")?.trim();
+  if (!answer) return undefined;
+
+  const n = Number(answer);
+  const preset = Number.isInteger(n) ? VEHICLE_PRESETS[n - 1] : findPreset(answer);
+  if (!preset) {
+    console.error(`No preset ${answer}`);
+  }
+  return preset;
+}
+
+/**
+ * Fills in FRAME_NAME and TRIM_CODE from a preset. A preset without a known
+ * trim code takes it from the frame's complectations on epc-data, asking
+ * which one when more than one matches.
+ */
+async function applyPreset(env: EnvVars, preset: VehiclePreset): Promise<void> {
+  let trim: TrimOption | undefined = preset.trimCode
+    ? { trimCode: preset.trimCode, name: preset.label }
+    : undefined;
+
+  if (!trim) {
+    const trims = matchTrims(preset, await fetchTrims(preset.frameName));
+    if (trims.length === 0) {
+      throw new Error(`No ${preset.grade} complectations listed for ${preset.frameName.toUpperCase()}`);
+    }
+    trim = trims[0];
+    if (trims.length > 1) {
+      console.log(`\n${trims.length} complectations match ${preset.label}:\n`);
+      trims.forEach((t, i) => console.log(`  ${String(i + 1).padStart(2)}) ${t.trimCode.toUpperCase()} ${t.name}`));
+      const answer = Number(prompt("\nWhich one? [1]:")?.trim() || "1");
+      trim = trims[answer - 1] ?? trims[0];
+    }
+  }
+
+  env.VEHICLE_NAME = trim.name;
+  env.FRAME_NAME = preset.frameName;
+  env.TRIM_CODE = trim.trimCode;
+
+  console.log("\nPreset vehicle info:");
+  console.log(`  Vehicle Name: ${env.VEHICLE_NAME}`);
+  console.log(`  Frame Name: ${env.FRAME_NAME}`);
+  console.log(`  Trim Code: ${env.TRIM_CODE}`);
+}
+
 async function main() {
+  if (Deno.args.includes("--list-presets")) {
+    printPresets();
+    return;
+  }
+
+  let preset: VehiclePreset | undefined;
+  const presetIndex = Deno.args.indexOf("--preset");
+  if (presetIndex !== -1) {
+    const id = Deno.args[presetIndex + 1] ?? "";
+    preset = findPreset(id);
+    if (!preset) {
+      console.error(`Unknown preset "${id}". Run with --list-presets to see them.`);
+      Deno.exit(1);
+    }
+  }
+
   console.log("Delica Parts Scraper - Bootstrap");
   console.log("=================================\n");
 
@@ -277,6 +392,18 @@ async function main() {
     console.log(`Using existing FRAME_NO=${env.FRAME_NO}\n`);
   }
 
+  if (preset) {
+    try {
+      await applyPreset(env, preset);
+      await saveEnv(env);
+      console.log("\n.env updated successfully!");
+    } catch (error) {
+      console.error(`\nError applying preset ${preset.id}: ${error}`);
+      Deno.exit(1);
+    }
+    return;
+  }
+
   // Fetch vehicle info
   try {
     const info = await fetchVehicleInfo(env.FRAME_NO);
@@ -294,6 +421,19 @@ async function main() {
 
   } catch (error) {
     console.error(`\nError fetching vehicle info: ${error}`);
+
+    const chosen = choosePreset();
+    if (chosen) {
+      try {
+        await applyPreset(env, chosen);
+        await saveEnv(env);
+        console.log("\n.env updated successfully!");
+        return;
+      } catch (error) {
+        console.error(`\nError applying preset ${chosen.id}: ${error}`);
+      }
+    }
+
     console.error("\nYou may need to manually add the following to .env:");
     console.error("  FRAME_NAME=pd6w");
     console.error("  TRIM_CODE=hseue9");
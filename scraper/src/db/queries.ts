@@ -119,11 +119,20 @@ export async function getDiagramsWithoutImages(
 }
 
 // Part operations
+
+/**
+ * SQL for a new part's added_at: now, if its group (the last argument) has
+ * been synced in full before, so the TUI can list parts a re-scrape found;
+ * NULL for parts from a group's first scrape. INSERT OR IGNORE leaves it
+ * alone on parts already in the catalog.
+ */
+const ADDED_AT = "(SELECT datetime('now') FROM group_sync WHERE group_id = ?)";
+
 export async function insertPart(client: Client, part: Part): Promise<void> {
   const searchTerms = generateSearchTerms(part.description, part.part_number);
   await client.execute({
-    sql: `INSERT OR IGNORE INTO parts (detail_page_id, part_number, pnc, description, ref_number, quantity, spec, notes, color, model_date_range, diagram_id, group_id, subgroup_id, replacement_part_number, search_terms)
-          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+    sql: `INSERT OR IGNORE INTO parts (detail_page_id, part_number, pnc, description, ref_number, quantity, spec, notes, color, model_date_range, diagram_id, group_id, subgroup_id, replacement_part_number, search_terms, added_at)
+          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ${ADDED_AT})`,
     args: [
       part.detail_page_id,
       part.part_number,
@@ -140,6 +149,7 @@ export async function insertPart(client: Client, part: Part): Promise<void> {
       part.subgroup_id ?? null,
       part.replacement_part_number ?? null,
       searchTerms,
+      part.group_id,
     ],
   });
 }
@@ -153,8 +163,8 @@ export async function insertParts(
   const batch = parts.map((part) => {
     const searchTerms = generateSearchTerms(part.description, part.part_number);
     return {
-      sql: `INSERT OR IGNORE INTO parts (detail_page_id, part_number, pnc, description, ref_number, quantity, spec, notes, color, model_date_range, diagram_id, group_id, subgroup_id, replacement_part_number, search_terms)
-            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+      sql: `INSERT OR IGNORE INTO parts (detail_page_id, part_number, pnc, description, ref_number, quantity, spec, notes, color, model_date_range, diagram_id, group_id, subgroup_id, replacement_part_number, search_terms, added_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ${ADDED_AT})`,
       args: [
         part.detail_page_id,
         part.part_number,
@@ -171,6 +181,7 @@ export async function insertParts(
         part.subgroup_id ?? null,
         part.replacement_part_number ?? null,
         searchTerms,
+        part.group_id,
       ],
     };
   });
@@ -279,6 +290,65 @@ export async function resetFailedUrls(client: Client): Promise<number> {
   return result.rowsAffected;
 }
 
+// Forget scrape progress for URLs under prefix so they are fetched again
+export async function resetUrlsWithPrefix(
+  client: Client,
+  prefix: string
+): Promise<number> {
+  const result = await client.execute({
+    sql: `DELETE FROM scrape_progress WHERE substr(url, 1, ?) = ?`,
+    args: [prefix.length, prefix],
+  });
+  return result.rowsAffected;
+}
+
+export async function countUnfinishedUrlsWithPrefix(
+  client: Client,
+  prefix: string
+): Promise<number> {
+  const result = await client.execute({
+    sql: `SELECT COUNT(*) as count FROM scrape_progress
+          WHERE status != 'completed' AND substr(url, 1, ?) = ?`,
+    args: [prefix.length, prefix],
+  });
+  return result.rows[0].count as number;
+}
+
+// Group sync times
+export async function markGroupSynced(
+  client: Client,
+  groupId: string
+): Promise<void> {
+  await client.execute({
+    sql: `INSERT OR REPLACE INTO group_sync (group_id, synced_at)
+          VALUES (?, datetime('now'))`,
+    args: [groupId],
+  });
+}
+
+export interface GroupSync {
+  groupId: string;
+  name: string;
+  parts: number;
+  syncedAt: string | null;
+}
+
+export async function getGroupSyncs(client: Client): Promise<GroupSync[]> {
+  const result = await client.execute(`
+    SELECT g.id, g.name, gs.synced_at,
+           (SELECT COUNT(*) FROM parts p WHERE p.group_id = g.id) as parts
+    FROM groups g
+    LEFT JOIN group_sync gs ON gs.group_id = g.id
+    ORDER BY g.name
+  `);
+  return result.rows.map((row) => ({
+    groupId: row.id as string,
+    name: row.name as string,
+    parts: row.parts as number,
+    syncedAt: row.synced_at as string | null,
+  }));
+}
+
 // Statistics
 export interface ScrapeStats {
   totalUrls: number;
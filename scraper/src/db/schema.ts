@@ -1,7 +1,60 @@
-import type { Client } from "@libsql/client";
+import type { Transaction } from "@libsql/client";
 import { generateSearchTerms } from "./search-terms.ts";
 
-export async function createSchema(client: Client): Promise<void> {
+/**
+ * What the schema is created and migrated through: a client, or a
+ * transaction when `migrate --dry-run` rolls the changes back.
+ */
+export type Executor = Pick<Transaction, "execute" | "batch">;
+
+/**
+ * Columns indexed by parts_fts, in FTS column order. Identifiers and
+ * descriptions come first; spec and notes text is indexed last so the TUI can
+ * weight matches there below part number and PNC matches.
+ */
+export const FTS_COLUMNS = [
+  "part_number",
+  "pnc",
+  "description",
+  "search_terms",
+  "spec",
+  "notes",
+];
+
+/**
+ * Create the triggers that keep parts_fts in sync with parts.
+ */
+async function createFtsTriggers(client: Executor, ifNotExists: boolean): Promise<void> {
+  const cols = FTS_COLUMNS.join(", ");
+  const newValues = FTS_COLUMNS.map((c) => `new.${c}`).join(", ");
+  const oldValues = FTS_COLUMNS.map((c) => `old.${c}`).join(", ");
+  const guard = ifNotExists ? "IF NOT EXISTS " : "";
+
+  await client.execute(`
+    CREATE TRIGGER ${guard}parts_ai AFTER INSERT ON parts BEGIN
+      INSERT INTO parts_fts(rowid, ${cols})
+      VALUES (new.id, ${newValues});
+    END
+  `);
+
+  await client.execute(`
+    CREATE TRIGGER ${guard}parts_ad AFTER DELETE ON parts BEGIN
+      INSERT INTO parts_fts(parts_fts, rowid, ${cols})
+      VALUES ('delete', old.id, ${oldValues});
+    END
+  `);
+
+  await client.execute(`
+    CREATE TRIGGER ${guard}parts_au AFTER UPDATE ON parts BEGIN
+      INSERT INTO parts_fts(parts_fts, rowid, ${cols})
+      VALUES ('delete', old.id, ${oldValues});
+      INSERT INTO parts_fts(rowid, ${cols})
+      VALUES (new.id, ${newValues});
+    END
+  `);
+}
+
+export async function createSchema(client: Executor): Promise<void> {
   // Groups table (top-level categories)
   await client.execute(`
     CREATE TABLE IF NOT EXISTS groups (
@@ -51,6 +104,7 @@ export async function createSchema(client: Client): Promise<void> {
       group_id TEXT NOT NULL REFERENCES groups(id),
       subgroup_id TEXT REFERENCES subgroups(id),
       replacement_part_number TEXT,
+      added_at TEXT,
       UNIQUE(part_number, diagram_id)
     )
   `);
@@ -104,37 +158,26 @@ export async function createSchema(client: Client): Promise<void> {
     )
   `);
 
-  // Full-text search index (includes expanded search_terms for synonyms/abbreviations)
+  // When each group was last scraped in full, by a full scrape or a
+  // group sync
   await client.execute(`
-    CREATE VIRTUAL TABLE IF NOT EXISTS parts_fts USING fts5(
-      part_number, description, search_terms,
-      content='parts', content_rowid='id'
+    CREATE TABLE IF NOT EXISTS group_sync (
+      group_id TEXT PRIMARY KEY,
+      synced_at TEXT NOT NULL
     )
   `);
 
-  // Triggers to keep FTS in sync (includes search_terms)
-  await client.execute(`
-    CREATE TRIGGER IF NOT EXISTS parts_ai AFTER INSERT ON parts BEGIN
-      INSERT INTO parts_fts(rowid, part_number, description, search_terms)
-      VALUES (new.id, new.part_number, new.description, new.search_terms);
-    END
-  `);
-
+  // Full-text search index (includes expanded search_terms for synonyms/abbreviations).
+  // Column order matters: the TUI weights columns when ranking results.
   await client.execute(`
-    CREATE TRIGGER IF NOT EXISTS parts_ad AFTER DELETE ON parts BEGIN
-      INSERT INTO parts_fts(parts_fts, rowid, part_number, description, search_terms)
-      VALUES ('delete', old.id, old.part_number, old.description, old.search_terms);
-    END
+    CREATE VIRTUAL TABLE IF NOT EXISTS parts_fts USING fts5(
+      ${FTS_COLUMNS.join(", ")},
+      content='parts', content_rowid='id'
+    )
   `);
 
-  await client.execute(`
-    CREATE TRIGGER IF NOT EXISTS parts_au AFTER UPDATE ON parts BEGIN
-      INSERT INTO parts_fts(parts_fts, rowid, part_number, description, search_terms)
-      VALUES ('delete', old.id, old.part_number, old.description, old.search_terms);
-      INSERT INTO parts_fts(rowid, part_number, description, search_terms)
-      VALUES (new.id, new.part_number, new.description, new.search_terms);
-    END
-  `);
+  // Triggers to keep FTS in sync
+  await createFtsTriggers(client, true);
 
   // Run migrations for existing databases BEFORE creating indexes on new columns
   await runMigrations(client);
@@ -199,7 +242,7 @@ export async function createSchema(client: Client): Promise<void> {
  * Run migrations to add new columns to existing tables.
  * Each migration is idempotent - it checks if the column exists before adding.
  */
-export async function runMigrations(client: Client): Promise<void> {
+export async function runMigrations(client: Executor): Promise<void> {
   // Migrate parts table
   const partsResult = await client.execute(`PRAGMA table_info(parts)`);
   const partsColumns = new Set(partsResult.rows.map((row) => row.name as string));
@@ -214,6 +257,7 @@ export async function runMigrations(client: Client): Promise<void> {
     { name: "subgroup_id", type: "TEXT REFERENCES subgroups(id)" },
     { name: "replacement_part_number", type: "TEXT" },
     { name: "search_terms", type: "TEXT" },
+    { name: "added_at", type: "TEXT" },
   ];
 
   for (const col of newPartsColumns) {
@@ -308,13 +352,51 @@ export async function runMigrations(client: Client): Promise<void> {
 
   // Migrate to enhanced search terms
   await migrateToEnhancedSearchTerms(client, partsColumns);
+
+  // Add PNC, spec and notes to the FTS index for weighted ranking
+  await migrateFtsColumns(client);
+}
+
+/**
+ * Rebuild parts_fts when it is missing any of FTS_COLUMNS, so older
+ * databases get PNC, spec and notes indexed.
+ */
+async function migrateFtsColumns(client: Executor): Promise<void> {
+  const ftsResult = await client.execute(`PRAGMA table_info(parts_fts)`);
+  const ftsColumns = new Set(ftsResult.rows.map((row) => row.name as string));
+
+  if (FTS_COLUMNS.every((c) => ftsColumns.has(c))) {
+    return;
+  }
+
+  console.log("  Rebuilding FTS index with PNC, spec and notes...");
+
+  await client.execute(`DROP TRIGGER IF EXISTS parts_ai`);
+  await client.execute(`DROP TRIGGER IF EXISTS parts_ad`);
+  await client.execute(`DROP TRIGGER IF EXISTS parts_au`);
+  await client.execute(`DROP TABLE IF EXISTS parts_fts`);
+
+  await client.execute(`
+    CREATE VIRTUAL TABLE parts_fts USING fts5(
+      ${FTS_COLUMNS.join(", ")},
+      content='parts', content_rowid='id'
+    )
+  `);
+
+  await createFtsTriggers(client, false);
+
+  console.log("    Repopulating FTS index...");
+  await client.execute(`
+    INSERT INTO parts_fts(rowid, ${FTS_COLUMNS.join(", ")})
+    SELECT id, ${FTS_COLUMNS.join(", ")} FROM parts
+  `);
 }
 
 /**
  * Migrate from the old categories table to the new groups/subgroups tables.
  * Also migrates diagrams.category_id to group_id and subgroup_id.
  */
-async function migrateCategoriestoGroupsSubgroups(client: Client): Promise<void> {
+async function migrateCategoriestoGroupsSubgroups(client: Executor): Promise<void> {
   // Check if categories table exists
   const tablesResult = await client.execute(`
     SELECT name FROM sqlite_master WHERE type='table' AND name='categories'
@@ -404,7 +486,7 @@ async function migrateCategoriestoGroupsSubgroups(client: Client): Promise<void>
 /**
  * Populate group_id and subgroup_id on parts from their linked diagrams.
  */
-async function populatePartsGroupIds(client: Client): Promise<void> {
+async function populatePartsGroupIds(client: Executor): Promise<void> {
   // Check if there are parts with null group_id that need populating
   const checkResult = await client.execute(`
     SELECT COUNT(*) as count FROM parts WHERE group_id IS NULL
@@ -437,7 +519,7 @@ async function populatePartsGroupIds(client: Client): Promise<void> {
  * and rebuilds FTS index to include the new column.
  */
 async function migrateToEnhancedSearchTerms(
-  client: Client,
+  client: Executor,
   partsColumns: Set<string>
 ): Promise<void> {
   // Check if search_terms column was just added (needs population)
@@ -549,7 +631,7 @@ async function migrateToEnhancedSearchTerms(
  * the replacement row and drop the replaces_id column.
  */
 async function migrateReplacesIdToReplacementPartNumber(
-  client: Client,
+  client: Executor,
   partsColumns: Set<string>
 ): Promise<void> {
   // Only run if replaces_id column exists
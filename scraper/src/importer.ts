@@ -0,0 +1,421 @@
+import type { Client } from "@libsql/client";
+import { join, relative, SEPARATOR } from "@std/path";
+import {
+  insertDiagram,
+  insertGroup,
+  insertParts,
+  insertSubgroup,
+  mergeReplacementParts,
+} from "./db/queries.ts";
+import {
+  extractPageTitle,
+  hasPartsTable,
+  isSubcategoryListing,
+  parseDetailListSections,
+  parsePartsPage,
+} from "./scraper/parser.ts";
+import { cleanSubgroupName, slugify } from "./utils.ts";
+import type { Diagram, Group, Part, Subgroup } from "./types.ts";
+
+// Catalog data read from a dump, in the database's own shape
+export interface ImportData {
+  format: ImportFormat;
+  groups: Group[];
+  subgroups: Subgroup[];
+  diagrams: Diagram[];
+  parts: Part[];
+}
+
+export type ImportFormat = "catalog-json" | "parts-json" | "html-mirror";
+
+// What an import adds; existing rows are never overwritten
+export interface ImportPreview {
+  newGroups: number;
+  newSubgroups: number;
+  newDiagrams: number;
+  newParts: number;
+  existingParts: number;
+  // Parts already in the catalog whose fields differ in the dump
+  differingParts: { part_number: string; diagram_id: string; fields: string[] }[];
+}
+
+const EPC_ORIGIN = "https://mitsubishi.epc-data.com/";
+
+/**
+ * Detect the dump's format and read it:
+ *  - catalog-json: an object with groups, subgroups, diagrams and parts arrays,
+ *    as written by this scraper's tables or other community scrapers
+ *  - parts-json: a flat array of parts, each naming its group and subgroup
+ *  - html-mirror: a directory of saved epc-data pages (e.g. `wget -m`)
+ */
+export async function readDump(path: string): Promise<ImportData> {
+  const info = await Deno.stat(path);
+  if (info.isDirectory) {
+    return await readHtmlMirror(path);
+  }
+
+  const text = await Deno.readTextFile(path);
+  let json: unknown;
+  try {
+    json = JSON.parse(text);
+  } catch {
+    throw new Error(`${path} is neither JSON nor a directory of HTML pages`);
+  }
+
+  if (Array.isArray(json)) {
+    return readPartsJson(json);
+  }
+  if (json && typeof json === "object" && Array.isArray((json as Record<string, unknown>).parts)) {
+    return readCatalogJson(json as Record<string, unknown>);
+  }
+  throw new Error(`${path}: expected a parts array or an object with a "parts" array`);
+}
+
+// Field lookup accepting snake_case or camelCase keys
+function field(record: Record<string, unknown>, name: string): unknown {
+  if (name in record) return record[name];
+  const camel = name.replace(/_([a-z])/g, (_, c: string) => c.toUpperCase());
+  return record[camel];
+}
+
+function str(record: Record<string, unknown>, name: string): string | null {
+  const value = field(record, name);
+  if (value === undefined || value === null || value === "") return null;
+  return String(value);
+}
+
+function num(record: Record<string, unknown>, name: string): number | null {
+  const value = field(record, name);
+  if (value === undefined || value === null || value === "") return null;
+  const n = Number(value);
+  return Number.isFinite(n) ? n : null;
+}
+
+function records(value: unknown): Record<string, unknown>[] {
+  return Array.isArray(value) ? value.filter((v) => v && typeof v === "object") : [];
+}
+
+function toPart(r: Record<string, unknown>, groupId: string, subgroupId: string | null, diagramId: string): Part {
+  return {
+    detail_page_id: str(r, "detail_page_id"),
+    part_number: str(r, "part_number")!,
+    pnc: str(r, "pnc"),
+    description: str(r, "description"),
+    ref_number: str(r, "ref_number"),
+    quantity: num(r, "quantity"),
+    spec: str(r, "spec"),
+    notes: str(r, "notes"),
+    color: str(r, "color"),
+    model_date_range: str(r, "model_date_range"),
+    diagram_id: diagramId,
+    group_id: groupId,
+    subgroup_id: subgroupId,
+    replacement_part_number: str(r, "replacement_part_number"),
+  };
+}
+
+function readCatalogJson(json: Record<string, unknown>): ImportData {
+  const data: ImportData = { format: "catalog-json", groups: [], subgroups: [], diagrams: [], parts: [] };
+
+  for (const r of records(json.groups)) {
+    const id = str(r, "id");
+    if (id) data.groups.push({ id, name: str(r, "name") ?? id });
+  }
+  for (const r of records(json.subgroups)) {
+    const id = str(r, "id");
+    const groupId = str(r, "group_id");
+    if (id && groupId) {
+      data.subgroups.push({ id, name: str(r, "name") ?? id, group_id: groupId, path: str(r, "path") ?? id });
+    }
+  }
+  for (const r of records(json.diagrams)) {
+    const id = str(r, "id");
+    const groupId = str(r, "group_id");
+    if (id && groupId) {
+      data.diagrams.push({
+        id,
+        group_id: groupId,
+        subgroup_id: str(r, "subgroup_id"),
+        name: str(r, "name") ?? id,
+        image_url: str(r, "image_url"),
+        image_path: null, // images are downloaded by the scraper, not copied
+        source_url: str(r, "source_url") ?? "",
+      });
+    }
+  }
+  for (const r of records(json.parts)) {
+    const groupId = str(r, "group_id");
+    const diagramId = str(r, "diagram_id");
+    if (str(r, "part_number") && groupId && diagramId) {
+      data.parts.push(toPart(r, groupId, str(r, "subgroup_id"), diagramId));
+    }
+  }
+  return data;
+}
+
+// Flat part lists name their group and subgroup instead of referencing
+// rows, so ids are derived the way the scraper derives them from URLs
+function readPartsJson(json: unknown[]): ImportData {
+  const data: ImportData = { format: "parts-json", groups: [], subgroups: [], diagrams: [], parts: [] };
+  const seen = new Set<string>();
+
+  for (const r of records(json)) {
+    const groupName = str(r, "group") ?? str(r, "group_name") ?? str(r, "group_id");
+    if (!str(r, "part_number") || !groupName) continue;
+    const groupId = str(r, "group_id") ?? slugify(groupName);
+    const subgroupName = str(r, "subgroup") ?? str(r, "subgroup_name");
+    const subgroupId = str(r, "subgroup_id") ??
+      (subgroupName ? `${groupId}/${slugify(subgroupName)}` : null);
+    const diagramId = str(r, "diagram_id") ?? subgroupId ?? groupId;
+
+    if (!seen.has(`g:${groupId}`)) {
+      seen.add(`g:${groupId}`);
+      data.groups.push({ id: groupId, name: groupName });
+    }
+    if (subgroupId && !seen.has(`s:${subgroupId}`)) {
+      seen.add(`s:${subgroupId}`);
+      data.subgroups.push({ id: subgroupId, name: subgroupName ?? subgroupId, group_id: groupId, path: subgroupId });
+    }
+    if (!seen.has(`d:${diagramId}`)) {
+      seen.add(`d:${diagramId}`);
+      data.diagrams.push({
+        id: diagramId,
+        group_id: groupId,
+        subgroup_id: subgroupId,
+        name: subgroupName ?? groupName,
+        image_url: str(r, "image_url"),
+        image_path: null,
+        source_url: "",
+      });
+    }
+    data.parts.push(toPart(r, groupId, subgroupId, diagramId));
+  }
+  return data;
+}
+
+async function* walkHtml(dir: string): AsyncGenerator<string> {
+  for await (const entry of Deno.readDir(dir)) {
+    const path = join(dir, entry.name);
+    if (entry.isDirectory) {
+      yield* walkHtml(path);
+    } else if (entry.isFile && /\.html?$/i.test(entry.name)) {
+      yield path;
+    }
+  }
+}
+
+// mirrorUrl rebuilds the page URL from a mirrored file's path, which wget and
+// similar tools lay out as host/delica_space_gear/frame/trim/group/...
+function mirrorUrl(root: string, file: string): string | null {
+  const segments = relative(root, file).split(SEPARATOR);
+  const start = segments.indexOf("delica_space_gear");
+  if (start < 0) return null;
+  const path = segments.slice(start);
+  const last = path[path.length - 1];
+  if (/^index\.html?$/i.test(last)) {
+    path.pop();
+  } else {
+    path[path.length - 1] = last.replace(/\.html?$/i, "");
+  }
+  return `${EPC_ORIGIN}${path.join("/")}/`;
+}
+
+/**
+ * Read saved epc-data pages the way the scraper reads live ones: subgroup
+ * listings first, to map detail pages onto diagram sections, then the parts
+ * pages themselves.
+ */
+async function readHtmlMirror(root: string): Promise<ImportData> {
+  const data: ImportData = { format: "html-mirror", groups: [], subgroups: [], diagrams: [], parts: [] };
+  const pages: { url: string; html: string }[] = [];
+  for await (const file of walkHtml(root)) {
+    const url = mirrorUrl(root, file);
+    if (url) pages.push({ url, html: await Deno.readTextFile(file) });
+  }
+  if (pages.length === 0) {
+    throw new Error(`${root} has no saved delica_space_gear pages`);
+  }
+
+  const groups = new Set<string>();
+  const addGroup = (id: string) => {
+    if (!groups.has(id)) {
+      groups.add(id);
+      data.groups.push({ id, name: id });
+    }
+  };
+  const detailMap = new Map<string, { diagramId: string; subgroupId: string }[]>();
+
+  for (const { url, html } of pages) {
+    if (!isSubcategoryListing(html, url)) continue;
+    const pathParts = new URL(url).pathname.split("/").filter(Boolean);
+    const [groupSlug, subgroupSlug] = [pathParts[3], pathParts[4]];
+    if (!groupSlug || !subgroupSlug) continue;
+    addGroup(groupSlug);
+
+    const basePath = `${groupSlug}/${subgroupSlug}`;
+    const pageTitle = cleanSubgroupName(extractPageTitle(html) || subgroupSlug.replace(/-/g, " "));
+    const sections = parseDetailListSections(html, url);
+    const multi = sections.length > 1;
+
+    if (!multi) {
+      data.subgroups.push({ id: basePath, name: pageTitle, group_id: groupSlug, path: basePath });
+    }
+    for (const section of sections) {
+      const subgroupId = multi ? `${basePath}/${section.slug}` : basePath;
+      const name = multi ? cleanSubgroupName(section.heading) : pageTitle;
+      if (multi) {
+        data.subgroups.push({ id: subgroupId, name: `${pageTitle} - ${name}`, group_id: groupSlug, path: basePath });
+      } else if (!section.imageUrl) {
+        continue;
+      }
+      data.diagrams.push({
+        id: subgroupId,
+        group_id: groupSlug,
+        subgroup_id: subgroupId,
+        name,
+        image_url: section.imageUrl,
+        image_path: null,
+        source_url: url,
+      });
+      for (const detailId of section.detailPageIds) {
+        const existing = detailMap.get(detailId) ?? [];
+        existing.push({ diagramId: subgroupId, subgroupId });
+        detailMap.set(detailId, existing);
+      }
+    }
+  }
+
+  for (const { url, html } of pages) {
+    if (!hasPartsTable(html)) continue;
+    const pathParts = new URL(url).pathname.split("/").filter(Boolean);
+    const groupSlug = pathParts[3] || "unknown";
+    const subgroupSlug = pathParts[4];
+    const detailPageId = pathParts[5] || null;
+    addGroup(groupSlug);
+
+    let mappings = detailPageId ? detailMap.get(detailPageId) : undefined;
+    const fallbackId = subgroupSlug ? `${groupSlug}/${subgroupSlug}` : groupSlug;
+    const { diagram, parts } = parsePartsPage(html, url, mappings?.[0].diagramId ?? fallbackId);
+
+    if (!mappings) {
+      // Listing page wasn't saved; one diagram per subgroup, like the scraper's fallback
+      const subgroupId = subgroupSlug ? fallbackId : null;
+      if (subgroupId && !data.subgroups.some((s) => s.id === subgroupId)) {
+        const name = cleanSubgroupName(extractPageTitle(html) || subgroupSlug.replace(/-/g, " "));
+        data.subgroups.push({ id: subgroupId, name, group_id: groupSlug, path: subgroupId });
+      }
+      if (!data.diagrams.some((d) => d.id === diagram.id)) {
+        data.diagrams.push({
+          id: diagram.id,
+          group_id: groupSlug,
+          subgroup_id: subgroupId,
+          name: diagram.name,
+          image_url: diagram.imageUrl,
+          image_path: null,
+          source_url: url,
+        });
+      }
+      mappings = [{ diagramId: diagram.id, subgroupId: subgroupId! }];
+    }
+
+    for (const mapping of mappings) {
+      for (const p of parts) {
+        data.parts.push({
+          detail_page_id: detailPageId,
+          part_number: p.partNumber,
+          pnc: p.pnc,
+          description: p.description,
+          ref_number: p.refNumber,
+          quantity: p.quantity,
+          spec: p.spec,
+          notes: p.notes,
+          color: p.color,
+          model_date_range: p.modelDateRange,
+          diagram_id: mapping.diagramId,
+          group_id: groupSlug,
+          subgroup_id: mapping.subgroupId ?? null,
+          replacement_part_number: null,
+        });
+      }
+    }
+  }
+  return data;
+}
+
+const COMPARED_FIELDS = ["pnc", "description", "ref_number", "quantity", "spec", "notes", "color", "model_date_range"] as const;
+
+async function existingIds(client: Client, table: string): Promise<Set<string>> {
+  const result = await client.execute(`SELECT id FROM ${table}`);
+  return new Set(result.rows.map((row) => String(row.id)));
+}
+
+// Compare the dump with the catalog without changing anything
+export async function previewImport(client: Client, data: ImportData): Promise<ImportPreview> {
+  const groups = await existingIds(client, "groups");
+  const subgroups = await existingIds(client, "subgroups");
+  const diagrams = await existingIds(client, "diagrams");
+
+  const existing = new Map<string, Record<string, unknown>>();
+  const result = await client.execute(
+    `SELECT part_number, diagram_id, ${COMPARED_FIELDS.join(", ")} FROM parts`,
+  );
+  for (const row of result.rows) {
+    existing.set(`${row.part_number}\u0000${row.diagram_id}`, row as unknown as Record<string, unknown>);
+  }
+
+  const preview: ImportPreview = {
+    newGroups: new Set(data.groups.filter((g) => !groups.has(g.id)).map((g) => g.id)).size,
+    newSubgroups: new Set(data.subgroups.filter((s) => !subgroups.has(s.id)).map((s) => s.id)).size,
+    newDiagrams: new Set(data.diagrams.filter((d) => !diagrams.has(d.id)).map((d) => d.id)).size,
+    newParts: 0,
+    existingParts: 0,
+    differingParts: [],
+  };
+
+  const counted = new Set<string>();
+  for (const part of data.parts) {
+    const key = `${part.part_number}\u0000${part.diagram_id}`;
+    if (counted.has(key)) continue;
+    counted.add(key);
+
+    const row = existing.get(key);
+    if (!row) {
+      preview.newParts++;
+      continue;
+    }
+    preview.existingParts++;
+    const fields = COMPARED_FIELDS.filter((f) => {
+      const theirs = part[f];
+      const ours = row[f];
+      return theirs !== null && String(theirs) !== String(ours ?? "");
+    });
+    if (fields.length > 0) {
+      preview.differingParts.push({ part_number: part.part_number, diagram_id: part.diagram_id, fields });
+    }
+  }
+  return preview;
+}
+
+// Add the dump's rows to the catalog. Existing rows are kept as they are,
+// like a re-scrape, so local overrides and user data stay attached.
+export async function applyImport(client: Client, data: ImportData): Promise<void> {
+  for (const group of data.groups) {
+    await insertGroup(client, group);
+  }
+  for (const subgroup of data.subgroups) {
+    await insertSubgroup(client, subgroup);
+  }
+  const diagrams = await existingIds(client, "diagrams");
+  for (const diagram of data.diagrams) {
+    // insertDiagram replaces, which would drop a downloaded image path
+    if (!diagrams.has(diagram.id)) {
+      await insertDiagram(client, diagram);
+    }
+  }
+  for (let i = 0; i < data.parts.length; i += 500) {
+    await insertParts(client, data.parts.slice(i, i + 500));
+  }
+  if (data.format === "html-mirror") {
+    await mergeReplacementParts(client);
+  }
+}
@@ -1,3 +1,4 @@
+import type { Transaction } from "@libsql/client";
 import { getClient, closeClient } from "./db/client.ts";
 import { createSchema, runMigrations } from "./db/schema.ts";
 import {
@@ -5,7 +6,9 @@ import {
   executeQuery,
   resetFailedUrls,
   getFailedUrls,
+  getGroupSyncs,
 } from "./db/queries.ts";
+import { applyImport, previewImport, readDump } from "./importer.ts";
 import { Scraper } from "./scraper/index.ts";
 import { DEFAULT_CONFIG } from "./types.ts";
 
@@ -27,7 +30,7 @@ async function initializeDatabase(): Promise<ReturnType<typeof getClient>> {
   return client;
 }
 
-async function scrapeCommand(): Promise<void> {
+async function scrapeCommand(groups: string[]): Promise<void> {
   console.log("Delica Parts Scraper");
   console.log("====================\n");
 
@@ -35,7 +38,7 @@ async function scrapeCommand(): Promise<void> {
 
   try {
     const scraper = new Scraper(client, DEFAULT_CONFIG);
-    await scraper.run();
+    await scraper.run(groups);
   } finally {
     await closeClient();
   }
@@ -62,6 +65,14 @@ async function statusCommand(): Promise<void> {
     console.log(`  Parts:          ${stats.totalParts}`);
     console.log(`  Images:         ${stats.imagesDownloaded}`);
 
+    const syncs = await getGroupSyncs(client);
+    if (syncs.length > 0) {
+      console.log("\nLast Synced:");
+      for (const sync of syncs) {
+        console.log(`  ${sync.groupId.padEnd(24)} ${String(sync.parts).padStart(6)} parts  ${sync.syncedAt ?? "never"}`);
+      }
+    }
+
     if (stats.failedUrls > 0) {
       console.log("\nFailed URLs:");
       const failedUrls = await getFailedUrls(client);
@@ -94,15 +105,77 @@ async function retryCommand(): Promise<void> {
   }
 }
 
-async function migrateCommand(): Promise<void> {
-  console.log("Delica Parts Scraper - Running Migrations");
+async function migrateCommand(dryRun: boolean): Promise<void> {
+  console.log(`Delica Parts Scraper - Running Migrations${dryRun ? " (dry run)" : ""}`);
   console.log("=========================================\n");
 
+  if (!dryRun) {
+    const client = await initializeDatabase();
+    try {
+      await runMigrations(client);
+      console.log("\nMigrations complete!");
+    } finally {
+      await closeClient();
+    }
+    return;
+  }
+
+  // Opening a missing database would create it
+  try {
+    await Deno.stat(DEFAULT_CONFIG.dbPath);
+  } catch (error) {
+    if (!(error instanceof Deno.errors.NotFound)) {
+      throw error;
+    }
+    console.log(`No database at ${DEFAULT_CONFIG.dbPath}; migrate would create it with the current schema.`);
+    console.log("\nDry run, nothing written.");
+    return;
+  }
+
+  // The migrations log each step as they go; they run in a transaction
+  // that's rolled back, so the counts are what a real run would change
+  const client = getClient(DEFAULT_CONFIG.dbPath);
+  const tx = await client.transaction("write");
+  try {
+    const before = await schemaState(tx);
+    await createSchema(tx);
+    const after = await schemaState(tx);
+
+    console.log("\nChanges:");
+    console.log(`  Schema changed: ${after.schemaVersion !== before.schemaVersion ? "yes" : "no"}`);
+    console.log(`  Rows written:   ${after.changes - before.changes}`);
+    console.log("\nDry run, nothing written.");
+  } finally {
+    await tx.rollback();
+    tx.close();
+    await closeClient();
+  }
+}
+
+// The schema version and rows changed so far on the transaction's connection
+async function schemaState(
+  tx: Transaction
+): Promise<{ schemaVersion: number; changes: number }> {
+  const version = await tx.execute("PRAGMA schema_version");
+  const changes = await tx.execute("SELECT total_changes() AS changes");
+  return {
+    schemaVersion: Number(version.rows[0].schema_version),
+    changes: Number(changes.rows[0].changes),
+  };
+}
+
+async function fetchImagesCommand(dryRun: boolean, verbose: boolean): Promise<void> {
+  console.log(`Delica Parts Scraper - Fetch Images${dryRun ? " (dry run)" : ""}`);
+  console.log("==================================\n");
+
   const client = await initializeDatabase();
 
   try {
-    await runMigrations(client);
-    console.log("\nMigrations complete!");
+    const scraper = new Scraper(client, DEFAULT_CONFIG);
+    await scraper.downloadImages({ dryRun, verbose });
+    if (dryRun) {
+      console.log("\nDry run, nothing written.");
+    }
   } finally {
     await closeClient();
   }
@@ -132,33 +205,102 @@ async function queryCommand(sql: string): Promise<void> {
   }
 }
 
+async function importCommand(path: string, dryRun: boolean): Promise<void> {
+  console.log(`Delica Parts Scraper - Import${dryRun ? " (dry run)" : ""}`);
+  console.log("==============================\n");
+
+  const client = await initializeDatabase();
+
+  try {
+    const data = await readDump(path);
+    console.log(`Detected format: ${data.format}`);
+    console.log(`Read ${data.groups.length} groups, ${data.subgroups.length} subgroups, ${data.diagrams.length} diagrams, ${data.parts.length} parts\n`);
+
+    const preview = await previewImport(client, data);
+    console.log("Changes:");
+    console.log(`  New groups:      ${preview.newGroups}`);
+    console.log(`  New subgroups:   ${preview.newSubgroups}`);
+    console.log(`  New diagrams:    ${preview.newDiagrams}`);
+    console.log(`  New parts:       ${preview.newParts}`);
+    console.log(`  Already present: ${preview.existingParts}`);
+
+    if (preview.differingParts.length > 0) {
+      console.log(`\n${preview.differingParts.length} existing parts differ from the dump (kept as they are):`);
+      for (const p of preview.differingParts.slice(0, 20)) {
+        console.log(`  ${p.part_number} in ${p.diagram_id}: ${p.fields.join(", ")}`);
+      }
+      if (preview.differingParts.length > 20) {
+        console.log(`  ... and ${preview.differingParts.length - 20} more`);
+      }
+    }
+
+    if (dryRun) {
+      console.log("\nDry run, nothing written.");
+      return;
+    }
+
+    await applyImport(client, data);
+    console.log("\nImport complete!");
+  } catch (error) {
+    console.error("Import error:", error instanceof Error ? error.message : error);
+    Deno.exit(1);
+  } finally {
+    await closeClient();
+  }
+}
+
 function printUsage(): void {
   console.log(`
 Delica Parts Scraper - CLI
 
 Usage:
   deno task scrape              Start or resume scraping
+  deno task scrape --group <id> Re-scrape only the given groups (repeatable or comma-separated)
   deno task status              Show scraping progress and statistics
   deno task retry               Retry failed URLs
-  deno task migrate             Run database migrations
+  deno task migrate [--dry-run] Run database migrations
+  deno task fetch-images [--dry-run] [--verbose]
+                                Download diagram images not yet downloaded
   deno task query "<SQL>"       Execute a SQL query
+  deno task import <path> [--dry-run]
+                                Import an epc-data JSON dump or saved HTML pages
 
 Examples:
   deno task scrape
+  deno task scrape --group engine,lubrication
   deno task status
   deno task retry
+  deno task migrate --dry-run
+  deno task fetch-images --dry-run --verbose
   deno task query "SELECT COUNT(*) FROM parts"
   deno task query "SELECT * FROM parts WHERE part_number LIKE 'MB%' LIMIT 10"
   deno task query "SELECT * FROM parts_fts WHERE parts_fts MATCH 'engine'"
+  deno task import ~/Downloads/epc-mirror --dry-run
 `);
 }
 
+// Group IDs from --group flags, each of which may list several
+function parseGroups(args: string[]): string[] {
+  const groups: string[] = [];
+  for (let i = 0; i < args.length; i++) {
+    let value: string | undefined;
+    if (args[i] === "--group") {
+      value = args[++i];
+    } else if (args[i].startsWith("--group=")) {
+      value = args[i].slice("--group=".length);
+    }
+    if (value === undefined) continue;
+    groups.push(...value.split(",").map((g) => g.trim()).filter(Boolean));
+  }
+  return groups;
+}
+
 // Main entry point
 const command = Deno.args[0];
 
 switch (command) {
   case "scrape":
-    await scrapeCommand();
+    await scrapeCommand(parseGroups(Deno.args.slice(1)));
     break;
   case "status":
     await statusCommand();
@@ -167,8 +309,13 @@ switch (command) {
     await retryCommand();
     break;
   case "migrate":
-    await migrateCommand();
+    await migrateCommand(Deno.args.slice(1).includes("--dry-run"));
     break;
+  case "fetch-images": {
+    const args = Deno.args.slice(1);
+    await fetchImagesCommand(args.includes("--dry-run"), args.includes("--verbose"));
+    break;
+  }
   case "query":
     if (Deno.args.length < 2) {
       console.error("Error: Missing SQL query argument");
@@ -177,6 +324,17 @@ switch (command) {
     }
     await queryCommand(Deno.args.slice(1).join(" "));
     break;
+  case "import": {
+    const args = Deno.args.slice(1);
+    const path = args.find((a) => !a.startsWith("--"));
+    if (!path) {
+      console.error("Error: Missing path argument");
+      console.error("Usage: deno task import <path> [--dry-run]");
+      Deno.exit(1);
+    }
+    await importCommand(path, args.includes("--dry-run"));
+    break;
+  }
   default:
     printUsage();
     if (command && command !== "help" && command !== "--help" && command !== "-h") {
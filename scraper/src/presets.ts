@@ -0,0 +1,76 @@
+/**
+ * Built-in vehicle presets for common Space Gear variants, so setting up
+ * doesn't need epc-data's internal frame and trim codes.
+ *
+ * The frame name is the model code in lower case. Trim codes differ by
+ * roof, transmission and market, so a preset only carries one where it's
+ * known; otherwise the trim is picked from the frame's complectation list
+ * on epc-data, narrowed down by grade and wheelbase.
+ */
+
+export type Wheelbase = "SWB" | "LWB";
+export type Grade = "Chamonix" | "Exceed";
+
+export interface VehiclePreset {
+  id: string; // e.g. "pd6w-swb-chamonix"
+  label: string;
+  frameName: string;
+  wheelbase: Wheelbase;
+  grade: Grade;
+  trimCode?: string;
+}
+
+/** The model codes presets are offered for, with what sets them apart. */
+export const PRESET_FRAMES: Array<{ frameName: string; description: string }> = [
+  { frameName: "pd4w", description: "2.4L petrol 4G64, 4WD" },
+  { frameName: "pd6w", description: "2.8L turbo diesel 4M40, 4WD" },
+  { frameName: "pd8w", description: "3.0L turbo diesel 4M40, 4WD" },
+  { frameName: "pe8w", description: "3.0L V6 petrol 6G72, 4WD" },
+];
+
+const WHEELBASES: Wheelbase[] = ["SWB", "LWB"];
+const GRADES: Grade[] = ["Chamonix", "Exceed"];
+
+// Trim codes confirmed against epc-data
+const KNOWN_TRIMS: Record<string, string> = {
+  "pd6w-swb-chamonix": "hseue9",
+};
+
+export const VEHICLE_PRESETS: VehiclePreset[] = PRESET_FRAMES.flatMap(({ frameName }) =>
+  WHEELBASES.flatMap((wheelbase) =>
+    GRADES.map((grade) => {
+      const id = `${frameName}-${wheelbase}-${grade}`.toLowerCase();
+      return {
+        id,
+        label: `${frameName.toUpperCase()} ${wheelbase} ${grade}`,
+        frameName,
+        wheelbase,
+        grade,
+        trimCode: KNOWN_TRIMS[id],
+      };
+    })
+  )
+);
+
+/** Finds a preset by id, ignoring case. */
+export function findPreset(id: string): VehiclePreset | undefined {
+  const wanted = id.trim().toLowerCase();
+  return VEHICLE_PRESETS.find((p) => p.id === wanted);
+}
+
+export interface TrimOption {
+  trimCode: string;
+  name: string;
+}
+
+/**
+ * Narrows a frame's complectations down to those matching the preset's
+ * grade and, where the names say, its wheelbase. Long wheelbase models are
+ * listed with "LONG" in the name.
+ */
+export function matchTrims(preset: VehiclePreset, trims: TrimOption[]): TrimOption[] {
+  const byGrade = trims.filter((t) => t.name.toLowerCase().includes(preset.grade.toLowerCase()));
+  const long = (t: TrimOption) => /\bLONG\b|\bLWB\b/i.test(t.name);
+  const byWheelbase = byGrade.filter((t) => preset.wheelbase === "LWB" ? long(t) : !long(t));
+  return byWheelbase.length > 0 ? byWheelbase : byGrade;
+}
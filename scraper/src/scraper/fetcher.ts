@@ -120,7 +120,7 @@ export class RateLimitedFetcher {
   }
 
 
-  async fetch(url: string, retries = 5): Promise<FetchResult> {
+  async fetch(url: string, retries = this.config.retries + 1): Promise<FetchResult> {
     for (let attempt = 1; attempt <= retries; attempt++) {
       this.maybeResetDelay();
       await this.enforceRateLimit();
@@ -130,8 +130,7 @@ export class RateLimitedFetcher {
         const referer = this.getReferer(url);
 
         const headers: Record<string, string> = {
-          "User-Agent":
-            "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+          "User-Agent": this.config.userAgent,
           Accept:
             "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8",
           "Accept-Language": "en-US,en;q=0.9",
@@ -151,7 +150,10 @@ export class RateLimitedFetcher {
           headers["Cookie"] = cookieHeader;
         }
 
-        const response = await fetch(url, { headers });
+        const response = await fetch(url, {
+          headers,
+          signal: AbortSignal.timeout(this.config.timeout),
+        });
 
         if (response.status === 429) {
           this.increaseDelay();
@@ -221,7 +223,7 @@ export class RateLimitedFetcher {
     };
   }
 
-  async fetchImage(url: string, retries = 5): Promise<{ ok: boolean; data?: Uint8Array; error?: string }> {
+  async fetchImage(url: string, retries = this.config.retries + 1): Promise<{ ok: boolean; data?: Uint8Array; error?: string }> {
     for (let attempt = 1; attempt <= retries; attempt++) {
       this.maybeResetDelay();
       await this.enforceRateLimit();
@@ -231,8 +233,7 @@ export class RateLimitedFetcher {
         const referer = this.getReferer(url);
 
         const headers: Record<string, string> = {
-          "User-Agent":
-            "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+          "User-Agent": this.config.userAgent,
           Accept: "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8",
           "Accept-Language": "en-US,en;q=0.9",
           "Accept-Encoding": "gzip, deflate, br",
@@ -248,7 +249,10 @@ export class RateLimitedFetcher {
           headers["Cookie"] = cookieHeader;
         }
 
-        const response = await fetch(url, { headers });
+        const response = await fetch(url, {
+          headers,
+          signal: AbortSignal.timeout(this.config.timeout),
+        });
 
         if (response.status === 429) {
           this.increaseDelay();
@@ -24,6 +24,9 @@ import {
   getPendingUrls,
   getDiagramsWithoutImages,
   mergeReplacementParts,
+  resetUrlsWithPrefix,
+  countUnfinishedUrlsWithPrefix,
+  markGroupSynced,
 } from "../db/queries.ts";
 
 export class Scraper {
@@ -33,6 +36,11 @@ export class Scraper {
   private urlQueue: string[] = [];
   private processedCount = 0;
 
+  // Groups to sync; empty means the whole catalog
+  private groups: Set<string> = new Set();
+  // Groups with pages scraped this run
+  private scrapedGroups: Set<string> = new Set();
+
   // Maps detail_page_id to pre-created diagram and subgroup IDs
   // A detail page can appear in multiple diagrams, so we store an array
   private diagramGroupMap: Map<string, Array<{ diagramId: string; subgroupId: string }>> = new Map();
@@ -49,20 +57,32 @@ export class Scraper {
     return urlObj.toString();
   }
 
-  async run(): Promise<void> {
+  /**
+   * Scrape the catalog, or with groups, re-scrape just those groups. A group
+   * sync forgets the groups' scrape progress so every page under them is
+   * fetched again, and doesn't follow links into other groups.
+   */
+  async run(groups: string[] = []): Promise<void> {
     console.log("Starting Delica Parts Scraper");
     console.log(`Base URL: ${this.config.baseUrl}`);
     console.log(`Frame Number: ${this.config.frameNumber}`);
     console.log("");
 
-    // Check for pending URLs from previous run
-    const pendingUrls = await getPendingUrls(this.client);
-    if (pendingUrls.length > 0) {
-      console.log(`Resuming with ${pendingUrls.length} pending URLs`);
-      this.urlQueue = [...pendingUrls];
+    this.groups = new Set(groups);
+
+    if (this.groups.size > 0) {
+      console.log(`Syncing groups: ${groups.join(", ")}`);
+      await this.startGroupSync();
     } else {
-      // Start fresh from index page
-      await this.scrapeIndex();
+      // Check for pending URLs from previous run
+      const pendingUrls = await getPendingUrls(this.client);
+      if (pendingUrls.length > 0) {
+        console.log(`Resuming with ${pendingUrls.length} pending URLs`);
+        this.urlQueue = [...pendingUrls];
+      } else {
+        // Start fresh from index page
+        await this.scrapeIndex();
+      }
     }
 
     // Process all pending URLs
@@ -74,9 +94,71 @@ export class Scraper {
     // Download images
     await this.downloadImages();
 
+    await this.recordSyncedGroups();
+
     console.log("\nScraping complete!");
   }
 
+  private groupUrl(groupId: string): string {
+    return `${this.config.baseUrl}${groupId}/`;
+  }
+
+  // Group of a catalog URL, from its path:
+  // ["delica_space_gear", "pd6w", "hseue9", "group", ...]
+  private urlGroup(url: string): string | undefined {
+    return new URL(url).pathname.split("/").filter(Boolean)[3];
+  }
+
+  private inScope(url: string): boolean {
+    if (this.groups.size === 0) return true;
+    const group = this.urlGroup(url);
+    return group !== undefined && this.groups.has(group);
+  }
+
+  private async startGroupSync(): Promise<void> {
+    const indexUrl = this.getUrlWithFrame(this.config.baseUrl);
+    console.log(`Fetching index page: ${indexUrl}`);
+
+    const result = await this.fetcher.fetch(indexUrl);
+    if (!result.ok) {
+      throw new Error(`Failed to fetch index: ${result.error}`);
+    }
+
+    const categories = parseIndexPage(result.html!, indexUrl);
+    const unknown = [...this.groups].filter((id) => !categories.some((c) => c.id === id));
+    if (unknown.length > 0) {
+      throw new Error(
+        `Unknown group(s): ${unknown.join(", ")}\nAvailable: ${categories.map((c) => c.id).join(", ")}`,
+      );
+    }
+
+    for (const cat of categories.filter((c) => this.groups.has(c.id))) {
+      await insertGroup(this.client, {
+        id: cat.id,
+        name: cat.name,
+      });
+      const reset = await resetUrlsWithPrefix(this.client, this.groupUrl(cat.id));
+      console.log(`  ${cat.id}: re-checking ${reset} previously scraped pages`);
+
+      const catUrl = this.getUrlWithFrame(cat.url);
+      await markUrlPending(this.client, catUrl);
+      this.urlQueue.push(catUrl);
+    }
+  }
+
+  // Record the sync time of each group scraped this run, once all its
+  // pages have been scraped
+  private async recordSyncedGroups(): Promise<void> {
+    for (const groupId of this.scrapedGroups) {
+      const unfinished = await countUnfinishedUrlsWithPrefix(this.client, this.groupUrl(groupId));
+      if (unfinished > 0) {
+        console.log(`  ${groupId}: ${unfinished} pages failed or pending, not marked as synced`);
+        continue;
+      }
+      await markGroupSynced(this.client, groupId);
+    }
+  }
+
   private async scrapeIndex(): Promise<void> {
     const indexUrl = this.getUrlWithFrame(this.config.baseUrl);
     console.log(`Fetching index page: ${indexUrl}`);
@@ -142,6 +224,9 @@ export class Scraper {
 
       await this.processPage(url, result.html!);
       await markUrlCompleted(this.client, url);
+
+      const group = this.urlGroup(url);
+      if (group) this.scrapedGroups.add(group);
     }
   }
 
@@ -170,6 +255,7 @@ export class Scraper {
     let newLinks = 0;
 
     for (const link of links) {
+      if (!this.inScope(link)) continue;
       const linkWithFrame = this.getUrlWithFrame(link);
       const status = await getUrlStatus(this.client, linkWithFrame);
       if (!status) {
@@ -392,7 +478,12 @@ export class Scraper {
     }
   }
 
-  async downloadImages(): Promise<void> {
+  /**
+   * Download the images of diagrams that don't have one yet. A dry run
+   * lists them without fetching or writing anything; verbose adds each
+   * image's URL.
+   */
+  async downloadImages({ dryRun = false, verbose = false } = {}): Promise<void> {
     const diagrams = await getDiagramsWithoutImages(this.client);
 
     if (diagrams.length === 0) {
@@ -400,6 +491,20 @@ export class Scraper {
       return;
     }
 
+    if (dryRun) {
+      console.log(`\nWould download ${diagrams.length} images:`);
+      for (const diagram of diagrams) {
+        if (!diagram.image_url) continue;
+        const filename = this.imageFilename(diagram.id, diagram.image_url);
+        const exists = await Deno.stat(`${this.config.imagesDir}/${filename}`).then(() => true, () => false);
+        console.log(`  ${filename}${exists ? " (replacing the file there; a changed image is kept in previous/)" : ""}`);
+        if (verbose) {
+          console.log(`    from ${diagram.image_url}`);
+        }
+      }
+      return;
+    }
+
     console.log(`\nDownloading ${diagrams.length} images...`);
 
     // Ensure images directory exists
@@ -417,19 +522,23 @@ export class Scraper {
     for (const diagram of diagrams) {
       if (!diagram.image_url) continue;
 
-      // Clean up the diagram ID for filename
-      const safeId = diagram.id.replace(/[^a-zA-Z0-9_-]/g, "_").substring(0, 100);
-      const filename = `${safeId}.${this.getExtension(diagram.image_url)}`;
+      const filename = this.imageFilename(diagram.id, diagram.image_url);
       const filePath = `${this.config.imagesDir}/${filename}`;
       // Store path relative to data directory in database
       const dbPath = `images/${filename}`;
 
       console.log(`  Downloading: ${filename}`);
+      if (verbose) {
+        console.log(`    from ${diagram.image_url}`);
+      }
 
       const result = await this.fetcher.fetchImage(diagram.image_url);
 
       if (result.ok && result.data) {
         try {
+          if (await this.keepPreviousRevision(filename, result.data)) {
+            console.log(`    Image changed, kept previous revision`);
+          }
           await Deno.writeFile(filePath, result.data);
           await updateDiagramImagePath(this.client, diagram.id, dbPath);
           downloaded++;
@@ -446,6 +555,36 @@ export class Scraper {
     console.log(`\nImages downloaded: ${downloaded}, failed: ${failed}`);
   }
 
+  /**
+   * Move an existing image aside to images/previous/ when a re-scrape brings
+   * a different one, so the TUI can compare the two revisions. Only the most
+   * recent prior revision is kept.
+   */
+  private async keepPreviousRevision(filename: string, data: Uint8Array): Promise<boolean> {
+    const filePath = `${this.config.imagesDir}/${filename}`;
+    let existing: Uint8Array;
+    try {
+      existing = await Deno.readFile(filePath);
+    } catch (error) {
+      if (error instanceof Deno.errors.NotFound) return false;
+      throw error;
+    }
+    if (existing.length === data.length && existing.every((b, i) => b === data[i])) {
+      return false;
+    }
+
+    const previousDir = `${this.config.imagesDir}/previous`;
+    await Deno.mkdir(previousDir, { recursive: true });
+    await Deno.rename(filePath, `${previousDir}/${filename}`);
+    return true;
+  }
+
+  // The file a diagram's image is saved as, named after the cleaned-up ID
+  private imageFilename(diagramId: string, imageUrl: string): string {
+    const safeId = diagramId.replace(/[^a-zA-Z0-9_-]/g, "_").substring(0, 100);
+    return `${safeId}.${this.getExtension(imageUrl)}`;
+  }
+
   private getExtension(url: string): string {
     const match = url.match(/\.([a-z]+)(?:\?|$)/i);
     return match ? match[1].toLowerCase() : "png";
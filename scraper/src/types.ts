@@ -97,6 +97,9 @@ export interface ScraperConfig {
   minDelay: number;
   maxDelay: number;
   backoffMultiplier: number;
+  userAgent: string;
+  timeout: number;
+  retries: number;
   dataDir: string;
   imagesDir: string;
   dbPath: string;
@@ -113,14 +116,35 @@ if (!frameName || !trimCode || !frameNumber) {
   );
 }
 
+// HTTP settings shared with the TUI's netutil package. Proxies come from the
+// standard HTTPS_PROXY / HTTP_PROXY / NO_PROXY variables, which Deno honors.
+const DEFAULT_USER_AGENT =
+  "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36";
+
+function envNumber(name: string, fallback: number): number {
+  const value = Deno.env.get(name);
+  if (value === undefined || value === "") return fallback;
+  const n = Number(value);
+  if (!Number.isInteger(n) || n < 0) {
+    throw new Error(`${name} must be a non-negative whole number, got "${value}"`);
+  }
+  return n;
+}
+
+// The TUI's sync command points the scraper at its -data directory
+const dataDir = Deno.env.get("DELICA_DATA_DIR") || "../data";
+
 export const DEFAULT_CONFIG: ScraperConfig = {
   baseUrl: `https://mitsubishi.epc-data.com/delica_space_gear/${frameName}/${trimCode}/`,
   frameNumber: frameNumber,
   initialDelay: 3000,   // 3 seconds
-  minDelay: 1000,       // 1 second minimum
+  minDelay: envNumber("DELICA_HTTP_HOST_DELAY", 1000), // 1 second minimum
   maxDelay: 120000,     // 2 minutes max
   backoffMultiplier: 1.5, // Gentler backoff
-  dataDir: "../data",
-  imagesDir: "../data/images",
-  dbPath: "../data/delica.db",
+  userAgent: Deno.env.get("DELICA_HTTP_USER_AGENT") || DEFAULT_USER_AGENT,
+  timeout: envNumber("DELICA_HTTP_TIMEOUT", 30) * 1000,
+  retries: envNumber("DELICA_HTTP_RETRIES", 4),
+  dataDir,
+  imagesDir: `${dataDir}/images`,
+  dbPath: `${dataDir}/delica.db`,
 };
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/report"
+)
+
+// runAging reports the parts on hand by how long they've sat unused, for
+// deciding what to sell.
+func runAging(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("aging", flag.ExitOnError)
+	months := fs.Int("months", 12, "Flag parts unused for at least this many months")
+	format := fs.String("format", "md", "Output format: md or csv")
+	output := fs.String("o", "", "Write to file instead of stdout")
+	fs.Parse(args)
+
+	if *months < 0 {
+		return fmt.Errorf("-months must not be negative")
+	}
+
+	items, err := database.GetShelf()
+	if err != nil {
+		return fmt.Errorf("load parts on hand: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "md", "markdown":
+		return report.WriteAgingMarkdown(w, items, *months, time.Now())
+	case "csv":
+		return report.WriteAgingCSV(w, items, *months, time.Now())
+	default:
+		return fmt.Errorf("unknown format %q (want md or csv)", *format)
+	}
+}
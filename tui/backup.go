@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// defaultBackupKeep is how many backups are kept when DELICA_BACKUP_KEEP
+// isn't set.
+const defaultBackupKeep = 10
+
+func backupKeep() int {
+	if v := os.Getenv("DELICA_BACKUP_KEEP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return defaultBackupKeep
+}
+
+// runBackup copies user data (bookmarks, notes, overrides, pins, prices) to
+// a timestamped file in <data>/backups, verifies it, and prunes old backups.
+func runBackup(database *db.DB, dataPath string, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "", "Write the backup to FILE instead of <data>/backups")
+	keep := fs.Int("keep", backupKeep(), "Number of backups to keep in <data>/backups (0 keeps all)")
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	dir := db.BackupDir(filepath.Join(dataPath, "delica.db"))
+	path := *output
+	if path == "" {
+		path = filepath.Join(dir, db.BackupName(time.Now(), ""))
+	}
+
+	var rows map[string]int
+	if report.dryRun {
+		var err error
+		if rows, err = database.UserDataRows(); err != nil {
+			return fmt.Errorf("count user data: %w", err)
+		}
+	} else {
+		result, err := database.Backup(path)
+		if err != nil {
+			os.Remove(path)
+			return fmt.Errorf("backup: %w", err)
+		}
+		rows = result.Rows
+	}
+
+	total := 0
+	for _, table := range db.UserTables {
+		if n, ok := rows[table]; ok {
+			report.change("copy %d rows of %s", n, table)
+			total += n
+		}
+	}
+	fmt.Printf("%s %d rows from %d tables to %s\n", report.did("Backed up", "Would back up"), total, len(rows), path)
+
+	if *output == "" {
+		var pruned []string
+		var err error
+		switch {
+		case !report.dryRun:
+			pruned, err = db.PruneBackups(dir, *keep)
+		case *keep > 0:
+			// The backup not taken would be the newest, leaving room
+			// for one fewer of these
+			var existing []string
+			existing, err = db.ListBackups(dir)
+			if len(existing) >= *keep {
+				pruned = existing[*keep-1:]
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("prune backups: %w", err)
+		}
+		for _, p := range pruned {
+			report.change("remove %s", filepath.Base(p))
+		}
+		if len(pruned) > 0 {
+			fmt.Printf("%s %d old backups\n", report.did("Removed", "Would remove"), len(pruned))
+		}
+	}
+	report.finish()
+	return nil
+}
+
+// runRestore replaces user data with a backup, by default the newest one in
+// <data>/backups. The current data is backed up first.
+func runRestore(database *db.DB, dataPath string, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	dir := db.BackupDir(filepath.Join(dataPath, "delica.db"))
+
+	var path string
+	switch fs.NArg() {
+	case 0:
+		backups, err := db.ListBackups(dir)
+		if err != nil {
+			return fmt.Errorf("list backups: %w", err)
+		}
+		if len(backups) == 0 {
+			return fmt.Errorf("no backups in %s", dir)
+		}
+		path = backups[0]
+	case 1:
+		path = fs.Arg(0)
+	default:
+		return fmt.Errorf("usage: delica-tui restore [-dry-run] [-verbose] [FILE]")
+	}
+
+	tables, err := database.CompareBackup(path)
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	for _, t := range tables {
+		if t.InBackup {
+			report.change("replace %d rows of %s with %d", t.Current, t.Table, t.Backup)
+		} else {
+			report.skip("keep %d rows of %s, not in the backup", t.Current, t.Table)
+		}
+	}
+	if report.dryRun {
+		fmt.Printf("Would restore user data from %s, backing up the current data first\n", path)
+		report.finish()
+		return nil
+	}
+
+	safety := filepath.Join(dir, db.BackupName(time.Now(), "pre-restore"))
+	if _, err := database.Backup(safety); err != nil {
+		os.Remove(safety)
+		return fmt.Errorf("back up current data: %w", err)
+	}
+
+	if err := database.Restore(path); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	fmt.Printf("Restored user data from %s\n", path)
+	fmt.Printf("Previous data saved to %s\n", safety)
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package barcode encodes part numbers as Code 128 barcodes and draws them
+// with block characters, so they can be scanned off the screen.
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// patterns holds the bar and space widths of each Code 128 symbol, in
+// modules, starting with a bar. 103-105 are the start codes.
+var patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232",
+}
+
+const (
+	codeC  = 99
+	codeB  = 100
+	startB = 104
+	startC = 105
+	stop   = "2331112"
+
+	// QuietZone is the blank margin, in modules, scanners need either side
+	QuietZone = 10
+)
+
+// Code128 encodes s, which must be printable ASCII, returning one bool per
+// module (true for a bar) including the quiet zones. Runs of four or more
+// digits use code set C, which packs two digits per symbol, so typical part
+// numbers stay narrow.
+func Code128(s string) ([]bool, error) {
+	if s == "" {
+		return nil, fmt.Errorf("nothing to encode")
+	}
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			return nil, fmt.Errorf("can't encode %q in a barcode", r)
+		}
+	}
+
+	var symbols []int
+	set := 0
+	for i := 0; i < len(s); {
+		if n := digitRun(s[i:]); n >= 4 || (n >= 2 && n == len(s)-i && n%2 == 0) {
+			n -= n % 2
+			switch set {
+			case 0:
+				symbols = append(symbols, startC)
+			case codeB:
+				symbols = append(symbols, codeC)
+			}
+			set = codeC
+			for end := i + n; i < end; i += 2 {
+				symbols = append(symbols, int(s[i]-'0')*10+int(s[i+1]-'0'))
+			}
+			continue
+		}
+		switch set {
+		case 0:
+			symbols = append(symbols, startB)
+		case codeC:
+			symbols = append(symbols, codeB)
+		}
+		set = codeB
+		symbols = append(symbols, int(s[i])-' ')
+		i++
+	}
+
+	// Check symbol: start value plus each symbol weighted by position
+	sum := symbols[0]
+	for i, v := range symbols[1:] {
+		sum += v * (i + 1)
+	}
+	symbols = append(symbols, sum%103)
+
+	modules := make([]bool, QuietZone)
+	for _, v := range symbols {
+		modules = appendPattern(modules, patterns[v])
+	}
+	modules = appendPattern(modules, stop)
+	return append(modules, make([]bool, QuietZone)...), nil
+}
+
+func digitRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// appendPattern appends the modules for alternating bar and space widths.
+func appendPattern(modules []bool, widths string) []bool {
+	bar := true
+	for _, w := range widths {
+		for i := 0; i < int(w-'0'); i++ {
+			modules = append(modules, bar)
+		}
+		bar = !bar
+	}
+	return modules
+}
+
+// Blocks draws modules as height identical lines, two modules per cell using
+// half blocks. Bars are drawn in the foreground color, so render the lines
+// dark on light for scanners.
+func Blocks(modules []bool, height int) []string {
+	var b strings.Builder
+	for i := 0; i < len(modules); i += 2 {
+		left := modules[i]
+		right := i+1 < len(modules) && modules[i+1]
+		switch {
+		case left && right:
+			b.WriteString("█")
+		case left:
+			b.WriteString("▌")
+		case right:
+			b.WriteString("▐")
+		default:
+			b.WriteString(" ")
+		}
+	}
+	line := b.String()
+	lines := make([]string, height)
+	for i := range lines {
+		lines[i] = line
+	}
+	return lines
+}
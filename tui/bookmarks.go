@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// runImportBookmarks bookmarks the catalog parts named in a list of part
+// numbers, one per line, read from FILE or standard input so a list can be
+// pasted. A purchase date after the number, optionally followed by the cost
+// and its currency, records the purchase too. Other extra columns are
+// ignored, as are lines whose first field has no digits, like headers and
+// comments.
+func runImportBookmarks(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("import-bookmarks", flag.ExitOnError)
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: delica-tui import-bookmarks [-dry-run] [-verbose] [FILE]")
+	}
+
+	var in io.Reader = os.Stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("open list: %w", err)
+		}
+		defer f.Close()
+		in = f
+	} else if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Paste part numbers, one per line, then press Ctrl+D")
+	}
+
+	var added, already, purchases int
+	var missing []string
+	seen := make(map[int]bool)
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		number := listedPartNumber(scanner.Text())
+		if number == "" {
+			continue
+		}
+		purchase, purchased, err := listedPurchase(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		lookup, err := database.LookupPartNumber(number)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if !lookup.Found {
+			missing = append(missing, fmt.Sprintf("line %d: %s, %s", line, number, lookup.Hint()))
+			report.skip("line %d: %s %s", line, number, lookup.Hint())
+			continue
+		}
+		match := lookup.Match
+		if seen[match.PartID] {
+			report.skip("line %d: %s listed already", line, match.PartNumber)
+			continue
+		}
+		seen[match.PartID] = true
+
+		if purchased {
+			report.change("line %d: record purchase of %s", line, match.PartNumber)
+			if !report.dryRun {
+				purchase.PartID = match.PartID
+				if err := database.SetPurchase(purchase); err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+			purchases++
+		}
+
+		bookmarked, err := database.IsBookmarked(match.PartID)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if bookmarked {
+			report.skip("line %d: %s bookmarked already", line, match.PartNumber)
+			already++
+			continue
+		}
+
+		desc := ""
+		if match.Description != nil {
+			desc = " " + *match.Description
+		}
+		switch {
+		case match.ViaReplacement:
+			desc += fmt.Sprintf(" (listed as %s, which %s replaces)", match.PartNumber, strings.ToUpper(number))
+		case match.Listings > 1:
+			desc += fmt.Sprintf(" (first of %d listings)", match.Listings)
+		}
+		fmt.Printf("  %s%s\n", match.PartNumber, desc)
+
+		if !report.dryRun {
+			if err := database.AddBookmark(match.PartID); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read list: %w", err)
+	}
+
+	fmt.Printf("%s %d parts, %d already bookmarked\n", report.did("Bookmarked", "Would bookmark"), added, already)
+	if purchases > 0 {
+		fmt.Printf("%s %d purchases\n", report.did("Recorded", "Would record"), purchases)
+	}
+	if len(missing) > 0 {
+		fmt.Printf("\n%d not found:\n", len(missing))
+		for _, m := range missing {
+			fmt.Printf("  %s\n", m)
+		}
+	}
+	report.finish()
+	return nil
+}
+
+// listedFields splits a list line on commas, semicolons and whitespace
+func listedFields(line string) []string {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	})
+	for i, f := range fields {
+		fields[i] = strings.Trim(f, `"'`)
+	}
+	return fields
+}
+
+// listedPurchase reads the purchase date, cost and currency following the
+// part number on a list line. ok is false when the line has no date.
+func listedPurchase(line string) (p db.Purchase, ok bool, err error) {
+	fields := listedFields(line)
+	if len(fields) < 2 {
+		return p, false, nil
+	}
+	return db.ParsePurchase(fields[1:])
+}
+
+// listedPartNumber returns the first field of a list line if it looks like
+// a part number
+func listedPartNumber(line string) string {
+	fields := listedFields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return ""
+	}
+	number := fields[0]
+	if !strings.ContainsFunc(number, unicode.IsDigit) {
+		return ""
+	}
+	return number
+}
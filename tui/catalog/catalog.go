@@ -0,0 +1,121 @@
+// Package catalog gives other Go programs read-only access to a scraped
+// parts database, the same one the TUI browses. It has no terminal UI
+// dependencies.
+//
+//	cat, err := catalog.Open("data/delica.db")
+//	if err != nil {
+//		return err
+//	}
+//	defer cat.Close()
+//
+//	results, err := cat.Search(ctx, "timing belt -cover")
+//
+// Every method takes a context; cancelling it interrupts the query. A
+// Catalog is safe for concurrent use, though queries run one at a time.
+package catalog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+type (
+	Group             = db.Group
+	Subgroup          = db.Subgroup
+	SubgroupWithGroup = db.SubgroupWithGroup
+	Diagram           = db.Diagram
+	Part              = db.PartWithDiagram
+	SearchResult      = db.SearchResult
+	Price             = db.Price
+)
+
+// Catalog is an open parts database.
+type Catalog struct {
+	mu sync.Mutex
+	db *db.DB
+}
+
+// Open opens the database at path read-only. Nothing is created or
+// migrated, so it's safe to use while the TUI or scraper has it open.
+func Open(path string) (*Catalog, error) {
+	d, err := db.OpenReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Catalog{db: d}, nil
+}
+
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
+// Groups returns the top-level categories, e.g. Engine or Transmission.
+func (c *Catalog) Groups(ctx context.Context) ([]Group, error) {
+	return query(c, ctx, c.db.GetGroups)
+}
+
+// Group returns a group by ID, or nil if there's none.
+func (c *Catalog) Group(ctx context.Context, id string) (*Group, error) {
+	return query(c, ctx, func() (*Group, error) { return c.db.GetGroup(id) })
+}
+
+// Subgroups returns a group's subgroups.
+func (c *Catalog) Subgroups(ctx context.Context, groupID string) ([]Subgroup, error) {
+	return query(c, ctx, func() ([]Subgroup, error) { return c.db.GetSubgroups(groupID) })
+}
+
+// Parts returns the parts on a subgroup's diagrams.
+func (c *Catalog) Parts(ctx context.Context, subgroupID string) ([]Part, error) {
+	return query(c, ctx, func() ([]Part, error) { return c.db.GetPartsForSubgroup(subgroupID) })
+}
+
+// Part returns a part by ID, or nil if there's none.
+func (c *Catalog) Part(ctx context.Context, id int) (*Part, error) {
+	return query(c, ctx, func() (*Part, error) { return c.db.GetPart(id) })
+}
+
+// Diagram returns a diagram by ID, or nil if there's none. ImagePath is
+// relative to the data directory.
+func (c *Catalog) Diagram(ctx context.Context, id string) (*Diagram, error) {
+	return query(c, ctx, func() (*Diagram, error) { return c.db.GetDiagram(id) })
+}
+
+// Search runs a full-text search with the TUI's syntax: words are ANDed,
+// OR gives alternatives, -word excludes and "quotes" match phrases.
+func (c *Catalog) Search(ctx context.Context, q string) ([]SearchResult, error) {
+	return query(c, ctx, func() ([]SearchResult, error) { return c.db.SearchParts(q) })
+}
+
+// SubgroupsForPartNumber returns every subgroup a part number appears in.
+func (c *Catalog) SubgroupsForPartNumber(ctx context.Context, partNumber string) ([]SubgroupWithGroup, error) {
+	return query(c, ctx, func() ([]SubgroupWithGroup, error) { return c.db.GetSubgroupsForPartNumber(partNumber) })
+}
+
+// Prices returns imported supplier prices for the given part numbers.
+func (c *Catalog) Prices(ctx context.Context, partNumbers ...string) ([]Price, error) {
+	return query(c, ctx, func() ([]Price, error) { return c.db.GetPrices(partNumbers...) })
+}
+
+// query runs fn with ctx able to interrupt it
+func query[T any](c *Catalog, ctx context.Context, fn func() (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	c.db.SetInterrupt(ctx.Done())
+	defer c.db.SetInterrupt(nil)
+
+	v, err := fn()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return zero, ctxErr
+		}
+		return zero, err
+	}
+	return v, nil
+}
@@ -0,0 +1,84 @@
+package catalog_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/catalog"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+// scraped saves a one-part catalog with only the scraper's tables, as a
+// fresh scrape leaves it before the TUI has opened it, and opens it
+func scraped(t *testing.T, setup ...string) *catalog.Catalog {
+	t.Helper()
+	c := dbtest.New(t)
+	c.Group("engine", "Engine")
+	c.Subgroup("eng1", "engine", "Timing Belt")
+	c.Diagram("d1", "eng1", "Timing belt", "images/d1.png")
+	c.Part(dbtest.Part{Number: "MD050125", Description: "BELT,TIMING", Qty: 1, Diagram: "d1"})
+	for _, query := range setup {
+		c.Exec(query)
+	}
+	path := filepath.Join(t.TempDir(), "delica.db")
+	c.Save(path)
+
+	cat, err := catalog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cat.Close() })
+	return cat
+}
+
+func TestScrapedCatalog(t *testing.T) {
+	cat := scraped(t)
+	ctx := context.Background()
+
+	groups, err := cat.Groups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].ID != "engine" {
+		t.Errorf("groups = %+v, want engine", groups)
+	}
+	part, err := cat.Part(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part == nil || part.PartNumber != "MD050125" {
+		t.Errorf("part 1 = %+v, want MD050125", part)
+	}
+	prices, err := cat.Prices(ctx, "MD050125")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 0 {
+		t.Errorf("prices = %+v, want none before any are imported", prices)
+	}
+}
+
+func TestCatalogOlderPrices(t *testing.T) {
+	// Prices as kept before availability and weights were
+	cat := scraped(t, `CREATE TABLE prices (
+		supplier_id TEXT NOT NULL,
+		part_number TEXT NOT NULL,
+		price REAL NOT NULL,
+		previous_price REAL,
+		currency TEXT NOT NULL DEFAULT 'USD',
+		stock INTEGER,
+		lead_time_days INTEGER,
+		url TEXT,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (supplier_id, part_number)
+	)`, "INSERT INTO prices (supplier_id, part_number, price) VALUES ('amayama', 'MD050125', 42.5)")
+
+	prices, err := cat.Prices(context.Background(), "MD050125")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Price != 42.5 || prices[0].Availability != nil || prices[0].WeightGrams != nil {
+		t.Errorf("prices = %+v, want the one recorded, without availability or weight", prices)
+	}
+}
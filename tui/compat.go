@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// compatBundleVersion is written to exported bundles and checked on import
+const compatBundleVersion = 1
+
+// compatBundle is the JSON form compatibility notes are shared in. An
+// entry without a contributor is attributed to the bundle's.
+type compatBundle struct {
+	Version     int                `json:"version"`
+	Contributor string             `json:"contributor,omitempty"`
+	Entries     []compatBundleItem `json:"entries"`
+}
+
+type compatBundleItem struct {
+	PartNumber  string `json:"part_number"`
+	Brand       string `json:"brand,omitempty"`
+	Xref        string `json:"xref,omitempty"`
+	Note        string `json:"note,omitempty"`
+	Contributor string `json:"contributor,omitempty"`
+	Source      string `json:"source,omitempty"`
+}
+
+// runImportCompat merges the entries of a JSON bundle into the
+// compatibility notes, updating those it already has from the same
+// contributor and keeping other contributors' alongside.
+func runImportCompat(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("import-compat", flag.ExitOnError)
+	by := fs.String("by", "", "Attribute entries to NAME when neither they nor the bundle name a contributor")
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: delica-tui import-compat [-by NAME] [-dry-run] [-verbose] FILE.json")
+	}
+
+	var in io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("open bundle: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var bundle compatBundle
+	if err := json.NewDecoder(in).Decode(&bundle); err != nil {
+		return fmt.Errorf("read bundle: %w", err)
+	}
+	if bundle.Version > compatBundleVersion {
+		return fmt.Errorf("bundle version %d is newer than this program supports (%d)", bundle.Version, compatBundleVersion)
+	}
+
+	contributor := bundle.Contributor
+	if contributor == "" {
+		contributor = *by
+	}
+	notes := make([]db.CompatNote, len(bundle.Entries))
+	for i, item := range bundle.Entries {
+		notes[i] = db.CompatNote{PartNumber: item.PartNumber, Brand: item.Brand, Xref: item.Xref, Note: item.Note, Contributor: item.Contributor, Source: item.Source}
+		if notes[i].Contributor == "" {
+			notes[i].Contributor = contributor
+		}
+	}
+	result, err := database.ImportCompatNotes(notes, report.dryRun)
+	if err != nil {
+		return err
+	}
+
+	for i, outcome := range result.Outcomes {
+		reportImport(report, outcome, compatNoteLabel(notes[i]))
+	}
+	fmt.Printf("Compatibility notes: %d %s, %d %s, %d unchanged\n",
+		result.Added, report.did("added", "to add"), result.Updated, report.did("updated", "to update"), result.Unchanged)
+	if result.Unmatched > 0 {
+		fmt.Printf("For a part number this catalog doesn't have: %d\n", result.Unmatched)
+	}
+	report.finish()
+	return nil
+}
+
+// compatNoteLabel names a note by part number, xref and contributor
+func compatNoteLabel(n db.CompatNote) string {
+	label := db.NormalizePartNumber(n.PartNumber)
+	if n.Xref != "" {
+		label += " → " + n.Brand + " " + strings.ToUpper(n.Xref)
+	} else {
+		label += " fitment note"
+	}
+	if n.Contributor != "" {
+		label += " from " + n.Contributor
+	}
+	return label
+}
+
+// runExportCompat writes the compatibility notes as a JSON bundle for
+// sharing, each entry carrying its contributor.
+func runExportCompat(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("export-compat", flag.ExitOnError)
+	output := fs.String("o", "", "Write to file instead of stdout")
+	by := fs.String("by", "", "Only export entries contributed by NAME")
+	fs.Parse(args)
+
+	notes, err := database.GetCompatNotes(*by)
+	if err != nil {
+		return fmt.Errorf("load compatibility notes: %w", err)
+	}
+
+	bundle := compatBundle{Version: compatBundleVersion, Entries: make([]compatBundleItem, len(notes))}
+	for i, n := range notes {
+		bundle.Entries[i] = compatBundleItem{PartNumber: n.PartNumber, Brand: n.Brand, Xref: n.Xref, Note: n.Note, Contributor: n.Contributor, Source: n.Source}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// hasAddedAt reports whether the parts table records when parts were added,
+// which databases scraped by older versions don't
+func (d *DB) hasAddedAt() (bool, error) {
+	var has bool
+	err := d.execute("SELECT 1 FROM pragma_table_info('parts') WHERE name = 'added_at'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			has = true
+			return nil
+		},
+	})
+	return has, err
+}
+
+// GetAddedParts returns the parts syncs have added to the catalog since a
+// "YYYY-MM-DD HH:MM:SS" UTC time, by group, subgroup and part number. The
+// scraper stamps only parts it finds in a group it had already scraped in
+// full, so a first scrape adds none.
+func (d *DB) GetAddedParts(since string) ([]AddedPart, error) {
+	has, err := d.hasAddedAt()
+	if err != nil || !has {
+		return nil, err
+	}
+
+	var parts []AddedPart
+	err = d.execute(`
+		SELECT p.id, p.part_number, p.pnc, p.description, g.name,
+			   COALESCE(s.name, di.name, ''), added.added_at
+		FROM parts added
+		JOIN parts_effective p ON p.id = added.id
+		JOIN groups g ON p.group_id = g.id
+		LEFT JOIN subgroups s ON p.subgroup_id = s.id
+		LEFT JOIN diagrams di ON p.diagram_id = di.id
+		WHERE added.added_at >= ?
+		ORDER BY g.name, COALESCE(s.name, di.name), p.part_number
+	`, &sqlitex.ExecOptions{
+		Args: []any{since},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			parts = append(parts, AddedPart{
+				PartID:       stmt.ColumnInt(0),
+				PartNumber:   stmt.ColumnText(1),
+				PNC:          nullableString(stmt, 2),
+				Description:  nullableString(stmt, 3),
+				GroupName:    stmt.ColumnText(4),
+				SubgroupName: stmt.ColumnText(5),
+				AddedAt:      stmt.ColumnText(6),
+			})
+			return nil
+		},
+	})
+	return parts, err
+}
+
+// CountAddedParts returns how many parts syncs have added since a
+// "YYYY-MM-DD HH:MM:SS" UTC time.
+func (d *DB) CountAddedParts(since string) (int, error) {
+	has, err := d.hasAddedAt()
+	if err != nil || !has {
+		return 0, err
+	}
+	var count int
+	err = d.execute("SELECT COUNT(*) FROM parts WHERE added_at >= ?", &sqlitex.ExecOptions{
+		Args: []any{since},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			count = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	return count, err
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Aggregates are each subgroup's part and diagram counts and the span of
+// its parts' date ranges, worked out ahead so list screens don't group the
+// parts table every time they're built, which is slow on hardware like a Pi
+// Zero. Parts without a subgroup are counted under their group with
+// an empty subgroup_id. Like part_attributes they're derived from the catalog:
+// each row keeps the catalog fingerprint it was computed from, and they're
+// computed again when it differs, as the database is opened or another
+// program writes to it.
+const createAggregatesTable = `
+	CREATE TABLE IF NOT EXISTS subgroup_aggregates (
+		group_id TEXT NOT NULL,
+		subgroup_id TEXT NOT NULL,
+		parts INTEGER NOT NULL,
+		diagrams INTEGER NOT NULL,
+		with_image INTEGER NOT NULL,
+		earliest TEXT NOT NULL,
+		latest TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		PRIMARY KEY (group_id, subgroup_id)
+	)
+`
+
+// Aggregate is what's known of a subgroup's contents without reading them.
+type Aggregate struct {
+	Parts     int
+	Diagrams  int
+	WithImage int    // diagrams whose image has been downloaded
+	Earliest  string // start of the earliest date range, YYYY.MM, or ""
+	Latest    string // end of the latest date range, YYYY.MM, or ""
+}
+
+// DateSpan describes the dates the subgroup's parts cover, e.g.
+// "1994.03-2007.01", or "" when none of them has a valid date range.
+func (a Aggregate) DateSpan() string {
+	if a.Earliest == "" {
+		return ""
+	}
+	return a.Earliest + "-" + a.Latest
+}
+
+// catalogFingerprint sums up the catalog cheaply enough to check at every
+// open: the number of parts, the highest part ID, and the number of
+// diagrams, of those with an image, and of subgroups
+func catalogFingerprint(conn *sqlite.Conn) (string, error) {
+	var fingerprint string
+	err := sqlitex.ExecuteTransient(conn, `
+		SELECT (SELECT COUNT(*) || ':' || COALESCE(MAX(id), 0) FROM parts)
+			|| '/' || (SELECT COUNT(*) || ':' || COUNT(image_path) FROM diagrams)
+			|| '/' || (SELECT COUNT(*) FROM subgroups)
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			fingerprint = stmt.ColumnText(0)
+			return nil
+		},
+	})
+	return fingerprint, err
+}
+
+// dateRangeMonths reads a model date range as ValidDateRange accepts it
+// into its first and last months, as YYYY.MM
+func dateRangeMonths(r string) (start, end string, ok bool) {
+	if !ValidDateRange(r) {
+		return "", "", false
+	}
+	m := dateRangePattern.FindStringSubmatch(strings.TrimSpace(r))
+	month := func(year, mon string) string {
+		n, _ := strconv.Atoi(mon)
+		return fmt.Sprintf("%s.%02d", year, n)
+	}
+	return month(m[1], m[2]), month(m[3], m[4]), true
+}
+
+// RefreshAggregates computes the subgroup aggregates again whatever the
+// catalog fingerprint says, for after a sync, which can change date ranges
+// without changing it.
+func (d *DB) RefreshAggregates() error {
+	d.mu.Lock()
+	err := aggregate(d.conn, true)
+	d.mu.Unlock()
+	d.Refresh()
+	return err
+}
+
+// refreshAggregates computes the aggregates again unless they're from a
+// catalog with the same fingerprint
+func refreshAggregates(conn *sqlite.Conn) error {
+	return aggregate(conn, false)
+}
+
+// aggregate computes the aggregates, unless they're current and force is
+// false
+func aggregate(conn *sqlite.Conn, force bool) (err error) {
+	fingerprint, err := catalogFingerprint(conn)
+	if err != nil {
+		return err
+	}
+	var current bool
+	if err = sqlitex.ExecuteTransient(conn, "SELECT 1 FROM subgroup_aggregates WHERE fingerprint = ? LIMIT 1", &sqlitex.ExecOptions{
+		Args: []any{fingerprint},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			current = true
+			return nil
+		},
+	}); err != nil || (current && !force) {
+		return err
+	}
+
+	defer sqlitex.Save(conn)(&err)
+
+	type key struct{ group, subgroup string }
+	aggregates := make(map[key]*Aggregate)
+	at := func(group, subgroup string) *Aggregate {
+		k := key{group, subgroup}
+		if aggregates[k] == nil {
+			aggregates[k] = &Aggregate{}
+		}
+		return aggregates[k]
+	}
+
+	// Every subgroup has a row, even one without parts
+	if err = sqlitex.ExecuteTransient(conn, "SELECT group_id, id FROM subgroups", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			at(stmt.ColumnText(0), stmt.ColumnText(1))
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+	if err = sqlitex.ExecuteTransient(conn, `
+		SELECT group_id, COALESCE(subgroup_id, ''), COUNT(*), COUNT(image_path)
+		FROM diagrams GROUP BY 1, 2
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			a := at(stmt.ColumnText(0), stmt.ColumnText(1))
+			a.Diagrams, a.WithImage = stmt.ColumnInt(2), stmt.ColumnInt(3)
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+	// Date ranges are read here rather than in SQL, as ValidDateRange reads
+	// them, so each distinct range is fetched once with its count
+	if err = sqlitex.ExecuteTransient(conn, `
+		SELECT group_id, COALESCE(subgroup_id, ''), COALESCE(model_date_range, ''), COUNT(*)
+		FROM parts GROUP BY 1, 2, 3
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			a := at(stmt.ColumnText(0), stmt.ColumnText(1))
+			a.Parts += stmt.ColumnInt(3)
+			if start, end, ok := dateRangeMonths(stmt.ColumnText(2)); ok {
+				if a.Earliest == "" || start < a.Earliest {
+					a.Earliest = start
+				}
+				if end > a.Latest {
+					a.Latest = end
+				}
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err = sqlitex.ExecuteTransient(conn, "DELETE FROM subgroup_aggregates", nil); err != nil {
+		return err
+	}
+	for k, a := range aggregates {
+		if err = sqlitex.Execute(conn, `
+			INSERT INTO subgroup_aggregates (group_id, subgroup_id, parts, diagrams, with_image, earliest, latest, fingerprint)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, &sqlitex.ExecOptions{
+			Args: []any{k.group, k.subgroup, a.Parts, a.Diagrams, a.WithImage, a.Earliest, a.Latest, fingerprint},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Attachments are external files, such as invoices or photos, listed under
+// a part's note. Only the path is stored; the files stay where they are.
+const createAttachmentsTable = `
+	CREATE TABLE IF NOT EXISTS note_attachments (
+		part_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (part_id, path)
+	)
+`
+
+// GetAttachments returns a part's attached file paths in the order they
+// were added.
+func (d *DB) GetAttachments(partID int) ([]string, error) {
+	var paths []string
+	err := d.execute("SELECT path FROM note_attachments WHERE part_id = ? ORDER BY created_at, rowid", &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			paths = append(paths, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	return paths, err
+}
+
+func (d *DB) AddAttachment(partID int, path string) error {
+	return d.executeTransient("INSERT OR IGNORE INTO note_attachments (part_id, path) VALUES (?, ?)", &sqlitex.ExecOptions{
+		Args: []any{partID, path},
+	})
+}
+
+func (d *DB) RemoveAttachment(partID int, path string) error {
+	return d.executeTransient("DELETE FROM note_attachments WHERE part_id = ? AND path = ?", &sqlitex.ExecOptions{
+		Args: []any{partID, path},
+	})
+}
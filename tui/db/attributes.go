@@ -0,0 +1,313 @@
+package db
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Attributes are a part's spec string, "MT,4WD,HIGH ROOF", read into
+// normalized name and value pairs so search can filter on them and vehicle
+// matching compares values rather than substrings. They're derived from the
+// catalog: each row keeps the spec it was parsed from, and parts whose spec
+// has changed are parsed again when the database is opened or another
+// program writes to it.
+const createAttributesTable = `
+	CREATE TABLE IF NOT EXISTS part_attributes (
+		part_id INTEGER NOT NULL,
+		spec TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (part_id, name, value)
+	)
+`
+
+// Attribute names. Spec items the parser doesn't recognize are kept as
+// AttrOther, which filters and vehicle matching ignore.
+const (
+	AttrDrive        = "drive"     // 2WD, 4WD
+	AttrTransmission = "trans"     // MT, AT
+	AttrRoof         = "roof"      // HIGH ROOF, STANDARD ROOF
+	AttrWheelbase    = "wheelbase" // SWB, LWB
+	AttrSteering     = "steering"  // RHD, LHD
+	AttrFuel         = "fuel"      // DIESEL, PETROL
+	AttrEngine       = "engine"    // an engine code such as 4M40
+	AttrGrade        = "grade"     // CHAMONIX, EXCEED, ...
+	AttrOther        = "other"
+)
+
+// attributeAliases maps each name's spellings in the catalog to the
+// normalized value
+var attributeAliases = map[string]map[string]string{
+	AttrDrive: {
+		"2WD": "2WD", "4X2": "2WD",
+		"4WD": "4WD", "4X4": "4WD",
+	},
+	AttrTransmission: {
+		"MANUAL": "MT", "AUTO": "AT", "AUTOMATIC": "AT",
+	},
+	AttrRoof: {
+		"HIGH": "HIGH ROOF", "HIGH ROOF": "HIGH ROOF", "HIGH-ROOF": "HIGH ROOF", "HIGHROOF": "HIGH ROOF", "H/ROOF": "HIGH ROOF",
+		"STANDARD": "STANDARD ROOF", "STANDARD ROOF": "STANDARD ROOF", "STD": "STANDARD ROOF", "STD ROOF": "STANDARD ROOF", "STD-ROOF": "STANDARD ROOF", "S/ROOF": "STANDARD ROOF",
+	},
+	AttrWheelbase: {
+		"SWB": "SWB", "SHORT": "SWB", "SHORT WHEELBASE": "SWB",
+		"LWB": "LWB", "LONG": "LWB", "LONG WHEELBASE": "LWB",
+	},
+	AttrSteering: {
+		"RHD": "RHD", "RH DRIVE": "RHD", "RIGHT HAND DRIVE": "RHD",
+		"LHD": "LHD", "LH DRIVE": "LHD", "LEFT HAND DRIVE": "LHD",
+	},
+	AttrFuel: {
+		"DIESEL": "DIESEL", "TD": "DIESEL", "TURBO DIESEL": "DIESEL", "DSL": "DIESEL",
+		"PETROL": "PETROL", "GASOLINE": "PETROL", "GSL": "PETROL",
+	},
+	AttrGrade: {
+		"CHAMONIX": "CHAMONIX", "EXCEED": "EXCEED", "SUPER EXCEED": "SUPER EXCEED",
+		"XR": "XR", "GL": "GL", "GLX": "GLX",
+	},
+}
+
+// attributeNames maps what a search filter can call an attribute to its name
+var attributeNames = map[string]string{
+	"drive":        AttrDrive,
+	"trans":        AttrTransmission,
+	"transmission": AttrTransmission,
+	"roof":         AttrRoof,
+	"wheelbase":    AttrWheelbase,
+	"wb":           AttrWheelbase,
+	"steering":     AttrSteering,
+	"fuel":         AttrFuel,
+	"engine":       AttrEngine,
+	"grade":        AttrGrade,
+}
+
+var (
+	// A transmission, MT, M/T, 5MT, 4A/T or 4CA/T
+	transmissionPattern = regexp.MustCompile(`^\d?C?([MA])/?T$`)
+	// A Mitsubishi engine code, 4M40, 6G74 or 4D56
+	enginePattern = regexp.MustCompile(`^[346][A-Z]\d{2}[A-Z]?$`)
+	// Words that turn a spec item around, "EXC. 4WD", which isn't an
+	// attribute of the part
+	negations = []string{"EXC", "EXC.", "EXCEPT", "W/O", "WITHOUT", "NOT"}
+)
+
+// Attribute is one normalized item of a part's spec.
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+func (a Attribute) String() string {
+	return a.Value
+}
+
+// lookupAttribute reads a normalized phrase as an attribute, within name
+// if it's set. ok is false when it isn't one.
+func lookupAttribute(phrase, name string) (Attribute, bool) {
+	if name == "" || name == AttrTransmission {
+		if m := transmissionPattern.FindStringSubmatch(phrase); m != nil {
+			return Attribute{AttrTransmission, m[1] + "T"}, true
+		}
+	}
+	if (name == "" || name == AttrEngine) && enginePattern.MatchString(phrase) {
+		return Attribute{AttrEngine, phrase}, true
+	}
+	for n, aliases := range attributeAliases {
+		if name != "" && n != name {
+			continue
+		}
+		if value, ok := aliases[phrase]; ok {
+			return Attribute{n, value}, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// normalizeSpecItem upper-cases an item and collapses its spaces
+func normalizeSpecItem(s string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+}
+
+// ParseSpec reads a spec string into attributes, in order and without
+// repeats. Items are separated by commas, semicolons or parentheses; an item
+// that isn't an attribute as a whole is read a word or phrase at a time, so
+// "HSEUE9 Chamonix (HIGH-ROOF), 4CA/T" gives grade, roof and transmission.
+// Items starting with EXC. or W/O, and words that match nothing, are kept
+// as AttrOther.
+func ParseSpec(spec string) []Attribute {
+	var attrs []Attribute
+	add := func(a Attribute) {
+		if !slices.Contains(attrs, a) {
+			attrs = append(attrs, a)
+		}
+	}
+
+	items := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ';' || r == '(' || r == ')'
+	})
+	for _, item := range items {
+		item = normalizeSpecItem(item)
+		if item == "" {
+			continue
+		}
+		words := strings.Fields(item)
+		if slices.Contains(negations, words[0]) {
+			add(Attribute{AttrOther, item})
+			continue
+		}
+		if a, ok := lookupAttribute(item, ""); ok {
+			add(a)
+			continue
+		}
+		// The longest phrase of up to three words matching at each word
+		for i := 0; i < len(words); {
+			n := min(3, len(words)-i)
+			for ; n > 0; n-- {
+				if a, ok := lookupAttribute(strings.Join(words[i:i+n], " "), ""); ok {
+					add(a)
+					break
+				}
+			}
+			if n == 0 {
+				add(Attribute{AttrOther, words[i]})
+				n = 1
+			}
+			i += n
+		}
+	}
+	return attrs
+}
+
+// Conflicts returns the vehicle attributes a part's attributes rule out:
+// for each name the part gives, other than AttrOther, the vehicle's values
+// when none of them is one of the part's. A part that doesn't give a name
+// fits every vehicle, and so does a name the vehicle doesn't give.
+func Conflicts(part, vehicle []Attribute) []Attribute {
+	partValues := make(map[string][]string)
+	for _, a := range part {
+		if a.Name != AttrOther {
+			partValues[a.Name] = append(partValues[a.Name], a.Value)
+		}
+	}
+	var conflicts []Attribute
+	for _, v := range vehicle {
+		values, ok := partValues[v.Name]
+		if ok && !hasAttribute(vehicle, v.Name, values) {
+			conflicts = append(conflicts, v)
+		}
+	}
+	return conflicts
+}
+
+// hasAttribute reports whether attrs gives name any of values
+func hasAttribute(attrs []Attribute, name string, values []string) bool {
+	for _, a := range attrs {
+		if a.Name == name && slices.Contains(values, a.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAttributes returns the attributes parsed from each part's spec, for
+// parts that have any.
+func (d *DB) GetAttributes(partIDs ...int) (map[int][]Attribute, error) {
+	attrs := make(map[int][]Attribute)
+	for _, id := range partIDs {
+		err := d.execute("SELECT name, value FROM part_attributes WHERE part_id = ? ORDER BY rowid", &sqlitex.ExecOptions{
+			Args: []any{id},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				attrs[id] = append(attrs[id], Attribute{Name: stmt.ColumnText(0), Value: stmt.ColumnText(1)})
+				return nil
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+// refreshAttributes drops the attributes of parts that are gone or whose
+// spec changed, and parses the specs of parts without any
+func refreshAttributes(conn *sqlite.Conn) (err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	if err = sqlitex.ExecuteTransient(conn, `
+		DELETE FROM part_attributes
+		WHERE NOT EXISTS (SELECT 1 FROM parts p WHERE p.id = part_attributes.part_id AND p.spec = part_attributes.spec)
+	`, nil); err != nil {
+		return err
+	}
+
+	type partSpec struct {
+		id   int
+		spec string
+	}
+	var pending []partSpec
+	if err = sqlitex.ExecuteTransient(conn, `
+		SELECT p.id, p.spec FROM parts p
+		WHERE TRIM(COALESCE(p.spec, '')) != ''
+			AND NOT EXISTS (SELECT 1 FROM part_attributes pa WHERE pa.part_id = p.id)
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			pending = append(pending, partSpec{stmt.ColumnInt(0), stmt.ColumnText(1)})
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		for _, a := range ParseSpec(p.spec) {
+			if err = sqlitex.Execute(conn, "INSERT OR IGNORE INTO part_attributes (part_id, spec, name, value) VALUES (?, ?, ?, ?)", &sqlitex.ExecOptions{
+				Args: []any{p.id, p.spec, a.Name, a.Value},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AttributeFilter keeps search results whose spec gives an attribute.
+type AttributeFilter Attribute
+
+// parseAttributeFilter reads a search word as an attribute filter: a name,
+// a colon and a value, "drive:4wd", "roof:high" or "engine:4m40". ok is
+// false for any other word.
+func parseAttributeFilter(word string) (AttributeFilter, bool) {
+	name, value, found := strings.Cut(word, ":")
+	if !found {
+		return AttributeFilter{}, false
+	}
+	name, ok := attributeNames[strings.ToLower(name)]
+	if !ok {
+		return AttributeFilter{}, false
+	}
+	a, ok := lookupAttribute(normalizeSpecItem(value), name)
+	return AttributeFilter(a), ok
+}
+
+// String describes the filter, e.g. "drive 4WD".
+func (f AttributeFilter) String() string {
+	return f.Name + " " + f.Value
+}
+
+// attributeFilterSQL returns conditions on parts_effective p keeping the
+// parts whose spec gives every filter's attribute, to AND onto a WHERE clause
+func attributeFilterSQL(filters []AttributeFilter) (sql string, args []any) {
+	for _, f := range filters {
+		sql += `
+			AND EXISTS (
+				SELECT 1 FROM part_attributes pa
+				WHERE pa.part_id = p.id AND pa.name = ? AND pa.value = ?
+			)`
+		args = append(args, f.Name, f.Value)
+	}
+	return sql, args
+}
@@ -0,0 +1,351 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// UserTables are the tables holding data the user created, as opposed to
+// the catalog the scraper manages. Backups copy only these.
+var UserTables = []string{"bookmarks", "notes", "note_attachments", "part_migrations", "part_overrides", "pins", "prices", "kb_entries", "compat_notes", "part_dimensions", "part_origins", "purchases", "labor", "diagram_hotspots", "vehicles", "cart", "settings", "collapsed_sections", "search_history", "part_views", "usage_counts", "removed_parts"}
+
+// BackupResult describes a verified backup.
+type BackupResult struct {
+	Path string
+	Rows map[string]int // rows copied per table
+}
+
+// BackupDir returns where automatic backups of the database at dbPath go.
+func BackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// BackupName returns a timestamped backup file name. reason, if set, marks
+// automatic backups, e.g. "pre-migration".
+func BackupName(t time.Time, reason string) string {
+	name := "user-data-" + t.Format("20060102-150405")
+	if reason != "" {
+		name += "-" + reason
+	}
+	return name + ".db"
+}
+
+// ListBackups returns the backup files in dir, newest first.
+func ListBackups(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "user-data-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	// Names start with the timestamp, so they sort chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// PruneBackups deletes all but the newest keep backups in dir, returning
+// the paths removed. keep <= 0 keeps everything.
+func PruneBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	paths, err := ListBackups(dir)
+	if err != nil || len(paths) <= keep {
+		return nil, err
+	}
+	var removed []string
+	for _, p := range paths[keep:] {
+		if err := os.Remove(p); err != nil {
+			return removed, err
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
+
+// Backup copies the user tables into a new SQLite file at path and checks
+// the copy's integrity and row counts.
+func (d *DB) Backup(path string) (BackupResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return backupConn(d.conn, path)
+}
+
+// TableRestore compares a user table with its copy in a backup.
+type TableRestore struct {
+	Table    string
+	Current  int // rows now
+	Backup   int // rows in the backup, which replace them
+	InBackup bool
+}
+
+// UserDataRows counts the rows of each user table, which is what Backup
+// would copy.
+func (d *DB) UserDataRows() (map[string]int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rows := make(map[string]int)
+	for _, table := range UserTables {
+		cols, err := tableColumns(d.conn, "main", table)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		if rows[table], err = countRows(d.conn, "main", table); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// CompareBackup checks the backup at path and says, table by table, what
+// Restore would replace. Tables missing from the backup, which Restore
+// leaves alone, have InBackup false.
+func (d *DB) CompareBackup(path string) ([]TableRestore, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	detach, err := attachBackup(d.conn, path)
+	if err != nil {
+		return nil, err
+	}
+	defer detach()
+
+	var tables []TableRestore
+	for _, table := range UserTables {
+		t := TableRestore{Table: table}
+		if t.Current, err = countRows(d.conn, "main", table); err != nil {
+			return nil, err
+		}
+		cols, err := tableColumns(d.conn, "backup", table)
+		if err != nil {
+			return nil, err
+		}
+		if t.InBackup = len(cols) > 0; t.InBackup {
+			if t.Backup, err = countRows(d.conn, "backup", table); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// Restore replaces the user tables with the contents of the backup at path.
+// Tables missing from the backup are left alone, and columns are matched by
+// name so backups from older versions still restore.
+func (d *DB) Restore(path string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	detach, err := attachBackup(d.conn, path)
+	if err != nil {
+		return err
+	}
+	defer detach()
+
+	return restoreTables(d.conn)
+}
+
+// attachBackup attaches the backup at path as "backup" once it passes an
+// integrity check. Call detach when done with it.
+func attachBackup(conn *sqlite.Conn, path string) (detach func(), err error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open backup: %w", err)
+	}
+	if err := sqlitex.ExecuteTransient(conn, "ATTACH DATABASE ? AS backup", &sqlitex.ExecOptions{
+		Args: []any{path},
+	}); err != nil {
+		return nil, fmt.Errorf("attach backup: %w", err)
+	}
+	detach = func() { sqlitex.ExecuteTransient(conn, "DETACH DATABASE backup", nil) }
+
+	if err := checkIntegrity(conn, "backup"); err != nil {
+		detach()
+		return nil, err
+	}
+	return detach, nil
+}
+
+func restoreTables(conn *sqlite.Conn) (err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	for _, table := range UserTables {
+		backupCols, err := tableColumns(conn, "backup", table)
+		if err != nil {
+			return err
+		}
+		if len(backupCols) == 0 {
+			continue
+		}
+		mainCols, err := tableColumns(conn, "main", table)
+		if err != nil {
+			return err
+		}
+		var cols []string
+		for _, c := range backupCols {
+			if containsColumn(mainCols, c) {
+				cols = append(cols, `"`+c+`"`)
+			}
+		}
+		list := strings.Join(cols, ", ")
+
+		if err := sqlitex.ExecuteTransient(conn, "DELETE FROM main."+table, nil); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+		if err := sqlitex.ExecuteTransient(conn,
+			fmt.Sprintf("INSERT INTO main.%s (%s) SELECT %s FROM backup.%s", table, list, list, table), nil); err != nil {
+			return fmt.Errorf("restore %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// backupConn does the work of Backup on a raw connection, so Open can take
+// a backup before the DB is constructed.
+func backupConn(conn *sqlite.Conn, path string) (BackupResult, error) {
+	result := BackupResult{Path: path, Rows: make(map[string]int)}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return result, fmt.Errorf("create backup dir: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return result, fmt.Errorf("backup %s already exists", path)
+	}
+
+	// The catalog connection can't create files, so create the backup
+	// database before attaching it
+	backup, err := sqlite.OpenConn(path, sqlite.OpenReadWrite|sqlite.OpenCreate)
+	if err != nil {
+		return result, fmt.Errorf("create backup: %w", err)
+	}
+	backup.Close()
+
+	if err := sqlitex.ExecuteTransient(conn, "ATTACH DATABASE ? AS backup", &sqlitex.ExecOptions{
+		Args: []any{path},
+	}); err != nil {
+		return result, fmt.Errorf("create backup: %w", err)
+	}
+	defer sqlitex.ExecuteTransient(conn, "DETACH DATABASE backup", nil)
+
+	for _, table := range UserTables {
+		cols, err := tableColumns(conn, "main", table)
+		if err != nil {
+			return result, err
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		if err := sqlitex.ExecuteTransient(conn,
+			fmt.Sprintf("CREATE TABLE backup.%s AS SELECT * FROM main.%s", table, table), nil); err != nil {
+			return result, fmt.Errorf("copy %s: %w", table, err)
+		}
+
+		var source, copied int
+		if source, err = countRows(conn, "main", table); err != nil {
+			return result, err
+		}
+		if copied, err = countRows(conn, "backup", table); err != nil {
+			return result, err
+		}
+		if source != copied {
+			return result, fmt.Errorf("backup of %s has %d rows, expected %d", table, copied, source)
+		}
+		result.Rows[table] = copied
+	}
+
+	return result, checkIntegrity(conn, "backup")
+}
+
+// backupBeforeMigration backs up existing user data when Open is about to
+// add user tables, i.e. the first run after an upgrade. Catalogs without a
+// file, from OpenConn, aren't backed up.
+func backupBeforeMigration(conn *sqlite.Conn, dbPath string) error {
+	if dbPath == "" {
+		return nil
+	}
+	var missing bool
+	var rows int
+	for _, table := range UserTables {
+		cols, err := tableColumns(conn, "main", table)
+		if err != nil {
+			return err
+		}
+		if len(cols) == 0 {
+			missing = true
+			continue
+		}
+		n, err := countRows(conn, "main", table)
+		if err != nil {
+			return err
+		}
+		rows += n
+	}
+	if !missing || rows == 0 {
+		return nil
+	}
+
+	path := filepath.Join(BackupDir(dbPath), BackupName(time.Now(), "pre-migration"))
+	_, err := backupConn(conn, path)
+	return err
+}
+
+// checkIntegrity runs SQLite's integrity check on an attached schema.
+func checkIntegrity(conn *sqlite.Conn, schema string) error {
+	var problems []string
+	err := sqlitex.ExecuteTransient(conn, "PRAGMA "+schema+".integrity_check", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			if msg := stmt.ColumnText(0); msg != "ok" {
+				problems = append(problems, msg)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// tableColumns returns a table's column names, or none if it doesn't exist.
+func tableColumns(conn *sqlite.Conn, schema, table string) ([]string, error) {
+	var cols []string
+	err := sqlitex.ExecuteTransient(conn, fmt.Sprintf("PRAGMA %s.table_info(%s)", schema, table), &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			cols = append(cols, stmt.ColumnText(1))
+			return nil
+		},
+	})
+	return cols, err
+}
+
+func countRows(conn *sqlite.Conn, schema, table string) (int, error) {
+	var n int
+	err := sqlitex.ExecuteTransient(conn, fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", schema, table), &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			n = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	return n, err
+}
+
+func containsColumn(cols []string, name string) bool {
+	for _, c := range cols {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+func TestBackupRestore(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.AddBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetBookmarkQty(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetNote(4, "replace with the gasket"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AddToCart(8, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), db.BackupName(time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC), ""))
+	result, err := database.Backup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for table, want := range map[string]int{"bookmarks": 1, "notes": 1, "cart": 1, "pins": 0} {
+		if got, ok := result.Rows[table]; !ok || got != want {
+			t.Errorf("backed up %d rows of %s, want %d", got, table, want)
+		}
+	}
+	if _, err := database.Backup(path); err == nil {
+		t.Error("backing up over an existing backup succeeded")
+	}
+
+	// Change everything after the backup
+	if err := database.RemoveBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AddBookmark(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetNote(4, "changed my mind"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ClearCart(); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := database.CompareBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if table.Table == "cart" && (!table.InBackup || table.Current != 0 || table.Backup != 1) {
+			t.Errorf("CompareBackup cart = %+v, want 0 now and 1 in the backup", table)
+		}
+	}
+
+	if err := database.Restore(path); err != nil {
+		t.Fatal(err)
+	}
+	bookmarks, err := database.GetBookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].PartID != 1 || bookmarks[0].Qty != 2 {
+		t.Errorf("bookmarks = %+v, want part 1, 2 needed", bookmarks)
+	}
+	if note, _ := database.GetNote(4); note == nil || *note != "replace with the gasket" {
+		t.Errorf("note = %v, want the one backed up", note)
+	}
+	if qty, _ := database.GetCartQty(8); qty != 4 {
+		t.Errorf("cart holds %d of part 8, want 4", qty)
+	}
+}
+
+func TestRestoreMissingBackup(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.AddBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Restore(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Fatal("restoring a missing backup succeeded")
+	}
+	if bookmarked, _ := database.IsBookmarked(1); !bookmarked {
+		t.Error("bookmark lost when the restore failed")
+	}
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// The cart is the parts to order next and how many of each. Unlike the
+// shortlist it's kept between sessions, and unlike bookmarks it's emptied
+// once the order is sent.
+const createCartTable = `
+	CREATE TABLE IF NOT EXISTS cart (
+		part_id INTEGER PRIMARY KEY,
+		qty INTEGER NOT NULL DEFAULT 1,
+		added_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// CartItem is a line of the cart, priced at the cheapest supplier price of
+// the part number, if one has been imported.
+type CartItem struct {
+	PartID      int
+	PartNumber  string
+	Description *string
+	Qty         int
+	Price       *Price // cheapest known price, nil if none
+}
+
+// Total is the line's cost at its price, or 0 without one.
+func (c CartItem) Total() float64 {
+	if c.Price == nil {
+		return 0
+	}
+	return c.Price.Price * float64(c.Qty)
+}
+
+// CartTotal sums the priced lines of a cart in one currency.
+type CartTotal struct {
+	Currency string
+	Amount   float64
+	Lines    int
+}
+
+// CartTotals sums the cart by currency, in the order the currencies first
+// appear, and counts the lines without a price.
+func CartTotals(items []CartItem) (totals []CartTotal, unpriced int) {
+	for _, item := range items {
+		if item.Price == nil {
+			unpriced++
+			continue
+		}
+		i := 0
+		for i < len(totals) && totals[i].Currency != item.Price.Currency {
+			i++
+		}
+		if i == len(totals) {
+			totals = append(totals, CartTotal{Currency: item.Price.Currency})
+		}
+		totals[i].Amount += item.Total()
+		totals[i].Lines++
+	}
+	return totals, unpriced
+}
+
+// AddToCart puts qty of a part in the cart, adding to the quantity if it's
+// already there.
+func (d *DB) AddToCart(partID, qty int) error {
+	return d.execute(`
+		INSERT INTO cart (part_id, qty) VALUES (?, ?)
+		ON CONFLICT(part_id) DO UPDATE SET qty = qty + excluded.qty
+	`, &sqlitex.ExecOptions{
+		Args: []any{partID, max(qty, 1)},
+	})
+}
+
+// SetCartQty sets how many of a part the cart holds, taking it out at 0.
+func (d *DB) SetCartQty(partID, qty int) error {
+	if qty <= 0 {
+		return d.execute("DELETE FROM cart WHERE part_id = ?", &sqlitex.ExecOptions{
+			Args: []any{partID},
+		})
+	}
+	return d.execute("UPDATE cart SET qty = ? WHERE part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{qty, partID},
+	})
+}
+
+// ClearCart empties the cart.
+func (d *DB) ClearCart() error {
+	return d.execute("DELETE FROM cart", nil)
+}
+
+// GetCart returns the cart in the order parts were added. Parts no longer
+// in the catalog are left out.
+func (d *DB) GetCart() ([]CartItem, error) {
+	var items []CartItem
+	err := d.execute(`
+		SELECT c.part_id, p.part_number, p.description, c.qty,
+			pr.supplier_id, pr.price, pr.currency, pr.stock, pr.lead_time_days, pr.url, pr.updated_at
+		FROM cart c
+		JOIN parts_effective p ON p.id = c.part_id
+		LEFT JOIN prices pr ON pr.rowid = (
+			SELECT rowid FROM prices WHERE part_number = p.part_number
+			ORDER BY price, supplier_id LIMIT 1
+		)
+		ORDER BY c.added_at, c.part_id
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			item := CartItem{
+				PartID:      stmt.ColumnInt(0),
+				PartNumber:  stmt.ColumnText(1),
+				Description: nullableString(stmt, 2),
+				Qty:         stmt.ColumnInt(3),
+			}
+			if stmt.ColumnType(4) != sqlite.TypeNull {
+				item.Price = &Price{
+					SupplierID:   stmt.ColumnText(4),
+					PartNumber:   item.PartNumber,
+					Price:        stmt.ColumnFloat(5),
+					Currency:     stmt.ColumnText(6),
+					Stock:        nullableInt(stmt, 7),
+					LeadTimeDays: nullableInt(stmt, 8),
+					URL:          nullableString(stmt, 9),
+					UpdatedAt:    stmt.ColumnText(10),
+				}
+			}
+			items = append(items, item)
+			return nil
+		},
+	})
+	return items, err
+}
+
+// GetCartQty returns how many of a part the cart holds, 0 if none.
+func (d *DB) GetCartQty(partID int) (int, error) {
+	var qty int
+	err := d.execute("SELECT qty FROM cart WHERE part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			qty = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	return qty, err
+}
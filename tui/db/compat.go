@@ -0,0 +1,191 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Compatibility notes are what owners have found out about a part number:
+// fitment notes ("also fits the L400 4M40T") and aftermarket cross
+// references, a brand and its part number. They're shared between installs
+// as bundles, each entry attributed to whoever contributed it. An entry is
+// keyed by part number, brand, xref and contributor, empty strings meaning
+// a fitment note, so one contributor has one fitment note per part and
+// importing a bundle again updates entries instead of duplicating them,
+// while the same xref from two contributors is kept twice.
+const createCompatTable = `
+	CREATE TABLE IF NOT EXISTS compat_notes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		part_number TEXT NOT NULL,
+		brand TEXT NOT NULL DEFAULT '',
+		xref TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		contributor TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT '',
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (part_number, brand, xref, contributor)
+	)
+`
+
+// CompatImportResult counts what ImportCompatNotes changed.
+type CompatImportResult struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Unmatched int // entries for a part number this catalog doesn't have
+
+	Outcomes []ImportOutcome // per entry, in order
+}
+
+// GetCompatNotesForPart returns the notes for a part's number and for the
+// number that replaces it, fitment notes first, then xrefs by brand.
+func (d *DB) GetCompatNotesForPart(partID int) ([]CompatNote, error) {
+	var notes []CompatNote
+	err := d.execute(`
+		SELECT c.id, c.part_number, c.brand, c.xref, c.note, c.contributor, c.source, c.updated_at
+		FROM parts_effective p
+		JOIN compat_notes c
+			ON c.part_number IN (
+				REPLACE(REPLACE(UPPER(p.part_number), '-', ''), ' ', ''),
+				REPLACE(REPLACE(UPPER(COALESCE(p.replacement_part_number, '')), '-', ''), ' ', '')
+			)
+		WHERE p.id = ?
+		ORDER BY c.xref != '', c.brand, c.xref, c.contributor
+	`, &sqlitex.ExecOptions{
+		Args:       []any{partID},
+		ResultFunc: scanCompatNotes(&notes),
+	})
+	return notes, err
+}
+
+// GetCompatNotes returns every note, or only those by contributor when it
+// isn't empty, ordered by part number.
+func (d *DB) GetCompatNotes(contributor string) ([]CompatNote, error) {
+	var notes []CompatNote
+	err := d.execute(`
+		SELECT id, part_number, brand, xref, note, contributor, source, updated_at
+		FROM compat_notes
+		WHERE ?1 = '' OR contributor = ?1
+		ORDER BY part_number, xref != '', brand, xref, contributor
+	`, &sqlitex.ExecOptions{
+		Args:       []any{strings.TrimSpace(contributor)},
+		ResultFunc: scanCompatNotes(&notes),
+	})
+	return notes, err
+}
+
+func scanCompatNotes(notes *[]CompatNote) func(stmt *sqlite.Stmt) error {
+	return func(stmt *sqlite.Stmt) error {
+		*notes = append(*notes, CompatNote{
+			ID:          stmt.ColumnInt(0),
+			PartNumber:  stmt.ColumnText(1),
+			Brand:       stmt.ColumnText(2),
+			Xref:        stmt.ColumnText(3),
+			Note:        stmt.ColumnText(4),
+			Contributor: stmt.ColumnText(5),
+			Source:      stmt.ColumnText(6),
+			UpdatedAt:   stmt.ColumnText(7),
+		})
+		return nil
+	}
+}
+
+// ImportCompatNotes adds notes, or updates the note and source of those
+// already present with the same key, in one transaction. Part numbers are
+// normalized. Entries need a part number and either a note or a brand and
+// xref. A dry run reports the same result and changes nothing.
+func (d *DB) ImportCompatNotes(notes []CompatNote, dryRun bool) (result CompatImportResult, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err = withDryRun(d.conn, dryRun, func() error {
+		result, err = importCompatNotes(d.conn, notes)
+		return err
+	})
+	return result, err
+}
+
+func importCompatNotes(conn *sqlite.Conn, notes []CompatNote) (result CompatImportResult, err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	for i, n := range notes {
+		n.PartNumber = NormalizePartNumber(n.PartNumber)
+		n.Brand = strings.TrimSpace(n.Brand)
+		n.Xref = strings.ToUpper(strings.TrimSpace(n.Xref))
+		n.Note = strings.TrimSpace(n.Note)
+		n.Contributor = strings.TrimSpace(n.Contributor)
+		switch {
+		case n.PartNumber == "":
+			return result, fmt.Errorf("entry %d: needs a part number", i+1)
+		case (n.Brand == "") != (n.Xref == ""):
+			return result, fmt.Errorf("entry %d (%s): an xref needs both a brand and a part number", i+1, n.PartNumber)
+		case n.Xref == "" && n.Note == "":
+			return result, fmt.Errorf("entry %d (%s): needs a note or an xref", i+1, n.PartNumber)
+		}
+
+		args := []any{n.PartNumber, n.Brand, n.Xref, n.Contributor, n.Note, n.Source}
+		var found, same bool
+		err = sqlitex.ExecuteTransient(conn, `
+			SELECT note = ?5 AND source = ?6 FROM compat_notes
+			WHERE part_number = ?1 AND brand = ?2 AND xref = ?3 AND contributor = ?4
+		`, &sqlitex.ExecOptions{
+			Args: args,
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				found, same = true, stmt.ColumnBool(0)
+				return nil
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+
+		var outcome ImportOutcome
+		switch {
+		case same:
+			outcome.Action = ImportUnchanged
+			result.Unchanged++
+		case found:
+			outcome.Action = ImportUpdated
+			err = sqlitex.ExecuteTransient(conn, `
+				UPDATE compat_notes SET note = ?5, source = ?6, updated_at = CURRENT_TIMESTAMP
+				WHERE part_number = ?1 AND brand = ?2 AND xref = ?3 AND contributor = ?4
+			`, &sqlitex.ExecOptions{Args: args})
+			result.Updated++
+		default:
+			outcome.Action = ImportAdded
+			err = sqlitex.ExecuteTransient(conn, `
+				INSERT INTO compat_notes (part_number, brand, xref, contributor, note, source) VALUES (?, ?, ?, ?, ?, ?)
+			`, &sqlitex.ExecOptions{Args: args})
+			result.Added++
+		}
+		if err != nil {
+			return result, err
+		}
+
+		var matched bool
+		err = sqlitex.ExecuteTransient(conn, `
+			SELECT 1 FROM parts
+			WHERE REPLACE(REPLACE(UPPER(part_number), '-', ''), ' ', '') = ?1
+				OR REPLACE(REPLACE(UPPER(replacement_part_number), '-', ''), ' ', '') = ?1
+			LIMIT 1
+		`, &sqlitex.ExecOptions{
+			Args: []any{n.PartNumber},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				matched = true
+				return nil
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+		if !matched {
+			result.Unmatched++
+		}
+		outcome.Matched = matched
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+	return result, nil
+}
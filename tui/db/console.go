@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// QueryResult holds the rows of an ad hoc query as text.
+type QueryResult struct {
+	Columns   []string
+	Rows      [][]string
+	Truncated bool // more rows than the limit were available
+}
+
+// QueryReadOnly runs a single SQL statement on a read-only connection of
+// its own, returning at most limit rows. It backs the SQL console, so the
+// statement is arbitrary user input: anything but reading, including
+// transactions, ATTACH and PRAGMA, is rejected before it runs. Cancelling
+// ctx interrupts the statement and returns ctx's error.
+func (d *DB) QueryReadOnly(ctx context.Context, query string, limit int) (*QueryResult, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	if word := strings.ToUpper(strings.Fields(query)[0]); slices.Contains(consoleRefused, word) {
+		return nil, fmt.Errorf("%s statements can't be run in the console", word)
+	}
+	if d.path == "" {
+		return nil, fmt.Errorf("the console needs a catalog file")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlite.OpenConn(d.path, sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	defer conn.Close()
+	conn.SetInterrupt(ctx.Done())
+	// parts_effective is a temporary view, so each connection makes its own
+	if err := sqlitex.ExecuteTransient(conn, createEffectivePartsView, nil); err != nil {
+		return nil, err
+	}
+	if err := conn.SetAuthorizer(sqlite.AuthorizeFunc(readOnlyAction)); err != nil {
+		return nil, err
+	}
+
+	stmt, trailing, err := conn.PrepareTransient(query)
+	if sqlite.ErrCode(err) == sqlite.ResultAuth {
+		return nil, fmt.Errorf("only statements that read can be run in the console")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Finalize()
+	if strings.TrimSpace(query[len(query)-trailing:]) != "" {
+		return nil, fmt.Errorf("only one statement can be run at a time")
+	}
+
+	result, err := readRows(stmt, limit)
+	if err == nil && !conn.AutocommitEnabled() {
+		err = fmt.Errorf("the statement left a transaction open")
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// consoleRefused are the statements the console turns away by their first
+// word: transactions, attaching other files and maintenance, none of which
+// reads the catalog
+var consoleRefused = []string{"BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE", "ATTACH", "DETACH", "PRAGMA", "VACUUM", "REINDEX", "ANALYZE"}
+
+// readOnlyAction allows the parts of a statement that only read: selects,
+// the tables and columns they read, functions and recursive queries. FTS5
+// checks PRAGMA data_version as it searches, so that read is allowed too.
+func readOnlyAction(action sqlite.Action) sqlite.AuthResult {
+	switch action.Type() {
+	case sqlite.OpSelect, sqlite.OpRead, sqlite.OpFunction, sqlite.OpRecursive:
+		return sqlite.AuthResultOK
+	case sqlite.OpPragma:
+		if action.Pragma() == "data_version" && action.PragmaArg() == "" {
+			return sqlite.AuthResultOK
+		}
+	}
+	return sqlite.AuthResultDeny
+}
+
+// readRows steps stmt, keeping at most limit rows as text
+func readRows(stmt *sqlite.Stmt, limit int) (*QueryResult, error) {
+	result := &QueryResult{}
+	for i := 0; i < stmt.ColumnCount(); i++ {
+		result.Columns = append(result.Columns, stmt.ColumnName(i))
+	}
+
+	for {
+		hasRow, err := stmt.Step()
+		if err != nil {
+			return nil, err
+		}
+		if !hasRow {
+			break
+		}
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		row := make([]string, stmt.ColumnCount())
+		for i := range row {
+			if stmt.ColumnType(i) == sqlite.TypeNull {
+				row[i] = "NULL"
+			} else {
+				row[i] = stmt.ColumnText(i)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+// fileDB opens a small catalog from a file, for what needs one, such as the
+// console, and returns its path
+func fileDB(t *testing.T) (*db.DB, string) {
+	t.Helper()
+	c := dbtest.New(t)
+	sampleParts(c)
+	path := filepath.Join(t.TempDir(), "delica.db")
+	c.Save(path)
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database, path
+}
+
+func TestQueryReadOnly(t *testing.T) {
+	database, _ := fileDB(t)
+	result, err := database.QueryReadOnly(context.Background(), "SELECT part_number FROM parts_effective ORDER BY part_number", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 2 || result.Rows[0][0] != "MD050125" {
+		t.Errorf("rows = %v, want MD050125 and MD329470", result.Rows)
+	}
+	if _, err := database.QueryReadOnly(context.Background(), "DELETE FROM parts", 10); err == nil {
+		t.Error("a DELETE ran in the console")
+	}
+}
+
+func TestQueryReadOnlyCancel(t *testing.T) {
+	database, _ := fileDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// Counts for far longer than the test would wait
+	start := time.Now()
+	_, err := database.QueryReadOnly(ctx, "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT count(*) FROM n", 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the query cancelled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled query ran for %v", elapsed)
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Counts are the sizes of the catalog's groups and subgroups.
+type Counts struct {
+	GroupSubgroups map[string]int       // group ID to number of subgroups
+	GroupParts     map[string]int       // group ID to number of parts
+	SubgroupParts  map[string]int       // subgroup ID to number of parts
+	Subgroups      map[string]Aggregate // subgroup ID to what's in it
+}
+
+// GetCounts returns subgroup and part counts for every group and subgroup,
+// read from the aggregates kept in step with the catalog. The catalog only
+// changes when the scraper runs, so they're read once per connection.
+func (d *DB) GetCounts() (*Counts, error) {
+	d.countsMu.Lock()
+	defer d.countsMu.Unlock()
+	if d.counts != nil {
+		return d.counts, nil
+	}
+
+	c := &Counts{
+		GroupSubgroups: make(map[string]int),
+		GroupParts:     make(map[string]int),
+		SubgroupParts:  make(map[string]int),
+		Subgroups:      make(map[string]Aggregate),
+	}
+	err := d.execute("SELECT group_id, subgroup_id, parts, diagrams, with_image, earliest, latest FROM subgroup_aggregates", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			group, subgroup := stmt.ColumnText(0), stmt.ColumnText(1)
+			a := Aggregate{
+				Parts:     stmt.ColumnInt(2),
+				Diagrams:  stmt.ColumnInt(3),
+				WithImage: stmt.ColumnInt(4),
+				Earliest:  stmt.ColumnText(5),
+				Latest:    stmt.ColumnText(6),
+			}
+			c.GroupParts[group] += a.Parts
+			if subgroup != "" {
+				c.GroupSubgroups[group]++
+				c.SubgroupParts[subgroup] = a.Parts
+				c.Subgroups[subgroup] = a
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		// A read-only connection to a database the TUI hasn't opened has no
+		// aggregates, so count the parts as they are
+		if c, err = countCatalog(d); err != nil {
+			return nil, err
+		}
+	}
+
+	d.counts = c
+	return c, nil
+}
+
+// countCatalog counts subgroups and parts from the catalog tables
+func countCatalog(d *DB) (*Counts, error) {
+	c := &Counts{
+		GroupSubgroups: make(map[string]int),
+		GroupParts:     make(map[string]int),
+		SubgroupParts:  make(map[string]int),
+		Subgroups:      make(map[string]Aggregate),
+	}
+	err := d.execute("SELECT group_id, COUNT(*) FROM subgroups GROUP BY group_id", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			c.GroupSubgroups[stmt.ColumnText(0)] = stmt.ColumnInt(1)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = d.execute("SELECT group_id, subgroup_id, COUNT(*) FROM parts GROUP BY group_id, subgroup_id", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			n := stmt.ColumnInt(2)
+			c.GroupParts[stmt.ColumnText(0)] += n
+			if stmt.ColumnType(1) != sqlite.TypeNull {
+				c.SubgroupParts[stmt.ColumnText(1)] += n
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for id, n := range c.SubgroupParts {
+		c.Subgroups[id] = Aggregate{Parts: n}
+	}
+	return c, nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Curation problem labels
+const (
+	ProblemNoDescription = "no description"
+	ProblemNoDiagram     = "no diagram"
+	ProblemNoQuantity    = "no quantity"
+	ProblemBadDateRange  = "bad date range"
+)
+
+// dateRangePattern matches ranges like "1994.03 - 1999.07" or "1997/01/15~2001/06",
+// mirroring what the scraper extracts from the EPC.
+var dateRangePattern = regexp.MustCompile(`^(\d{4})[./-](\d{1,2})(?:[./-]\d{1,2})?\s*[-~]\s*(\d{4})[./-](\d{1,2})(?:[./-]\d{1,2})?$`)
+
+// ValidDateRange reports whether s is a well-formed model date range with
+// real months and a start that isn't after the end.
+func ValidDateRange(s string) bool {
+	m := dateRangePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return false
+	}
+	startYear, _ := strconv.Atoi(m[1])
+	startMonth, _ := strconv.Atoi(m[2])
+	endYear, _ := strconv.Atoi(m[3])
+	endMonth, _ := strconv.Atoi(m[4])
+	if startMonth < 1 || startMonth > 12 || endMonth < 1 || endMonth > 12 {
+		return false
+	}
+	return startYear*100+startMonth <= endYear*100+endMonth
+}
+
+// buildDatePattern matches a build date like "1999.07.3" or "1999-07"
+var buildDatePattern = regexp.MustCompile(`^(\d{4})[./-](\d{1,2})`)
+
+// DateRangeCovers reports whether the model date range r, as ValidDateRange
+// accepts, includes the month of date, a build date such as MANUFACTURE_DATE.
+// ok is false when either can't be read, so callers can treat the part as
+// fitting.
+func DateRangeCovers(r, date string) (covers, ok bool) {
+	if !ValidDateRange(r) {
+		return false, false
+	}
+	d := buildDatePattern.FindStringSubmatch(strings.TrimSpace(date))
+	if d == nil {
+		return false, false
+	}
+	m := dateRangePattern.FindStringSubmatch(strings.TrimSpace(r))
+	month := func(year, mon string) int {
+		y, _ := strconv.Atoi(year)
+		mo, _ := strconv.Atoi(mon)
+		return y*100 + mo
+	}
+	built := month(d[1], d[2])
+	return month(m[1], m[2]) <= built && built <= month(m[3], m[4]), true
+}
+
+// DateRangeYears returns the first and last year of the model date range
+// r, as ValidDateRange accepts. ok is false when it can't be read.
+func DateRangeYears(r string) (from, to int, ok bool) {
+	if !ValidDateRange(r) {
+		return 0, 0, false
+	}
+	m := dateRangePattern.FindStringSubmatch(strings.TrimSpace(r))
+	from, _ = strconv.Atoi(m[1])
+	to, _ = strconv.Atoi(m[3])
+	return from, to, true
+}
+
+// GetCurationIssues returns parts with missing descriptions, missing diagram
+// images, no quantity, or malformed date ranges.
+func (d *DB) GetCurationIssues() ([]CurationIssue, error) {
+	var issues []CurationIssue
+	err := d.execute(`
+		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
+			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
+			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
+			   p.replacement_part_number,
+			   dg.id IS NULL OR dg.image_path IS NULL
+		FROM parts_effective p
+		LEFT JOIN diagrams dg ON p.diagram_id = dg.id
+		WHERE p.description IS NULL OR TRIM(p.description) = ''
+		   OR dg.id IS NULL OR dg.image_path IS NULL
+		   OR p.quantity IS NULL OR p.quantity = 0
+		   OR p.model_date_range IS NOT NULL
+		ORDER BY p.part_number, p.id
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			part := scanPart(stmt)
+
+			var problems []string
+			if part.Description == nil || strings.TrimSpace(*part.Description) == "" {
+				problems = append(problems, ProblemNoDescription)
+			}
+			if stmt.ColumnBool(15) {
+				problems = append(problems, ProblemNoDiagram)
+			}
+			if part.Quantity == nil || *part.Quantity == 0 {
+				problems = append(problems, ProblemNoQuantity)
+			}
+			if part.ModelDateRange != nil && !ValidDateRange(*part.ModelDateRange) {
+				problems = append(problems, ProblemBadDateRange)
+			}
+
+			if len(problems) > 0 {
+				issues = append(issues, CurationIssue{Part: part, Problems: problems})
+			}
+			return nil
+		},
+	})
+	return issues, err
+}
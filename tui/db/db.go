@@ -3,13 +3,39 @@ package db
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"zombiezen.com/go/sqlite"
 	"zombiezen.com/go/sqlite/sqlitex"
 )
 
 type DB struct {
+	// mu serializes access to conn, which is not safe for concurrent use.
+	// Queries run from background commands as well as the Update loop.
+	mu   sync.Mutex
 	conn *sqlite.Conn
+
+	// ftsColumns are the parts_fts columns in index order, for ranking
+	ftsColumns []string
+
+	// counts caches GetCounts; countsMu guards it separately from mu,
+	// which each query takes
+	countsMu sync.Mutex
+	counts   *Counts
+
+	// The file Open was given and what it was when last checked, to tell
+	// when another program changes or replaces it
+	path        string
+	file        os.FileInfo
+	dataVersion int64
+
+	// The latest data version seen but not yet reported by CheckCatalog,
+	// and when it was first seen
+	pendingVersion int64
+	pendingSince   time.Time
 }
 
 func Open(path string) (*DB, error) {
@@ -17,12 +43,32 @@ func Open(path string) (*DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
+	return open(conn, path)
+}
+
+// OpenConn is Open for a catalog on a connection made elsewhere, such as
+// the in-memory ones package dbtest builds. The DB takes the connection
+// over. With no file behind it, CheckCatalog never sees it change and
+// there's nowhere to back up to before migrating.
+func OpenConn(conn *sqlite.Conn) (*DB, error) {
+	return open(conn, "")
+}
+
+// open creates and migrates the user tables on conn, to the catalog at
+// path, closing conn if that fails
+func open(conn *sqlite.Conn, path string) (*DB, error) {
+	// Back up user data before changing the schema
+	if err := backupBeforeMigration(conn, path); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("pre-migration backup: %w", err)
+	}
 
 	// Ensure bookmarks table exists
-	err = sqlitex.ExecuteTransient(conn, `
+	err := sqlitex.ExecuteTransient(conn, `
 		CREATE TABLE IF NOT EXISTS bookmarks (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			part_id INTEGER NOT NULL UNIQUE,
+			qty INTEGER NOT NULL DEFAULT 1,
 			created_at TEXT DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (part_id) REFERENCES parts(id) ON DELETE CASCADE
 		)
@@ -32,6 +78,12 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("create bookmarks table: %w", err)
 	}
 
+	// Bookmarks from before quantities were kept need one, each of 1
+	if err = addBookmarkQty(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("add bookmarks qty: %w", err)
+	}
+
 	// Ensure notes table exists
 	err = sqlitex.ExecuteTransient(conn, `
 		CREATE TABLE IF NOT EXISTS notes (
@@ -47,16 +99,250 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("create notes table: %w", err)
 	}
 
-	return &DB{conn: conn}, nil
+	// Ensure note attachments table exists
+	if err = sqlitex.ExecuteTransient(conn, createAttachmentsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create note_attachments table: %w", err)
+	}
+
+	// Ensure the record of user data moved to replacement parts exists
+	if err = sqlitex.ExecuteTransient(conn, createPartMigrationsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create part_migrations table: %w", err)
+	}
+
+	// Ensure saved parts a re-scrape deletes are remembered
+	if err = sqlitex.ExecuteScript(conn, createRemovedPartsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create removed_parts table: %w", err)
+	}
+
+	// Ensure local overrides table and the view that applies it exist
+	if err = sqlitex.ExecuteTransient(conn, createOverridesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create part_overrides table: %w", err)
+	}
+	if err = sqlitex.ExecuteTransient(conn, createEffectivePartsView, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create parts_effective view: %w", err)
+	}
+
+	// Ensure pinned groups and subgroups table exists
+	if err = sqlitex.ExecuteTransient(conn, createPinsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create pins table: %w", err)
+	}
+
+	// Ensure supplier prices table exists
+	if err = sqlitex.ExecuteTransient(conn, createPricesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create prices table: %w", err)
+	}
+	if err = addPriceColumns(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("add prices column: %w", err)
+	}
+
+	// Ensure knowledge base table exists
+	if err = sqlitex.ExecuteTransient(conn, createKBTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create kb_entries table: %w", err)
+	}
+
+	// Ensure compatibility notes table exists
+	if err = sqlitex.ExecuteTransient(conn, createCompatTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create compat_notes table: %w", err)
+	}
+
+	// Ensure purchases table exists
+	if err = sqlitex.ExecuteTransient(conn, createPurchasesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create purchases table: %w", err)
+	}
+	if err = addPurchaseQty(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("add purchases quantity: %w", err)
+	}
+
+	// Ensure labor table exists
+	if err = sqlitex.ExecuteTransient(conn, createLaborTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create labor table: %w", err)
+	}
+
+	// Ensure diagram hotspots table exists
+	if err = sqlitex.ExecuteTransient(conn, createHotspotsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create diagram_hotspots table: %w", err)
+	}
+
+	// Ensure part dimensions table exists
+	if err = sqlitex.ExecuteTransient(conn, createDimensionsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create part_dimensions table: %w", err)
+	}
+
+	// Ensure part origins table exists
+	if err = sqlitex.ExecuteTransient(conn, createOriginsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create part_origins table: %w", err)
+	}
+
+	// Ensure vehicle profiles table exists
+	if err = sqlitex.ExecuteTransient(conn, createVehiclesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create vehicles table: %w", err)
+	}
+
+	// Ensure cart table exists
+	if err = sqlitex.ExecuteTransient(conn, createCartTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create cart table: %w", err)
+	}
+
+	// Ensure part attributes table exists and matches the specs
+	if err = sqlitex.ExecuteTransient(conn, createAttributesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create part_attributes table: %w", err)
+	}
+	if err = refreshAttributes(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("parse part specs: %w", err)
+	}
+
+	// Ensure subgroup aggregates table exists and matches the catalog
+	if err = sqlitex.ExecuteTransient(conn, createAggregatesTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create subgroup_aggregates table: %w", err)
+	}
+	if err = refreshAggregates(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("aggregate subgroups: %w", err)
+	}
+
+	// Ensure feature usage table exists
+	if err = sqlitex.ExecuteTransient(conn, createUsageTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create usage_counts table: %w", err)
+	}
+
+	// Ensure collapsed sections table exists
+	if err = sqlitex.ExecuteTransient(conn, createCollapsedSectionsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create collapsed_sections table: %w", err)
+	}
+
+	// Ensure display settings table exists
+	if err = sqlitex.ExecuteTransient(conn, createSettingsTable, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create settings table: %w", err)
+	}
+
+	// Ensure search and part view history tables exist
+	if err = sqlitex.ExecuteScript(conn, createHistoryTables, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create history tables: %w", err)
+	}
+
+	ftsColumns, err := loadFTSColumns(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read parts_fts columns: %w", err)
+	}
+
+	// Search finds parts by their overridden text
+	if err = indexOverrides(conn, ftsColumns); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	d := &DB{conn: conn, ftsColumns: ftsColumns, path: path}
+	d.file, _ = os.Stat(path)
+	d.dataVersion, _ = readDataVersion(conn)
+	return d, nil
+}
+
+// OpenReadOnly opens the catalog for reading without creating or migrating
+// any tables, for programs other than the TUI. Local overrides apply if the
+// TUI has recorded any. Writes, including bookmarks and notes, fail.
+func OpenReadOnly(path string) (*DB, error) {
+	conn, err := sqlite.OpenConn(path, sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// parts_effective joins part_overrides, search filters on
+	// part_dimensions and part_attributes, and prices are the TUI's own, so
+	// databases the TUI hasn't opened get empty stand-ins for this
+	// connection only
+	for table, create := range map[string]string{"part_overrides": createOverridesTable, "part_dimensions": createDimensionsTable, "part_attributes": createAttributesTable, "prices": createPricesTable} {
+		var exists bool
+		err = sqlitex.ExecuteTransient(conn, "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?", &sqlitex.ExecOptions{
+			Args: []any{table},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				exists = true
+				return nil
+			},
+		})
+		if err == nil && !exists {
+			err = sqlitex.ExecuteTransient(conn, strings.Replace(create, "CREATE TABLE", "CREATE TEMP TABLE", 1), nil)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = sqlitex.ExecuteTransient(conn, createEffectivePartsView, nil)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create parts_effective view: %w", err)
+	}
+	if err = viewPriceColumns(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create prices view: %w", err)
+	}
+
+	ftsColumns, err := loadFTSColumns(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read parts_fts columns: %w", err)
+	}
+
+	return &DB{conn: conn, ftsColumns: ftsColumns}, nil
+}
+
+// SetInterrupt makes queries fail once done is closed, including one that
+// is already running, until it's replaced. Pass nil to clear it. Callers
+// use it to honor context cancellation; see package catalog.
+func (d *DB) SetInterrupt(done <-chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.conn.SetInterrupt(done)
 }
 
 func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return d.conn.Close()
 }
 
+func (d *DB) execute(query string, opts *sqlitex.ExecOptions) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return sqlitex.Execute(d.conn, query, opts)
+}
+
+func (d *DB) executeTransient(query string, opts *sqlitex.ExecOptions) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return sqlitex.ExecuteTransient(d.conn, query, opts)
+}
+
 func (d *DB) GetGroups() ([]Group, error) {
 	var groups []Group
-	err := sqlitex.Execute(d.conn, "SELECT id, name FROM groups ORDER BY name", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, name FROM groups ORDER BY name", &sqlitex.ExecOptions{
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			groups = append(groups, Group{
 				ID:   stmt.ColumnText(0),
@@ -70,7 +356,7 @@ func (d *DB) GetGroups() ([]Group, error) {
 
 func (d *DB) GetGroup(id string) (*Group, error) {
 	var group *Group
-	err := sqlitex.Execute(d.conn, "SELECT id, name FROM groups WHERE id = ?", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, name FROM groups WHERE id = ?", &sqlitex.ExecOptions{
 		Args: []any{id},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			group = &Group{
@@ -85,7 +371,7 @@ func (d *DB) GetGroup(id string) (*Group, error) {
 
 func (d *DB) GetSubgroups(groupID string) ([]Subgroup, error) {
 	var subgroups []Subgroup
-	err := sqlitex.Execute(d.conn, "SELECT id, name, group_id FROM subgroups WHERE group_id = ? ORDER BY name", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, name, group_id FROM subgroups WHERE group_id = ? ORDER BY name", &sqlitex.ExecOptions{
 		Args: []any{groupID},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			subgroups = append(subgroups, Subgroup{
@@ -101,7 +387,7 @@ func (d *DB) GetSubgroups(groupID string) ([]Subgroup, error) {
 
 func (d *DB) GetSubgroup(id string) (*Subgroup, error) {
 	var subgroup *Subgroup
-	err := sqlitex.Execute(d.conn, "SELECT id, name, group_id FROM subgroups WHERE id = ?", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, name, group_id FROM subgroups WHERE id = ?", &sqlitex.ExecOptions{
 		Args: []any{id},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			subgroup = &Subgroup{
@@ -117,12 +403,12 @@ func (d *DB) GetSubgroup(id string) (*Subgroup, error) {
 
 func (d *DB) GetPartsForSubgroup(subgroupID string) ([]PartWithDiagram, error) {
 	var parts []PartWithDiagram
-	err := sqlitex.Execute(d.conn, `
+	err := d.execute(`
 		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
 			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
 			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
 			   p.replacement_part_number, d.image_path
-		FROM parts p
+		FROM parts_effective p
 		JOIN diagrams d ON p.diagram_id = d.id
 		WHERE p.subgroup_id = ?
 		ORDER BY p.ref_number, p.part_number
@@ -136,9 +422,63 @@ func (d *DB) GetPartsForSubgroup(subgroupID string) ([]PartWithDiagram, error) {
 	return parts, err
 }
 
+// GetPartsForDiagram lists a diagram's parts, for diagrams reached without
+// a subgroup.
+func (d *DB) GetPartsForDiagram(diagramID string) ([]PartWithDiagram, error) {
+	var parts []PartWithDiagram
+	err := d.execute(`
+		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
+			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
+			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
+			   p.replacement_part_number, d.image_path
+		FROM parts_effective p
+		JOIN diagrams d ON p.diagram_id = d.id
+		WHERE p.diagram_id = ?
+		ORDER BY p.ref_number, p.part_number
+	`, &sqlitex.ExecOptions{
+		Args: []any{diagramID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			parts = append(parts, scanPartWithDiagram(stmt))
+			return nil
+		},
+	})
+	return parts, err
+}
+
+// GetPartsForGroup lists a group's parts with the names of their subgroup
+// and diagram, for narrowing down without a search query. Score is unset.
+func (d *DB) GetPartsForGroup(groupID string) ([]SearchResult, error) {
+	var parts []SearchResult
+	err := d.execute(`
+		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
+			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
+			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
+			   p.replacement_part_number, d.image_path,
+			   g.name, s.name, d.name
+		FROM parts_effective p
+		JOIN diagrams d ON p.diagram_id = d.id
+		JOIN groups g ON p.group_id = g.id
+		LEFT JOIN subgroups s ON s.id = COALESCE(p.subgroup_id, d.subgroup_id)
+		WHERE p.group_id = ?
+		ORDER BY s.name, p.ref_number, p.part_number
+	`, &sqlitex.ExecOptions{
+		Args: []any{groupID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			parts = append(parts, SearchResult{
+				PartWithDiagram: scanPartWithDiagram(stmt),
+				GroupName:       stmt.ColumnText(16),
+				SubgroupName:    nullableString(stmt, 17),
+				DiagramName:     stmt.ColumnText(18),
+			})
+			return nil
+		},
+	})
+	return parts, err
+}
+
 func (d *DB) GetDiagramForSubgroup(subgroupID string) (*Diagram, error) {
 	var diagram *Diagram
-	err := sqlitex.Execute(d.conn, "SELECT id, group_id, subgroup_id, name, image_url, image_path, source_url FROM diagrams WHERE subgroup_id = ? LIMIT 1", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, group_id, subgroup_id, name, image_url, image_path, source_url FROM diagrams WHERE subgroup_id = ? LIMIT 1", &sqlitex.ExecOptions{
 		Args: []any{subgroupID},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			diagram = &Diagram{
@@ -158,7 +498,7 @@ func (d *DB) GetDiagramForSubgroup(subgroupID string) (*Diagram, error) {
 
 func (d *DB) GetDiagram(id string) (*Diagram, error) {
 	var diagram *Diagram
-	err := sqlitex.Execute(d.conn, "SELECT id, group_id, subgroup_id, name, image_url, image_path, source_url FROM diagrams WHERE id = ?", &sqlitex.ExecOptions{
+	err := d.execute("SELECT id, group_id, subgroup_id, name, image_url, image_path, source_url FROM diagrams WHERE id = ?", &sqlitex.ExecOptions{
 		Args: []any{id},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			diagram = &Diagram{
@@ -176,14 +516,46 @@ func (d *DB) GetDiagram(id string) (*Diagram, error) {
 	return diagram, err
 }
 
+func (d *DB) GetDiagramsForGroup(groupID string) ([]DiagramWithNames, error) {
+	var diagrams []DiagramWithNames
+	err := d.execute(`
+		SELECT dg.id, dg.group_id, dg.subgroup_id, dg.name, dg.image_url, dg.image_path, dg.source_url,
+			   g.name, s.name
+		FROM diagrams dg
+		JOIN groups g ON dg.group_id = g.id
+		LEFT JOIN subgroups s ON dg.subgroup_id = s.id
+		WHERE dg.group_id = ?
+		ORDER BY s.name, dg.name
+	`, &sqlitex.ExecOptions{
+		Args: []any{groupID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			diagrams = append(diagrams, DiagramWithNames{
+				Diagram: Diagram{
+					ID:         stmt.ColumnText(0),
+					GroupID:    stmt.ColumnText(1),
+					SubgroupID: nullableString(stmt, 2),
+					Name:       stmt.ColumnText(3),
+					ImageURL:   nullableString(stmt, 4),
+					ImagePath:  nullableString(stmt, 5),
+					SourceURL:  stmt.ColumnText(6),
+				},
+				GroupName:    stmt.ColumnText(7),
+				SubgroupName: nullableString(stmt, 8),
+			})
+			return nil
+		},
+	})
+	return diagrams, err
+}
+
 func (d *DB) GetPart(id int) (*PartWithDiagram, error) {
 	var part *PartWithDiagram
-	err := sqlitex.Execute(d.conn, `
+	err := d.execute(`
 		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
 			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
 			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
 			   p.replacement_part_number, d.image_path
-		FROM parts p
+		FROM parts_effective p
 		JOIN diagrams d ON p.diagram_id = d.id
 		WHERE p.id = ?
 	`, &sqlitex.ExecOptions{
@@ -198,31 +570,88 @@ func (d *DB) GetPart(id int) (*PartWithDiagram, error) {
 }
 
 func (d *DB) SearchParts(query string) ([]SearchResult, error) {
-	if query == "" {
-		return nil, nil
+	parsed, err := ParseSearchQuery(query)
+	if err != nil || parsed.FTS == "" && !parsed.HasFilters() {
+		return nil, err
+	}
+	filterSQL, filterArgs := dimensionFilterSQL(parsed.Dims)
+	attrsSQL, attrsArgs := attributeFilterSQL(parsed.Attrs)
+	filterSQL, filterArgs = filterSQL+attrsSQL, append(filterArgs, attrsArgs...)
+	if parsed.FTS == "" {
+		return d.searchByFilters(filterSQL, filterArgs)
 	}
+
+	columns := d.searchColumns()
+	if len(columns) == 0 {
+		return nil, ErrNoSearchIndex
+	}
+
+	// Rank by weighted bm25 so part number and PNC matches come first.
+	// bm25 scores are negative, lower is better.
+	bm25, highlights := rankSQL(columns)
+
 	var results []SearchResult
-	err := sqlitex.Execute(d.conn, `
+	err = d.execute(`
 		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
 			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
 			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
 			   p.replacement_part_number, d.image_path,
-			   g.name, s.name
-		FROM parts p
-		JOIN parts_fts fts ON p.id = fts.rowid
+			   g.name, s.name, d.name, `+bm25+` AS score, `+strings.Join(highlights, ", ")+`
+		FROM parts_effective p
+		JOIN parts_fts ON p.id = parts_fts.rowid
 		JOIN diagrams d ON p.diagram_id = d.id
 		JOIN groups g ON p.group_id = g.id
-		LEFT JOIN subgroups s ON p.subgroup_id = s.id
-		WHERE parts_fts MATCH ?
-		ORDER BY rank
+		LEFT JOIN subgroups s ON s.id = COALESCE(p.subgroup_id, d.subgroup_id)
+		WHERE parts_fts MATCH ?`+filterSQL+`
+		ORDER BY score
+		LIMIT 50
+	`, &sqlitex.ExecOptions{
+		Args: append([]any{parsed.FTS}, filterArgs...),
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			result := SearchResult{
+				PartWithDiagram: scanPartWithDiagram(stmt),
+				GroupName:       stmt.ColumnText(16),
+				SubgroupName:    nullableString(stmt, 17),
+				DiagramName:     stmt.ColumnText(18),
+				Score:           -stmt.ColumnFloat(19),
+			}
+			for i, col := range columns {
+				if strings.ContainsRune(stmt.ColumnText(20+i), '\x01') {
+					result.MatchedColumns = append(result.MatchedColumns, col)
+				}
+			}
+			results = append(results, result)
+			return nil
+		},
+	})
+	return results, err
+}
+
+// searchByFilters returns the parts passing dimension and attribute filters
+// alone, by part number, for queries with no words to match
+func (d *DB) searchByFilters(filterSQL string, filterArgs []any) ([]SearchResult, error) {
+	var results []SearchResult
+	err := d.execute(`
+		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
+			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
+			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
+			   p.replacement_part_number, d.image_path,
+			   g.name, s.name, d.name
+		FROM parts_effective p
+		JOIN diagrams d ON p.diagram_id = d.id
+		JOIN groups g ON p.group_id = g.id
+		LEFT JOIN subgroups s ON s.id = COALESCE(p.subgroup_id, d.subgroup_id)
+		WHERE 1`+filterSQL+`
+		ORDER BY p.part_number
 		LIMIT 50
 	`, &sqlitex.ExecOptions{
-		Args: []any{query + "*"},
+		Args: filterArgs,
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			results = append(results, SearchResult{
 				PartWithDiagram: scanPartWithDiagram(stmt),
 				GroupName:       stmt.ColumnText(16),
 				SubgroupName:    nullableString(stmt, 17),
+				DiagramName:     stmt.ColumnText(18),
 			})
 			return nil
 		},
@@ -231,20 +660,43 @@ func (d *DB) SearchParts(query string) ([]SearchResult, error) {
 }
 
 func (d *DB) AddBookmark(partID int) error {
-	return sqlitex.ExecuteTransient(d.conn, "INSERT OR IGNORE INTO bookmarks (part_id) VALUES (?)", &sqlitex.ExecOptions{
+	return d.executeTransient("INSERT OR IGNORE INTO bookmarks (part_id) VALUES (?)", &sqlitex.ExecOptions{
 		Args: []any{partID},
 	})
 }
 
+// addBookmarkQty adds the qty column to a bookmarks table made before it
+// existed
+func addBookmarkQty(conn *sqlite.Conn) error {
+	var found bool
+	err := sqlitex.ExecuteTransient(conn, "SELECT 1 FROM pragma_table_info('bookmarks') WHERE name = 'qty'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			found = true
+			return nil
+		},
+	})
+	if err != nil || found {
+		return err
+	}
+	return sqlitex.ExecuteTransient(conn, "ALTER TABLE bookmarks ADD COLUMN qty INTEGER NOT NULL DEFAULT 1", nil)
+}
+
+// SetBookmarkQty sets how many of a bookmarked part are needed, at least 1.
+func (d *DB) SetBookmarkQty(partID, qty int) error {
+	return d.executeTransient("UPDATE bookmarks SET qty = ? WHERE part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{max(qty, 1), partID},
+	})
+}
+
 func (d *DB) RemoveBookmark(partID int) error {
-	return sqlitex.ExecuteTransient(d.conn, "DELETE FROM bookmarks WHERE part_id = ?", &sqlitex.ExecOptions{
+	return d.executeTransient("DELETE FROM bookmarks WHERE part_id = ?", &sqlitex.ExecOptions{
 		Args: []any{partID},
 	})
 }
 
 func (d *DB) IsBookmarked(partID int) (bool, error) {
 	var found bool
-	err := sqlitex.Execute(d.conn, "SELECT 1 FROM bookmarks WHERE part_id = ?", &sqlitex.ExecOptions{
+	err := d.execute("SELECT 1 FROM bookmarks WHERE part_id = ?", &sqlitex.ExecOptions{
 		Args: []any{partID},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			found = true
@@ -256,12 +708,12 @@ func (d *DB) IsBookmarked(partID int) (bool, error) {
 
 func (d *DB) GetBookmarks() ([]BookmarkResult, error) {
 	var bookmarks []BookmarkResult
-	err := sqlitex.Execute(d.conn, `
+	err := d.execute(`
 		SELECT b.id, b.part_id, b.created_at,
 			   p.part_number, p.pnc, p.description,
-			   g.name, s.name
+			   g.name, s.name, b.qty, p.spec, p.model_date_range
 		FROM bookmarks b
-		JOIN parts p ON b.part_id = p.id
+		JOIN parts_effective p ON b.part_id = p.id
 		JOIN groups g ON p.group_id = g.id
 		LEFT JOIN subgroups s ON p.subgroup_id = s.id
 		ORDER BY b.created_at DESC
@@ -276,6 +728,10 @@ func (d *DB) GetBookmarks() ([]BookmarkResult, error) {
 				Description:  nullableString(stmt, 5),
 				GroupName:    stmt.ColumnText(6),
 				SubgroupName: nullableString(stmt, 7),
+				Qty:          stmt.ColumnInt(8),
+
+				Spec:           nullableString(stmt, 9),
+				ModelDateRange: nullableString(stmt, 10),
 			})
 			return nil
 		},
@@ -285,7 +741,7 @@ func (d *DB) GetBookmarks() ([]BookmarkResult, error) {
 
 func (d *DB) GetBookmarkCount() (int, error) {
 	var count int
-	err := sqlitex.Execute(d.conn, "SELECT COUNT(*) FROM bookmarks", &sqlitex.ExecOptions{
+	err := d.execute("SELECT COUNT(*) FROM bookmarks", &sqlitex.ExecOptions{
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			count = stmt.ColumnInt(0)
 			return nil
@@ -294,8 +750,25 @@ func (d *DB) GetBookmarkCount() (int, error) {
 	return count, err
 }
 
+// GetSavedPartIDs returns the parts with a bookmark, note or attachment,
+// the ones the user has already dealt with.
+func (d *DB) GetSavedPartIDs() (map[int]bool, error) {
+	ids := make(map[int]bool)
+	err := d.execute(`
+		SELECT part_id FROM bookmarks
+		UNION SELECT part_id FROM notes
+		UNION SELECT part_id FROM note_attachments
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			ids[stmt.ColumnInt(0)] = true
+			return nil
+		},
+	})
+	return ids, err
+}
+
 func (d *DB) SetNote(partID int, content string) error {
-	return sqlitex.ExecuteTransient(d.conn, `
+	return d.executeTransient(`
 		INSERT INTO notes (part_id, content) VALUES (?, ?)
 		ON CONFLICT(part_id) DO UPDATE SET content = ?, updated_at = CURRENT_TIMESTAMP
 	`, &sqlitex.ExecOptions{
@@ -304,14 +777,14 @@ func (d *DB) SetNote(partID int, content string) error {
 }
 
 func (d *DB) RemoveNote(partID int) error {
-	return sqlitex.ExecuteTransient(d.conn, "DELETE FROM notes WHERE part_id = ?", &sqlitex.ExecOptions{
+	return d.executeTransient("DELETE FROM notes WHERE part_id = ?", &sqlitex.ExecOptions{
 		Args: []any{partID},
 	})
 }
 
 func (d *DB) GetNote(partID int) (*string, error) {
 	var content *string
-	err := sqlitex.Execute(d.conn, "SELECT content FROM notes WHERE part_id = ?", &sqlitex.ExecOptions{
+	err := d.execute("SELECT content FROM notes WHERE part_id = ?", &sqlitex.ExecOptions{
 		Args: []any{partID},
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			c := stmt.ColumnText(0)
@@ -322,18 +795,59 @@ func (d *DB) GetNote(partID int) (*string, error) {
 	return content, err
 }
 
-func (d *DB) GetNotes() ([]NoteResult, error) {
+// GetNotes returns limit notes from offset, most recently edited first. A
+// limit of 0 returns them all.
+func (d *DB) GetNotes(limit, offset int) ([]NoteResult, error) {
+	return d.queryNotes("", nil, limit, offset)
+}
+
+// SearchNotes returns limit notes from offset whose content, part number or
+// description contains query, ignoring case, most recently edited first,
+// with how many match in all.
+func (d *DB) SearchNotes(query string, limit, offset int) ([]NoteResult, int, error) {
+	where := `WHERE instr(lower(n.content), lower(?1)) > 0
+		OR instr(lower(p.part_number), lower(?1)) > 0
+		OR instr(lower(COALESCE(p.description, '')), lower(?1)) > 0`
+	args := []any{query}
+
+	var total int
+	err := d.execute(`
+		SELECT COUNT(*)
+		FROM notes n
+		JOIN parts_effective p ON n.part_id = p.id
+		`+where, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			total = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	notes, err := d.queryNotes(where, args, limit, offset)
+	return notes, total, err
+}
+
+// queryNotes reads the notes a WHERE clause over notes n and
+// parts_effective p keeps, limit of them from offset, 0 meaning all
+func (d *DB) queryNotes(where string, args []any, limit, offset int) ([]NoteResult, error) {
+	if limit <= 0 {
+		limit = -1
+	}
 	var notes []NoteResult
-	err := sqlitex.Execute(d.conn, `
+	err := d.execute(`
 		SELECT n.id, n.part_id, n.content, n.updated_at,
 			   p.part_number, p.pnc, p.description,
 			   g.name, s.name
 		FROM notes n
-		JOIN parts p ON n.part_id = p.id
+		JOIN parts_effective p ON n.part_id = p.id
 		JOIN groups g ON p.group_id = g.id
 		LEFT JOIN subgroups s ON p.subgroup_id = s.id
-		ORDER BY n.updated_at DESC
-	`, &sqlitex.ExecOptions{
+		`+where+`
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT `+fmt.Sprint(limit)+` OFFSET `+fmt.Sprint(max(offset, 0)), &sqlitex.ExecOptions{
+		Args: args,
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			notes = append(notes, NoteResult{
 				ID:           stmt.ColumnInt(0),
@@ -354,7 +868,7 @@ func (d *DB) GetNotes() ([]NoteResult, error) {
 
 func (d *DB) GetNoteCount() (int, error) {
 	var count int
-	err := sqlitex.Execute(d.conn, "SELECT COUNT(*) FROM notes", &sqlitex.ExecOptions{
+	err := d.execute("SELECT COUNT(*) FROM notes", &sqlitex.ExecOptions{
 		ResultFunc: func(stmt *sqlite.Stmt) error {
 			count = stmt.ColumnInt(0)
 			return nil
@@ -381,35 +895,41 @@ func nullableInt(stmt *sqlite.Stmt, col int) *int {
 	return &i
 }
 
+// scanPart reads the standard 15 part columns starting at column 0
+func scanPart(stmt *sqlite.Stmt) Part {
+	return Part{
+		ID:                    stmt.ColumnInt(0),
+		DetailPageID:          nullableString(stmt, 1),
+		PartNumber:            stmt.ColumnText(2),
+		PNC:                   nullableString(stmt, 3),
+		Description:           nullableString(stmt, 4),
+		RefNumber:             nullableString(stmt, 5),
+		Quantity:              nullableInt(stmt, 6),
+		Spec:                  nullableString(stmt, 7),
+		Notes:                 nullableString(stmt, 8),
+		Color:                 nullableString(stmt, 9),
+		ModelDateRange:        nullableString(stmt, 10),
+		DiagramID:             stmt.ColumnText(11),
+		GroupID:               stmt.ColumnText(12),
+		SubgroupID:            nullableString(stmt, 13),
+		ReplacementPartNumber: nullableString(stmt, 14),
+	}
+}
+
 func scanPartWithDiagram(stmt *sqlite.Stmt) PartWithDiagram {
 	return PartWithDiagram{
-		Part: Part{
-			ID:                    stmt.ColumnInt(0),
-			DetailPageID:          nullableString(stmt, 1),
-			PartNumber:            stmt.ColumnText(2),
-			PNC:                   nullableString(stmt, 3),
-			Description:           nullableString(stmt, 4),
-			RefNumber:             nullableString(stmt, 5),
-			Quantity:              nullableInt(stmt, 6),
-			Spec:                  nullableString(stmt, 7),
-			Notes:                 nullableString(stmt, 8),
-			Color:                 nullableString(stmt, 9),
-			ModelDateRange:        nullableString(stmt, 10),
-			DiagramID:             stmt.ColumnText(11),
-			GroupID:               stmt.ColumnText(12),
-			SubgroupID:            nullableString(stmt, 13),
-			ReplacementPartNumber: nullableString(stmt, 14),
-		},
+		Part:      scanPart(stmt),
 		ImagePath: nullableString(stmt, 15),
 	}
 }
 
 func (d *DB) GetSubgroupsForPartNumber(partNumber string) ([]SubgroupWithGroup, error) {
 	var subgroups []SubgroupWithGroup
-	err := sqlitex.Execute(d.conn, `
+	err := d.execute(`
 		SELECT DISTINCT s.id, s.name, g.id, g.name
 		FROM parts p
-		JOIN subgroups s ON p.subgroup_id = s.id
+		JOIN diagrams d ON p.diagram_id = d.id
+		JOIN subgroups s ON s.id = COALESCE(p.subgroup_id, d.subgroup_id)
 		JOIN groups g ON s.group_id = g.id
 		WHERE p.part_number = ?
 		ORDER BY g.name, s.name
@@ -428,5 +948,64 @@ func (d *DB) GetSubgroupsForPartNumber(partNumber string) ([]SubgroupWithGroup,
 	return subgroups, err
 }
 
+func (d *DB) GetAllSubgroups() ([]SubgroupWithGroup, error) {
+	var subgroups []SubgroupWithGroup
+	err := d.execute(`
+		SELECT s.id, s.name, g.id, g.name
+		FROM subgroups s
+		JOIN groups g ON s.group_id = g.id
+		ORDER BY g.name, s.name
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			subgroups = append(subgroups, SubgroupWithGroup{
+				SubgroupID:   stmt.ColumnText(0),
+				SubgroupName: stmt.ColumnText(1),
+				GroupID:      stmt.ColumnText(2),
+				GroupName:    stmt.ColumnText(3),
+			})
+			return nil
+		},
+	})
+	return subgroups, err
+}
+
+// GetSavedPartChanges returns bookmarked or noted parts that the catalog now
+// lists as superseded, or that no longer exist after a re-scrape.
+func (d *DB) GetSavedPartChanges() ([]SavedPartChange, error) {
+	var changes []SavedPartChange
+	err := d.execute(`
+		WITH saved AS (
+			SELECT part_id FROM bookmarks
+			UNION
+			SELECT part_id FROM notes
+		)
+		SELECT sv.part_id, COALESCE(p.part_number, rp.part_number, ''), COALESCE(p.description, rp.description), p.replacement_part_number,
+			   EXISTS (SELECT 1 FROM parts r WHERE r.part_number = p.replacement_part_number),
+			   EXISTS (SELECT 1 FROM bookmarks b WHERE b.part_id = sv.part_id),
+			   EXISTS (SELECT 1 FROM notes n WHERE n.part_id = sv.part_id),
+			   p.id IS NULL
+		FROM saved sv
+		LEFT JOIN parts p ON p.id = sv.part_id
+		LEFT JOIN removed_parts rp ON rp.part_id = sv.part_id
+		WHERE p.id IS NULL OR p.replacement_part_number IS NOT NULL
+		ORDER BY p.id IS NULL, COALESCE(p.part_number, rp.part_number) IS NULL, COALESCE(p.part_number, rp.part_number), sv.part_id
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			changes = append(changes, SavedPartChange{
+				PartID:                stmt.ColumnInt(0),
+				PartNumber:            stmt.ColumnText(1),
+				Description:           nullableString(stmt, 2),
+				ReplacementPartNumber: nullableString(stmt, 3),
+				ReplacementInCatalog:  stmt.ColumnBool(4),
+				Bookmarked:            stmt.ColumnBool(5),
+				HasNote:               stmt.ColumnBool(6),
+				Missing:               stmt.ColumnBool(7),
+			})
+			return nil
+		},
+	})
+	return changes, err
+}
+
 // Unused import guard
 var _ = context.Background
@@ -0,0 +1,80 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+// sampleParts fills c with one subgroup of two parts
+func sampleParts(c *dbtest.Catalog) {
+	c.Group("engine", "Engine")
+	c.Subgroup("eng1", "engine", "Timing Belt")
+	c.Diagram("d1", "eng1", "Timing belt", "images/d1.png")
+	c.Part(dbtest.Part{Number: "MD050125", Description: "BELT,TIMING", Diagram: "d1"})
+	c.Part(dbtest.Part{Number: "MD329470", Description: "TENSIONER,TIMING BELT", Diagram: "d1"})
+}
+
+func TestOpenCreatesUserTables(t *testing.T) {
+	database := dbtest.Sample(t)
+	rows, err := database.UserDataRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range db.UserTables {
+		if n, ok := rows[table]; !ok || n != 0 {
+			t.Errorf("%s: %d rows, exists %v; want an empty table", table, n, ok)
+		}
+	}
+}
+
+func TestOpenAddsBookmarkQty(t *testing.T) {
+	// Bookmarks as kept before quantities were
+	c := dbtest.New(t)
+	sampleParts(c)
+	c.Exec(`CREATE TABLE bookmarks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		part_id INTEGER NOT NULL UNIQUE,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)`)
+	c.Exec("INSERT INTO bookmarks (part_id) VALUES (1), (2)")
+	database := c.Open()
+
+	bookmarks, err := database.GetBookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("%d bookmarks, want the 2 from before", len(bookmarks))
+	}
+	for _, b := range bookmarks {
+		if b.Qty != 1 {
+			t.Errorf("%s: %d needed, want 1", b.PartNumber, b.Qty)
+		}
+	}
+}
+
+func TestOpenIndexesExistingOverrides(t *testing.T) {
+	// An override recorded before the search index was built
+	c := dbtest.New(t)
+	sampleParts(c)
+	c.Exec(`CREATE TABLE part_overrides (
+		part_number TEXT NOT NULL,
+		diagram_id TEXT NOT NULL,
+		field TEXT NOT NULL,
+		value TEXT,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (part_number, diagram_id, field)
+	)`)
+	c.Exec("INSERT INTO part_overrides (part_number, diagram_id, field, value) VALUES ('MD329470', 'd1', 'description', 'PULLEY,IDLER')")
+	database := c.Open()
+
+	results, err := database.SearchParts("pulley")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := partNumbers(results); len(got) != 1 || got[0] != "MD329470" {
+		t.Errorf("pulley found %v, want MD329470", got)
+	}
+}
@@ -0,0 +1,275 @@
+// Package dbtest builds miniature catalogs in memory for db and model tests,
+// so they don't need the scraped database. A Catalog has the scraper's
+// schema (scraper/src/db/schema.ts); add groups, subgroups, diagrams and
+// parts to it, then Open it for a *db.DB with the user tables and the search
+// index built as the TUI would:
+//
+//	c := dbtest.New(t)
+//	c.Group("engine", "Engine")
+//	c.Subgroup("eng1", "engine", "Timing Belt")
+//	c.Diagram("d1", "eng1", "Timing belt", "images/d1.png")
+//	id := c.Part(dbtest.Part{Number: "MD050125", Description: "BELT,TIMING", Ref: "1", Diagram: "d1"})
+//	database := c.Open()
+//
+// Sample returns one already filled in.
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// schema is the catalog the scraper creates, less parts_fts, which Open
+// builds with db.BuildSearchIndex
+const schema = `
+CREATE TABLE groups (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE TABLE subgroups (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	group_id TEXT NOT NULL REFERENCES groups(id),
+	path TEXT NOT NULL
+);
+CREATE TABLE diagrams (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL REFERENCES groups(id),
+	subgroup_id TEXT REFERENCES subgroups(id),
+	name TEXT NOT NULL,
+	image_url TEXT,
+	image_path TEXT,
+	source_url TEXT NOT NULL
+);
+CREATE TABLE parts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	detail_page_id TEXT,
+	part_number TEXT NOT NULL,
+	pnc TEXT,
+	description TEXT,
+	ref_number TEXT,
+	quantity INTEGER,
+	spec TEXT,
+	notes TEXT,
+	color TEXT,
+	model_date_range TEXT,
+	diagram_id TEXT NOT NULL REFERENCES diagrams(id),
+	group_id TEXT NOT NULL REFERENCES groups(id),
+	subgroup_id TEXT REFERENCES subgroups(id),
+	replacement_part_number TEXT,
+	search_terms TEXT,
+	added_at TEXT,
+	UNIQUE(part_number, diagram_id)
+);
+CREATE TABLE tags (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	category TEXT NOT NULL
+);
+CREATE TABLE tags_to_parts (
+	tag_id TEXT NOT NULL REFERENCES tags(id),
+	part_id INTEGER NOT NULL REFERENCES parts(id),
+	PRIMARY KEY (tag_id, part_id)
+);
+CREATE TABLE scrape_progress (
+	url TEXT PRIMARY KEY,
+	status TEXT NOT NULL,
+	scraped_at TEXT,
+	error TEXT
+);
+CREATE TABLE group_sync (
+	group_id TEXT PRIMARY KEY,
+	synced_at TEXT NOT NULL
+);
+CREATE INDEX idx_parts_part_number ON parts(part_number);
+CREATE INDEX idx_parts_diagram_id ON parts(diagram_id);
+CREATE INDEX idx_parts_pnc ON parts(pnc);
+CREATE INDEX idx_parts_group_id ON parts(group_id);
+CREATE INDEX idx_parts_subgroup_id ON parts(subgroup_id);
+CREATE INDEX idx_diagrams_group_id ON diagrams(group_id);
+CREATE INDEX idx_diagrams_subgroup_id ON diagrams(subgroup_id);
+CREATE INDEX idx_subgroups_group_id ON subgroups(group_id);
+`
+
+// Catalog is a miniature catalog being built. Adding to it fails the test
+// on a mistake, such as a part on a diagram that doesn't exist.
+type Catalog struct {
+	t    testing.TB
+	conn *sqlite.Conn
+}
+
+// Part is a catalog part. Empty fields are stored as NULL, as the scraper
+// leaves what the EPC doesn't list; the group and subgroup are the
+// diagram's.
+type Part struct {
+	Number      string
+	PNC         string
+	Description string
+	Ref         string
+	Qty         int
+	Spec        string
+	Notes       string
+	Color       string
+	ModelDates  string // as the EPC gives them, like 9402-9709
+	Replacement string // the part number superseding this one
+	SearchTerms string
+	AddedAt     string // when a sync added it, YYYY-MM-DD HH:MM:SS
+	Diagram     string
+}
+
+// New returns an empty catalog, closed when the test ends unless Open
+// hands it to a DB.
+func New(t testing.TB) *Catalog {
+	t.Helper()
+	conn, err := sqlite.OpenConn(":memory:")
+	if err != nil {
+		t.Fatalf("open catalog: %v", err)
+	}
+	if err := sqlitex.ExecuteScript(conn, schema, nil); err != nil {
+		conn.Close()
+		t.Fatalf("create catalog schema: %v", err)
+	}
+	c := &Catalog{t: t, conn: conn}
+	t.Cleanup(func() {
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+	return c
+}
+
+func (c *Catalog) exec(query string, args ...any) {
+	c.t.Helper()
+	if c.conn == nil {
+		c.t.Fatal("catalog already opened")
+	}
+	if err := sqlitex.ExecuteTransient(c.conn, query, &sqlitex.ExecOptions{Args: args}); err != nil {
+		c.t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// Group adds a group.
+func (c *Catalog) Group(id, name string) {
+	c.t.Helper()
+	c.exec("INSERT INTO groups (id, name) VALUES (?, ?)", id, name)
+}
+
+// Subgroup adds a subgroup to a group.
+func (c *Catalog) Subgroup(id, groupID, name string) {
+	c.t.Helper()
+	c.exec("INSERT INTO subgroups (id, name, group_id, path) VALUES (?, ?, ?, ?)",
+		id, name, groupID, groupID+"/"+id)
+}
+
+// Diagram adds a subgroup's diagram. imagePath is relative to the data
+// directory, or "" for a diagram whose image wasn't downloaded.
+func (c *Catalog) Diagram(id, subgroupID, name, imagePath string) {
+	c.t.Helper()
+	c.exec(`INSERT INTO diagrams (id, group_id, subgroup_id, name, image_path, source_url)
+		SELECT ?, group_id, id, ?, ?, ? FROM subgroups WHERE id = ?`,
+		id, name, null(imagePath), "https://example.com/diagrams/"+id, subgroupID)
+	if c.conn.Changes() == 0 {
+		c.t.Fatalf("diagram %s: no subgroup %s", id, subgroupID)
+	}
+}
+
+// Part adds a part to its diagram and returns its ID, which count up from
+// 1 in the order parts are added.
+func (c *Catalog) Part(p Part) int {
+	c.t.Helper()
+	var qty any
+	if p.Qty != 0 {
+		qty = p.Qty
+	}
+	c.exec(`INSERT INTO parts (part_number, pnc, description, ref_number, quantity, spec, notes, color,
+			model_date_range, diagram_id, group_id, subgroup_id, replacement_part_number, search_terms, added_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, id, group_id, subgroup_id, ?, ?, ? FROM diagrams WHERE id = ?`,
+		p.Number, null(p.PNC), null(p.Description), null(p.Ref), qty, null(p.Spec), null(p.Notes), null(p.Color),
+		null(p.ModelDates), null(p.Replacement), null(p.SearchTerms), null(p.AddedAt), p.Diagram)
+	if c.conn.Changes() == 0 {
+		c.t.Fatalf("part %s: no diagram %s", p.Number, p.Diagram)
+	}
+	return int(c.conn.LastInsertRowID())
+}
+
+// Exec runs a statement on the catalog before it's opened, for what the
+// other methods don't cover, such as user tables as an older version left
+// them.
+func (c *Catalog) Exec(query string, args ...any) {
+	c.t.Helper()
+	c.exec(query, args...)
+}
+
+// Save writes the catalog to a new file at path as the scraper leaves it,
+// with none of the tables Open adds, for code that opens a database by its
+// path. The catalog can still be added to and opened after.
+func (c *Catalog) Save(path string) {
+	c.t.Helper()
+	c.exec("VACUUM INTO ?", path)
+}
+
+// Open returns the catalog as a DB, closed when the test ends. Nothing can
+// be added to the catalog after.
+func (c *Catalog) Open() *db.DB {
+	c.t.Helper()
+	if c.conn == nil {
+		c.t.Fatal("catalog already opened")
+	}
+	conn := c.conn
+	c.conn = nil
+	database, err := db.OpenConn(conn)
+	if err != nil {
+		c.t.Fatalf("open catalog: %v", err)
+	}
+	c.t.Cleanup(func() { database.Close() })
+	if err := database.BuildSearchIndex(); err != nil {
+		c.t.Fatalf("build search index: %v", err)
+	}
+	return database
+}
+
+// Sample returns a small catalog of three groups: engine (a timing belt,
+// and a tensioner superseded by another), lubrication (an oil pump, and an
+// oil filter whose diagram has no image) and suspension (left and right
+// arms under one PNC, and a bolt). The diagram images aren't included.
+func Sample(t testing.TB) *db.DB {
+	t.Helper()
+	c := New(t)
+	c.Group("engine", "Engine")
+	c.Group("lubrication", "Lubrication")
+	c.Group("suspension", "Front Suspension")
+	c.Subgroup("eng1", "engine", "Timing Belt")
+	c.Subgroup("lub1", "lubrication", "Oil Pump")
+	c.Subgroup("lub2", "lubrication", "Oil Filter")
+	c.Subgroup("sus1", "suspension", "Front Suspension Arm")
+	c.Diagram("d1", "eng1", "Timing belt", "images/d1.png")
+	c.Diagram("d2", "lub1", "Oil pump", "images/d2.png")
+	c.Diagram("d3", "lub2", "Oil filter", "")
+	c.Diagram("d4", "sus1", "Arm", "images/d4.png")
+
+	for _, p := range []Part{
+		{Number: "MD050125", PNC: "10", Description: "BELT,TIMING", Ref: "1", Qty: 1, Spec: "4M40", ModelDates: "9402-9709", SearchTerms: "timing belt cambelt", Diagram: "d1"},
+		{Number: "MD329470", PNC: "20", Description: "TENSIONER,TIMING BELT", Ref: "2", Qty: 1, Replacement: "MD360806", Diagram: "d1"},
+		{Number: "MD360806", PNC: "20", Description: "TENSIONER,TIMING BELT", Ref: "2", Qty: 1, Diagram: "d1"},
+		{Number: "ME013307", PNC: "30", Description: "PUMP,OIL", Ref: "1", Qty: 1, SearchTerms: "oil pump", Diagram: "d2"},
+		{Number: "MD069782", PNC: "40", Description: "FILTER,OIL", Ref: "1", Qty: 1, SearchTerms: "oil filter", Diagram: "d3"},
+		{Number: "MB430997", PNC: "51", Description: "ARM,FR SUSP LWR,LH", Ref: "4", Qty: 1, Diagram: "d4"},
+		{Number: "MB430998", PNC: "51", Description: "ARM,FR SUSP LWR,RH", Ref: "4", Qty: 1, Diagram: "d4"},
+		{Number: "MB433279", PNC: "60", Description: "BOLT", Ref: "5", Qty: 4, Diagram: "d4"},
+	} {
+		c.Part(p)
+	}
+	return c.Open()
+}
+
+// null stores an empty string as NULL
+func null(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
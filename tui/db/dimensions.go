@@ -0,0 +1,298 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Dimensions are measurements the user records for a part number: thread
+// size and pitch, length, outside and inside diameter and width, which the
+// catalog rarely gives and a hardware store matches on. They're keyed by
+// part number like prices, so they survive re-scrapes and apply to every
+// diagram listing the number. Values keep the unit they were entered in;
+// search compares them in millimetres.
+const createDimensionsTable = `
+	CREATE TABLE IF NOT EXISTS part_dimensions (
+		part_number TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value REAL NOT NULL,
+		unit TEXT NOT NULL DEFAULT 'mm',
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (part_number, name)
+	)
+`
+
+// Dimension names, in the order they're shown
+const (
+	DimThread = "thread" // nominal diameter of a metric thread: M8 is 8 mm
+	DimPitch  = "pitch"
+	DimLength = "length"
+	DimOD     = "od"
+	DimID     = "id"
+	DimWidth  = "width"
+)
+
+var dimensionOrder = []string{DimThread, DimPitch, DimLength, DimOD, DimID, DimWidth}
+
+// dimensionNames maps what can be typed for a dimension to its name
+var dimensionNames = map[string]string{
+	"thread": DimThread,
+	"pitch":  DimPitch,
+	"length": DimLength,
+	"len":    DimLength,
+	"od":     DimOD,
+	"id":     DimID,
+	"width":  DimWidth,
+	"w":      DimWidth,
+}
+
+// mmPerUnit converts the units a dimension can be entered in
+var mmPerUnit = map[string]float64{"mm": 1, "cm": 10, "in": 25.4}
+
+// Dimension is one measurement of a part.
+type Dimension struct {
+	Name  string
+	Value float64
+	Unit  string // mm, cm or in
+}
+
+// MM returns the value in millimetres.
+func (d Dimension) MM() float64 {
+	return d.Value * mmPerUnit[d.Unit]
+}
+
+// String gives the value and unit, e.g. "45 mm".
+func (d Dimension) String() string {
+	return strconv.FormatFloat(d.Value, 'f', -1, 64) + " " + d.Unit
+}
+
+// DimensionLabel names a dimension for display, e.g. "OD".
+func DimensionLabel(name string) string {
+	switch name {
+	case DimOD, DimID:
+		return strings.ToUpper(name)
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+var (
+	// A metric thread, M8 or M8x1.25
+	threadPattern = regexp.MustCompile(`^[Mm](\d{1,2}(?:\.\d+)?)(?:\s*[xX×]\s*(\d+(?:\.\d+)?))?$`)
+	// A named dimension, "length 45mm", "od: 22" or "len=1.5in"
+	dimensionPattern = regexp.MustCompile(`^([A-Za-z]+)\s*[:=]?\s*(\d+(?:\.\d+)?)\s*(mm|cm|in|")?$`)
+)
+
+// parseThread reads a metric thread as its diameter and, if given, pitch.
+// ok is false when s isn't one.
+func parseThread(s string) (dims []Dimension, ok bool) {
+	m := threadPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, false
+	}
+	size, _ := strconv.ParseFloat(m[1], 64)
+	dims = append(dims, Dimension{Name: DimThread, Value: size, Unit: "mm"})
+	if m[2] != "" {
+		pitch, _ := strconv.ParseFloat(m[2], 64)
+		dims = append(dims, Dimension{Name: DimPitch, Value: pitch, Unit: "mm"})
+	}
+	return dims, true
+}
+
+// ParseDimensions reads dimensions as typed on the part detail screen:
+// comma-separated, each a metric thread or a name and a value with an
+// optional unit, mm by default: "M8x1.25, length 45mm, od 22". A name given
+// twice keeps the last value.
+func ParseDimensions(input string) ([]Dimension, error) {
+	byName := make(map[string]Dimension)
+	for _, item := range strings.Split(input, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if thread, ok := parseThread(item); ok {
+			for _, d := range thread {
+				byName[d.Name] = d
+			}
+			continue
+		}
+
+		m := dimensionPattern.FindStringSubmatch(item)
+		if m == nil {
+			return nil, fmt.Errorf("%q: write a name and a size, like length 45mm, or a thread like M8x1.25", item)
+		}
+		name, ok := dimensionNames[strings.ToLower(m[1])]
+		if !ok {
+			return nil, fmt.Errorf("%q: dimensions are thread, pitch, length, od, id and width", m[1])
+		}
+		value, _ := strconv.ParseFloat(m[2], 64)
+		unit := m[3]
+		switch unit {
+		case "":
+			unit = "mm"
+		case `"`:
+			unit = "in"
+		}
+		byName[name] = Dimension{Name: name, Value: value, Unit: unit}
+	}
+	return sortDimensions(byName), nil
+}
+
+func sortDimensions(byName map[string]Dimension) []Dimension {
+	var dims []Dimension
+	for _, name := range dimensionOrder {
+		if d, ok := byName[name]; ok {
+			dims = append(dims, d)
+		}
+	}
+	return dims
+}
+
+// FormatDimensions writes dimensions back in the form ParseDimensions
+// reads, for editing.
+func FormatDimensions(dims []Dimension) string {
+	var items []string
+	byName := make(map[string]Dimension)
+	for _, d := range dims {
+		byName[d.Name] = d
+	}
+	if thread, ok := byName[DimThread]; ok && thread.Unit == "mm" {
+		item := "M" + strconv.FormatFloat(thread.Value, 'f', -1, 64)
+		if pitch, ok := byName[DimPitch]; ok && pitch.Unit == "mm" {
+			item += "x" + strconv.FormatFloat(pitch.Value, 'f', -1, 64)
+			delete(byName, DimPitch)
+		}
+		items = append(items, item)
+		delete(byName, DimThread)
+	}
+	for _, d := range sortDimensions(byName) {
+		items = append(items, d.Name+" "+strconv.FormatFloat(d.Value, 'f', -1, 64)+d.Unit)
+	}
+	return strings.Join(items, ", ")
+}
+
+// GetDimensions returns the dimensions recorded for a part number, in
+// display order.
+func (d *DB) GetDimensions(partNumber string) ([]Dimension, error) {
+	byName := make(map[string]Dimension)
+	err := d.execute("SELECT name, value, unit FROM part_dimensions WHERE part_number = ?", &sqlitex.ExecOptions{
+		Args: []any{strings.ToUpper(strings.TrimSpace(partNumber))},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			byName[stmt.ColumnText(0)] = Dimension{Name: stmt.ColumnText(0), Value: stmt.ColumnFloat(1), Unit: stmt.ColumnText(2)}
+			return nil
+		},
+	})
+	return sortDimensions(byName), err
+}
+
+// SetDimensions replaces a part number's dimensions. No dimensions clears
+// them.
+func (d *DB) SetDimensions(partNumber string, dims []Dimension) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return setDimensions(d.conn, strings.ToUpper(strings.TrimSpace(partNumber)), dims)
+}
+
+func setDimensions(conn *sqlite.Conn, partNumber string, dims []Dimension) (err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	if err = sqlitex.ExecuteTransient(conn, "DELETE FROM part_dimensions WHERE part_number = ?", &sqlitex.ExecOptions{
+		Args: []any{partNumber},
+	}); err != nil {
+		return err
+	}
+	for _, dim := range dims {
+		if _, ok := mmPerUnit[dim.Unit]; !ok || !slices.Contains(dimensionOrder, dim.Name) {
+			return fmt.Errorf("unknown dimension %s in %s", dim.Name, dim.Unit)
+		}
+		if err = sqlitex.ExecuteTransient(conn, "INSERT INTO part_dimensions (part_number, name, value, unit) VALUES (?, ?, ?, ?)", &sqlitex.ExecOptions{
+			Args: []any{partNumber, dim.Name, dim.Value, dim.Unit},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DimensionFilter keeps search results with a dimension from Min to Max
+// millimetres.
+type DimensionFilter struct {
+	Name     string
+	Min, Max float64
+}
+
+// dimensionTolerance allows for rounding in entered values, in mm
+const dimensionTolerance = 0.01
+
+// parseDimensionFilters reads a search word as dimension filters: a metric
+// thread, M8 or M8x1.25, or a name, a colon and a size or range,
+// "length:45", "od:20-25mm". ok is false for any other word.
+func parseDimensionFilters(word string) (filters []DimensionFilter, ok bool) {
+	if thread, ok := parseThread(word); ok {
+		for _, d := range thread {
+			filters = append(filters, DimensionFilter{Name: d.Name, Min: d.MM(), Max: d.MM()})
+		}
+		return filters, true
+	}
+
+	name, value, found := strings.Cut(word, ":")
+	if !found {
+		return nil, false
+	}
+	if name, ok = dimensionNames[strings.ToLower(name)]; !ok {
+		return nil, false
+	}
+	unit := "mm"
+	for u := range mmPerUnit {
+		if strings.HasSuffix(value, u) {
+			unit, value = u, strings.TrimSuffix(value, u)
+			break
+		}
+	}
+	low, high, isRange := strings.Cut(value, "-")
+	if !isRange {
+		high = low
+	}
+	lowValue, err := strconv.ParseFloat(low, 64)
+	if err != nil {
+		return nil, false
+	}
+	highValue, err := strconv.ParseFloat(high, 64)
+	if err != nil {
+		return nil, false
+	}
+	return []DimensionFilter{{
+		Name: name,
+		Min:  min(lowValue, highValue) * mmPerUnit[unit],
+		Max:  max(lowValue, highValue) * mmPerUnit[unit],
+	}}, true
+}
+
+// String describes the filter, e.g. "thread 8 mm" or "length 40-50 mm".
+func (f DimensionFilter) String() string {
+	size := strconv.FormatFloat(f.Min, 'f', -1, 64)
+	if f.Max != f.Min {
+		size += "-" + strconv.FormatFloat(f.Max, 'f', -1, 64)
+	}
+	return strings.ToLower(DimensionLabel(f.Name)) + " " + size + " mm"
+}
+
+// dimensionFilterSQL returns conditions on parts_effective p keeping the
+// parts whose dimensions pass every filter, to AND onto a WHERE clause
+func dimensionFilterSQL(filters []DimensionFilter) (sql string, args []any) {
+	for _, f := range filters {
+		sql += `
+			AND EXISTS (
+				SELECT 1 FROM part_dimensions pd
+				WHERE pd.part_number = UPPER(TRIM(p.part_number)) AND pd.name = ?
+					AND pd.value * (CASE pd.unit WHEN 'in' THEN 25.4 WHEN 'cm' THEN 10 ELSE 1 END) BETWEEN ? AND ?
+			)`
+		args = append(args, f.Name, f.Min-dimensionTolerance, f.Max+dimensionTolerance)
+	}
+	return sql, args
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"errors"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// ImportAction is what an import did with one entry, or on a dry run
+// would do.
+type ImportAction string
+
+const (
+	ImportAdded     ImportAction = "add"
+	ImportUpdated   ImportAction = "update"
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+// ImportOutcome is an import's decision on one entry. Matched is false for
+// entries keyed to something this catalog doesn't have.
+type ImportOutcome struct {
+	Action  ImportAction
+	Matched bool
+}
+
+// errDryRun rolls back the savepoint a dry run made its changes in
+var errDryRun = errors.New("dry run")
+
+// withDryRun runs fn in a savepoint, rolled back when dryRun is set, so a
+// dry run goes through the same statements as a real one and reports
+// exactly what it would change.
+func withDryRun(conn *sqlite.Conn, dryRun bool, fn func() error) (err error) {
+	defer func() {
+		if errors.Is(err, errDryRun) {
+			err = nil
+		}
+	}()
+	defer sqlitex.Save(conn)(&err)
+
+	if err = fn(); err == nil && dryRun {
+		err = errDryRun
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// orphanRules say which rows of each table point at a part, diagram, group
+// or subgroup the catalog no longer has, once a re-scrape dropped it.
+// Overrides, prices and other data keyed by part number are meant to
+// outlive part IDs, and part migrations are a record of dropped parts, so
+// they're never orphans. Removed parts only name the bookmarks and notes of
+// dropped parts, so they go when those do.
+var orphanRules = []struct{ table, where string }{
+	{"bookmarks", "part_id NOT IN (SELECT id FROM parts)"},
+	{"notes", "part_id NOT IN (SELECT id FROM parts)"},
+	{"note_attachments", "part_id NOT IN (SELECT id FROM parts)"},
+	{"purchases", "part_id NOT IN (SELECT id FROM parts)"},
+	{"labor", "part_id NOT IN (SELECT id FROM parts)"},
+	{"part_views", "part_id NOT IN (SELECT id FROM parts)"},
+	{"pins", "(kind = 'group' AND target_id NOT IN (SELECT id FROM groups)) OR (kind = 'subgroup' AND target_id NOT IN (SELECT id FROM subgroups))"},
+	{"diagram_hotspots", "diagram_id NOT IN (SELECT id FROM diagrams)"},
+	{"removed_parts", "part_id NOT IN (SELECT part_id FROM bookmarks UNION SELECT part_id FROM notes)"},
+}
+
+// Orphans counts the rows of each table that point at something the catalog
+// no longer has, leaving out tables with none.
+type Orphans map[string]int
+
+// Total is the number of orphaned rows across tables.
+func (o Orphans) Total() int {
+	n := 0
+	for _, rows := range o {
+		n += rows
+	}
+	return n
+}
+
+// CountOrphans counts the orphaned rows of each user table. It fails for a
+// catalog without parts, where every row would count.
+func (d *DB) CountOrphans() (Orphans, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := checkCatalogParts(d.conn); err != nil {
+		return nil, err
+	}
+	orphans := make(Orphans)
+	for _, rule := range orphanRules {
+		err := sqlitex.ExecuteTransient(d.conn, "SELECT COUNT(*) FROM "+rule.table+" WHERE "+rule.where, &sqlitex.ExecOptions{
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				if n := stmt.ColumnInt(0); n > 0 {
+					orphans[rule.table] = n
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", rule.table, err)
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphans deletes the orphaned rows of every user table in one
+// transaction, returning how many went from each.
+func (d *DB) RemoveOrphans() (orphans Orphans, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := checkCatalogParts(d.conn); err != nil {
+		return nil, err
+	}
+	defer sqlitex.Save(d.conn)(&err)
+
+	orphans = make(Orphans)
+	for _, rule := range orphanRules {
+		if err := sqlitex.ExecuteTransient(d.conn, "DELETE FROM "+rule.table+" WHERE "+rule.where, nil); err != nil {
+			return nil, fmt.Errorf("clean %s: %w", rule.table, err)
+		}
+		if n := d.conn.Changes(); n > 0 {
+			orphans[rule.table] = n
+		}
+	}
+	return orphans, nil
+}
+
+// checkCatalogParts fails when the catalog has no parts, as when it's
+// missing or a scrape was cut short, since all user data would look orphaned
+func checkCatalogParts(conn *sqlite.Conn) error {
+	var found bool
+	err := sqlitex.ExecuteTransient(conn, "SELECT 1 FROM parts LIMIT 1", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			found = true
+			return nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("read parts: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("the catalog has no parts, so all user data would look orphaned")
+	}
+	return nil
+}
+
+// GetImagePaths returns the diagram images the catalog refers to, relative
+// to the data directory as stored, in their platform form.
+func (d *DB) GetImagePaths() (map[string]bool, error) {
+	paths := make(map[string]bool)
+	err := d.execute("SELECT image_path FROM diagrams WHERE image_path IS NOT NULL", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			paths[filepath.Clean(filepath.FromSlash(stmt.ColumnText(0)))] = true
+			return nil
+		},
+	})
+	return paths, err
+}
+
+// Vacuum rebuilds the database file, returning the space freed rows left
+// behind to the filesystem.
+func (d *DB) Vacuum() error {
+	return d.executeTransient("VACUUM", nil)
+}
+
+// FreeBytes is the space in the database file held by free pages, which
+// Vacuum would give back.
+func (d *DB) FreeBytes() (int64, error) {
+	var pages, size int64
+	err := d.executeTransient("SELECT freelist_count, page_size FROM pragma_freelist_count(), pragma_page_size()", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			pages, size = stmt.ColumnInt64(0), stmt.ColumnInt64(1)
+			return nil
+		},
+	})
+	return pages * size, err
+}
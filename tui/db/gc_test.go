@@ -0,0 +1,80 @@
+package db_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+// A part ID the sample catalog doesn't have, as after a re-scrape dropped it
+const droppedPart = 999
+
+func TestRemoveOrphans(t *testing.T) {
+	database := dbtest.Sample(t)
+	for _, id := range []int{1, droppedPart} {
+		if err := database.AddBookmark(id); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.SetNote(id, "check the tensioner too"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pin := range [][2]string{{"group", "engine"}, {"group", "transmission"}, {"subgroup", "tra1"}} {
+		if err := database.AddPin(pin[0], pin[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := db.Orphans{"bookmarks": 1, "notes": 1, "pins": 2}
+	counted, err := database.CountOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(counted, want) {
+		t.Errorf("CountOrphans = %v, want %v", counted, want)
+	}
+
+	removed, err := database.RemoveOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(removed, want) {
+		t.Errorf("RemoveOrphans = %v, want %v", removed, want)
+	}
+	if counted, err = database.CountOrphans(); err != nil {
+		t.Fatal(err)
+	}
+	if counted.Total() != 0 {
+		t.Errorf("%v still orphaned after removing them", counted)
+	}
+
+	// What the catalog still has stays
+	if bookmarked, _ := database.IsBookmarked(1); !bookmarked {
+		t.Error("bookmark on a catalog part removed")
+	}
+	if note, _ := database.GetNote(1); note == nil {
+		t.Error("note on a catalog part removed")
+	}
+	pins, err := database.GetPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 1 || pins[0].ID != "engine" {
+		t.Errorf("pins = %+v, want only the engine group", pins)
+	}
+}
+
+func TestRemoveOrphansEmptyCatalog(t *testing.T) {
+	database := dbtest.New(t).Open()
+	if err := database.SetNote(droppedPart, "keep me"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.RemoveOrphans(); err == nil {
+		t.Error("RemoveOrphans on a catalog without parts succeeded")
+	}
+	if note, _ := database.GetNote(droppedPart); note == nil {
+		t.Error("note removed from a catalog without parts")
+	}
+}
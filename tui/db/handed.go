@@ -0,0 +1,95 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// handMarker matches the side in a description: LH, RH, L/H, R.H., LEFT
+// or RIGHT
+var handMarker = regexp.MustCompile(`\b(?:([LR])\.?/?H\b\.?|(LEFT|RIGHT)\b)`)
+
+// Hand returns "LH" or "RH" for a description naming one side, or "" for
+// one naming neither or both (e.g. "LH/RH" for a part that fits either).
+func Hand(description string) string {
+	hand, _ := handedKey(description)
+	return hand
+}
+
+// handedKey returns the description's side and the description with the
+// side and punctuation removed, so "ARM,FR SUSP LWR,LH" and
+// "ARM, FR SUSP LWR, R.H." share a key.
+func handedKey(description string) (hand, key string) {
+	upper := strings.ToUpper(description)
+	matches := handMarker.FindAllStringSubmatchIndex(upper, -1)
+	for _, m := range matches {
+		side := "RH"
+		if upper[m[0]] == 'L' {
+			side = "LH"
+		}
+		if hand != "" && hand != side {
+			return "", ""
+		}
+		hand = side
+	}
+	if hand == "" {
+		return "", ""
+	}
+
+	var b strings.Builder
+	for _, r := range handMarker.ReplaceAllString(upper, "*") {
+		if r == '*' || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return hand, b.String()
+}
+
+// GetOppositeHandPart returns the other-side counterpart of a handed part:
+// a part in the same group whose description matches once the side is
+// ignored, preferring the same diagram and PNC. It returns nil when the
+// part isn't handed or no counterpart is listed.
+func (d *DB) GetOppositeHandPart(partID int) (*PartWithDiagram, error) {
+	part, err := d.GetPart(partID)
+	if err != nil || part == nil || part.Description == nil {
+		return nil, err
+	}
+	hand, key := handedKey(*part.Description)
+	if hand == "" {
+		return nil, nil
+	}
+	pnc := ""
+	if part.PNC != nil {
+		pnc = *part.PNC
+	}
+
+	var match *PartWithDiagram
+	err = d.execute(`
+		SELECT p.id, p.detail_page_id, p.part_number, p.pnc, p.description,
+			   p.ref_number, p.quantity, p.spec, p.notes, p.color,
+			   p.model_date_range, p.diagram_id, p.group_id, p.subgroup_id,
+			   p.replacement_part_number, d.image_path
+		FROM parts_effective p
+		JOIN diagrams d ON p.diagram_id = d.id
+		WHERE p.group_id = ? AND p.id != ? AND p.part_number != ?
+			AND p.description IS NOT NULL
+		ORDER BY p.diagram_id = ? DESC, p.pnc = ? DESC, abs(p.id - ?)
+	`, &sqlitex.ExecOptions{
+		Args: []any{part.GroupID, partID, part.PartNumber, part.DiagramID, pnc, partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			if match != nil {
+				return nil
+			}
+			other, otherKey := handedKey(stmt.ColumnText(4))
+			if other != "" && other != hand && otherKey == key {
+				p := scanPartWithDiagram(stmt)
+				match = &p
+			}
+			return nil
+		},
+	})
+	return match, err
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Hotspots are where a diagram's callouts are, captured by hand on the
+// hotspot screen, for navigating a diagram by its callouts and drawing
+// overlays on it. X and y are pixels of the diagram image as scraped, from
+// its top left, so they hold at any display size. A ref number can have
+// several, as callouts repeat on busy diagrams.
+const createHotspotsTable = `
+	CREATE TABLE IF NOT EXISTS diagram_hotspots (
+		diagram_id TEXT NOT NULL,
+		ref_number TEXT NOT NULL,
+		x INTEGER NOT NULL,
+		y INTEGER NOT NULL,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (diagram_id, ref_number, x, y)
+	)
+`
+
+// Hotspot is a callout's position on a diagram.
+type Hotspot struct {
+	DiagramID string
+	RefNumber string
+	X, Y      int
+}
+
+// AddHotspot records a callout's position. Recording the same one twice
+// does nothing.
+func (d *DB) AddHotspot(h Hotspot) error {
+	return d.execute(`
+		INSERT INTO diagram_hotspots (diagram_id, ref_number, x, y) VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, &sqlitex.ExecOptions{
+		Args: []any{h.DiagramID, h.RefNumber, h.X, h.Y},
+	})
+}
+
+// RemoveHotspot forgets a callout's position.
+func (d *DB) RemoveHotspot(h Hotspot) error {
+	return d.execute("DELETE FROM diagram_hotspots WHERE diagram_id = ? AND ref_number = ? AND x = ? AND y = ?", &sqlitex.ExecOptions{
+		Args: []any{h.DiagramID, h.RefNumber, h.X, h.Y},
+	})
+}
+
+// GetHotspots returns the callout positions captured on a diagram, by ref
+// number.
+func (d *DB) GetHotspots(diagramID string) ([]Hotspot, error) {
+	var hotspots []Hotspot
+	err := d.execute(`
+		SELECT diagram_id, ref_number, x, y FROM diagram_hotspots
+		WHERE diagram_id = ?
+		ORDER BY ref_number, y, x
+	`, &sqlitex.ExecOptions{
+		Args: []any{diagramID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			hotspots = append(hotspots, Hotspot{
+				DiagramID: stmt.ColumnText(0),
+				RefNumber: stmt.ColumnText(1),
+				X:         stmt.ColumnInt(2),
+				Y:         stmt.ColumnInt(3),
+			})
+			return nil
+		},
+	})
+	return hotspots, err
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Kinds of journal entries
+const (
+	JournalNote     = "note"
+	JournalBookmark = "bookmark"
+	JournalLabor    = "labor"
+)
+
+// GetJournal returns dated user activity on parts, oldest first: notes by
+// when they were last edited, bookmarks by when they were added and labor
+// sessions by when they started. since, if not empty, is a YYYY-MM-DD date
+// to start from.
+func (d *DB) GetJournal(since string) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	var replacements []string // each entry's replacement part number, which is priced too
+	err := d.execute(`
+		WITH activity AS (
+			SELECT updated_at AS at, 'note' AS kind, part_id, content AS detail, 0 AS seconds FROM notes
+			UNION ALL
+			SELECT created_at, 'bookmark', part_id, NULL, 0 FROM bookmarks
+			UNION ALL
+			SELECT l.started_at, 'labor', l.part_id, NULL, `+laborSeconds+` FROM labor l
+		)
+		SELECT a.at, a.kind, a.part_id, p.part_number, p.description, a.detail, a.seconds, p.replacement_part_number
+		FROM activity a
+		LEFT JOIN parts_effective p ON p.id = a.part_id
+		WHERE ? = '' OR a.at >= ?
+		ORDER BY a.at, a.kind, a.part_id
+	`, &sqlitex.ExecOptions{
+		Args: []any{since, since},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			entries = append(entries, JournalEntry{
+				At:          stmt.ColumnText(0),
+				Kind:        stmt.ColumnText(1),
+				PartID:      stmt.ColumnInt(2),
+				PartNumber:  stmt.ColumnText(3),
+				Description: nullableString(stmt, 4),
+				Detail:      stmt.ColumnText(5),
+				Labor:       time.Duration(stmt.ColumnInt(6)) * time.Second,
+			})
+			replacements = append(replacements, stmt.ColumnText(7))
+			return nil
+		},
+	})
+	if err != nil || len(entries) == 0 {
+		return entries, err
+	}
+
+	// The lowest price of each part number in each currency, since amounts
+	// in different currencies can't be compared without rates
+	lowest := make(map[string]map[string]float64)
+	err = d.execute("SELECT part_number, currency, MIN(price) FROM prices GROUP BY part_number, currency", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			number := stmt.ColumnText(0)
+			if lowest[number] == nil {
+				lowest[number] = make(map[string]float64)
+			}
+			lowest[number][stmt.ColumnText(1)] = stmt.ColumnFloat(2)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		byCurrency := make(map[string]float64)
+		for _, number := range []string{entries[i].PartNumber, replacements[i]} {
+			for currency, price := range lowest[number] {
+				if was, ok := byCurrency[currency]; !ok || price < was {
+					byCurrency[currency] = price
+				}
+			}
+		}
+		for _, currency := range slices.Sorted(maps.Keys(byCurrency)) {
+			entries[i].LowestPrices = append(entries[i].LowestPrices, CurrencyPrice{Price: byCurrency[currency], Currency: currency})
+		}
+	}
+	return entries, err
+}
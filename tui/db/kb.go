@@ -0,0 +1,173 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Knowledge base entries are notes about a kind of part rather than one
+// listing: service bulletins and known issues, keyed to a PNC (every part
+// carrying it) or a subgroup (every part in it). Empty strings mean "not
+// keyed by this", so the title is unique per key and importing a bundle
+// again updates entries instead of duplicating them.
+const createKBTable = `
+	CREATE TABLE IF NOT EXISTS kb_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pnc TEXT NOT NULL DEFAULT '',
+		subgroup_id TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL,
+		body TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT '',
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (pnc, subgroup_id, title)
+	)
+`
+
+// KBImportResult counts what ImportKBEntries changed.
+type KBImportResult struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Unmatched int // entries keyed to a PNC or subgroup this catalog doesn't have
+
+	Outcomes []ImportOutcome // per entry, in order
+}
+
+// GetKBEntriesForPart returns the entries for a part's PNC and for its
+// subgroup (or its diagram's), PNC entries first. An entry keyed by both
+// only applies to parts matching both.
+func (d *DB) GetKBEntriesForPart(partID int) ([]KBEntry, error) {
+	var entries []KBEntry
+	err := d.execute(`
+		SELECT k.id, k.pnc, k.subgroup_id, k.title, k.body, k.source, k.updated_at
+		FROM parts_effective p
+		JOIN diagrams d ON d.id = p.diagram_id
+		JOIN kb_entries k
+			ON (k.pnc = '' OR k.pnc = UPPER(TRIM(p.pnc)))
+			AND (k.subgroup_id = '' OR k.subgroup_id = COALESCE(p.subgroup_id, d.subgroup_id))
+		WHERE p.id = ?
+		ORDER BY k.pnc = '', k.title
+	`, &sqlitex.ExecOptions{
+		Args:       []any{partID},
+		ResultFunc: scanKBEntries(&entries),
+	})
+	return entries, err
+}
+
+// GetKBEntries returns every entry, ordered by key then title.
+func (d *DB) GetKBEntries() ([]KBEntry, error) {
+	var entries []KBEntry
+	err := d.execute(`
+		SELECT id, pnc, subgroup_id, title, body, source, updated_at
+		FROM kb_entries
+		ORDER BY pnc, subgroup_id, title
+	`, &sqlitex.ExecOptions{
+		ResultFunc: scanKBEntries(&entries),
+	})
+	return entries, err
+}
+
+func scanKBEntries(entries *[]KBEntry) func(stmt *sqlite.Stmt) error {
+	return func(stmt *sqlite.Stmt) error {
+		*entries = append(*entries, KBEntry{
+			ID:         stmt.ColumnInt(0),
+			PNC:        stmt.ColumnText(1),
+			SubgroupID: stmt.ColumnText(2),
+			Title:      stmt.ColumnText(3),
+			Body:       stmt.ColumnText(4),
+			Source:     stmt.ColumnText(5),
+			UpdatedAt:  stmt.ColumnText(6),
+		})
+		return nil
+	}
+}
+
+// ImportKBEntries adds entries, or updates the body and source of those
+// already present with the same key and title, in one transaction. PNCs are
+// uppercased. Entries need a title and a PNC or subgroup. A dry run reports
+// the same result and changes nothing.
+func (d *DB) ImportKBEntries(entries []KBEntry, dryRun bool) (result KBImportResult, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err = withDryRun(d.conn, dryRun, func() error {
+		result, err = importKBEntries(d.conn, entries)
+		return err
+	})
+	return result, err
+}
+
+func importKBEntries(conn *sqlite.Conn, entries []KBEntry) (result KBImportResult, err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	for i, e := range entries {
+		e.PNC = strings.ToUpper(strings.TrimSpace(e.PNC))
+		e.SubgroupID = strings.TrimSpace(e.SubgroupID)
+		e.Title = strings.TrimSpace(e.Title)
+		if e.Title == "" || e.PNC == "" && e.SubgroupID == "" {
+			return result, fmt.Errorf("entry %d: needs a title and a pnc or subgroup", i+1)
+		}
+
+		var found, same bool
+		err = sqlitex.ExecuteTransient(conn, `
+			SELECT body = ?4 AND source = ?5 FROM kb_entries
+			WHERE pnc = ?1 AND subgroup_id = ?2 AND title = ?3
+		`, &sqlitex.ExecOptions{
+			Args: []any{e.PNC, e.SubgroupID, e.Title, e.Body, e.Source},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				found, same = true, stmt.ColumnBool(0)
+				return nil
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+
+		var outcome ImportOutcome
+		switch {
+		case same:
+			outcome.Action = ImportUnchanged
+			result.Unchanged++
+		case found:
+			outcome.Action = ImportUpdated
+			err = sqlitex.ExecuteTransient(conn, `
+				UPDATE kb_entries SET body = ?4, source = ?5, updated_at = CURRENT_TIMESTAMP
+				WHERE pnc = ?1 AND subgroup_id = ?2 AND title = ?3
+			`, &sqlitex.ExecOptions{Args: []any{e.PNC, e.SubgroupID, e.Title, e.Body, e.Source}})
+			result.Updated++
+		default:
+			outcome.Action = ImportAdded
+			err = sqlitex.ExecuteTransient(conn, `
+				INSERT INTO kb_entries (pnc, subgroup_id, title, body, source) VALUES (?, ?, ?, ?, ?)
+			`, &sqlitex.ExecOptions{Args: []any{e.PNC, e.SubgroupID, e.Title, e.Body, e.Source}})
+			result.Added++
+		}
+		if err != nil {
+			return result, err
+		}
+
+		var matched bool
+		err = sqlitex.ExecuteTransient(conn, `
+			SELECT 1 WHERE (?1 = '' OR EXISTS (SELECT 1 FROM parts WHERE UPPER(TRIM(pnc)) = ?1))
+				AND (?2 = '' OR EXISTS (SELECT 1 FROM subgroups WHERE id = ?2))
+		`, &sqlitex.ExecOptions{
+			Args: []any{e.PNC, e.SubgroupID},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				matched = true
+				return nil
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+		if !matched {
+			result.Unmatched++
+		}
+		outcome.Matched = matched
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+	return result, nil
+}
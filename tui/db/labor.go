@@ -0,0 +1,102 @@
+package db
+
+import (
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Labor is time spent working on a part, timed from part detail so a job's
+// hours add up in the journal. A session with no stopped_at is the running
+// timer; there is at most one. Times are UTC, like CURRENT_TIMESTAMP.
+const createLaborTable = `
+	CREATE TABLE IF NOT EXISTS labor (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		part_id INTEGER NOT NULL,
+		started_at TEXT NOT NULL,
+		stopped_at TEXT
+	)
+`
+
+// laborSeconds is the length of a labor session l in seconds, a running one
+// counting up to now
+const laborSeconds = `CAST(ROUND((julianday(COALESCE(l.stopped_at, 'now')) - julianday(l.started_at)) * 86400) AS INTEGER)`
+
+// LaborTimer is the running labor timer.
+type LaborTimer struct {
+	PartID     int
+	PartNumber string // empty if the part is no longer in the catalog
+	StartedAt  time.Time
+}
+
+// StartLabor starts timing work on a part, stopping the timer first if it
+// was running for another.
+func (d *DB) StartLabor(partID int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return startLabor(d.conn, partID)
+}
+
+func startLabor(conn *sqlite.Conn, partID int) (err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	if err = sqlitex.ExecuteTransient(conn, "UPDATE labor SET stopped_at = datetime('now') WHERE stopped_at IS NULL", nil); err != nil {
+		return err
+	}
+	return sqlitex.ExecuteTransient(conn, "INSERT INTO labor (part_id, started_at) VALUES (?, datetime('now'))", &sqlitex.ExecOptions{
+		Args: []any{partID},
+	})
+}
+
+// StopLabor stops the running timer and returns how long it ran, or 0 if it
+// wasn't running.
+func (d *DB) StopLabor() (time.Duration, error) {
+	var seconds int
+	err := d.execute("SELECT "+laborSeconds+" FROM labor l WHERE l.stopped_at IS NULL", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			seconds = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = d.execute("UPDATE labor SET stopped_at = datetime('now') WHERE stopped_at IS NULL", nil)
+	return time.Duration(seconds) * time.Second, err
+}
+
+// GetRunningLabor returns the running timer, or nil if none is running.
+func (d *DB) GetRunningLabor() (*LaborTimer, error) {
+	var timer *LaborTimer
+	err := d.execute(`
+		SELECT l.part_id, COALESCE(p.part_number, ''), l.started_at
+		FROM labor l
+		LEFT JOIN parts p ON p.id = l.part_id
+		WHERE l.stopped_at IS NULL
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			started, err := time.ParseInLocation(time.DateTime, stmt.ColumnText(2), time.UTC)
+			if err != nil {
+				return err
+			}
+			timer = &LaborTimer{PartID: stmt.ColumnInt(0), PartNumber: stmt.ColumnText(1), StartedAt: started}
+			return nil
+		},
+	})
+	return timer, err
+}
+
+// GetLaborTotal returns the time worked on a part, including the running
+// timer's.
+func (d *DB) GetLaborTotal(partID int) (time.Duration, error) {
+	var seconds int
+	err := d.execute("SELECT COALESCE(SUM("+laborSeconds+"), 0) FROM labor l WHERE l.part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			seconds = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	return time.Duration(seconds) * time.Second, err
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Origins record where the user knows a part number comes from: genuine
+// Mitsubishi, the OEM supplier who makes it for them, or aftermarket, with
+// the country of origin when known. Keyed by part number like dimensions, so
+// they survive re-scrapes.
+const createOriginsTable = `
+	CREATE TABLE IF NOT EXISTS part_origins (
+		part_number TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		country TEXT NOT NULL DEFAULT '',
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Origin sources, in the order the list filter cycles through them
+const (
+	SourceGenuine     = "genuine"
+	SourceOEM         = "oem"
+	SourceAftermarket = "aftermarket"
+)
+
+var OriginSources = []string{SourceGenuine, SourceOEM, SourceAftermarket}
+
+// sourceNames maps what can be typed for a source to its name
+var sourceNames = map[string]string{
+	"genuine":     SourceGenuine,
+	"mmc":         SourceGenuine,
+	"mitsubishi":  SourceGenuine,
+	"oem":         SourceOEM,
+	"aftermarket": SourceAftermarket,
+	"am":          SourceAftermarket,
+}
+
+// Origin is where a part number comes from.
+type Origin struct {
+	Source  string
+	Country string // empty when unknown
+}
+
+// SourceLabel names a source for display, e.g. "OEM".
+func SourceLabel(source string) string {
+	switch source {
+	case SourceGenuine:
+		return "Genuine MMC"
+	case SourceOEM:
+		return "OEM"
+	case SourceAftermarket:
+		return "Aftermarket"
+	}
+	return source
+}
+
+// String describes the origin, e.g. "OEM, Japan".
+func (o Origin) String() string {
+	if o.Country == "" {
+		return SourceLabel(o.Source)
+	}
+	return SourceLabel(o.Source) + ", " + o.Country
+}
+
+// Badge is the short tag lists show after a description, e.g. "[OEM JP]"
+// or "[GENUINE]".
+func (o Origin) Badge() string {
+	tag := strings.ToUpper(o.Source)
+	if o.Country != "" {
+		tag += " " + strings.ToUpper(o.Country)
+	}
+	return "[" + tag + "]"
+}
+
+// ParseOrigin reads an origin as typed on the part detail screen: a source,
+// optionally followed by a comma and the country, "oem, Japan". Empty input
+// gives nil, which forgets the origin.
+func ParseOrigin(input string) (*Origin, error) {
+	source, country, _ := strings.Cut(input, ",")
+	source = strings.TrimSpace(source)
+	country = strings.TrimSpace(country)
+	if source == "" {
+		if country != "" {
+			return nil, fmt.Errorf("write genuine, oem or aftermarket before the country")
+		}
+		return nil, nil
+	}
+	name, ok := sourceNames[strings.ToLower(source)]
+	if !ok {
+		return nil, fmt.Errorf("%q: origins are genuine, oem or aftermarket", source)
+	}
+	return &Origin{Source: name, Country: country}, nil
+}
+
+// FormatOrigin writes an origin back in the form ParseOrigin reads, for
+// editing.
+func FormatOrigin(o *Origin) string {
+	if o == nil {
+		return ""
+	}
+	if o.Country == "" {
+		return o.Source
+	}
+	return o.Source + ", " + o.Country
+}
+
+// GetOrigin returns the origin recorded for a part number, or nil.
+func (d *DB) GetOrigin(partNumber string) (*Origin, error) {
+	var origin *Origin
+	err := d.execute("SELECT source, country FROM part_origins WHERE part_number = ?", &sqlitex.ExecOptions{
+		Args: []any{strings.ToUpper(strings.TrimSpace(partNumber))},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			origin = &Origin{Source: stmt.ColumnText(0), Country: stmt.ColumnText(1)}
+			return nil
+		},
+	})
+	return origin, err
+}
+
+// GetOrigins returns every recorded origin by upper-case part number, for
+// badging lists.
+func (d *DB) GetOrigins() (map[string]Origin, error) {
+	origins := make(map[string]Origin)
+	err := d.execute("SELECT part_number, source, country FROM part_origins", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			origins[stmt.ColumnText(0)] = Origin{Source: stmt.ColumnText(1), Country: stmt.ColumnText(2)}
+			return nil
+		},
+	})
+	return origins, err
+}
+
+// SetOrigin records a part number's origin. nil forgets it.
+func (d *DB) SetOrigin(partNumber string, origin *Origin) error {
+	partNumber = strings.ToUpper(strings.TrimSpace(partNumber))
+	if origin == nil {
+		return d.execute("DELETE FROM part_origins WHERE part_number = ?", &sqlitex.ExecOptions{
+			Args: []any{partNumber},
+		})
+	}
+	if _, ok := sourceNames[origin.Source]; !ok {
+		return fmt.Errorf("unknown origin %s", origin.Source)
+	}
+	return d.execute(`
+		INSERT INTO part_origins (part_number, source, country) VALUES (?, ?, ?)
+		ON CONFLICT(part_number) DO UPDATE SET source = excluded.source, country = excluded.country, updated_at = CURRENT_TIMESTAMP
+	`, &sqlitex.ExecOptions{
+		Args: []any{partNumber, origin.Source, origin.Country},
+	})
+}
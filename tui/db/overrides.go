@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Catalog fields that can be overridden locally
+const (
+	FieldDescription    = "description"
+	FieldQuantity       = "quantity"
+	FieldNotes          = "notes"
+	FieldModelDateRange = "model_date_range"
+)
+
+// Overrides are keyed by part number and diagram (the catalog's natural key)
+// rather than part id, so they survive the scraper re-importing the catalog.
+const createOverridesTable = `
+	CREATE TABLE IF NOT EXISTS part_overrides (
+		part_number TEXT NOT NULL,
+		diagram_id TEXT NOT NULL,
+		field TEXT NOT NULL,
+		value TEXT,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (part_number, diagram_id, field)
+	)
+`
+
+// parts_effective is the parts table with local overrides applied. Read
+// queries select from it instead of parts. It is a TEMP view so it never
+// touches the schema the scraper manages.
+const createEffectivePartsView = `
+	CREATE TEMP VIEW IF NOT EXISTS parts_effective AS
+	SELECT p.id, p.detail_page_id, p.part_number, p.pnc,
+		   CASE WHEN od.field IS NULL THEN p.description ELSE od.value END AS description,
+		   p.ref_number,
+		   CASE WHEN oq.field IS NULL THEN p.quantity ELSE CAST(oq.value AS INTEGER) END AS quantity,
+		   p.spec,
+		   CASE WHEN onote.field IS NULL THEN p.notes ELSE onote.value END AS notes,
+		   p.color,
+		   CASE WHEN odr.field IS NULL THEN p.model_date_range ELSE odr.value END AS model_date_range,
+		   p.diagram_id, p.group_id, p.subgroup_id, p.replacement_part_number
+	FROM parts p
+	LEFT JOIN part_overrides od
+		ON od.part_number = p.part_number AND od.diagram_id = p.diagram_id AND od.field = 'description'
+	LEFT JOIN part_overrides oq
+		ON oq.part_number = p.part_number AND oq.diagram_id = p.diagram_id AND oq.field = 'quantity'
+	LEFT JOIN part_overrides onote
+		ON onote.part_number = p.part_number AND onote.diagram_id = p.diagram_id AND onote.field = 'notes'
+	LEFT JOIN part_overrides odr
+		ON odr.part_number = p.part_number AND odr.diagram_id = p.diagram_id AND odr.field = 'model_date_range'
+`
+
+// SetPartOverride stores a local value for a catalog field. A nil value
+// overrides the field to empty. Quantity values must be whole numbers.
+func (d *DB) SetPartOverride(partID int, field string, value *string) error {
+	var arg any
+	if value != nil {
+		arg = *value
+	}
+
+	switch field {
+	case FieldDescription, FieldNotes, FieldModelDateRange:
+	case FieldQuantity:
+		if value != nil {
+			qty, err := strconv.Atoi(strings.TrimSpace(*value))
+			if err != nil || qty < 0 {
+				return fmt.Errorf("quantity must be a whole number")
+			}
+			arg = strconv.Itoa(qty)
+		}
+	default:
+		return fmt.Errorf("field %q can't be overridden", field)
+	}
+
+	return d.executeTransient(`
+		INSERT INTO part_overrides (part_number, diagram_id, field, value)
+		SELECT part_number, diagram_id, ?, ? FROM parts WHERE id = ?
+		ON CONFLICT (part_number, diagram_id, field)
+		DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+	`, &sqlitex.ExecOptions{
+		Args: []any{field, arg, partID},
+	})
+}
+
+// RevertPartOverride removes a local override, restoring the catalog value.
+func (d *DB) RevertPartOverride(partID int, field string) error {
+	return d.executeTransient(`
+		DELETE FROM part_overrides
+		WHERE field = ?
+		  AND (part_number, diagram_id) = (SELECT part_number, diagram_id FROM parts WHERE id = ?)
+	`, &sqlitex.ExecOptions{
+		Args: []any{field, partID},
+	})
+}
+
+// GetOverriddenFields returns the set of fields overridden for a part.
+func (d *DB) GetOverriddenFields(partID int) (map[string]bool, error) {
+	fields := make(map[string]bool)
+	err := d.execute(`
+		SELECT o.field
+		FROM part_overrides o
+		JOIN parts p ON o.part_number = p.part_number AND o.diagram_id = p.diagram_id
+		WHERE p.id = ?
+	`, &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			fields[stmt.ColumnText(0)] = true
+			return nil
+		},
+	})
+	return fields, err
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"slices"
+	"strings"
+)
+
+// partNumberTemplates are the shapes of Mitsubishi part numbers: M, a
+// letter and six digits, MD050125, and four digits, a letter and three
+// digits, 1500A023, which newer parts are numbered in. M is literal, L any
+// letter and D any digit.
+var partNumberTemplates = []string{"MLDDDDDD", "DDDDLDDD"}
+
+// digitLookalikes are letters typed, or misread off a worn label, where a
+// digit goes
+var digitLookalikes = map[byte]byte{
+	'O': '0', 'Q': '0', 'D': '0',
+	'I': '1', 'L': '1',
+	'Z': '2',
+	'S': '5',
+	'G': '6',
+	'T': '7',
+	'B': '8',
+}
+
+// PartNumberCheck is what CheckPartNumber makes of a part number as typed.
+type PartNumberCheck struct {
+	Number     string   // normalized, as NormalizePartNumber gives it
+	Problem    string   // what's wrong with it, "" if it's in a Mitsubishi format
+	Malformed  bool     // it can't be a part number, so isn't worth looking up
+	Candidates []string // numbers in a Mitsubishi format it may have been meant as
+}
+
+// OK reports whether the number is in a Mitsubishi format.
+func (c PartNumberCheck) OK() bool {
+	return c.Problem == ""
+}
+
+// CheckPartNumber checks a part number against the Mitsubishi formats
+// without the catalog. Numbers in neither format aren't rejected, since
+// the catalog lists a few others, but get a problem and candidates: the
+// number with lookalike letters read as digits, a missing leading M added,
+// or a digit added or dropped. Mitsubishi numbers carry no check digit, so
+// well-formed ones get their adjacent digits swapped as candidates instead,
+// for when the number isn't in the catalog; SuggestPartNumbers keeps the
+// candidates that are.
+func CheckPartNumber(input string) PartNumberCheck {
+	c := PartNumberCheck{Number: NormalizePartNumber(input)}
+	n := c.Number
+	switch {
+	case n == "":
+		c.Problem, c.Malformed = "empty", true
+		return c
+	case strings.IndexFunc(n, func(r rune) bool { return !isPartNumberChar(r) && !isPartNumberPunct(r) }) >= 0:
+		c.Problem, c.Malformed = "has characters part numbers don't", true
+		return c
+	case strings.IndexFunc(n, isPartNumberPunct) >= 0:
+		// A full stop or slash copied along with the number
+		stripped := CheckPartNumber(strings.Map(func(r rune) rune {
+			if isPartNumberPunct(r) {
+				return -1
+			}
+			return r
+		}, n))
+		if stripped.Malformed {
+			c.Problem, c.Malformed = "has characters part numbers don't", true
+			return c
+		}
+		c.Problem = "has punctuation"
+		c.Candidates = append([]string{stripped.Number}, stripped.Candidates...)
+		return c
+	case strings.IndexFunc(n, isDigit) < 0:
+		c.Problem, c.Malformed = "has no digits", true
+		return c
+	case len(n) < 6:
+		c.Problem, c.Malformed = "too short", true
+		return c
+	}
+
+	for _, t := range partNumberTemplates {
+		if fitsTemplate(n, t) {
+			c.Candidates = transpositions(n, t)
+			return c
+		}
+	}
+
+	add := func(problem string, candidates ...string) {
+		if len(candidates) == 0 {
+			return
+		}
+		if c.Problem == "" {
+			c.Problem = problem
+		}
+		for _, candidate := range candidates {
+			if !slices.Contains(c.Candidates, candidate) {
+				c.Candidates = append(c.Candidates, candidate)
+			}
+		}
+	}
+	for _, t := range partNumberTemplates {
+		switch len(n) - len(t) {
+		case 0:
+			if fixed, ok := readAsTemplate(n, t); ok {
+				add("letter where a digit goes", fixed)
+			}
+		case -1:
+			if t[0] == 'M' && n[0] != 'M' {
+				if fixed, ok := readAsTemplate("M"+n, t); ok {
+					add("starts without the M", fixed)
+				}
+			}
+			add("a digit short", insertions(n, t)...)
+		case 1:
+			add("a digit too many", deletions(n, t)...)
+		}
+	}
+	if c.Problem == "" {
+		c.Problem = "not in a Mitsubishi format like MD050125 or 1500A023"
+	}
+	return c
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isPartNumberChar(r rune) bool {
+	return isDigit(r) || (r >= 'A' && r <= 'Z')
+}
+
+func isPartNumberPunct(r rune) bool {
+	return strings.ContainsRune("./_'\"", r)
+}
+
+// fitsTemplate reports whether n has the shape of template t as it is
+func fitsTemplate(n, t string) bool {
+	fixed, ok := readAsTemplate(n, t)
+	return ok && fixed == n
+}
+
+// readAsTemplate reads n in the shape of template t, taking lookalike
+// letters where t wants digits for the digits
+func readAsTemplate(n, t string) (string, bool) {
+	if len(n) != len(t) {
+		return "", false
+	}
+	b := []byte(n)
+	for i := range len(t) {
+		switch t[i] {
+		case 'M':
+			if b[i] != 'M' {
+				return "", false
+			}
+		case 'L':
+			if b[i] < 'A' || b[i] > 'Z' {
+				return "", false
+			}
+		case 'D':
+			if isDigit(rune(b[i])) {
+				continue
+			}
+			digit, ok := digitLookalikes[b[i]]
+			if !ok {
+				return "", false
+			}
+			b[i] = digit
+		}
+	}
+	return string(b), true
+}
+
+// transpositions returns n with each pair of neighboring digits swapped
+func transpositions(n, t string) []string {
+	var out []string
+	for i := 0; i+1 < len(n); i++ {
+		if t[i] != 'D' || t[i+1] != 'D' || n[i] == n[i+1] {
+			continue
+		}
+		b := []byte(n)
+		b[i], b[i+1] = b[i+1], b[i]
+		out = append(out, string(b))
+	}
+	return out
+}
+
+// insertions returns the numbers in template t that n is with a digit
+// dropped
+func insertions(n, t string) []string {
+	var out []string
+	for i := 0; i <= len(n); i++ {
+		for digit := byte('0'); digit <= '9'; digit++ {
+			candidate := n[:i] + string(digit) + n[i:]
+			if fixed, ok := readAsTemplate(candidate, t); ok && !slices.Contains(out, fixed) {
+				out = append(out, fixed)
+			}
+		}
+	}
+	return out
+}
+
+// deletions returns the numbers in template t that n is with a digit too
+// many
+func deletions(n, t string) []string {
+	var out []string
+	for i := range len(n) {
+		if !isDigit(rune(n[i])) {
+			continue
+		}
+		candidate := n[:i] + n[i+1:]
+		if fixed, ok := readAsTemplate(candidate, t); ok && !slices.Contains(out, fixed) {
+			out = append(out, fixed)
+		}
+	}
+	return out
+}
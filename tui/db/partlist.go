@@ -0,0 +1,137 @@
+package db
+
+import (
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// PartNumberMatch is the catalog part a part number from a user's list
+// resolves to.
+type PartNumberMatch struct {
+	PartID         int
+	PartNumber     string
+	Description    *string
+	ViaReplacement bool // the catalog only lists the number as a replacement for this part
+	Listings       int  // catalog listings of the number; the first is used
+}
+
+// NormalizePartNumber uppercases a part number and drops spaces and dashes,
+// which spreadsheets and supplier sites add freely.
+func NormalizePartNumber(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "\t", "").Replace(strings.TrimSpace(s)))
+}
+
+// FindPartNumber resolves a part number as written in a user's list. A
+// number the catalog only knows as a replacement resolves to the part it
+// replaces. ok is false when nothing matches.
+func (d *DB) FindPartNumber(input string) (match PartNumberMatch, ok bool, err error) {
+	number := NormalizePartNumber(input)
+	if number == "" {
+		return match, false, nil
+	}
+
+	for _, column := range []string{"part_number", "replacement_part_number"} {
+		err = d.execute(`
+			SELECT id, part_number, description, COUNT(*) OVER ()
+			FROM parts_effective
+			WHERE REPLACE(REPLACE(UPPER(`+column+`), '-', ''), ' ', '') = ?
+			ORDER BY id
+			LIMIT 1
+		`, &sqlitex.ExecOptions{
+			Args: []any{number},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				match = PartNumberMatch{
+					PartID:         stmt.ColumnInt(0),
+					PartNumber:     stmt.ColumnText(1),
+					Description:    nullableString(stmt, 2),
+					ViaReplacement: column == "replacement_part_number",
+					Listings:       stmt.ColumnInt(3),
+				}
+				ok = true
+				return nil
+			},
+		})
+		if err != nil || ok {
+			return match, ok, err
+		}
+	}
+	return match, false, nil
+}
+
+// SuggestPartNumbers returns the candidates of a checked part number that
+// the catalog lists, as part numbers or replacements, in the catalog's own
+// spelling.
+func (d *DB) SuggestPartNumbers(check PartNumberCheck) ([]string, error) {
+	if len(check.Candidates) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(check.Candidates))
+	for i, c := range check.Candidates {
+		args[i] = c
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+
+	var suggestions []string
+	err := d.execute(`
+		SELECT part_number FROM (
+			SELECT part_number AS part_number FROM parts_effective
+			UNION
+			SELECT replacement_part_number FROM parts_effective WHERE replacement_part_number IS NOT NULL
+		)
+		WHERE REPLACE(REPLACE(UPPER(part_number), '-', ''), ' ', '') IN (`+placeholders+`)
+		GROUP BY REPLACE(REPLACE(UPPER(part_number), '-', ''), ' ', '')
+		ORDER BY part_number
+	`, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			suggestions = append(suggestions, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	return suggestions, err
+}
+
+// PartNumberLookup is a part number as typed, checked for format and
+// resolved against the catalog.
+type PartNumberLookup struct {
+	Check       PartNumberCheck
+	Match       PartNumberMatch
+	Found       bool
+	Suggestions []string // catalog numbers it may have been meant as, when it isn't found
+}
+
+// LookupPartNumber checks a part number's format before resolving it with
+// FindPartNumber. Malformed numbers aren't looked up, and numbers that
+// aren't found get suggestions from the catalog.
+func (d *DB) LookupPartNumber(input string) (PartNumberLookup, error) {
+	l := PartNumberLookup{Check: CheckPartNumber(input)}
+	if l.Check.Malformed {
+		return l, nil
+	}
+	var err error
+	if l.Match, l.Found, err = d.FindPartNumber(input); err != nil || l.Found {
+		return l, err
+	}
+	l.Suggestions, err = d.SuggestPartNumbers(l.Check)
+	return l, err
+}
+
+// Hint says why a part number wasn't found and what it may have been meant
+// as, "letter where a digit goes; did you mean MD050125?", or "" if it was
+// found.
+func (l PartNumberLookup) Hint() string {
+	if l.Found {
+		return ""
+	}
+	hint := "not in the catalog"
+	if !l.Check.OK() {
+		hint = l.Check.Problem
+	}
+	if len(l.Suggestions) > 0 {
+		shown := l.Suggestions[:min(len(l.Suggestions), 3)]
+		hint += "; did you mean " + strings.Join(shown, " or ") + "?"
+	}
+	return hint
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Kinds of pinned items
+const (
+	PinGroup    = "group"
+	PinSubgroup = "subgroup"
+)
+
+const createPinsTable = `
+	CREATE TABLE IF NOT EXISTS pins (
+		kind TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (kind, target_id)
+	)
+`
+
+// GetPins returns pinned groups and subgroups in the order they were pinned.
+// Pins whose target no longer exists in the catalog are left out.
+func (d *DB) GetPins() ([]Pin, error) {
+	var pins []Pin
+	err := d.execute(`
+		SELECT p.kind, p.target_id,
+			   COALESCE(s.name, g.name), COALESCE(sg.id, g.id), COALESCE(sg.name, g.name)
+		FROM pins p
+		LEFT JOIN subgroups s ON p.kind = 'subgroup' AND s.id = p.target_id
+		LEFT JOIN groups sg ON sg.id = s.group_id
+		LEFT JOIN groups g ON p.kind = 'group' AND g.id = p.target_id
+		WHERE s.id IS NOT NULL OR g.id IS NOT NULL
+		ORDER BY p.created_at, p.rowid
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			pins = append(pins, Pin{
+				Kind:      stmt.ColumnText(0),
+				ID:        stmt.ColumnText(1),
+				Name:      stmt.ColumnText(2),
+				GroupID:   stmt.ColumnText(3),
+				GroupName: stmt.ColumnText(4),
+			})
+			return nil
+		},
+	})
+	return pins, err
+}
+
+func (d *DB) AddPin(kind, id string) error {
+	return d.executeTransient("INSERT OR IGNORE INTO pins (kind, target_id) VALUES (?, ?)", &sqlitex.ExecOptions{
+		Args: []any{kind, id},
+	})
+}
+
+func (d *DB) RemovePin(kind, id string) error {
+	return d.executeTransient("DELETE FROM pins WHERE kind = ? AND target_id = ?", &sqlitex.ExecOptions{
+		Args: []any{kind, id},
+	})
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// PNCMatch is a parts catalog number that starts with what was typed, with
+// the description most of its parts share.
+type PNCMatch struct {
+	PNC         string
+	Description *string
+	Parts       int
+	Diagrams    int
+}
+
+// PNCPart is one part carrying a PNC, with where it is listed.
+type PNCPart struct {
+	PartID         int
+	PartNumber     string
+	Description    *string
+	GroupName      string
+	DiagramName    string
+	ModelDateRange *string
+}
+
+// FindPNCs returns up to limit PNCs starting with prefix, ignoring case. An
+// exact match comes first, then shorter codes, so "11" lists 11 before 110.
+func (d *DB) FindPNCs(prefix string, limit int) ([]PNCMatch, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return nil, nil
+	}
+
+	var matches []PNCMatch
+	err := d.execute(`
+		SELECT p.pnc,
+			   (SELECT q.description FROM parts_effective q
+				WHERE q.pnc = p.pnc AND q.description IS NOT NULL
+				GROUP BY q.description ORDER BY COUNT(*) DESC, q.description LIMIT 1),
+			   COUNT(*), COUNT(DISTINCT p.diagram_id)
+		FROM parts_effective p
+		WHERE SUBSTR(UPPER(p.pnc), 1, LENGTH(?1)) = ?1
+		GROUP BY p.pnc
+		ORDER BY UPPER(p.pnc) = ?1 DESC, LENGTH(p.pnc), p.pnc
+		LIMIT ?2
+	`, &sqlitex.ExecOptions{
+		Args: []any{prefix, limit},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			matches = append(matches, PNCMatch{
+				PNC:         stmt.ColumnText(0),
+				Description: nullableString(stmt, 1),
+				Parts:       stmt.ColumnInt(2),
+				Diagrams:    stmt.ColumnInt(3),
+			})
+			return nil
+		},
+	})
+	return matches, err
+}
+
+// GetPartsForPNC returns every part carrying pnc, grouped by where it is
+// listed.
+func (d *DB) GetPartsForPNC(pnc string) ([]PNCPart, error) {
+	var parts []PNCPart
+	err := d.execute(`
+		SELECT p.id, p.part_number, p.description, g.name, dg.name, p.model_date_range
+		FROM parts_effective p
+		JOIN groups g ON p.group_id = g.id
+		JOIN diagrams dg ON p.diagram_id = dg.id
+		WHERE p.pnc = ?
+		ORDER BY g.name, dg.name, p.part_number
+	`, &sqlitex.ExecOptions{
+		Args: []any{pnc},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			parts = append(parts, PNCPart{
+				PartID:         stmt.ColumnInt(0),
+				PartNumber:     stmt.ColumnText(1),
+				Description:    nullableString(stmt, 2),
+				GroupName:      stmt.ColumnText(3),
+				DiagramName:    stmt.ColumnText(4),
+				ModelDateRange: nullableString(stmt, 5),
+			})
+			return nil
+		},
+	})
+	return parts, err
+}
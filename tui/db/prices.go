@@ -0,0 +1,257 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Prices are keyed by part number rather than part id, like overrides, so
+// they survive re-scrapes and apply to every diagram listing the number.
+// previous_price is the price before the last change in the same currency,
+// for the digest's price drops. availability and weight_grams come from
+// looking the part up on the vendor's site, which CSV imports don't give.
+const createPricesTable = `
+	CREATE TABLE IF NOT EXISTS prices (
+		supplier_id TEXT NOT NULL,
+		part_number TEXT NOT NULL,
+		price REAL NOT NULL,
+		previous_price REAL,
+		currency TEXT NOT NULL DEFAULT 'USD',
+		stock INTEGER,
+		lead_time_days INTEGER,
+		url TEXT,
+		availability TEXT,
+		weight_grams INTEGER,
+		updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (supplier_id, part_number)
+	)
+`
+
+// GetPrices returns price data for any of the given part numbers, cheapest
+// first. Pass a part's number and its replacement to see both.
+func (d *DB) GetPrices(partNumbers ...string) ([]Price, error) {
+	if len(partNumbers) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(partNumbers))
+	for i, pn := range partNumbers {
+		args[i] = pn
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(partNumbers)), ", ")
+
+	var prices []Price
+	err := d.execute(`
+		SELECT supplier_id, part_number, price, currency, stock, lead_time_days, url, updated_at,
+			availability, weight_grams
+		FROM prices
+		WHERE part_number IN (`+placeholders+`)
+		ORDER BY price, supplier_id
+	`, &sqlitex.ExecOptions{
+		Args: args,
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			prices = append(prices, Price{
+				SupplierID:   stmt.ColumnText(0),
+				PartNumber:   stmt.ColumnText(1),
+				Price:        stmt.ColumnFloat(2),
+				Currency:     stmt.ColumnText(3),
+				Stock:        nullableInt(stmt, 4),
+				LeadTimeDays: nullableInt(stmt, 5),
+				URL:          nullableString(stmt, 6),
+				UpdatedAt:    stmt.ColumnText(7),
+				Availability: nullableString(stmt, 8),
+				WeightGrams:  nullableInt(stmt, 9),
+			})
+			return nil
+		},
+	})
+	return prices, err
+}
+
+// PriceImportResult counts what ImportPrices changed.
+type PriceImportResult struct {
+	Added     int
+	Updated   int
+	Unchanged int // same price and details; the date is still refreshed
+
+	Actions []ImportAction // per price, in order
+}
+
+// SetPrice records a supplier's current price for a part number.
+func (d *DB) SetPrice(p Price) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return setPrice(d.conn, p)
+}
+
+// ImportPrices records prices in one transaction, saying of each whether
+// it's new, changed or the same as the one recorded. A dry run reports the
+// same result and changes nothing.
+func (d *DB) ImportPrices(prices []Price, dryRun bool) (result PriceImportResult, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err = withDryRun(d.conn, dryRun, func() error {
+		for _, p := range prices {
+			var found, same bool
+			err := sqlitex.ExecuteTransient(d.conn, `
+				SELECT price = ?3 AND currency = ?4 AND stock IS ?5 AND lead_time_days IS ?6 AND url IS ?7
+				FROM prices WHERE supplier_id = ?1 AND part_number = ?2
+			`, &sqlitex.ExecOptions{
+				Args: priceArgs(p)[:7],
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					found, same = true, stmt.ColumnBool(0)
+					return nil
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case same:
+				result.Unchanged++
+				result.Actions = append(result.Actions, ImportUnchanged)
+			case found:
+				result.Updated++
+				result.Actions = append(result.Actions, ImportUpdated)
+			default:
+				result.Added++
+				result.Actions = append(result.Actions, ImportAdded)
+			}
+			if err := setPrice(d.conn, p); err != nil {
+				return fmt.Errorf("%s %s: %w", p.SupplierID, p.PartNumber, err)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func setPrice(conn *sqlite.Conn, p Price) error {
+	return sqlitex.ExecuteTransient(conn, `
+		INSERT INTO prices (supplier_id, part_number, price, currency, stock, lead_time_days, url, updated_at,
+			availability, weight_grams)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP), ?, ?)
+		ON CONFLICT (supplier_id, part_number) DO UPDATE SET
+			previous_price = CASE
+				WHEN prices.price != excluded.price AND prices.currency = excluded.currency THEN prices.price
+				WHEN prices.currency != excluded.currency THEN NULL
+				ELSE prices.previous_price
+			END,
+			price = excluded.price, currency = excluded.currency, stock = excluded.stock,
+			lead_time_days = excluded.lead_time_days, url = excluded.url, updated_at = excluded.updated_at,
+			availability = excluded.availability, weight_grams = excluded.weight_grams
+	`, &sqlitex.ExecOptions{Args: priceArgs(p)})
+}
+
+// priceArgs are p's columns in table order, nil for unset optional ones
+func priceArgs(p Price) []any {
+	var url any
+	if p.URL != nil {
+		url = *p.URL
+	}
+	var stock, leadTime any
+	if p.Stock != nil {
+		stock = *p.Stock
+	}
+	if p.LeadTimeDays != nil {
+		leadTime = *p.LeadTimeDays
+	}
+	var availability, weight any
+	if p.Availability != nil {
+		availability = *p.Availability
+	}
+	if p.WeightGrams != nil {
+		weight = *p.WeightGrams
+	}
+	return []any{p.SupplierID, p.PartNumber, p.Price, p.Currency, stock, leadTime, url, p.UpdatedAt, availability, weight}
+}
+
+// addedPriceColumns are the prices columns added after the table was first
+// made, in the order they came
+var addedPriceColumns = []struct{ name, decl string }{
+	{"previous_price", "REAL"},
+	{"availability", "TEXT"},
+	{"weight_grams", "INTEGER"},
+}
+
+// addPriceColumns adds the columns a prices table made before them lacks
+func addPriceColumns(conn *sqlite.Conn) error {
+	have := make(map[string]bool)
+	err := sqlitex.ExecuteTransient(conn, "SELECT name FROM pragma_table_info('prices')", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			have[stmt.ColumnText(0)] = true
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, col := range addedPriceColumns {
+		if have[col.name] {
+			continue
+		}
+		if err := sqlitex.ExecuteTransient(conn, "ALTER TABLE prices ADD COLUMN "+col.name+" "+col.decl, nil); err != nil {
+			return fmt.Errorf("%s: %w", col.name, err)
+		}
+	}
+	return nil
+}
+
+// viewPriceColumns stands in for addPriceColumns on a read-only connection:
+// when the prices table was made before some of its columns, a temporary
+// view of the same name adds them as NULL for this connection only
+func viewPriceColumns(conn *sqlite.Conn) error {
+	have := make(map[string]bool)
+	err := sqlitex.ExecuteTransient(conn, "SELECT name FROM pragma_table_info('prices')", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			have[stmt.ColumnText(0)] = true
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, col := range addedPriceColumns {
+		if !have[col.name] {
+			missing = append(missing, "NULL AS "+col.name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return sqlitex.ExecuteTransient(conn, "CREATE TEMP VIEW prices AS SELECT *, "+strings.Join(missing, ", ")+" FROM main.prices", nil)
+}
+
+// GetPriceDrops returns the supplier prices of bookmarked parts that fell
+// at their last change on or after a "YYYY-MM-DD" date, biggest fall first.
+func (d *DB) GetPriceDrops(since string) ([]PriceDrop, error) {
+	var drops []PriceDrop
+	err := d.execute(`
+		SELECT p.id, p.part_number, p.description, pr.supplier_id, pr.price, pr.previous_price, pr.currency, pr.updated_at
+		FROM bookmarks b
+		JOIN parts_effective p ON p.id = b.part_id
+		JOIN prices pr ON pr.part_number = p.part_number
+		WHERE pr.previous_price > pr.price AND pr.updated_at >= ?
+		ORDER BY (pr.previous_price - pr.price) / pr.previous_price DESC, p.part_number, pr.supplier_id
+	`, &sqlitex.ExecOptions{
+		Args: []any{since},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			drops = append(drops, PriceDrop{
+				PartID:        stmt.ColumnInt(0),
+				PartNumber:    stmt.ColumnText(1),
+				Description:   nullableString(stmt, 2),
+				SupplierID:    stmt.ColumnText(3),
+				Price:         stmt.ColumnFloat(4),
+				PreviousPrice: stmt.ColumnFloat(5),
+				Currency:      stmt.ColumnText(6),
+				UpdatedAt:     stmt.ColumnText(7),
+			})
+			return nil
+		},
+	})
+	return drops, err
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// When, how many and for how much a part on the shelf was bought, one
+// record per part. Cost is what was paid in total, in the currency it was
+// paid in.
+const createPurchasesTable = `
+	CREATE TABLE IF NOT EXISTS purchases (
+		part_id INTEGER PRIMARY KEY,
+		purchased_on TEXT NOT NULL,
+		cost REAL,
+		currency TEXT NOT NULL DEFAULT '',
+		quantity INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Purchase records buying a part. Currency is empty when it wasn't given;
+// a Qty under 1 is stored as 1.
+type Purchase struct {
+	PartID      int
+	PurchasedOn string // YYYY-MM-DD
+	Cost        *float64
+	Currency    string
+	Qty         int
+}
+
+// addPurchaseQty adds the quantity column to a purchases table made before
+// it existed
+func addPurchaseQty(conn *sqlite.Conn) error {
+	var found bool
+	err := sqlitex.ExecuteTransient(conn, "SELECT 1 FROM pragma_table_info('purchases') WHERE name = 'quantity'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			found = true
+			return nil
+		},
+	})
+	if err != nil || found {
+		return err
+	}
+	return sqlitex.ExecuteTransient(conn, "ALTER TABLE purchases ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1", nil)
+}
+
+// ShelfItem is a part on hand: bookmarked, or with a purchase recorded.
+// Since is the purchase date or, without one, the day it was bookmarked.
+type ShelfItem struct {
+	PartID      int
+	PartNumber  string // empty if the part is no longer in the catalog
+	Description *string
+	Since       string // YYYY-MM-DD
+	Purchased   bool   // Since is a recorded purchase date
+	Cost        *float64
+	Currency    string
+}
+
+// SetPurchase records or replaces the purchase of a part.
+func (d *DB) SetPurchase(p Purchase) error {
+	var cost any
+	if p.Cost != nil {
+		cost = *p.Cost
+	}
+	return d.execute(`
+		INSERT INTO purchases (part_id, purchased_on, cost, currency, quantity) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(part_id) DO UPDATE SET purchased_on = excluded.purchased_on, cost = excluded.cost,
+			currency = excluded.currency, quantity = excluded.quantity
+	`, &sqlitex.ExecOptions{
+		Args: []any{p.PartID, p.PurchasedOn, cost, strings.ToUpper(p.Currency), max(p.Qty, 1)},
+	})
+}
+
+// RemovePurchase forgets the purchase of a part.
+func (d *DB) RemovePurchase(partID int) error {
+	return d.execute("DELETE FROM purchases WHERE part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{partID},
+	})
+}
+
+// GetPurchase returns the purchase of a part, or nil if none is recorded.
+func (d *DB) GetPurchase(partID int) (*Purchase, error) {
+	var p *Purchase
+	err := d.execute(`
+		SELECT part_id, purchased_on, cost, currency, quantity FROM purchases WHERE part_id = ?
+	`, &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			p = &Purchase{
+				PartID:      stmt.ColumnInt(0),
+				PurchasedOn: stmt.ColumnText(1),
+				Currency:    stmt.ColumnText(3),
+				Qty:         stmt.ColumnInt(4),
+			}
+			if stmt.ColumnType(2) != sqlite.TypeNull {
+				cost := stmt.ColumnFloat(2)
+				p.Cost = &cost
+			}
+			return nil
+		},
+	})
+	return p, err
+}
+
+// PartQty sets a part's catalog quantity against how many of its number are
+// wanted and already bought. Needed is the qty of its number's bookmarks
+// and Have the quantity of its number's recorded purchases; Needed is 0
+// when it isn't bookmarked.
+type PartQty struct {
+	Catalog *int // per vehicle, as the diagram lists it
+	Needed  int
+	Have    int
+}
+
+// ToGet is how many more are needed than are on the shelf.
+func (q PartQty) ToGet() int {
+	return max(q.Needed-q.Have, 0)
+}
+
+// GetPartQty returns a part's catalog, needed and bought quantities, or nil
+// for a part not in the catalog.
+func (d *DB) GetPartQty(partID int) (*PartQty, error) {
+	var q *PartQty
+	err := d.execute(`
+		SELECT p.quantity,
+			(SELECT COALESCE(SUM(b.qty), 0) FROM bookmarks b
+				JOIN parts_effective o ON o.id = b.part_id WHERE o.part_number = p.part_number),
+			(SELECT COALESCE(SUM(pu.quantity), 0) FROM purchases pu
+				JOIN parts_effective o ON o.id = pu.part_id WHERE o.part_number = p.part_number)
+		FROM parts_effective p WHERE p.id = ?
+	`, &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			q = &PartQty{
+				Catalog: nullableInt(stmt, 0),
+				Needed:  stmt.ColumnInt(1),
+				Have:    stmt.ColumnInt(2),
+			}
+			return nil
+		},
+	})
+	return q, err
+}
+
+// GetShelf returns every bookmarked or purchased part, longest held first.
+func (d *DB) GetShelf() ([]ShelfItem, error) {
+	var items []ShelfItem
+	err := d.execute(`
+		WITH held AS (
+			SELECT part_id FROM bookmarks
+			UNION
+			SELECT part_id FROM purchases
+		)
+		SELECT h.part_id, COALESCE(p.part_number, ''), p.description,
+			COALESCE(pu.purchased_on, DATE(b.created_at)), pu.part_id IS NOT NULL,
+			pu.cost, COALESCE(pu.currency, '')
+		FROM held h
+		LEFT JOIN parts_effective p ON p.id = h.part_id
+		LEFT JOIN purchases pu ON pu.part_id = h.part_id
+		LEFT JOIN bookmarks b ON b.part_id = h.part_id
+		ORDER BY 4, 2
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			item := ShelfItem{
+				PartID:     stmt.ColumnInt(0),
+				PartNumber: stmt.ColumnText(1),
+				Since:      stmt.ColumnText(3),
+				Purchased:  stmt.ColumnBool(4),
+				Currency:   stmt.ColumnText(6),
+			}
+			if stmt.ColumnType(2) != sqlite.TypeNull {
+				desc := stmt.ColumnText(2)
+				item.Description = &desc
+			}
+			if stmt.ColumnType(5) != sqlite.TypeNull {
+				cost := stmt.ColumnFloat(5)
+				item.Cost = &cost
+			}
+			items = append(items, item)
+			return nil
+		},
+	})
+	return items, err
+}
+
+// ParsePurchase reads a purchase from fields like "2024-03-01 45.00 NZD x2":
+// a date, then optionally the cost and its currency, and how many were
+// bought as "x2" anywhere after the date. A leading currency symbol on the
+// cost is dropped. ok is false when no field is a date.
+func ParsePurchase(fields []string) (p Purchase, ok bool, err error) {
+	i := 0
+	for ; i < len(fields); i++ {
+		if _, err := time.Parse("2006-01-02", fields[i]); err == nil {
+			break
+		}
+	}
+	if i == len(fields) {
+		return p, false, nil
+	}
+	p.PurchasedOn = fields[i]
+
+	var rest []string
+	for _, f := range fields[i+1:] {
+		n, isQty := strings.CutPrefix(strings.ToLower(f), "x")
+		if !isQty {
+			n, isQty = strings.CutPrefix(f, "×")
+		}
+		if !isQty {
+			rest = append(rest, f)
+			continue
+		}
+		qty, err := strconv.Atoi(n)
+		if err != nil || qty < 1 {
+			return p, true, fmt.Errorf("quantity must be like x2, got %q", f)
+		}
+		p.Qty = qty
+	}
+	if len(rest) > 0 {
+		amount := strings.TrimLeftFunc(rest[0], func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+		cost, err := strconv.ParseFloat(amount, 64)
+		if err != nil || cost < 0 {
+			return p, true, fmt.Errorf("cost must be a number, got %q", rest[0])
+		}
+		p.Cost = &cost
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		currency := strings.ToUpper(rest[0])
+		if len(currency) != 3 || strings.ContainsFunc(currency, func(r rune) bool { return r < 'A' || r > 'Z' }) {
+			return p, true, fmt.Errorf("currency must be a code like USD, got %q", rest[0])
+		}
+		p.Currency = currency
+	}
+	return p, true, nil
+}
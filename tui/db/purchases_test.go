@@ -0,0 +1,60 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+func TestGetPartQtySumsPurchases(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.AddBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetBookmarkQty(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	purchase, ok, err := db.ParsePurchase([]string{"2024-03-01", "45.00", "NZD", "x3"})
+	if err != nil || !ok {
+		t.Fatalf("ParsePurchase = %v, %v", ok, err)
+	}
+	purchase.PartID = 1
+	if err := database.SetPurchase(purchase); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := database.GetPartQty(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Needed != 4 || q.Have != 3 || q.ToGet() != 1 {
+		t.Errorf("qty = %+v, to get %d; want 4 needed, 3 had, 1 to get", q, q.ToGet())
+	}
+	if p, _ := database.GetPurchase(1); p == nil || p.Qty != 3 {
+		t.Errorf("purchase = %+v, want 3 bought", p)
+	}
+}
+
+func TestOpenAddsPurchaseQty(t *testing.T) {
+	// A purchase recorded before quantities were
+	c := dbtest.New(t)
+	sampleParts(c)
+	c.Exec(`CREATE TABLE purchases (
+		part_id INTEGER PRIMARY KEY,
+		purchased_on TEXT NOT NULL,
+		cost REAL,
+		currency TEXT NOT NULL DEFAULT '',
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)`)
+	c.Exec("INSERT INTO purchases (part_id, purchased_on) VALUES (1, '2023-01-15')")
+	database := c.Open()
+
+	q, err := database.GetPartQty(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Have != 1 {
+		t.Errorf("have %d, want the 1 from before", q.Have)
+	}
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SearchQuery is a search box query translated to FTS5 syntax.
+//
+// Words are ANDed together and prefix-matched. OR between words makes
+// alternatives, binding looser than AND, and a leading minus excludes a
+// word: "belt tensioner OR pulley -timing". Double quotes match a phrase.
+// Every word is quoted in the FTS query, so punctuation in part numbers
+// can't be misread as FTS syntax. A metric thread or a dimension and size
+// filters by the dimensions recorded for parts instead of matching text:
+// "bolt M8x1.25 length:20-30", and an attribute and value by what the
+// part's spec gives: "mirror drive:4wd roof:high".
+type SearchQuery struct {
+	FTS   string            // FTS5 MATCH expression, empty if there is nothing to match
+	Dims  []DimensionFilter // all must pass
+	Attrs []AttributeFilter // all must pass
+
+	// Interpretation, for showing how the query was read
+	Any  [][]string // alternatives, each a list of words that must all match
+	None []string   // excluded words
+}
+
+// ParseSearchQuery reads the search box syntax. It returns an error when
+// the query only excludes words, since FTS5 needs something to match.
+func ParseSearchQuery(input string) (SearchQuery, error) {
+	var q SearchQuery
+	var group []string
+
+	for _, tok := range tokenizeQuery(input) {
+		switch {
+		case !tok.quoted && tok.text == "OR":
+			if len(group) > 0 {
+				q.Any = append(q.Any, group)
+				group = nil
+			}
+		case !tok.quoted && tok.text == "AND":
+			// Words are ANDed anyway
+		case tok.exclude:
+			q.None = append(q.None, tok.text)
+		case !tok.quoted && isDimensionFilter(tok.text, &q.Dims):
+		case !tok.quoted && isAttributeFilter(tok.text, &q.Attrs):
+		default:
+			group = append(group, tok.text)
+		}
+	}
+	if len(group) > 0 {
+		q.Any = append(q.Any, group)
+	}
+
+	if len(q.Any) == 0 {
+		if len(q.None) > 0 {
+			return q, fmt.Errorf("add a word to search for, not only exclusions")
+		}
+		return q, nil
+	}
+
+	var alternatives []string
+	for _, g := range q.Any {
+		terms := make([]string, len(g))
+		for i, word := range g {
+			terms[i] = ftsTerm(word)
+		}
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	q.FTS = strings.Join(alternatives, " OR ")
+
+	if len(q.None) > 0 {
+		excluded := make([]string, len(q.None))
+		for i, word := range q.None {
+			excluded[i] = ftsTerm(word)
+		}
+		q.FTS = "(" + q.FTS + ") NOT (" + strings.Join(excluded, " OR ") + ")"
+	}
+	return q, nil
+}
+
+// Explain describes the query as parsed, e.g.
+// "belt AND tensioner OR pulley, excluding timing".
+func (q SearchQuery) Explain() string {
+	var alternatives []string
+	for _, g := range q.Any {
+		alternatives = append(alternatives, strings.Join(g, " AND "))
+	}
+	s := strings.Join(alternatives, " OR ")
+	if len(q.None) > 0 {
+		s += ", excluding " + strings.Join(q.None, ", ")
+	}
+	var filters []string
+	for _, f := range q.Dims {
+		filters = append(filters, f.String())
+	}
+	for _, f := range q.Attrs {
+		filters = append(filters, f.String())
+	}
+	if len(filters) > 0 {
+		if s != "" {
+			s += ", "
+		}
+		s += "with " + strings.Join(filters, ", ")
+	}
+	return s
+}
+
+// HasFilters reports whether the query filters on dimensions or attributes.
+func (q SearchQuery) HasFilters() bool {
+	return len(q.Dims) > 0 || len(q.Attrs) > 0
+}
+
+// isDimensionFilter adds the filters a word stands for to dims, reporting
+// whether it was one
+func isDimensionFilter(word string, dims *[]DimensionFilter) bool {
+	filters, ok := parseDimensionFilters(word)
+	*dims = append(*dims, filters...)
+	return ok
+}
+
+// isAttributeFilter adds the filter a word stands for to attrs, reporting
+// whether it was one
+func isAttributeFilter(word string, attrs *[]AttributeFilter) bool {
+	filter, ok := parseAttributeFilter(word)
+	if ok {
+		*attrs = append(*attrs, filter)
+	}
+	return ok
+}
+
+type queryToken struct {
+	text    string
+	quoted  bool
+	exclude bool
+}
+
+// tokenizeQuery splits on whitespace, keeping double-quoted phrases
+// together. An unterminated quote runs to the end of the input.
+func tokenizeQuery(input string) []queryToken {
+	var tokens []queryToken
+	rest := strings.TrimSpace(input)
+	for rest != "" {
+		var tok queryToken
+		if strings.HasPrefix(rest, "-") {
+			tok.exclude = true
+			rest = rest[1:]
+		}
+		if strings.HasPrefix(rest, `"`) {
+			tok.quoted = true
+			rest = rest[1:]
+			end := strings.Index(rest, `"`)
+			if end < 0 {
+				end = len(rest)
+			}
+			tok.text = strings.TrimSpace(rest[:end])
+			rest = rest[min(end+1, len(rest)):]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			tok.text = rest[:end]
+			rest = rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+		if tok.text != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// ftsTerm quotes a word or phrase as an FTS5 string, prefix-matching its
+// last token. Embedded quotes are doubled, per FTS5 string syntax.
+func ftsTerm(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"*`
+}
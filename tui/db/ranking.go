@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// ftsWeights are the bm25 weights for each parts_fts column. A hit on a part
+// number or PNC is a far stronger signal than a word buried in notes or spec
+// text. Columns not listed weigh 1.
+var ftsWeights = map[string]float64{
+	"part_number":  10,
+	"pnc":          8,
+	"description":  4,
+	"search_terms": 2,
+	"spec":         1,
+	"notes":        1,
+}
+
+// loadFTSColumns returns the parts_fts columns in index order. Databases
+// scraped before the index covered pnc, spec and notes have fewer columns.
+func loadFTSColumns(conn *sqlite.Conn) ([]string, error) {
+	var cols []string
+	err := sqlitex.ExecuteTransient(conn, "SELECT name FROM pragma_table_info('parts_fts') ORDER BY cid", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			cols = append(cols, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	return cols, err
+}
+
+// rankSQL returns the weighted bm25() expression for the FTS columns, followed
+// by one highlight() expression per column used to tell which columns matched.
+func rankSQL(columns []string) (bm25 string, highlights []string) {
+	weights := make([]string, len(columns))
+	for i, col := range columns {
+		w, ok := ftsWeights[col]
+		if !ok {
+			w = 1
+		}
+		weights[i] = fmt.Sprintf("%g", w)
+		highlights = append(highlights, fmt.Sprintf("highlight(parts_fts, %d, char(1), char(2))", i))
+	}
+	bm25 = "bm25(parts_fts"
+	if len(weights) > 0 {
+		bm25 += ", " + strings.Join(weights, ", ")
+	}
+	return bm25 + ")", highlights
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// historyLimit is how many searches and viewed parts are remembered
+const historyLimit = 50
+
+// Searches that led to a part and parts opened, for the search screen's
+// empty state, backed up with the user data. Times have milliseconds so
+// quick successive entries keep their order.
+const createHistoryTables = `
+	CREATE TABLE IF NOT EXISTS search_history (
+		query TEXT PRIMARY KEY,
+		searched_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS part_views (
+		part_id INTEGER PRIMARY KEY,
+		views INTEGER NOT NULL DEFAULT 1,
+		viewed_at TEXT NOT NULL
+	);
+`
+
+// AddRecentSearch records query as the latest search, forgetting the oldest
+// beyond historyLimit.
+func (d *DB) AddRecentSearch(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	err := d.executeTransient(`
+		INSERT INTO search_history (query, searched_at)
+		VALUES (?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		ON CONFLICT (query) DO UPDATE SET searched_at = excluded.searched_at
+	`, &sqlitex.ExecOptions{Args: []any{query}})
+	if err != nil {
+		return err
+	}
+	return d.executeTransient(`
+		DELETE FROM search_history WHERE query NOT IN (
+			SELECT query FROM search_history ORDER BY searched_at DESC LIMIT ?
+		)
+	`, &sqlitex.ExecOptions{Args: []any{historyLimit}})
+}
+
+// GetRecentSearches returns up to limit searches, latest first.
+func (d *DB) GetRecentSearches(limit int) ([]string, error) {
+	var queries []string
+	err := d.execute("SELECT query FROM search_history ORDER BY searched_at DESC LIMIT ?", &sqlitex.ExecOptions{
+		Args: []any{limit},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			queries = append(queries, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	return queries, err
+}
+
+// AddPartView records that a part was opened, forgetting the least recently
+// viewed beyond historyLimit.
+func (d *DB) AddPartView(partID int) error {
+	err := d.executeTransient(`
+		INSERT INTO part_views (part_id, viewed_at)
+		VALUES (?, strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		ON CONFLICT (part_id) DO UPDATE SET views = views + 1, viewed_at = excluded.viewed_at
+	`, &sqlitex.ExecOptions{Args: []any{partID}})
+	if err != nil {
+		return err
+	}
+	return d.executeTransient(`
+		DELETE FROM part_views WHERE part_id NOT IN (
+			SELECT part_id FROM part_views ORDER BY viewed_at DESC LIMIT ?
+		)
+	`, &sqlitex.ExecOptions{Args: []any{historyLimit}})
+}
+
+// GetRecentParts returns up to limit recently opened parts, latest first.
+// Parts no longer in the catalog are left out.
+func (d *DB) GetRecentParts(limit int) ([]RecentPart, error) {
+	var parts []RecentPart
+	err := d.execute(`
+		SELECT v.part_id, p.part_number, p.description, g.name, s.name, v.views, v.viewed_at
+		FROM part_views v
+		JOIN parts_effective p ON p.id = v.part_id
+		JOIN groups g ON g.id = p.group_id
+		LEFT JOIN subgroups s ON s.id = p.subgroup_id
+		ORDER BY v.viewed_at DESC
+		LIMIT ?
+	`, &sqlitex.ExecOptions{
+		Args: []any{limit},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			parts = append(parts, RecentPart{
+				PartID:       stmt.ColumnInt(0),
+				PartNumber:   stmt.ColumnText(1),
+				Description:  nullableString(stmt, 2),
+				GroupName:    stmt.ColumnText(3),
+				SubgroupName: nullableString(stmt, 4),
+				Views:        stmt.ColumnInt(5),
+				ViewedAt:     stmt.ColumnText(6),
+			})
+			return nil
+		},
+	})
+	return parts, err
+}
+
+// GetMostBookmarkedGroups returns up to limit groups by how many bookmarked
+// parts they hold, most first. Groups without bookmarks are left out.
+func (d *DB) GetMostBookmarkedGroups(limit int) ([]BookmarkedGroup, error) {
+	var groups []BookmarkedGroup
+	err := d.execute(`
+		SELECT g.id, g.name, COUNT(*) AS n
+		FROM bookmarks b
+		JOIN parts_effective p ON p.id = b.part_id
+		JOIN groups g ON g.id = p.group_id
+		GROUP BY g.id
+		ORDER BY n DESC, g.name
+		LIMIT ?
+	`, &sqlitex.ExecOptions{
+		Args: []any{limit},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			groups = append(groups, BookmarkedGroup{
+				ID:        stmt.ColumnText(0),
+				Name:      stmt.ColumnText(1),
+				Bookmarks: stmt.ColumnInt(2),
+			})
+			return nil
+		},
+	})
+	return groups, err
+}
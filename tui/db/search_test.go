@@ -0,0 +1,93 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+func partNumbers(results []db.SearchResult) []string {
+	numbers := make([]string, len(results))
+	for i, r := range results {
+		numbers[i] = r.PartNumber
+	}
+	return numbers
+}
+
+func TestSearchParts(t *testing.T) {
+	database := dbtest.Sample(t)
+
+	tests := []struct {
+		query string
+		want  []string // in order when first is set, else any order
+		first string
+	}{
+		{query: "MD050125", want: []string{"MD050125"}},
+		{query: "cambelt", want: []string{"MD050125"}},
+		{query: "oil", want: []string{"ME013307", "MD069782"}},
+		{query: "tensioner", want: []string{"MD329470", "MD360806"}},
+		{query: "belt", first: "MD050125", want: []string{"MD050125", "MD329470", "MD360806"}},
+		{query: "nothing-like-this"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := database.SearchParts(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := partNumbers(results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("found %v, want %v", got, tt.want)
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, g := range got {
+					found = found || g == want
+				}
+				if !found {
+					t.Errorf("found %v, want %s among them", got, want)
+				}
+			}
+			if tt.first != "" && got[0] != tt.first {
+				t.Errorf("found %v, want %s first", got, tt.first)
+			}
+		})
+	}
+}
+
+func TestSearchPartsOverriddenText(t *testing.T) {
+	database := dbtest.Sample(t)
+	description := "GASKET,ROCKER COVER"
+	if err := database.SetPartOverride(6, db.FieldDescription, &description); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := database.SearchParts("gasket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := partNumbers(results); len(got) != 1 || got[0] != "MB430997" {
+		t.Errorf("gasket found %v, want the part it overrides", got)
+	}
+
+	// The catalog's own text no longer matches
+	results, err = database.SearchParts("lwr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := partNumbers(results); len(got) != 1 || got[0] != "MB430998" {
+		t.Errorf("lwr found %v, want only the arm not overridden", got)
+	}
+
+	// Back to the catalog's text once the override is reverted
+	if err := database.RevertPartOverride(6, db.FieldDescription); err != nil {
+		t.Fatal(err)
+	}
+	if results, err = database.SearchParts("gasket"); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("gasket found %v after the override was reverted", partNumbers(results))
+	}
+}
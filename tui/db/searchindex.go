@@ -0,0 +1,204 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// ErrNoSearchIndex is returned by SearchParts for catalogs without parts_fts,
+// such as older exports. BuildSearchIndex creates it.
+var ErrNoSearchIndex = errors.New("the catalog has no search index")
+
+// searchIndexColumns are the parts columns parts_fts indexes, in the same
+// order as the scraper (FTS_COLUMNS in scraper/src/db/schema.ts)
+var searchIndexColumns = []string{"part_number", "pnc", "description", "search_terms", "spec", "notes"}
+
+// HasSearchIndex reports whether the catalog has parts_fts for searching.
+func (d *DB) HasSearchIndex() bool {
+	return len(d.searchColumns()) > 0
+}
+
+// searchColumns returns the parts_fts columns, which BuildSearchIndex can
+// change while the TUI runs
+func (d *DB) searchColumns() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ftsColumns
+}
+
+// BuildSearchIndex creates parts_fts from the parts table, with the triggers
+// that keep it current, as the scraper would have, then indexes local
+// overrides. Columns the parts table lacks are left out.
+func (d *DB) BuildSearchIndex() (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer sqlitex.Save(d.conn)(&err)
+
+	var partsColumns []string
+	err = sqlitex.ExecuteTransient(d.conn, "SELECT name FROM pragma_table_info('parts')", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			partsColumns = append(partsColumns, stmt.ColumnText(0))
+			return nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	var cols []string
+	for _, col := range searchIndexColumns {
+		if slices.Contains(partsColumns, col) {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("parts table has none of the searchable columns")
+	}
+
+	list := strings.Join(cols, ", ")
+	prefixed := func(prefix string) string {
+		values := make([]string, len(cols))
+		for i, col := range cols {
+			values[i] = prefix + col
+		}
+		return strings.Join(values, ", ")
+	}
+	for _, query := range []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS parts_fts USING fts5(%s, content='parts', content_rowid='id')", list),
+		// The rebuild indexes parts as scraped, so any triggers applying
+		// overrides go until indexOverrides puts them back
+		"DROP TRIGGER IF EXISTS parts_ai",
+		"DROP TRIGGER IF EXISTS parts_ad",
+		"DROP TRIGGER IF EXISTS parts_au",
+		"INSERT INTO parts_fts(parts_fts) VALUES ('rebuild')",
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS parts_ai AFTER INSERT ON parts BEGIN
+			INSERT INTO parts_fts(rowid, %s) VALUES (new.id, %s);
+		END`, list, prefixed("new.")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS parts_ad AFTER DELETE ON parts BEGIN
+			INSERT INTO parts_fts(parts_fts, rowid, %s) VALUES ('delete', old.id, %s);
+		END`, list, prefixed("old.")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS parts_au AFTER UPDATE ON parts BEGIN
+			INSERT INTO parts_fts(parts_fts, rowid, %s) VALUES ('delete', old.id, %s);
+			INSERT INTO parts_fts(rowid, %s) VALUES (new.id, %s);
+		END`, list, prefixed("old."), list, prefixed("new.")),
+	} {
+		if err = sqlitex.ExecuteTransient(d.conn, query, nil); err != nil {
+			return fmt.Errorf("build search index: %w", err)
+		}
+	}
+
+	if d.ftsColumns, err = loadFTSColumns(d.conn); err != nil {
+		return err
+	}
+	return indexOverrides(d.conn, d.ftsColumns)
+}
+
+// indexedValue is the SQL for the text parts_fts indexes in col for the
+// parts row alias: its local override where there is one, so a search finds
+// a part by the text it's shown with. An override to empty indexes nothing.
+func indexedValue(alias, col string) string {
+	if col != FieldDescription && col != FieldNotes {
+		return alias + "." + col
+	}
+	return fmt.Sprintf(`COALESCE((SELECT COALESCE(o.value, '') FROM part_overrides o
+		WHERE o.part_number = %[1]s.part_number AND o.diagram_id = %[1]s.diagram_id AND o.field = '%[2]s'), %[1]s.%[2]s)`, alias, col)
+}
+
+// indexOverrides makes parts_fts index overridden text in place of the
+// catalog's, given its columns. The scraper's triggers index parts as
+// scraped, so they're swapped for ones that apply overrides and the parts
+// with overrides are indexed again; triggers on part_overrides then keep the
+// index current as overrides are saved and reverted. A catalog whose
+// triggers already apply overrides is left alone, until a re-scrape
+// replaces them.
+func indexOverrides(conn *sqlite.Conn, cols []string) (err error) {
+	if len(cols) == 0 {
+		return nil
+	}
+	var applied bool
+	err = sqlitex.ExecuteTransient(conn, "SELECT 1 FROM sqlite_master WHERE type = 'trigger' AND name = 'parts_au' AND sql LIKE '%part_overrides%'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			applied = true
+			return nil
+		},
+	})
+	if err != nil || applied {
+		return err
+	}
+	defer sqlitex.Save(conn)(&err)
+
+	list := strings.Join(cols, ", ")
+	// values lists what's indexed for the parts row alias, with the field
+	// whose override is changing, if any, as was
+	values := func(alias, field, was string) string {
+		v := make([]string, len(cols))
+		for i, col := range cols {
+			if col == field {
+				v[i] = was
+			} else {
+				v[i] = indexedValue(alias, col)
+			}
+		}
+		return strings.Join(v, ", ")
+	}
+	plain := func(alias string) string {
+		v := make([]string, len(cols))
+		for i, col := range cols {
+			v[i] = alias + "." + col
+		}
+		return strings.Join(v, ", ")
+	}
+	overridden := `EXISTS (SELECT 1 FROM part_overrides o
+		WHERE o.part_number = p.part_number AND o.diagram_id = p.diagram_id AND o.field IN ('description', 'notes'))`
+
+	queries := []string{
+		"DROP TRIGGER IF EXISTS parts_ai",
+		"DROP TRIGGER IF EXISTS parts_ad",
+		"DROP TRIGGER IF EXISTS parts_au",
+		fmt.Sprintf("INSERT INTO parts_fts(parts_fts, rowid, %s) SELECT 'delete', p.id, %s FROM parts p WHERE %s", list, plain("p"), overridden),
+		fmt.Sprintf("INSERT INTO parts_fts(rowid, %s) SELECT p.id, %s FROM parts p WHERE %s", list, values("p", "", ""), overridden),
+		fmt.Sprintf(`CREATE TRIGGER parts_ai AFTER INSERT ON parts BEGIN
+			INSERT INTO parts_fts(rowid, %s) VALUES (new.id, %s);
+		END`, list, values("new", "", "")),
+		fmt.Sprintf(`CREATE TRIGGER parts_ad AFTER DELETE ON parts BEGIN
+			INSERT INTO parts_fts(parts_fts, rowid, %s) VALUES ('delete', old.id, %s);
+		END`, list, values("old", "", "")),
+		fmt.Sprintf(`CREATE TRIGGER parts_au AFTER UPDATE ON parts BEGIN
+			INSERT INTO parts_fts(parts_fts, rowid, %s) VALUES ('delete', old.id, %s);
+			INSERT INTO parts_fts(rowid, %s) VALUES (new.id, %s);
+		END`, list, values("old", "", ""), list, values("new", "", "")),
+	}
+	for _, field := range []string{FieldDescription, FieldNotes} {
+		if !slices.Contains(cols, field) {
+			continue
+		}
+		// Each swaps the part's indexed text, as it was before the override
+		// changed, for what it is now
+		reindex := func(name, event, row, was string) string {
+			return fmt.Sprintf(`CREATE TRIGGER part_overrides_fts_%[1]s_%[2]s AFTER %[3]s ON part_overrides WHEN %[4]s.field = '%[2]s' BEGIN
+				INSERT INTO parts_fts(parts_fts, rowid, %[5]s) SELECT 'delete', p.id, %[6]s FROM parts p
+				WHERE p.part_number = %[4]s.part_number AND p.diagram_id = %[4]s.diagram_id;
+				INSERT INTO parts_fts(rowid, %[5]s) SELECT p.id, %[7]s FROM parts p
+				WHERE p.part_number = %[4]s.part_number AND p.diagram_id = %[4]s.diagram_id;
+			END`, name, field, event, row, list, values("p", field, was), values("p", "", ""))
+		}
+		for _, name := range []string{"ai", "au", "ad"} {
+			queries = append(queries, fmt.Sprintf("DROP TRIGGER IF EXISTS part_overrides_fts_%s_%s", name, field))
+		}
+		queries = append(queries,
+			reindex("ai", "INSERT", "new", "p."+field),
+			reindex("au", "UPDATE", "new", "COALESCE(old.value, '')"),
+			reindex("ad", "DELETE", "old", "COALESCE(old.value, '')"),
+		)
+	}
+	for _, query := range queries {
+		if err = sqlitex.ExecuteTransient(conn, query, nil); err != nil {
+			return fmt.Errorf("index overrides: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Sections of the part detail screen the user has collapsed, by name. They
+// stay collapsed for every part until expanded again, and are backed up
+// with the user data.
+const createCollapsedSectionsTable = `
+	CREATE TABLE IF NOT EXISTS collapsed_sections (
+		section TEXT PRIMARY KEY
+	)
+`
+
+// GetCollapsedSections returns the names of the collapsed sections.
+func (d *DB) GetCollapsedSections() (map[string]bool, error) {
+	collapsed := make(map[string]bool)
+	err := d.execute("SELECT section FROM collapsed_sections", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			collapsed[stmt.ColumnText(0)] = true
+			return nil
+		},
+	})
+	return collapsed, err
+}
+
+// SetSectionCollapsed collapses or expands a section.
+func (d *DB) SetSectionCollapsed(section string, collapsed bool) error {
+	if !collapsed {
+		return d.execute("DELETE FROM collapsed_sections WHERE section = ?", &sqlitex.ExecOptions{Args: []any{section}})
+	}
+	return d.execute("INSERT OR IGNORE INTO collapsed_sections (section) VALUES (?)", &sqlitex.ExecOptions{Args: []any{section}})
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Display settings that hold across sessions, by name. Backed up with the
+// user data, so restoring a backup brings the preferences back too.
+const createSettingsTable = `
+	CREATE TABLE IF NOT EXISTS settings (
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)
+`
+
+// Setting names
+const (
+	SettingHideSuperseded = "hide_superseded" // "1" leaves superseded parts out of lists
+)
+
+// GetSetting returns a setting's value, or "" if it isn't set.
+func (d *DB) GetSetting(name string) (string, error) {
+	var value string
+	err := d.execute("SELECT value FROM settings WHERE name = ?", &sqlitex.ExecOptions{
+		Args: []any{name},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			value = stmt.ColumnText(0)
+			return nil
+		},
+	})
+	return value, err
+}
+
+// SetSetting sets a setting. "" removes it, so it reads as unset.
+func (d *DB) SetSetting(name, value string) error {
+	if value == "" {
+		return d.execute("DELETE FROM settings WHERE name = ?", &sqlitex.ExecOptions{Args: []any{name}})
+	}
+	return d.execute("INSERT OR REPLACE INTO settings (name, value) VALUES (?, ?)", &sqlitex.ExecOptions{Args: []any{name, value}})
+}
+
+// HidingSuperseded reports whether lists leave out superseded parts.
+func (d *DB) HidingSuperseded() bool {
+	value, _ := d.GetSetting(SettingHideSuperseded)
+	return value == "1"
+}
+
+// SetHidingSuperseded sets whether lists leave out superseded parts.
+func (d *DB) SetHidingSuperseded(hide bool) error {
+	value := ""
+	if hide {
+		value = "1"
+	}
+	return d.SetSetting(SettingHideSuperseded, value)
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"fmt"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Moves of user data from superseded parts to their replacements, kept so
+// it's clear later why a bookmark or note sits on a different number.
+const createPartMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS part_migrations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		from_part_id INTEGER NOT NULL,
+		from_part_number TEXT NOT NULL,
+		to_part_id INTEGER NOT NULL,
+		to_part_number TEXT NOT NULL,
+		bookmark INTEGER NOT NULL,
+		note INTEGER NOT NULL,
+		attachments INTEGER NOT NULL,
+		migrated_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Saved parts a re-scrape deleted, recorded by the trigger as they go so the
+// report can still say which parts they were; the scraper's connection runs
+// the trigger too. Once gc removes the part's bookmark and note, the row goes
+// with them.
+const createRemovedPartsTable = `
+	CREATE TABLE IF NOT EXISTS removed_parts (
+		part_id INTEGER PRIMARY KEY,
+		part_number TEXT NOT NULL,
+		description TEXT,
+		removed_at TEXT DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TRIGGER IF NOT EXISTS parts_saved_removed AFTER DELETE ON parts
+	WHEN old.id IN (SELECT part_id FROM bookmarks UNION SELECT part_id FROM notes)
+	BEGIN
+		INSERT OR REPLACE INTO removed_parts (part_id, part_number, description)
+		VALUES (old.id, old.part_number, old.description);
+	END;
+`
+
+// PartMigration describes user data moved from a superseded part to its
+// replacement.
+type PartMigration struct {
+	FromPartID     int
+	FromPartNumber string
+	ToPartID       int
+	ToPartNumber   string
+	Bookmark       bool // the bookmark moved (or merged into an existing one)
+	Note           bool // the note moved, appended to any note already on the replacement
+	Attachments    int
+}
+
+// GetReplacementPartID returns the catalog part that supersedes partID,
+// preferring a listing in the same diagram. ok is false when the part has
+// no replacement or the replacement number isn't in the catalog.
+func (d *DB) GetReplacementPartID(partID int) (id int, ok bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id, _, _, ok, err = replacementPart(d.conn, partID)
+	return id, ok, err
+}
+
+func replacementPart(conn *sqlite.Conn, partID int) (id int, fromNumber, toNumber string, ok bool, err error) {
+	err = sqlitex.ExecuteTransient(conn, `
+		SELECT r.id, p.part_number, r.part_number
+		FROM parts p
+		JOIN parts r ON r.part_number = p.replacement_part_number
+		WHERE p.id = ?
+		ORDER BY r.diagram_id = p.diagram_id DESC, r.id
+		LIMIT 1
+	`, &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			id, fromNumber, toNumber, ok = stmt.ColumnInt(0), stmt.ColumnText(1), stmt.ColumnText(2), true
+			return nil
+		},
+	})
+	return id, fromNumber, toNumber, ok, err
+}
+
+// GetSupersededPartIDs returns the parts whose replacement number is in the
+// catalog, which lists can leave out in favour of the replacement.
+func (d *DB) GetSupersededPartIDs() (map[int]bool, error) {
+	superseded := make(map[int]bool)
+	err := d.execute(`
+		SELECT p.id FROM parts p
+		WHERE p.replacement_part_number IS NOT NULL
+			AND p.replacement_part_number != p.part_number
+			AND EXISTS (SELECT 1 FROM parts r WHERE r.part_number = p.replacement_part_number)
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			superseded[stmt.ColumnInt(0)] = true
+			return nil
+		},
+	})
+	return superseded, err
+}
+
+// MigrateToReplacement moves a superseded part's bookmark, note, note
+// attachments and purchase to its replacement in one transaction and
+// records the move. A bookmark already on the replacement isn't duplicated,
+// and notes on both are combined.
+func (d *DB) MigrateToReplacement(partID int) (PartMigration, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return migrateToReplacement(d.conn, partID)
+}
+
+func migrateToReplacement(conn *sqlite.Conn, partID int) (m PartMigration, err error) {
+	defer sqlitex.Save(conn)(&err)
+
+	toID, fromNumber, toNumber, ok, err := replacementPart(conn, partID)
+	if err != nil {
+		return m, err
+	}
+	if !ok {
+		return m, fmt.Errorf("part %d has no replacement in the catalog", partID)
+	}
+	m = PartMigration{FromPartID: partID, FromPartNumber: fromNumber, ToPartID: toID, ToPartNumber: toNumber}
+
+	exec := func(query string, args ...any) error {
+		return sqlitex.ExecuteTransient(conn, query, &sqlitex.ExecOptions{Args: args})
+	}
+
+	// Bookmark, with its quantity
+	if err = exec("INSERT OR IGNORE INTO bookmarks (part_id, qty) SELECT ?, qty FROM bookmarks WHERE part_id = ?", toID, partID); err != nil {
+		return m, err
+	}
+	if err = exec("DELETE FROM bookmarks WHERE part_id = ?", partID); err != nil {
+		return m, err
+	}
+	m.Bookmark = conn.Changes() > 0
+
+	// Note, appended to any note the replacement already has
+	var note *string
+	err = sqlitex.ExecuteTransient(conn, "SELECT content FROM notes WHERE part_id = ?", &sqlitex.ExecOptions{
+		Args: []any{partID},
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			c := stmt.ColumnText(0)
+			note = &c
+			return nil
+		},
+	})
+	if err != nil {
+		return m, err
+	}
+	if note != nil {
+		m.Note = true
+		if err = exec(`
+			INSERT INTO notes (part_id, content) VALUES (?, ?)
+			ON CONFLICT(part_id) DO UPDATE SET content = content || char(10) || char(10) || ?, updated_at = CURRENT_TIMESTAMP
+		`, toID, *note, *note); err != nil {
+			return m, err
+		}
+		if err = exec("DELETE FROM notes WHERE part_id = ?", partID); err != nil {
+			return m, err
+		}
+	}
+
+	// Attachments; paths the replacement already lists are dropped
+	if err = exec("UPDATE OR IGNORE note_attachments SET part_id = ? WHERE part_id = ?", toID, partID); err != nil {
+		return m, err
+	}
+	m.Attachments = conn.Changes()
+	if err = exec("DELETE FROM note_attachments WHERE part_id = ?", partID); err != nil {
+		return m, err
+	}
+
+	// Purchase, unless the replacement has its own
+	if err = exec("UPDATE OR IGNORE purchases SET part_id = ? WHERE part_id = ?", toID, partID); err != nil {
+		return m, err
+	}
+	if err = exec("DELETE FROM purchases WHERE part_id = ?", partID); err != nil {
+		return m, err
+	}
+
+	// Labor, which is the job's whatever the part number
+	if err = exec("UPDATE labor SET part_id = ? WHERE part_id = ?", toID, partID); err != nil {
+		return m, err
+	}
+
+	if !m.Bookmark && !m.Note && m.Attachments == 0 {
+		return m, fmt.Errorf("%s has no bookmark or note to move", fromNumber)
+	}
+
+	err = exec(`
+		INSERT INTO part_migrations (from_part_id, from_part_number, to_part_id, to_part_number, bookmark, note, attachments)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, partID, fromNumber, toID, toNumber, m.Bookmark, m.Note, m.Attachments)
+	return m, err
+}
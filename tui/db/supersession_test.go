@@ -0,0 +1,75 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+)
+
+// In the sample catalog, MD329470 is superseded by MD360806
+const (
+	supersededPart  = 2
+	replacementPart = 3
+)
+
+func TestMigrateToReplacement(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.AddBookmark(supersededPart); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetBookmarkQty(supersededPart, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetNote(supersededPart, "old tensioner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetNote(replacementPart, "new tensioner"); err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok, err := database.GetReplacementPartID(supersededPart); err != nil || !ok || id != replacementPart {
+		t.Fatalf("GetReplacementPartID = %d, %v, %v; want %d", id, ok, err, replacementPart)
+	}
+	migration, err := database.MigrateToReplacement(supersededPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migration.Bookmark || !migration.Note || migration.ToPartNumber != "MD360806" {
+		t.Errorf("migration = %+v, want the bookmark and note moved to MD360806", migration)
+	}
+
+	if bookmarked, _ := database.IsBookmarked(supersededPart); bookmarked {
+		t.Error("superseded part still bookmarked")
+	}
+	bookmarks, err := database.GetBookmarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].PartID != replacementPart || bookmarks[0].Qty != 2 {
+		t.Errorf("bookmarks = %+v, want the replacement with the 2 needed", bookmarks)
+	}
+	if note, _ := database.GetNote(supersededPart); note != nil {
+		t.Errorf("superseded part still has note %q", *note)
+	}
+	if note, _ := database.GetNote(replacementPart); note == nil || *note != "new tensioner\n\nold tensioner" {
+		t.Errorf("replacement note = %v, want both notes", note)
+	}
+
+	// Nothing is left to move
+	if _, err := database.MigrateToReplacement(supersededPart); err == nil {
+		t.Error("migrating a part with nothing saved succeeded")
+	}
+}
+
+func TestMigrateWithoutReplacement(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.AddBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateToReplacement(1); err == nil {
+		t.Error("migrating a part without a replacement succeeded")
+	}
+	if bookmarked, _ := database.IsBookmarked(1); !bookmarked {
+		t.Error("bookmark lost when the migration failed")
+	}
+}
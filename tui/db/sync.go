@@ -0,0 +1,148 @@
+package db
+
+import (
+	"net/url"
+	"strings"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// GetGroupSyncs returns every group with its part count and last sync time
+// from the scraper's group_sync table, which databases scraped by older
+// versions don't have.
+func (d *DB) GetGroupSyncs() ([]GroupSync, error) {
+	var hasTable bool
+	err := d.execute("SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'group_sync'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			hasTable = true
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	syncedAt := "NULL"
+	if hasTable {
+		syncedAt = "(SELECT synced_at FROM group_sync gs WHERE gs.group_id = g.id)"
+	}
+
+	var syncs []GroupSync
+	err = d.execute(`
+		SELECT g.id, g.name, (SELECT COUNT(*) FROM parts p WHERE p.group_id = g.id), `+syncedAt+`
+		FROM groups g
+		ORDER BY g.name
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			syncs = append(syncs, GroupSync{
+				GroupID:  stmt.ColumnText(0),
+				Name:     stmt.ColumnText(1),
+				Parts:    stmt.ColumnInt(2),
+				SyncedAt: nullableString(stmt, 3),
+			})
+			return nil
+		},
+	})
+	return syncs, err
+}
+
+// SyncPlan is what a sync would do, as far as the database can tell
+// without fetching: the scrape progress it clears or resumes, and the
+// diagram images it would download. What the pages hold is only known once
+// they're fetched.
+type SyncPlan struct {
+	Groups        []GroupPlan // for a group sync, in the order asked
+	Pending       int         // pages a full scrape resumes with; none starts from the index
+	Failed        int         // pages that failed, which only the scraper's retry fetches again
+	MissingImages int         // diagrams with an image not yet downloaded
+}
+
+// GroupPlan is what re-scraping one group would do.
+type GroupPlan struct {
+	GroupSync
+	Known bool // in the catalog; the scraper checks unknown IDs against the site
+	Pages int  // scrape progress cleared, so each page is fetched again
+}
+
+// GetSyncPlan returns what syncing the given groups, or with none resuming
+// the full scrape, would do.
+func (d *DB) GetSyncPlan(groups []string) (*SyncPlan, error) {
+	plan := &SyncPlan{}
+	pages := make(map[string]int)
+
+	// Databases imported rather than scraped have no scrape progress
+	var hasProgress bool
+	err := d.execute("SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'scrape_progress'", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			hasProgress = true
+			return nil
+		},
+	})
+	if err == nil && hasProgress {
+		err = d.execute("SELECT url, status FROM scrape_progress", &sqlitex.ExecOptions{
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				switch stmt.ColumnText(1) {
+				case "pending":
+					plan.Pending++
+				case "failed":
+					plan.Failed++
+				}
+				if group, ok := urlGroup(stmt.ColumnText(0)); ok {
+					pages[group]++
+				}
+				return nil
+			},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = d.execute("SELECT COUNT(*) FROM diagrams WHERE image_url IS NOT NULL AND image_path IS NULL", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			plan.MissingImages = stmt.ColumnInt(0)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return plan, nil
+	}
+
+	syncs, err := d.GetGroupSyncs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range groups {
+		g := GroupPlan{GroupSync: GroupSync{GroupID: id}, Pages: pages[id]}
+		for _, s := range syncs {
+			if s.GroupID == id {
+				g.GroupSync, g.Known = s, true
+			}
+		}
+		plan.Groups = append(plan.Groups, g)
+	}
+	return plan, nil
+}
+
+// urlGroup returns the group of a catalog page URL, the fourth segment of
+// its path as the scraper reads it:
+// /delica_space_gear/<frame>/<trim>/<group>/...
+func urlGroup(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	var segments []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if len(segments) < 4 {
+		return "", false
+	}
+	return segments[3], true
+}
@@ -1,10 +1,20 @@
 package db
 
+import "time"
+
 type Group struct {
 	ID   string
 	Name string
 }
 
+// GroupSync is when the scraper last scraped a group in full.
+type GroupSync struct {
+	GroupID  string
+	Name     string
+	Parts    int
+	SyncedAt *string // nil if the group hasn't been synced since sync times were recorded
+}
+
 type Subgroup struct {
 	ID      string
 	Name    string
@@ -47,7 +57,12 @@ type PartWithDiagram struct {
 type SearchResult struct {
 	PartWithDiagram
 	GroupName    string
-	SubgroupName *string
+	SubgroupName *string // the part's subgroup, or else its diagram's
+	DiagramName  string
+
+	// Relevance, higher is better, and the FTS columns the query matched
+	Score          float64
+	MatchedColumns []string
 }
 
 type BookmarkResult struct {
@@ -59,6 +74,10 @@ type BookmarkResult struct {
 	GroupName    string
 	SubgroupName *string
 	CreatedAt    string
+	Qty          int // how many are needed, at least 1
+
+	Spec           *string
+	ModelDateRange *string
 }
 
 type NoteResult struct {
@@ -73,9 +92,150 @@ type NoteResult struct {
 	UpdatedAt    string
 }
 
+// AddedPart is a catalog part that a sync found after its group had
+// already been scraped in full.
+type AddedPart struct {
+	PartID       int
+	PartNumber   string
+	PNC          *string
+	Description  *string
+	GroupName    string
+	SubgroupName string // the part's subgroup, or else its diagram's
+	AddedAt      string
+}
+
 type SubgroupWithGroup struct {
 	SubgroupID   string
 	SubgroupName string
 	GroupID      string
 	GroupName    string
 }
+
+// SavedPartChange describes a bookmarked or noted part whose catalog entry
+// has been superseded or has disappeared from the catalog.
+type SavedPartChange struct {
+	PartID                int
+	PartNumber            string  // for a missing part, as last seen; empty if removed before that was recorded
+	Description           *string // likewise
+	ReplacementPartNumber *string
+	ReplacementInCatalog  bool
+	Bookmarked            bool
+	HasNote               bool
+	Missing               bool // part_id no longer exists in the catalog
+}
+
+// CurationIssue is a part with incomplete or malformed catalog data.
+type CurationIssue struct {
+	Part
+	Problems []string
+}
+
+// DiagramWithNames is a diagram with its group and subgroup names, used for
+// building human-readable export filenames.
+type DiagramWithNames struct {
+	Diagram
+	GroupName    string
+	SubgroupName *string
+}
+
+// Pin is a pinned group or subgroup. For groups, GroupID and GroupName
+// describe the group itself.
+type Pin struct {
+	Kind      string
+	ID        string
+	Name      string
+	GroupID   string
+	GroupName string
+}
+
+// Price is a supplier's price for a part number. Stock and lead time are
+// nil when the supplier doesn't say.
+type Price struct {
+	SupplierID   string
+	PartNumber   string
+	Price        float64
+	Currency     string
+	Stock        *int
+	LeadTimeDays *int
+	URL          *string // supplier page for the part, if known
+	UpdatedAt    string
+	Availability *string // as the vendor's site words it, when looked up there
+	WeightGrams  *int    // shipping weight the vendor lists
+}
+
+// PriceDrop is a supplier's price for a bookmarked part that came down at
+// its last change.
+type PriceDrop struct {
+	PartID        int
+	PartNumber    string
+	Description   *string
+	SupplierID    string
+	Price         float64
+	PreviousPrice float64
+	Currency      string
+	UpdatedAt     string
+}
+
+// JournalEntry is one dated piece of user activity on a part. LowestPrices
+// are the cheapest known supplier price in each currency, not what was
+// paid; prices in different currencies aren't compared.
+type JournalEntry struct {
+	At           string
+	Kind         string // JournalNote, JournalBookmark or JournalLabor
+	PartID       int
+	PartNumber   string // empty if the part is no longer in the catalog
+	Description  *string
+	Detail       string          // note text
+	Labor        time.Duration   // time worked, for JournalLabor
+	LowestPrices []CurrencyPrice // by currency code
+}
+
+// CurrencyPrice is an amount in a currency.
+type CurrencyPrice struct {
+	Price    float64
+	Currency string
+}
+
+// KBEntry is a knowledge base note, such as a service bulletin or known
+// issue, for every part with a PNC or in a subgroup. One of PNC and
+// SubgroupID is set, or both to narrow it to a PNC within a subgroup.
+type KBEntry struct {
+	ID         int
+	PNC        string
+	SubgroupID string
+	Title      string
+	Body       string
+	Source     string // where it came from, e.g. a bulletin number or forum thread
+	UpdatedAt  string
+}
+
+// CompatNote is a fitment note about a part number, or an aftermarket
+// part that crosses to it when Brand and Xref are set.
+type CompatNote struct {
+	ID          int
+	PartNumber  string // normalized
+	Brand       string
+	Xref        string
+	Note        string
+	Contributor string // who shared it, empty for the user's own
+	Source      string // e.g. a forum thread or catalog page
+	UpdatedAt   string
+}
+
+// RecentPart is a part opened recently and how often it has been.
+type RecentPart struct {
+	PartID       int
+	PartNumber   string
+	Description  *string
+	GroupName    string
+	SubgroupName *string
+	Views        int
+	ViewedAt     string
+}
+
+// BookmarkedGroup is a group with the number of its parts bookmarked.
+type BookmarkedGroup struct {
+	ID        string
+	Name      string
+	Bookmarks int
+}
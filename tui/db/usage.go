@@ -0,0 +1,72 @@
+package db
+
+import (
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Feature usage counts, recorded only when DELICA_METRICS opts in: how
+// often each screen is opened and each key pressed on it, to see which
+// workflows matter before changing keybindings. They hold screen and key
+// names only, never part numbers or anything typed, and nothing sends them
+// anywhere. Backed up with the user data.
+const createUsageTable = `
+	CREATE TABLE IF NOT EXISTS usage_counts (
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		first_used TEXT NOT NULL,
+		last_used TEXT NOT NULL,
+		PRIMARY KEY (kind, name)
+	)
+`
+
+// Kinds of usage counted
+const (
+	UsageScreen = "screen" // a screen opened, by name
+	UsageKey    = "key"    // a key or chord pressed, as "<screen>: <key>"
+)
+
+// UsageCount is how often a screen or key was used.
+type UsageCount struct {
+	Kind      string
+	Name      string
+	Count     int
+	FirstUsed string // YYYY-MM-DD HH:MM:SS, UTC
+	LastUsed  string
+}
+
+// RecordUsage counts one use of a screen or key.
+func (d *DB) RecordUsage(kind, name string) error {
+	return d.execute(`
+		INSERT INTO usage_counts (kind, name, count, first_used, last_used)
+		VALUES (?, ?, 1, datetime('now'), datetime('now'))
+		ON CONFLICT (kind, name) DO UPDATE SET count = count + 1, last_used = excluded.last_used
+	`, &sqlitex.ExecOptions{Args: []any{kind, name}})
+}
+
+// GetUsage returns the usage counted, most used first.
+func (d *DB) GetUsage() ([]UsageCount, error) {
+	var counts []UsageCount
+	err := d.execute(`
+		SELECT kind, name, count, first_used, last_used FROM usage_counts
+		ORDER BY count DESC, kind, name
+	`, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			counts = append(counts, UsageCount{
+				Kind:      stmt.ColumnText(0),
+				Name:      stmt.ColumnText(1),
+				Count:     stmt.ColumnInt(2),
+				FirstUsed: stmt.ColumnText(3),
+				LastUsed:  stmt.ColumnText(4),
+			})
+			return nil
+		},
+	})
+	return counts, err
+}
+
+// ResetUsage forgets the usage counted so far.
+func (d *DB) ResetUsage() error {
+	return d.execute("DELETE FROM usage_counts", nil)
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"fmt"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Vehicles are the vans the user keeps profiles for, one of them active:
+// the one EPC links, spec matching and job templates follow. The .env
+// settings FRAME_NO, VEHICLE_NAME and the rest seed the first profile.
+const createVehiclesTable = `
+	CREATE TABLE IF NOT EXISTS vehicles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		frame_no TEXT NOT NULL DEFAULT '',
+		frame_name TEXT NOT NULL DEFAULT '',
+		trim_code TEXT NOT NULL DEFAULT '',
+		exterior_code TEXT NOT NULL DEFAULT '',
+		interior_code TEXT NOT NULL DEFAULT '',
+		manufacture_date TEXT NOT NULL DEFAULT '',
+		spec TEXT NOT NULL DEFAULT '',
+		active INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Vehicle fields, as SetVehicleField names them
+const (
+	VehicleName            = "name"
+	VehicleFrameNo         = "frame_no"
+	VehicleFrameName       = "frame_name"
+	VehicleTrimCode        = "trim_code"
+	VehicleExteriorCode    = "exterior_code"
+	VehicleInteriorCode    = "interior_code"
+	VehicleManufactureDate = "manufacture_date"
+	VehicleSpec            = "spec"
+)
+
+// Vehicle is a van's profile. Fields other than Name may be empty.
+type Vehicle struct {
+	ID              int
+	Name            string // display name from the EPC, "HSEUE9 Chamonix (HIGH-ROOF), 4CA/T complectation"
+	FrameNo         string // PD6W-0500900
+	FrameName       string // frame code for EPC links, pd6w
+	TrimCode        string // complectation code for EPC links, hseue9
+	ExteriorCode    string
+	InteriorCode    string
+	ManufactureDate string // build date, 1999.07.3
+	Spec            string // "4WD, AT, HIGH ROOF, 6G74", to match part specs against
+	Active          bool
+}
+
+// Field returns the value of one of the Vehicle field names.
+func (v Vehicle) Field(field string) string {
+	switch field {
+	case VehicleName:
+		return v.Name
+	case VehicleFrameNo:
+		return v.FrameNo
+	case VehicleFrameName:
+		return v.FrameName
+	case VehicleTrimCode:
+		return v.TrimCode
+	case VehicleExteriorCode:
+		return v.ExteriorCode
+	case VehicleInteriorCode:
+		return v.InteriorCode
+	case VehicleManufactureDate:
+		return v.ManufactureDate
+	case VehicleSpec:
+		return v.Spec
+	}
+	return ""
+}
+
+// Attributes reads the van's attributes, to match part specs against: its
+// spec if it has one, otherwise what its name gives, such as the grade,
+// roof and transmission of "HSEUE9 Chamonix (HIGH-ROOF), 4CA/T". Items that
+// aren't attributes are left out.
+func (v Vehicle) Attributes() []Attribute {
+	spec := v.Spec
+	if spec == "" {
+		spec = v.Name
+	}
+	var attrs []Attribute
+	for _, a := range ParseSpec(spec) {
+		if a.Name != AttrOther {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+const vehicleColumns = "id, name, frame_no, frame_name, trim_code, exterior_code, interior_code, manufacture_date, spec, active"
+
+func scanVehicle(stmt *sqlite.Stmt) Vehicle {
+	return Vehicle{
+		ID:              stmt.ColumnInt(0),
+		Name:            stmt.ColumnText(1),
+		FrameNo:         stmt.ColumnText(2),
+		FrameName:       stmt.ColumnText(3),
+		TrimCode:        stmt.ColumnText(4),
+		ExteriorCode:    stmt.ColumnText(5),
+		InteriorCode:    stmt.ColumnText(6),
+		ManufactureDate: stmt.ColumnText(7),
+		Spec:            stmt.ColumnText(8),
+		Active:          stmt.ColumnBool(9),
+	}
+}
+
+// GetVehicles returns the vehicle profiles in the order they were added.
+func (d *DB) GetVehicles() ([]Vehicle, error) {
+	var vehicles []Vehicle
+	err := d.execute("SELECT "+vehicleColumns+" FROM vehicles ORDER BY id", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			vehicles = append(vehicles, scanVehicle(stmt))
+			return nil
+		},
+	})
+	return vehicles, err
+}
+
+// GetActiveVehicle returns the active vehicle profile, or nil if there are
+// none.
+func (d *DB) GetActiveVehicle() (*Vehicle, error) {
+	var v *Vehicle
+	err := d.execute("SELECT "+vehicleColumns+" FROM vehicles ORDER BY active DESC, id LIMIT 1", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			found := scanVehicle(stmt)
+			v = &found
+			return nil
+		},
+	})
+	return v, err
+}
+
+// AddVehicle adds a vehicle profile, making it the active one if it's the
+// first, and returns its ID.
+func (d *DB) AddVehicle(v Vehicle) (id int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer sqlitex.Save(d.conn)(&err)
+
+	err = sqlitex.Execute(d.conn, `
+		INSERT INTO vehicles (name, frame_no, frame_name, trim_code, exterior_code, interior_code, manufacture_date, spec, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOT EXISTS (SELECT 1 FROM vehicles))
+	`, &sqlitex.ExecOptions{
+		Args: []any{v.Name, v.FrameNo, v.FrameName, v.TrimCode, v.ExteriorCode, v.InteriorCode, v.ManufactureDate, v.Spec},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(d.conn.LastInsertRowID()), nil
+}
+
+// SeedVehicle adds v as the first vehicle profile while there are none, so
+// settings from before profiles carry over. It reports whether it did.
+func (d *DB) SeedVehicle(v Vehicle) (bool, error) {
+	vehicles, err := d.GetVehicles()
+	if err != nil || len(vehicles) > 0 {
+		return false, err
+	}
+	_, err = d.AddVehicle(v)
+	return err == nil, err
+}
+
+// SetVehicleField sets one field of a vehicle profile. The name can't be
+// empty.
+func (d *DB) SetVehicleField(id int, field, value string) error {
+	switch field {
+	case VehicleName:
+		if value == "" {
+			return fmt.Errorf("a vehicle needs a name")
+		}
+	case VehicleFrameNo, VehicleFrameName, VehicleTrimCode, VehicleExteriorCode, VehicleInteriorCode, VehicleManufactureDate, VehicleSpec:
+	default:
+		return fmt.Errorf("unknown vehicle field %q", field)
+	}
+	return d.executeTransient("UPDATE vehicles SET "+field+" = ? WHERE id = ?", &sqlitex.ExecOptions{
+		Args: []any{value, id},
+	})
+}
+
+// SetActiveVehicle makes a vehicle profile the active one.
+func (d *DB) SetActiveVehicle(id int) error {
+	return d.execute("UPDATE vehicles SET active = (id = ?)", &sqlitex.ExecOptions{
+		Args: []any{id},
+	})
+}
+
+// RemoveVehicle deletes a vehicle profile. Removing the active one makes
+// the first remaining profile active.
+func (d *DB) RemoveVehicle(id int) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer sqlitex.Save(d.conn)(&err)
+
+	if err = sqlitex.Execute(d.conn, "DELETE FROM vehicles WHERE id = ?", &sqlitex.ExecOptions{
+		Args: []any{id},
+	}); err != nil {
+		return err
+	}
+	return sqlitex.ExecuteTransient(d.conn, `
+		UPDATE vehicles SET active = 1
+		WHERE id = (SELECT MIN(id) FROM vehicles)
+			AND NOT EXISTS (SELECT 1 FROM vehicles WHERE active = 1)
+	`, nil)
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// CatalogChange is how the database file changed since it was last checked.
+type CatalogChange int
+
+const (
+	CatalogUnchanged CatalogChange = iota
+	CatalogUpdated                 // another program wrote to it
+	CatalogReplaced                // a different file is at its path now
+)
+
+// readDataVersion reads the count SQLite keeps of commits made by other
+// connections to the file conn has open
+func readDataVersion(conn *sqlite.Conn) (int64, error) {
+	var version int64
+	err := sqlitex.ExecuteTransient(conn, "PRAGMA data_version", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			version = stmt.ColumnInt64(0)
+			return nil
+		},
+	})
+	return version, err
+}
+
+// CheckCatalog reports whether another program, such as the scraper, has
+// changed the database since the last check: written to it, or replaced the
+// file at its path, which leaves this connection on the old one. Writes are
+// only reported once no more have come for settle, so a scrape committing
+// page after page is reported once it pauses or finishes rather than at
+// every check. While the path is missing, as it is part way through a
+// replace, it reports no change so the next check sees the new file.
+func (d *DB) CheckCatalog(settle time.Duration) (CatalogChange, error) {
+	if d.path == "" {
+		return CatalogUnchanged, nil
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return CatalogUnchanged, nil
+	}
+	if d.file != nil && !os.SameFile(d.file, info) {
+		return CatalogReplaced, nil
+	}
+
+	d.mu.Lock()
+	version, err := readDataVersion(d.conn)
+	changed := err == nil && version != d.dataVersion
+	if changed && version != d.pendingVersion {
+		// Still being written
+		d.pendingVersion, d.pendingSince = version, time.Now()
+	}
+	if changed && time.Since(d.pendingSince) < settle {
+		changed = false
+	}
+	if changed {
+		// Specs are parsed and subgroups aggregated again before the write
+		// counts as seen, so one that can't be yet, with the writer still
+		// busy, is retried at the next check
+		if refreshAttributes(d.conn) == nil && refreshAggregates(d.conn) == nil {
+			d.dataVersion = version
+		} else {
+			changed = false
+		}
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return CatalogUnchanged, fmt.Errorf("read data version: %w", err)
+	}
+	if !changed {
+		return CatalogUnchanged, nil
+	}
+	d.Refresh()
+	return CatalogUpdated, nil
+}
+
+// Refresh drops what's cached from the catalog, so it's read again.
+func (d *DB) Refresh() {
+	d.countsMu.Lock()
+	d.counts = nil
+	d.countsMu.Unlock()
+}
+
+// Reopen connects to the file now at the database's path, migrating it as
+// Open does, and closes the connection to the one it replaced.
+func (d *DB) Reopen() error {
+	fresh, err := Open(d.path)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	old := d.conn
+	d.conn, d.ftsColumns = fresh.conn, fresh.ftsColumns
+	d.file, d.dataVersion = fresh.file, fresh.dataVersion
+	d.pendingVersion = 0
+	d.mu.Unlock()
+	d.Refresh()
+	return old.Close()
+}
@@ -0,0 +1,54 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+func TestCheckCatalogSettles(t *testing.T) {
+	database, path := fileDB(t)
+	writer, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	check := func(settle time.Duration) db.CatalogChange {
+		t.Helper()
+		change, err := database.CheckCatalog(settle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return change
+	}
+
+	const settle = 300 * time.Millisecond
+	if change := check(settle); change != db.CatalogUnchanged {
+		t.Fatalf("change = %v before any write", change)
+	}
+	if err := writer.AddBookmark(1); err != nil {
+		t.Fatal(err)
+	}
+	if change := check(settle); change != db.CatalogUnchanged {
+		t.Errorf("change = %v straight after a write, want it held until writes stop", change)
+	}
+
+	// Another write starts the wait again
+	time.Sleep(settle / 2)
+	if err := writer.AddBookmark(2); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(settle / 2)
+	if change := check(settle); change != db.CatalogUnchanged {
+		t.Errorf("change = %v with a write %v ago", change, settle/2)
+	}
+
+	time.Sleep(settle)
+	if change := check(settle); change != db.CatalogUpdated {
+		t.Errorf("change = %v once writes stopped, want updated", change)
+	}
+	if change := check(settle); change != db.CatalogUnchanged {
+		t.Errorf("change = %v after the write was reported", change)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/report"
+)
+
+// runDigest summarizes what changed for saved parts since a date, for a
+// cron job to mail or publish as a feed: price drops on bookmarked parts,
+// catalog changes to bookmarked and noted parts, and parts syncs added.
+func runDigest(database *db.DB, args []string) error {
+	flags := flag.NewFlagSet("digest", flag.ExitOnError)
+	format := flags.String("format", "md", "Output format: md, html or rss")
+	output := flags.String("o", "", "Write to file instead of stdout; for rss, the feed file to add to")
+	since := flags.String("since", "", "Cover changes on or after this date (YYYY-MM-DD); default a week ago")
+	skipEmpty := flags.Bool("skip-empty", false, "Write nothing when there's nothing to report, so cron sends no mail")
+	link := flags.String("link", "http://localhost:8080/", "Channel link for rss, such as the serve address")
+	flags.Parse(args)
+
+	now := time.Now()
+	if *since == "" {
+		*since = now.AddDate(0, 0, -7).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", *since); err != nil {
+		return fmt.Errorf("invalid -since date %q (want YYYY-MM-DD)", *since)
+	}
+	if *format == "rss" && *output == "" {
+		return fmt.Errorf("-format rss needs -o, the feed file to add to")
+	}
+
+	d := report.Digest{Since: *since}
+	var err error
+	if d.PriceDrops, err = database.GetPriceDrops(*since); err != nil {
+		return fmt.Errorf("load price drops: %w", err)
+	}
+	if d.Changes, err = database.GetSavedPartChanges(); err != nil {
+		return fmt.Errorf("load changes: %w", err)
+	}
+	if d.Added, err = database.GetAddedParts(*since); err != nil {
+		return fmt.Errorf("load added parts: %w", err)
+	}
+	if *skipEmpty && d.Empty() {
+		return nil
+	}
+
+	if *format == "rss" {
+		existing, err := os.ReadFile(*output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("read feed: %w", err)
+		}
+		var b bytes.Buffer
+		if err := report.WriteDigestRSS(&b, existing, d, now, *link); err != nil {
+			return err
+		}
+		return os.WriteFile(*output, b.Bytes(), 0o644)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "md", "markdown":
+		return report.WriteDigestMarkdown(w, d, now)
+	case "html":
+		return report.WriteDigestHTML(w, d, now)
+	default:
+		return fmt.Errorf("unknown format %q (want md, html or rss)", *format)
+	}
+}
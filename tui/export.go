@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/export"
+)
+
+// runExportDiagrams copies all diagram images for a group into a flat folder.
+func runExportDiagrams(database *db.DB, dataPath string, args []string) error {
+	fs := flag.NewFlagSet("export-diagrams", flag.ExitOnError)
+	output := fs.String("o", "", "Output directory (default: <data>/exports/<group>)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: delica-tui export-diagrams [-o DIR] GROUP_ID")
+	}
+	groupID := fs.Arg(0)
+
+	group, err := database.GetGroup(groupID)
+	if err != nil {
+		return fmt.Errorf("load group: %w", err)
+	}
+	if group == nil {
+		return fmt.Errorf("group %q not found", groupID)
+	}
+
+	diagrams, err := database.GetDiagramsForGroup(groupID)
+	if err != nil {
+		return fmt.Errorf("load diagrams: %w", err)
+	}
+
+	outDir := *output
+	if outDir == "" {
+		outDir = filepath.Join(dataPath, "exports", export.Slug(groupID))
+	}
+
+	result, err := export.Diagrams(dataPath, outDir, diagrams)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d diagrams to %s", result.Written, result.Dir)
+	if result.Skipped > 0 {
+		fmt.Printf(" (%d without images)", result.Skipped)
+	}
+	fmt.Println()
+	return nil
+}
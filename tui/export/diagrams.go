@@ -0,0 +1,112 @@
+// Package export writes catalog data out of the app for use elsewhere.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+
+	"github.com/disintegration/imaging"
+)
+
+// DiagramResult summarizes a diagram export.
+type DiagramResult struct {
+	Written int
+	Skipped int // diagrams without a downloaded image
+	Dir     string
+}
+
+// ErrUnreadableImage is returned by DiagramFile.Write when the diagram's
+// downloaded image can't be decoded.
+var ErrUnreadableImage = errors.New("unreadable image")
+
+// DiagramFile is a diagram to export and the file name it gets.
+type DiagramFile struct {
+	Diagram db.DiagramWithNames
+	Name    string // group_subgroup_diagram.png
+}
+
+// Diagrams converts each diagram's image to PNG in outDir, named
+// group_subgroup_diagram.png so the folder reads well on a tablet.
+// Image paths are resolved relative to dataPath.
+func Diagrams(dataPath, outDir string, diagrams []db.DiagramWithNames) (DiagramResult, error) {
+	result := DiagramResult{Dir: outDir}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return result, fmt.Errorf("create export directory: %w", err)
+	}
+
+	files, skipped := PlanDiagrams(diagrams)
+	result.Skipped = skipped
+	for _, f := range files {
+		if err := f.Write(dataPath, outDir); errors.Is(err, ErrUnreadableImage) {
+			result.Skipped++
+			continue
+		} else if err != nil {
+			return result, err
+		}
+		result.Written++
+	}
+	return result, nil
+}
+
+// PlanDiagrams names the export file of each diagram with a downloaded
+// image, returning how many had none.
+func PlanDiagrams(diagrams []db.DiagramWithNames) (files []DiagramFile, skipped int) {
+	used := make(map[string]int)
+	for _, d := range diagrams {
+		if d.ImagePath == nil {
+			skipped++
+			continue
+		}
+
+		parts := []string{Slug(d.GroupName)}
+		if d.SubgroupName != nil {
+			parts = append(parts, Slug(*d.SubgroupName))
+		}
+		parts = append(parts, Slug(d.Name))
+		base := strings.Join(parts, "_")
+
+		// Several diagrams can share a name within a subgroup
+		used[base]++
+		if n := used[base]; n > 1 {
+			base = fmt.Sprintf("%s_%d", base, n)
+		}
+		files = append(files, DiagramFile{Diagram: d, Name: base + ".png"})
+	}
+	return files, skipped
+}
+
+// Write converts the diagram's image to PNG in outDir, which must exist.
+func (f DiagramFile) Write(dataPath, outDir string) error {
+	img, err := image.Open(filepath.Join(dataPath, *f.Diagram.ImagePath))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreadableImage, err)
+	}
+	if err := imaging.Save(img, filepath.Join(outDir, f.Name)); err != nil {
+		return fmt.Errorf("write %s: %w", strings.TrimSuffix(f.Name, ".png"), err)
+	}
+	return nil
+}
+
+// Slug lowercases s and replaces runs of non-alphanumerics with a hyphen,
+// e.g. "Oil Pump & Filter" becomes "oil-pump-filter".
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
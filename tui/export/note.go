@@ -0,0 +1,77 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Note is a part's note with what a reader elsewhere needs to know which
+// part it's about, such as someone on an owners forum.
+type Note struct {
+	PartNumber  string
+	PNC         string
+	Description string
+	Group       string
+	Subgroup    string
+	Vehicle     string // e.g. the active vehicle profile's name
+	FrameNo     string
+	Updated     string // already formatted for reading
+	Content     string
+}
+
+// Text lays the note out as plain text under a header naming the part, its
+// place in the catalog and the vehicle, ready to paste into a post.
+func (n Note) Text() string {
+	var b strings.Builder
+
+	title := strings.ToUpper(n.PartNumber)
+	if n.Description != "" {
+		title += " - " + n.Description
+	}
+	b.WriteString(title + "\n")
+
+	var details []string
+	if n.PNC != "" {
+		details = append(details, "PNC "+n.PNC)
+	}
+	location := n.Group
+	if n.Subgroup != "" {
+		location += " > " + n.Subgroup
+	}
+	if location != "" {
+		details = append(details, location)
+	}
+	if len(details) > 0 {
+		b.WriteString(strings.Join(details, " · ") + "\n")
+	}
+
+	vehicle := strings.TrimSpace(n.Vehicle)
+	if n.FrameNo != "" {
+		vehicle = strings.TrimSpace(vehicle + " (" + n.FrameNo + ")")
+	}
+	if vehicle != "" {
+		b.WriteString("Vehicle: " + vehicle + "\n")
+	}
+	if n.Updated != "" {
+		b.WriteString("Noted " + n.Updated + "\n")
+	}
+
+	b.WriteString(strings.Repeat("-", max(len(title), 20)) + "\n\n")
+	b.WriteString(strings.TrimSpace(n.Content) + "\n")
+	return b.String()
+}
+
+// WriteNote saves the note's text in outDir as note-<part number>.txt,
+// replacing an earlier export of it, and returns the file's path.
+func WriteNote(outDir string, n Note) (string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(outDir, "note-"+Slug(n.PartNumber)+".txt")
+	if err := os.WriteFile(path, []byte(n.Text()), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	return path, nil
+}
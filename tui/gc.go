@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// runGC removes what the catalog no longer needs: user data pointing at
+// parts, diagrams, groups or subgroups a re-scrape dropped, diagram images
+// no diagram refers to, and the free space left in the database. User data
+// is backed up before any of it is removed. Scaled images are only cached in
+// memory, so there are none on disk to remove.
+func runGC(database *db.DB, dataPath string, args []string) error {
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	report := reportFlags(flags)
+	flags.Parse(args)
+
+	dbPath := filepath.Join(dataPath, "delica.db")
+
+	// Orphaned user data
+	orphans, err := database.CountOrphans()
+	if err != nil {
+		return fmt.Errorf("find orphaned user data: %w", err)
+	}
+	if orphans.Total() > 0 && !report.dryRun {
+		safety := filepath.Join(db.BackupDir(dbPath), db.BackupName(time.Now(), "pre-gc"))
+		if _, err := database.Backup(safety); err != nil {
+			os.Remove(safety)
+			return fmt.Errorf("back up user data: %w", err)
+		}
+		fmt.Printf("User data saved to %s\n", safety)
+		if orphans, err = database.RemoveOrphans(); err != nil {
+			return fmt.Errorf("remove orphaned user data: %w", err)
+		}
+	}
+	for _, table := range slices.Sorted(maps.Keys(orphans)) {
+		report.change("remove %d rows of %s", orphans[table], table)
+	}
+	fmt.Printf("%s %d rows of user data for parts, diagrams or groups no longer in the catalog\n", report.did("Removed", "Would remove"), orphans.Total())
+
+	// Unreferenced diagram images
+	files, bytes, err := removeUnusedImages(database, dataPath, report)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %d unused diagram images (%s)\n", report.did("Removed", "Would remove"), files, byteSize(bytes))
+
+	// Free space in the database
+	free, err := database.FreeBytes()
+	if err != nil {
+		return fmt.Errorf("measure free space: %w", err)
+	}
+	if report.dryRun {
+		fmt.Printf("Would vacuum delica.db, freeing at least %s\n", byteSize(free))
+	} else {
+		before, _ := os.Stat(dbPath)
+		if err := database.Vacuum(); err != nil {
+			return fmt.Errorf("vacuum (is the TUI running?): %w", err)
+		}
+		after, _ := os.Stat(dbPath)
+		if before != nil && after != nil {
+			free = max(before.Size()-after.Size(), 0)
+		}
+		fmt.Printf("Vacuumed delica.db, freeing %s\n", byteSize(free))
+	}
+
+	fmt.Printf("%s %s in all\n", report.did("Reclaimed", "Would reclaim"), byteSize(bytes+free))
+	report.finish()
+	return nil
+}
+
+// removeUnusedImages deletes the files under <data>/images that no diagram
+// refers to, keeping the previous revisions sync saved of those that are.
+// It returns how many files went and their size.
+func removeUnusedImages(database *db.DB, dataPath string, report *reporter) (files int, bytes int64, err error) {
+	used, err := database.GetImagePaths()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read diagram images: %w", err)
+	}
+	if len(used) == 0 {
+		// A catalog scraped without images; nothing tells which are unused
+		report.skip("keep images/, which the catalog doesn't refer to")
+		return 0, 0, nil
+	}
+
+	root := filepath.Join(dataPath, "images")
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dataPath, path)
+		if err != nil {
+			return err
+		}
+		current := rel
+		if filepath.Base(filepath.Dir(rel)) == "previous" {
+			current = filepath.Join(filepath.Dir(filepath.Dir(rel)), filepath.Base(rel))
+		}
+		if used[current] {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		report.change("remove %s", rel)
+		if !report.dryRun {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return files, bytes, fmt.Errorf("remove unused images: %w", err)
+	}
+	return files, bytes, nil
+}
+
+// byteSize words a size for the summary
+func byteSize(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%d KB", (n+1<<10-1)>>10)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
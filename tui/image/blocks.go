@@ -0,0 +1,143 @@
+package image
+
+import (
+	goimage "image"
+	"image/color"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/muesli/termenv"
+)
+
+// blocksRenderer draws images as text, for terminals with no graphics
+// protocol: each cell is two pixels stacked, the upper half block in the
+// top pixel's color over the bottom's, in true color or the nearest of the
+// terminal's 256 or 16. Terminals without color, or ascii, get ASCII shades
+// instead, dark for the diagrams' black lines. Being text, the image is
+// part of the screen: screens fill the lines they leave for it with Row and
+// Render draws nothing.
+type blocksRenderer struct {
+	ascii bool
+}
+
+func (r blocksRenderer) Name() string {
+	if r.ascii {
+		return "ascii"
+	}
+	return "blocks"
+}
+
+// blocksProfile is the colors the terminal takes, read from the
+// environment as lipgloss does
+var blocksProfile = sync.OnceValue(termenv.EnvColorProfile)
+
+// asciiShades run from light to dark
+const asciiShades = " .:-=+*#%@"
+
+func (blocksRenderer) encode(img goimage.Image, k *KittyImage) error {
+	k.cells = imaging.Resize(img, k.CellWidth(), k.CellHeight()*2, imaging.Box)
+	return nil
+}
+
+func (blocksRenderer) Render(img *KittyImage, region goimage.Rectangle) string { return "" }
+
+func (blocksRenderer) Clear(id uint32) string { return "" }
+
+func (blocksRenderer) ClearAll() string { return "" }
+
+// row draws the cells of a row of the image from col, widthCells of them
+// or to the right edge if widthCells is 0
+func (r blocksRenderer) row(img *KittyImage, row, col, widthCells int) string {
+	if img.cells == nil {
+		return ""
+	}
+	bounds := img.cells.Bounds()
+	y := row * 2
+	if row < 0 || y >= bounds.Dy() || col >= bounds.Dx() {
+		return ""
+	}
+	end := bounds.Dx()
+	if widthCells > 0 {
+		end = min(col+widthCells, end)
+	}
+	pixel := func(x, y int) color.NRGBA {
+		if y >= bounds.Dy() {
+			return color.NRGBA{}
+		}
+		return img.cells.NRGBAAt(x, y)
+	}
+
+	profile := blocksProfile()
+	if r.ascii || profile == termenv.Ascii {
+		var b strings.Builder
+		for x := max(col, 0); x < end; x++ {
+			b.WriteByte(asciiShade(pixel(x, y), pixel(x, y+1)))
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	var fg, bg string // colors set so far, "" for the terminal's own
+	set := func(wantFg, wantBg string) {
+		if wantFg == "" && fg != "" || wantBg == "" && bg != "" {
+			b.WriteString("\x1b[0m")
+			fg, bg = "", ""
+		}
+		if wantFg != fg {
+			b.WriteString("\x1b[" + wantFg + "m")
+			fg = wantFg
+		}
+		if wantBg != bg {
+			b.WriteString("\x1b[" + wantBg + "m")
+			bg = wantBg
+		}
+	}
+	seq := func(c color.NRGBA, background bool) string {
+		return profile.FromColor(color.NRGBA{c.R, c.G, c.B, 0xff}).Sequence(background)
+	}
+	for x := max(col, 0); x < end; x++ {
+		top, bottom := pixel(x, y), pixel(x, y+1)
+		switch {
+		case transparent(top) && transparent(bottom):
+			set("", "")
+			b.WriteByte(' ')
+		case transparent(bottom):
+			set(seq(top, false), "")
+			b.WriteString("▀")
+		case transparent(top):
+			set(seq(bottom, false), "")
+			b.WriteString("▄")
+		default:
+			set(seq(top, false), seq(bottom, true))
+			b.WriteString("▀")
+		}
+	}
+	set("", "")
+	return b.String()
+}
+
+// transparent reports whether a pixel is more see-through than not, so
+// left for the terminal's background
+func transparent(c color.NRGBA) bool {
+	return c.A < 0x80
+}
+
+// asciiShade is the shade for a cell of two pixels by how dark they are,
+// blank where they're transparent
+func asciiShade(top, bottom color.NRGBA) byte {
+	var dark, n int
+	for _, c := range []color.NRGBA{top, bottom} {
+		if transparent(c) {
+			continue
+		}
+		// Rec. 601 luma
+		luma := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+		dark += 255 - luma
+		n++
+	}
+	if n == 0 {
+		return ' '
+	}
+	return asciiShades[dark/n*len(asciiShades)/256]
+}
@@ -0,0 +1,120 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/disintegration/imaging"
+)
+
+// DefaultMaxMegapixels is the largest image decoded, or scaled at full size,
+// without DELICA_IMAGE_MAX_MP. Scanned diagrams are well under it; a 24MP
+// RGBA bitmap takes about 96MB, which a Raspberry Pi can spare once.
+const DefaultMaxMegapixels = 24
+
+// maxPixels is the pixel budget from DELICA_IMAGE_MAX_MP, read once. It's
+// 64-bit, as are the sizes compared with it, so a header claiming a huge
+// image can't overflow an int on 32-bit ARM.
+var maxPixels = sync.OnceValue(func() uint64 {
+	if v := os.Getenv("DELICA_IMAGE_MAX_MP"); v != "" {
+		if mp, err := strconv.ParseFloat(v, 64); err == nil && mp > 0 {
+			return uint64(mp * 1e6)
+		}
+	}
+	return DefaultMaxMegapixels * 1e6
+})
+
+var downscaled atomic.Int64
+
+// refused holds the paths of bitmaps too large to decode, as each is
+// tried again every time it's shown or prefetched
+var refused sync.Map
+
+// Downscaled returns how many images were shrunk to fit the pixel budget
+// this session.
+func Downscaled() int {
+	return int(downscaled.Load())
+}
+
+// Refused returns how many bitmaps weren't decoded this session because
+// decoding them would take more memory than the pixel budget allows.
+func Refused() int {
+	n := 0
+	refused.Range(func(any, any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// withinBudget returns the largest size with the aspect of width x height
+// that fits the pixel budget, and whether it had to shrink.
+func withinBudget(width, height int) (int, int, bool) {
+	budget, pixels := maxPixels(), uint64(width)*uint64(height)
+	if pixels <= budget {
+		return width, height, false
+	}
+	f := math.Sqrt(float64(budget) / float64(pixels))
+	return max(int(float64(width)*f), 1), max(int(float64(height)*f), 1), true
+}
+
+// decodedBytes is about what a decoder holds for an image of cfg. Decoders
+// keep the file's own pixel format, which for scanned diagrams (gray,
+// paletted, or JPEG's YCbCr) takes a quarter to three quarters of what the
+// RGBA copy the budget is sized for would.
+func decodedBytes(cfg goimage.Config) uint64 {
+	pixels := uint64(cfg.Width) * uint64(cfg.Height)
+	switch cfg.ColorModel {
+	case color.GrayModel, color.AlphaModel:
+		return pixels
+	case color.Gray16Model, color.Alpha16Model:
+		return 2 * pixels
+	case color.YCbCrModel:
+		return 3 * pixels // without chroma subsampling, at worst
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 8 * pixels
+	}
+	if _, ok := cfg.ColorModel.(color.Palette); ok {
+		return pixels
+	}
+	return 4 * pixels
+}
+
+// openBitmap decodes a bitmap, shrinking one over the pixel budget to fit
+// it as soon as it's decoded, so only the smaller copy is kept. The header
+// is read first: the decoders can't decode at a reduced size, so a bitmap
+// whose own pixel format would take more memory than the budget's RGBA
+// size is refused rather than decoded.
+func openBitmap(path string) (goimage.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := goimage.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	width, height, shrink := withinBudget(cfg.Width, cfg.Height)
+	if !shrink {
+		return imaging.Open(path)
+	}
+	if decodedBytes(cfg) > 4*maxPixels() {
+		refused.Store(path, struct{}{})
+		return nil, fmt.Errorf("%dx%d is too large to decode within the %g megapixel budget; raise DELICA_IMAGE_MAX_MP to show it",
+			cfg.Width, cfg.Height, float64(maxPixels())/1e6)
+	}
+
+	img, err := imaging.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	downscaled.Add(1)
+	return imaging.Resize(img, width, height, imaging.Box), nil
+}
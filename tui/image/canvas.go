@@ -0,0 +1,140 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"image/color"
+	"sync/atomic"
+
+	"github.com/disintegration/imaging"
+)
+
+// Canvas is an image scaled once to fit a pane, for drawing marks on and
+// sending again as they move, like the crosshair of the hotspot screen.
+// Every draw has the canvas's image ID, so the terminal replaces the image
+// rather than stacking copies.
+type Canvas struct {
+	base   *goimage.NRGBA
+	scale  float64
+	width  int // of the original, in pixels
+	height int
+	id     uint32
+}
+
+// MarkStyle is how a mark is drawn on a canvas.
+type MarkStyle int
+
+const (
+	MarkSpot      MarkStyle = iota // a small dot
+	MarkSelected                   // a larger dot in another color
+	MarkCrosshair                  // lines across the whole image, open at the point
+)
+
+// Mark is a point on a canvas, in pixels of the original image.
+type Mark struct {
+	X, Y  int
+	Style MarkStyle
+}
+
+var (
+	spotColor      = color.NRGBA{R: 30, G: 100, B: 230, A: 255}
+	selectedColor  = color.NRGBA{R: 240, G: 120, B: 0, A: 255}
+	crosshairColor = color.NRGBA{R: 230, G: 30, B: 30, A: 255}
+	outlineColor   = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// LoadCanvas loads an image scaled to fit within maxWidth x maxHeight
+// cells.
+func LoadCanvas(path string, maxWidthCells, maxHeightCells int) (*Canvas, error) {
+	src, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	scale := fitScale(src, FitPane, maxWidthCells, maxHeightCells)
+
+	origWidth, origHeight := src.size()
+	newWidth := max(int(float64(origWidth)*scale), 1)
+	newHeight := max(int(float64(origHeight)*scale), 1)
+	if w, h, shrink := withinBudget(newWidth, newHeight); shrink {
+		downscaled.Add(1)
+		scale *= float64(w) / float64(newWidth)
+		newWidth, newHeight = w, h
+	}
+
+	return &Canvas{
+		base:   imaging.Clone(src.render(newWidth, newHeight)),
+		scale:  scale,
+		width:  origWidth,
+		height: origHeight,
+		id:     atomic.AddUint32(&imageIDCounter, 1),
+	}, nil
+}
+
+// Size returns the size of the original image in pixels, the space marks
+// are placed in.
+func (c *Canvas) Size() (width, height int) {
+	return c.width, c.height
+}
+
+// Scale returns how many pixels the canvas has per pixel of the original.
+func (c *Canvas) Scale() float64 {
+	return c.scale
+}
+
+// Draw returns the canvas with marks drawn on it, later marks over
+// earlier ones.
+func (c *Canvas) Draw(marks []Mark) (*KittyImage, error) {
+	img := imaging.Clone(c.base)
+	for _, mark := range marks {
+		x := int(float64(mark.X) * c.scale)
+		y := int(float64(mark.Y) * c.scale)
+		switch mark.Style {
+		case MarkSpot:
+			drawDot(img, x, y, 3, spotColor)
+		case MarkSelected:
+			drawDot(img, x, y, 5, selectedColor)
+		case MarkCrosshair:
+			drawCrosshair(img, x, y)
+		default:
+			return nil, fmt.Errorf("unknown mark style %d", mark.Style)
+		}
+	}
+	return encode(img, c.id, c.scale)
+}
+
+// drawDot fills a square of radius r around x, y with a one pixel outline,
+// so it shows on dark lines and white paper alike
+func drawDot(img *goimage.NRGBA, x, y, r int, c color.NRGBA) {
+	for dy := -r - 1; dy <= r+1; dy++ {
+		for dx := -r - 1; dx <= r+1; dx++ {
+			if dx < -r || dx > r || dy < -r || dy > r {
+				setPixel(img, x+dx, y+dy, outlineColor)
+			} else {
+				setPixel(img, x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+// drawCrosshair draws lines through x, y across the whole image, leaving
+// the point itself clear to see what's under it
+func drawCrosshair(img *goimage.NRGBA, x, y int) {
+	const gap = 6
+	b := img.Bounds()
+	for px := b.Min.X; px < b.Max.X; px++ {
+		if px < x-gap || px > x+gap {
+			setPixel(img, px, y, crosshairColor)
+		}
+	}
+	for py := b.Min.Y; py < b.Max.Y; py++ {
+		if py < y-gap || py > y+gap {
+			setPixel(img, x, py, crosshairColor)
+		}
+	}
+}
+
+func setPixel(img *goimage.NRGBA, x, y int, c color.NRGBA) {
+	if (goimage.Point{X: x, Y: y}).In(img.Bounds()) {
+		img.SetNRGBA(x, y, c)
+	}
+}
@@ -0,0 +1,86 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Fallback cell size in pixels, used when the terminal doesn't report one.
+const (
+	defaultCellWidthPx  = 10
+	defaultCellHeightPx = 20
+)
+
+// cellSize holds the current cell size in pixels, packed as width<<16 | height.
+var cellSize atomic.Uint32
+
+func init() {
+	setCellSize(defaultCellWidthPx, defaultCellHeightPx)
+}
+
+func setCellSize(w, h int) {
+	cellSize.Store(uint32(w)<<16 | uint32(h))
+}
+
+// CellSize returns the terminal cell size in pixels used to scale images.
+func CellSize() (width, height int) {
+	v := cellSize.Load()
+	return int(v >> 16), int(v & 0xffff)
+}
+
+// reported is the cell size the terminal answered QueryCellSize with,
+// packed as cellSize is, or 0 if it wasn't asked or didn't answer.
+var reported atomic.Uint32
+
+// QueryCellSize asks the terminal for its cell size when TIOCGWINSZ has no
+// pixel fields and DELICA_CELL_SIZE isn't set, for DetectCellSize to use.
+// It reads the reply from stdin, so it must run before the program takes
+// over its input.
+func QueryCellSize() {
+	if os.Getenv("DELICA_CELL_SIZE") != "" {
+		return
+	}
+	if _, _, ok := terminalCellSize(); ok {
+		return
+	}
+	if w, h, ok := reportedCellSize(); ok {
+		reported.Store(uint32(w)<<16 | uint32(h))
+	}
+}
+
+// parseCellSizeReply reads the cell size from a reply to CSI 16 t such as
+// "\x1b[6;20;10t", which gives the height before the width
+func parseCellSizeReply(reply string) (width, height int, ok bool) {
+	start := strings.Index(reply, "\x1b[6;")
+	if start < 0 {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(reply[start:], "\x1b[6;%d;%dt", &height, &width); err != nil {
+		return 0, 0, false
+	}
+	return width, height, width > 0 && height > 0 && width <= 0xffff && height <= 0xffff
+}
+
+// DetectCellSize updates the cell size from the terminal. DELICA_CELL_SIZE
+// (e.g. "9x18") takes precedence, for terminals or multiplexers that report
+// wrong pixel dimensions. Otherwise the TIOCGWINSZ pixel fields are used,
+// then what the terminal answered QueryCellSize with. The current size is
+// kept if none is available.
+func DetectCellSize() {
+	if v := os.Getenv("DELICA_CELL_SIZE"); v != "" {
+		var w, h int
+		if _, err := fmt.Sscanf(v, "%dx%d", &w, &h); err == nil && w > 0 && h > 0 {
+			setCellSize(w, h)
+			return
+		}
+	}
+	if w, h, ok := terminalCellSize(); ok {
+		setCellSize(w, h)
+		return
+	}
+	if v := reported.Load(); v != 0 {
+		cellSize.Store(v)
+	}
+}
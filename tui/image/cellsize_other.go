@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package image
+
+// terminalCellSize is not supported on this platform.
+func terminalCellSize() (width, height int, ok bool) {
+	return 0, 0, false
+}
+
+// reportedCellSize is not supported on this platform.
+func reportedCellSize() (width, height int, ok bool) {
+	return 0, 0, false
+}
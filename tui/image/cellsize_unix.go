@@ -0,0 +1,37 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package image
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalCellSize divides the window's pixel size by its size in cells, as
+// reported by the TIOCGWINSZ ioctl. Terminals that don't fill in the pixel
+// fields report zero.
+func terminalCellSize() (width, height int, ok bool) {
+	for _, f := range []*os.File{os.Stdout, os.Stdin, os.Stderr} {
+		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+		if err != nil {
+			continue
+		}
+		if ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+			return 0, 0, false
+		}
+		return int(ws.Xpixel) / int(ws.Col), int(ws.Ypixel) / int(ws.Row), true
+	}
+	return 0, 0, false
+}
+
+// reportedCellSize asks the terminal for its cell size in pixels with
+// CSI 16 t, which terminals that leave the TIOCGWINSZ pixel fields empty,
+// or sit behind SSH or a multiplexer that does, may still answer.
+func reportedCellSize() (width, height int, ok bool) {
+	reply, ok := queryTerminal("\x1b[16t")
+	if !ok {
+		return 0, 0, false
+	}
+	return parseCellSizeReply(reply)
+}
@@ -0,0 +1,25 @@
+package image
+
+// Fit is how an image is scaled into its pane.
+type Fit int
+
+const (
+	FitPane    Fit = iota // whole image within the pane
+	FitWidth              // pane width; taller images scroll
+	ActualSize            // one image pixel per screen pixel; pans both ways
+)
+
+func (f Fit) String() string {
+	switch f {
+	case FitWidth:
+		return "fit width"
+	case ActualSize:
+		return "actual size"
+	}
+	return "fit pane"
+}
+
+// Next returns the mode after f, wrapping around.
+func (f Fit) Next() Fit {
+	return (f + 1) % 3
+}
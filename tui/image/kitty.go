@@ -3,9 +3,12 @@ package image
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	goimage "image"
 	"image/png"
 	"os"
+	"strings"
 	"sync/atomic"
 
 	"github.com/disintegration/imaging"
@@ -13,75 +16,259 @@ import (
 
 var imageIDCounter uint32
 
-// KittyImage represents an image prepared for Kitty protocol rendering
+// Disabled turns images off, for -low-bandwidth: every load fails with
+// ErrDisabled, so screens show what they would for a missing diagram and
+// no image data goes to the terminal.
+var Disabled bool
+
+// ErrDisabled is returned by loads while images are Disabled.
+var ErrDisabled = errors.New("images are off")
+
+// KittyImage is an image prepared for drawing in the terminal. It's named
+// for the Kitty protocol, the first one supported; the Renderer in use when
+// it was loaded decides how it's encoded.
 type KittyImage struct {
-	data   string // base64 encoded PNG
+	data   string // as the renderer sends it: base64 PNG for Kitty, sixels
 	width  int    // pixels
 	height int    // pixels
 	id     uint32
+	scale  float64 // scaled pixels per original pixel
+
+	// Quantized pixels, kept by Sixel to draw regions, which it can't
+	// crop from what it sent
+	pixels *goimage.Paletted
+
+	// A pixel per half cell, kept by Blocks to draw rows as text
+	cells *goimage.NRGBA
+
+	// Cell size in pixels when the image was scaled
+	cellWidth  int
+	cellHeight int
 }
 
 // LoadAndScale loads an image, scales it to fit within maxWidth x maxHeight cells,
 // and prepares it for Kitty protocol rendering.
-// Assumes ~10 pixels per cell width, ~20 pixels per cell height.
+// Cells are converted to pixels using the size from DetectCellSize.
 func LoadAndScale(path string, maxWidthCells, maxHeightCells int) (*KittyImage, error) {
+	return LoadFit(path, FitPane, maxWidthCells, maxHeightCells)
+}
+
+// LoadFit loads an image, scales it into maxWidth x maxHeight cells as fit
+// says, and prepares it for Kitty protocol rendering. FitWidth ignores the
+// height and ActualSize ignores both.
+// Cells are converted to pixels using the size from DetectCellSize.
+func LoadFit(path string, fit Fit, maxWidthCells, maxHeightCells int) (*KittyImage, error) {
+	src, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(src, fitScale(src, fit, maxWidthCells, maxHeightCells))
+}
+
+// fitScale is the scale that fits src into maxWidth x maxHeight cells as
+// fit says
+func fitScale(src source, fit Fit, maxWidthCells, maxHeightCells int) float64 {
+	// Convert cells to pixels
+	cellWidth, cellHeight := CellSize()
+	maxWidthPx := maxWidthCells * cellWidth
+	maxHeightPx := maxHeightCells * cellHeight
+
+	origWidth, origHeight := src.size()
+
+	// Calculate scale factor
+	scale := 1.0
+	switch fit {
+	case FitPane:
+		scale = min(float64(maxWidthPx)/float64(origWidth), float64(maxHeightPx)/float64(origHeight))
+	case FitWidth:
+		scale = float64(maxWidthPx) / float64(origWidth)
+	}
+	return scale
+}
+
+// LoadScaled loads an image scaled by scale, where 1 is actual size, for
+// zooming by arbitrary steps.
+func LoadScaled(path string, scale float64) (*KittyImage, error) {
+	src, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(src, scale)
+}
+
+// Open decodes an image at its natural size, rasterizing SVG diagrams.
+func Open(path string) (goimage.Image, error) {
+	src, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	return src.render(src.size()), nil
+}
+
+// source is an image that can be drawn at any size: a decoded bitmap is
+// resampled, an SVG rasterized afresh
+type source interface {
+	size() (width, height int)
+	render(width, height int) goimage.Image
+}
+
+type bitmapSource struct {
+	img goimage.Image
+}
+
+func (s bitmapSource) size() (int, int) {
+	return s.img.Bounds().Dx(), s.img.Bounds().Dy()
+}
+
+func (s bitmapSource) render(width, height int) goimage.Image {
+	if w, h := s.size(); w == width && h == height {
+		return s.img
+	}
+	return imaging.Resize(s.img, width, height, imaging.Lanczos)
+}
+
+func open(path string) (source, error) {
+	if Disabled {
+		return nil, ErrDisabled
+	}
+
 	// Check file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file not found: %s", path)
 	}
 
-	// Load image
-	img, err := imaging.Open(path)
+	if IsSVG(path) {
+		src, err := openSVG(path)
+		if err != nil {
+			return nil, fmt.Errorf("open image: %w", err)
+		}
+		return src, nil
+	}
+
+	// Load image, within the pixel budget
+	img, err := openBitmap(path)
 	if err != nil {
 		return nil, fmt.Errorf("open image: %w", err)
 	}
+	return bitmapSource{img}, nil
+}
 
-	// Convert cells to pixels (approximate)
-	maxWidthPx := maxWidthCells * 10
-	maxHeightPx := maxHeightCells * 20
+// prepare scales src and encodes it for Kitty protocol rendering
+func prepare(src source, scale float64) (*KittyImage, error) {
+	origWidth, origHeight := src.size()
 
-	// Scale to fit
-	bounds := img.Bounds()
-	origWidth := bounds.Dx()
-	origHeight := bounds.Dy()
+	newWidth := max(int(float64(origWidth)*scale), 1)
+	newHeight := max(int(float64(origHeight)*scale), 1)
 
-	// Calculate scale factor
-	scaleW := float64(maxWidthPx) / float64(origWidth)
-	scaleH := float64(maxHeightPx) / float64(origHeight)
-	scale := scaleW
-	if scaleH < scaleW {
-		scale = scaleH
+	// Zooming in, or a huge SVG at actual size, mustn't render past the
+	// pixel budget either
+	if w, h, shrink := withinBudget(newWidth, newHeight); shrink {
+		downscaled.Add(1)
+		scale *= float64(w) / float64(newWidth)
+		newWidth, newHeight = w, h
 	}
 
-	newWidth := int(float64(origWidth) * scale)
-	newHeight := int(float64(origHeight) * scale)
-
 	// Resize
-	resized := imaging.Resize(img, newWidth, newHeight, imaging.Lanczos)
+	img := src.render(newWidth, newHeight)
 
-	// Encode to PNG
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, resized); err != nil {
-		return nil, fmt.Errorf("encode png: %w", err)
-	}
-
-	// Base64 encode
-	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	return encode(img, atomic.AddUint32(&imageIDCounter, 1), scale)
+}
 
-	id := atomic.AddUint32(&imageIDCounter, 1)
+// encode prepares a scaled image under id for the renderer in use
+func encode(img goimage.Image, id uint32, scale float64) (*KittyImage, error) {
+	cellWidth, cellHeight := CellSize()
 
-	return &KittyImage{
-		data:   encoded,
-		width:  newWidth,
-		height: newHeight,
+	k := &KittyImage{
+		width:  img.Bounds().Dx(),
+		height: img.Bounds().Dy(),
 		id:     id,
-	}, nil
+		scale:  scale,
+
+		cellWidth:  cellWidth,
+		cellHeight: cellHeight,
+	}
+	if err := renderer.encode(img, k); err != nil {
+		return nil, err
+	}
+	return k, nil
 }
 
 // Render returns the escape sequence to display the image.
 // The image is transmitted and displayed in one command.
 // Note: Caller is responsible for cursor positioning if needed.
 func (img *KittyImage) Render() string {
+	return renderer.Render(img, goimage.Rectangle{})
+}
+
+// RenderRegion displays only the part of the image starting at cell col,
+// row and spanning widthCells x heightCells, clipped to the image.
+func (img *KittyImage) RenderRegion(col, row, widthCells, heightCells int) string {
+	x := min(col*img.cellWidth, img.width)
+	y := min(row*img.cellHeight, img.height)
+	w := min(widthCells*img.cellWidth, img.width-x)
+	h := min(heightCells*img.cellHeight, img.height-y)
+	return renderer.Render(img, goimage.Rect(x, y, x+w, y+h))
+}
+
+// Row returns a row of cells of the image drawn as text, for the renderers
+// that draw images as text, or "" for the rest, whose images Render draws.
+// Screens put the rows in the lines they leave blank for the image.
+func (img *KittyImage) Row(row int) string {
+	return renderer.row(img, row, 0, 0)
+}
+
+// RowRegion is Row for the widthCells cells from col, as RenderRegion
+// draws them.
+func (img *KittyImage) RowRegion(col, row, widthCells int) string {
+	return renderer.row(img, row, col, widthCells)
+}
+
+// Inline returns the image for printing into the terminal's normal flow, as
+// cat would, rather than drawn over a screen: at the cursor, leaving it on
+// the image's last line. Images printed this way stay in the scrollback.
+func (img *KittyImage) Inline() string {
+	if img.cells == nil {
+		// Without an ID, so the next run's images, numbered from 1 again,
+		// don't replace it
+		anonymous := *img
+		anonymous.id = 0
+		return anonymous.Render()
+	}
+	rows := make([]string, img.CellHeight())
+	for i := range rows {
+		rows[i] = img.Row(i)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// kittyRenderer draws images with the Kitty graphics protocol, sending each
+// as a PNG
+type kittyRenderer struct{}
+
+func (kittyRenderer) Name() string { return "kitty" }
+
+func (kittyRenderer) encode(img goimage.Image, k *KittyImage) error {
+	// Encode to PNG
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("encode png: %w", err)
+	}
+
+	// Base64 encode
+	k.data = base64.StdEncoding.EncodeToString(buf.Bytes())
+	return nil
+}
+
+// Render transmits and displays the image, or the region of it
+func (kittyRenderer) Render(img *KittyImage, region goimage.Rectangle) string {
+	var keys string
+	if img.id != 0 {
+		keys = fmt.Sprintf("i=%d,", img.id)
+	}
+	if !region.Empty() {
+		keys += fmt.Sprintf("x=%d,y=%d,w=%d,h=%d,", region.Min.X, region.Min.Y, region.Dx(), region.Dy())
+	}
+
 	// Kitty graphics protocol:
 	// \x1b_G<key>=<value>,...;<payload>\x1b\\
 	//
@@ -89,10 +276,11 @@ func (img *KittyImage) Render() string {
 	// a=T - transmit and display
 	// f=100 - PNG format
 	// t=d - direct transmission
-	// i=<id> - image ID
+	// i=<id> - image ID, left for the terminal to pick if 0
 	// s=<width> - width in pixels
 	// v=<height> - height in pixels
 	// q=2 - suppress responses
+	// x,y,w,h - optional source rectangle in pixels
 
 	// For large images, we need to chunk the data
 	// Kitty protocol recommends chunks of 4096 bytes
@@ -115,8 +303,8 @@ func (img *KittyImage) Render() string {
 
 		result.WriteString("\x1b_G")
 		if first {
-			result.WriteString(fmt.Sprintf("a=T,f=100,t=d,i=%d,s=%d,v=%d,q=2,m=%d;",
-				img.id, img.width, img.height, more))
+			result.WriteString(fmt.Sprintf("a=T,f=100,t=d,s=%d,v=%d,%sq=2,m=%d;",
+				img.width, img.height, keys, more))
 			first = false
 		} else {
 			result.WriteString(fmt.Sprintf("m=%d;", more))
@@ -128,17 +316,39 @@ func (img *KittyImage) Render() string {
 	return result.String()
 }
 
-// Clear returns the escape sequence to delete an image by ID.
-func Clear(id uint32) string {
+func (kittyRenderer) row(*KittyImage, int, int, int) string { return "" }
+
+func (kittyRenderer) Clear(id uint32) string {
 	// a=d - delete
 	// d=I - delete by ID
 	// i=<id> - image ID
 	return fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", id)
 }
 
+func (kittyRenderer) ClearAll() string {
+	return "\x1b_Ga=d,d=A,q=2\x1b\\"
+}
+
+// Clear returns the escape sequence to delete an image by ID.
+func Clear(id uint32) string {
+	return renderer.Clear(id)
+}
+
 // ClearAll returns the escape sequence to delete all images.
 func ClearAll() string {
-	return "\x1b_Ga=d,d=A,q=2\x1b\\"
+	return renderer.ClearAll()
+}
+
+// Bytes is the memory the encoded image takes.
+func (img *KittyImage) Bytes() int {
+	n := len(img.data)
+	if img.pixels != nil {
+		n += len(img.pixels.Pix)
+	}
+	if img.cells != nil {
+		n += len(img.cells.Pix)
+	}
+	return n
 }
 
 // ID returns the image's unique identifier.
@@ -146,12 +356,17 @@ func (img *KittyImage) ID() uint32 {
 	return img.id
 }
 
-// CellHeight estimates the height in terminal cells.
+// CellHeight returns the height in terminal cells.
 func (img *KittyImage) CellHeight() int {
-	return (img.height + 19) / 20 // Round up
+	return (img.height + img.cellHeight - 1) / img.cellHeight // Round up
 }
 
-// CellWidth estimates the width in terminal cells.
+// CellWidth returns the width in terminal cells.
 func (img *KittyImage) CellWidth() int {
-	return (img.width + 9) / 10 // Round up
+	return (img.width + img.cellWidth - 1) / img.cellWidth // Round up
+}
+
+// Scale returns how many pixels the image has per pixel of the original.
+func (img *KittyImage) Scale() float64 {
+	return img.scale
 }
@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package image
+
+// deviceAttributes is not supported on this platform, which keeps Kitty
+// unless DELICA_IMAGE_PROTOCOL says otherwise.
+func deviceAttributes() ([]int, bool) {
+	return nil, false
+}
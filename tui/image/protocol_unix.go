@@ -0,0 +1,73 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package image
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"golang.org/x/sys/unix"
+)
+
+// attributesTimeout is how long to wait for the terminal to answer the
+// device attributes query; every terminal in use answers at once
+const attributesTimeout = 200 * time.Millisecond
+
+// deviceAttributes asks the terminal for its primary device attributes. It
+// reports false when stdin or stdout isn't a terminal, or no reply comes in
+// time.
+func deviceAttributes() ([]int, bool) {
+	reply, ok := queryTerminal("")
+	if !ok {
+		return nil, false
+	}
+	return parseDeviceAttributes(reply)
+}
+
+// queryTerminal writes query followed by the device attributes query and
+// returns everything the terminal replies up to the end of its attributes,
+// read from stdin in raw mode. Every terminal answers the attributes query,
+// after anything it answers to query, so a query it doesn't know costs no
+// more than the round trip. It reports false when stdin or stdout isn't a
+// terminal, or no reply comes in time.
+func queryTerminal(query string) (string, bool) {
+	in, out := os.Stdin.Fd(), os.Stdout.Fd()
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return "", false
+	}
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return "", false
+	}
+	defer term.Restore(in, state)
+
+	if _, err := os.Stdout.WriteString(query + "\x1b[c"); err != nil {
+		return "", false
+	}
+
+	var reply strings.Builder
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(attributesTimeout)
+	for !strings.HasSuffix(reply.String(), "c") {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return "", false
+		}
+		fds := []unix.PollFd{{Fd: int32(in), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(wait.Milliseconds())+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n == 0 {
+			return "", false
+		}
+		n, err = unix.Read(int(in), buf)
+		if err != nil || n == 0 {
+			return "", false
+		}
+		reply.Write(buf[:n])
+	}
+	return reply.String(), true
+}
@@ -0,0 +1,141 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Renderer draws prepared images with one terminal graphics protocol.
+type Renderer interface {
+	// Name is the protocol's name, as DELICA_IMAGE_PROTOCOL gives it
+	Name() string
+
+	// Render returns the escape sequence drawing the image at the cursor,
+	// only the region of it in pixels unless region is empty, or "" if the
+	// renderer draws images as text
+	Render(img *KittyImage, region goimage.Rectangle) string
+
+	// Clear and ClearAll return the escape sequences deleting an image
+	// drawn before, or every one, or "" if the protocol has none
+	Clear(id uint32) string
+	ClearAll() string
+
+	// encode fills in the data the renderer sends for a scaled image
+	encode(img goimage.Image, k *KittyImage) error
+
+	// row returns a row of cells of the image as text, from col for
+	// widthCells cells or to the edge if 0, or "" if Render draws it
+	row(img *KittyImage, row, col, widthCells int) string
+}
+
+// The renderers, by protocol
+var (
+	Kitty  Renderer = kittyRenderer{}
+	Sixel  Renderer = sixelRenderer{}
+	Blocks Renderer = blocksRenderer{}
+	ASCII  Renderer = blocksRenderer{ascii: true}
+)
+
+// renderer draws every image; Kitty unless DetectProtocol finds otherwise
+var renderer = Kitty
+
+// Use sets the renderer images are encoded and drawn with. Images loaded
+// before keep the encoding they have, so it's set before any are loaded.
+func Use(r Renderer) {
+	renderer = r
+}
+
+// Protocol returns the name of the protocol images are drawn with.
+func Protocol() string {
+	return renderer.Name()
+}
+
+// ParseProtocol reads a protocol name as DELICA_IMAGE_PROTOCOL gives it.
+func ParseProtocol(name string) (Renderer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "kitty":
+		return Kitty, nil
+	case "sixel":
+		return Sixel, nil
+	case "blocks":
+		return Blocks, nil
+	case "ascii":
+		return ASCII, nil
+	}
+	return nil, fmt.Errorf("unknown image protocol %q (want kitty, sixel, blocks or ascii)", name)
+}
+
+// DetectProtocol picks the renderer for the terminal, before the program
+// takes over its input. DELICA_IMAGE_PROTOCOL (kitty, sixel, blocks or
+// ascii) takes precedence. Otherwise terminals known for the Kitty protocol
+// use it, and others that answer the device attributes query with sixel
+// support get Sixel, as foot, mlterm and WezTerm (whose Kitty support is off
+// by default) do. Terminals that answer without sixel support, such as
+// GNOME Terminal, Alacritty and Terminal.app, have no graphics protocol and
+// get Blocks. A terminal that doesn't answer keeps Kitty.
+func DetectProtocol() error {
+	if v := os.Getenv("DELICA_IMAGE_PROTOCOL"); v != "" && v != "auto" {
+		r, err := ParseProtocol(v)
+		if err != nil {
+			return err
+		}
+		Use(r)
+		return nil
+	}
+	if kittyTerminal() {
+		Use(Kitty)
+		return nil
+	}
+	attrs, ok := deviceAttributes()
+	switch {
+	case !ok:
+	case slices.Contains(attrs, sixelAttribute):
+		Use(Sixel)
+	default:
+		Use(Blocks)
+	}
+	return nil
+}
+
+// kittyTerminal reports whether the environment names a terminal that
+// speaks the Kitty graphics protocol
+func kittyTerminal() bool {
+	// Konsole draws Kitty images too
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("GHOSTTY_RESOURCES_DIR") != "" || os.Getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	switch os.Getenv("TERM") {
+	case "xterm-kitty", "xterm-ghostty":
+		return true
+	}
+	return strings.EqualFold(os.Getenv("TERM_PROGRAM"), "ghostty")
+}
+
+// sixelAttribute is the primary device attribute of terminals that draw
+// sixels
+const sixelAttribute = 4
+
+// parseDeviceAttributes reads a primary device attributes reply such as
+// "\x1b[?62;4;22c" into its numbers
+func parseDeviceAttributes(reply string) ([]int, bool) {
+	start := strings.Index(reply, "\x1b[?")
+	if start < 0 {
+		return nil, false
+	}
+	reply = reply[start+3:]
+	end := strings.IndexByte(reply, 'c')
+	if end < 0 {
+		return nil, false
+	}
+	var attrs []int
+	for _, field := range strings.Split(reply[:end], ";") {
+		if n, err := strconv.Atoi(field); err == nil {
+			attrs = append(attrs, n)
+		}
+	}
+	return attrs, len(attrs) > 0
+}
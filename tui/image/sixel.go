@@ -0,0 +1,125 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"slices"
+	"strings"
+)
+
+// sixelRenderer draws images as sixels, for terminals such as foot, mlterm
+// and WezTerm that don't speak the Kitty protocol. Images are quantized to
+// a fixed 256 color palette with dithering, which suits diagrams, mostly
+// line art, well enough. Sixels can't be deleted; they go as text is drawn
+// over them.
+type sixelRenderer struct{}
+
+func (sixelRenderer) Name() string { return "sixel" }
+
+// sixelPalette is the colors images are quantized to, with transparent
+// last for pixels that aren't drawn
+var sixelPalette = append(slices.Clone(palette.Plan9[:255]), color.Transparent)
+
+func (sixelRenderer) encode(img goimage.Image, k *KittyImage) error {
+	bounds := img.Bounds()
+	pixels := goimage.NewPaletted(goimage.Rect(0, 0, bounds.Dx(), bounds.Dy()), sixelPalette)
+	draw.FloydSteinberg.Draw(pixels, pixels.Bounds(), img, bounds.Min)
+	k.pixels = pixels
+	k.data = encodeSixel(pixels)
+	return nil
+}
+
+// Render draws the image, encoding the region afresh if there is one
+func (sixelRenderer) Render(img *KittyImage, region goimage.Rectangle) string {
+	if region.Empty() || img.pixels == nil {
+		return img.data
+	}
+	return encodeSixel(img.pixels.SubImage(region).(*goimage.Paletted))
+}
+
+func (sixelRenderer) row(*KittyImage, int, int, int) string { return "" }
+
+func (sixelRenderer) Clear(id uint32) string { return "" }
+
+func (sixelRenderer) ClearAll() string { return "" }
+
+// encodeSixel writes a paletted image as a sixel sequence: the palette, then
+// each band of six rows as a pass per color over it, run-length encoded.
+// The transparent color isn't drawn, leaving what's behind.
+func encodeSixel(img *goimage.Paletted) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	transparent := uint8(len(img.Palette) - 1)
+
+	var b strings.Builder
+	// P2=1 leaves undrawn pixels as they are; the raster attributes give
+	// a 1:1 aspect ratio and the size
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range img.Palette[:transparent] {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	// Sixels per color for the band, each a bit per row
+	rows := make(map[uint8][]byte)
+	var colors []uint8
+	for top := 0; top < height; top += 6 {
+		clear(rows)
+		colors = colors[:0]
+		for dy := 0; dy < 6 && top+dy < height; dy++ {
+			y := bounds.Min.Y + top + dy
+			for x := 0; x < width; x++ {
+				c := img.ColorIndexAt(bounds.Min.X+x, y)
+				if c == transparent {
+					continue
+				}
+				row, ok := rows[c]
+				if !ok {
+					row = make([]byte, width)
+					rows[c] = row
+					colors = append(colors, c)
+				}
+				row[x] |= 1 << dy
+			}
+		}
+
+		slices.Sort(colors)
+		for i, c := range colors {
+			if i > 0 {
+				b.WriteByte('$') // back to the start of the band
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRow(&b, rows[c])
+		}
+		b.WriteByte('-') // next band
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// writeSixelRow writes a color's sixels along a band, repeats as !count
+func writeSixelRow(b *strings.Builder, row []byte) {
+	// Blank sixels at the end of the row needn't be sent
+	end := len(row)
+	for end > 0 && row[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		run := 1
+		for x+run < end && row[x+run] == row[x] {
+			run++
+		}
+		ch := byte('?' + row[x])
+		if run > 3 {
+			fmt.Fprintf(b, "!%d%c", run, ch)
+		} else {
+			for range run {
+				b.WriteByte(ch)
+			}
+		}
+		x += run
+	}
+}
@@ -0,0 +1,62 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// IsSVG reports whether path names an SVG diagram, by its extension.
+func IsSVG(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".svg")
+}
+
+// svgSource is a parsed SVG, rasterized at whatever size it's shown at so
+// zooming stays sharp. Its natural size comes from the viewBox, or the
+// width and height attributes without one.
+type svgSource struct {
+	icon          *oksvg.SvgIcon
+	width, height int
+}
+
+func openSVG(path string) (*svgSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Traced diagrams often use elements the renderer skips, such as text;
+	// draw what it understands rather than nothing
+	icon, err := oksvg.ReadIconStream(f, oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("parse svg: %w", err)
+	}
+	width, height := int(icon.ViewBox.W), int(icon.ViewBox.H)
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("parse svg: no viewBox, or width and height")
+	}
+	return &svgSource{icon: icon, width: width, height: height}, nil
+}
+
+func (s *svgSource) size() (int, int) {
+	return s.width, s.height
+}
+
+// render draws the SVG at width x height on white, like the catalog's
+// scanned diagrams, so black strokes stay visible on a dark terminal
+func (s *svgSource) render(width, height int) goimage.Image {
+	img := goimage.NewRGBA(goimage.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), goimage.NewUniform(color.White), goimage.Point{}, draw.Src)
+	s.icon.SetTarget(0, 0, float64(width), float64(height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	s.icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+	return img
+}
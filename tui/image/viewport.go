@@ -0,0 +1,82 @@
+package image
+
+import (
+	goimage "image"
+	"sync/atomic"
+
+	"github.com/disintegration/imaging"
+)
+
+// Viewport is an image scaled once for showing a part of it at a time, as
+// the full screen viewer does when zoomed in. Each crop is encoded afresh,
+// so only what's on screen goes to the terminal, under the viewport's image
+// ID so the terminal replaces the last crop rather than stacking them.
+type Viewport struct {
+	scaled *goimage.NRGBA
+	scale  float64
+	id     uint32
+
+	cellWidth  int
+	cellHeight int
+}
+
+// LoadViewport loads an image scaled by scale, where 1 is actual size, or
+// with scale 0 fitted within maxWidth x maxHeight cells.
+func LoadViewport(path string, scale float64, maxWidthCells, maxHeightCells int) (*Viewport, error) {
+	src, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	if scale == 0 {
+		scale = fitScale(src, FitPane, maxWidthCells, maxHeightCells)
+	}
+
+	origWidth, origHeight := src.size()
+	newWidth := max(int(float64(origWidth)*scale), 1)
+	newHeight := max(int(float64(origHeight)*scale), 1)
+	if w, h, shrink := withinBudget(newWidth, newHeight); shrink {
+		downscaled.Add(1)
+		scale *= float64(w) / float64(newWidth)
+		newWidth, newHeight = w, h
+	}
+
+	cellWidth, cellHeight := CellSize()
+	return &Viewport{
+		scaled:     imaging.Clone(src.render(newWidth, newHeight)),
+		scale:      scale,
+		id:         atomic.AddUint32(&imageIDCounter, 1),
+		cellWidth:  cellWidth,
+		cellHeight: cellHeight,
+	}, nil
+}
+
+// Crop returns the widthCells x heightCells cells of the image from col,
+// row, clipped to the image, ready to draw.
+func (v *Viewport) Crop(col, row, widthCells, heightCells int) (*KittyImage, error) {
+	b := v.scaled.Bounds()
+	x := min(col*v.cellWidth, b.Dx()-1)
+	y := min(row*v.cellHeight, b.Dy()-1)
+	w := min(widthCells*v.cellWidth, b.Dx()-x)
+	h := min(heightCells*v.cellHeight, b.Dy()-y)
+	return encode(imaging.Crop(v.scaled, goimage.Rect(x, y, x+w, y+h)), v.id, v.scale)
+}
+
+// ID returns the image ID every crop is drawn under.
+func (v *Viewport) ID() uint32 {
+	return v.id
+}
+
+// Scale returns how many pixels the viewport has per pixel of the original.
+func (v *Viewport) Scale() float64 {
+	return v.scale
+}
+
+// CellWidth returns the width of the whole scaled image in terminal cells.
+func (v *Viewport) CellWidth() int {
+	return (v.scaled.Bounds().Dx() + v.cellWidth - 1) / v.cellWidth
+}
+
+// CellHeight returns the height of the whole scaled image in terminal cells.
+func (v *Viewport) CellHeight() int {
+	return (v.scaled.Bounds().Dy() + v.cellHeight - 1) / v.cellHeight
+}
@@ -0,0 +1,130 @@
+// Package jobs holds job templates: the parts a common job, such as a timing
+// belt service, usually needs. Parts are listed by PNC rather than part
+// number, so one template suits any Space Gear catalog; the TUI resolves
+// them against the user's. Built-in templates ship with the TUI and users
+// add their own as JSON files in data/templates.
+package jobs
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+//go:embed builtin/*.json
+var builtinFS embed.FS
+
+// Item is one part a job needs. Name says what it is, for lines whose PNC
+// the catalog doesn't carry.
+type Item struct {
+	PNC  string `json:"pnc"`
+	Name string `json:"name"`
+	Qty  int    `json:"qty,omitempty"` // 0 means 1
+	Note string `json:"note,omitempty"`
+}
+
+// Template is a named list of parts for a job.
+type Template struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Items       []Item `json:"items"`
+
+	// Source is "built-in" or the file a user template was read from
+	Source string `json:"-"`
+}
+
+// file is a template file: one or more templates, versioned like
+// knowledge base bundles.
+type file struct {
+	Version   int        `json:"version"`
+	Templates []Template `json:"templates"`
+}
+
+// Dir is where user templates are read from.
+func Dir(dataPath string) string {
+	return filepath.Join(dataPath, "templates")
+}
+
+// Parse reads a template file, checking each template has an ID, a name
+// and items with a PNC.
+func Parse(data []byte) ([]Template, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("unsupported version %d", f.Version)
+	}
+	for i, t := range f.Templates {
+		if strings.TrimSpace(t.ID) == "" || strings.TrimSpace(t.Name) == "" {
+			return nil, fmt.Errorf("template %d: id and name are required", i+1)
+		}
+		if len(t.Items) == 0 {
+			return nil, fmt.Errorf("template %s: no items", t.ID)
+		}
+		for j, item := range t.Items {
+			if strings.TrimSpace(item.PNC) == "" {
+				return nil, fmt.Errorf("template %s, item %d: pnc is required", t.ID, j+1)
+			}
+			if item.Qty < 0 {
+				return nil, fmt.Errorf("template %s, item %d: negative qty", t.ID, j+1)
+			}
+		}
+	}
+	return f.Templates, nil
+}
+
+// Load returns the built-in templates and the user's, by name. A user
+// template with a built-in's ID replaces it. Files that can't be read are
+// reported in errs and skipped.
+func Load(dataPath string) (templates []Template, errs []error) {
+	byID := make(map[string]Template)
+
+	builtin, _ := fs.Glob(builtinFS, "builtin/*.json")
+	for _, name := range builtin {
+		data, err := builtinFS.ReadFile(name)
+		if err == nil {
+			var ts []Template
+			if ts, err = Parse(data); err == nil {
+				for _, t := range ts {
+					t.Source = "built-in"
+					byID[t.ID] = t
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(Dir(dataPath), "*.json"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var ts []Template
+			if ts, err = Parse(data); err == nil {
+				for _, t := range ts {
+					t.Source = filepath.Base(path)
+					byID[t.ID] = t
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+		}
+	}
+
+	for _, t := range byID {
+		templates = append(templates, t)
+	}
+	slices.SortFunc(templates, func(a, b Template) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return templates, errs
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/report"
+)
+
+// runJournal exports a chronological journal of notes, bookmarks and time
+// worked, for documenting work on the van.
+func runJournal(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	format := fs.String("format", "md", "Output format: md or csv")
+	output := fs.String("o", "", "Write to file instead of stdout")
+	since := fs.String("since", "", "Only include activity on or after this date (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	if *since != "" {
+		if _, err := time.Parse("2006-01-02", *since); err != nil {
+			return fmt.Errorf("invalid -since date %q (want YYYY-MM-DD)", *since)
+		}
+	}
+
+	entries, err := database.GetJournal(*since)
+	if err != nil {
+		return fmt.Errorf("load journal: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "md", "markdown":
+		return report.WriteJournalMarkdown(w, entries, time.Now())
+	case "csv":
+		return report.WriteJournalCSV(w, entries)
+	default:
+		return fmt.Errorf("unknown format %q (want md or csv)", *format)
+	}
+}
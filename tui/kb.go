@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// kbBundleVersion is written to exported bundles and checked on import
+const kbBundleVersion = 1
+
+// kbBundle is the JSON form knowledge base entries are shared in.
+type kbBundle struct {
+	Version int            `json:"version"`
+	Entries []kbBundleItem `json:"entries"`
+}
+
+type kbBundleItem struct {
+	PNC      string `json:"pnc,omitempty"`
+	Subgroup string `json:"subgroup,omitempty"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	Source   string `json:"source,omitempty"`
+}
+
+// runImportKB adds the entries of a JSON bundle to the knowledge base,
+// updating entries it already has with the same key and title.
+func runImportKB(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("import-kb", flag.ExitOnError)
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: delica-tui import-kb [-dry-run] [-verbose] FILE.json")
+	}
+
+	var in io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("open bundle: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var bundle kbBundle
+	if err := json.NewDecoder(in).Decode(&bundle); err != nil {
+		return fmt.Errorf("read bundle: %w", err)
+	}
+	if bundle.Version > kbBundleVersion {
+		return fmt.Errorf("bundle version %d is newer than this program supports (%d)", bundle.Version, kbBundleVersion)
+	}
+
+	entries := make([]db.KBEntry, len(bundle.Entries))
+	for i, item := range bundle.Entries {
+		entries[i] = db.KBEntry{PNC: item.PNC, SubgroupID: item.Subgroup, Title: item.Title, Body: item.Body, Source: item.Source}
+	}
+	result, err := database.ImportKBEntries(entries, report.dryRun)
+	if err != nil {
+		return err
+	}
+
+	for i, outcome := range result.Outcomes {
+		reportImport(report, outcome, kbEntryLabel(entries[i]))
+	}
+	fmt.Printf("Knowledge base: %d %s, %d %s, %d unchanged\n",
+		result.Added, report.did("added", "to add"), result.Updated, report.did("updated", "to update"), result.Unchanged)
+	if result.Unmatched > 0 {
+		fmt.Printf("Keyed to a PNC or subgroup this catalog doesn't have: %d\n", result.Unmatched)
+	}
+	report.finish()
+	return nil
+}
+
+// kbEntryLabel names an entry by its key and title
+func kbEntryLabel(e db.KBEntry) string {
+	var key []string
+	if e.PNC != "" {
+		key = append(key, "PNC "+strings.ToUpper(e.PNC))
+	}
+	if e.SubgroupID != "" {
+		key = append(key, "subgroup "+e.SubgroupID)
+	}
+	return strings.Join(key, ", ") + ": " + e.Title
+}
+
+// reportImport reports an import's decision on one entry, flagging entries
+// for something the catalog doesn't have
+func reportImport(report *reporter, outcome db.ImportOutcome, label string) {
+	if !outcome.Matched {
+		label += " (not in this catalog)"
+	}
+	if outcome.Action == db.ImportUnchanged {
+		report.skip("%-9s %s", outcome.Action, label)
+	} else {
+		report.change("%-9s %s", outcome.Action, label)
+	}
+}
+
+// runExportKB writes the knowledge base as a JSON bundle for sharing.
+func runExportKB(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("export-kb", flag.ExitOnError)
+	output := fs.String("o", "", "Write to file instead of stdout")
+	fs.Parse(args)
+
+	entries, err := database.GetKBEntries()
+	if err != nil {
+		return fmt.Errorf("load knowledge base: %w", err)
+	}
+
+	bundle := kbBundle{Version: kbBundleVersion, Entries: make([]kbBundleItem, len(entries))}
+	for i, e := range entries {
+		bundle.Entries[i] = kbBundleItem{PNC: e.PNC, Subgroup: e.SubgroupID, Title: e.Title, Body: e.Body, Source: e.Source}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
@@ -0,0 +1,221 @@
+// Package locale formats dates, numbers and prices for screens and
+// human-readable exports. DELICA_LOCALE picks a convention (en-US, en-GB,
+// de-DE, fr-FR, ja-JP and a few more) and DELICA_DATE_FORMAT overrides the
+// date order, e.g. DD/MM/YYYY. Unset, dates are ISO (2006-01-02) and prices
+// read "USD 12.50", which is also what CSV exports always use.
+package locale
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale is a set of formatting conventions.
+type Locale struct {
+	DateLayout string // time layout for dates
+	Decimal    string // decimal separator
+	Thousands  string // digit group separator, "" for none
+	Symbols    bool   // show currency symbols instead of codes
+	SymbolLast bool   // put the currency after the amount, "12,50 €"
+}
+
+// ISO is the default: ISO dates, plain decimals and currency codes.
+var ISO = Locale{DateLayout: "2006-01-02", Decimal: "."}
+
+var presets = map[string]Locale{
+	"en-us": {DateLayout: "01/02/2006", Decimal: ".", Thousands: ",", Symbols: true},
+	"en-ca": {DateLayout: "2006-01-02", Decimal: ".", Thousands: ",", Symbols: true},
+	"en-gb": {DateLayout: "02/01/2006", Decimal: ".", Thousands: ",", Symbols: true},
+	"en-au": {DateLayout: "02/01/2006", Decimal: ".", Thousands: ",", Symbols: true},
+	"en-nz": {DateLayout: "02/01/2006", Decimal: ".", Thousands: ",", Symbols: true},
+	"de-de": {DateLayout: "02.01.2006", Decimal: ",", Thousands: ".", Symbols: true, SymbolLast: true},
+	"nl-nl": {DateLayout: "02-01-2006", Decimal: ",", Thousands: ".", Symbols: true},
+	"fr-fr": {DateLayout: "02/01/2006", Decimal: ",", Thousands: " ", Symbols: true, SymbolLast: true},
+	"ja-jp": {DateLayout: "2006/01/02", Decimal: ".", Thousands: ",", Symbols: true},
+}
+
+// Currency symbols for Symbols locales; other currencies keep their code.
+var symbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"AUD": "A$",
+	"CAD": "C$",
+	"NZD": "NZ$",
+}
+
+// Currencies without minor units
+var wholeCurrencies = map[string]bool{"JPY": true}
+
+// Parse returns the conventions for a locale name such as "en-GB" or
+// "de_DE.UTF-8", or ISO and false if it isn't known.
+func Parse(name string) (Locale, bool) {
+	name, _, _ = strings.Cut(name, ".")
+	name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	if name == "" || name == "iso" || name == "c" || name == "posix" {
+		return ISO, true
+	}
+	if l, ok := presets[name]; ok {
+		return l, true
+	}
+	return ISO, false
+}
+
+// DateLayout converts a pattern like DD/MM/YYYY or YYYY.MM.DD to a time
+// layout.
+func DateLayout(pattern string) string {
+	return strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02").Replace(strings.ToUpper(pattern))
+}
+
+var (
+	current     Locale
+	currentOnce sync.Once
+)
+
+// Current returns the locale from DELICA_LOCALE and DELICA_DATE_FORMAT,
+// read once. Unknown locales fall back to ISO.
+func Current() Locale {
+	currentOnce.Do(func() {
+		current, _ = Parse(os.Getenv("DELICA_LOCALE"))
+		if pattern := os.Getenv("DELICA_DATE_FORMAT"); pattern != "" {
+			current.DateLayout = DateLayout(pattern)
+		}
+	})
+	return current
+}
+
+// Date formats the date of t.
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.DateLayout)
+}
+
+// DateTime formats t to the minute.
+func (l Locale) DateTime(t time.Time) string {
+	return t.Format(l.DateLayout + " 15:04")
+}
+
+// Timestamp layouts found in the database and .env
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006.01.2", // EPC manufacture dates, e.g. 1999.07.3
+}
+
+// DateString reformats the date in a stored timestamp such as SQLite's
+// "2006-01-02 15:04:05", returning s unchanged if it can't be parsed.
+func (l Locale) DateString(s string) string {
+	if t, ok := parseTimestamp(s); ok {
+		return l.Date(t)
+	}
+	return s
+}
+
+// DateTimeString reformats a stored timestamp to the minute, returning s
+// unchanged if it can't be parsed.
+func (l Locale) DateTimeString(s string) string {
+	if t, ok := parseTimestamp(s); ok {
+		return l.DateTime(t)
+	}
+	return s
+}
+
+func parseTimestamp(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Number formats f with the given number of decimals and the locale's
+// separators.
+func (l Locale) Number(f float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+
+	if l.Thousands != "" && len(whole) > 3 {
+		var b strings.Builder
+		lead := len(whole) % 3
+		if lead > 0 {
+			b.WriteString(whole[:lead])
+		}
+		for i := lead; i < len(whole); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.Thousands)
+			}
+			b.WriteString(whole[i : i+3])
+		}
+		whole = b.String()
+	}
+
+	if f < 0 && strings.ContainsAny(s, "123456789") {
+		whole = "-" + whole
+	}
+	if frac == "" {
+		return whole
+	}
+	return whole + l.Decimal + frac
+}
+
+// Price formats an amount in a currency given by its ISO code.
+func (l Locale) Price(amount float64, currency string) string {
+	currency = strings.ToUpper(currency)
+	decimals := 2
+	if wholeCurrencies[currency] {
+		decimals = 0
+	}
+	if !l.Symbols {
+		return currency + " " + l.Number(amount, 2)
+	}
+
+	n := l.Number(amount, decimals)
+	symbol, ok := symbols[currency]
+	switch {
+	case !ok:
+		return currency + " " + n
+	case l.SymbolLast:
+		return n + " " + symbol
+	}
+	return symbol + n
+}
+
+// Hours formats time worked: minutes under an hour, e.g. "45 min", and
+// hours to a tenth above, "2.5 h" or "3 h".
+func (l Locale) Hours(d time.Duration) string {
+	if d < time.Hour {
+		return strconv.Itoa(int(d.Round(time.Minute).Minutes())) + " min"
+	}
+	hours := math.Round(d.Hours()*10) / 10
+	if hours == math.Trunc(hours) {
+		return l.Number(hours, 0) + " h"
+	}
+	return l.Number(hours, 1) + " h"
+}
+
+// Date formats t's date in the current locale.
+func Date(t time.Time) string { return Current().Date(t) }
+
+// DateTime formats t to the minute in the current locale.
+func DateTime(t time.Time) string { return Current().DateTime(t) }
+
+// DateString reformats a stored timestamp's date in the current locale.
+func DateString(s string) string { return Current().DateString(s) }
+
+// DateTimeString reformats a stored timestamp to the minute in the current
+// locale.
+func DateTimeString(s string) string { return Current().DateTimeString(s) }
+
+// Price formats an amount in the current locale.
+func Price(amount float64, currency string) string { return Current().Price(amount, currency) }
+
+// Hours formats time worked in the current locale.
+func Hours(d time.Duration) string { return Current().Hours(d) }
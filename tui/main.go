@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
-	"delica-tui/db"
-	"delica-tui/model"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/model"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
 	"github.com/joho/godotenv"
 )
 
 func main() {
 	dataPath := flag.String("data", "./data", "Path to data directory (contains delica.db and images/)")
+	lowBandwidth := flag.Bool("low-bandwidth", false, "For slow links such as SSH over a phone hotspot: no images or colors, fewer redraws")
 	flag.Parse()
 
 	// Resolve to absolute path
@@ -36,11 +41,94 @@ func main() {
 	}
 	defer database.Close()
 
+	// Subcommands run non-interactively and exit
+	if flag.NArg() > 0 {
+		var err error
+		switch cmd := flag.Arg(0); cmd {
+		case "report":
+			err = runReport(database, flag.Args()[1:])
+		case "migrate-saved":
+			err = runMigrateSaved(database, absDataPath, flag.Args()[1:])
+		case "export-diagrams":
+			err = runExportDiagrams(database, absDataPath, flag.Args()[1:])
+		case "journal":
+			err = runJournal(database, flag.Args()[1:])
+		case "digest":
+			err = runDigest(database, flag.Args()[1:])
+		case "aging":
+			err = runAging(database, flag.Args()[1:])
+		case "backup":
+			err = runBackup(database, absDataPath, flag.Args()[1:])
+		case "restore":
+			err = runRestore(database, absDataPath, flag.Args()[1:])
+		case "import-prices":
+			err = runImportPrices(database, flag.Args()[1:])
+		case "import-bookmarks":
+			err = runImportBookmarks(database, flag.Args()[1:])
+		case "import-kb":
+			err = runImportKB(database, flag.Args()[1:])
+		case "export-kb":
+			err = runExportKB(database, flag.Args()[1:])
+		case "import-compat":
+			err = runImportCompat(database, flag.Args()[1:])
+		case "export-compat":
+			err = runExportCompat(database, flag.Args()[1:])
+		case "sync":
+			err = runSync(database, absDataPath, flag.Args()[1:])
+		case "serve":
+			err = runServe(database, absDataPath, flag.Args()[1:])
+		case "gc":
+			err = runGC(database, absDataPath, flag.Args()[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", cmd)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Measure cells before any screen scales an image, and ask the terminal
+	// for them and which protocol draws them while its replies can still be
+	// read
+	image.QueryCellSize()
+	image.DetectCellSize()
+	if err := image.DetectProtocol(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if *lowBandwidth {
+		// Before the home screen loads its banner
+		image.Disabled = true
+		ui.SetLowBandwidth()
+		opts = append(opts, tea.WithFPS(ui.LowBandwidthFPS))
+	}
 	m := model.New(database, absDataPath)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	// Capturing the mouse stops the terminal selecting text, so it's opt-in
+	if mouse, _ := strconv.ParseBool(os.Getenv("DELICA_MOUSE")); mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, opts...)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Diagrams kept with P go into the scrollback, now the alternate
+	// screen is gone
+	if m, ok := final.(*model.Model); ok {
+		width, _, err := term.GetSize(os.Stdout.Fd())
+		if err != nil {
+			width = 80
+		}
+		if err := m.PrintKeptDiagrams(os.Stdout, width); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// runMigrateSaved moves bookmarks, notes and attachments from each
+// superseded part whose replacement is in the catalog to the replacement,
+// as m does on part detail, typically after report lists them. User data is
+// backed up before anything is moved.
+func runMigrateSaved(database *db.DB, dataPath string, args []string) error {
+	flags := flag.NewFlagSet("migrate-saved", flag.ExitOnError)
+	report := reportFlags(flags)
+	flags.Parse(args)
+
+	changes, err := database.GetSavedPartChanges()
+	if err != nil {
+		return fmt.Errorf("load changes: %w", err)
+	}
+	var movable []db.SavedPartChange
+	for _, c := range changes {
+		if c.Missing || !c.ReplacementInCatalog {
+			report.skip("%s: no replacement in the catalog", c.PartNumber)
+			continue
+		}
+		movable = append(movable, c)
+	}
+
+	if len(movable) > 0 && !report.dryRun {
+		safety := filepath.Join(db.BackupDir(filepath.Join(dataPath, "delica.db")), db.BackupName(time.Now(), "pre-migrate"))
+		if _, err := database.Backup(safety); err != nil {
+			os.Remove(safety)
+			return fmt.Errorf("back up user data: %w", err)
+		}
+		fmt.Printf("User data saved to %s\n", safety)
+	}
+
+	for _, c := range movable {
+		if report.dryRun {
+			report.change("move %s from %s to %s", savedWhat(c.Bookmarked, c.HasNote, 0), c.PartNumber, *c.ReplacementPartNumber)
+			continue
+		}
+		m, err := database.MigrateToReplacement(c.PartID)
+		if err != nil {
+			return fmt.Errorf("move %s: %w", c.PartNumber, err)
+		}
+		report.change("moved %s from %s to %s", savedWhat(m.Bookmark, m.Note, m.Attachments), m.FromPartNumber, m.ToPartNumber)
+	}
+	fmt.Printf("%s saved data of %d superseded parts to their replacements\n", report.did("Moved", "Would move"), len(movable))
+	report.finish()
+	return nil
+}
+
+// savedWhat lists what's saved for a part, e.g. "bookmark, note"
+func savedWhat(bookmark, note bool, attachments int) string {
+	var saved []string
+	if bookmark {
+		saved = append(saved, "bookmark")
+	}
+	if note {
+		saved = append(saved, "note")
+	}
+	if attachments > 0 {
+		saved = append(saved, fmt.Sprintf("%d attachments", attachments))
+	}
+	return strings.Join(saved, ", ")
+}
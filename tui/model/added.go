@@ -0,0 +1,199 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// addedPeriods are the spans, in days, p cycles the recently added screen
+// through; the first is where it starts
+var addedPeriods = []int{30, 90, 365, 7}
+
+var addedColumns = []ui.Column{
+	{Title: "GROUP", Width: 14},
+	{Title: "SUBGROUP", Width: 18},
+	{Title: "PART", Width: 14},
+	{Title: "DESCRIPTION"},
+	{Title: "ADDED", Width: 11},
+}
+
+// addedSince returns the time days ago in the form the scraper stamps
+// parts with
+func addedSince(days int) string {
+	return time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+}
+
+// AddedModel lists the parts syncs have added to the catalog lately, by
+// group, to find diagrams published for late-model vans since the first
+// scrape.
+type AddedModel struct {
+	db     *db.DB
+	days   int
+	parts  []db.AddedPart
+	groups int
+	table  *ui.Table
+}
+
+func NewAddedModel(database *db.DB, days int) *AddedModel {
+	m := &AddedModel{db: database}
+	m.load(days)
+	return m
+}
+
+func (m *AddedModel) load(days int) {
+	m.days = days
+	m.parts, _ = m.db.GetAddedParts(addedSince(days))
+
+	rows := make([]ui.TableRow, len(m.parts))
+	groups := make(map[string]bool)
+	for i, p := range m.parts {
+		groups[p.GroupName] = true
+		rows[i] = ui.TableRow{
+			ID:    fmt.Sprintf("%d", p.PartID),
+			Cells: []string{p.GroupName, p.SubgroupName, p.PartNumber, deref(p.Description), locale.DateString(p.AddedAt)},
+		}
+	}
+	m.groups = len(groups)
+	m.table = ui.NewTable(addedColumns, rows)
+}
+
+// nextPeriod moves to the next span of days, keeping the cursor on the
+// same part if it's still listed
+func (m *AddedModel) nextPeriod() {
+	next := addedPeriods[0]
+	for i, days := range addedPeriods {
+		if days == m.days {
+			next = addedPeriods[(i+1)%len(addedPeriods)]
+		}
+	}
+	prev := m.table
+	m.load(next)
+	m.table.KeepPosition(prev)
+}
+
+func (m *AddedModel) Update(msg tea.Msg) (*AddedModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if ui.IsPeriod(msg) {
+			m.nextPeriod()
+			return m, nil, nil
+		}
+		m.table.HandleKey(msg)
+		if ui.IsEnter(msg) {
+			if item := m.table.Selected(); item != nil {
+				var partID int
+				fmt.Sscanf(item.ID, "%d", &partID)
+				s := PartDetailScreen(partID, false)
+				return m, nil, &s
+			}
+		}
+	}
+	return m, nil, nil
+}
+
+func (m *AddedModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *AddedModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("RECENTLY ADDED"))
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("Last %d days", m.days))
+	switch len(m.parts) {
+	case 0:
+		lines = append(lines, "No new parts")
+	case 1:
+		lines = append(lines, "1 new part")
+	default:
+		lines = append(lines, fmt.Sprintf("%d new parts in %d groups", len(m.parts), m.groups))
+	}
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Parts a sync found in"))
+	lines = append(lines, ui.DimStyle.Render("groups already scraped"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *AddedModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Header
+	b.WriteString(ui.HeaderStyle.Render("NEW IN THE CATALOG"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles)
+	tableHeight := height - 4
+	if tableHeight < 6 {
+		tableHeight = 6
+	}
+	if tableHeight > 16 {
+		tableHeight = 16
+	}
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
+
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	if len(m.parts) == 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("Nothing added in the last %d days", m.days)))
+		b.WriteString("\n\n")
+		b.WriteString(ui.DimStyle.Render("Run delica-tui sync to check"))
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("the catalog for new parts"))
+	} else {
+		b.WriteString(m.table.View())
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   p period   " + m.table.SortHint()))
+
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// attachment is an external file listed under a part's note.
+type attachment struct {
+	path    string
+	missing bool // the file was moved or deleted since it was attached
+}
+
+func loadAttachments(database *db.DB, partID int) []attachment {
+	paths, _ := database.GetAttachments(partID)
+	attachments := make([]attachment, len(paths))
+	for i, p := range paths {
+		_, err := os.Stat(p)
+		attachments[i] = attachment{path: p, missing: err != nil}
+	}
+	return attachments
+}
+
+// attachmentPrompt asks for the path of a file to attach to a part's note.
+type attachmentPrompt struct {
+	active bool
+	input  textinput.Model
+	err    string
+}
+
+func newAttachmentPrompt() attachmentPrompt {
+	ti := textinput.New()
+	ti.Placeholder = "~/Documents/invoice.pdf"
+	ti.CharLimit = 500
+	ti.Width = 40
+	ti.Prompt = ""
+	return attachmentPrompt{input: ti}
+}
+
+func (p *attachmentPrompt) open() tea.Cmd {
+	p.active = true
+	p.err = ""
+	p.input.SetValue("")
+	return p.input.Focus()
+}
+
+func (p *attachmentPrompt) close() {
+	p.active = false
+	p.input.Blur()
+}
+
+// update handles a message while the prompt is open. added is true once a
+// file has been attached, when the caller should reload the list.
+func (p *attachmentPrompt) update(msg tea.Msg, database *db.DB, partID int) (cmd tea.Cmd, added bool) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			p.close()
+			return nil, false
+		case ui.IsEnter(msg):
+			path, err := resolveAttachmentPath(p.input.Value())
+			if err == nil {
+				err = database.AddAttachment(partID, path)
+			}
+			if err != nil {
+				p.err = err.Error()
+				return nil, false
+			}
+			p.close()
+			return nil, true
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	return cmd, false
+}
+
+// resolveAttachmentPath expands ~ and makes the path absolute, so the
+// attachment still opens when the TUI is started from another directory.
+// The file must exist when it is attached.
+func resolveAttachmentPath(input string) (string, error) {
+	path := strings.Trim(strings.TrimSpace(input), `"'`)
+	if path == "" {
+		return "", fmt.Errorf("enter a file path")
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("file not found: %s", path)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	return path, nil
+}
@@ -1,68 +1,178 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"delica-tui/db"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/notify"
+	"github.com/mshick/delica-space-gear-parts/tui/order"
+	"github.com/mshick/delica-space-gear-parts/tui/supplier"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// bookmarkColumns add how many of each part are needed to the located
+// part columns; the first five are shown unless chosen otherwise
+var bookmarkColumns = []listColumn{
+	partNumberColumn,
+	pncColumn,
+	{key: "description", column: ui.Column{Title: "DESCRIPTION", Width: 28}, shown: true},
+	{key: "qty", column: ui.Column{Title: "QTY", Width: 5, Numeric: true}, shown: true},
+	{key: "location", column: ui.Column{Title: "LOCATION"}, shown: true},
+	specColumn,
+	priceColumn,
+	datesColumn,
+}
+
 type BookmarksModel struct {
 	db        *db.DB
+	writes    *writeQueue
 	bookmarks []db.BookmarkResult
-	menu      *ui.Menu
+	table     *ui.Table
+	columns   *columnSet
 }
 
-func NewBookmarksModel(database *db.DB) *BookmarksModel {
-	bookmarks, _ := database.GetBookmarks()
+func NewBookmarksModel(database *db.DB, writes *writeQueue) *BookmarksModel {
+	m := &BookmarksModel{db: database, writes: writes, columns: loadColumnSet(database, "bookmarks", bookmarkColumns)}
+	m.load()
+	return m
+}
 
-	var items []ui.MenuItem
-	for _, b := range bookmarks {
-		label := b.PartNumber
-		if b.PNC != nil {
-			label = fmt.Sprintf("[%s] %s", *b.PNC, b.PartNumber)
-		}
+func (m *BookmarksModel) load() {
+	m.bookmarks, _ = m.db.GetBookmarks()
+	m.render()
+}
 
-		var hintParts []string
-		if b.Description != nil {
-			hintParts = append(hintParts, *b.Description)
+// render rebuilds the table from the bookmarks held
+func (m *BookmarksModel) render() {
+	origins, _ := m.db.GetOrigins()
+	var prices map[string]db.Price
+	if m.columns.has(priceColumn.key) && len(m.bookmarks) > 0 {
+		partNumbers := make([]string, len(m.bookmarks))
+		for i, b := range m.bookmarks {
+			partNumbers[i] = b.PartNumber
 		}
+		prices = partPrices(m.db, partNumbers)
+	}
+
+	rows := make([]ui.TableRow, len(m.bookmarks))
+	for i, b := range m.bookmarks {
+		location := b.GroupName
 		if b.SubgroupName != nil {
-			hintParts = append(hintParts, fmt.Sprintf("%s > %s", b.GroupName, *b.SubgroupName))
-		} else {
-			hintParts = append(hintParts, b.GroupName)
+			location = fmt.Sprintf("%s > %s", b.GroupName, *b.SubgroupName)
 		}
-		hint := strings.Join(hintParts, " - ")
-
-		items = append(items, ui.MenuItem{
-			ID:    fmt.Sprintf("%d", b.PartID),
-			Label: label,
-			Hint:  hint,
+		rows[i] = m.columns.row(fmt.Sprintf("%d", b.PartID), map[string]string{
+			"part":        b.PartNumber,
+			"pnc":         deref(b.PNC),
+			"description": badged(deref(b.Description), origins, b.PartNumber),
+			"qty":         fmt.Sprintf("%d", b.Qty),
+			"location":    location,
+			"spec":        deref(b.Spec),
+			"price":       priceCell(prices, b.PartNumber),
+			"dates":       deref(b.ModelDateRange),
 		})
 	}
 
-	return &BookmarksModel{
-		db:        database,
-		bookmarks: bookmarks,
-		menu:      ui.NewMenu(items),
+	prev := m.table
+	m.table = ui.NewTable(m.columns.columns(), rows)
+	if prev != nil {
+		m.table.KeepPosition(prev)
+	}
+}
+
+// selected returns the bookmark under the cursor
+func (m *BookmarksModel) selected() *db.BookmarkResult {
+	item := m.table.Selected()
+	if item == nil {
+		return nil
+	}
+	for i, b := range m.bookmarks {
+		if fmt.Sprintf("%d", b.PartID) == item.ID {
+			return &m.bookmarks[i]
+		}
+	}
+	return nil
+}
+
+// setQty changes how many of the selected part are needed, showing it
+// straight away and queueing the write
+func (m *BookmarksModel) setQty(delta int) tea.Cmd {
+	b := m.selected()
+	if b == nil {
+		return nil
+	}
+	qty := max(b.Qty+delta, 1)
+	if qty == b.Qty {
+		return nil
+	}
+	b.Qty = qty
+	m.render()
+
+	database, partID := m.db, b.PartID
+	event := webhook.Event{Kind: "bookmark", Action: "set", PartID: partID, PartNumber: b.PartNumber, Description: deref(b.Description), Qty: qty}
+	return m.writes.enqueue(partID, writeBookmarkQty, m.writes.next(), func() error {
+		return database.SetBookmarkQty(partID, qty)
+	}, event)
+}
+
+// orderLines lists the bookmarks as order lines, in the order shown
+func (m *BookmarksModel) orderLines() []order.Line {
+	byID := make(map[string]db.BookmarkResult, len(m.bookmarks))
+	for _, b := range m.bookmarks {
+		byID[fmt.Sprintf("%d", b.PartID)] = b
 	}
+	var lines []order.Line
+	for _, id := range m.table.IDs() {
+		b := byID[id]
+		lines = append(lines, order.Line{PartNumber: b.PartNumber, Description: deref(b.Description), Qty: b.Qty})
+	}
+	return lines
 }
 
 func (m *BookmarksModel) Update(msg tea.Msg) (*BookmarksModel, tea.Cmd, *Screen) {
 	switch msg := msg.(type) {
+	case userDataWrittenMsg:
+		// Show what was saved in place of the quantity that wasn't
+		if msg.kind == writeBookmarkQty && msg.err != nil {
+			m.load()
+			err := msg.err
+			return m, func() tea.Msg { return toastMsg{text: fmt.Sprintf("Quantity not saved: %v", err), isError: true} }, nil
+		}
 	case tea.KeyMsg:
-		if ui.IsUp(msg) {
-			m.menu.Up()
+		// The column chooser takes keys until it's closed; the sorting
+		// starts over when the columns change
+		if m.columns.choosing {
+			if m.columns.update(msg) {
+				m.render()
+				m.table.SortBy(-1, false)
+			}
+			return m, nil, nil
+		}
+		if ui.IsColumns(msg) {
+			m.columns.open()
+			return m, nil, nil
+		}
+		if ui.IsCheckLinks(msg) && len(m.bookmarks) > 0 {
+			return m, m.checkLinks(), nil
+		}
+		if ui.IsCopyOrder(msg) && len(m.bookmarks) > 0 {
+			return m, copyOrderCmd(m.orderLines()), nil
 		}
-		if ui.IsDown(msg) {
-			m.menu.Down()
+		if ui.IsMoreQty(msg) {
+			return m, m.setQty(1), nil
 		}
+		if ui.IsLessQty(msg) {
+			return m, m.setQty(-1), nil
+		}
+		m.table.HandleKey(msg)
 		if ui.IsEnter(msg) {
-			if item := m.menu.Selected(); item != nil {
+			if item := m.table.Selected(); item != nil {
 				var partID int
 				fmt.Sscanf(item.ID, "%d", &partID)
 				s := PartDetailScreen(partID, false)
@@ -73,6 +183,49 @@ func (m *BookmarksModel) Update(msg tea.Msg) (*BookmarksModel, tea.Cmd, *Screen)
 	return m, nil, nil
 }
 
+// checkLinks fetches every imported price link of the bookmarked parts in
+// the progress panel, so dead supplier pages show up as failures
+func (m *BookmarksModel) checkLinks() tea.Cmd {
+	database := m.db
+	partNumbers := make([]string, len(m.bookmarks))
+	for i, b := range m.bookmarks {
+		partNumbers[i] = b.PartNumber
+	}
+	return func() tea.Msg {
+		client, err := netutil.Default()
+		if err != nil {
+			return toastMsg{text: err.Error(), isError: true}
+		}
+		prices, err := database.GetPrices(partNumbers...)
+		if err != nil {
+			return toastMsg{text: fmt.Sprintf("Read prices: %v", err), isError: true}
+		}
+
+		var tasks []bulkTask
+		for _, p := range prices {
+			if p.URL == nil {
+				continue
+			}
+			name := p.SupplierID
+			if s, ok := supplier.ByID(p.SupplierID); ok {
+				name = s.Name()
+			}
+			url := *p.URL
+			tasks = append(tasks, bulkTask{
+				label: fmt.Sprintf("%s %s", name, p.PartNumber),
+				run: func(ctx context.Context) error {
+					_, err := client.Get(ctx, url)
+					return err
+				},
+			})
+		}
+		if len(tasks) == 0 {
+			return toastMsg{text: "No price links on bookmarked parts; import prices with a url column first"}
+		}
+		return bulkStartMsg{kind: notify.KindLinks, title: "Check links", tasks: tasks, network: true}
+	}
+}
+
 func (m *BookmarksModel) View(width, height int) string {
 	if width == 0 {
 		width = 80
@@ -96,7 +249,7 @@ func (m *BookmarksModel) View(width, height int) string {
 	}
 
 	leftContent := m.renderLeftPane(splitHeight)
-	rightContent := m.renderRightPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
 
 	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
 
@@ -110,6 +263,13 @@ func (m *BookmarksModel) renderLeftPane(height int) string {
 	lines = append(lines, ui.HeaderStyle.Render("SAVED PARTS"))
 	lines = append(lines, "")
 	lines = append(lines, fmt.Sprintf("%d bookmarks", len(m.bookmarks)))
+	qty := 0
+	for _, b := range m.bookmarks {
+		qty += b.Qty
+	}
+	if qty > len(m.bookmarks) {
+		lines = append(lines, fmt.Sprintf("%d parts to order", qty))
+	}
 	lines = append(lines, "")
 	lines = append(lines, ui.DimStyle.Render("Press b on any part"))
 	lines = append(lines, ui.DimStyle.Render("to bookmark it"))
@@ -122,7 +282,7 @@ func (m *BookmarksModel) renderLeftPane(height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *BookmarksModel) renderRightPane(height int) string {
+func (m *BookmarksModel) renderRightPane(width, height int) string {
 	var b strings.Builder
 
 	// Header
@@ -130,36 +290,45 @@ func (m *BookmarksModel) renderRightPane(height int) string {
 	b.WriteString("\n")
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
 
-	// Adjust menu visible items based on available height (max 15)
-	menuHeight := height - 5
-	if menuHeight < 5 {
-		menuHeight = 5
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles)
+	tableHeight := height - 4
+	if tableHeight < 6 {
+		tableHeight = 6
 	}
-	if menuHeight > 15 {
-		menuHeight = 15
+	if tableHeight > 16 {
+		tableHeight = 16
 	}
-	m.menu.MaxVisibleItems = menuHeight
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
 
-	// One less blank line if menu scrolls (to account for scroll indicator)
-	if len(m.menu.Items) > m.menu.MaxVisibleItems {
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
 		b.WriteString("\n")
 	} else {
 		b.WriteString("\n\n")
 	}
 
 	// Menu
-	if len(m.bookmarks) == 0 {
+	if m.columns.choosing {
+		b.WriteString(m.columns.view())
+		return b.String()
+	} else if len(m.bookmarks) == 0 {
 		b.WriteString(ui.DimStyle.Render("No bookmarks yet"))
 		b.WriteString("\n\n")
 		b.WriteString(ui.DimStyle.Render("Navigate to a part and"))
 		b.WriteString("\n")
 		b.WriteString(ui.DimStyle.Render("press 'b' to bookmark it"))
 	} else {
-		b.WriteString(m.menu.View())
+		b.WriteString(m.table.View())
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select"))
+	linksHint := "l check price links"
+	if !netutil.Online() {
+		linksHint += " (once back online)"
+	}
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   +/- qty   Y copy order   " + m.table.SortHint() + "   " + linksHint + "   ctrl+k columns"))
 
 	return b.String()
 }
@@ -0,0 +1,347 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Bulk operations run this many tasks at once, and the panel lists this
+// many of them
+const (
+	bulkWorkers = 4
+	bulkRows    = 5
+)
+
+// bulkTask is one unit of a bulk operation, such as exporting one diagram.
+// run should return promptly once ctx is cancelled.
+type bulkTask struct {
+	label string
+	run   func(ctx context.Context) error
+}
+
+// bulkStartMsg asks for tasks to run in the progress panel. Screens return
+// it from a command, the way they start other background work.
+type bulkStartMsg struct {
+	kind   string // notify kind, choosing how the finish is announced
+	title  string
+	detail string // appended to the summary, such as where files went
+	tasks  []bulkTask
+
+	// network marks work that needs the connection, which waits for it
+	// while offline instead of failing task by task
+	network bool
+}
+
+type bulkStatus int
+
+const (
+	bulkPending bulkStatus = iota
+	bulkRunning
+	bulkDone
+	bulkFailed
+	bulkCancelled
+)
+
+// bulkEventMsg reports a task changing status, or with finished set, that
+// every task has stopped
+type bulkEventMsg struct {
+	job      int
+	index    int
+	status   bulkStatus
+	err      error
+	finished bool
+}
+
+// bulkProgress runs one bulk operation at a time and draws its panel along
+// the bottom of the screen. Failures are appended to a log in the data
+// directory when it finishes.
+type bulkProgress struct {
+	job     int // increments per operation, so stale events are dropped
+	kind    string
+	title   string
+	detail  string
+	tasks   []bulkTask
+	status  []bulkStatus
+	errs    []error
+	started time.Time
+	events  chan bulkEventMsg
+	cancel  context.CancelFunc
+	logPath string
+
+	running   bool
+	cancelled bool
+	visible   bool
+	summary   string
+}
+
+// start runs msg's tasks on a pool of workers, or refuses while another
+// operation is running.
+func (p *bulkProgress) start(msg bulkStartMsg, logPath string) tea.Cmd {
+	if p.running {
+		return func() tea.Msg {
+			return toastMsg{text: fmt.Sprintf("Wait for %s to finish, or cancel it", strings.ToLower(p.title)), isError: true}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.job++
+	*p = bulkProgress{
+		job:     p.job,
+		kind:    msg.kind,
+		title:   msg.title,
+		detail:  msg.detail,
+		tasks:   msg.tasks,
+		status:  make([]bulkStatus, len(msg.tasks)),
+		errs:    make([]error, len(msg.tasks)),
+		started: time.Now(),
+		events:  make(chan bulkEventMsg, 2*len(msg.tasks)+1),
+		cancel:  cancel,
+		logPath: logPath,
+		running: true,
+		visible: true,
+	}
+
+	tasks, events := p.tasks, p.events
+	go func() {
+		queue := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < bulkWorkers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					if ctx.Err() != nil {
+						events <- bulkEventMsg{index: i, status: bulkCancelled}
+						continue
+					}
+					events <- bulkEventMsg{index: i, status: bulkRunning}
+					if err := tasks[i].run(ctx); err != nil {
+						status := bulkFailed
+						if ctx.Err() != nil {
+							status = bulkCancelled
+						}
+						events <- bulkEventMsg{index: i, status: status, err: err}
+					} else {
+						events <- bulkEventMsg{index: i, status: bulkDone}
+					}
+				}
+			}()
+		}
+		for i := range tasks {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+		events <- bulkEventMsg{finished: true}
+		close(events)
+	}()
+
+	return p.listen()
+}
+
+// listen waits for the next event of the running operation
+func (p *bulkProgress) listen() tea.Cmd {
+	job, events := p.job, p.events
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return nil
+		}
+		ev.job = job
+		return ev
+	}
+}
+
+// handle records an event, returning the command that waits for the next
+// one. Once every task has stopped it logs failures, announces the finish
+// the way the operation's kind is configured to and, if the panel was
+// hidden, toasts the summary.
+func (p *bulkProgress) handle(ev bulkEventMsg) tea.Cmd {
+	if ev.job != p.job {
+		return nil
+	}
+	if !ev.finished {
+		p.status[ev.index] = ev.status
+		p.errs[ev.index] = ev.err
+		return p.listen()
+	}
+
+	p.running = false
+	p.cancel()
+	done, failed, cancelled := p.counts()
+	p.summary = fmt.Sprintf("%s: %d done", p.title, done)
+	if failed > 0 {
+		p.summary += fmt.Sprintf(", %d failed", failed)
+	}
+	if cancelled > 0 {
+		p.summary += fmt.Sprintf(", %d cancelled", cancelled)
+	}
+	if p.detail != "" {
+		p.summary += " · " + p.detail
+	}
+	if failed > 0 || cancelled > 0 {
+		if err := p.writeLog(); err != nil {
+			p.summary += fmt.Sprintf(" (log not written: %v)", err)
+		} else if failed > 0 {
+			p.summary += " (failures logged to " + p.logPath + ")"
+		}
+	}
+
+	var cmds []tea.Cmd
+	if !p.cancelled {
+		cmds = append(cmds, announce(p.kind, p.title, p.summary))
+	}
+	if !p.visible {
+		summary, isError := p.summary, failed > 0
+		cmds = append(cmds, func() tea.Msg { return toastMsg{text: summary, isError: isError} })
+	}
+	return tea.Batch(cmds...)
+}
+
+// update handles keys while the panel is shown: c cancels, esc hides it and
+// leaves the operation running, or closes it once finished.
+func (p *bulkProgress) update(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case ui.IsCancelBulk(msg) && p.running && !p.cancelled:
+		p.cancelled = true
+		p.cancel()
+	case ui.IsBack(msg), ui.IsEnter(msg) && !p.running:
+		p.visible = false
+	}
+	return nil
+}
+
+func (p *bulkProgress) counts() (done, failed, cancelled int) {
+	for _, s := range p.status {
+		switch s {
+		case bulkDone:
+			done++
+		case bulkFailed:
+			failed++
+		case bulkCancelled:
+			cancelled++
+		}
+	}
+	return done, failed, cancelled
+}
+
+// eta estimates the time left from the average pace so far
+func (p *bulkProgress) eta() (time.Duration, bool) {
+	done, failed, cancelled := p.counts()
+	finished := done + failed + cancelled
+	if finished == 0 || finished == len(p.tasks) {
+		return 0, false
+	}
+	perTask := time.Since(p.started) / time.Duration(finished)
+	return perTask * time.Duration(len(p.tasks)-finished), true
+}
+
+// writeLog appends the operation's failed and cancelled tasks to the log
+func (p *bulkProgress) writeLog() error {
+	f, err := os.OpenFile(p.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := time.Now().Format(time.RFC3339)
+	fmt.Fprintf(f, "%s %s\n", now, p.summary)
+	for i, t := range p.tasks {
+		switch p.status[i] {
+		case bulkFailed:
+			fmt.Fprintf(f, "%s   failed %s: %v\n", now, t.label, p.errs[i])
+		case bulkCancelled:
+			fmt.Fprintf(f, "%s   cancelled %s\n", now, t.label)
+		}
+	}
+	return nil
+}
+
+// height is how many lines the panel takes below the screen: the full panel
+// while shown, one line while a hidden operation runs.
+func (p *bulkProgress) height() int {
+	switch {
+	case p.visible:
+		return len(p.rows()) + 3
+	case p.running:
+		return 2
+	}
+	return 0
+}
+
+// rows picks the tasks to list: those running, then the latest failures
+func (p *bulkProgress) rows() []int {
+	var rows []int
+	for i, s := range p.status {
+		if s == bulkRunning && len(rows) < bulkRows {
+			rows = append(rows, i)
+		}
+	}
+	for i := len(p.status) - 1; i >= 0 && len(rows) < bulkRows; i-- {
+		if p.status[i] == bulkFailed {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+func (p *bulkProgress) View(width int) string {
+	var lines []string
+	rule := ui.DimStyle.Render(strings.Repeat("─", max(width-4, 0)))
+	lines = append(lines, "  "+rule)
+
+	done, failed, cancelled := p.counts()
+	finished := done + failed + cancelled
+	line := ui.HeaderStyle.Render(strings.ToUpper(p.title)) + "  " + progressBar(finished, len(p.tasks), 20) +
+		fmt.Sprintf("  %d/%d", finished, len(p.tasks))
+	if failed > 0 {
+		line += "  " + ui.ErrorStyle.Render(fmt.Sprintf("%d failed", failed))
+	}
+	if eta, ok := p.eta(); ok && p.running {
+		line += "  " + ui.DimStyle.Render("ETA "+formatETA(eta))
+	}
+	if !p.visible {
+		return strings.Join(append(lines, "  "+line), "\n")
+	}
+	lines = append(lines, "  "+line)
+
+	for _, i := range p.rows() {
+		label := truncateText(p.tasks[i].label, max(width/2, 20))
+		if p.status[i] == bulkFailed {
+			lines = append(lines, "    "+ui.ErrorStyle.Render("✗ ")+label+ui.DimStyle.Render("  "+truncateText(p.errs[i].Error(), max(width/2-8, 10))))
+		} else {
+			lines = append(lines, "    "+ui.DimStyle.Render("… ")+label)
+		}
+	}
+
+	switch {
+	case p.cancelled && p.running:
+		lines = append(lines, "  "+ui.DimStyle.Render("Cancelling..."))
+	case p.running:
+		lines = append(lines, "  "+ui.DimStyle.Render("c cancel   esc hide"))
+	default:
+		lines = append(lines, "  "+ui.DimStyle.Render(p.summary+"   esc close"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func progressBar(n, total, width int) string {
+	filled := width
+	if total > 0 {
+		filled = n * width / total
+	}
+	return ui.SelectedStyle.Render(strings.Repeat("█", filled)) + ui.DimStyle.Render(strings.Repeat("░", width-filled))
+}
+
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Callout mode on the subgroup screen browses the diagram like the EPC:
+// the arrow keys move to the nearest captured hotspot that way, tab and
+// shift+tab through them in ref number order, and a click picks the one
+// under the mouse. The list's cursor follows to the selected ref number's
+// part, so enter opens it.
+
+// calloutReach is how near a click must be to a hotspot to pick it, in
+// cells
+const calloutReach = 2
+
+// startCallouts enters callout mode on the hotspot of the part under the
+// list's cursor, or the first one
+func (m *SubgroupModel) startCallouts() tea.Cmd {
+	if len(m.hotspots) == 0 {
+		return func() tea.Msg {
+			return toastMsg{text: "No callouts captured for this diagram; C captures them"}
+		}
+	}
+	if m.canvas == nil {
+		canvas, err := image.LoadCanvas(m.prefetch.diagramPath(*m.diagram.ImagePath), diagramWidthCells, diagramHeightCells)
+		if err != nil {
+			return func() tea.Msg { return toastMsg{text: fmt.Sprintf("Load diagram: %v", err), isError: true} }
+		}
+		m.canvas = canvas
+	}
+
+	// Callouts are placed on the current revision
+	m.blinking = false
+	m.setShowPrevious(false)
+	m.clearImageID = m.img.ID()
+	m.callouts = true
+
+	m.spot = 0
+	if row := m.table.Selected(); row != nil {
+		ref := m.partRef(row.ID)
+		for i, h := range m.hotspots {
+			if h.RefNumber == ref {
+				m.spot = i
+				break
+			}
+		}
+	}
+	return m.selectCallout(m.spot)
+}
+
+// stopCallouts returns the keys to the list
+func (m *SubgroupModel) stopCallouts() {
+	if m.marked != nil {
+		m.clearImageID = m.marked.ID()
+	}
+	m.callouts, m.marked = false, nil
+}
+
+func (m *SubgroupModel) updateCallouts(msg tea.KeyMsg) (*SubgroupModel, tea.Cmd, *Screen) {
+	switch {
+	case ui.IsCallouts(msg), ui.IsBack(msg):
+		m.stopCallouts()
+	case ui.IsNextRef(msg):
+		return m, m.selectCallout((m.spot + 1) % len(m.hotspots)), nil
+	case ui.IsPrevRef(msg):
+		return m, m.selectCallout((m.spot + len(m.hotspots) - 1) % len(m.hotspots)), nil
+	case ui.IsLeft(msg):
+		return m, m.stepCallout(-1, 0), nil
+	case ui.IsRight(msg):
+		return m, m.stepCallout(1, 0), nil
+	case ui.IsUp(msg):
+		return m, m.stepCallout(0, -1), nil
+	case ui.IsDown(msg):
+		return m, m.stepCallout(0, 1), nil
+	case ui.IsEnter(msg):
+		if s := m.openCallout(); s != nil {
+			return m, nil, s
+		}
+		ref := m.hotspots[m.spot].RefNumber
+		return m, func() tea.Msg { return toastMsg{text: fmt.Sprintf("No part listed under ref %s", ref)} }, nil
+	}
+	return m, nil, nil
+}
+
+// stepCallout moves to the nearest hotspot in direction dx, dy, favoring
+// ones in line with the selected one over ones off to the side
+func (m *SubgroupModel) stepCallout(dx, dy int) tea.Cmd {
+	from := m.hotspots[m.spot]
+	best, bestScore := -1, 0
+	for i, h := range m.hotspots {
+		along := (h.X-from.X)*dx + (h.Y-from.Y)*dy
+		if along <= 0 {
+			continue
+		}
+		across := (h.X-from.X)*dy - (h.Y-from.Y)*dx
+		score := along + 2*max(across, -across)
+		if best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return m.selectCallout(best)
+}
+
+// selectCallout selects a hotspot, moving the list's cursor to its part
+// and redrawing the diagram with the ref number's spots standing out
+func (m *SubgroupModel) selectCallout(i int) tea.Cmd {
+	m.spot = i
+	ref := m.hotspots[i].RefNumber
+
+	var marks []image.Mark
+	for _, h := range m.hotspots {
+		if h.RefNumber != ref {
+			marks = append(marks, image.Mark{X: h.X, Y: h.Y, Style: image.MarkSpot})
+		}
+	}
+	for _, h := range m.hotspots {
+		if h.RefNumber == ref {
+			marks = append(marks, image.Mark{X: h.X, Y: h.Y, Style: image.MarkSelected})
+		}
+	}
+	if img, err := m.canvas.Draw(marks); err == nil {
+		m.marked = img
+	}
+
+	if row := m.refRow(ref); row >= 0 && row != m.table.Cursor {
+		m.table.Cursor = row
+		m.prefetchSeq++
+		return m.prefetch.schedule(m.prefetchSeq)
+	}
+	return nil
+}
+
+// openCallout opens the selected ref number's part
+func (m *SubgroupModel) openCallout() *Screen {
+	if m.refRow(m.hotspots[m.spot].RefNumber) != m.table.Cursor {
+		return nil
+	}
+	var partID int
+	fmt.Sscanf(m.table.Selected().ID, "%d", &partID)
+	s := PartDetailScreen(partID, false)
+	return &s
+}
+
+// clickCallout picks the hotspot nearest a click on the diagram, entering
+// callout mode if need be. Clicking the selected one opens its part.
+func (m *SubgroupModel) clickCallout(x, y int) (tea.Cmd, *Screen) {
+	if m.img == nil || len(m.hotspots) == 0 {
+		return nil, nil
+	}
+	cx, cy := x-diagramLeft, y-diagramTop
+	if cx < 0 || cy < 0 || cx >= m.img.CellWidth() || cy >= m.img.CellHeight() {
+		return nil, nil
+	}
+
+	// The middle of the cell clicked, in pixels of the original image
+	cellWidth, cellHeight := image.CellSize()
+	scale := m.img.Scale()
+	px := int(float64(cx*cellWidth+cellWidth/2) / scale)
+	py := int(float64(cy*cellHeight+cellHeight/2) / scale)
+	reach := int(float64(calloutReach*cellWidth) / scale)
+
+	best, bestDist := -1, reach*reach+1
+	for i, h := range m.hotspots {
+		dx, dy := h.X-px, h.Y-py
+		if d := dx*dx + dy*dy; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best < 0 {
+		return nil, nil
+	}
+
+	if !m.callouts {
+		if cmd := m.startCallouts(); !m.callouts {
+			return cmd, nil
+		}
+	} else if best == m.spot {
+		if s := m.openCallout(); s != nil {
+			return nil, s
+		}
+	}
+	return m.selectCallout(best), nil
+}
+
+// refRow returns the row of the list with the first part under ref, or -1
+// if none is listed
+func (m *SubgroupModel) refRow(ref string) int {
+	for i, id := range m.table.IDs() {
+		if m.partRef(id) == ref {
+			return i
+		}
+	}
+	return -1
+}
+
+// partRef returns the ref number of the listed part with row ID id
+func (m *SubgroupModel) partRef(id string) string {
+	for _, p := range m.parts {
+		if strconv.Itoa(p.ID) == id {
+			return strings.TrimSpace(deref(p.RefNumber))
+		}
+	}
+	return ""
+}
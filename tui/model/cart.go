@@ -0,0 +1,409 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/order"
+	"github.com/mshick/delica-space-gear-parts/tui/report"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var cartColumns = []ui.Column{
+	{Title: "PART", Width: 14},
+	{Title: "DESCRIPTION"},
+	{Title: "QTY", Width: 5, Numeric: true},
+	{Title: "EACH", Width: 12},
+	{Title: "TOTAL", Width: 12},
+}
+
+// cartPrompt asks how many of a part to put in the cart.
+type cartPrompt struct {
+	active bool
+	input  textinput.Model
+	err    string
+}
+
+func newCartPrompt() cartPrompt {
+	ti := textinput.New()
+	ti.CharLimit = 4
+	ti.Width = 6
+	ti.Prompt = ""
+	return cartPrompt{input: ti}
+}
+
+func (p *cartPrompt) open() tea.Cmd {
+	p.active = true
+	p.err = ""
+	p.input.SetValue("1")
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *cartPrompt) close() {
+	p.active = false
+	p.input.Blur()
+}
+
+// update handles a message while the prompt is open, queueing the part's
+// addition on enter and toasting how many the cart will hold.
+func (p *cartPrompt) update(msg tea.Msg, database *db.DB, writes *writeQueue, part *db.PartWithDiagram) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			p.close()
+			return nil
+		case ui.IsEnter(msg):
+			qty, err := strconv.Atoi(strings.TrimSpace(p.input.Value()))
+			if err != nil || qty < 1 {
+				p.err = "Enter how many, 1 or more"
+				return nil
+			}
+			p.close()
+			inCart, _ := database.GetCartQty(part.ID)
+			inCart += qty
+			text := fmt.Sprintf("Added %d × %s to the cart", qty, part.PartNumber)
+			if inCart > qty {
+				text += fmt.Sprintf(", %d in it now", inCart)
+			}
+
+			// Additions add up, so each one runs rather than replacing
+			// any still waiting
+			event := webhook.Event{Kind: "cart", Action: "add", PartID: part.ID, PartNumber: part.PartNumber, Description: deref(part.Description), Qty: inCart}
+			partID := part.ID
+			written := writes.enqueue(partID, writeCart, 0, func() error {
+				return database.AddToCart(partID, qty)
+			}, event)
+			return tea.Batch(func() tea.Msg { return toastMsg{text: text} }, written)
+		}
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return cmd
+}
+
+// CartModel lists the parts to order with their quantities and cheapest
+// known prices, totalled by currency, for exporting to a supplier.
+type CartModel struct {
+	db       *db.DB
+	writes   *writeQueue
+	dataPath string
+	items    []db.CartItem
+	table    *ui.Table
+	status   string
+	clearing bool // waiting for y to empty the cart
+}
+
+func NewCartModel(database *db.DB, writes *writeQueue, dataPath string) *CartModel {
+	m := &CartModel{db: database, writes: writes, dataPath: dataPath}
+	m.load()
+	return m
+}
+
+func (m *CartModel) load() {
+	m.items, _ = m.db.GetCart()
+	m.render()
+}
+
+// render rebuilds the table from the items held
+func (m *CartModel) render() {
+	rows := make([]ui.TableRow, len(m.items))
+	for i, item := range m.items {
+		each, total := "", ""
+		if item.Price != nil {
+			each = locale.Price(item.Price.Price, item.Price.Currency)
+			total = locale.Price(item.Total(), item.Price.Currency)
+		}
+		rows[i] = ui.TableRow{
+			ID:    strconv.Itoa(item.PartID),
+			Cells: []string{item.PartNumber, deref(item.Description), strconv.Itoa(item.Qty), each, total},
+		}
+	}
+	prev := m.table
+	m.table = ui.NewTable(cartColumns, rows)
+	if prev != nil {
+		m.table.KeepPosition(prev)
+	}
+}
+
+// Editing reports whether emptying the cart is waiting to be confirmed,
+// when keys go to this screen rather than the app
+func (m *CartModel) Editing() bool {
+	return m.clearing
+}
+
+// selected returns the line under the cursor
+func (m *CartModel) selected() *db.CartItem {
+	item := m.table.Selected()
+	if item == nil {
+		return nil
+	}
+	for i := range m.items {
+		if strconv.Itoa(m.items[i].PartID) == item.ID {
+			return &m.items[i]
+		}
+	}
+	return nil
+}
+
+// setQty changes how many of the selected part to order; at 0 it leaves
+// the cart. The change shows straight away and the write is queued.
+func (m *CartModel) setQty(qty int) tea.Cmd {
+	item := m.selected()
+	if item == nil {
+		return nil
+	}
+	database, partID := m.db, item.PartID
+	event := webhook.Event{Kind: "cart", Action: "set", PartID: partID, PartNumber: item.PartNumber, Description: deref(item.Description), Qty: qty}
+	if qty <= 0 {
+		event.Action, event.Qty = "remove", 0
+		m.status = fmt.Sprintf("Took %s out of the cart", item.PartNumber)
+		m.items = slices.DeleteFunc(m.items, func(it db.CartItem) bool { return it.PartID == partID })
+	} else {
+		item.Qty = qty
+	}
+	m.render()
+
+	return m.writes.enqueue(partID, writeCart, m.writes.next(), func() error {
+		return database.SetCartQty(partID, qty)
+	}, event)
+}
+
+// clear empties the cart, queueing the write
+func (m *CartModel) clear() tea.Cmd {
+	events := make([]webhook.Event, len(m.items))
+	for i, item := range m.items {
+		events[i] = webhook.Event{Kind: "cart", Action: "remove", PartID: item.PartID, PartNumber: item.PartNumber, Description: deref(item.Description)}
+	}
+	m.items = nil
+	m.render()
+	m.status = "Cart emptied"
+
+	database := m.db
+	return m.writes.enqueue(0, writeCart, 0, database.ClearCart, events...)
+}
+
+// orderLines lists the cart as order lines, in the order shown
+func (m *CartModel) orderLines() []order.Line {
+	byID := make(map[string]db.CartItem, len(m.items))
+	for _, item := range m.items {
+		byID[strconv.Itoa(item.PartID)] = item
+	}
+	var lines []order.Line
+	for _, id := range m.table.IDs() {
+		item := byID[id]
+		lines = append(lines, order.Line{PartNumber: item.PartNumber, Description: deref(item.Description), Qty: item.Qty})
+	}
+	return lines
+}
+
+// export writes the cart as CSV to the data directory's orders folder, next
+// to the order e-mails drafted from the shortlist
+func (m *CartModel) export() {
+	path := filepath.Join(m.dataPath, "orders", "cart-"+time.Now().Format("20060102-150405")+".csv")
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		var f *os.File
+		if f, err = os.Create(path); err == nil {
+			err = report.WriteCartCSV(f, m.items)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Cart not exported: %v", err)
+		return
+	}
+	m.status = fmt.Sprintf("Exported %d lines to %s", len(m.items), path)
+}
+
+func (m *CartModel) Update(msg tea.Msg) (*CartModel, tea.Cmd, *Screen) {
+	// Show what was saved in place of a change that wasn't
+	if msg, ok := msg.(userDataWrittenMsg); ok && msg.kind == writeCart && msg.err != nil {
+		m.load()
+		m.status = fmt.Sprintf("Cart not saved: %v", msg.err)
+		return m, nil, nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, nil
+	}
+
+	if m.clearing {
+		m.clearing = false
+		m.status = ""
+		if ui.IsConfirm(keyMsg) {
+			return m, m.clear(), nil
+		}
+		return m, nil, nil
+	}
+
+	m.status = ""
+	switch {
+	case ui.IsMoreQty(keyMsg):
+		if item := m.selected(); item != nil {
+			return m, m.setQty(item.Qty + 1), nil
+		}
+	case ui.IsLessQty(keyMsg):
+		// Down to 1; d takes the part out
+		if item := m.selected(); item != nil && item.Qty > 1 {
+			return m, m.setQty(item.Qty - 1), nil
+		}
+	case ui.IsRemove(keyMsg):
+		return m, m.setQty(0), nil
+	case ui.IsExport(keyMsg) && len(m.items) > 0:
+		m.export()
+	case ui.IsCopyOrder(keyMsg) && len(m.items) > 0:
+		return m, copyOrderCmd(m.orderLines()), nil
+	case ui.IsClearCart(keyMsg) && len(m.items) > 0:
+		m.clearing = true
+	default:
+		m.table.HandleKey(keyMsg)
+		if ui.IsEnter(keyMsg) {
+			if item := m.selected(); item != nil {
+				s := PartDetailScreen(item.PartID, false)
+				return m, nil, &s
+			}
+		}
+	}
+	return m, nil, nil
+}
+
+func (m *CartModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *CartModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("CART"))
+	lines = append(lines, "")
+	qty := 0
+	for _, item := range m.items {
+		qty += item.Qty
+	}
+	switch {
+	case len(m.items) == 0:
+		lines = append(lines, "Empty")
+	case qty == 1:
+		lines = append(lines, "1 part")
+	case len(m.items) == 1:
+		lines = append(lines, fmt.Sprintf("%d of 1 part", qty))
+	default:
+		lines = append(lines, fmt.Sprintf("%d parts on %d lines", qty, len(m.items)))
+	}
+
+	// Running totals, one per currency the prices are in
+	totals, unpriced := db.CartTotals(m.items)
+	if len(totals) > 0 || unpriced > 0 {
+		lines = append(lines, "")
+	}
+	for _, t := range totals {
+		lines = append(lines, ui.SelectedStyle.Render(locale.Price(t.Amount, t.Currency)))
+	}
+	if unpriced > 0 {
+		lines = append(lines, ui.DimStyle.Render(fmt.Sprintf("%d without a price", unpriced)))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Press + on any part"))
+	lines = append(lines, ui.DimStyle.Render("to put it in the cart"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *CartModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Header
+	b.WriteString(ui.HeaderStyle.Render("PARTS TO ORDER"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles)
+	tableHeight := height - 6
+	if tableHeight < 6 {
+		tableHeight = 6
+	}
+	if tableHeight > 16 {
+		tableHeight = 16
+	}
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
+
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	if len(m.items) == 0 {
+		b.WriteString(ui.DimStyle.Render("Nothing in the cart"))
+		b.WriteString("\n\n")
+		b.WriteString(ui.DimStyle.Render("+ on a part's detail screen"))
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("adds it with a quantity"))
+		return b.String()
+	}
+	b.WriteString(m.table.View())
+	b.WriteString("\n\n")
+
+	switch {
+	case m.clearing:
+		b.WriteString(ui.ErrorStyle.Render("Empty the cart? y empties it, any other key keeps it"))
+		b.WriteString("\n")
+	case m.status != "":
+		b.WriteString(ui.SelectedStyle.Render(m.status))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   +/- quantity   d remove   x export csv   Y copy order   C empty   " + m.table.SortHint()))
+
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// catalogCheckInterval is how often the database file is checked for
+// changes made by another program, such as the scraper, and catalogSettle
+// how long writes must stop for before the catalog is reloaded, so a
+// running scrape doesn't reload the screen at every check
+const (
+	catalogCheckInterval = 3 * time.Second
+	catalogSettle        = 10 * time.Second
+)
+
+// catalogCheckMsg reports a check of the database file, after reopening it
+// if it was replaced
+type catalogCheckMsg struct {
+	change db.CatalogChange
+	err    error
+}
+
+// checkCatalog checks the database file in the background, after delay
+func checkCatalog(database *db.DB, delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		change, err := database.CheckCatalog(catalogSettle)
+		if err == nil && change == db.CatalogReplaced {
+			err = database.Reopen()
+		}
+		return catalogCheckMsg{change: change, err: err}
+	})
+}
+
+// catalogChecked reloads what was read from the catalog once it changes on
+// disk: the jump index, cached diagrams and the current screen, keeping its
+// cursor and scroll, unless text is being typed or edited on it or the
+// answers given to the wizard, which would be lost.
+func (m *Model) catalogChecked(msg catalogCheckMsg) tea.Cmd {
+	next := checkCatalog(m.db, catalogCheckInterval)
+	if msg.err != nil {
+		return tea.Batch(next, func() tea.Msg {
+			return toastMsg{text: fmt.Sprintf("Catalog changed on disk, not reloaded: %v", msg.err), isError: true}
+		})
+	}
+	if msg.change == db.CatalogUnchanged {
+		return next
+	}
+
+	m.jumpIndex = nil
+	m.images.reset()
+	m.prefetch.cancel()
+
+	text := "Catalog changed on disk; reloaded"
+	if msg.change == db.CatalogReplaced {
+		text = "Catalog replaced on disk; reloaded"
+	}
+	cmds := []tea.Cmd{next}
+	if m.typingText() || m.screen.Type == ScreenWizard {
+		text += "; reopen this screen to see it"
+	} else {
+		if imgID := m.getCurrentImageID(); imgID != 0 {
+			m.pendingImageClear = imgID
+		}
+		prev := m.currentModel()
+		m.initScreen()
+		m.keepPosition(prev)
+		cmds = append(cmds, m.screenChanged())
+	}
+	return tea.Batch(append(cmds, func() tea.Msg { return toastMsg{text: text} })...)
+}
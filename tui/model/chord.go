@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// chordTimeout is how long the first key of a chord waits for the second
+// before it counts as a key of its own
+const chordTimeout = time.Second
+
+type chordTimeoutMsg struct {
+	seq int
+}
+
+// chord holds the first key of a chord while it waits for the second, shown
+// along the bottom of the screen with the keys that can follow it.
+type chord struct {
+	prefix  *tea.KeyMsg
+	seq     int
+	passing bool // a held key is being replayed, so it mustn't start a chord again
+}
+
+func (c *chord) start(msg tea.KeyMsg) tea.Cmd {
+	c.prefix = &msg
+	c.seq++
+	seq := c.seq
+	return tea.Tick(chordTimeout, func(time.Time) tea.Msg {
+		return chordTimeoutMsg{seq: seq}
+	})
+}
+
+// take returns the held key and stops waiting
+func (c *chord) take() (tea.KeyMsg, bool) {
+	if c.prefix == nil {
+		return tea.KeyMsg{}, false
+	}
+	prefix := *c.prefix
+	c.prefix = nil
+	return prefix, true
+}
+
+// height is how many lines the indicator takes below the screen.
+func (c *chord) height() int {
+	if c.prefix == nil {
+		return 0
+	}
+	return 1
+}
+
+var chordHints = map[string]string{
+	ui.ChordTop:       "top",
+	ui.ChordHome:      "home",
+	ui.ChordBookmarks: "bookmarks",
+	ui.ChordNotes:     "notes",
+	ui.ChordJournal:   "journal",
+	ui.ChordCopyPart:  "copy part number",
+}
+
+func (c *chord) View(width int) string {
+	prefix := c.prefix.String()
+	var hints []string
+	for _, name := range ui.Chords {
+		if key, ok := strings.CutPrefix(name, prefix+" "); ok {
+			hints = append(hints, key+" "+chordHints[name])
+		}
+	}
+	line := ui.SelectedStyle.Render(prefix+"-") + "  " + ui.DimStyle.Render(strings.Join(hints, "   "))
+	return "  " + lipgloss.NewStyle().MaxWidth(max(width-4, 0)).Render(line)
+}
+
+// passKey sends a held chord key through as a key of its own
+func (m *Model) passKey(msg tea.KeyMsg) tea.Cmd {
+	m.chord.passing = true
+	defer func() { m.chord.passing = false }()
+	_, cmd := m.Update(msg)
+	return cmd
+}
+
+// runChord does what a completed chord asks
+func (m *Model) runChord(name string, prefix tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch name {
+	case ui.ChordTop:
+		// Lists already jump to the top on g
+		return m, m.passKey(prefix)
+	case ui.ChordHome:
+		if m.screen.Type != ScreenHome {
+			return m.navigate(HomeScreen())
+		}
+	case ui.ChordBookmarks:
+		if m.screen.Type != ScreenBookmarks {
+			return m.navigate(BookmarksScreen())
+		}
+	case ui.ChordNotes:
+		if m.screen.Type != ScreenNotes {
+			return m.navigate(NotesScreen())
+		}
+	case ui.ChordJournal:
+		if m.screen.Type != ScreenJournal || m.journal.day != "" {
+			return m.navigate(JournalScreen(""))
+		}
+	case ui.ChordCopyPart:
+		part := m.currentPart()
+		if part == nil {
+			return m, func() tea.Msg { return toastMsg{text: "No part selected to copy", isError: true} }
+		}
+		return m, copyCmd(strings.ToUpper(part.PartNumber))
+	}
+	return m, nil
+}
+
+// copyCmd copies text to the clipboard in the background, asking the
+// terminal to copy it when no clipboard program can
+func copyCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := opener.Copy(text); err != nil {
+			return toastMsg{text: fmt.Sprintf("Asked the terminal to copy %s", text), clipboard: text}
+		}
+		return toastMsg{text: "Copied " + text}
+	}
+}
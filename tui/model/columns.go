@@ -0,0 +1,198 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listColumn is a column a parts list can show, by the key its choice is
+// saved under
+type listColumn struct {
+	key    string
+	column ui.Column
+	shown  bool // shown until the user chooses otherwise
+}
+
+// Columns the parts lists can add to their own; the part number always
+// comes first
+var (
+	specColumn  = listColumn{key: "spec", column: ui.Column{Title: "SPEC", Width: 22}}
+	priceColumn = listColumn{key: "price", column: ui.Column{Title: "PRICE", Width: 12}}
+	datesColumn = listColumn{key: "dates", column: ui.Column{Title: "DATES", Width: 16}}
+)
+
+// columnSet is the columns a list shows out of those it can, saved per
+// screen, and the chooser that picks them
+type columnSet struct {
+	db      *db.DB
+	setting string
+	all     []listColumn
+	shown   []string // keys, in the order of all
+
+	// Chooser, open over the list
+	choosing bool
+	cursor   int // into all, after the first
+	err      string
+}
+
+// loadColumnSet reads which of all a screen shows; nothing saved, or only
+// names no longer offered, means the defaults
+func loadColumnSet(database *db.DB, screen string, all []listColumn) *columnSet {
+	c := &columnSet{db: database, setting: "columns_" + screen, all: all}
+	saved, _ := database.GetSetting(c.setting)
+	keys := strings.Split(saved, ",")
+	for _, col := range all {
+		if slices.Contains(keys, col.key) {
+			c.shown = append(c.shown, col.key)
+		}
+	}
+	if len(c.shown) == 0 {
+		for _, col := range all {
+			if col.shown {
+				c.shown = append(c.shown, col.key)
+			}
+		}
+	}
+	return c
+}
+
+// has reports whether the column with key is shown
+func (c *columnSet) has(key string) bool {
+	return slices.Contains(c.shown, key)
+}
+
+// columns returns the shown columns for a table. The last takes what's left
+// of the row when none of them does.
+func (c *columnSet) columns() []ui.Column {
+	var columns []ui.Column
+	flexible := false
+	for _, col := range c.all {
+		if c.has(col.key) {
+			columns = append(columns, col.column)
+			flexible = flexible || col.column.Width == 0
+		}
+	}
+	if !flexible && len(columns) > 0 {
+		columns[len(columns)-1].Width = 0
+	}
+	return columns
+}
+
+// fixedWidth is the cells the shown columns take besides the flexible one
+func (c *columnSet) fixedWidth() int {
+	width := 0
+	for _, col := range c.columns() {
+		width += col.Width
+	}
+	return width
+}
+
+// row lays out a table row from every column's cell by key
+func (c *columnSet) row(id string, cells map[string]string) ui.TableRow {
+	row := ui.TableRow{ID: id}
+	for _, col := range c.all {
+		if c.has(col.key) {
+			row.Cells = append(row.Cells, cells[col.key])
+		}
+	}
+	return row
+}
+
+// toggle shows or hides the column under the chooser's cursor and saves
+// the choice
+func (c *columnSet) toggle() {
+	key := c.all[c.cursor].key
+	var shown []string
+	for _, col := range c.all {
+		if (col.key == key) != c.has(col.key) {
+			shown = append(shown, col.key)
+		}
+	}
+	if err := c.db.SetSetting(c.setting, strings.Join(shown, ",")); err != nil {
+		c.err = fmt.Sprintf("Columns not saved: %v", err)
+		return
+	}
+	c.shown, c.err = shown, ""
+}
+
+func (c *columnSet) open() {
+	c.choosing = true
+	c.cursor = 1
+	c.err = ""
+}
+
+// update handles a key while the chooser is open, reporting whether the
+// columns changed so the list is laid out again
+func (c *columnSet) update(msg tea.KeyMsg) (changed bool) {
+	switch {
+	case ui.IsBack(msg), ui.IsColumns(msg):
+		c.choosing = false
+	case ui.IsUp(msg):
+		c.cursor = max(c.cursor-1, 1)
+	case ui.IsDown(msg):
+		c.cursor = min(c.cursor+1, len(c.all)-1)
+	case ui.IsEnter(msg), msg.String() == " ":
+		c.toggle()
+		return c.err == ""
+	}
+	return false
+}
+
+// view lists the columns with checkboxes, the first fixed in place
+func (c *columnSet) view() string {
+	var b strings.Builder
+	b.WriteString(ui.DimStyle.Render("Columns to show:"))
+	b.WriteString("\n")
+	for i, col := range c.all {
+		box := "[ ]"
+		if c.has(col.key) {
+			box = "[x]"
+		}
+		label := box + " " + col.column.Title
+		switch {
+		case i == 0:
+			b.WriteString("  " + ui.DimStyle.Render(label+" (always)"))
+		case i == c.cursor:
+			b.WriteString(ui.SelectedStyle.Render("› ") + ui.SelectedLabelStyle.Render(label))
+		default:
+			b.WriteString("  " + label)
+		}
+		b.WriteString("\n")
+	}
+	if c.err != "" {
+		b.WriteString(ui.ErrorStyle.Render(c.err))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   space show/hide   esc done"))
+	return b.String()
+}
+
+// partPrices returns the cheapest known price of each part number, keyed
+// in upper case, for the price column
+func partPrices(database *db.DB, partNumbers []string) map[string]db.Price {
+	prices, _ := database.GetPrices(partNumbers...)
+	cheapest := make(map[string]db.Price)
+	for _, p := range prices {
+		key := strings.ToUpper(p.PartNumber)
+		if _, ok := cheapest[key]; !ok {
+			cheapest[key] = p
+		}
+	}
+	return cheapest
+}
+
+// priceCell formats a part's cheapest price, or "" without one
+func priceCell(prices map[string]db.Price, partNumber string) string {
+	if p, ok := prices[strings.ToUpper(partNumber)]; ok {
+		return locale.Price(p.Price, p.Currency)
+	}
+	return ""
+}
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Most rows a console query returns, and the widest a column is drawn
+const (
+	consoleRowLimit    = 1000
+	consoleColumnWidth = 40
+)
+
+// ConsoleModel runs read-only SQL against the catalog. It isn't on any
+// menu; ctrl+o opens it from anywhere. Queries run in the background, so a
+// slow one can be cancelled with esc or ctrl+c.
+type ConsoleModel struct {
+	db     *db.DB
+	input  textinput.Model
+	result *db.QueryResult
+	err    string
+	offset int // first row shown
+	page   int // rows shown at once, set by View
+
+	runs   int                // counts queries run, to match results to the latest
+	cancel context.CancelFunc // interrupts the running query; nil when none is
+}
+
+// consoleResultMsg brings back the outcome of a console query
+type consoleResultMsg struct {
+	run    int
+	result *db.QueryResult
+	err    error
+}
+
+func NewConsoleModel(database *db.DB) *ConsoleModel {
+	ti := textinput.New()
+	ti.Placeholder = "SELECT part_number, description FROM parts_effective WHERE ..."
+	ti.Focus()
+	ti.CharLimit = 1000
+	ti.Width = 80
+	ti.Prompt = "sql> "
+
+	return &ConsoleModel{
+		db:    database,
+		input: ti,
+		page:  10,
+	}
+}
+
+func (m *ConsoleModel) Update(msg tea.Msg) (*ConsoleModel, tea.Cmd, *Screen) {
+	if msg, ok := msg.(consoleResultMsg); ok {
+		m.handleResult(msg)
+		return m, nil, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.Running() && (ui.IsBack(msg) || msg.String() == "ctrl+c") {
+			m.Cancel()
+			return m, nil, nil
+		}
+		// Rows scroll with the arrow, page and ctrl keys; everything else types
+		if m.result != nil {
+			if offset, ok := ui.MoveCursor(msg, m.offset, len(m.result.Rows)-m.page+1, m.page, true); ok {
+				m.offset = offset
+				return m, nil, nil
+			}
+		}
+		if ui.IsEnter(msg) {
+			return m, m.run(), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd, nil
+}
+
+// run starts the query in the input, cancelling one still running
+func (m *ConsoleModel) run() tea.Cmd {
+	m.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.runs++
+	run, database, query := m.runs, m.db, m.input.Value()
+	return func() tea.Msg {
+		result, err := database.QueryReadOnly(ctx, query, consoleRowLimit)
+		return consoleResultMsg{run: run, result: result, err: err}
+	}
+}
+
+func (m *ConsoleModel) handleResult(msg consoleResultMsg) {
+	if msg.run != m.runs {
+		return
+	}
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.offset = 0
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.result = nil
+		m.err = "Query cancelled"
+	case msg.err != nil:
+		m.result = nil
+		m.err = msg.err.Error()
+	default:
+		m.result = msg.result
+		m.err = ""
+	}
+}
+
+// Running reports whether a query is still running, in which case esc
+// cancels it rather than leaving the console
+func (m *ConsoleModel) Running() bool {
+	return m.cancel != nil
+}
+
+// Cancel interrupts the running query, if any; its result still arrives,
+// as the cancellation.
+func (m *ConsoleModel) Cancel() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *ConsoleModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	var lines []string
+	lines = append(lines, ui.HeaderStyle.Render("SQL CONSOLE")+ui.DimStyle.Render("  read-only"))
+	lines = append(lines, "")
+	m.input.Width = width - 16
+	lines = append(lines, ui.BoxStyle.Render(m.input.View()))
+	lines = append(lines, "")
+
+	// Table fills what's left above the status and footer lines
+	m.page = height - len(lines) - 8
+	if m.page < 3 {
+		m.page = 3
+	}
+
+	switch {
+	case m.Running():
+		lines = append(lines, ui.DimStyle.Render("Running…"))
+	case m.err != "":
+		lines = append(lines, ui.ErrorStyle.Render(m.err))
+	case m.result == nil:
+		lines = append(lines, ui.DimStyle.Render("Type a query and press enter. Writes are rejected."))
+		lines = append(lines, ui.DimStyle.Render("Tables: groups, subgroups, diagrams, parts, parts_effective, bookmarks, notes"))
+	case len(m.result.Columns) == 0:
+		lines = append(lines, ui.DimStyle.Render("Statement returned no columns"))
+	default:
+		lines = append(lines, m.renderTable(width-4)...)
+		lines = append(lines, "")
+		lines = append(lines, ui.DimStyle.Render(m.status()))
+	}
+
+	lines = strings.Split(strings.Join(lines, "\n"), "\n")
+	for i := range lines {
+		lines[i] = "  " + lines[i]
+	}
+	for len(lines) < height-4 {
+		lines = append(lines, "")
+	}
+	footer := "enter run   ↑↓ scroll   pgup/pgdn page   ctrl+u/ctrl+d half page"
+	if m.Running() {
+		footer = "esc/ctrl+c cancel"
+	}
+	lines = append(lines, "  "+ui.DimStyle.Render(footer))
+
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+func (m *ConsoleModel) status() string {
+	total := len(m.result.Rows)
+	if total == 0 {
+		return "No rows"
+	}
+	last := m.offset + m.page
+	if last > total {
+		last = total
+	}
+	s := fmt.Sprintf("Rows %d-%d of %d", m.offset+1, last, total)
+	if m.result.Truncated {
+		s += fmt.Sprintf(" (limited to %d)", consoleRowLimit)
+	}
+	return s
+}
+
+// renderTable draws the visible page of rows with columns sized to their
+// content, cut off at the screen edge.
+func (m *ConsoleModel) renderTable(width int) []string {
+	widths := make([]int, len(m.result.Columns))
+	for i, c := range m.result.Columns {
+		widths[i] = len(c)
+	}
+	end := m.offset + m.page
+	if end > len(m.result.Rows) {
+		end = len(m.result.Rows)
+	}
+	visible := m.result.Rows[m.offset:end]
+	for _, row := range visible {
+		for i, v := range row {
+			if w := lipgloss.Width(v); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > consoleColumnWidth {
+			widths[i] = consoleColumnWidth
+		}
+	}
+
+	formatRow := func(values []string) string {
+		cells := make([]string, len(values))
+		for i, v := range values {
+			v = strings.ReplaceAll(v, "\n", " ")
+			cell := truncateText(v, widths[i])
+			cells[i] = cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+		}
+		return truncateText(strings.Join(cells, "  "), width)
+	}
+
+	lines := []string{ui.HeaderStyle.Render(formatRow(m.result.Columns))}
+	for _, row := range visible {
+		lines = append(lines, formatRow(row))
+	}
+	return lines
+}
+
+// truncateText shortens plain text to width cells, marking the cut with an
+// ellipsis.
+func truncateText(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + "…"
+}
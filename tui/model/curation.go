@@ -0,0 +1,239 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type CurationModel struct {
+	db     *db.DB
+	issues []db.CurationIssue
+	menu   *ui.Menu
+
+	// Inline editing of local overrides
+	editor fieldEditor
+}
+
+func NewCurationModel(database *db.DB) *CurationModel {
+	m := &CurationModel{
+		db:     database,
+		menu:   ui.NewMenu(nil),
+		editor: newFieldEditor(),
+	}
+	m.reload()
+	return m
+}
+
+// reload re-runs the curation query, keeping the cursor position so fixed
+// parts drop out of the list without losing your place.
+func (m *CurationModel) reload() {
+	cursor := m.menu.Cursor
+	m.issues, _ = m.db.GetCurationIssues()
+
+	var items []ui.MenuItem
+	for _, issue := range m.issues {
+		label := issue.PartNumber
+		if issue.PNC != nil {
+			label = fmt.Sprintf("[%s] %s", *issue.PNC, issue.PartNumber)
+		}
+		items = append(items, ui.MenuItem{
+			ID:    fmt.Sprintf("%d", issue.ID),
+			Label: label,
+			Hint:  strings.Join(issue.Problems, ", "),
+		})
+	}
+
+	m.menu = ui.NewMenu(items)
+	if cursor >= len(items) {
+		cursor = len(items) - 1
+	}
+	if cursor > 0 {
+		m.menu.Cursor = cursor
+	}
+}
+
+func (m *CurationModel) selected() *db.CurationIssue {
+	if m.menu.Cursor >= 0 && m.menu.Cursor < len(m.issues) {
+		return &m.issues[m.menu.Cursor]
+	}
+	return nil
+}
+
+// Editing reports whether an inline edit is in progress.
+func (m *CurationModel) Editing() bool {
+	return m.editor.active
+}
+
+func (m *CurationModel) Update(msg tea.Msg) (*CurationModel, tea.Cmd, *Screen) {
+	if m.editor.active {
+		issue := m.selected()
+		if issue == nil {
+			m.editor.close()
+			return m, nil, nil
+		}
+		cmd, changed := m.editor.update(msg, m.db, issue.Part)
+		if changed {
+			m.reload()
+		}
+		return m, cmd, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.menu.HandleKey(msg)
+		if ui.IsEdit(msg) {
+			// Start on the first field with a problem
+			field := 0
+			if issue := m.selected(); issue != nil {
+				for i, f := range editableFields {
+					if f.problem != "" && containsString(issue.Problems, f.problem) {
+						field = i
+						break
+					}
+				}
+				return m, m.editor.open(issue.Part, field), nil
+			}
+		}
+		if ui.IsEnter(msg) {
+			if issue := m.selected(); issue != nil {
+				s := PartDetailScreen(issue.ID, false)
+				return m, nil, &s
+			}
+		}
+	}
+	return m, nil, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CurationModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *CurationModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("CURATION"))
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("%d parts need attention", len(m.issues)))
+	lines = append(lines, "")
+
+	if issue := m.selected(); issue != nil {
+		lines = append(lines, ui.PartNumberStyle.Render(strings.ToUpper(issue.PartNumber)))
+		lines = append(lines, "")
+
+		labelStyle := lipgloss.NewStyle().Width(14).Foreground(ui.ColorDim)
+		for i, f := range editableFields {
+			editingField := m.editor.active && i == m.editor.index
+			var value string
+			if editingField {
+				value = m.editor.input.View()
+			} else if v := fieldValue(issue.Part, f.field); v != nil {
+				value = *v
+			} else {
+				value = ui.DimStyle.Render("—")
+			}
+			if f.problem != "" && containsString(issue.Problems, f.problem) && !editingField {
+				value += " " + ui.ErrorStyle.Render("!")
+			}
+			lines = append(lines, labelStyle.Render(f.label)+value)
+		}
+
+		if containsString(issue.Problems, db.ProblemNoDiagram) {
+			lines = append(lines, "")
+			lines = append(lines, ui.ErrorStyle.Render("Diagram image missing"))
+		}
+
+		if m.editor.err != "" {
+			lines = append(lines, "")
+			lines = append(lines, ui.ErrorStyle.Render(m.editor.err))
+		}
+	}
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *CurationModel) renderRightPane(height int) string {
+	var b strings.Builder
+
+	// Header
+	b.WriteString(ui.HeaderStyle.Render("INCOMPLETE PARTS"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust menu visible items based on available height (max 15)
+	menuHeight := height - 5
+	if menuHeight < 5 {
+		menuHeight = 5
+	}
+	if menuHeight > 15 {
+		menuHeight = 15
+	}
+	m.menu.MaxVisibleItems = menuHeight
+
+	// One less blank line if menu scrolls (to account for scroll indicator)
+	if len(m.menu.Items) > m.menu.MaxVisibleItems {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	if len(m.issues) == 0 {
+		b.WriteString(ui.DimStyle.Render("Nothing to curate"))
+	} else {
+		b.WriteString(m.menu.View())
+	}
+
+	b.WriteString("\n\n")
+	if m.editor.active {
+		b.WriteString(ui.DimStyle.Render(m.editor.hint()))
+	} else {
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter view   e edit"))
+	}
+
+	return b.String()
+}
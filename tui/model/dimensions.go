@@ -0,0 +1,96 @@
+package model
+
+import (
+	"strconv"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dimensionsPrompt edits a part number's dimensions as one line, e.g.
+// "M8x1.25, length 45mm". Clearing the input forgets them.
+type dimensionsPrompt struct {
+	active bool
+	input  textinput.Model
+	err    string
+}
+
+func newDimensionsPrompt() dimensionsPrompt {
+	ti := textinput.New()
+	ti.Placeholder = "M8x1.25, length 45mm, od 22, id 12"
+	ti.CharLimit = 120
+	ti.Width = 50
+	ti.Prompt = ""
+	return dimensionsPrompt{input: ti}
+}
+
+// open starts from the recorded dimensions
+func (p *dimensionsPrompt) open(current []db.Dimension) tea.Cmd {
+	p.active = true
+	p.err = ""
+	p.input.SetValue(db.FormatDimensions(current))
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *dimensionsPrompt) close() {
+	p.active = false
+	p.input.Blur()
+}
+
+// update handles a message while the prompt is open. saved is true once the
+// dimensions have been recorded, when the caller should reload them.
+func (p *dimensionsPrompt) update(msg tea.Msg, database *db.DB, partNumber string) (cmd tea.Cmd, saved bool) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			p.close()
+			return nil, false
+		case ui.IsEnter(msg):
+			dims, err := db.ParseDimensions(p.input.Value())
+			if err == nil {
+				err = database.SetDimensions(partNumber, dims)
+			}
+			if err != nil {
+				p.err = err.Error()
+				return nil, false
+			}
+			p.close()
+			return nil, true
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	return cmd, false
+}
+
+// dimensionLines pairs each dimension's label with its value for the
+// Dimensions block, showing a thread and its pitch together as M8 × 1.25
+func dimensionLines(dims []db.Dimension) (labels, values []string) {
+	var pitch *db.Dimension
+	for i, d := range dims {
+		if d.Name == db.DimPitch {
+			pitch = &dims[i]
+		}
+	}
+	for _, d := range dims {
+		switch {
+		case d.Name == db.DimThread && d.Unit == "mm":
+			value := "M" + strconv.FormatFloat(d.Value, 'f', -1, 64)
+			if pitch != nil && pitch.Unit == "mm" {
+				value += " × " + strconv.FormatFloat(pitch.Value, 'f', -1, 64)
+				pitch = nil
+			}
+			labels = append(labels, db.DimensionLabel(d.Name))
+			values = append(values, value)
+		case d.Name == db.DimPitch && pitch == nil:
+			// Shown with the thread
+		default:
+			labels = append(labels, db.DimensionLabel(d.Name))
+			values = append(values, d.String())
+		}
+	}
+	return labels, values
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editableFields are the catalog fields that can be overridden locally, in
+// the order tab cycles through them. problem is the matching curation label.
+var editableFields = []struct {
+	field   string
+	label   string
+	problem string
+}{
+	{db.FieldDescription, "Description", db.ProblemNoDescription},
+	{db.FieldQuantity, "Quantity", db.ProblemNoQuantity},
+	{db.FieldNotes, "Notes", ""},
+	{db.FieldModelDateRange, "Date Range", db.ProblemBadDateRange},
+}
+
+func fieldValue(p db.Part, field string) *string {
+	switch field {
+	case db.FieldDescription:
+		return p.Description
+	case db.FieldQuantity:
+		if p.Quantity != nil {
+			q := fmt.Sprintf("%d", *p.Quantity)
+			return &q
+		}
+	case db.FieldNotes:
+		return p.Notes
+	case db.FieldModelDateRange:
+		return p.ModelDateRange
+	}
+	return nil
+}
+
+// fieldEditor edits one overridable catalog field of a part at a time,
+// saving to the local overrides table.
+type fieldEditor struct {
+	active bool
+	index  int // into editableFields
+	input  textinput.Model
+	err    string
+}
+
+func newFieldEditor() fieldEditor {
+	ti := textinput.New()
+	ti.CharLimit = 200
+	ti.Width = 30
+	ti.Prompt = ""
+	return fieldEditor{input: ti}
+}
+
+// open starts editing field index of part, prefilled with its current value.
+func (e *fieldEditor) open(part db.Part, index int) tea.Cmd {
+	e.active = true
+	e.index = index
+	e.err = ""
+	e.input.SetValue("")
+	if v := fieldValue(part, editableFields[index].field); v != nil {
+		e.input.SetValue(*v)
+	}
+	e.input.CursorEnd()
+	return e.input.Focus()
+}
+
+func (e *fieldEditor) close() {
+	e.active = false
+	e.input.Blur()
+}
+
+func (e *fieldEditor) label() string {
+	return editableFields[e.index].label
+}
+
+// update handles a message while editing. changed is true after a save or
+// revert, when the caller should reload the part.
+func (e *fieldEditor) update(msg tea.Msg, database *db.DB, part db.Part) (cmd tea.Cmd, changed bool) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		field := editableFields[e.index].field
+		switch {
+		case ui.IsBack(msg):
+			e.close()
+			return nil, false
+		case msg.Type == tea.KeyTab:
+			return e.open(part, (e.index+1)%len(editableFields)), false
+		case ui.IsRevert(msg):
+			if err := database.RevertPartOverride(part.ID, field); err != nil {
+				e.err = err.Error()
+				return nil, false
+			}
+			e.close()
+			return nil, true
+		case ui.IsEnter(msg):
+			var value *string
+			if v := strings.TrimSpace(e.input.Value()); v != "" {
+				value = &v
+			}
+			if err := database.SetPartOverride(part.ID, field, value); err != nil {
+				e.err = err.Error()
+				return nil, false
+			}
+			e.close()
+			return nil, true
+		}
+	}
+	e.input, cmd = e.input.Update(msg)
+	return cmd, false
+}
+
+// hint returns the key help shown while editing.
+func (e *fieldEditor) hint() string {
+	return "enter save   tab next field   ctrl+r revert   esc cancel"
+}
@@ -1,10 +1,16 @@
 package model
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
-	"delica-tui/db"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/export"
+	"github.com/mshick/delica-space-gear-parts/tui/notify"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -12,39 +18,137 @@ import (
 
 type GroupModel struct {
 	db        *db.DB
+	dataPath  string
 	groupID   string
 	group     *db.Group
 	subgroups []db.Subgroup
+	pinned    map[string]bool
+	counts    *db.Counts
 	menu      *ui.Menu
 }
 
-func NewGroupModel(database *db.DB, groupID string) *GroupModel {
+func NewGroupModel(database *db.DB, groupID string, dataPath string) *GroupModel {
 	group, _ := database.GetGroup(groupID)
 	subgroups, _ := database.GetSubgroups(groupID)
+	counts, _ := database.GetCounts()
 
-	var items []ui.MenuItem
-	for _, s := range subgroups {
-		items = append(items, ui.MenuItem{ID: s.ID, Label: s.Name})
-	}
-
-	return &GroupModel{
+	m := &GroupModel{
 		db:        database,
+		dataPath:  dataPath,
 		groupID:   groupID,
 		group:     group,
 		subgroups: subgroups,
-		menu:      ui.NewMenu(items),
+		counts:    counts,
+	}
+	m.loadPins()
+	m.menu = ui.NewMenu(m.menuItems())
+	return m
+}
+
+func (m *GroupModel) loadPins() {
+	m.pinned = make(map[string]bool)
+	pins, _ := m.db.GetPins()
+	for _, p := range pins {
+		if p.Kind == db.PinSubgroup {
+			m.pinned[p.ID] = true
+		}
+	}
+}
+
+// menuItems lists pinned subgroups first, then the rest, each alphabetically
+func (m *GroupModel) menuItems() []ui.MenuItem {
+	var pinned, rest []ui.MenuItem
+	for _, s := range m.subgroups {
+		var hint string
+		if m.counts != nil {
+			hint = subgroupHint(m.counts.Subgroups[s.ID])
+		}
+		if m.pinned[s.ID] {
+			pinned = append(pinned, ui.MenuItem{ID: s.ID, Label: "^ " + s.Name, Hint: hint})
+		} else {
+			rest = append(rest, ui.MenuItem{ID: s.ID, Label: s.Name, Hint: hint})
+		}
+	}
+	return append(pinned, rest...)
+}
+
+// subgroupHint describes a subgroup from its aggregates, e.g. "12 parts ·
+// 1994.03-2007.01", noting when its diagram hasn't been downloaded
+func subgroupHint(a db.Aggregate) string {
+	hints := []string{fmt.Sprintf("%d parts", a.Parts)}
+	if span := a.DateSpan(); span != "" {
+		hints = append(hints, span)
+	}
+	if a.Diagrams > 0 && a.WithImage == 0 {
+		hints = append(hints, "no image")
+	}
+	return strings.Join(hints, " · ")
+}
+
+// togglePin pins or unpins the selected subgroup, keeping it selected
+func (m *GroupModel) togglePin() {
+	item := m.menu.Selected()
+	if item == nil {
+		return
+	}
+	id := item.ID
+	if m.pinned[id] {
+		m.db.RemovePin(db.PinSubgroup, id)
+	} else {
+		m.db.AddPin(db.PinSubgroup, id)
+	}
+	m.loadPins()
+
+	m.menu = ui.NewMenu(m.menuItems())
+	for i, it := range m.menu.Items {
+		if it.ID == id {
+			m.menu.Cursor = i
+			break
+		}
+	}
+}
+
+// exportDiagrams copies every diagram in the group to data/exports/<group>,
+// one progress panel task per diagram
+func (m *GroupModel) exportDiagrams() tea.Cmd {
+	database, dataPath, groupID := m.db, m.dataPath, m.groupID
+	return func() tea.Msg {
+		diagrams, err := database.GetDiagramsForGroup(groupID)
+		if err != nil {
+			return toastMsg{text: fmt.Sprintf("Export failed: %v", err), isError: true}
+		}
+		outDir := filepath.Join(dataPath, "exports", export.Slug(groupID))
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return toastMsg{text: fmt.Sprintf("Export failed: %v", err), isError: true}
+		}
+		files, _ := export.PlanDiagrams(diagrams)
+		if len(files) == 0 {
+			return toastMsg{text: "No downloaded diagrams to export"}
+		}
+
+		tasks := make([]bulkTask, len(files))
+		for i, f := range files {
+			tasks[i] = bulkTask{
+				label: f.Name,
+				run: func(ctx context.Context) error {
+					return f.Write(dataPath, outDir)
+				},
+			}
+		}
+		return bulkStartMsg{kind: notify.KindExport, title: "Export diagrams", detail: outDir, tasks: tasks}
 	}
 }
 
 func (m *GroupModel) Update(msg tea.Msg) (*GroupModel, tea.Cmd, *Screen) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if ui.IsUp(msg) {
-			m.menu.Up()
+		if ui.IsExport(msg) {
+			return m, m.exportDiagrams(), nil
 		}
-		if ui.IsDown(msg) {
-			m.menu.Down()
+		if ui.IsPin(msg) {
+			m.togglePin()
 		}
+		m.menu.HandleKey(msg)
 		if ui.IsEnter(msg) {
 			if item := m.menu.Selected(); item != nil {
 				s := SubgroupScreen(item.ID)
@@ -110,6 +214,9 @@ func (m *GroupModel) renderRightPane(height int) string {
 		title = strings.ToUpper(m.group.Name)
 	}
 	b.WriteString(ui.HeaderStyle.Render(title))
+	if m.counts != nil {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf(" — %d subgroups, %d parts", m.counts.GroupSubgroups[m.groupID], m.counts.GroupParts[m.groupID])))
+	}
 	b.WriteString("\n")
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
 
@@ -138,7 +245,7 @@ func (m *GroupModel) renderRightPane(height int) string {
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select"))
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   p pin   x export diagrams"))
 
 	return b.String()
 }
@@ -0,0 +1,156 @@
+package model
+
+import "strings"
+
+// visit is a screen in the navigation history with the model it had, so
+// going back can return to it as it was left.
+type visit struct {
+	screen Screen
+	model  any
+}
+
+// currentModel returns the model of the screen being shown
+func (m *Model) currentModel() any {
+	switch m.screen.Type {
+	case ScreenHome:
+		return m.home
+	case ScreenGroup:
+		return m.group
+	case ScreenSubgroup:
+		return m.subgroup
+	case ScreenPartDetail:
+		return m.partDetail
+	case ScreenSearch:
+		return m.search
+	case ScreenBookmarks:
+		return m.bookmarks
+	case ScreenNotes:
+		return m.notes
+	case ScreenJump:
+		return m.jump
+	case ScreenCuration:
+		return m.curation
+	case ScreenConsole:
+		return m.console
+	case ScreenPNC:
+		return m.pnc
+	case ScreenJournal:
+		return m.journal
+	case ScreenScan:
+		return m.scan
+	case ScreenPaste:
+		return m.paste
+	case ScreenTemplates:
+		return m.templates
+	case ScreenAdded:
+		return m.added
+	case ScreenHotspots:
+		return m.hotspots
+	case ScreenStats:
+		return m.stats
+	case ScreenVehicles:
+		return m.vehicles
+	case ScreenCart:
+		return m.cart
+	case ScreenWizard:
+		return m.wizard
+	case ScreenViewer:
+		return m.viewer
+	case ScreenTree:
+		return m.tree
+	}
+	return nil
+}
+
+// resume brings back a screen whose state is only what was typed into it,
+// such as a search query and its results, exactly as it was left. It
+// reports false for screens that need rebuilding.
+func (m *Model) resume(prev any) bool {
+	switch prev := prev.(type) {
+	case *SearchModel:
+		m.search = prev
+		if prev.hideSuperseded != m.db.HidingSuperseded() {
+			prev.reloadSuperseded()
+		}
+		// Parts just viewed go to the top of the launchpad
+		if strings.TrimSpace(prev.input.Value()) == "" {
+			prev.loadLaunchpad()
+		}
+	case *JumpModel:
+		m.jump = prev
+	case *PNCModel:
+		m.pnc = prev
+	case *ConsoleModel:
+		m.console = prev
+	case *ScanModel:
+		m.scan = prev
+	case *PasteModel:
+		m.paste = prev
+	case *TemplatesModel:
+		m.templates = prev
+	case *WizardModel:
+		m.wizard = prev
+	case *ViewerModel:
+		m.viewer = prev
+	case *TreeModel:
+		m.tree = prev
+	default:
+		return false
+	}
+	return true
+}
+
+// keepPosition carries the cursor and scroll of a screen's previous model
+// over to the one just rebuilt for it. These screens are rebuilt rather
+// than resumed so that changes made meanwhile, like a new bookmark, show.
+func (m *Model) keepPosition(prev any) {
+	switch prev := prev.(type) {
+	case *HomeModel:
+		m.home.menu.KeepPosition(prev.menu)
+	case *GroupModel:
+		m.group.menu.KeepPosition(prev.menu)
+	case *SubgroupModel:
+		// Still narrowed to the origin that was chosen
+		if prev.originFilter != "" {
+			m.subgroup.originFilter = prev.originFilter
+			m.subgroup.buildTable()
+		}
+		m.subgroup.table.KeepPosition(prev.table)
+	case *BookmarksModel:
+		m.bookmarks.table.KeepPosition(prev.table)
+	case *NotesModel:
+		// Back on the page and filter that were left
+		if prev.page > 0 || prev.filter.Value() != "" {
+			m.notes.page = prev.page
+			m.notes.filter.SetValue(prev.filter.Value())
+			m.notes.load()
+		}
+		m.notes.table.KeepPosition(prev.table)
+	case *JournalModel:
+		m.journal.menu.KeepPosition(prev.menu)
+	case *AddedModel:
+		if prev.days != m.added.days {
+			m.added.load(prev.days)
+		}
+		m.added.table.KeepPosition(prev.table)
+	case *StatsModel:
+		m.stats.table.KeepPosition(prev.table)
+	case *VehiclesModel:
+		m.vehicles.table.KeepPosition(prev.table)
+	case *CartModel:
+		m.cart.table.KeepPosition(prev.table)
+	case *CurationModel:
+		m.curation.menu.KeepPosition(prev.menu)
+	case *PartDetailModel:
+		m.partDetail.keepPosition(prev)
+	case *TemplatesModel:
+		m.templates.menu.KeepPosition(prev.menu)
+	case *HotspotsModel:
+		m.hotspots.menu.KeepPosition(prev.menu)
+		m.hotspots.x, m.hotspots.y = prev.x, prev.y
+	case *ViewerModel:
+		// Zoomed and panned as it was; View clamps the pan to the image
+		m.viewer.zoom, m.viewer.fitScale = prev.zoom, prev.fitScale
+		m.viewer.panX, m.viewer.panY = prev.panX, prev.panY
+	}
+}
@@ -3,104 +3,339 @@ package model
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"delica-tui/db"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-func getVehicleInfo() (name, frame, exterior, interior, date string) {
-	name = os.Getenv("VEHICLE_NAME")
-	if name == "" {
-		name = "Mitsubishi Delica Space Gear"
+// defaultBanner is shown when no vehicle photo or banner file is configured.
+var defaultBanner = []string{
+	"   ____________________",
+	"  /  |     |     |    |\\",
+	" /___|_____|_____|____|_\\_",
+	"|   _                 _   |",
+	"'--(_)---------------(_)--'",
+}
+
+// Banner image size in cells
+const (
+	bannerWidthCells  = 40
+	bannerHeightCells = 12
+)
+
+// loadBanner loads the home screen banner. VEHICLE_IMAGE names a photo and
+// VEHICLE_BANNER a text file of ASCII art; relative paths are resolved from
+// the project root (the parent of the data directory). The photo wins when
+// it loads, otherwise the ASCII banner or the built-in van is used.
+func loadBanner(dataPath string) (*image.KittyImage, []string) {
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dataPath, "..", p)
+	}
+
+	if p := os.Getenv("VEHICLE_IMAGE"); p != "" {
+		if img, err := image.LoadAndScale(resolve(p), bannerWidthCells, bannerHeightCells); err == nil {
+			return img, nil
+		}
+	}
+	if p := os.Getenv("VEHICLE_BANNER"); p != "" {
+		if data, err := os.ReadFile(resolve(p)); err == nil {
+			return nil, strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		}
 	}
-	frame = os.Getenv("FRAME_NO")
-	exterior = os.Getenv("EXTERIOR_CODE")
-	interior = os.Getenv("INTERIOR_CODE")
-	date = os.Getenv("MANUFACTURE_DATE")
-	return
+	return nil, defaultBanner
 }
 
 type HomeModel struct {
 	db            *db.DB
 	groups        []db.Group
+	pins          []db.Pin
 	bookmarkCount int
 	noteCount     int
+	addedCount    int
+	cartCount     int
+	vehicle       db.Vehicle
+	vehicleCount  int
+	syncedAt      map[string]string // group ID to last sync date
+	counts        *db.Counts
 	menu          *ui.Menu
+	bannerImg     *image.KittyImage
+	bannerText    []string
+	images        *imageCache
 }
 
-func NewHomeModel(database *db.DB) *HomeModel {
+func NewHomeModel(database *db.DB, dataPath string, images *imageCache) *HomeModel {
 	groups, _ := database.GetGroups()
 	bookmarkCount, _ := database.GetBookmarkCount()
 	noteCount, _ := database.GetNoteCount()
+	addedCount, _ := database.CountAddedParts(addedSince(addedPeriods[0]))
+	pins, _ := database.GetPins()
+	vehicles, _ := database.GetVehicles()
+	cart, _ := database.GetCart()
+
+	syncedAt := make(map[string]string)
+	syncs, _ := database.GetGroupSyncs()
+	for _, s := range syncs {
+		if s.SyncedAt != nil {
+			syncedAt[s.GroupID] = locale.DateString(*s.SyncedAt)
+		}
+	}
 
-	// Build menu items
+	counts, _ := database.GetCounts()
+
+	bannerImg, bannerText := loadBanner(dataPath)
+
+	m := &HomeModel{
+		db:            database,
+		groups:        groups,
+		pins:          pins,
+		bookmarkCount: bookmarkCount,
+		noteCount:     noteCount,
+		addedCount:    addedCount,
+		cartCount:     len(cart),
+		vehicle:       activeVehicle(database),
+		vehicleCount:  len(vehicles),
+		syncedAt:      syncedAt,
+		counts:        counts,
+		bannerImg:     bannerImg,
+		bannerText:    bannerText,
+		images:        images,
+	}
+	m.menu = ui.NewMenu(m.menuItems())
+	return m
+}
+
+func (m *HomeModel) menuItems() []ui.MenuItem {
 	var items []ui.MenuItem
 
 	// Search and bookmarks
 	items = append(items, ui.MenuItem{ID: "__search__", Label: "/ Search", Hint: "Find parts by number or name"})
+	items = append(items, ui.MenuItem{ID: "__wizard__", Label: "? Find My Part", Hint: "Answer a few questions instead"})
+	items = append(items, ui.MenuItem{ID: "__jump__", Label: "@ Jump", Hint: "Go to a subgroup by name"})
+	items = append(items, ui.MenuItem{ID: "__tree__", Label: "| Catalog Tree", Hint: "Every group, subgroup and part in one tree"})
+	items = append(items, ui.MenuItem{ID: "__pnc__", Label: "= PNC", Hint: "Find parts by catalog number"})
+	items = append(items, ui.MenuItem{ID: "__scan__", Label: "+ Scan", Hint: "Enter part numbers in a batch"})
+	items = append(items, ui.MenuItem{ID: "__paste__", Label: "+ Paste List", Hint: "Add a parts list to the shortlist"})
+	items = append(items, ui.MenuItem{ID: "__templates__", Label: "+ Job Templates", Hint: "Parts for common jobs"})
 
 	bookmarkHint := ""
-	if bookmarkCount > 0 {
-		bookmarkHint = fmt.Sprintf("%d saved", bookmarkCount)
+	if m.bookmarkCount > 0 {
+		bookmarkHint = fmt.Sprintf("%d saved", m.bookmarkCount)
 	}
 	items = append(items, ui.MenuItem{ID: "__bookmarks__", Label: "* Bookmarks", Hint: bookmarkHint})
 
 	noteHint := ""
-	if noteCount > 0 {
-		noteHint = fmt.Sprintf("%d parts", noteCount)
+	if m.noteCount > 0 {
+		noteHint = fmt.Sprintf("%d parts", m.noteCount)
 	}
 	items = append(items, ui.MenuItem{ID: "__notes__", Label: "# Notes", Hint: noteHint})
+	items = append(items, ui.MenuItem{ID: "__journal__", Label: "~ Journal", Hint: "Work by day"})
+
+	cartHint := "Parts to order"
+	if m.cartCount > 0 {
+		cartHint = fmt.Sprintf("%d lines to order", m.cartCount)
+	}
+	items = append(items, ui.MenuItem{ID: "__cart__", Label: "$ Cart", Hint: cartHint})
+
+	addedHint := "New parts after a sync"
+	if m.addedCount > 0 {
+		addedHint = fmt.Sprintf("%d new in %d days", m.addedCount, addedPeriods[0])
+	}
+	items = append(items, ui.MenuItem{ID: "__added__", Label: "> Recently Added", Hint: addedHint})
+
+	vehicleHint := "Add your van"
+	if m.vehicleCount > 1 {
+		vehicleHint = fmt.Sprintf("%d profiles, switch van", m.vehicleCount)
+	} else if m.vehicleCount == 1 {
+		vehicleHint = "Profile of your van"
+	}
+	items = append(items, ui.MenuItem{ID: "__vehicles__", Label: "& Vehicles", Hint: vehicleHint})
+	items = append(items, ui.MenuItem{ID: "__curation__", Label: "! Curation", Hint: "Fix incomplete catalog data"})
+	if usageEnabled {
+		items = append(items, ui.MenuItem{ID: "__stats__", Label: "% Usage Stats", Hint: "Screens and keys used most"})
+	}
 
 	// Separator (empty item that we'll skip in navigation)
 	items = append(items, ui.MenuItem{ID: "__separator__", Label: ""})
 
+	// Pinned groups and subgroups
+	if len(m.pins) > 0 {
+		for _, p := range m.pins {
+			item := ui.MenuItem{ID: pinItemID(p.Kind, p.ID), Label: "^ " + p.Name}
+			if p.Kind == db.PinSubgroup {
+				item.Hint = p.GroupName
+			}
+			items = append(items, item)
+		}
+		items = append(items, ui.MenuItem{ID: "__separator__", Label: ""})
+	}
+
 	// Groups
-	for _, g := range groups {
-		items = append(items, ui.MenuItem{ID: g.ID, Label: g.Name})
+	for _, g := range m.groups {
+		var hints []string
+		if m.counts != nil {
+			hints = append(hints, fmt.Sprintf("%d subgroups, %d parts", m.counts.GroupSubgroups[g.ID], m.counts.GroupParts[g.ID]))
+		}
+		if date, ok := m.syncedAt[g.ID]; ok {
+			hints = append(hints, "synced "+date)
+		}
+		items = append(items, ui.MenuItem{ID: g.ID, Label: g.Name, Hint: strings.Join(hints, " · ")})
 	}
 
-	return &HomeModel{
-		db:            database,
-		groups:        groups,
-		bookmarkCount: bookmarkCount,
-		noteCount:     noteCount,
-		menu:          ui.NewMenu(items),
+	return items
+}
+
+// Pinned items are prefixed so they don't collide with group IDs
+const pinItemPrefix = "__pin__:"
+
+func pinItemID(kind, id string) string {
+	return pinItemPrefix + kind + ":" + id
+}
+
+func parsePinItemID(itemID string) (kind, id string, ok bool) {
+	rest, ok := strings.CutPrefix(itemID, pinItemPrefix)
+	if !ok {
+		return "", "", false
+	}
+	kind, id, ok = strings.Cut(rest, ":")
+	return kind, id, ok
+}
+
+func (m *HomeModel) isPinned(kind, id string) bool {
+	for _, p := range m.pins {
+		if p.Kind == kind && p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// togglePin pins the selected group, or unpins the selected pinned item,
+// keeping the cursor on the same item where it still exists.
+func (m *HomeModel) togglePin() {
+	item := m.menu.Selected()
+	if item == nil {
+		return
+	}
+
+	kind, id, ok := parsePinItemID(item.ID)
+	if !ok {
+		// Only groups can be pinned from here, not the screens above them
+		if strings.HasPrefix(item.ID, "__") {
+			return
+		}
+		kind, id = db.PinGroup, item.ID
+	}
+
+	if m.isPinned(kind, id) {
+		m.db.RemovePin(kind, id)
+	} else {
+		m.db.AddPin(kind, id)
+	}
+	m.pins, _ = m.db.GetPins()
+
+	selectedID := item.ID
+	cursor := m.menu.Cursor
+	m.menu = ui.NewMenu(m.menuItems())
+	m.menu.Cursor = cursor
+	for i, it := range m.menu.Items {
+		if it.ID == selectedID {
+			m.menu.Cursor = i
+			break
+		}
+	}
+	if m.menu.Cursor >= len(m.menu.Items) {
+		m.menu.Cursor = len(m.menu.Items) - 1
+	}
+	if m.menu.Selected() != nil && m.menu.Selected().ID == "__separator__" {
+		m.menu.Down()
 	}
 }
 
 func (m *HomeModel) Update(msg tea.Msg) (*HomeModel, tea.Cmd, *Screen) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if ui.IsUp(msg) {
-			m.menu.Up()
-			// Skip separator
+		prev := m.menu.Cursor
+		if m.menu.HandleKey(msg) {
+			// Skip separator, continuing in the direction of travel
 			if m.menu.Selected() != nil && m.menu.Selected().ID == "__separator__" {
-				m.menu.Up()
+				if m.menu.Cursor < prev {
+					m.menu.Up()
+				} else {
+					m.menu.Down()
+				}
 			}
 		}
-		if ui.IsDown(msg) {
-			m.menu.Down()
-			// Skip separator
-			if m.menu.Selected() != nil && m.menu.Selected().ID == "__separator__" {
-				m.menu.Down()
-			}
+		if ui.IsPin(msg) {
+			m.togglePin()
 		}
 		if ui.IsEnter(msg) {
 			if item := m.menu.Selected(); item != nil {
+				if kind, id, ok := parsePinItemID(item.ID); ok {
+					s := GroupScreen(id)
+					if kind == db.PinSubgroup {
+						s = SubgroupScreen(id)
+					}
+					return m, nil, &s
+				}
 				switch item.ID {
 				case "__search__":
 					s := SearchScreen("")
 					return m, nil, &s
+				case "__wizard__":
+					s := WizardScreen()
+					return m, nil, &s
+				case "__tree__":
+					s := TreeScreen()
+					return m, nil, &s
+				case "__jump__":
+					s := JumpScreen()
+					return m, nil, &s
+				case "__pnc__":
+					s := PNCScreen()
+					return m, nil, &s
+				case "__scan__":
+					s := ScanScreen()
+					return m, nil, &s
+				case "__paste__":
+					s := PasteScreen()
+					return m, nil, &s
+				case "__templates__":
+					s := TemplatesScreen()
+					return m, nil, &s
 				case "__bookmarks__":
 					s := BookmarksScreen()
 					return m, nil, &s
 				case "__notes__":
 					s := NotesScreen()
 					return m, nil, &s
+				case "__journal__":
+					s := JournalScreen("")
+					return m, nil, &s
+				case "__cart__":
+					s := CartScreen()
+					return m, nil, &s
+				case "__added__":
+					s := AddedScreen()
+					return m, nil, &s
+				case "__curation__":
+					s := CurationScreen()
+					return m, nil, &s
+				case "__stats__":
+					s := StatsScreen()
+					return m, nil, &s
+				case "__vehicles__":
+					s := VehiclesScreen()
+					return m, nil, &s
 				case "__separator__":
 					// Do nothing
 				default:
@@ -135,31 +370,64 @@ func (m *HomeModel) View(width, height int) string {
 		splitHeight = 10
 	}
 
-	leftContent := m.renderLeftPane(splitHeight)
+	leftContent := m.renderLeftPane(ui.SplitPaneLeftWidth(width-2), splitHeight)
 	rightContent := m.renderRightPane(splitHeight)
 
 	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
 
-	return header + "\n" + split
+	// Output banner image at the top of the left pane
+	var img string
+	if m.bannerImg != nil {
+		img = "\x1b7" + "  " + m.bannerImg.Render() + "\x1b8"
+	}
+
+	return header + "\n" + img + split
 }
 
-func (m *HomeModel) renderLeftPane(height int) string {
+func (m *HomeModel) renderLeftPane(width, height int) string {
 	var lines []string
 
-	// Vehicle info from environment
-	name, frame, exterior, interior, date := getVehicleInfo()
-
-	lines = append(lines, ui.HeaderStyle.Render(name))
+	// Banner: image placeholder lines or ASCII art
+	if m.bannerImg != nil {
+		for i := 0; i < m.bannerImg.CellHeight(); i++ {
+			lines = append(lines, m.bannerImg.Row(i))
+		}
+	} else {
+		for _, line := range m.bannerText {
+			lines = append(lines, ui.DimStyle.Render(line))
+		}
+	}
 	lines = append(lines, "")
-	lines = append(lines, fmt.Sprintf("Frame: %s", frame))
-	lines = append(lines, fmt.Sprintf("Exterior: %s", exterior))
-	lines = append(lines, fmt.Sprintf("Interior: %s", interior))
-	lines = append(lines, fmt.Sprintf("Manufactured: %s", date))
 
-	// Pad to fill height
-	for len(lines) < height {
+	// The active vehicle profile
+	v := m.vehicle
+	lines = append(lines, ui.HeaderStyle.Render(vehicleName(v)))
+	if v.FrameNo != "" {
+		lines = append(lines, ui.PartNumberStyle.Render(v.FrameNo))
+	}
+	var colors []string
+	if v.ExteriorCode != "" {
+		colors = append(colors, "Exterior "+v.ExteriorCode)
+	}
+	if v.InteriorCode != "" {
+		colors = append(colors, "Interior "+v.InteriorCode)
+	}
+	if len(colors) > 0 {
+		lines = append(lines, ui.DimStyle.Render(strings.Join(colors, " · ")))
+	}
+	if v.ManufactureDate != "" {
+		lines = append(lines, ui.DimStyle.Render("Built "+locale.DateString(v.ManufactureDate)))
+	}
+
+	// Pad to fill height, with image memory use at the bottom
+	var stats []string
+	if m.images != nil {
+		stats = strings.Split(ui.DimStyle.Width(width).Render(m.images.stats()), "\n")
+	}
+	for len(lines) < height-len(stats) {
 		lines = append(lines, "")
 	}
+	lines = append(lines, stats...)
 
 	return strings.Join(lines, "\n")
 }
@@ -188,7 +456,7 @@ func (m *HomeModel) renderRightPane(height int) string {
 	b.WriteString(m.renderMenuWithSeparator())
 
 	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   / search"))
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   p pin   / search   ctrl+p jump"))
 
 	return b.String()
 }
@@ -221,3 +489,10 @@ func (m *HomeModel) renderMenuWithSeparator() string {
 	}
 	return b.String()
 }
+
+func (m *HomeModel) ImageID() uint32 {
+	if m.bannerImg != nil {
+		return m.bannerImg.ID()
+	}
+	return 0
+}
@@ -0,0 +1,352 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HotspotsModel captures where a diagram's callouts are: move the crosshair
+// onto a callout and press enter to record it for the selected ref number.
+// Positions are kept in pixels of the original image.
+type HotspotsModel struct {
+	db       *db.DB
+	diagram  *db.Diagram
+	canvas   *image.Canvas
+	img      *image.KittyImage
+	imgError string
+
+	refs     []string            // ref numbers of the diagram's parts, in callout order
+	parts    map[string][]string // descriptions of the parts under each ref number
+	hotspots []db.Hotspot
+	menu     *ui.Menu
+
+	// The crosshair
+	x, y int
+}
+
+func NewHotspotsModel(database *db.DB, diagramID string, prefetch *prefetcher) *HotspotsModel {
+	m := &HotspotsModel{db: database, parts: make(map[string][]string)}
+	m.diagram, _ = database.GetDiagram(diagramID)
+
+	parts, _ := database.GetPartsForDiagram(diagramID)
+	for _, p := range parts {
+		ref := strings.TrimSpace(deref(p.RefNumber))
+		if ref == "" {
+			continue
+		}
+		if _, ok := m.parts[ref]; !ok {
+			m.refs = append(m.refs, ref)
+		}
+		name := deref(p.Description)
+		if name == "" {
+			name = p.PartNumber
+		}
+		if !slices.Contains(m.parts[ref], name) {
+			m.parts[ref] = append(m.parts[ref], name)
+		}
+	}
+	sort.SliceStable(m.refs, func(i, j int) bool { return refLess(m.refs[i], m.refs[j]) })
+
+	if m.diagram != nil && m.diagram.ImagePath != nil {
+		canvas, err := image.LoadCanvas(prefetch.diagramPath(*m.diagram.ImagePath), diagramWidthCells, diagramHeightCells)
+		if err != nil {
+			m.imgError = err.Error()
+		} else {
+			m.canvas = canvas
+			w, h := canvas.Size()
+			m.x, m.y = w/2, h/2
+		}
+	}
+
+	m.menu = ui.NewMenu(nil)
+	m.load()
+	return m
+}
+
+// refLess orders ref numbers by their number, so 2 comes before 10, and
+// otherwise as text
+func refLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	if (errA == nil) != (errB == nil) {
+		return errA == nil
+	}
+	return a < b
+}
+
+// load reads the captured hotspots and redraws the diagram with them
+func (m *HotspotsModel) load() {
+	if m.diagram != nil {
+		m.hotspots, _ = m.db.GetHotspots(m.diagram.ID)
+	}
+
+	counts := make(map[string]int)
+	for _, h := range m.hotspots {
+		counts[h.RefNumber]++
+	}
+	items := make([]ui.MenuItem, len(m.refs))
+	for i, ref := range m.refs {
+		hint := strings.Join(m.parts[ref], ", ")
+		switch counts[ref] {
+		case 0:
+		case 1:
+			hint = "1 spot - " + hint
+		default:
+			hint = fmt.Sprintf("%d spots - ", counts[ref]) + hint
+		}
+		items[i] = ui.MenuItem{ID: ref, Label: ref, Hint: truncateText(hint, 40), Tinted: counts[ref] > 0}
+	}
+	prev := m.menu
+	m.menu = ui.NewMenu(items)
+	m.menu.KeepPosition(prev)
+	m.draw()
+}
+
+// draw puts the hotspots and crosshair on the diagram, the selected ref
+// number's spots standing out
+func (m *HotspotsModel) draw() {
+	if m.canvas == nil {
+		return
+	}
+	selected := ""
+	if item := m.menu.Selected(); item != nil {
+		selected = item.ID
+	}
+	var marks []image.Mark
+	for _, h := range m.hotspots {
+		if h.RefNumber != selected {
+			marks = append(marks, image.Mark{X: h.X, Y: h.Y, Style: image.MarkSpot})
+		}
+	}
+	for _, h := range m.hotspots {
+		if h.RefNumber == selected {
+			marks = append(marks, image.Mark{X: h.X, Y: h.Y, Style: image.MarkSelected})
+		}
+	}
+	marks = append(marks, image.Mark{X: m.x, Y: m.y, Style: image.MarkCrosshair})
+
+	img, err := m.canvas.Draw(marks)
+	if err != nil {
+		m.imgError = err.Error()
+		return
+	}
+	m.img = img
+}
+
+// step is how far the crosshair moves: about a cell, or with fine about a
+// screen pixel, in pixels of the original image
+func (m *HotspotsModel) step(fine bool) int {
+	scale := m.canvas.Scale()
+	if fine {
+		return max(int(1/scale), 1)
+	}
+	cellWidth, _ := image.CellSize()
+	return max(int(float64(cellWidth)/scale), 1)
+}
+
+func (m *HotspotsModel) move(dx, dy int) {
+	w, h := m.canvas.Size()
+	m.x = min(max(m.x+dx, 0), w-1)
+	m.y = min(max(m.y+dy, 0), h-1)
+	m.draw()
+}
+
+// nearest returns the captured hotspot closest to the crosshair, if one is
+// within a cell of it
+func (m *HotspotsModel) nearest() *db.Hotspot {
+	reach := m.step(false)
+	var found *db.Hotspot
+	best := reach*reach + 1
+	for i, h := range m.hotspots {
+		dx, dy := h.X-m.x, h.Y-m.y
+		if d := dx*dx + dy*dy; d < best {
+			found, best = &m.hotspots[i], d
+		}
+	}
+	return found
+}
+
+func (m *HotspotsModel) Update(msg tea.Msg) (*HotspotsModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if ui.IsNextRef(msg) {
+			m.menu.Down()
+			m.draw()
+			return m, nil, nil
+		}
+		if ui.IsPrevRef(msg) {
+			m.menu.Up()
+			m.draw()
+			return m, nil, nil
+		}
+		if m.canvas == nil {
+			return m, nil, nil
+		}
+		if dx, dy := ui.Pan(msg); dx != 0 || dy != 0 {
+			step := m.step(true)
+			m.move(dx*step, dy*step)
+			return m, nil, nil
+		}
+		switch {
+		case ui.IsLeft(msg):
+			m.move(-m.step(false), 0)
+		case ui.IsRight(msg):
+			m.move(m.step(false), 0)
+		case ui.IsUp(msg):
+			m.move(0, -m.step(false))
+		case ui.IsDown(msg):
+			m.move(0, m.step(false))
+		case ui.IsEnter(msg):
+			return m, m.capture(), nil
+		case ui.IsRemove(msg):
+			return m, m.remove(), nil
+		}
+	}
+	return m, nil, nil
+}
+
+// capture records the crosshair as a callout of the selected ref number
+// and moves on to the next one
+func (m *HotspotsModel) capture() tea.Cmd {
+	item := m.menu.Selected()
+	if item == nil || m.diagram == nil {
+		return nil
+	}
+	h := db.Hotspot{DiagramID: m.diagram.ID, RefNumber: item.ID, X: m.x, Y: m.y}
+	if err := m.db.AddHotspot(h); err != nil {
+		return func() tea.Msg { return toastMsg{text: fmt.Sprintf("Save hotspot: %v", err), isError: true} }
+	}
+	m.menu.Down()
+	m.load()
+	return func() tea.Msg { return toastMsg{text: fmt.Sprintf("Ref %s at %d, %d", h.RefNumber, h.X, h.Y)} }
+}
+
+// remove forgets the hotspot under the crosshair
+func (m *HotspotsModel) remove() tea.Cmd {
+	h := m.nearest()
+	if h == nil {
+		return func() tea.Msg { return toastMsg{text: "No hotspot under the crosshair"} }
+	}
+	removed := *h
+	if err := m.db.RemoveHotspot(removed); err != nil {
+		return func() tea.Msg { return toastMsg{text: fmt.Sprintf("Remove hotspot: %v", err), isError: true} }
+	}
+	m.load()
+	return func() tea.Msg {
+		return toastMsg{text: fmt.Sprintf("Removed ref %s at %d, %d", removed.RefNumber, removed.X, removed.Y)}
+	}
+}
+
+func (m *HotspotsModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	var result strings.Builder
+
+	// Top margin (2 blank lines to match other pages)
+	result.WriteString("\n\n")
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderDiagram(splitHeight)
+	rightContent := m.renderRefs(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	// Output image escape with positioning, as on the subgroup screen
+	if m.img != nil {
+		result.WriteString("\x1b7")   // Save cursor position
+		result.WriteString("  ")      // Left padding (matches split pane margin)
+		result.WriteString("\x1b[1B") // Move cursor down 1 line (past the caption)
+		result.WriteString(m.img.Render())
+		result.WriteString("\x1b8") // Restore cursor position
+	}
+
+	result.WriteString(split)
+
+	return result.String()
+}
+
+func (m *HotspotsModel) renderDiagram(height int) string {
+	var lines []string
+
+	if m.img != nil {
+		lines = append(lines, ui.DimStyle.Render(m.diagram.ID)+"  "+ui.DimStyle.Render(fmt.Sprintf("x %d  y %d", m.x, m.y)))
+		// Image is rendered separately in View(), just add placeholder
+		// lines, unless it's drawn as text
+		for i := 0; i < m.img.CellHeight(); i++ {
+			lines = append(lines, m.img.Row(i))
+		}
+	} else if image.Disabled {
+		lines = append(lines, ui.DimStyle.Render("Diagram hidden in low-bandwidth mode"))
+	} else if m.imgError != "" {
+		lines = append(lines, ui.ErrorStyle.Render(m.imgError))
+	} else {
+		lines = append(lines, ui.DimStyle.Render("No diagram available"))
+	}
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *HotspotsModel) renderRefs(width, height int) string {
+	var b strings.Builder
+
+	title := "UNKNOWN"
+	if m.diagram != nil {
+		title = strings.ToUpper(m.diagram.Name)
+	}
+	b.WriteString(ui.HeaderStyle.Render("HOTSPOTS > " + title))
+	b.WriteString(strings.Repeat(" ", 5))
+	b.WriteString(ui.CountStyle.Render(fmt.Sprintf("%d", len(m.hotspots))))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	if len(m.refs) == 0 {
+		b.WriteString(ui.DimStyle.Render("None of this diagram's parts"))
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("have a ref number"))
+	} else {
+		m.menu.MaxVisibleItems = max(height-6, 5)
+		m.menu.Width = width
+		b.WriteString(lipgloss.NewStyle().MaxWidth(width).Render(m.menu.View()))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(ui.DimStyle.Render("←↑↓→ move   HJKL fine   tab/shift+tab ref   enter save   d remove"))
+
+	return b.String()
+}
+
+func (m *HotspotsModel) ImageID() uint32 {
+	if m.img != nil {
+		return m.img.ID()
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+)
+
+// defaultImageCacheMB is how much memory scaled images may take without
+// DELICA_IMAGE_CACHE_MB
+const defaultImageCacheMB = 64
+
+func imageCacheLimit() int {
+	if v := os.Getenv("DELICA_IMAGE_CACHE_MB"); v != "" {
+		if mb, err := strconv.Atoi(v); err == nil && mb >= 0 {
+			return mb << 20
+		}
+	}
+	return defaultImageCacheMB << 20
+}
+
+// imageCache keeps scaled diagram images so screens that show many
+// diagrams, like search and the subgroup parts list, only load each one
+// once. It is shared across screen models and filled from background
+// commands. Once the images take more than limit bytes, the least recently
+// used are dropped; screens still showing one keep their own reference.
+type imageCache struct {
+	mu      sync.Mutex
+	images  map[string]*list.Element // of *cachedImage, most recent first
+	recent  *list.List
+	bytes   int
+	limit   int
+	hits    int
+	misses  int
+	evicted int
+}
+
+type cachedImage struct {
+	key string
+	img *image.KittyImage
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{
+		images: make(map[string]*list.Element),
+		recent: list.New(),
+		limit:  imageCacheLimit(),
+	}
+}
+
+// Images are cached per path and size
+func imageCacheKey(path string, widthCells, heightCells int) string {
+	return fmt.Sprintf("%s@%dx%d", path, widthCells, heightCells)
+}
+
+func (c *imageCache) get(path string, widthCells, heightCells int) *image.KittyImage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.images[imageCacheKey(path, widthCells, heightCells)]
+	if e == nil {
+		return nil
+	}
+	c.recent.MoveToFront(e)
+	return e.Value.(*cachedImage).img
+}
+
+// load returns the cached image for path at the given size, loading and
+// scaling it on a miss.
+func (c *imageCache) load(path string, widthCells, heightCells int) (*image.KittyImage, error) {
+	if img := c.get(path, widthCells, heightCells); img != nil {
+		c.mu.Lock()
+		c.hits++
+		c.mu.Unlock()
+		return img, nil
+	}
+	img, err := image.LoadAndScale(path, widthCells, heightCells)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses++
+	key := imageCacheKey(path, widthCells, heightCells)
+	if e := c.images[key]; e != nil {
+		// Loaded meanwhile by another command
+		c.recent.MoveToFront(e)
+		return e.Value.(*cachedImage).img, nil
+	}
+	c.images[key] = c.recent.PushFront(&cachedImage{key: key, img: img})
+	c.bytes += img.Bytes()
+	c.evict()
+	return img, nil
+}
+
+// evict drops the least recently used images until the cache is within its
+// limit, keeping the newest even if it alone is over
+func (c *imageCache) evict() {
+	for c.bytes > c.limit && c.recent.Len() > 1 {
+		e := c.recent.Back()
+		cached := c.recent.Remove(e).(*cachedImage)
+		delete(c.images, cached.key)
+		c.bytes -= cached.img.Bytes()
+		c.evicted++
+	}
+}
+
+// reset drops every image, for when the files may have changed
+func (c *imageCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images = make(map[string]*list.Element)
+	c.recent.Init()
+	c.bytes = 0
+}
+
+// stats describes the cache and the pixel budget for the home screen
+func (c *imageCache) stats() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	line := fmt.Sprintf("Images %d cached · %.1f/%d MB", c.recent.Len(), float64(c.bytes)/(1<<20), c.limit>>20)
+	if total := c.hits + c.misses; total > 0 {
+		line += fmt.Sprintf(" · %d%% hits", c.hits*100/total)
+	}
+	if c.evicted > 0 {
+		line += fmt.Sprintf(" · %d evicted", c.evicted)
+	}
+	if n := image.Downscaled(); n > 0 {
+		line += fmt.Sprintf(" · %d downscaled", n)
+	}
+	if n := image.Refused(); n > 0 {
+		line += fmt.Sprintf(" · %d too large", n)
+	}
+	return line
+}
@@ -0,0 +1,284 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// JournalModel lists the days you noted or bookmarked parts, newest first,
+// or with day set, the parts of one day's job.
+type JournalModel struct {
+	db      *db.DB
+	day     string // YYYY-MM-DD, or "" for the list of days
+	entries []db.JournalEntry
+	days    int
+	labor   time.Duration // worked on the day's parts
+	menu    *ui.Menu
+}
+
+func NewJournalModel(database *db.DB, day string) *JournalModel {
+	entries, _ := database.GetJournal(day)
+	m := &JournalModel{db: database, day: day}
+	if day == "" {
+		m.menu = ui.NewMenu(m.dayItems(entries))
+	} else {
+		// GetJournal returns everything from the day on
+		for _, e := range entries {
+			if journalDay(e) == day {
+				m.entries = append(m.entries, e)
+			}
+		}
+		m.menu = ui.NewMenu(m.partItems())
+	}
+	return m
+}
+
+func journalDay(e db.JournalEntry) string {
+	day, _, _ := strings.Cut(e.At, " ")
+	day, _, _ = strings.Cut(day, "T")
+	return day
+}
+
+// dayItems summarizes each day's activity, newest day first
+func (m *JournalModel) dayItems(entries []db.JournalEntry) []ui.MenuItem {
+	var items []ui.MenuItem
+	parts := make(map[string]map[int]bool)
+	notes := make(map[string]string)
+	labor := make(map[string]time.Duration)
+	for _, e := range entries {
+		day := journalDay(e)
+		if parts[day] == nil {
+			parts[day] = make(map[int]bool)
+			items = append(items, ui.MenuItem{ID: day, Label: locale.DateString(day)})
+		}
+		parts[day][e.PartID] = true
+		labor[day] += e.Labor
+		if e.Kind == db.JournalNote && notes[day] == "" {
+			notes[day] = strings.ReplaceAll(e.Detail, "\n", " ")
+		}
+	}
+	m.days = len(items)
+
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	for i := range items {
+		day := items[i].ID
+		items[i].Hint = fmt.Sprintf("%d parts", len(parts[day]))
+		if labor[day] > 0 {
+			items[i].Hint += ", " + locale.Hours(labor[day]) + " worked"
+		}
+		if notes[day] != "" {
+			items[i].Hint += " - " + truncateText(notes[day], 50)
+		}
+	}
+	return items
+}
+
+// partItems lists each part of the day once, with its note if it has one
+// and the time worked on it
+func (m *JournalModel) partItems() []ui.MenuItem {
+	var items []ui.MenuItem
+	var labor []time.Duration
+	var says []int
+	seen := make(map[int]int)
+	for _, e := range m.entries {
+		// A note says more than a bookmark, and either more than the timer
+		hint, rank := "worked on", 0
+		switch e.Kind {
+		case db.JournalNote:
+			hint, rank = truncateText(strings.ReplaceAll(e.Detail, "\n", " "), 50), 2
+		case db.JournalBookmark:
+			hint, rank = "bookmarked", 1
+		}
+		if e.Description != nil {
+			hint = *e.Description + " - " + hint
+		}
+
+		if i, ok := seen[e.PartID]; ok {
+			if rank > says[i] {
+				items[i].Hint, says[i] = hint, rank
+			}
+			labor[i] += e.Labor
+			continue
+		}
+		label := e.PartNumber
+		if label == "" {
+			label = fmt.Sprintf("part #%d", e.PartID)
+		}
+		seen[e.PartID] = len(items)
+		items = append(items, ui.MenuItem{ID: fmt.Sprintf("%d", e.PartID), Label: label, Hint: hint})
+		labor = append(labor, e.Labor)
+		says = append(says, rank)
+	}
+	for i := range items {
+		if labor[i] > 0 {
+			items[i].Hint += ", " + locale.Hours(labor[i])
+		}
+	}
+	m.labor = 0
+	for _, d := range labor {
+		m.labor += d
+	}
+	return items
+}
+
+// repeatJob puts every part of the day on the shortlist, to order or work
+// through again
+func (m *JournalModel) repeatJob() tea.Cmd {
+	database := m.db
+	ids := make([]int, len(m.menu.Items))
+	for i, item := range m.menu.Items {
+		fmt.Sscanf(item.ID, "%d", &ids[i])
+	}
+	return func() tea.Msg {
+		var parts []*db.PartWithDiagram
+		for _, id := range ids {
+			if part, err := database.GetPart(id); err == nil && part != nil {
+				parts = append(parts, part)
+			}
+		}
+		return shortlistAddMsg{parts: parts}
+	}
+}
+
+func (m *JournalModel) Update(msg tea.Msg) (*JournalModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.day != "" && ui.IsRepeatJob(msg) && len(m.menu.Items) > 0 {
+			return m, m.repeatJob(), nil
+		}
+		m.menu.HandleKey(msg)
+		if ui.IsEnter(msg) {
+			if item := m.menu.Selected(); item != nil {
+				if m.day == "" {
+					s := JournalScreen(item.ID)
+					return m, nil, &s
+				}
+				var partID int
+				fmt.Sscanf(item.ID, "%d", &partID)
+				s := PartDetailScreen(partID, false)
+				return m, nil, &s
+			}
+		}
+	}
+	return m, nil, nil
+}
+
+func (m *JournalModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *JournalModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("JOURNAL"))
+	lines = append(lines, "")
+	if m.day == "" {
+		if m.days == 1 {
+			lines = append(lines, "1 day of work")
+		} else {
+			lines = append(lines, fmt.Sprintf("%d days of work", m.days))
+		}
+		lines = append(lines, "")
+		lines = append(lines, ui.DimStyle.Render("Notes, bookmarks and"))
+		lines = append(lines, ui.DimStyle.Render("time worked"))
+		lines = append(lines, ui.DimStyle.Render("grouped by day"))
+	} else {
+		lines = append(lines, locale.DateString(m.day))
+		lines = append(lines, fmt.Sprintf("%d parts", len(m.menu.Items)))
+		if m.labor > 0 {
+			lines = append(lines, locale.Hours(m.labor)+" worked")
+		}
+		lines = append(lines, "")
+		lines = append(lines, ui.DimStyle.Render("R puts them all on"))
+		lines = append(lines, ui.DimStyle.Render("the shortlist"))
+	}
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *JournalModel) renderRightPane(height int) string {
+	var b strings.Builder
+
+	// Header
+	if m.day == "" {
+		b.WriteString(ui.HeaderStyle.Render("WORK BY DAY"))
+	} else {
+		b.WriteString(ui.HeaderStyle.Render("PARTS IN THIS JOB"))
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust menu visible items based on available height (max 15)
+	menuHeight := height - 5
+	if menuHeight < 5 {
+		menuHeight = 5
+	}
+	if menuHeight > 15 {
+		menuHeight = 15
+	}
+	m.menu.MaxVisibleItems = menuHeight
+
+	// One less blank line if menu scrolls (to account for scroll indicator)
+	if len(m.menu.Items) > m.menu.MaxVisibleItems {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	// Menu
+	if len(m.menu.Items) == 0 {
+		b.WriteString(ui.DimStyle.Render("Nothing noted or bookmarked yet"))
+	} else {
+		b.WriteString(m.menu.View())
+	}
+
+	b.WriteString("\n\n")
+	if m.day == "" {
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter open day"))
+	} else {
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter open part   R repeat this job"))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,343 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// jumpEntry is a single group or subgroup in the jump index.
+type jumpEntry struct {
+	label   string // "GROUP > SUBGROUP" or "GROUP"
+	name    string // lowercased subgroup (or group) name
+	context string // lowercased group name for subgroups, empty for groups
+	target  Screen
+}
+
+// jumpIndex is a small in-memory index of the catalog taxonomy used for
+// fuzzy quick-jump. It is built once and reused for every jump.
+type jumpIndex struct {
+	entries []jumpEntry
+}
+
+type jumpMatch struct {
+	entry jumpEntry
+	score int
+}
+
+func newJumpIndex(database *db.DB) *jumpIndex {
+	groups, _ := database.GetGroups()
+	subgroups, _ := database.GetAllSubgroups()
+
+	idx := &jumpIndex{}
+	for _, g := range groups {
+		idx.entries = append(idx.entries, jumpEntry{
+			label:  strings.ToUpper(g.Name),
+			name:   strings.ToLower(g.Name),
+			target: GroupScreen(g.ID),
+		})
+	}
+	for _, s := range subgroups {
+		idx.entries = append(idx.entries, jumpEntry{
+			label:   fmt.Sprintf("%s > %s", strings.ToUpper(s.GroupName), strings.ToUpper(s.SubgroupName)),
+			name:    strings.ToLower(s.SubgroupName),
+			context: strings.ToLower(s.GroupName),
+			target:  SubgroupScreen(s.SubgroupID),
+		})
+	}
+	return idx
+}
+
+// search returns entries matching every whitespace-separated term of the
+// query, best matches first. Terms matching the subgroup name itself score
+// higher than terms that only match the parent group.
+func (idx *jumpIndex) search(query string, limit int) []jumpMatch {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []jumpMatch
+	for _, e := range idx.entries {
+		total := 0
+		ok := true
+		for _, term := range terms {
+			score := fuzzyWordScore(term, e.name)
+			if score > 0 {
+				score += 5
+			} else if e.context != "" {
+				score = fuzzyWordScore(term, e.context)
+			}
+			if score == 0 {
+				ok = false
+				break
+			}
+			total += score
+		}
+		if ok {
+			matches = append(matches, jumpMatch{entry: e, score: total})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.label < matches[j].entry.label
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// fuzzyWordScore scores term against the best-matching word in text. A term
+// matches a word when its characters appear in order and the first character
+// matches the start of the word ("pmp" matches "pump"). Returns 0 for no match.
+func fuzzyWordScore(term, text string) int {
+	best := 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '-' || r == '/' || r == '(' || r == ')'
+	}) {
+		if s := subsequenceScore(term, word); s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+func subsequenceScore(term, word string) int {
+	if term == "" || word == "" || term[0] != word[0] {
+		return 0
+	}
+	if strings.HasPrefix(word, term) {
+		return 20 + len(term)
+	}
+
+	score := 10
+	wi := 1
+	prev := 0
+	for ti := 1; ti < len(term); ti++ {
+		found := false
+		for ; wi < len(word); wi++ {
+			if word[wi] == term[ti] {
+				if wi == prev+1 {
+					score += 3
+				} else {
+					score -= wi - prev - 1
+				}
+				prev = wi
+				wi++
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	if score < 1 {
+		score = 1
+	}
+	return score
+}
+
+type JumpModel struct {
+	db       *db.DB
+	index    *jumpIndex
+	input    textinput.Model
+	matches  []jumpMatch
+	partHint string // why a part number typed wasn't found
+	cursor   int
+	visible  int // matches shown at once, set by View
+}
+
+func NewJumpModel(index *jumpIndex, database *db.DB) *JumpModel {
+	ti := textinput.New()
+	ti.Placeholder = "Jump to a group, subgroup or part number..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	return &JumpModel{
+		db:      database,
+		index:   index,
+		input:   ti,
+		visible: 20,
+	}
+}
+
+func (m *JumpModel) Update(msg tea.Msg) (*JumpModel, tea.Cmd, *Screen) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Letter keys type into the input, so only arrow, page and ctrl keys navigate
+		if cursor, ok := ui.MoveCursor(msg, m.cursor, min(len(m.matches), m.visible), m.visible, true); ok {
+			m.cursor = cursor
+			return m, nil, nil
+		}
+		if ui.IsEnter(msg) && len(m.matches) > 0 {
+			s := m.matches[m.cursor].entry.target
+			return m, nil, &s
+		}
+	}
+
+	prevValue := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+
+	// The index is small, so match synchronously on every keystroke
+	if m.input.Value() != prevValue {
+		m.matches = append(m.partMatches(m.input.Value()), m.index.search(m.input.Value(), 50)...)
+		m.cursor = 0
+	}
+
+	return m, cmd, nil
+}
+
+// partMatches returns the part a query that looks like a part number
+// names, or failing that the parts it was likely meant as, setting partHint
+// to say why. Words that can't be part numbers aren't looked up.
+func (m *JumpModel) partMatches(query string) []jumpMatch {
+	m.partHint = ""
+	if strings.Contains(strings.TrimSpace(query), " ") {
+		return nil
+	}
+	lookup, err := m.db.LookupPartNumber(query)
+	if err != nil || lookup.Check.Malformed {
+		return nil
+	}
+	var found []db.PartNumberMatch
+	if lookup.Found {
+		found = append(found, lookup.Match)
+	} else {
+		m.partHint = fmt.Sprintf("%s: %s", lookup.Check.Number, lookup.Hint())
+		for _, s := range lookup.Suggestions {
+			if match, ok, _ := m.db.FindPartNumber(s); ok {
+				found = append(found, match)
+			}
+		}
+	}
+
+	var parts []jumpMatch
+	for _, match := range found {
+		label := "PART " + match.PartNumber
+		if match.Description != nil {
+			label += "  " + *match.Description
+		}
+		parts = append(parts, jumpMatch{entry: jumpEntry{label: label, target: PartDetailScreen(match.PartID, false)}})
+	}
+	return parts
+}
+
+func (m *JumpModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *JumpModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("QUICK JUMP"))
+	lines = append(lines, "")
+	lines = append(lines, "Type part of a group")
+	lines = append(lines, "or subgroup name, or")
+	lines = append(lines, "a part number")
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Letters can be skipped:"))
+	lines = append(lines, ui.DimStyle.Render("\"oil pmp\" finds OIL PUMP"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *JumpModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Input box
+	inputBox := ui.BoxStyle.Render(m.input.View())
+	b.WriteString(inputBox)
+	b.WriteString("\n\n")
+
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	query := strings.TrimSpace(m.input.Value())
+	if m.partHint != "" {
+		b.WriteString(ui.ErrorStyle.Render(truncateText(m.partHint, width)))
+		b.WriteString("\n\n")
+	}
+	if query == "" {
+		b.WriteString(ui.DimStyle.Render("Start typing to jump"))
+	} else if len(m.matches) == 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("No matches for \"%s\"", query)))
+	} else {
+		maxResults := height - 8
+		if maxResults < 5 {
+			maxResults = 5
+		}
+		if maxResults > 20 {
+			maxResults = 20
+		}
+		m.visible = maxResults
+
+		for i, match := range m.matches {
+			if i >= maxResults {
+				break
+			}
+			if i == m.cursor {
+				b.WriteString(ui.SelectedStyle.Render("> "))
+				b.WriteString(ui.SelectedLabelStyle.Render(match.entry.label))
+			} else {
+				b.WriteString("  ")
+				b.WriteString(ui.NormalLabelStyle.Render(match.entry.label))
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%d matches", len(m.matches))))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(ui.DimStyle.Render("↑↓ select   enter jump"))
+
+	return b.String()
+}
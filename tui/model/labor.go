@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// laborChangedMsg says the labor timer was started or stopped
+type laborChangedMsg struct{}
+
+// laborBar is the line along the bottom showing the running labor timer,
+// so it isn't left running when you move on
+type laborBar struct {
+	timer *db.LaborTimer
+}
+
+func (l *laborBar) load(database *db.DB) {
+	l.timer, _ = database.GetRunningLabor()
+}
+
+func (l *laborBar) height() int {
+	if l.timer == nil {
+		return 0
+	}
+	return 1
+}
+
+func (l *laborBar) View(width int) string {
+	part := l.timer.PartNumber
+	if part == "" {
+		part = fmt.Sprintf("part #%d", l.timer.PartID)
+	}
+	started := l.timer.StartedAt.Local()
+	since := started.Format("15:04")
+	if started.Format("2006-01-02") != time.Now().Format("2006-01-02") {
+		since = locale.DateTime(started)
+	}
+	text := fmt.Sprintf("● Timing work on %s since %s · t on its detail stops it", part, since)
+	return "  " + ui.SelectedStyle.MaxWidth(max(width-4, 0)).Render(text)
+}
+
+// toggleLabor starts timing work on a part, or stops the timer if it's
+// already timing this part
+func toggleLabor(database *db.DB, part *db.PartWithDiagram) tea.Cmd {
+	return func() tea.Msg {
+		running, err := database.GetRunningLabor()
+		if err != nil {
+			return toastMsg{text: fmt.Sprintf("Timer failed: %v", err), isError: true}
+		}
+		if running != nil && running.PartID == part.ID {
+			worked, err := database.StopLabor()
+			if err != nil {
+				return toastMsg{text: fmt.Sprintf("Timer failed: %v", err), isError: true}
+			}
+			return tea.BatchMsg{
+				func() tea.Msg { return laborChangedMsg{} },
+				func() tea.Msg {
+					return toastMsg{text: fmt.Sprintf("Stopped the timer: %s on %s", locale.Hours(worked), part.PartNumber)}
+				},
+			}
+		}
+		if err := database.StartLabor(part.ID); err != nil {
+			return toastMsg{text: fmt.Sprintf("Timer failed: %v", err), isError: true}
+		}
+		text := "Timing work on " + part.PartNumber
+		if running != nil {
+			text += "; stopped the timer on " + running.PartNumber
+		}
+		return tea.BatchMsg{
+			func() tea.Msg { return laborChangedMsg{} },
+			func() tea.Msg { return toastMsg{text: text} },
+		}
+	}
+}
@@ -2,10 +2,17 @@ package model
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"delica-tui/db"
-	"delica-tui/image"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/notify"
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -14,7 +21,7 @@ type Model struct {
 	db       *db.DB
 	dataPath string
 	screen   Screen
-	history  []Screen
+	history  []visit
 
 	// Screen models
 	home       *HomeModel
@@ -24,6 +31,59 @@ type Model struct {
 	search     *SearchModel
 	bookmarks  *BookmarksModel
 	notes      *NotesModel
+	jump       *JumpModel
+	curation   *CurationModel
+	console    *ConsoleModel
+	pnc        *PNCModel
+	journal    *JournalModel
+	scan       *ScanModel
+	paste      *PasteModel
+	templates  *TemplatesModel
+	added      *AddedModel
+	hotspots   *HotspotsModel
+	stats      *StatsModel
+	vehicles   *VehiclesModel
+	cart       *CartModel
+	wizard     *WizardModel
+	viewer     *ViewerModel
+	tree       *TreeModel
+
+	// Taxonomy index for quick-jump, built on first use
+	jumpIndex *jumpIndex
+
+	// Background queue for bookmark and note writes
+	writes *writeQueue
+
+	// Scaled diagrams, shared by search previews, the subgroup and part
+	// screens, and prefetching
+	images *imageCache
+
+	// Background loading of the parts around the subgroup cursor
+	prefetch *prefetcher
+
+	// Session scratchpad of candidate parts, drawn as a bottom drawer
+	shortlist shortlist
+
+	// Diagram scaling chosen on part detail, kept for the session
+	imageFit image.Fit
+
+	// Short notice along the bottom of the screen
+	toast toast
+
+	// Diagrams kept with P, printed into the scrollback on quitting
+	kept []keptDiagram
+
+	// First key of a chord like g h, waiting for the second
+	chord chord
+
+	// Progress of a long-running bulk operation, drawn above the shortlist
+	bulk bulkProgress
+
+	// Whether the network is up, and the work waiting for it
+	connectivity connectivity
+
+	// The running labor timer, shown along the bottom
+	labor laborBar
 
 	// Terminal size
 	width  int
@@ -31,6 +91,12 @@ type Model struct {
 
 	// Image to clear on next render
 	pendingImageClear uint32
+
+	// OSC 52 clipboard request to write on next render
+	pendingClipboard string
+
+	// Ring the terminal bell on next render
+	pendingBell bool
 }
 
 func New(database *db.DB, dataPath string) *Model {
@@ -38,13 +104,21 @@ func New(database *db.DB, dataPath string) *Model {
 		db:       database,
 		dataPath: dataPath,
 		screen:   HomeScreen(),
+		writes:   newWriteQueue(webhook.FromEnv(dataPath)),
+		images:   newImageCache(),
+	}
+	m.prefetch = newPrefetcher(database, dataPath, m.images)
+	// Profiles replace the van set in .env, which seeds the first
+	if v, ok := vehicleFromEnv(); ok {
+		database.SeedVehicle(v)
 	}
-	m.home = NewHomeModel(database)
+	m.home = NewHomeModel(database, dataPath, m.images)
+	m.labor.load(database)
 	return m
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(checkOnline(0), checkCatalog(m.db, catalogCheckInterval))
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -52,14 +126,148 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		// Font size changes resize the window too
+		image.DetectCellSize()
+		return m, nil
+
+	case shortlistPromotedMsg:
+		m.shortlist.handlePromoted(msg)
+		return m, nil
+
+	case shortlistAddMsg:
+		m.shortlist.add(msg.parts...)
+		return m, nil
+
+	case shortlistItemsMsg:
+		m.shortlist.addItems(msg.items)
+		return m, nil
+
+	case userDataWrittenMsg:
+		// A failure shows on the screen that made the change, or once
+		// that's been left, as a toast
+		if msg.err != nil && !m.showsWrite(msg.partID, msg.kind) {
+			return m, m.toast.show(toastMsg{text: writeFailure(m.db, msg), isError: true})
+		}
+
+	case partMigratedMsg:
+		if msg.err != nil && !m.showsWrite(msg.from, writeMigrate) {
+			return m, m.toast.show(toastMsg{text: writeFailure(m.db, userDataWrittenMsg{partID: msg.from, kind: writeMigrate, err: msg.err}), isError: true})
+		}
+
+	case toastMsg:
+		if msg.clipboard != "" {
+			m.pendingClipboard = opener.OSC52(msg.clipboard)
+		}
+		return m, m.toast.show(msg)
+
+	case toastExpiredMsg:
+		m.toast.expire(msg)
+		return m, nil
+
+	case keepDiagramMsg:
+		return m, m.keep(msg.diagram)
+
+	case bulkStartMsg:
+		if msg.network && !netutil.Online() {
+			return m, m.connectivity.queue(msg)
+		}
+		return m, m.bulk.start(msg, filepath.Join(m.dataPath, "bulk.log"))
+
+	case bulkEventMsg:
+		cmd := m.bulk.handle(msg)
+		if !m.bulk.running && netutil.Online() {
+			if next, ok := m.connectivity.next(); ok {
+				cmd = tea.Batch(cmd, m.bulk.start(next, filepath.Join(m.dataPath, "bulk.log")))
+			}
+		}
+		return m, cmd
+
+	case laborChangedMsg:
+		m.labor.load(m.db)
+		if m.screen.Type == ScreenPartDetail && m.partDetail != nil {
+			m.partDetail.loadLabor()
+		}
+		return m, nil
+
+	case onlineMsg:
+		cmds := []tea.Cmd{checkOnline(onlineCheckInterval)}
+		switch {
+		case msg.changed && !msg.online:
+			cmds = append(cmds, func() tea.Msg {
+				return toastMsg{text: "Offline: network features will wait for the connection", isError: true}
+			})
+		case msg.changed && msg.online:
+			text := "Back online"
+			if !m.bulk.running {
+				if next, ok := m.connectivity.next(); ok {
+					text += "; running " + strings.ToLower(next.title)
+					cmds = append(cmds, m.bulk.start(next, filepath.Join(m.dataPath, "bulk.log")))
+				}
+			}
+			cmds = append(cmds, func() tea.Msg { return toastMsg{text: text} })
+		}
+		return m, tea.Batch(cmds...)
+
+	case catalogCheckMsg:
+		return m, m.catalogChecked(msg)
+
+	case bellMsg:
+		m.pendingBell = true
+		return m, nil
+
+	case chordTimeoutMsg:
+		if msg.seq != m.chord.seq {
+			return m, nil
+		}
+		if prefix, ok := m.chord.take(); ok {
+			return m, m.passKey(prefix)
+		}
 		return m, nil
 
 	case tea.KeyMsg:
+		// Inline editors receive every key, including esc to cancel
+		if m.editing() {
+			break
+		}
+
+		// The progress panel takes keys while it's shown
+		if m.bulk.visible {
+			return m, m.bulk.update(msg)
+		}
+
+		// The open shortlist drawer takes keys until it's closed
+		if m.shortlist.open {
+			cmd, nav := m.shortlist.update(msg, m.db, m.writes, m.dataPath)
+			if nav != nil {
+				return m.navigate(*nav)
+			}
+			return m, cmd
+		}
+
+		// Chords: the first key waits for the second, and a key that
+		// doesn't complete one sends the first through on its own
+		if prefix, ok := m.chord.take(); ok {
+			if name, ok := ui.Chord(prefix, msg); ok {
+				m.recordKey(name)
+				return m.runChord(name, prefix)
+			}
+			cmd := m.passKey(prefix)
+			_, next := m.Update(msg)
+			return m, tea.Batch(cmd, next)
+		}
+		if ui.IsChordPrefix(msg) && !m.typingText() && !m.chord.passing {
+			return m, m.chord.start(msg)
+		}
+
+		// Letters typed into a text input aren't counted, only keys like
+		// enter and esc
+		if !m.typingText() || (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace) {
+			m.recordKey(msg.String())
+		}
+
 		// Global keys
-		if ui.IsQuit(msg) {
-			// Clear all images before quitting by printing directly
-			fmt.Print(image.ClearAll())
-			return m, tea.Quit
+		if ui.IsQuit(msg) && !m.typingText() {
+			return m, m.quit()
 		}
 		if ui.IsBack(msg) {
 			return m.goBack()
@@ -67,6 +275,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if ui.IsSearch(msg) && m.screen.Type != ScreenSearch {
 			return m.navigate(SearchScreen(""))
 		}
+		if ui.IsJump(msg) && m.screen.Type != ScreenJump {
+			return m.navigate(JumpScreen())
+		}
+		if ui.IsConsole(msg) && m.screen.Type != ScreenConsole {
+			return m.navigate(ConsoleScreen())
+		}
+		if ui.IsPNC(msg) && m.screen.Type != ScreenPNC {
+			return m.navigate(PNCScreen())
+		}
+		if ui.IsShortlist(msg) && !m.typingText() {
+			if part := m.currentPart(); part != nil {
+				m.shortlist.toggle(part)
+			}
+			return m, nil
+		}
+		if ui.IsShortlistDrawer(msg) && !m.typingText() {
+			m.shortlist.open = true
+			return m, nil
+		}
+		if ui.IsHideSuperseded(msg) && !m.editing() {
+			return m, m.toggleSuperseded()
+		}
 	}
 
 	// Delegate to active screen
@@ -88,6 +318,38 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.bookmarks, cmd, nav = m.bookmarks.Update(msg)
 	case ScreenNotes:
 		m.notes, cmd, nav = m.notes.Update(msg)
+	case ScreenJump:
+		m.jump, cmd, nav = m.jump.Update(msg)
+	case ScreenCuration:
+		m.curation, cmd, nav = m.curation.Update(msg)
+	case ScreenConsole:
+		m.console, cmd, nav = m.console.Update(msg)
+	case ScreenPNC:
+		m.pnc, cmd, nav = m.pnc.Update(msg)
+	case ScreenJournal:
+		m.journal, cmd, nav = m.journal.Update(msg)
+	case ScreenScan:
+		m.scan, cmd, nav = m.scan.Update(msg)
+	case ScreenPaste:
+		m.paste, cmd, nav = m.paste.Update(msg)
+	case ScreenTemplates:
+		m.templates, cmd, nav = m.templates.Update(msg)
+	case ScreenAdded:
+		m.added, cmd, nav = m.added.Update(msg)
+	case ScreenHotspots:
+		m.hotspots, cmd, nav = m.hotspots.Update(msg)
+	case ScreenStats:
+		m.stats, cmd, nav = m.stats.Update(msg)
+	case ScreenVehicles:
+		m.vehicles, cmd, nav = m.vehicles.Update(msg)
+	case ScreenCart:
+		m.cart, cmd, nav = m.cart.Update(msg)
+	case ScreenWizard:
+		m.wizard, cmd, nav = m.wizard.Update(msg)
+	case ScreenViewer:
+		m.viewer, cmd, nav = m.viewer.Update(msg)
+	case ScreenTree:
+		m.tree, cmd, nav = m.tree.Update(msg)
 	}
 
 	if nav != nil {
@@ -108,30 +370,94 @@ func (m *Model) View() string {
 		}
 		m.pendingImageClear = 0
 	}
+	clearPrefix += m.pendingClipboard
+	m.pendingClipboard = ""
+	if m.pendingBell {
+		clearPrefix += notify.Bell
+		m.pendingBell = false
+	}
+
+	// The toast, progress panel and shortlist drawer take the bottom of
+	// the terminal
+	drawer := m.shortlist.height()
+	height := m.height - drawer - m.labor.height() - m.connectivity.height() - m.toast.height() - m.chord.height() - m.bulk.height()
 
 	var content string
 	switch m.screen.Type {
 	case ScreenHome:
-		content = m.home.View(m.width, m.height)
+		content = m.home.View(m.width, height)
 	case ScreenGroup:
-		content = m.group.View(m.width, m.height)
+		content = m.group.View(m.width, height)
 	case ScreenSubgroup:
-		content = m.subgroup.View(m.width, m.height)
+		content = m.subgroup.View(m.width, height)
 	case ScreenPartDetail:
-		content = m.partDetail.View(m.width, m.height)
+		content = m.partDetail.View(m.width, height)
 	case ScreenSearch:
-		content = m.search.View(m.width, m.height)
+		content = m.search.View(m.width, height)
 	case ScreenBookmarks:
-		content = m.bookmarks.View(m.width, m.height)
+		content = m.bookmarks.View(m.width, height)
 	case ScreenNotes:
-		content = m.notes.View(m.width, m.height)
+		content = m.notes.View(m.width, height)
+	case ScreenJump:
+		content = m.jump.View(m.width, height)
+	case ScreenCuration:
+		content = m.curation.View(m.width, height)
+	case ScreenConsole:
+		content = m.console.View(m.width, height)
+	case ScreenPNC:
+		content = m.pnc.View(m.width, height)
+	case ScreenJournal:
+		content = m.journal.View(m.width, height)
+	case ScreenScan:
+		content = m.scan.View(m.width, height)
+	case ScreenPaste:
+		content = m.paste.View(m.width, height)
+	case ScreenTemplates:
+		content = m.templates.View(m.width, height)
+	case ScreenAdded:
+		content = m.added.View(m.width, height)
+	case ScreenHotspots:
+		content = m.hotspots.View(m.width, height)
+	case ScreenStats:
+		content = m.stats.View(m.width, height)
+	case ScreenVehicles:
+		content = m.vehicles.View(m.width, height)
+	case ScreenCart:
+		content = m.cart.View(m.width, height)
+	case ScreenWizard:
+		content = m.wizard.View(m.width, height)
+	case ScreenViewer:
+		content = m.viewer.View(m.width, height)
+	case ScreenTree:
+		content = m.tree.View(m.width, height)
 	default:
 		content = "Unknown screen"
 	}
 
 	// Ensure output fills full terminal height to prevent artifacts
-	content = ui.FitHeight(content, m.height)
+	content = ui.FitHeight(content, height)
+	if m.labor.height() > 0 {
+		content += "\n" + m.labor.View(m.width)
+	}
+	if m.connectivity.height() > 0 {
+		content += "\n" + m.connectivity.View(m.width)
+	}
+	if m.toast.height() > 0 {
+		content += "\n" + m.toast.View(m.width)
+	}
+	if m.chord.height() > 0 {
+		content += "\n" + m.chord.View(m.width)
+	}
+	if m.bulk.height() > 0 {
+		content += "\n" + m.bulk.View(m.width)
+	}
+	if drawer > 0 {
+		content += "\n" + m.shortlist.View(m.width)
+	}
 
+	if ui.ASCII {
+		content = ui.ToASCII(content)
+	}
 	return clearPrefix + content
 }
 
@@ -142,36 +468,40 @@ func (m *Model) navigate(to Screen) (*Model, tea.Cmd) {
 	}
 
 	// Push current screen to history
-	m.history = append(m.history, m.screen)
+	m.history = append(m.history, visit{screen: m.screen, model: m.currentModel()})
 	m.screen = to
+	m.recordUsage(db.UsageScreen, to.Type.String())
 
 	// Initialize new screen model
-	switch to.Type {
-	case ScreenHome:
-		m.home = NewHomeModel(m.db)
-	case ScreenGroup:
-		m.group = NewGroupModel(m.db, to.GroupID)
-	case ScreenSubgroup:
-		m.subgroup = NewSubgroupModel(m.db, to.SubgroupID, m.dataPath)
-	case ScreenPartDetail:
-		m.partDetail = NewPartDetailModel(m.db, to.PartID, m.dataPath)
-	case ScreenSearch:
-		m.search = NewSearchModel(m.db, to.Query)
-	case ScreenBookmarks:
-		m.bookmarks = NewBookmarksModel(m.db)
-	case ScreenNotes:
-		m.notes = NewNotesModel(m.db)
+	m.initScreen()
+	if to.Type == ScreenPartDetail {
+		// For the search launchpad
+		m.db.AddPartView(to.PartID)
 	}
 
-	// Clear screen on navigation to prevent artifacts
-	return m, tea.ClearScreen
+	return m, m.screenChanged()
+}
+
+// quitWait is the longest quitting waits for the writes still queued to be
+// saved, and again for the webhook to deliver them, so a locked database or
+// an unreachable webhook can't keep the program from exiting
+const quitWait = 2 * time.Second
+
+// quit clears the images and ends the program once the writes still queued,
+// such as a bookmark toggled just before, are saved and published
+func (m *Model) quit() tea.Cmd {
+	// Printed directly, as the program won't draw again
+	fmt.Print(image.ClearAll())
+	writes := m.writes
+	return func() tea.Msg {
+		writes.flush(quitWait)
+		return tea.Quit()
+	}
 }
 
 func (m *Model) goBack() (*Model, tea.Cmd) {
 	if len(m.history) == 0 {
-		// Clear all images and quit
-		fmt.Print(image.ClearAll())
-		return m, tea.Quit
+		return m, m.quit()
 	}
 
 	// Mark current image for clearing on next render
@@ -180,34 +510,248 @@ func (m *Model) goBack() (*Model, tea.Cmd) {
 	}
 
 	// Pop from history
-	m.screen = m.history[len(m.history)-1]
+	prev := m.history[len(m.history)-1]
 	m.history = m.history[:len(m.history)-1]
+	m.screen = prev.screen
+	if m.resume(prev.model) {
+		return m, m.screenChanged()
+	}
 
 	// Re-initialize screen model
+	m.initScreen()
+
+	m.keepPosition(prev.model)
+
+	return m, m.screenChanged()
+}
+
+// toggleSuperseded hides or shows parts whose replacement is in the catalog
+// in the part lists, refiltering the one on screen. Other screens pick the
+// setting up when they're next built.
+func (m *Model) toggleSuperseded() tea.Cmd {
+	hide := !m.db.HidingSuperseded()
+	if err := m.db.SetHidingSuperseded(hide); err != nil {
+		return func() tea.Msg {
+			return toastMsg{text: fmt.Sprintf("Setting not saved: %v", err), isError: true}
+		}
+	}
+	switch m.screen.Type {
+	case ScreenSubgroup:
+		m.subgroup.reloadSuperseded()
+	case ScreenSearch:
+		m.search.reloadSuperseded()
+	}
+	text := "Superseded parts shown"
+	if hide {
+		text = "Superseded parts hidden"
+	}
+	return func() tea.Msg {
+		return toastMsg{text: text}
+	}
+}
+
+// initScreen builds the model for the current screen afresh
+func (m *Model) initScreen() {
 	switch m.screen.Type {
 	case ScreenHome:
-		m.home = NewHomeModel(m.db)
+		m.home = NewHomeModel(m.db, m.dataPath, m.images)
 	case ScreenGroup:
-		m.group = NewGroupModel(m.db, m.screen.GroupID)
+		m.group = NewGroupModel(m.db, m.screen.GroupID, m.dataPath)
 	case ScreenSubgroup:
-		m.subgroup = NewSubgroupModel(m.db, m.screen.SubgroupID, m.dataPath)
+		if m.screen.DiagramID != "" {
+			m.subgroup = NewDiagramModel(m.db, m.screen.DiagramID, m.screen.PartID, m.prefetch)
+		} else {
+			m.subgroup = NewSubgroupModel(m.db, m.screen.SubgroupID, m.prefetch)
+		}
 	case ScreenPartDetail:
-		m.partDetail = NewPartDetailModel(m.db, m.screen.PartID, m.dataPath)
+		m.partDetail = NewPartDetailModel(m.db, m.screen.PartID, m.dataPath, m.writes, &m.imageFit, m.prefetch)
 	case ScreenSearch:
-		m.search = NewSearchModel(m.db, m.screen.Query)
+		m.search = NewSearchModel(m.db, m.screen.Query, m.dataPath, m.images)
 	case ScreenBookmarks:
-		m.bookmarks = NewBookmarksModel(m.db)
+		m.bookmarks = NewBookmarksModel(m.db, m.writes)
 	case ScreenNotes:
-		m.notes = NewNotesModel(m.db)
+		m.notes = NewNotesModel(m.db, m.dataPath)
+	case ScreenJump:
+		if m.jumpIndex == nil {
+			m.jumpIndex = newJumpIndex(m.db)
+		}
+		m.jump = NewJumpModel(m.jumpIndex, m.db)
+	case ScreenCuration:
+		m.curation = NewCurationModel(m.db)
+	case ScreenConsole:
+		m.console = NewConsoleModel(m.db)
+	case ScreenPNC:
+		m.pnc = NewPNCModel(m.db)
+	case ScreenJournal:
+		m.journal = NewJournalModel(m.db, m.screen.Day)
+	case ScreenScan:
+		m.scan = NewScanModel(m.db, m.writes)
+	case ScreenPaste:
+		m.paste = NewPasteModel(m.db)
+	case ScreenTemplates:
+		m.templates = NewTemplatesModel(m.db, m.dataPath)
+	case ScreenAdded:
+		m.added = NewAddedModel(m.db, addedPeriods[0])
+	case ScreenHotspots:
+		m.hotspots = NewHotspotsModel(m.db, m.screen.DiagramID, m.prefetch)
+	case ScreenStats:
+		m.stats = NewStatsModel(m.db, m.images)
+	case ScreenVehicles:
+		m.vehicles = NewVehiclesModel(m.db)
+	case ScreenCart:
+		m.cart = NewCartModel(m.db, m.writes, m.dataPath)
+	case ScreenWizard:
+		m.wizard = NewWizardModel(m.db, m.dataPath, m.images)
+	case ScreenViewer:
+		m.viewer = NewViewerModel(m.db, m.screen.DiagramID, m.dataPath)
+	case ScreenTree:
+		m.tree = NewTreeModel(m.db)
 	}
+}
+
+// screenChanged finishes a move to another screen. Prefetched part data is
+// only good for the subgroup screen it was loaded from, having been taken
+// by now if that screen opened one of its parts.
+func (m *Model) screenChanged() tea.Cmd {
+	m.prefetch.cancel()
 
 	// Clear screen on navigation to prevent artifacts
-	return m, tea.ClearScreen
+	cmd := tea.ClearScreen
+	switch m.screen.Type {
+	case ScreenSubgroup:
+		return tea.Batch(cmd, m.subgroup.prefetchAround())
+	case ScreenPartDetail:
+		return tea.Batch(cmd, m.partDetail.lookupPrices(false))
+	}
+	return cmd
+}
+
+// showsWrite reports whether the current screen shows the outcome of a
+// write to a part's user data, as the screen that made it does while it's
+// open. Part 0 stands for writes to many parts at once.
+func (m *Model) showsWrite(partID int, kind writeKind) bool {
+	switch m.screen.Type {
+	case ScreenPartDetail:
+		return m.partDetail != nil && m.partDetail.partID == partID && kind != writeBookmarkQty
+	case ScreenBookmarks:
+		return kind == writeBookmarkQty
+	case ScreenCart:
+		return kind == writeCart
+	case ScreenScan:
+		return kind == writeBookmark && partID == 0
+	}
+	return false
+}
+
+// editing reports whether an inline editor on the current screen is open
+func (m *Model) editing() bool {
+	switch m.screen.Type {
+	case ScreenPartDetail:
+		return m.partDetail != nil && (m.partDetail.editingNote || m.partDetail.editor.active || m.partDetail.attacher.active || m.partDetail.purchaser.active || m.partDetail.dimensioner.active || m.partDetail.originator.active || m.partDetail.carter.active || m.partDetail.confirmOpenAll || m.partDetail.openWith.active)
+	case ScreenCuration:
+		return m.curation != nil && m.curation.Editing()
+	case ScreenVehicles:
+		return m.vehicles != nil && m.vehicles.Editing()
+	case ScreenCart:
+		return m.cart != nil && m.cart.Editing()
+	case ScreenStats:
+		// The reset prompt takes the next key, esc included
+		return m.stats != nil && m.stats.confirming
+	case ScreenSubgroup:
+		// The column chooser and callout mode take esc to close
+		return m.subgroup != nil && (m.subgroup.columns.choosing || m.subgroup.callouts)
+	case ScreenSearch:
+		return m.search != nil && m.search.columns.choosing
+	case ScreenBookmarks:
+		return m.bookmarks != nil && m.bookmarks.columns.choosing
+	case ScreenNotes:
+		return m.notes != nil && m.notes.Filtering()
+	case ScreenConsole:
+		// A running query takes esc to cancel it
+		return m.console != nil && m.console.Running()
+	case ScreenTree:
+		return m.tree != nil && m.tree.Filtering()
+	case ScreenPaste:
+		// The preview takes esc to return to the text
+		return m.paste != nil && m.paste.preview
+	case ScreenTemplates:
+		// A checked template takes esc to return to the list
+		return m.templates != nil && m.templates.template != nil
+	}
+	return false
+}
+
+// typingText reports whether the current screen has a focused text input,
+// in which case printable keys like q must reach the input instead
+func (m *Model) typingText() bool {
+	switch m.screen.Type {
+	case ScreenSearch, ScreenJump, ScreenConsole, ScreenPNC, ScreenScan, ScreenPaste, ScreenTree:
+		return true
+	}
+	return m.editing()
+}
+
+// currentPart returns the part the current screen shows or has selected,
+// if any
+func (m *Model) currentPart() *db.PartWithDiagram {
+	var selected string
+	switch m.screen.Type {
+	case ScreenPartDetail:
+		if m.partDetail != nil {
+			return m.partDetail.part
+		}
+	case ScreenSubgroup:
+		if row := m.subgroup.table.Selected(); row != nil {
+			selected = row.ID
+		}
+	case ScreenBookmarks:
+		if row := m.bookmarks.table.Selected(); row != nil {
+			selected = row.ID
+		}
+	case ScreenNotes:
+		if row := m.notes.table.Selected(); row != nil {
+			selected = row.ID
+		}
+	case ScreenAdded:
+		if row := m.added.table.Selected(); row != nil {
+			selected = row.ID
+		}
+	case ScreenCart:
+		if row := m.cart.table.Selected(); row != nil {
+			selected = row.ID
+		}
+	case ScreenWizard:
+		// Candidates are keyed by their place in the list
+		if p := m.wizard.selected(); p != nil {
+			return &p.PartWithDiagram
+		}
+	case ScreenTree:
+		return m.tree.selectedPart()
+	case ScreenJournal:
+		// Only a day's job lists parts; the days are keyed by date
+		if item := m.journal.menu.Selected(); m.journal.day != "" && item != nil {
+			selected = item.ID
+		}
+	}
+	if selected == "" {
+		return nil
+	}
+	// These lists use part IDs as item IDs
+	var partID int
+	if _, err := fmt.Sscanf(selected, "%d", &partID); err != nil {
+		return nil
+	}
+	part, _ := m.db.GetPart(partID)
+	return part
 }
 
 // getCurrentImageID returns the image ID from the current screen, if any
 func (m *Model) getCurrentImageID() uint32 {
 	switch m.screen.Type {
+	case ScreenHome:
+		if m.home != nil {
+			return m.home.ImageID()
+		}
 	case ScreenSubgroup:
 		if m.subgroup != nil {
 			return m.subgroup.ImageID()
@@ -216,6 +760,22 @@ func (m *Model) getCurrentImageID() uint32 {
 		if m.partDetail != nil {
 			return m.partDetail.ImageID()
 		}
+	case ScreenSearch:
+		if m.search != nil {
+			return m.search.ImageID()
+		}
+	case ScreenHotspots:
+		if m.hotspots != nil {
+			return m.hotspots.ImageID()
+		}
+	case ScreenWizard:
+		if m.wizard != nil {
+			return m.wizard.ImageID()
+		}
+	case ScreenViewer:
+		if m.viewer != nil {
+			return m.viewer.ImageID()
+		}
 	}
 	return 0
 }
@@ -2,64 +2,202 @@ package model
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
-	"delica-tui/db"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/export"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+var noteColumns = []ui.Column{
+	{Title: "PART", Width: 14},
+	{Title: "PNC", Width: 8},
+	{Title: "NOTE"},
+}
+
+// notesPageSize is how many notes the notes screen lists at a time
+const notesPageSize = 50
+
+// NotesModel lists notes a page at a time, optionally only those matching
+// the filter box.
 type NotesModel struct {
-	db    *db.DB
-	notes []db.NoteResult
-	menu  *ui.Menu
+	db       *db.DB
+	dataPath string
+	notes    []db.NoteResult // the page shown
+	table    *ui.Table
+
+	filter    textinput.Model
+	filtering bool // the filter box has focus
+	page      int
+	matches   int // notes the filter keeps, or all of them
+	total     int // all notes
 }
 
-func NewNotesModel(database *db.DB) *NotesModel {
-	notes, _ := database.GetNotes()
+func NewNotesModel(database *db.DB, dataPath string) *NotesModel {
+	ti := textinput.New()
+	ti.Placeholder = "text, part number or description"
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.Prompt = "filter: "
 
-	var items []ui.MenuItem
-	for _, n := range notes {
-		label := n.PartNumber
-		if n.PNC != nil {
-			label = fmt.Sprintf("[%s] %s", *n.PNC, n.PartNumber)
-		}
+	m := &NotesModel{
+		db:       database,
+		dataPath: dataPath,
+		filter:   ti,
+	}
+	m.load()
+	return m
+}
 
-		// Truncate note content for hint display
-		hint := n.Content
-		if len(hint) > 60 {
-			hint = hint[:57] + "..."
+// load reads the current page for the filter, stepping back a page when
+// it's past the last one, as after notes are deleted
+func (m *NotesModel) load() {
+	m.total, _ = m.db.GetNoteCount()
+	query := strings.TrimSpace(m.filter.Value())
+	for {
+		if query == "" {
+			m.notes, _ = m.db.GetNotes(notesPageSize, m.page*notesPageSize)
+			m.matches = m.total
+		} else {
+			m.notes, m.matches, _ = m.db.SearchNotes(query, notesPageSize, m.page*notesPageSize)
+		}
+		if len(m.notes) > 0 || m.page == 0 {
+			break
 		}
-		// Replace newlines with spaces for single-line display
-		hint = strings.ReplaceAll(hint, "\n", " ")
+		m.page = (m.matches - 1) / notesPageSize
+	}
 
-		items = append(items, ui.MenuItem{
+	rows := make([]ui.TableRow, len(m.notes))
+	for i, n := range m.notes {
+		// Replace newlines with spaces for single-line display; the table
+		// truncates to the column
+		rows[i] = ui.TableRow{
 			ID:    fmt.Sprintf("%d", n.PartID),
-			Label: label,
-			Hint:  hint,
-		})
+			Cells: []string{n.PartNumber, deref(n.PNC), strings.ReplaceAll(n.Content, "\n", " ")},
+		}
+	}
+	prev := m.table
+	m.table = ui.NewTable(noteColumns, rows)
+	if prev != nil {
+		m.table.SortBy(prev.SortColumn, prev.SortDesc)
+	}
+	m.table.Typing = m.filtering
+}
+
+func (m *NotesModel) pages() int {
+	return max((m.matches+notesPageSize-1)/notesPageSize, 1)
+}
+
+// Filtering reports whether the filter box has focus, taking every key
+func (m *NotesModel) Filtering() bool {
+	return m.filtering
+}
+
+// selected returns the note under the cursor, if any
+func (m *NotesModel) selected() *db.NoteResult {
+	row := m.table.Selected()
+	if row == nil {
+		return nil
+	}
+	for i := range m.notes {
+		if fmt.Sprint(m.notes[i].PartID) == row.ID {
+			return &m.notes[i]
+		}
 	}
+	return nil
+}
+
+// exportNote writes the note with its part's details to data/exports/notes
+// and copies the same text to the clipboard, for pasting into a forum post
+func (m *NotesModel) exportNote(n db.NoteResult) tea.Cmd {
+	outDir := filepath.Join(m.dataPath, "exports", "notes")
+	vehicle := activeVehicle(m.db)
+	return func() tea.Msg {
+		note := export.Note{
+			PartNumber:  n.PartNumber,
+			PNC:         deref(n.PNC),
+			Description: deref(n.Description),
+			Group:       n.GroupName,
+			Subgroup:    deref(n.SubgroupName),
+			Vehicle:     vehicle.Name,
+			FrameNo:     vehicle.FrameNo,
+			Updated:     locale.DateString(n.UpdatedAt),
+			Content:     n.Content,
+		}
+		path, err := export.WriteNote(outDir, note)
+		if err != nil {
+			return toastMsg{text: fmt.Sprintf("Export failed: %v", err), isError: true}
+		}
+		if opener.Copy(note.Text()) != nil {
+			return toastMsg{text: "Saved " + path + " and asked the terminal to copy it", clipboard: note.Text()}
+		}
+		return toastMsg{text: "Copied the note and saved it to " + path}
+	}
+}
 
-	return &NotesModel{
-		db:    database,
-		notes: notes,
-		menu:  ui.NewMenu(items),
+// updateFilter handles a key while the filter box has focus: arrows move
+// through the matches, enter keeps the filter and esc clears it
+func (m *NotesModel) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case ui.IsBack(msg):
+		m.filter.SetValue("")
+		fallthrough
+	case ui.IsEnter(msg):
+		m.filtering = false
+		m.filter.Blur()
+		m.load()
+		return nil
+	}
+	if m.table.HandleKey(msg) {
+		return nil
 	}
+	before := m.filter.Value()
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != before {
+		m.page = 0
+		m.load()
+	}
+	return cmd
 }
 
 func (m *NotesModel) Update(msg tea.Msg) (*NotesModel, tea.Cmd, *Screen) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if ui.IsUp(msg) {
-			m.menu.Up()
+		if m.filtering {
+			return m, m.updateFilter(msg), nil
+		}
+		switch {
+		case ui.IsFilter(msg):
+			m.filtering = true
+			m.table.Typing = true
+			return m, m.filter.Focus(), nil
+		case ui.IsNextPage(msg):
+			if m.page+1 < m.pages() {
+				m.page++
+				m.load()
+			}
+			return m, nil, nil
+		case ui.IsPrevPage(msg):
+			if m.page > 0 {
+				m.page--
+				m.load()
+			}
+			return m, nil, nil
 		}
-		if ui.IsDown(msg) {
-			m.menu.Down()
+		m.table.HandleKey(msg)
+		if n := m.selected(); ui.IsExport(msg) && n != nil {
+			return m, m.exportNote(*n), nil
 		}
 		if ui.IsEnter(msg) {
-			if item := m.menu.Selected(); item != nil {
+			if item := m.table.Selected(); item != nil {
 				var partID int
 				fmt.Sscanf(item.ID, "%d", &partID)
 				s := PartDetailScreen(partID, false)
@@ -93,7 +231,7 @@ func (m *NotesModel) View(width, height int) string {
 	}
 
 	leftContent := m.renderLeftPane(splitHeight)
-	rightContent := m.renderRightPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
 
 	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
 
@@ -105,7 +243,7 @@ func (m *NotesModel) renderLeftPane(height int) string {
 
 	lines = append(lines, ui.HeaderStyle.Render("NOTES"))
 	lines = append(lines, "")
-	lines = append(lines, fmt.Sprintf("%d parts with notes", len(m.notes)))
+	lines = append(lines, fmt.Sprintf("%d parts with notes", m.total))
 	lines = append(lines, "")
 	lines = append(lines, ui.DimStyle.Render("Press n on any part"))
 	lines = append(lines, ui.DimStyle.Render("to add a note"))
@@ -118,44 +256,75 @@ func (m *NotesModel) renderLeftPane(height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *NotesModel) renderRightPane(height int) string {
+func (m *NotesModel) renderRightPane(width, height int) string {
 	var b strings.Builder
 
-	// Header
+	// Header, with which notes of how many are shown
 	b.WriteString(ui.HeaderStyle.Render("PARTS WITH NOTES"))
+	if len(m.notes) > 0 {
+		first := m.page*notesPageSize + 1
+		count := fmt.Sprintf("  %d-%d of %d", first, first+len(m.notes)-1, m.matches)
+		if m.matches != m.total {
+			count += fmt.Sprintf(" matching, %d in all", m.total)
+		}
+		b.WriteString(ui.CountStyle.Render(count))
+	}
 	b.WriteString("\n")
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
 
-	// Adjust menu visible items based on available height (max 15)
-	menuHeight := height - 5
-	if menuHeight < 5 {
-		menuHeight = 5
+	// The filter box, while it has focus or holds a filter
+	filterLines := 0
+	if m.filtering || m.filter.Value() != "" {
+		b.WriteString("\n")
+		if m.filtering {
+			b.WriteString(m.filter.View())
+		} else {
+			b.WriteString(ui.DimStyle.Render(m.filter.Prompt + m.filter.Value()))
+		}
+		filterLines = 1
 	}
-	if menuHeight > 15 {
-		menuHeight = 15
+
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles)
+	tableHeight := height - 4 - filterLines
+	if tableHeight < 6 {
+		tableHeight = 6
 	}
-	m.menu.MaxVisibleItems = menuHeight
+	if tableHeight > 16 {
+		tableHeight = 16
+	}
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
 
-	// One less blank line if menu scrolls (to account for scroll indicator)
-	if len(m.menu.Items) > m.menu.MaxVisibleItems {
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
 		b.WriteString("\n")
 	} else {
 		b.WriteString("\n\n")
 	}
 
 	// Menu
-	if len(m.notes) == 0 {
+	if len(m.notes) == 0 && m.total > 0 {
+		b.WriteString(ui.DimStyle.Render("No notes match"))
+	} else if len(m.notes) == 0 {
 		b.WriteString(ui.DimStyle.Render("No notes yet"))
 		b.WriteString("\n\n")
 		b.WriteString(ui.DimStyle.Render("Navigate to a part and"))
 		b.WriteString("\n")
 		b.WriteString(ui.DimStyle.Render("press 'n' to add a note"))
 	} else {
-		b.WriteString(m.menu.View())
+		b.WriteString(m.table.View())
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select"))
+	switch {
+	case m.filtering:
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter keep filter   esc clear filter"))
+	case m.pages() > 1:
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("↑↓ navigate   enter select   f filter   [ ] page %d/%d   x export   ", m.page+1, m.pages()) + m.table.SortHint()))
+	default:
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select   f filter   x export   " + m.table.SortHint()))
+	}
 
 	return b.String()
 }
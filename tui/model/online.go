@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onlineCheckInterval is how often connectivity is checked
+const onlineCheckInterval = 30 * time.Second
+
+// onlineMsg reports a connectivity check. changed is true when the network
+// came or went since the last one.
+type onlineMsg struct {
+	online  bool
+	changed bool
+}
+
+// checkOnline checks connectivity in the background, after delay
+func checkOnline(delay time.Duration) tea.Cmd {
+	check := func() tea.Msg {
+		online, changed := netutil.CheckOnline(context.Background())
+		return onlineMsg{online: online, changed: changed}
+	}
+	if delay == 0 {
+		return check
+	}
+	return tea.Tick(delay, func(time.Time) tea.Msg { return check() })
+}
+
+// connectivity tracks whether the network is up and holds the network work
+// asked for while it wasn't, to start once it's back.
+type connectivity struct {
+	queued []bulkStartMsg
+}
+
+// queue holds msg for later, replacing an earlier request of the same
+// operation, and says so
+func (c *connectivity) queue(msg bulkStartMsg) tea.Cmd {
+	for i, q := range c.queued {
+		if q.title == msg.title {
+			c.queued = append(c.queued[:i], c.queued[i+1:]...)
+			break
+		}
+	}
+	c.queued = append(c.queued, msg)
+	text := fmt.Sprintf("Offline: %s will run when the connection is back", strings.ToLower(msg.title))
+	return func() tea.Msg { return toastMsg{text: text} }
+}
+
+// next takes the oldest queued operation, if any
+func (c *connectivity) next() (bulkStartMsg, bool) {
+	if len(c.queued) == 0 {
+		return bulkStartMsg{}, false
+	}
+	msg := c.queued[0]
+	c.queued = c.queued[1:]
+	return msg, true
+}
+
+// height is how many lines the offline bar takes below the screen
+func (c *connectivity) height() int {
+	if netutil.Online() {
+		return 0
+	}
+	return 1
+}
+
+func (c *connectivity) View(width int) string {
+	text := "● Offline: link checks and webhooks wait for the connection"
+	if len(c.queued) > 0 {
+		titles := make([]string, len(c.queued))
+		for i, q := range c.queued {
+			titles[i] = strings.ToLower(q.title)
+		}
+		text += " · queued: " + strings.Join(titles, ", ")
+	}
+	return "  " + ui.ErrorStyle.MaxWidth(max(width-4, 0)).Render(text)
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openWithPopup lists a part's links, EPC and suppliers, each with a letter
+// that opens it. Links used to share the detail screen's cursor with its
+// subgroups and prices, which got hard to follow for parts listed in many
+// subgroups.
+type openWithPopup struct {
+	active bool
+	links  []partLink
+	keys   []string
+	cursor int
+}
+
+func (p *openWithPopup) open(links []partLink) {
+	p.active = true
+	p.links = links
+	p.keys = linkKeys(links)
+	p.cursor = 0
+}
+
+// linkKeys gives each link the first letter of its label that no earlier
+// link took, or else a digit
+func linkKeys(links []partLink) []string {
+	keys := make([]string, len(links))
+	taken := make(map[string]bool)
+	digit := 1
+	for i, link := range links {
+		for _, r := range strings.ToLower(link.label) {
+			if k := string(r); unicode.IsLetter(r) && !taken[k] {
+				keys[i] = k
+				break
+			}
+		}
+		if keys[i] == "" && digit <= 9 {
+			keys[i] = string(rune('0' + digit))
+			digit++
+		}
+		taken[keys[i]] = true
+	}
+	return keys
+}
+
+// update handles a key while the popup is open, returning the command that
+// opens the link chosen, if any. Any choice closes the popup.
+func (p *openWithPopup) update(msg tea.KeyMsg) tea.Cmd {
+	if cursor, ok := ui.MoveCursor(msg, p.cursor, len(p.links), len(p.links), true); ok {
+		p.cursor = cursor
+		return nil
+	}
+	switch {
+	case ui.IsBack(msg):
+		p.active = false
+		return nil
+	case ui.IsEnter(msg):
+		p.active = false
+		if p.cursor < len(p.links) {
+			return openCmd(p.links[p.cursor].url)
+		}
+		return nil
+	}
+	for i, k := range p.keys {
+		if k != "" && msg.String() == k {
+			p.active = false
+			return openCmd(p.links[i].url)
+		}
+	}
+	return nil
+}
+
+// view draws the popup in a box width cells wide
+func (p *openWithPopup) view(width int) string {
+	var lines []string
+	lines = append(lines, ui.HeaderStyle.Render("OPEN WITH"))
+	lines = append(lines, "")
+
+	urlWidth := max(width-26, 10)
+	for i, link := range p.links {
+		key := "   "
+		if p.keys[i] != "" {
+			key = "[" + p.keys[i] + "]"
+		}
+		label := lipgloss.NewStyle().Width(14).Render(link.label)
+		url := truncateText(link.url, urlWidth)
+		if i == p.cursor {
+			lines = append(lines, ui.SelectedStyle.Render("> "+key+" ")+ui.SelectedLabelStyle.Render(label)+ui.LinkStyle.Render(url))
+		} else {
+			lines = append(lines, "  "+ui.DimStyle.Render(key)+" "+label+ui.DimStyle.Render(url))
+		}
+	}
+
+	return ui.BoxStyle.Width(max(width-4, 20)).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// originPrompt edits a part number's origin as one line, e.g. "oem, Japan".
+// Clearing the input forgets it.
+type originPrompt struct {
+	active bool
+	input  textinput.Model
+	err    string
+}
+
+func newOriginPrompt() originPrompt {
+	ti := textinput.New()
+	ti.Placeholder = "genuine, oem or aftermarket, then the country"
+	ti.CharLimit = 60
+	ti.Width = 50
+	ti.Prompt = ""
+	return originPrompt{input: ti}
+}
+
+// open starts from the recorded origin
+func (p *originPrompt) open(current *db.Origin) tea.Cmd {
+	p.active = true
+	p.err = ""
+	p.input.SetValue(db.FormatOrigin(current))
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *originPrompt) close() {
+	p.active = false
+	p.input.Blur()
+}
+
+// update handles a message while the prompt is open. saved is true once the
+// origin has been recorded, when the caller should reload it.
+func (p *originPrompt) update(msg tea.Msg, database *db.DB, partNumber string) (cmd tea.Cmd, saved bool) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			p.close()
+			return nil, false
+		case ui.IsEnter(msg):
+			origin, err := db.ParseOrigin(p.input.Value())
+			if err == nil {
+				err = database.SetOrigin(partNumber, origin)
+			}
+			if err != nil {
+				p.err = err.Error()
+				return nil, false
+			}
+			p.close()
+			return nil, true
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	return cmd, false
+}
+
+// badged puts a part number's origin badge, if one is recorded, before its
+// description for a list cell, where narrow columns can't cut it off
+func badged(description string, origins map[string]db.Origin, partNumber string) string {
+	origin, ok := origins[strings.ToUpper(partNumber)]
+	if !ok {
+		return description
+	}
+	return strings.TrimSpace(origin.Badge() + " " + description)
+}
+
+// nextOriginFilter cycles a list's origin filter from everything through
+// each source and back
+func nextOriginFilter(current string) string {
+	for i, source := range db.OriginSources {
+		if source == current {
+			if i+1 < len(db.OriginSources) {
+				return db.OriginSources[i+1]
+			}
+			return ""
+		}
+	}
+	return db.OriginSources[0]
+}
@@ -3,20 +3,50 @@ package model
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
 
-	"delica-tui/db"
-	"delica-tui/image"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/barcode"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/supplier"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// partDetailPage is how far page keys move the cursor on the detail screen,
+// whose sections don't scroll
+const partDetailPage = 10
+
+// Replacement numbers this close to the part number get a character diff
+const maxHighlightedEdits = 4
+
+// Cells the fit-pane diagram is scaled into
+const (
+	diagramWidthCells  = 92
+	diagramHeightCells = 46
+)
+
+// Each notch of the mouse wheel zooms the diagram by zoomStep, up to
+// maxZoom pixels per original pixel
+const (
+	zoomStep = 1.25
+	maxZoom  = 2.0
+)
+
+// The diagram's top-left cell on screen: past the left margin, and below the
+// top margin and the diagram ID
+const (
+	diagramLeft = 2
+	diagramTop  = 3
+)
+
 type PartDetailModel struct {
 	db         *db.DB
 	partID     int
@@ -27,32 +57,119 @@ type PartDetailModel struct {
 	isBookmark bool
 	img        *image.KittyImage
 	imgError   string
-	subgroups  []db.SubgroupWithGroup
-	links      []string // URLs for external links
-	cursor     int      // unified cursor for subgroups + links
+	imgPath    string
+
+	// The subgroup's own diagram, when the part is drawn on another, and
+	// whether it's shown in place of the part's
+	subgroupDiagram     *db.Diagram
+	subgroupImgPath     string
+	showSubgroupDiagram bool
+	subgroups           []db.SubgroupWithGroup
+	prices              []db.Price
+	costs               supplier.Costs // landed cost settings, read with the prices
+	costsErr            error
+	lookingUp           []string // suppliers whose prices are being looked up
+	links               []partLink
+	cursor              int // unified cursor for attachments + subgroups + prices
+	openWith            openWithPopup
+
+	showBarcode bool
+
+	// Diagram scaling. fit is shared with the session so the chosen mode
+	// carries over to the next part; the rest describes the loaded image.
+	fit          *image.Fit
+	imgFit       image.Fit
+	imgWidth     int  // pane width img was scaled for, in cells
+	panX, panY   int  // cells scrolled in the zoomed modes
+	viewW, viewH int  // visible image area, set by View
+	minimap      bool // the image overflows it, so a minimap goes below
+	clearImageID uint32
+
+	// Which pane the keys go to: the details, or the diagram, where the
+	// arrow keys pan a zoomed image
+	focus ui.Pane
+
+	// Mouse wheel zoom, in pixels per original pixel, overriding fit while
+	// set. fitScale is the scale of fit's image, where zooming out stops.
+	zoom     float64
+	imgZoom  float64
+	fitScale float64
 
 	// Note editing
 	note        *string
 	editingNote bool
 	noteInput   textarea.Model
-}
 
-func NewPartDetailModel(database *db.DB, partID int, dataPath string) *PartDetailModel {
-	part, _ := database.GetPart(partID)
-	var diagram *db.Diagram
-	var group *db.Group
-	var subgroup *db.Subgroup
+	// Optimistic user-data writes. Each change takes a new seq; if the
+	// latest write of a kind fails, the value last saved is shown again.
+	writes      *writeQueue
+	bookmarkSeq int
+	noteSeq     int
+	purchaseSeq int
+	writeError  string
 
-	if part != nil {
-		diagram, _ = database.GetDiagram(part.DiagramID)
-		group, _ = database.GetGroup(part.GroupID)
-		if part.SubgroupID != nil {
-			subgroup, _ = database.GetSubgroup(*part.SubgroupID)
-		}
-	}
+	// Local overrides of catalog fields
+	overridden map[string]bool
+	editor     fieldEditor
+
+	// External files listed under the note
+	attachments []attachment
+	attacher    attachmentPrompt
+	attachError string
+
+	// When and for how much the part was bought, if recorded
+	purchase  *db.Purchase
+	qty       *db.PartQty // catalog quantity against bookmarked and bought
+	purchaser purchasePrompt
+	carter    cartPrompt
+
+	// Time worked on the part, with the timer's if it's running on it
+	labor  time.Duration
+	timing bool
+
+	// Dimensions recorded for the part number, and their editor
+	dims        []db.Dimension
+	dimensioner dimensionsPrompt
+
+	// Attributes parsed from the spec, and the active van's to match them
+	// against
+	attrs   []db.Attribute
+	vehicle db.Vehicle
+
+	// Where the part number comes from, and its editor
+	origin     *db.Origin
+	originator originPrompt
+
+	// Asking before opening every link at once
+	confirmOpenAll bool
+
+	// Sections folded to one line, the same for every part
+	collapsed map[string]bool
+
+	// Fit-pane diagrams shared with the subgroup screen and prefetching
+	diagrams *imageCache
+
+	// Catalog part superseding this one, if it's listed
+	replacementID  int
+	hasReplacement bool
+
+	// Other-side counterpart of an LH or RH part, if it's listed
+	counterpart *db.PartWithDiagram
+
+	// Knowledge base entries for the part's PNC or subgroup
+	kb []db.KBEntry
+
+	// Fitment notes and aftermarket xrefs shared for its part number
+	compat []db.CompatNote
+}
+
+func NewPartDetailModel(database *db.DB, partID int, dataPath string, writes *writeQueue, fit *image.Fit, prefetch *prefetcher) *PartDetailModel {
+	data := prefetch.take(partID)
+	part := data.part
 
 	isBookmark, _ := database.IsBookmarked(partID)
 	note, _ := database.GetNote(partID)
+	overridden, _ := database.GetOverriddenFields(partID)
 
 	// Initialize textarea for note editing
 	ti := textarea.New()
@@ -63,14 +180,9 @@ func NewPartDetailModel(database *db.DB, partID int, dataPath string) *PartDetai
 	ti.ShowLineNumbers = false
 	ti.Prompt = ""
 
-	// Get all subgroups containing this part number
-	var subgroups []db.SubgroupWithGroup
-	if part != nil {
-		subgroups, _ = database.GetSubgroupsForPartNumber(part.PartNumber)
-	}
-
-	// Build links list
-	var links []string
+	// Build links list, to the EPC for the active van
+	vehicle := activeVehicle(database)
+	var links []partLink
 	if part != nil {
 		subgroupID := ""
 		if part.SubgroupID != nil {
@@ -80,15 +192,15 @@ func NewPartDetailModel(database *db.DB, partID int, dataPath string) *PartDetai
 		if part.DetailPageID != nil {
 			detailPageID = *part.DetailPageID
 		}
-		frameName := os.Getenv("FRAME_NAME")
+		frameName := vehicle.FrameName
 		if frameName == "" {
 			frameName = "pd6w"
 		}
-		trimCode := os.Getenv("TRIM_CODE")
+		trimCode := vehicle.TrimCode
 		if trimCode == "" {
 			trimCode = "hseue9"
 		}
-		frameNo := os.Getenv("FRAME_NO")
+		frameNo := vehicle.FrameNo
 		if frameNo == "" {
 			frameNo = "PD6W-0500900"
 		}
@@ -99,69 +211,406 @@ func NewPartDetailModel(database *db.DB, partID int, dataPath string) *PartDetai
 		if part.ReplacementPartNumber != nil {
 			partNum = *part.ReplacementPartNumber
 		}
-		amayamaURL := fmt.Sprintf("https://www.amayama.com/en/part/mitsubishi/%s", partNum)
-		amazonURL := fmt.Sprintf("https://www.amazon.com/s?k=%s", partNum)
 
-		links = []string{epcURL, amayamaURL, amazonURL}
+		links = append(links, partLink{label: "EPC", url: epcURL})
+		for _, s := range supplier.All() {
+			links = append(links, partLink{label: s.Name(), url: s.PartURL(partNum)})
+		}
 	}
 
 	m := &PartDetailModel{
-		db:         database,
-		partID:     partID,
-		part:       part,
-		diagram:    diagram,
-		group:      group,
-		subgroup:   subgroup,
-		isBookmark: isBookmark,
-		subgroups:  subgroups,
-		links:      links,
-		cursor:     0,
+		db:          database,
+		partID:      partID,
+		part:        part,
+		diagram:     data.diagram,
+		group:       data.group,
+		subgroup:    data.subgroup,
+		isBookmark:  isBookmark,
+		subgroups:   data.subgroups,
+		prices:      data.prices,
+		links:       links,
+		vehicle:     vehicle,
+		cursor:      0,
 		note:        note,
 		editingNote: false,
 		noteInput:   ti,
+		writes:      writes,
+		fit:         fit,
+		overridden:  overridden,
+		editor:      newFieldEditor(),
+		attachments: loadAttachments(database, partID),
+		attacher:    newAttachmentPrompt(),
+		purchaser:   newPurchasePrompt(),
+		carter:      newCartPrompt(),
+		dimensioner: newDimensionsPrompt(),
+		originator:  newOriginPrompt(),
+
+		replacementID:  data.replacementID,
+		hasReplacement: data.hasReplacement,
+		counterpart:    data.counterpart,
+		diagrams:       prefetch.diagrams,
+	}
+	if len(m.prices) > 0 {
+		m.costs, m.costsErr = supplier.CostsFromEnv()
+	}
+	m.collapsed, _ = database.GetCollapsedSections()
+	m.kb, _ = database.GetKBEntriesForPart(partID)
+	m.compat, _ = database.GetCompatNotesForPart(partID)
+	m.purchase, _ = database.GetPurchase(partID)
+	m.qty, _ = database.GetPartQty(partID)
+	m.loadLabor()
+	if part != nil {
+		m.dims, _ = database.GetDimensions(part.PartNumber)
+		m.origin, _ = database.GetOrigin(part.PartNumber)
+	}
+	if attrs, err := database.GetAttributes(partID); err == nil {
+		m.attrs = attrs[partID]
 	}
 
-	// Load image - use larger size for better visibility
+	// Load image - use larger size for better visibility. The zoomed modes
+	// depend on the pane width, so View loads those.
 	if part != nil && part.ImagePath != nil {
-		imgPath := filepath.Join(dataPath, *part.ImagePath)
-		if img, err := image.LoadAndScale(imgPath, 92, 46); err == nil {
-			m.img = img
-		} else {
-			m.imgError = err.Error()
+		m.imgPath = filepath.Join(dataPath, *part.ImagePath)
+		if *fit == image.FitPane {
+			m.loadImage(0)
 		}
 	}
+	if data.subgroupDiagram != nil {
+		m.subgroupDiagram = data.subgroupDiagram
+		m.subgroupImgPath = filepath.Join(dataPath, *data.subgroupDiagram.ImagePath)
+	}
 
 	return m
 }
 
+// shownImgPath is the diagram in the image pane: the part's, or the
+// subgroup's when toggled to it
+func (m *PartDetailModel) shownImgPath() string {
+	if m.showSubgroupDiagram {
+		return m.subgroupImgPath
+	}
+	return m.imgPath
+}
+
+// shownDiagramID is the ID of the diagram in the image pane
+func (m *PartDetailModel) shownDiagramID() string {
+	if m.showSubgroupDiagram {
+		return m.subgroupDiagram.ID
+	}
+	return m.part.DiagramID
+}
+
+// toggleDiagram swaps the image pane between the part's diagram and the
+// subgroup's, starting the new one unzoomed
+func (m *PartDetailModel) toggleDiagram() {
+	m.showSubgroupDiagram = !m.showSubgroupDiagram
+	if m.img != nil {
+		m.clearImageID = m.img.ID()
+	}
+	m.img, m.imgError = nil, ""
+	m.zoom = 0
+	m.panX, m.panY = 0, 0
+}
+
+// diagramCaption names the diagram shown above it, saying whose it is when
+// the part and its subgroup are drawn on different diagrams
+func (m *PartDetailModel) diagramCaption() string {
+	if m.subgroupDiagram == nil {
+		if m.diagram == nil {
+			return ""
+		}
+		return m.diagram.ID
+	}
+	if m.showSubgroupDiagram {
+		name := "subgroup"
+		if m.subgroup != nil {
+			name = strings.ToUpper(m.subgroup.Name)
+		}
+		return fmt.Sprintf("%s  %s, part not on it  v part's", m.subgroupDiagram.ID, name)
+	}
+	id := m.part.DiagramID
+	if m.diagram != nil {
+		id = m.diagram.ID
+	}
+	return fmt.Sprintf("%s  part's diagram  v subgroup's", id)
+}
+
+// loadImage scales the diagram for the current mode, reloading it when the
+// mode or, for fit-width, the pane width has changed.
+func (m *PartDetailModel) loadImage(paneWidth int) {
+	path := m.shownImgPath()
+	if path == "" {
+		return
+	}
+	fit := *m.fit
+	loaded := m.img != nil || m.imgError != ""
+	if loaded && fit == m.imgFit && m.zoom == m.imgZoom && (fit != image.FitWidth || m.zoom != 0 || paneWidth == m.imgWidth) {
+		return
+	}
+
+	var img *image.KittyImage
+	var err error
+	switch {
+	case m.zoom != 0:
+		img, err = image.LoadScaled(path, m.zoom)
+	case fit == image.FitPane:
+		img, err = m.diagrams.load(path, diagramWidthCells, diagramHeightCells)
+	case fit == image.FitWidth:
+		img, err = image.LoadFit(path, fit, paneWidth, diagramHeightCells)
+	default:
+		img, err = image.LoadFit(path, fit, diagramWidthCells, diagramHeightCells)
+	}
+	if m.img != nil {
+		m.clearImageID = m.img.ID()
+	}
+	m.img, m.imgError = img, ""
+	if err != nil {
+		m.imgError = err.Error()
+	}
+	if fit != m.imgFit || m.zoom == 0 && m.imgZoom != 0 {
+		m.panX, m.panY = 0, 0
+	}
+	if img != nil && m.zoom == 0 {
+		m.fitScale = img.Scale()
+	}
+	m.imgFit, m.imgWidth, m.imgZoom = fit, paneWidth, m.zoom
+}
+
+// cropped reports whether the diagram is larger than its pane, so only the
+// region at panX, panY is shown
+func (m *PartDetailModel) cropped() bool {
+	return m.imgFit != image.FitPane || m.imgZoom != 0
+}
+
+// wheelZoom zooms the diagram one step in or out, keeping the point under
+// the mouse at cell x, y of the screen where it is. Zooming out to the
+// chosen fit mode's scale returns to that mode.
+func (m *PartDetailModel) wheelZoom(in bool, x, y int) {
+	if m.img == nil {
+		return
+	}
+	// Only over the visible diagram
+	cx, cy := x-diagramLeft, y-diagramTop
+	visibleW, visibleH := m.img.CellWidth()-m.panX, m.img.CellHeight()-m.panY
+	if m.cropped() {
+		visibleW, visibleH = min(visibleW, m.viewW), min(visibleH, m.viewH)
+	}
+	if cx < 0 || cy < 0 || cx >= visibleW || cy >= visibleH {
+		return
+	}
+
+	// Several notches can arrive before View loads the last zoom
+	current := m.img.Scale()
+	if m.zoom != 0 {
+		current = m.zoom
+	}
+	scale := current / zoomStep
+	if in {
+		scale = min(current*zoomStep, maxZoom)
+		if scale <= current {
+			return
+		}
+	}
+	if !in && scale <= m.fitScale {
+		m.zoom = 0
+		return
+	}
+
+	// The image cell under the mouse moves with the scale; pan so it stays
+	// under the mouse. View clamps the pan to the new image.
+	ratio := scale / current
+	m.panX = int(float64(m.panX+cx)*ratio) - cx
+	m.panY = int(float64(m.panY+cy)*ratio) - cy
+	m.zoom = scale
+}
+
+// pan scrolls a zoomed diagram, keeping the view within the image
+func (m *PartDetailModel) pan(dx, dy int) {
+	if m.img == nil || !m.cropped() {
+		return
+	}
+	if m.imgFit == image.FitWidth && m.imgZoom == 0 {
+		dx = 0
+	}
+	m.panX = max(0, min(m.panX+dx*(m.viewW/4+1), m.img.CellWidth()-m.viewW))
+	m.panY = max(0, min(m.panY+dy*(m.viewH/4+1), m.img.CellHeight()-m.viewH))
+}
+
+type partLink struct {
+	label string
+	url   string
+}
+
+// The cursor runs over attachments, then subgroups and prices, in the order
+// they're shown. Links have their own popup.
 func (m *PartDetailModel) totalItems() int {
-	return len(m.subgroups) + len(m.links)
+	return m.shownAttachments() + m.shownSubgroups() + m.shownPrices()
+}
+
+// keepPosition restores the cursor, barcode, shown diagram and its zoom and pan of
+// the same part's previous model, when going back to it
+func (m *PartDetailModel) keepPosition(prev *PartDetailModel) {
+	if prev.partID != m.partID {
+		return
+	}
+	m.cursor = max(0, min(prev.cursor, m.totalItems()-1))
+	m.showBarcode = prev.showBarcode
+	if prev.showSubgroupDiagram && m.subgroupDiagram != nil {
+		m.toggleDiagram()
+	}
+	m.zoom = prev.zoom
+	m.panX, m.panY = prev.panX, prev.panY
+	m.focus = prev.focus
+}
+
+// diagramFocused reports whether the keys go to the diagram pane
+func (m *PartDetailModel) diagramFocused() bool {
+	return m.focus == ui.PaneLeft && m.shownImgPath() != "" && !image.Disabled
+}
+
+func (m *PartDetailModel) isAttachmentSelected() bool {
+	return m.cursor < m.shownAttachments()
+}
+
+func (m *PartDetailModel) selectedSubgroupIndex() int {
+	return m.cursor - m.shownAttachments()
 }
 
 func (m *PartDetailModel) isSubgroupSelected() bool {
-	return m.cursor < len(m.subgroups)
+	i := m.selectedSubgroupIndex()
+	return i >= 0 && i < m.shownSubgroups()
 }
 
-func (m *PartDetailModel) selectedLinkIndex() int {
-	return m.cursor - len(m.subgroups)
+func (m *PartDetailModel) selectedPriceIndex() int {
+	return m.cursor - m.shownAttachments() - m.shownSubgroups()
 }
 
-func openURL(url string) error {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", url)
-	case "linux":
-		cmd = exec.Command("xdg-open", url)
-	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
-	default:
-		return fmt.Errorf("unsupported platform")
+// priceURL returns the supplier page for a price row, falling back to the
+// supplier's part URL when the price data didn't include one.
+func priceURL(p db.Price) string {
+	if p.URL != nil {
+		return *p.URL
+	}
+	if s, ok := supplier.ByID(p.SupplierID); ok {
+		return s.PartURL(p.PartNumber)
 	}
-	return cmd.Start()
+	return ""
+}
+
+func supplierName(id string) string {
+	if s, ok := supplier.ByID(id); ok {
+		return s.Name()
+	}
+	return id
+}
+
+func (m *PartDetailModel) loadLabor() {
+	m.labor, _ = m.db.GetLaborTotal(m.partID)
+	running, _ := m.db.GetRunningLabor()
+	m.timing = running != nil && running.PartID == m.partID
 }
 
 func (m *PartDetailModel) Update(msg tea.Msg) (*PartDetailModel, tea.Cmd, *Screen) {
+	if msg, ok := msg.(tea.MouseMsg); ok && msg.Action == tea.MouseActionPress {
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.wheelZoom(true, msg.X, msg.Y)
+		case tea.MouseButtonWheelDown:
+			m.wheelZoom(false, msg.X, msg.Y)
+		}
+		return m, nil, nil
+	}
+	if msg, ok := msg.(userDataWrittenMsg); ok {
+		m.handleWritten(msg)
+		return m, nil, nil
+	}
+	if msg, ok := msg.(pricesFetchedMsg); ok && msg.partID == m.partID {
+		return m, m.handlePricesFetched(msg), nil
+	}
+	if msg, ok := msg.(partMigratedMsg); ok && msg.from == m.partID {
+		if msg.err != nil {
+			m.writeError = fmt.Sprintf("Not moved: %v", msg.err)
+			return m, nil, nil
+		}
+		s := PartDetailScreen(msg.to, false)
+		return m, nil, &s
+	}
+
+	// Handle catalog field editing mode
+	if m.editor.active {
+		cmd, changed := m.editor.update(msg, m.db, m.part.Part)
+		if changed {
+			if part, err := m.db.GetPart(m.partID); err == nil && part != nil {
+				m.part = part
+			}
+			m.overridden, _ = m.db.GetOverriddenFields(m.partID)
+			m.qty, _ = m.db.GetPartQty(m.partID)
+		}
+		return m, cmd, nil
+	}
+
+	// Handle attachment path entry
+	if m.attacher.active {
+		cmd, added := m.attacher.update(msg, m.db, m.partID)
+		if added {
+			m.attachments = loadAttachments(m.db, m.partID)
+			m.attachError = ""
+			m.cursor = max(m.shownAttachments()-1, 0)
+		}
+		return m, cmd, nil
+	}
+
+	// Handle purchase entry
+	if m.purchaser.active {
+		cmd, purchase, entered := m.purchaser.update(msg, m.partID)
+		if entered {
+			cmd = m.savePurchase(purchase)
+		}
+		return m, cmd, nil
+	}
+
+	// Handle cart quantity entry
+	if m.carter.active {
+		return m, m.carter.update(msg, m.db, m.writes, m.part), nil
+	}
+
+	// Handle dimensions entry
+	if m.dimensioner.active {
+		cmd, saved := m.dimensioner.update(msg, m.db, m.part.PartNumber)
+		if saved {
+			m.dims, _ = m.db.GetDimensions(m.part.PartNumber)
+		}
+		return m, cmd, nil
+	}
+
+	// Handle origin entry
+	if m.originator.active {
+		cmd, saved := m.originator.update(msg, m.db, m.part.PartNumber)
+		if saved {
+			m.origin, _ = m.db.GetOrigin(m.part.PartNumber)
+		}
+		return m, cmd, nil
+	}
+
+	// The link popup takes every key until it closes
+	if m.openWith.active {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			return m, m.openWith.update(msg), nil
+		}
+		return m, nil, nil
+	}
+
+	// Any key but y or enter cancels opening every link
+	if m.confirmOpenAll {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			m.confirmOpenAll = false
+			if ui.IsConfirm(msg) {
+				return m, m.openAll(), nil
+			}
+		}
+		return m, nil, nil
+	}
+
 	// Handle note editing mode
 	if m.editingNote {
 		switch msg := msg.(type) {
@@ -169,15 +618,23 @@ func (m *PartDetailModel) Update(msg tea.Msg) (*PartDetailModel, tea.Cmd, *Scree
 			if ui.IsSaveNote(msg) {
 				// Save or delete note
 				content := strings.TrimSpace(m.noteInput.Value())
+				m.noteSeq = m.writes.next()
+				var cmd tea.Cmd
 				if content == "" {
-					m.db.RemoveNote(m.partID)
 					m.note = nil
+					cmd = m.writes.enqueue(m.partID, writeNote, m.noteSeq, func() error {
+						return m.db.RemoveNote(m.partID)
+					}, m.event("note", "remove"))
 				} else {
-					m.db.SetNote(m.partID, content)
 					m.note = &content
+					event := m.event("note", "set")
+					event.Note = content
+					cmd = m.writes.enqueue(m.partID, writeNote, m.noteSeq, func() error {
+						return m.db.SetNote(m.partID, content)
+					}, event)
 				}
 				m.editingNote = false
-				return m, nil, nil
+				return m, cmd, nil
 			}
 			if ui.IsBack(msg) {
 				// Cancel editing
@@ -195,44 +652,166 @@ func (m *PartDetailModel) Update(msg tea.Msg) (*PartDetailModel, tea.Cmd, *Scree
 	case tea.KeyMsg:
 		totalItems := m.totalItems()
 
-		if totalItems > 0 {
-			if ui.IsUp(msg) {
-				if m.cursor > 0 {
-					m.cursor--
-				}
+		if ui.IsFocusPane(msg) && m.shownImgPath() != "" && !image.Disabled {
+			if m.focus == ui.PaneLeft {
+				m.focus = ui.PaneRight
+			} else {
+				m.focus = ui.PaneLeft
+			}
+			return m, nil, nil
+		}
+
+		if m.diagramFocused() {
+			if dx, dy := ui.Arrow(msg); dx != 0 || dy != 0 {
+				m.pan(dx, dy)
 				return m, nil, nil
 			}
-			if ui.IsDown(msg) {
-				if m.cursor < totalItems-1 {
-					m.cursor++
-				}
+		} else if totalItems > 0 {
+			if cursor, ok := ui.MoveCursor(msg, m.cursor, totalItems, partDetailPage, false); ok {
+				m.cursor = cursor
 				return m, nil, nil
 			}
 			if ui.IsEnter(msg) {
-				if m.isSubgroupSelected() {
+				if m.isAttachmentSelected() {
+					// Open the file with the platform's default app
+					m.attachError = ""
+					a := m.attachments[m.cursor]
+					if _, err := os.Stat(a.path); err != nil {
+						m.attachError = fmt.Sprintf("File not found: %s", a.path)
+						return m, nil, nil
+					}
+					return m, openCmd(a.path), nil
+				} else if m.isSubgroupSelected() {
 					// Navigate to subgroup
-					selected := m.subgroups[m.cursor]
+					selected := m.subgroups[m.selectedSubgroupIndex()]
 					s := SubgroupScreen(selected.SubgroupID)
 					return m, nil, &s
-				} else {
-					// Open link in browser
-					linkIdx := m.selectedLinkIndex()
-					if linkIdx >= 0 && linkIdx < len(m.links) {
-						openURL(m.links[linkIdx])
+				} else if priceIdx := m.selectedPriceIndex(); priceIdx < len(m.prices) {
+					// Open the supplier's page for this price
+					if url := priceURL(m.prices[priceIdx]); url != "" {
+						return m, openCmd(url), nil
 					}
 				}
 				return m, nil, nil
 			}
+			if ui.IsRemove(msg) && m.isAttachmentSelected() {
+				if err := m.db.RemoveAttachment(m.partID, m.attachments[m.cursor].path); err != nil {
+					m.attachError = err.Error()
+					return m, nil, nil
+				}
+				m.attachments = loadAttachments(m.db, m.partID)
+				m.attachError = ""
+				return m, nil, nil
+			}
+		}
+
+		if ui.IsMigrate(msg) && m.canMigrate() {
+			return m, m.migrate(), nil
+		}
+
+		if ui.IsAttach(msg) {
+			return m, m.attacher.open(), nil
+		}
+
+		if ui.IsPurchase(msg) {
+			return m, m.purchaser.open(m.purchase), nil
+		}
+
+		if ui.IsMoreQty(msg) && m.part != nil {
+			return m, m.carter.open(), nil
+		}
+		if ui.IsRefreshPrices(msg) && m.part != nil {
+			return m, m.lookupPrices(true), nil
+		}
+		if ui.IsTimer(msg) && m.part != nil {
+			return m, toggleLabor(m.db, m.part), nil
+		}
+
+		if ui.IsDimensions(msg) && m.part != nil {
+			return m, m.dimensioner.open(m.dims), nil
+		}
+
+		if ui.IsOrigin(msg) && m.part != nil {
+			return m, m.originator.open(m.origin), nil
+		}
+
+		if ui.IsOpenWith(msg) && len(m.links) > 0 {
+			m.openWith.open(m.links)
+			return m, nil, nil
+		}
+
+		if ui.IsOpenAll(msg) && len(m.links) > 0 {
+			m.confirmOpenAll = true
+			return m, nil, nil
+		}
+
+		if ui.IsOppositeHand(msg) && m.counterpart != nil {
+			s := PartDetailScreen(m.counterpart.ID, false)
+			return m, nil, &s
+		}
+
+		if ui.IsShortlistPair(msg) && m.counterpart != nil {
+			parts := []*db.PartWithDiagram{m.part, m.counterpart}
+			return m, func() tea.Msg { return shortlistAddMsg{parts: parts} }, nil
 		}
 
 		if ui.IsBookmark(msg) {
+			m.bookmarkSeq = m.writes.next()
 			if m.isBookmark {
-				m.db.RemoveBookmark(m.partID)
 				m.isBookmark = false
-			} else {
-				m.db.AddBookmark(m.partID)
-				m.isBookmark = true
+				return m, m.writes.enqueue(m.partID, writeBookmark, m.bookmarkSeq, func() error {
+					return m.db.RemoveBookmark(m.partID)
+				}, m.event("bookmark", "remove")), nil
+			}
+			m.isBookmark = true
+			event := m.event("bookmark", "add")
+			event.Qty = 1
+			return m, m.writes.enqueue(m.partID, writeBookmark, m.bookmarkSeq, func() error {
+				return m.db.AddBookmark(m.partID)
+			}, event), nil
+		}
+
+		if ui.IsEdit(msg) && m.part != nil {
+			return m, m.editor.open(m.part.Part, 0), nil
+		}
+
+		if ui.IsBarcode(msg) {
+			m.showBarcode = !m.showBarcode
+			return m, nil, nil
+		}
+
+		if n := ui.Section(msg); n > 0 && n <= len(detailSections) {
+			m.toggleSection(n)
+			return m, nil, nil
+		}
+
+		if ui.IsDiagramToggle(msg) && m.subgroupDiagram != nil {
+			m.toggleDiagram()
+			return m, nil, nil
+		}
+
+		if ui.IsFullScreen(msg) && m.shownImgPath() != "" && !image.Disabled {
+			s := ViewerScreen(m.shownDiagramID())
+			return m, nil, &s
+		}
+
+		if ui.IsKeepDiagram(msg) && m.shownImgPath() != "" {
+			caption := m.part.PartNumber + "  " + deref(m.part.Description)
+			if id := strings.Fields(m.diagramCaption()); len(id) > 0 {
+				caption = id[0] + "  " + caption
 			}
+			return m, keepDiagram(m.shownImgPath(), caption), nil
+		}
+
+		if ui.IsImageFit(msg) && m.shownImgPath() != "" {
+			*m.fit = m.fit.Next()
+			m.zoom = 0
+			return m, nil, nil
+		}
+
+		if dx, dy := ui.Pan(msg); dx != 0 || dy != 0 {
+			m.pan(dx, dy)
+			return m, nil, nil
 		}
 
 		if ui.IsNote(msg) {
@@ -250,6 +829,171 @@ func (m *PartDetailModel) Update(msg tea.Msg) (*PartDetailModel, tea.Cmd, *Scree
 	return m, nil, nil
 }
 
+// openAll opens the EPC and supplier links in browser tabs, in the order
+// they're listed
+func (m *PartDetailModel) openAll() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.links))
+	for i, link := range m.links {
+		cmds[i] = openCmd(link.url)
+	}
+	return tea.Sequence(cmds...)
+}
+
+// partMigratedMsg reports the outcome of moving user data to a replacement.
+type partMigratedMsg struct {
+	from, to int
+	err      error
+}
+
+// canMigrate reports whether the part is superseded by a listed part and
+// has a bookmark, note or attachments that could follow it there.
+func (m *PartDetailModel) canMigrate() bool {
+	return m.hasReplacement && (m.isBookmark || m.note != nil || len(m.attachments) > 0)
+}
+
+// migrateOffer invites moving the user's data to the replacement part
+func (m *PartDetailModel) migrateOffer() string {
+	var kinds []string
+	if m.isBookmark {
+		kinds = append(kinds, "bookmark")
+	}
+	if m.note != nil {
+		kinds = append(kinds, "note")
+	}
+	if len(m.attachments) > 0 {
+		kinds = append(kinds, "attachments")
+	}
+	verb, pronoun := "are", "them"
+	if len(kinds) == 1 && kinds[0] != "attachments" {
+		verb, pronoun = "is", "it"
+	}
+	return fmt.Sprintf("Your %s %s on the old number; m moves %s to %s",
+		strings.Join(kinds, " and "), verb, pronoun, strings.ToUpper(*m.part.ReplacementPartNumber))
+}
+
+// migrate moves the bookmark, note and attachments to the replacement part
+// behind any writes still queued for this part, then opens the replacement.
+func (m *PartDetailModel) migrate() tea.Cmd {
+	partID, toID := m.partID, m.replacementID
+	var events []webhook.Event
+	if m.isBookmark {
+		events = append(events, m.event("bookmark", "remove"))
+	}
+	if m.note != nil {
+		events = append(events, m.event("note", "remove"))
+	}
+
+	var migration db.PartMigration
+	written := m.writes.enqueue(partID, writeMigrate, 0, func() error {
+		var err error
+		migration, err = m.db.MigrateToReplacement(partID)
+		return err
+	}, events...)
+	return func() tea.Msg {
+		result := written().(userDataWrittenMsg)
+		if result.err == nil {
+			m.writes.notifier.Publish(migrationEvents(m.db, migration)...)
+		}
+		return partMigratedMsg{from: partID, to: toID, err: result.err}
+	}
+}
+
+// migrationEvents describes the replacement's new user data for the webhook
+func migrationEvents(database *db.DB, migration db.PartMigration) []webhook.Event {
+	e := webhook.Event{PartID: migration.ToPartID, PartNumber: migration.ToPartNumber}
+	if part, err := database.GetPart(migration.ToPartID); err == nil && part != nil && part.Description != nil {
+		e.Description = *part.Description
+	}
+	var events []webhook.Event
+	if migration.Bookmark {
+		bookmark := e
+		bookmark.Kind, bookmark.Action = "bookmark", "add"
+		events = append(events, bookmark)
+	}
+	if migration.Note {
+		note := e
+		note.Kind, note.Action = "note", "set"
+		if content, err := database.GetNote(migration.ToPartID); err == nil && content != nil {
+			note.Note = *content
+		}
+		events = append(events, note)
+	}
+	return events
+}
+
+// event describes a change to this part's user data for the webhook
+func (m *PartDetailModel) event(kind, action string) webhook.Event {
+	e := webhook.Event{Kind: kind, Action: action, PartID: m.partID}
+	if m.part != nil {
+		e.PartNumber = m.part.PartNumber
+		if m.part.Description != nil {
+			e.Description = *m.part.Description
+		}
+	}
+	return e
+}
+
+// savePurchase shows purchase, or no purchase for nil, and queues saving it
+func (m *PartDetailModel) savePurchase(purchase *db.Purchase) tea.Cmd {
+	if purchase != nil {
+		// As SetPurchase stores it
+		purchase.Currency = strings.ToUpper(purchase.Currency)
+	}
+	m.purchase = purchase
+	m.purchaseSeq = m.writes.next()
+	database, partID := m.db, m.partID
+	if purchase == nil {
+		return m.writes.enqueue(partID, writePurchase, m.purchaseSeq, func() error {
+			return database.RemovePurchase(partID)
+		}, m.event("purchase", "remove"))
+	}
+	p := *purchase
+	event := m.event("purchase", "set")
+	event.PurchasedOn, event.Cost, event.Currency, event.Qty = p.PurchasedOn, p.Cost, p.Currency, max(p.Qty, 1)
+	return m.writes.enqueue(partID, writePurchase, m.purchaseSeq, func() error {
+		return database.SetPurchase(p)
+	}, event)
+}
+
+// handleWritten reconciles an optimistic change with the outcome of its
+// write. Only the latest write of each kind is rolled back; an earlier
+// failure is superseded by the newer value already queued behind it. It
+// rolls back to the value last saved, read back from the database: the one
+// shown before the change may not have been saved either, or may have been
+// replaced in the queue before it ran.
+func (m *PartDetailModel) handleWritten(msg userDataWrittenMsg) {
+	if msg.partID != m.partID {
+		return
+	}
+	if msg.kind == writeBookmark || msg.kind == writePurchase {
+		m.qty, _ = m.db.GetPartQty(m.partID)
+	}
+	if msg.err == nil {
+		m.writeError = ""
+		return
+	}
+
+	switch msg.kind {
+	case writeBookmark:
+		if msg.seq == m.bookmarkSeq {
+			m.isBookmark, _ = m.db.IsBookmarked(m.partID)
+		}
+		m.writeError = fmt.Sprintf("Bookmark not saved: %v", msg.err)
+	case writeNote:
+		if msg.seq == m.noteSeq {
+			m.note, _ = m.db.GetNote(m.partID)
+		}
+		m.writeError = fmt.Sprintf("Note not saved: %v", msg.err)
+	case writePurchase:
+		if msg.seq == m.purchaseSeq {
+			m.purchase, _ = m.db.GetPurchase(m.partID)
+		}
+		m.writeError = fmt.Sprintf("Purchase not saved: %v", msg.err)
+	case writeCart:
+		m.writeError = fmt.Sprintf("Cart not saved: %v", msg.err)
+	}
+}
+
 func (m *PartDetailModel) View(width, height int) string {
 	if width == 0 {
 		width = 80
@@ -274,10 +1018,32 @@ func (m *PartDetailModel) View(width, height int) string {
 		splitHeight = 10
 	}
 
+	// Zoomed diagrams scroll within the left pane, below the diagram ID
+	// and above the mode line, and the minimap when they overflow it
+	paneWidth := ui.SplitPaneLeftWidth(width - 2)
+	m.loadImage(paneWidth)
+	m.viewW, m.viewH = paneWidth, splitHeight-2
+	m.minimap = m.img != nil && m.cropped() && (m.img.CellWidth() > m.viewW || m.img.CellHeight() > m.viewH)
+	if m.minimap {
+		m.viewH -= ui.MinimapLines(m.img.CellWidth(), m.img.CellHeight())
+	}
+	m.pan(0, 0)
+
 	leftContent := m.renderDiagram(splitHeight)
-	rightContent := m.renderPartInfo()
+	rightContent := m.renderPartInfo(ui.SplitPaneRightWidth(width - 2))
+
+	var split string
+	if m.shownImgPath() != "" && !image.Disabled {
+		split = ui.RenderFocusedSplitPane(leftContent, rightContent, width-2, splitHeight, m.focus)
+	} else {
+		split = ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+	}
 
-	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+	// Delete a diagram replaced by a change of scaling
+	if m.clearImageID != 0 {
+		result.WriteString(image.Clear(m.clearImageID))
+		m.clearImageID = 0
+	}
 
 	// Output image escape with positioning
 	// Save cursor, move to image position, render, restore cursor
@@ -285,7 +1051,11 @@ func (m *PartDetailModel) View(width, height int) string {
 		result.WriteString("\x1b7")   // Save cursor position
 		result.WriteString("  ")      // Left padding (matches split pane margin)
 		result.WriteString("\x1b[1B") // Move cursor down 1 line (past diagram ID)
-		result.WriteString(m.img.Render())
+		if !m.cropped() {
+			result.WriteString(m.img.Render())
+		} else {
+			result.WriteString(m.img.RenderRegion(m.panX, m.panY, m.viewW, m.viewH))
+		}
 		result.WriteString("\x1b8") // Restore cursor position
 	}
 
@@ -299,20 +1069,39 @@ func (m *PartDetailModel) renderDiagram(height int) string {
 
 	if m.img != nil {
 		// Add diagram ID above the image, truncated to image width
-		if m.diagram != nil {
-			maxWidth := m.img.CellWidth()
-			diagramID := lipgloss.NewStyle().MaxWidth(maxWidth).Render(m.diagram.ID)
-			lines = append(lines, ui.DimStyle.Render(diagramID))
+		imgWidth, imgHeight := m.img.CellWidth(), m.img.CellHeight()
+		if m.cropped() {
+			imgWidth = min(imgWidth, m.viewW)
+			imgHeight = min(imgHeight-m.panY, m.viewH)
 		}
-		// Image is rendered separately in View(), just add placeholder lines
-		imgHeight := m.img.CellHeight()
+		if caption := m.diagramCaption(); caption != "" {
+			caption = lipgloss.NewStyle().MaxWidth(min(max(imgWidth, 20), m.viewW)).Render(caption)
+			lines = append(lines, ui.DimStyle.Render(caption))
+		}
+		// Image is rendered separately in View(), just add placeholder
+		// lines, unless it's drawn as text
 		for i := 0; i < imgHeight; i++ {
-			lines = append(lines, "")
+			if m.cropped() {
+				lines = append(lines, m.img.RowRegion(m.panX, m.panY+i, imgWidth))
+			} else {
+				lines = append(lines, m.img.Row(i))
+			}
+		}
+		if m.minimap {
+			lines = append(lines, strings.Split(ui.Minimap(m.img.CellWidth(), m.img.CellHeight(), m.panX, m.panY, m.viewW, m.viewH), "\n")...)
 		}
+		if m.cropped() {
+			lines = append(lines, ui.DimStyle.Render(m.panHint()))
+		}
+	} else if image.Disabled {
+		lines = append(lines, ui.DimStyle.Render("Diagram hidden in low-bandwidth mode"))
 	} else if m.imgError != "" {
 		lines = append(lines, ui.ErrorStyle.Render(m.imgError))
 	} else {
 		lines = append(lines, ui.DimStyle.Render("No diagram available"))
+		if m.subgroupDiagram != nil {
+			lines = append(lines, ui.DimStyle.Render("v show the subgroup's diagram"))
+		}
 	}
 
 	// Pad to fill height
@@ -323,7 +1112,25 @@ func (m *PartDetailModel) renderDiagram(height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *PartDetailModel) renderPartInfo() string {
+// panHint describes a zoomed diagram's position and how to move it
+func (m *PartDetailModel) panHint() string {
+	rows := fmt.Sprintf("rows %d-%d/%d", m.panY+1, min(m.panY+m.viewH, m.img.CellHeight()), m.img.CellHeight())
+	scroll, pan := "J/K scroll", "HJKL pan"
+	if m.diagramFocused() {
+		scroll, pan = "↑↓ scroll", "←↑↓→ pan"
+	}
+	if m.imgFit == image.FitWidth && m.imgZoom == 0 {
+		return fmt.Sprintf("%s  %s  %s", strings.ToUpper(m.imgFit.String()), rows, scroll)
+	}
+	cols := fmt.Sprintf("cols %d-%d/%d", m.panX+1, min(m.panX+m.viewW, m.img.CellWidth()), m.img.CellWidth())
+	mode := strings.ToUpper(m.imgFit.String())
+	if m.imgZoom != 0 {
+		mode = fmt.Sprintf("ZOOM %.0f%%", m.imgZoom*100)
+	}
+	return fmt.Sprintf("%s  %s  %s  %s", mode, rows, cols, pan)
+}
+
+func (m *PartDetailModel) renderPartInfo(width int) string {
 	var b strings.Builder
 
 	// Header - show GROUP > SUBGROUP breadcrumb
@@ -341,34 +1148,76 @@ func (m *PartDetailModel) renderPartInfo() string {
 	// Part number and description
 	b.WriteString(ui.PartNumberStyle.Render(strings.ToUpper(m.part.PartNumber)))
 	b.WriteString("\n")
+	if m.showBarcode {
+		b.WriteString(renderBarcode(strings.ToUpper(m.part.PartNumber)))
+	}
 	desc := "NO DESCRIPTION"
 	if m.part.Description != nil {
 		desc = strings.ToUpper(*m.part.Description)
 	}
 	b.WriteString(desc)
+	b.WriteString(m.localMark(db.FieldDescription))
 	b.WriteString("\n\n")
 
 	// Fields
-	m.renderField(&b, "PNC", m.part.PNC)
-	m.renderField(&b, "Ref #", m.part.RefNumber)
-	if m.part.Quantity != nil {
-		b.WriteString(m.fieldLine("Quantity", fmt.Sprintf("%d", *m.part.Quantity)))
+	if m.collapsed[sectionFields] {
+		m.renderCollapsed(&b, sectionFields, 0)
+	} else {
+		m.renderField(&b, "PNC", m.part.PNC)
+		m.renderField(&b, "Ref #", m.part.RefNumber)
+		if line := m.quantityLine(); line != "" {
+			b.WriteString(m.fieldLine("Catalog qty", line))
+		}
+		m.renderSpec(&b)
+		m.renderField(&b, "Color", m.part.Color)
+		if m.part.ModelDateRange != nil {
+			b.WriteString(m.fieldLine("Date Range", strings.ToUpper(*m.part.ModelDateRange)+m.localMark(db.FieldModelDateRange)))
+		}
+		if m.origin != nil {
+			b.WriteString(m.fieldLine("Origin", ui.SelectedStyle.Render(m.origin.Badge())))
+		}
+		m.renderReplacement(&b)
+		if m.canMigrate() {
+			b.WriteString(m.fieldLine("", ui.DimStyle.Render(m.migrateOffer())))
+		}
+		m.renderCounterpart(&b)
+		m.renderDimensions(&b)
 	}
-	m.renderField(&b, "Spec", m.part.Spec)
-	m.renderField(&b, "Color", m.part.Color)
-	m.renderField(&b, "Date Range", m.part.ModelDateRange)
-	m.renderField(&b, "Replaces", m.part.ReplacementPartNumber)
+	m.renderUsage(&b)
 
-	if m.part.Notes != nil {
+	// Catalog notes, the knowledge base and fitment notes, and below them
+	// the user's note and its attachments
+	if n := m.noteCount(); m.collapsed[sectionNotes] && n > 0 {
 		b.WriteString("\n")
-		b.WriteString(ui.DimStyle.Render("Notes:"))
+		m.renderCollapsed(&b, sectionNotes, n)
+	} else if !m.collapsed[sectionNotes] {
+		if m.part.Notes != nil {
+			b.WriteString("\n")
+			b.WriteString(ui.DimStyle.Render("Notes:"))
+			b.WriteString(m.localMark(db.FieldNotes))
+			b.WriteString("\n")
+			b.WriteString(strings.ToUpper(*m.part.Notes))
+			b.WriteString("\n")
+		}
+		m.renderKB(&b)
+		m.renderCompat(&b)
+	}
+
+	// Catalog field editor
+	if m.editor.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("Edit %s (local):", m.editor.label())))
 		b.WriteString("\n")
-		b.WriteString(strings.ToUpper(*m.part.Notes))
+		b.WriteString(m.editor.input.View())
 		b.WriteString("\n")
+		if m.editor.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.editor.err))
+			b.WriteString("\n")
+		}
 	}
 
 	// User note
-	if m.note != nil && !m.editingNote {
+	if m.note != nil && !m.editingNote && !m.collapsed[sectionNotes] {
 		b.WriteString("\n")
 		b.WriteString(ui.DimStyle.Render("My Note:"))
 		b.WriteString("\n")
@@ -385,17 +1234,69 @@ func (m *PartDetailModel) renderPartInfo() string {
 		b.WriteString("\n")
 	}
 
+	m.renderAttachments(&b)
+
+	if m.dimensioner.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("Dimensions (thread, length, od, id, width; mm unless cm or in):"))
+		b.WriteString("\n")
+		b.WriteString(m.dimensioner.input.View())
+		b.WriteString("\n")
+		if m.dimensioner.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.dimensioner.err))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.originator.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("Origin (genuine, oem or aftermarket, then the country if known):"))
+		b.WriteString("\n")
+		b.WriteString(m.originator.input.View())
+		b.WriteString("\n")
+		if m.originator.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.originator.err))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.purchaser.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("Purchased (date, cost, currency):"))
+		b.WriteString("\n")
+		b.WriteString(m.purchaser.input.View())
+		b.WriteString("\n")
+		if m.purchaser.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.purchaser.err))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.carter.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("How many to put in the cart:"))
+		b.WriteString("\n")
+		b.WriteString(m.carter.input.View())
+		b.WriteString("\n")
+		if m.carter.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.carter.err))
+			b.WriteString("\n")
+		}
+	}
+
 	b.WriteString("\n")
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────────"))
 	b.WriteString("\n\n")
 
 	// Subgroups
-	if len(m.subgroups) > 0 {
+	if m.collapsed[sectionSubgroups] && len(m.subgroups) > 0 {
+		m.renderCollapsed(&b, sectionSubgroups, len(m.subgroups))
+	} else if len(m.subgroups) > 0 {
 		b.WriteString(ui.DimStyle.Render("Subgroups:"))
 		b.WriteString("\n")
 		for i, sg := range m.subgroups {
 			label := fmt.Sprintf("%s > %s", strings.ToUpper(sg.GroupName), strings.ToUpper(sg.SubgroupName))
-			if i == m.cursor {
+			if m.shownAttachments()+i == m.cursor {
 				b.WriteString(ui.SelectedStyle.Render("> "))
 				b.WriteString(ui.SelectedLabelStyle.Render(label))
 			} else {
@@ -407,33 +1308,66 @@ func (m *PartDetailModel) renderPartInfo() string {
 		b.WriteString("\n")
 	}
 
-	// Links
-	b.WriteString(ui.DimStyle.Render("Links:"))
-	b.WriteString("\n")
+	// Supplier prices
+	lookup := ""
+	if len(m.lookingUp) > 0 {
+		lookup = "  looking up " + strings.Join(m.lookingUp, ", ") + "..."
+	}
+	if m.collapsed[sectionPrices] && len(m.prices) > 0 {
+		m.renderCollapsed(&b, sectionPrices, len(m.prices))
+	} else if len(m.prices) > 0 {
+		b.WriteString(ui.DimStyle.Render("Prices:" + lookup))
+		b.WriteString("\n")
+		b.WriteString(m.renderPrices())
+		b.WriteString("\n")
+	} else if lookup != "" {
+		b.WriteString(ui.DimStyle.Render("Prices:" + lookup))
+		b.WriteString("\n\n")
+	}
 
-	linkLabels := []string{"EPC", "Amayama", "Amazon"}
-	for i, url := range m.links {
-		cursorIdx := len(m.subgroups) + i
-		label := linkLabels[i]
-		if cursorIdx == m.cursor {
-			b.WriteString(ui.SelectedStyle.Render("> "))
-			b.WriteString(ui.SelectedLabelStyle.Render(label))
-			b.WriteString(" ")
-			b.WriteString(ui.LinkStyle.Render(url))
-		} else {
-			b.WriteString("  ")
-			b.WriteString(label)
-			b.WriteString(" ")
-			b.WriteString(ui.DimStyle.Render(url))
+	// Links, listed by name; l opens the popup with their URLs
+	if m.openWith.active {
+		b.WriteString(m.openWith.view(width))
+		b.WriteString("\n")
+	} else if m.collapsed[sectionLinks] && len(m.links) > 0 {
+		m.renderCollapsed(&b, sectionLinks, len(m.links))
+	} else if len(m.links) > 0 {
+		labels := make([]string, len(m.links))
+		for i, link := range m.links {
+			labels[i] = link.label
 		}
+		b.WriteString(ui.DimStyle.Render("Links: "))
+		b.WriteString(strings.Join(labels, " · "))
 		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
 
+	if m.writeError != "" {
+		b.WriteString(ui.ErrorStyle.Render(m.writeError))
+		b.WriteString("\n")
+	}
+	if m.attachError != "" {
+		b.WriteString(ui.ErrorStyle.Render(m.attachError))
+		b.WriteString("\n")
+	}
+
 	// Footer
-	if m.editingNote {
+	if m.editor.active {
+		b.WriteString(ui.DimStyle.Render(m.editor.hint()))
+	} else if m.editingNote {
 		b.WriteString(ui.DimStyle.Render("ctrl+s save   esc cancel"))
+	} else if m.attacher.active {
+		b.WriteString(ui.DimStyle.Render("enter attach   esc cancel"))
+	} else if m.purchaser.active || m.dimensioner.active || m.originator.active {
+		b.WriteString(ui.DimStyle.Render("enter save (empty to forget)   esc cancel"))
+	} else if m.carter.active {
+		b.WriteString(ui.DimStyle.Render("enter add   esc cancel"))
+	} else if m.openWith.active {
+		b.WriteString(ui.DimStyle.Render("letter or enter open   esc close"))
+	} else if m.confirmOpenAll {
+		b.WriteString(ui.SelectedStyle.Render(fmt.Sprintf("Open all %d links in the browser? ", len(m.links))))
+		b.WriteString(ui.DimStyle.Render("y open   any other key cancels"))
 	} else {
 		bookmarkAction := "bookmark"
 		if m.isBookmark {
@@ -443,12 +1377,323 @@ func (m *PartDetailModel) renderPartInfo() string {
 		if m.note != nil {
 			noteAction = "edit note"
 		}
-		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("esc back   ↑↓ navigate   enter select   b %s   n %s", bookmarkAction, noteAction)))
+		nav := "↑↓ navigate   enter select"
+		if m.diagramFocused() {
+			nav = "tab details   ←↑↓→ pan diagram"
+		} else if m.shownImgPath() != "" && !image.Disabled {
+			nav += "   tab diagram"
+		}
+		hint := fmt.Sprintf("esc back   %s   b %s   n %s   + cart   p prices   a attach   e edit   c barcode   D dimensions   i origin   t timer   l open with   w open all links   1-6 fold sections", nav, bookmarkAction, noteAction)
+		if m.isAttachmentSelected() {
+			hint += "   d detach"
+		}
+		if m.shownImgPath() != "" {
+			hint += "   z " + m.fit.Next().String() + "   F full screen   P keep for scrollback"
+		}
+		b.WriteString(ui.DimStyle.Render(hint))
+	}
+
+	return b.String()
+}
+
+// renderUsage shows when the part was bought and the time worked on it
+func (m *PartDetailModel) renderUsage(b *strings.Builder) {
+	if m.purchase == nil && m.labor == 0 && !m.timing {
+		return
+	}
+	if m.collapsed[sectionUsage] {
+		m.renderCollapsed(b, sectionUsage, 0)
+		return
+	}
+	if m.purchase != nil {
+		b.WriteString(m.fieldLine("Purchased", describePurchase(m.purchase)))
+	}
+	if m.timing {
+		b.WriteString(m.fieldLine("Worked", locale.Hours(m.labor)+" (timer running)"))
+	} else if m.labor > 0 {
+		b.WriteString(m.fieldLine("Worked", locale.Hours(m.labor)))
+	}
+}
+
+// noteCount is how many notes the notes section holds, attachments
+// included
+func (m *PartDetailModel) noteCount() int {
+	n := len(m.kb) + len(m.compat) + len(m.attachments)
+	if m.part.Notes != nil {
+		n++
+	}
+	if m.note != nil {
+		n++
+	}
+	return n
+}
+
+// renderDimensions lists the dimensions recorded for the part number
+func (m *PartDetailModel) renderDimensions(b *strings.Builder) {
+	if len(m.dims) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("Dimensions:"))
+	b.WriteString("\n")
+	labels, values := dimensionLines(m.dims)
+	for i := range labels {
+		b.WriteString(m.fieldLine(labels[i], values[i]))
+	}
+}
+
+// renderKB lists the knowledge base entries for the part, each title with
+// its body and source below it
+func (m *PartDetailModel) renderKB(b *strings.Builder) {
+	if len(m.kb) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("Known Issues:"))
+	b.WriteString("\n")
+	for _, e := range m.kb {
+		b.WriteString(ui.SelectedLabelStyle.Render(e.Title))
+		b.WriteString("\n")
+		if e.Body != "" {
+			b.WriteString(e.Body)
+			b.WriteString("\n")
+		}
+		if e.Source != "" {
+			b.WriteString(ui.DimStyle.Render("Source: " + e.Source))
+			b.WriteString("\n")
+		}
 	}
+}
 
+// renderCompat lists the community's fitment notes for the part, then its
+// aftermarket cross references, each with who contributed it
+func (m *PartDetailModel) renderCompat(b *strings.Builder) {
+	if len(m.compat) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("Fitment & Cross References:"))
+	b.WriteString("\n")
+	for _, n := range m.compat {
+		if n.Xref != "" {
+			b.WriteString(ui.SelectedLabelStyle.Render(strings.ToUpper(n.Brand) + " " + n.Xref))
+			b.WriteString("\n")
+		}
+		if n.Note != "" {
+			b.WriteString(n.Note)
+			b.WriteString("\n")
+		}
+		var credit []string
+		if n.Contributor != "" {
+			credit = append(credit, "From "+n.Contributor)
+		}
+		if n.Source != "" {
+			credit = append(credit, "Source: "+n.Source)
+		}
+		if len(credit) > 0 {
+			b.WriteString(ui.DimStyle.Render(strings.Join(credit, " · ")))
+			b.WriteString("\n")
+		}
+	}
+}
+
+// renderAttachments lists the files attached to the note, flagging any
+// that have moved since, followed by the path prompt when it's open
+func (m *PartDetailModel) renderAttachments(b *strings.Builder) {
+	if m.shownAttachments() > 0 {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("Attachments:"))
+		b.WriteString("\n")
+		missing := 0
+		for i, a := range m.attachments {
+			label := filepath.Base(a.path)
+			if i == m.cursor {
+				b.WriteString(ui.SelectedStyle.Render("> "))
+				b.WriteString(ui.SelectedLabelStyle.Render(label))
+			} else {
+				b.WriteString("  ")
+				b.WriteString(label)
+			}
+			b.WriteString(" ")
+			b.WriteString(ui.DimStyle.Render(filepath.Dir(a.path)))
+			if a.missing {
+				b.WriteString(ui.ErrorStyle.Render(" (missing)"))
+				missing++
+			}
+			b.WriteString("\n")
+		}
+		if missing > 0 {
+			b.WriteString(ui.ErrorStyle.Render(fmt.Sprintf("%d attached file(s) not found; d to detach", missing)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.attacher.active {
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("Attach file:"))
+		b.WriteString("\n")
+		b.WriteString(m.attacher.input.View())
+		b.WriteString("\n")
+		if m.attacher.err != "" {
+			b.WriteString(ui.ErrorStyle.Render(m.attacher.err))
+			b.WriteString("\n")
+		}
+	}
+}
+
+// renderBarcode draws the part number as a Code 128 barcode for scanning
+// at the parts counter, with the number printed beneath like a label.
+func renderBarcode(partNumber string) string {
+	modules, err := barcode.Code128(partNumber)
+	if err != nil {
+		return ui.ErrorStyle.Render(err.Error()) + "\n"
+	}
+	lines := barcode.Blocks(modules, 3)
+	width := lipgloss.Width(lines[0])
+	lines = append(lines, lipgloss.PlaceHorizontal(width, lipgloss.Center, partNumber))
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(ui.BarcodeStyle.Render(line))
+		b.WriteString("\n")
+	}
 	return b.String()
 }
 
+// renderPrices renders the supplier comparison table, cheapest first
+func (m *PartDetailModel) renderPrices() string {
+	var b strings.Builder
+
+	supplierCol := lipgloss.NewStyle().Width(12)
+	priceCol := lipgloss.NewStyle().Width(12)
+	stockCol := lipgloss.NewStyle().Width(7)
+	leadCol := lipgloss.NewStyle().Width(6)
+	weightCol := lipgloss.NewStyle().Width(9)
+
+	// Looked up prices say how available the part is rather than how many,
+	// and may give its weight
+	var weights bool
+	for _, p := range m.prices {
+		if p.Availability != nil {
+			stockCol = stockCol.Width(14)
+		}
+		weights = weights || p.WeightGrams != nil
+	}
+
+	updatedCol := lipgloss.NewStyle().Width(11)
+
+	header := "  " + supplierCol.Render("SUPPLIER") + priceCol.Render("PRICE") +
+		stockCol.Render("STOCK") + leadCol.Render("LEAD")
+	if weights {
+		header += weightCol.Render("WEIGHT")
+	}
+	if m.costs.Enabled() {
+		header += updatedCol.Render("UPDATED") + "LANDED"
+	} else {
+		header += "UPDATED"
+	}
+	b.WriteString(ui.DimStyle.Render(header))
+	b.WriteString("\n")
+
+	for i, p := range m.prices {
+		stock := "—"
+		if p.Stock != nil {
+			stock = fmt.Sprintf("%d", *p.Stock)
+		} else if p.Availability != nil {
+			stock = *p.Availability
+		}
+		lead := "—"
+		if p.LeadTimeDays != nil {
+			lead = fmt.Sprintf("%dd", *p.LeadTimeDays)
+		}
+		updated := locale.DateString(p.UpdatedAt)
+		name := supplierName(p.SupplierID)
+		if p.PartNumber != m.part.PartNumber {
+			name += "*"
+		}
+
+		row := supplierCol.Render(name) + priceCol.Render(locale.Price(p.Price, p.Currency)) +
+			stockCol.Render(stock) + leadCol.Render(lead)
+		if weights {
+			weight := "—"
+			if p.WeightGrams != nil {
+				weight = formatWeight(*p.WeightGrams)
+			}
+			row += weightCol.Render(weight)
+		}
+		if m.costs.Enabled() {
+			landed := "no " + strings.ToUpper(p.Currency) + " rate"
+			if amount, currency, ok := m.costs.Landed(p.SupplierID, p.Price, p.Currency); ok {
+				landed = locale.Price(amount, currency)
+			}
+			row += updatedCol.Render(updated) + landed
+		} else {
+			row += updated
+		}
+		if m.shownAttachments()+m.shownSubgroups()+i == m.cursor {
+			b.WriteString(ui.SelectedStyle.Render("> "))
+			b.WriteString(ui.SelectedLabelStyle.Render(row))
+		} else {
+			b.WriteString("  ")
+			b.WriteString(row)
+		}
+		b.WriteString("\n")
+	}
+
+	if m.part.ReplacementPartNumber != nil {
+		for _, p := range m.prices {
+			if p.PartNumber != m.part.PartNumber {
+				b.WriteString(ui.DimStyle.Render("  * price for replacement " + strings.ToUpper(*m.part.ReplacementPartNumber)))
+				b.WriteString("\n")
+				break
+			}
+		}
+	}
+	if m.costsErr != nil {
+		b.WriteString(ui.ErrorStyle.Render("  " + m.costsErr.Error()))
+		b.WriteString("\n")
+	} else if m.costs.Enabled() {
+		b.WriteString(ui.DimStyle.Render("  landed: " + m.costs.Describe()))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// quantityLine sets how many the catalog lists per vehicle against how
+// many of the number are bookmarked as needed and bought, as
+// "4 per vehicle — I need: 2 (have 1 in inventory)"
+func (m *PartDetailModel) quantityLine() string {
+	q := m.qty
+	if q == nil || q.Catalog == nil && q.Needed == 0 {
+		return ""
+	}
+	line := "—"
+	if q.Catalog != nil {
+		line = fmt.Sprintf("%d per vehicle", *q.Catalog) + m.localMark(db.FieldQuantity)
+	}
+	if q.Needed == 0 {
+		return line
+	}
+
+	have := "none in inventory"
+	if q.Have > 0 {
+		have = fmt.Sprintf("have %d in inventory", q.Have)
+	}
+	if get := q.ToGet(); get > 0 && q.Have > 0 {
+		have += fmt.Sprintf(", %d to get", get)
+	}
+	return line + ui.DimStyle.Render(" — I need: ") + fmt.Sprintf("%d", q.Needed) + ui.DimStyle.Render(" ("+have+")")
+}
+
+// formatWeight shows grams as g under a kilo and kg from one
+func formatWeight(grams int) string {
+	if grams < 1000 {
+		return fmt.Sprintf("%d g", grams)
+	}
+	return locale.Current().Number(float64(grams)/1000, 2) + " kg"
+}
+
 func (m *PartDetailModel) renderField(b *strings.Builder, label string, value *string) {
 	if value == nil {
 		return
@@ -456,11 +1701,65 @@ func (m *PartDetailModel) renderField(b *strings.Builder, label string, value *s
 	b.WriteString(m.fieldLine(label, strings.ToUpper(*value)))
 }
 
+// renderSpec shows the part's spec, flagging the vehicle attributes it
+// rules out
+func (m *PartDetailModel) renderSpec(b *strings.Builder) {
+	if m.part.Spec == nil {
+		return
+	}
+	value := strings.ToUpper(*m.part.Spec)
+	if conflicts := db.Conflicts(m.attrs, m.vehicle.Attributes()); len(conflicts) > 0 {
+		value += "  " + ui.ErrorStyle.Render("not for your "+describeSpec(conflicts))
+	}
+	b.WriteString(m.fieldLine("Spec", value))
+}
+
+// renderReplacement shows the replacement part number. When it differs from
+// this part's number by only a few characters, both are shown with the
+// differences highlighted, so a mistyped number stands out.
+func (m *PartDetailModel) renderReplacement(b *strings.Builder) {
+	if m.part.ReplacementPartNumber == nil {
+		return
+	}
+	current := strings.ToUpper(m.part.PartNumber)
+	replacement := strings.ToUpper(*m.part.ReplacementPartNumber)
+
+	before, after, edits := ui.CharDiff(current, replacement)
+	if edits == 0 || edits > maxHighlightedEdits {
+		b.WriteString(m.fieldLine("Replaces", replacement))
+		return
+	}
+	b.WriteString(m.fieldLine("Replaces", after))
+	b.WriteString(m.fieldLine("", before+ui.DimStyle.Render(" this part")))
+}
+
+// renderCounterpart offers the other side of a handed part, since they're
+// usually replaced together
+func (m *PartDetailModel) renderCounterpart(b *strings.Builder) {
+	if m.counterpart == nil {
+		return
+	}
+	label := "Other Side"
+	if m.counterpart.Description != nil {
+		label += " " + db.Hand(*m.counterpart.Description)
+	}
+	b.WriteString(m.fieldLine(label, strings.ToUpper(m.counterpart.PartNumber)))
+	b.WriteString(m.fieldLine("", ui.DimStyle.Render("o open   O shortlist both")))
+}
+
 func (m *PartDetailModel) fieldLine(label, value string) string {
 	labelStyle := lipgloss.NewStyle().Width(16).Foreground(ui.ColorDim)
 	return labelStyle.Render(label) + value + "\n"
 }
 
+// localMark flags a field whose value comes from a local override
+func (m *PartDetailModel) localMark(field string) string {
+	if m.overridden[field] {
+		return ui.DimStyle.Render(" (local)")
+	}
+	return ""
+}
+
 func (m *PartDetailModel) ImageID() uint32 {
 	if m.img != nil {
 		return m.img.ID()
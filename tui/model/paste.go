@@ -0,0 +1,324 @@
+package model
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pasteRow is one line of a pasted parts list, resolved against the
+// catalog. err says why the row can't be added.
+type pasteRow struct {
+	line  int
+	input string
+	qty   int
+	note  string
+	match db.PartNumberMatch
+	err   string
+}
+
+// PasteModel takes a parts list pasted as "part_number, qty, note" lines,
+// such as a column copied from a spreadsheet, and previews what each line
+// matched before the list goes on the shortlist. Lines that can't be added
+// are flagged with the reason.
+type PasteModel struct {
+	db      *db.DB
+	input   textarea.Model
+	rows    []pasteRow
+	preview bool // showing the checked rows rather than the text
+	cursor  int
+	status  string
+}
+
+func NewPasteModel(database *db.DB) *PasteModel {
+	ta := textarea.New()
+	ta.Placeholder = "MD050125, 1, timing belt job\nMD329470, 2"
+	ta.CharLimit = 0
+	ta.MaxHeight = 999
+	ta.Focus()
+	return &PasteModel{db: database, input: ta}
+}
+
+func (m *PasteModel) Update(msg tea.Msg) (*PasteModel, tea.Cmd, *Screen) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.preview {
+			return m.updatePreview(msg)
+		}
+		if ui.IsSaveNote(msg) {
+			m.check()
+			return m, nil, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd, nil
+}
+
+// updatePreview handles keys on the checked rows: enter adds the good ones,
+// esc goes back to the text to fix the flagged ones
+func (m *PasteModel) updatePreview(msg tea.KeyMsg) (*PasteModel, tea.Cmd, *Screen) {
+	if cursor, ok := ui.MoveCursor(msg, m.cursor, len(m.rows), 10, false); ok {
+		m.cursor = cursor
+		return m, nil, nil
+	}
+	switch {
+	case ui.IsBack(msg), ui.IsEdit(msg):
+		m.preview = false
+		m.status = ""
+		return m, m.input.Focus(), nil
+	case ui.IsEnter(msg):
+		return m, m.add(), nil
+	}
+	return m, nil, nil
+}
+
+// check parses and resolves every line, then shows the result
+func (m *PasteModel) check() {
+	m.rows = nil
+	m.cursor = 0
+	m.status = ""
+	for i, line := range strings.Split(m.input.Value(), "\n") {
+		row, ok := parsePasteLine(line)
+		if !ok {
+			continue
+		}
+		row.line = i + 1
+		if row.err == "" {
+			lookup, err := m.db.LookupPartNumber(row.input)
+			switch {
+			case err != nil:
+				row.err = fmt.Sprintf("lookup failed: %v", err)
+			case !lookup.Found:
+				row.err = lookup.Hint()
+			default:
+				row.match = lookup.Match
+			}
+		}
+		m.rows = append(m.rows, row)
+	}
+	if len(m.rows) == 0 {
+		m.status = "Nothing to check; paste part_number, qty, note lines"
+		return
+	}
+	m.preview = true
+	m.input.Blur()
+}
+
+// parsePasteLine reads "part_number, qty, note" from a line, or tab
+// separated as spreadsheets copy. The quantity defaults to 1 and anything
+// after it is the note. ok is false for blank lines, comments and a header
+// row, whose first field has no digits.
+func parsePasteLine(line string) (row pasteRow, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return row, false
+	}
+
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	if strings.Contains(line, "\t") && !strings.Contains(line, ",") {
+		r.Comma = '\t'
+	}
+	fields, err := r.Read()
+	if err != nil || len(fields) == 0 {
+		fields = []string{line}
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if !strings.ContainsFunc(fields[0], unicode.IsDigit) {
+		return row, false
+	}
+
+	row = pasteRow{input: fields[0], qty: 1}
+	if len(fields) > 1 && fields[1] != "" {
+		qty, err := strconv.Atoi(fields[1])
+		if err != nil || qty < 1 {
+			row.err = fmt.Sprintf("quantity %q isn't a whole number", fields[1])
+		}
+		row.qty = qty
+	}
+	if len(fields) > 2 {
+		row.note = strings.Join(fields[2:], ", ")
+	}
+	return row, true
+}
+
+func (m *PasteModel) counts() (ready, flagged int) {
+	for _, row := range m.rows {
+		if row.err == "" {
+			ready++
+		} else {
+			flagged++
+		}
+	}
+	return ready, flagged
+}
+
+// add puts the rows without errors on the shortlist
+func (m *PasteModel) add() tea.Cmd {
+	var items []shortlistItem
+	for _, row := range m.rows {
+		if row.err != "" {
+			continue
+		}
+		desc := ""
+		if row.match.Description != nil {
+			desc = *row.match.Description
+		}
+		items = append(items, shortlistItem{
+			partID:      row.match.PartID,
+			partNumber:  row.match.PartNumber,
+			description: desc,
+			qty:         row.qty,
+			note:        row.note,
+		})
+	}
+	if len(items) == 0 {
+		m.status = "No lines to add; e to fix the flagged ones"
+		return nil
+	}
+
+	_, flagged := m.counts()
+	m.status = fmt.Sprintf("Added %d lines to the shortlist", len(items))
+	if flagged > 0 {
+		m.status += fmt.Sprintf("; %d flagged lines left out", flagged)
+	}
+	return func() tea.Msg { return shortlistItemsMsg{items: items} }
+}
+
+func (m *PasteModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *PasteModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("PASTE LIST"))
+	lines = append(lines, "")
+	lines = append(lines, "One part per line:")
+	lines = append(lines, "number, qty, note")
+	lines = append(lines, "")
+	if m.preview {
+		ready, flagged := m.counts()
+		lines = append(lines, fmt.Sprintf("%d ready", ready))
+		if flagged > 0 {
+			lines = append(lines, ui.ErrorStyle.Render(fmt.Sprintf("%d flagged", flagged)))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, ui.DimStyle.Render("Commas or tabs"))
+	lines = append(lines, ui.DimStyle.Render("between columns"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *PasteModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	if !m.preview {
+		b.WriteString(ui.HeaderStyle.Render("PASTE PARTS"))
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+		b.WriteString("\n\n")
+		m.input.SetWidth(max(width-2, 20))
+		m.input.SetHeight(max(height-8, 5))
+		b.WriteString(m.input.View())
+		b.WriteString("\n\n")
+		if m.status != "" {
+			b.WriteString(m.status)
+			b.WriteString("\n")
+		}
+		b.WriteString(ui.DimStyle.Render("ctrl+s check lines   esc back"))
+		return b.String()
+	}
+
+	b.WriteString(ui.HeaderStyle.Render("CHECK BEFORE ADDING"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	maxRows := max(height-7, 5)
+	start := max(0, min(m.cursor-maxRows/2, len(m.rows)-maxRows))
+	end := min(start+maxRows, len(m.rows))
+	labelStyle := lipgloss.NewStyle().Width(18)
+	for i := start; i < end; i++ {
+		row := m.rows[i]
+		mark := ui.SelectedStyle.Render("✓ ")
+		label := row.match.PartNumber
+		hint := fmt.Sprintf("x%d  %s", row.qty, strings.ToUpper(scanMatchHint(row.input, row.match)))
+		if row.note != "" {
+			hint += " - " + row.note
+		}
+		if row.err != "" {
+			mark = ui.ErrorStyle.Render("✗ ")
+			label = row.input
+			hint = fmt.Sprintf("line %d: %s", row.line, row.err)
+		}
+		hint = truncateText(hint, max(width-22, 10))
+		if row.err != "" {
+			hint = ui.ErrorStyle.Render(hint)
+		} else {
+			hint = ui.DimStyle.Render(hint)
+		}
+
+		if i == m.cursor {
+			b.WriteString(ui.SelectedStyle.Render("> ") + mark + ui.SelectedLabelStyle.Render(labelStyle.Render(label)) + hint + "\n")
+		} else {
+			b.WriteString("  " + mark + ui.NormalLabelStyle.Render(labelStyle.Render(label)) + hint + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(m.status)
+		b.WriteString("\n")
+	}
+	ready, _ := m.counts()
+	b.WriteString(ui.DimStyle.Render(fmt.Sprintf("↑↓ select   enter add %d to shortlist   e edit lines   esc back to text", ready)))
+
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PNCModel completes a partial PNC to the codes starting with it, then lists
+// the parts carrying the chosen one.
+type PNCModel struct {
+	db      *db.DB
+	input   textinput.Model
+	matches []db.PNCMatch
+	cursor  int
+	visible int // rows shown at once, set by View
+
+	// Parts of the chosen PNC; typing goes back to the codes
+	chosen *db.PNCMatch
+	parts  []db.PNCPart
+}
+
+func NewPNCModel(database *db.DB) *PNCModel {
+	ti := textinput.New()
+	ti.Placeholder = "PNC, e.g. 11302..."
+	ti.Focus()
+	ti.CharLimit = 20
+	ti.Width = 30
+
+	return &PNCModel{
+		db:      database,
+		input:   ti,
+		visible: 20,
+	}
+}
+
+func (m *PNCModel) Update(msg tea.Msg) (*PNCModel, tea.Cmd, *Screen) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		rows := len(m.matches)
+		if m.chosen != nil {
+			rows = len(m.parts)
+		}
+		// Letter keys type into the input, so only arrow, page and ctrl keys navigate
+		if cursor, ok := ui.MoveCursor(msg, m.cursor, min(rows, m.visible), m.visible, true); ok {
+			m.cursor = cursor
+			return m, nil, nil
+		}
+		if ui.IsEnter(msg) && m.chosen != nil && len(m.parts) > 0 {
+			s := PartDetailScreen(m.parts[m.cursor].PartID, false)
+			return m, nil, &s
+		}
+		if ui.IsEnter(msg) && m.chosen == nil && len(m.matches) > 0 {
+			return m, nil, m.choose(m.matches[m.cursor])
+		}
+	}
+
+	prevValue := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+
+	// Completions come from one indexed query, so run it on every keystroke
+	if m.input.Value() != prevValue {
+		m.matches, _ = m.db.FindPNCs(m.input.Value(), 50)
+		m.chosen = nil
+		m.parts = nil
+		m.cursor = 0
+	}
+
+	return m, cmd, nil
+}
+
+// choose lists the parts carrying match, opening the part straight away when
+// there is only one
+func (m *PNCModel) choose(match db.PNCMatch) *Screen {
+	parts, _ := m.db.GetPartsForPNC(match.PNC)
+	if len(parts) == 1 {
+		s := PartDetailScreen(parts[0].PartID, false)
+		return &s
+	}
+	m.chosen = &match
+	m.parts = parts
+	m.cursor = 0
+	return nil
+}
+
+func (m *PNCModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *PNCModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("PNC LOOKUP"))
+	lines = append(lines, "")
+	lines = append(lines, "Type the start of a")
+	lines = append(lines, "parts catalog number")
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("The same PNC names a"))
+	lines = append(lines, ui.DimStyle.Render("part across diagrams"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *PNCModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Input box
+	inputBox := ui.BoxStyle.Render(m.input.View())
+	b.WriteString(inputBox)
+	b.WriteString("\n\n")
+
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	maxResults := height - 8
+	if maxResults < 5 {
+		maxResults = 5
+	}
+	if maxResults > 20 {
+		maxResults = 20
+	}
+	m.visible = maxResults
+
+	query := strings.TrimSpace(m.input.Value())
+	switch {
+	case query == "":
+		b.WriteString(ui.DimStyle.Render("Start typing a PNC"))
+	case m.chosen != nil:
+		b.WriteString(ui.HeaderStyle.Render("PNC " + m.chosen.PNC))
+		b.WriteString("\n\n")
+		for i, p := range m.parts {
+			if i >= maxResults {
+				break
+			}
+			hint := fmt.Sprintf("%s > %s", p.GroupName, p.DiagramName)
+			if p.Description != nil {
+				hint = *p.Description + " - " + hint
+			}
+			b.WriteString(m.row(i, p.PartNumber, 18, hint, width))
+		}
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%d parts", len(m.parts))))
+	case len(m.matches) == 0:
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("No PNCs start with \"%s\"", query)))
+	default:
+		for i, match := range m.matches {
+			if i >= maxResults {
+				break
+			}
+			hint := fmt.Sprintf("%d parts", match.Parts)
+			if match.Diagrams > 1 {
+				hint += fmt.Sprintf(" in %d diagrams", match.Diagrams)
+			}
+			if match.Description != nil {
+				hint = *match.Description + " - " + hint
+			}
+			b.WriteString(m.row(i, match.PNC, 10, hint, width))
+		}
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%d matches", len(m.matches))))
+	}
+
+	b.WriteString("\n\n")
+	if m.chosen != nil {
+		b.WriteString(ui.DimStyle.Render("↑↓ select   enter open part   type to change PNC"))
+	} else {
+		b.WriteString(ui.DimStyle.Render("↑↓ select   enter list parts"))
+	}
+
+	return b.String()
+}
+
+// row renders one selectable line: the label in a column of labelWidth
+// cells, then a dim hint cut to fit width
+func (m *PNCModel) row(i int, label string, labelWidth int, hint string, width int) string {
+	labelStyle := lipgloss.NewStyle().Width(labelWidth)
+	hint = truncateText(strings.ToUpper(hint), max(width-labelWidth-2, 10))
+	if i == m.cursor {
+		return ui.SelectedStyle.Render("> ") + ui.SelectedLabelStyle.Render(labelStyle.Render(label)) + ui.DimStyle.Render(hint) + "\n"
+	}
+	return "  " + ui.NormalLabelStyle.Render(labelStyle.Render(label)) + ui.DimStyle.Render(hint) + "\n"
+}
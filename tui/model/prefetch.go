@@ -0,0 +1,197 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultPrefetchDepth is how many parts above and below the subgroup
+// cursor are prefetched when DELICA_PREFETCH_DEPTH isn't set.
+const defaultPrefetchDepth = 2
+
+// prefetchDelay is how long the cursor has to rest before prefetching
+// starts, so holding an arrow key doesn't queue work for every row.
+const prefetchDelay = 150 * time.Millisecond
+
+func prefetchDepth() int {
+	if v := os.Getenv("DELICA_PREFETCH_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultPrefetchDepth
+}
+
+// partData is the catalog side of the part detail screen. User data such
+// as the bookmark and note is always read when the screen opens.
+type partData struct {
+	part            *db.PartWithDiagram
+	diagram         *db.Diagram
+	group           *db.Group
+	subgroup        *db.Subgroup
+	subgroupDiagram *db.Diagram            // the subgroup's own diagram, when the part is drawn on another
+	subgroups       []db.SubgroupWithGroup // every subgroup listing the part number
+	prices          []db.Price             // for the part number and its replacement
+	replacementID   int
+	hasReplacement  bool
+	counterpart     *db.PartWithDiagram
+}
+
+func loadPartData(database *db.DB, partID int) *partData {
+	d := &partData{}
+	d.part, _ = database.GetPart(partID)
+	if d.part == nil {
+		return d
+	}
+
+	d.diagram, _ = database.GetDiagram(d.part.DiagramID)
+	d.group, _ = database.GetGroup(d.part.GroupID)
+	if d.part.SubgroupID != nil {
+		d.subgroup, _ = database.GetSubgroup(*d.part.SubgroupID)
+	} else if d.diagram != nil && d.diagram.SubgroupID != nil {
+		d.subgroup, _ = database.GetSubgroup(*d.diagram.SubgroupID)
+	}
+	if d.subgroup != nil {
+		if main, _ := database.GetDiagramForSubgroup(d.subgroup.ID); main != nil && main.ID != d.part.DiagramID && main.ImagePath != nil {
+			d.subgroupDiagram = main
+		}
+	}
+	d.subgroups, _ = database.GetSubgroupsForPartNumber(d.part.PartNumber)
+
+	numbers := []string{d.part.PartNumber}
+	if d.part.ReplacementPartNumber != nil {
+		numbers = append(numbers, *d.part.ReplacementPartNumber)
+		d.replacementID, d.hasReplacement, _ = database.GetReplacementPartID(partID)
+	}
+	d.prices, _ = database.GetPrices(numbers...)
+	d.counterpart, _ = database.GetOppositeHandPart(partID)
+	return d
+}
+
+// prefetcher loads part detail data and diagrams for the parts around the
+// subgroup cursor in the background, so opening one is instant. Leaving the
+// subgroup screen cancels any prefetch under way and drops unused data,
+// which keeps it from going stale after an edit.
+type prefetcher struct {
+	db       *db.DB
+	dataPath string
+	depth    int
+
+	// diagrams holds fit-pane diagrams, which the subgroup screen shows at
+	// the same size. It's the cache search previews use too.
+	diagrams *imageCache
+
+	mu    sync.Mutex
+	gen   int // bumped by cancel; runs from older generations stop
+	parts map[int]*partData
+}
+
+func newPrefetcher(database *db.DB, dataPath string, images *imageCache) *prefetcher {
+	return &prefetcher{
+		db:       database,
+		dataPath: dataPath,
+		depth:    prefetchDepth(),
+		diagrams: images,
+		parts:    make(map[int]*partData),
+	}
+}
+
+// prefetchMsg starts a prefetch around a cursor position once the cursor
+// has rested there.
+type prefetchMsg struct {
+	seq int
+}
+
+// schedule waits out prefetchDelay before asking for a prefetch; seq lets
+// the screen ignore requests the cursor has moved past.
+func (p *prefetcher) schedule(seq int) tea.Cmd {
+	if p.depth == 0 {
+		return nil
+	}
+	return tea.Tick(prefetchDelay, func(time.Time) tea.Msg {
+		return prefetchMsg{seq: seq}
+	})
+}
+
+// around returns the part IDs within depth of index, nearest first.
+func (p *prefetcher) around(ids []int, index int) []int {
+	var near []int
+	for d := 1; d <= p.depth; d++ {
+		if index+d < len(ids) {
+			near = append(near, ids[index+d])
+		}
+		if index-d >= 0 {
+			near = append(near, ids[index-d])
+		}
+	}
+	return near
+}
+
+// run loads each part not already prefetched, one at a time so the screen's
+// own queries aren't held up for long.
+func (p *prefetcher) run(ids []int) tea.Cmd {
+	if len(ids) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	gen := p.gen
+	p.mu.Unlock()
+
+	return func() tea.Msg {
+		for _, id := range ids {
+			p.mu.Lock()
+			stale, have := p.gen != gen, p.parts[id] != nil
+			p.mu.Unlock()
+			if stale {
+				return nil
+			}
+			if have {
+				continue
+			}
+
+			data := loadPartData(p.db, id)
+			if data.part != nil && data.part.ImagePath != nil {
+				p.diagrams.load(p.diagramPath(*data.part.ImagePath), diagramWidthCells, diagramHeightCells)
+			}
+
+			p.mu.Lock()
+			if p.gen == gen {
+				p.parts[id] = data
+			}
+			p.mu.Unlock()
+		}
+		return nil
+	}
+}
+
+// take returns and forgets the prefetched data for a part, loading it now
+// if it wasn't prefetched.
+func (p *prefetcher) take(partID int) *partData {
+	p.mu.Lock()
+	data := p.parts[partID]
+	delete(p.parts, partID)
+	p.mu.Unlock()
+	if data != nil {
+		return data
+	}
+	return loadPartData(p.db, partID)
+}
+
+// cancel stops any prefetch under way and drops what it had loaded.
+func (p *prefetcher) cancel() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gen++
+	clear(p.parts)
+}
+
+func (p *prefetcher) diagramPath(imagePath string) string {
+	return filepath.Join(p.dataPath, imagePath)
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/pricing"
+	"github.com/mshick/delica-space-gear-parts/tui/supplier"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pricesFetchedMsg brings back a price lookup for a part. manual is set
+// when p asked for it, so the outcome is announced.
+type pricesFetchedMsg struct {
+	partID  int
+	results []pricing.Result
+	manual  bool
+}
+
+// lookupPrices looks the part up on the vendor sites in the background.
+// Opening the part looks up only the suppliers whose price is older than
+// the TTL, and quietly; p looks every one up again and says how it went.
+func (m *PartDetailModel) lookupPrices(manual bool) tea.Cmd {
+	if m.part == nil || len(m.lookingUp) > 0 {
+		return nil
+	}
+	fail := func(text string) tea.Cmd {
+		if !manual {
+			return nil
+		}
+		return func() tea.Msg { return toastMsg{text: text, isError: true} }
+	}
+
+	// Vendors sell the current number, as the supplier links use
+	partNumber := m.part.PartNumber
+	if m.part.ReplacementPartNumber != nil {
+		partNumber = *m.part.ReplacementPartNumber
+	}
+	sources := pricing.Sources()
+	if !manual {
+		ttl, err := pricing.TTLFromEnv()
+		if err != nil {
+			return nil
+		}
+		sources = pricing.Stale(m.prices, partNumber, ttl, time.Now().UTC())
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	if !netutil.Online() {
+		return fail("Offline: prices can't be looked up until the connection is back")
+	}
+	client, err := netutil.Default()
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	m.lookingUp = make([]string, len(sources))
+	for i, s := range sources {
+		m.lookingUp[i] = s.Name()
+	}
+	database, partID := m.db, m.partID
+	return func() tea.Msg {
+		results := pricing.Refresh(context.Background(), database, client, partNumber, sources)
+		return pricesFetchedMsg{partID: partID, results: results, manual: manual}
+	}
+}
+
+// handlePricesFetched shows the prices a lookup saved, and for p, what it
+// found
+func (m *PartDetailModel) handlePricesFetched(msg pricesFetchedMsg) tea.Cmd {
+	m.lookingUp = nil
+	numbers := []string{m.part.PartNumber}
+	if m.part.ReplacementPartNumber != nil {
+		numbers = append(numbers, *m.part.ReplacementPartNumber)
+	}
+	m.prices, _ = m.db.GetPrices(numbers...)
+	if len(m.prices) > 0 && !m.costs.Enabled() && m.costsErr == nil {
+		m.costs, m.costsErr = supplier.CostsFromEnv()
+	}
+	if !msg.manual {
+		return nil
+	}
+
+	var found, failed []string
+	for _, r := range msg.results {
+		if r.Err != nil {
+			failed = append(failed, r.Err.Error())
+		} else {
+			found = append(found, r.Supplier.Name())
+		}
+	}
+	var text string
+	switch {
+	case len(failed) == 0:
+		text = "Prices updated from " + strings.Join(found, " and ")
+	case len(found) == 0:
+		text = "No prices found: " + strings.Join(failed, "; ")
+	default:
+		text = fmt.Sprintf("Prices updated from %s; %s", strings.Join(found, " and "), strings.Join(failed, "; "))
+	}
+	return func() tea.Msg { return toastMsg{text: text, isError: len(found) == 0} }
+}
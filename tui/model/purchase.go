@@ -0,0 +1,103 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// purchasePrompt asks when a part was bought, how many and for how much,
+// for the aging report and the part's quantities. Clearing the input forgets the purchase.
+type purchasePrompt struct {
+	active bool
+	input  textinput.Model
+	err    string
+}
+
+func newPurchasePrompt() purchasePrompt {
+	ti := textinput.New()
+	ti.Placeholder = "2024-03-01 45.00 NZD x2"
+	ti.CharLimit = 40
+	ti.Width = 40
+	ti.Prompt = ""
+	return purchasePrompt{input: ti}
+}
+
+// open starts from the recorded purchase, or today's date
+func (p *purchasePrompt) open(current *db.Purchase) tea.Cmd {
+	p.active = true
+	p.err = ""
+	value := time.Now().Format("2006-01-02") + " "
+	if current != nil {
+		value = current.PurchasedOn
+		if current.Cost != nil {
+			value += " " + strconv.FormatFloat(*current.Cost, 'f', 2, 64)
+		}
+		if current.Currency != "" {
+			value += " " + current.Currency
+		}
+		if current.Qty > 1 {
+			value += " x" + strconv.Itoa(current.Qty)
+		}
+	}
+	p.input.SetValue(value)
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *purchasePrompt) close() {
+	p.active = false
+	p.input.Blur()
+}
+
+// update handles a message while the prompt is open. entered is true once
+// a purchase has been entered, which the caller saves: purchase is what was
+// entered, or nil to forget the purchase.
+func (p *purchasePrompt) update(msg tea.Msg, partID int) (cmd tea.Cmd, purchase *db.Purchase, entered bool) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			p.close()
+			return nil, nil, false
+		case ui.IsEnter(msg):
+			fields := strings.Fields(p.input.Value())
+			if len(fields) > 0 {
+				parsed, ok, err := db.ParsePurchase(fields)
+				switch {
+				case err != nil:
+					p.err = err.Error()
+					return nil, nil, false
+				case !ok || fields[0] != parsed.PurchasedOn:
+					p.err = "start with the date, like 2024-03-01"
+					return nil, nil, false
+				}
+				parsed.PartID = partID
+				purchase = &parsed
+			}
+			p.close()
+			return nil, purchase, true
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	return cmd, nil, false
+}
+
+// describePurchase summarizes a purchase for the part's fields: the date,
+// how many if more than one, and the cost if it's known
+func describePurchase(p *db.Purchase) string {
+	s := locale.DateString(p.PurchasedOn)
+	if p.Qty > 1 {
+		s = strconv.Itoa(p.Qty) + " on " + s
+	}
+	if p.Cost != nil {
+		s += " for " + strings.TrimSpace(locale.Price(*p.Cost, p.Currency))
+	}
+	return s
+}
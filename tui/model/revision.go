@@ -0,0 +1,32 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long each diagram revision stays up while blinking between them
+const revisionBlinkInterval = 700 * time.Millisecond
+
+type revisionBlinkMsg struct {
+	seq int
+}
+
+// previousRevisionPath returns where sync keeps the image a diagram had
+// before its last re-scrape replaced it, or "" if it was never replaced.
+func previousRevisionPath(imgPath string) string {
+	path := filepath.Join(filepath.Dir(imgPath), "previous", filepath.Base(imgPath))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func blinkRevision(seq int) tea.Cmd {
+	return tea.Tick(revisionBlinkInterval, func(time.Time) tea.Msg {
+		return revisionBlinkMsg{seq: seq}
+	})
+}
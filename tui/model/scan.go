@@ -0,0 +1,340 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// scanLine is one part number entered on the scan screen. Entering a part
+// again counts it rather than listing it twice.
+type scanLine struct {
+	input  string
+	lookup db.PartNumberLookup
+	count  int
+}
+
+// ScanModel takes part numbers one per line, typed or from a barcode
+// scanner that ends each code with enter, and resolves each against the
+// catalog as it's entered. The batch can then be bookmarked, which stands in
+// for adding it to the parts shelf, or put on the shortlist to order.
+type ScanModel struct {
+	db     *db.DB
+	writes *writeQueue
+	input  textinput.Model
+	lines  []scanLine
+	cursor int
+	status string
+
+	// What the line being typed resolves to so far
+	preview db.PartNumberLookup
+}
+
+func NewScanModel(database *db.DB, writes *writeQueue) *ScanModel {
+	ti := textinput.New()
+	ti.Placeholder = "Part number, e.g. MD050125"
+	ti.Focus()
+	ti.CharLimit = 40
+	ti.Width = 30
+
+	return &ScanModel{
+		db:     database,
+		writes: writes,
+		input:  ti,
+	}
+}
+
+func (m *ScanModel) Update(msg tea.Msg) (*ScanModel, tea.Cmd, *Screen) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case userDataWrittenMsg:
+		if msg.kind == writeBookmark && msg.err != nil {
+			m.status = fmt.Sprintf("Bookmarks not saved: %v", msg.err)
+		}
+		return m, nil, nil
+
+	case tea.KeyMsg:
+		// Letter keys type into the input, so only arrow, page and ctrl keys navigate
+		if cursor, ok := ui.MoveCursor(msg, m.cursor, len(m.lines), 10, true); ok {
+			m.cursor = cursor
+			return m, nil, nil
+		}
+		switch {
+		case ui.IsEnter(msg):
+			m.enter()
+			return m, nil, nil
+		case msg.Type == tea.KeyTab && len(m.preview.Suggestions) > 0:
+			// Take the number the line was likely meant as
+			m.input.SetValue(m.preview.Suggestions[0])
+			m.input.CursorEnd()
+			m.preview, _ = m.db.LookupPartNumber(m.input.Value())
+			return m, nil, nil
+		case msg.Type == tea.KeyBackspace && m.input.Value() == "" && len(m.lines) > 0:
+			m.lines = append(m.lines[:m.cursor], m.lines[m.cursor+1:]...)
+			m.cursor = max(0, min(m.cursor, len(m.lines)-1))
+			return m, nil, nil
+		case ui.IsBookmarkAll(msg):
+			return m, m.bookmarkAll(), nil
+		case ui.IsShortlistAll(msg):
+			return m, m.shortlistAll(), nil
+		}
+	}
+
+	prevValue := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+
+	if m.input.Value() != prevValue {
+		m.preview, _ = m.db.LookupPartNumber(m.input.Value())
+	}
+
+	return m, cmd, nil
+}
+
+// enter resolves the typed part number and lists it, or counts it again if
+// it's listed already
+func (m *ScanModel) enter() {
+	input := strings.TrimSpace(m.input.Value())
+	m.input.SetValue("")
+	m.preview = db.PartNumberLookup{}
+	if input == "" {
+		return
+	}
+
+	lookup, err := m.db.LookupPartNumber(input)
+	if err != nil {
+		m.status = fmt.Sprintf("Lookup failed: %v", err)
+		return
+	}
+	for i, line := range m.lines {
+		same := line.lookup.Found && lookup.Found && line.lookup.Match.PartID == lookup.Match.PartID
+		if same || !line.lookup.Found && !lookup.Found && db.NormalizePartNumber(line.input) == db.NormalizePartNumber(input) {
+			m.lines[i].count++
+			m.cursor = i
+			m.status = ""
+			return
+		}
+	}
+	m.lines = append(m.lines, scanLine{input: input, lookup: lookup, count: 1})
+	m.cursor = len(m.lines) - 1
+	m.status = ""
+}
+
+// found returns the parts the batch resolved to, in the order entered
+func (m *ScanModel) found() []*db.PartWithDiagram {
+	var parts []*db.PartWithDiagram
+	for _, line := range m.lines {
+		if !line.lookup.Found {
+			continue
+		}
+		if part, err := m.db.GetPart(line.lookup.Match.PartID); err == nil && part != nil {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// bookmarkAll bookmarks every part found that isn't bookmarked yet, in one
+// queued write
+func (m *ScanModel) bookmarkAll() tea.Cmd {
+	var ids []int
+	var events []webhook.Event
+	for _, part := range m.found() {
+		if bookmarked, _ := m.db.IsBookmarked(part.ID); bookmarked {
+			continue
+		}
+		desc := ""
+		if part.Description != nil {
+			desc = *part.Description
+		}
+		ids = append(ids, part.ID)
+		events = append(events, webhook.Event{Kind: "bookmark", Action: "add", PartID: part.ID, PartNumber: part.PartNumber, Description: desc, Qty: 1})
+	}
+	if len(ids) == 0 {
+		m.status = "Nothing new to bookmark"
+		return nil
+	}
+
+	m.status = fmt.Sprintf("Bookmarked %d parts", len(ids))
+	database := m.db
+	return m.writes.enqueue(0, writeBookmark, 0, func() error {
+		for _, id := range ids {
+			if err := database.AddBookmark(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, events...)
+}
+
+// shortlistAll puts every part found on the shortlist, to order from
+func (m *ScanModel) shortlistAll() tea.Cmd {
+	parts := m.found()
+	if len(parts) == 0 {
+		m.status = "No parts found to shortlist"
+		return nil
+	}
+	m.status = ""
+	return func() tea.Msg { return shortlistAddMsg{parts: parts} }
+}
+
+func (m *ScanModel) counts() (found, missing int) {
+	for _, line := range m.lines {
+		if line.lookup.Found {
+			found++
+		} else {
+			missing++
+		}
+	}
+	return found, missing
+}
+
+func (m *ScanModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *ScanModel) renderLeftPane(height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("BATCH SCAN"))
+	lines = append(lines, "")
+	lines = append(lines, "Type or scan one part")
+	lines = append(lines, "number per line")
+	lines = append(lines, "")
+	found, missing := m.counts()
+	lines = append(lines, fmt.Sprintf("%d found", found))
+	if missing > 0 {
+		lines = append(lines, ui.ErrorStyle.Render(fmt.Sprintf("%d not found", missing)))
+	}
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Dashes and spaces"))
+	lines = append(lines, ui.DimStyle.Render("don't matter"))
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *ScanModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Input box
+	inputBox := ui.BoxStyle.Render(m.input.View())
+	b.WriteString(inputBox)
+	b.WriteString("\n")
+
+	// What the line being typed resolves to
+	switch {
+	case strings.TrimSpace(m.input.Value()) == "":
+		b.WriteString(ui.DimStyle.Render("enter adds the line"))
+	case m.preview.Found:
+		b.WriteString(ui.DimStyle.Render(truncateText(scanMatchHint(m.input.Value(), m.preview.Match), width)))
+	case m.preview.Check.Malformed:
+		b.WriteString(ui.DimStyle.Render("No match yet"))
+	case len(m.preview.Suggestions) > 0:
+		b.WriteString(ui.ErrorStyle.Render(truncateText(m.preview.Hint()+"  tab takes it", width)))
+	default:
+		b.WriteString(ui.DimStyle.Render(truncateText(m.preview.Hint(), width)))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	maxRows := height - 9
+	if maxRows < 5 {
+		maxRows = 5
+	}
+
+	if len(m.lines) == 0 {
+		b.WriteString(ui.DimStyle.Render("Nothing scanned yet"))
+		b.WriteString("\n")
+	}
+	start := max(0, min(m.cursor-maxRows/2, len(m.lines)-maxRows))
+	end := min(start+maxRows, len(m.lines))
+	labelStyle := lipgloss.NewStyle().Width(18)
+	for i := start; i < end; i++ {
+		line := m.lines[i]
+		label := line.input
+		hint := line.lookup.Hint()
+		if line.lookup.Found {
+			label = line.lookup.Match.PartNumber
+			hint = scanMatchHint(line.input, line.lookup.Match)
+		}
+		hint = strings.ToUpper(hint)
+		if line.count > 1 {
+			hint = fmt.Sprintf("x%d  %s", line.count, hint)
+		}
+		hint = truncateText(hint, max(width-22, 10))
+
+		mark := ui.SelectedStyle.Render("✓ ")
+		if !line.lookup.Found {
+			mark = ui.ErrorStyle.Render("✗ ")
+		}
+		if i == m.cursor {
+			b.WriteString(ui.SelectedStyle.Render("> ") + mark + ui.SelectedLabelStyle.Render(labelStyle.Render(label)) + ui.DimStyle.Render(hint) + "\n")
+		} else {
+			b.WriteString("  " + mark + ui.NormalLabelStyle.Render(labelStyle.Render(label)) + ui.DimStyle.Render(hint) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(m.status)
+		b.WriteString("\n")
+	}
+	b.WriteString(ui.DimStyle.Render("↑↓ select   backspace remove   ctrl+b bookmark all   ctrl+s shortlist all"))
+
+	return b.String()
+}
+
+// scanMatchHint describes the part input resolved to
+func scanMatchHint(input string, match db.PartNumberMatch) string {
+	var hint string
+	if match.Description != nil {
+		hint = *match.Description
+	}
+	switch {
+	case match.ViaReplacement:
+		hint += " (replaced by " + db.NormalizePartNumber(input) + ")"
+	case match.Listings > 1:
+		hint += fmt.Sprintf(" (%d listings)", match.Listings)
+	}
+	return strings.TrimSpace(hint)
+}
@@ -10,14 +10,32 @@ const (
 	ScreenSearch
 	ScreenBookmarks
 	ScreenNotes
+	ScreenJump
+	ScreenCuration
+	ScreenConsole
+	ScreenPNC
+	ScreenJournal
+	ScreenScan
+	ScreenPaste
+	ScreenTemplates
+	ScreenAdded
+	ScreenHotspots
+	ScreenStats
+	ScreenVehicles
+	ScreenCart
+	ScreenWizard
+	ScreenViewer
+	ScreenTree
 )
 
 type Screen struct {
 	Type       ScreenType
 	GroupID    string
 	SubgroupID string
+	DiagramID  string // set for a diagram shown on the subgroup, hotspots or viewer screen
 	PartID     int
 	Query      string
+	Day        string // YYYY-MM-DD of a journal day
 	FromSearch bool
 }
 
@@ -33,6 +51,12 @@ func SubgroupScreen(subgroupID string) Screen {
 	return Screen{Type: ScreenSubgroup, SubgroupID: subgroupID}
 }
 
+// DiagramScreen shows a diagram's parts on the subgroup screen with partID
+// selected, for parts that have no subgroup of their own.
+func DiagramScreen(diagramID string, partID int) Screen {
+	return Screen{Type: ScreenSubgroup, DiagramID: diagramID, PartID: partID}
+}
+
 func PartDetailScreen(partID int, fromSearch bool) Screen {
 	return Screen{Type: ScreenPartDetail, PartID: partID, FromSearch: fromSearch}
 }
@@ -48,3 +72,77 @@ func BookmarksScreen() Screen {
 func NotesScreen() Screen {
 	return Screen{Type: ScreenNotes}
 }
+
+func JumpScreen() Screen {
+	return Screen{Type: ScreenJump}
+}
+
+func CurationScreen() Screen {
+	return Screen{Type: ScreenCuration}
+}
+
+func ConsoleScreen() Screen {
+	return Screen{Type: ScreenConsole}
+}
+
+func PNCScreen() Screen {
+	return Screen{Type: ScreenPNC}
+}
+
+// JournalScreen lists the days in the journal, or with day set, the parts of
+// that day's job.
+func JournalScreen(day string) Screen {
+	return Screen{Type: ScreenJournal, Day: day}
+}
+
+func ScanScreen() Screen {
+	return Screen{Type: ScreenScan}
+}
+
+func PasteScreen() Screen {
+	return Screen{Type: ScreenPaste}
+}
+
+func TemplatesScreen() Screen {
+	return Screen{Type: ScreenTemplates}
+}
+
+// AddedScreen lists the parts syncs added to the catalog lately.
+func AddedScreen() Screen {
+	return Screen{Type: ScreenAdded}
+}
+
+// HotspotsScreen captures where a diagram's callouts are.
+func HotspotsScreen(diagramID string) Screen {
+	return Screen{Type: ScreenHotspots, DiagramID: diagramID}
+}
+
+// StatsScreen shows the feature usage counted with DELICA_METRICS.
+func StatsScreen() Screen {
+	return Screen{Type: ScreenStats}
+}
+
+// VehiclesScreen lists the vehicle profiles to switch between and edit.
+func VehiclesScreen() Screen {
+	return Screen{Type: ScreenVehicles}
+}
+
+// CartScreen lists the parts to order, with totals.
+func CartScreen() Screen {
+	return Screen{Type: ScreenCart}
+}
+
+// WizardScreen finds a part by asking questions about it.
+func WizardScreen() Screen {
+	return Screen{Type: ScreenWizard}
+}
+
+// ViewerScreen shows a diagram full screen, to zoom and pan.
+func ViewerScreen(diagramID string) Screen {
+	return Screen{Type: ScreenViewer, DiagramID: diagramID}
+}
+
+// TreeScreen shows the whole catalog as one collapsible tree.
+func TreeScreen() Screen {
+	return Screen{Type: ScreenTree}
+}
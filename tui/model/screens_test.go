@@ -0,0 +1,68 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/model"
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/viewtest"
+)
+
+// newDriver opens the sample catalog in the app at 120x40. Images are off,
+// as with -low-bandwidth, so diagrams show as missing rather than drawn for
+// whichever terminal runs the tests, and nothing is prefetched behind the
+// screens. The connection check dials a port that refuses, so the app goes
+// offline, saying so, and never looks prices up.
+func newDriver(t *testing.T) *viewtest.Driver {
+	t.Helper()
+	// Online is kept across tests; start each one online, as the app does
+	t.Setenv("DELICA_ONLINE_PROBE", "off")
+	netutil.CheckOnline(context.Background())
+	t.Setenv("DELICA_ONLINE_PROBE", "127.0.0.1:1")
+	t.Setenv("DELICA_PREFETCH_DEPTH", "0")
+	t.Setenv("DELICA_WEBHOOK_URL", "")
+	t.Setenv("DELICA_WEBHOOK_CSV", "")
+	image.Disabled = true
+	t.Cleanup(func() { image.Disabled = false })
+
+	return viewtest.New(model.New(dbtest.Sample(t), t.TempDir()), 120, 40)
+}
+
+func TestHomeScreen(t *testing.T) {
+	d := newDriver(t)
+	viewtest.Golden(t, "home", d.View())
+}
+
+func TestSearchScreen(t *testing.T) {
+	d := newDriver(t)
+	d.Keys("/")
+	d.Type("belt")
+	viewtest.Golden(t, "search_belt", d.View())
+}
+
+func TestPartDetailScreen(t *testing.T) {
+	d := newDriver(t)
+	d.Keys("/")
+	d.Type("MD050125")
+	d.Keys("enter")
+	viewtest.Golden(t, "part_detail", d.View())
+}
+
+func TestBookmarksScreen(t *testing.T) {
+	d := newDriver(t)
+	d.Keys("/")
+	d.Type("MD050125")
+	d.Keys("enter", "b", "+", "enter")
+	viewtest.Golden(t, "part_detail_saved", d.View())
+
+	// Back home and down the menu to the bookmarks
+	d.Keys("esc", "esc")
+	for range 8 {
+		d.Keys("down")
+	}
+	d.Keys("enter")
+	viewtest.Golden(t, "bookmarks", d.View())
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxKeptDiagrams is how many diagrams are kept for the scrollback, the
+// latest ones
+const maxKeptDiagrams = 5
+
+// keptHeightCells is the most rows a kept diagram takes in the scrollback
+const keptHeightCells = 40
+
+// keptDiagram is a diagram kept with P to print into the terminal's
+// scrollback on quitting, for reference once the TUI is gone, such as
+// while typing an order
+type keptDiagram struct {
+	path    string
+	caption string
+}
+
+type keepDiagramMsg struct {
+	diagram keptDiagram
+}
+
+// keepDiagram keeps the diagram at path for the scrollback
+func keepDiagram(path, caption string) tea.Cmd {
+	if image.Disabled {
+		return func() tea.Msg { return toastMsg{text: "Images are off in low-bandwidth mode"} }
+	}
+	return func() tea.Msg { return keepDiagramMsg{diagram: keptDiagram{path: path, caption: caption}} }
+}
+
+// keep adds a diagram to those printed on quitting, moving it to the end
+// if it's kept already
+func (m *Model) keep(d keptDiagram) tea.Cmd {
+	m.kept = slices.DeleteFunc(m.kept, func(k keptDiagram) bool { return k.path == d.path })
+	m.kept = append(m.kept, d)
+	if len(m.kept) > maxKeptDiagrams {
+		m.kept = m.kept[len(m.kept)-maxKeptDiagrams:]
+	}
+	return m.toast.show(toastMsg{text: fmt.Sprintf("Kept for the scrollback (%d), printed when you quit", len(m.kept))})
+}
+
+// PrintKeptDiagrams writes the diagrams kept with P to w, fitted to width
+// cells, each under its caption. It's for after the program has left the
+// alternate screen, so the diagrams stay in the terminal's scrollback.
+func (m *Model) PrintKeptDiagrams(w io.Writer, width int) error {
+	for _, d := range m.kept {
+		fmt.Fprintln(w, ui.DimStyle.Render(d.caption))
+		img, err := image.LoadAndScale(d.path, width, keptHeightCells)
+		if err != nil {
+			fmt.Fprintln(w, ui.ErrorStyle.Render(err.Error()))
+			continue
+		}
+		if _, err := fmt.Fprintln(w, img.Inline()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
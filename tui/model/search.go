@@ -2,24 +2,100 @@ package model
 
 import (
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"delica-tui/db"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Size of the diagram preview beside search results
+const (
+	previewWidthCells  = 48
+	previewHeightCells = 24
+)
+
 type SearchModel struct {
 	db            *db.DB
+	dataPath      string
 	input         textinput.Model
 	results       []db.SearchResult
-	cursor        int
+	table         *ui.Table
 	lastQuery     string
 	debounceTimer *time.Timer
+
+	// Recorded origins, badged on the results
+	origins map[string]db.Origin
+
+	// Results before superseded parts are left out, and how many were
+	found          []db.SearchResult
+	superseded     map[int]bool
+	hideSuperseded bool
+	hidden         int
+
+	// showRelevance replaces result hints with score and matched columns
+	showRelevance bool
+
+	// Columns chosen for the results, and the prices the price column shows
+	columns *columnSet
+	prices  map[string]db.Price
+
+	// Catalogs from older exports lack parts_fts; enter builds it
+	noIndex       bool
+	buildingIndex bool
+	indexError    string
+
+	// What an empty query shows instead of results
+	launchpad    []launchItem
+	launchCursor int
+
+	// Diagram preview of the selected result, loaded in the background
+	previews     *imageCache
+	previewPath  string // image the preview should show
+	preview      *image.KittyImage
+	previewError string
+	clearImageID uint32 // previous preview, to delete on next render
+}
+
+// Kinds of launchpad item
+const (
+	launchSearch = iota
+	launchPart
+	launchGroup
+)
+
+// launchItem is a recent search, recently viewed part or much-bookmarked
+// group, listed while the query is empty.
+type launchItem struct {
+	kind    int
+	query   string
+	partID  int
+	groupID string
+	label   string
+	hint    string
+}
+
+// How many of each kind the launchpad lists
+const (
+	launchSearches = 5
+	launchParts    = 8
+	launchGroups   = 5
+)
+
+// searchDebounce is how long typing pauses before the query runs. Over a
+// slow link it waits longer, so a word typed fast is one search and redraw.
+func searchDebounce() time.Duration {
+	if ui.LowBandwidth {
+		return 600 * time.Millisecond
+	}
+	return 150 * time.Millisecond
 }
 
 type searchResultsMsg struct {
@@ -27,7 +103,17 @@ type searchResultsMsg struct {
 	results []db.SearchResult
 }
 
-func NewSearchModel(database *db.DB, query string) *SearchModel {
+type searchIndexBuiltMsg struct {
+	err error
+}
+
+type previewLoadedMsg struct {
+	path string
+	img  *image.KittyImage
+	err  error
+}
+
+func NewSearchModel(database *db.DB, query string, dataPath string, previews *imageCache) *SearchModel {
 	ti := textinput.New()
 	ti.Placeholder = "Search parts by number or description..."
 	ti.Focus()
@@ -36,47 +122,330 @@ func NewSearchModel(database *db.DB, query string) *SearchModel {
 	ti.Width = 50
 
 	m := &SearchModel{
-		db:    database,
-		input: ti,
+		db:       database,
+		dataPath: dataPath,
+		input:    ti,
+		previews: previews,
+		noIndex:  !database.HasSearchIndex(),
+		columns:  loadColumnSet(database, "search", searchColumns),
 	}
+	m.origins, _ = database.GetOrigins()
+	m.superseded, _ = database.GetSupersededPartIDs()
+	m.hideSuperseded = database.HidingSuperseded()
+	m.setResults(nil)
+	m.loadLaunchpad()
 
 	// Initial search if query provided
 	if query != "" {
-		m.results, _ = database.SearchParts(query)
+		results, _ := database.SearchParts(query)
+		m.setResults(results)
 		m.lastQuery = query
+		// Load the first preview now, like other screens load their diagram
+		if cmd := m.updatePreview(); cmd != nil {
+			m.Update(cmd())
+		}
 	}
 
 	return m
 }
 
+// loadLaunchpad lists recent searches, recently viewed parts and the
+// groups with the most bookmarks, putting the cursor on the first
+func (m *SearchModel) loadLaunchpad() {
+	m.launchpad = nil
+	m.launchCursor = 0
+
+	searches, _ := m.db.GetRecentSearches(launchSearches)
+	for _, q := range searches {
+		m.launchpad = append(m.launchpad, launchItem{kind: launchSearch, query: q, label: q})
+	}
+	parts, _ := m.db.GetRecentParts(launchParts)
+	for _, p := range parts {
+		location := deref(p.SubgroupName)
+		if p.SubgroupName == nil {
+			location = p.GroupName
+		}
+		hint := location
+		if p.Views > 1 {
+			hint += fmt.Sprintf(" · %d views", p.Views)
+		}
+		m.launchpad = append(m.launchpad, launchItem{
+			kind:   launchPart,
+			partID: p.PartID,
+			label:  strings.TrimSpace(p.PartNumber + " " + deref(p.Description)),
+			hint:   hint,
+		})
+	}
+	groups, _ := m.db.GetMostBookmarkedGroups(launchGroups)
+	for _, g := range groups {
+		hint := "1 bookmark"
+		if g.Bookmarks != 1 {
+			hint = fmt.Sprintf("%d bookmarks", g.Bookmarks)
+		}
+		m.launchpad = append(m.launchpad, launchItem{kind: launchGroup, groupID: g.ID, label: g.Name, hint: hint})
+	}
+}
+
+// showingLaunchpad reports whether the launchpad stands in for results
+func (m *SearchModel) showingLaunchpad() bool {
+	return !m.noIndex && strings.TrimSpace(m.input.Value()) == "" && len(m.launchpad) > 0
+}
+
+// launch opens the launchpad item under the cursor: a search is run again,
+// a part or group is navigated to.
+func (m *SearchModel) launch() (tea.Cmd, *Screen) {
+	if m.launchCursor >= len(m.launchpad) {
+		return nil, nil
+	}
+	item := m.launchpad[m.launchCursor]
+	switch item.kind {
+	case launchPart:
+		s := PartDetailScreen(item.partID, true)
+		return nil, &s
+	case launchGroup:
+		s := GroupScreen(item.groupID)
+		return nil, &s
+	}
+	m.input.SetValue(item.query)
+	m.input.CursorEnd()
+	query := item.query
+	return func() tea.Msg {
+		results, _ := m.db.SearchParts(query)
+		return searchResultsMsg{query: query, results: results}
+	}, nil
+}
+
+// Columns of the results table, the first four shown unless chosen
+// otherwise, and the part number and PNC with each result's relevance
+var (
+	searchColumns = []listColumn{
+		partNumberColumn,
+		pncColumn,
+		{key: "description", column: ui.Column{Title: "DESCRIPTION", Width: 28}, shown: true},
+		{key: "location", column: ui.Column{Title: "LOCATION"}, shown: true},
+		specColumn,
+		priceColumn,
+		datesColumn,
+	}
+	relevanceColumns = []ui.Column{
+		{Title: "PART", Width: 14},
+		{Title: "PNC", Width: 8},
+		{Title: "SCORE", Width: 8, Numeric: true},
+		{Title: "MATCHED"},
+	}
+)
+
+// setResults shows new results best match first, unless sorted by a column,
+// with the cursor on the top row
+func (m *SearchModel) setResults(results []db.SearchResult) {
+	m.found = results
+	m.results = nil
+	m.hidden = 0
+	for _, r := range results {
+		if m.hideSuperseded && m.superseded[r.ID] {
+			m.hidden++
+			continue
+		}
+		m.results = append(m.results, r)
+	}
+	m.prices = nil
+	prev := m.table
+	m.buildTable()
+	if prev != nil {
+		m.table.SortBy(prev.SortColumn, prev.SortDesc)
+	}
+	m.table.Cursor = 0
+}
+
+// reloadSuperseded picks up a change to whether superseded parts are
+// hidden, filtering the results found again
+func (m *SearchModel) reloadSuperseded() {
+	m.superseded, _ = m.db.GetSupersededPartIDs()
+	m.hideSuperseded = m.db.HidingSuperseded()
+	m.setResults(m.found)
+}
+
+// buildTable lays out the results with or without their relevance. Rows
+// are keyed by their index in results.
+func (m *SearchModel) buildTable() {
+	if m.columns.has(priceColumn.key) && m.prices == nil && len(m.results) > 0 {
+		partNumbers := make([]string, len(m.results))
+		for i, r := range m.results {
+			partNumbers[i] = r.PartNumber
+		}
+		m.prices = partPrices(m.db, partNumbers)
+	}
+
+	rows := make([]ui.TableRow, len(m.results))
+	for i, r := range m.results {
+		if m.showRelevance {
+			rows[i] = ui.TableRow{ID: strconv.Itoa(i), Cells: []string{
+				r.PartNumber, deref(r.PNC), fmt.Sprintf("%.2f", r.Score), strings.Join(r.MatchedColumns, ", "),
+			}}
+			continue
+		}
+		location := deref(r.SubgroupName)
+		if r.SubgroupName == nil {
+			location = r.GroupName + " - " + r.DiagramName
+		}
+		rows[i] = m.columns.row(strconv.Itoa(i), map[string]string{
+			"part":        r.PartNumber,
+			"pnc":         deref(r.PNC),
+			"description": badged(deref(r.Description), m.origins, r.PartNumber),
+			"location":    location,
+			"spec":        deref(r.Spec),
+			"price":       priceCell(m.prices, r.PartNumber),
+			"dates":       deref(r.ModelDateRange),
+		})
+	}
+	columns := m.columns.columns()
+	if m.showRelevance {
+		columns = relevanceColumns
+	}
+	m.table = ui.NewTable(columns, rows)
+	m.table.Typing = true
+}
+
+// selected returns the result under the cursor, if any
+func (m *SearchModel) selected() *db.SearchResult {
+	row := m.table.Selected()
+	if row == nil {
+		return nil
+	}
+	i, _ := strconv.Atoi(row.ID)
+	return &m.results[i]
+}
+
+// updatePreview points the preview at the selected result's diagram. It
+// shows a cached image right away, otherwise returns a command to load it.
+func (m *SearchModel) updatePreview() tea.Cmd {
+	var path string
+	if r := m.selected(); r != nil && r.ImagePath != nil && !image.Disabled {
+		path = filepath.Join(m.dataPath, *r.ImagePath)
+	}
+	if path == m.previewPath {
+		return nil
+	}
+	m.previewPath = path
+	m.previewError = ""
+	m.setPreview(nil)
+	if path == "" {
+		return nil
+	}
+
+	if img := m.previews.get(path, previewWidthCells, previewHeightCells); img != nil {
+		m.setPreview(img)
+		return nil
+	}
+	previews := m.previews
+	return func() tea.Msg {
+		img, err := previews.load(path, previewWidthCells, previewHeightCells)
+		return previewLoadedMsg{path: path, img: img, err: err}
+	}
+}
+
+// setPreview swaps the displayed image, scheduling the old one for deletion
+func (m *SearchModel) setPreview(img *image.KittyImage) {
+	if m.preview != nil && (img == nil || img.ID() != m.preview.ID()) {
+		m.clearImageID = m.preview.ID()
+	}
+	m.preview = img
+}
+
+func (m *SearchModel) ImageID() uint32 {
+	if m.preview != nil {
+		return m.preview.ID()
+	}
+	return 0
+}
+
 func (m *SearchModel) Update(msg tea.Msg) (*SearchModel, tea.Cmd, *Screen) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// Navigation with arrow keys only (j/k should type into input)
-		if msg.Type == tea.KeyUp {
-			if m.cursor > 0 {
-				m.cursor--
+		// The column chooser takes keys, letters included, until it's
+		// closed; the sorting starts over when the columns change
+		if m.columns.choosing {
+			if m.columns.update(msg) {
+				m.buildTable()
 			}
 			return m, nil, nil
 		}
-		if msg.Type == tea.KeyDown {
-			if m.cursor < len(m.results)-1 {
-				m.cursor++
+		if ui.IsColumns(msg) && !m.showRelevance {
+			m.columns.open()
+			return m, nil, nil
+		}
+		if m.showingLaunchpad() {
+			if cursor, ok := ui.MoveCursor(msg, m.launchCursor, len(m.launchpad), launchSearches, true); ok {
+				m.launchCursor = cursor
+				return m, nil, nil
+			}
+			if ui.IsEnter(msg) {
+				cmd, nav := m.launch()
+				return m, cmd, nav
 			}
+		}
+
+		// Letter keys type into the input, so only arrow, page, tab and
+		// ctrl keys navigate
+		if m.table.HandleKey(msg) {
+			return m, m.updatePreview(), nil
+		}
+		if ui.IsToggleDebug(msg) {
+			m.showRelevance = !m.showRelevance
+			prev := m.table
+			m.buildTable()
+			m.table.KeepPosition(prev)
 			return m, nil, nil
 		}
-		if ui.IsEnter(msg) && len(m.results) > 0 {
-			result := m.results[m.cursor]
+		if ui.IsEnter(msg) && m.noIndex && !m.buildingIndex {
+			m.buildingIndex = true
+			m.indexError = ""
+			return m, func() tea.Msg {
+				return searchIndexBuiltMsg{err: m.db.BuildSearchIndex()}
+			}, nil
+		}
+		if result := m.selected(); ui.IsEnter(msg) && result != nil {
+			// Searches that led somewhere are offered again on the launchpad
+			m.db.AddRecentSearch(m.input.Value())
 			s := PartDetailScreen(result.ID, true)
+			if result.Part.SubgroupID == nil {
+				// Without a subgroup the detail screen leads nowhere, so
+				// show the part on its diagram
+				s = DiagramScreen(result.DiagramID, result.ID)
+			}
 			return m, nil, &s
 		}
 
+	case searchIndexBuiltMsg:
+		m.buildingIndex = false
+		if msg.err != nil {
+			m.indexError = msg.err.Error()
+			return m, nil, nil
+		}
+		m.noIndex = false
+		query := m.input.Value()
+		return m, func() tea.Msg {
+			results, _ := m.db.SearchParts(query)
+			return searchResultsMsg{query: query, results: results}
+		}, nil
+
 	case searchResultsMsg:
 		if msg.query == m.input.Value() {
-			m.results = msg.results
-			m.cursor = 0
+			m.setResults(msg.results)
+			return m, m.updatePreview(), nil
+		}
+		return m, nil, nil
+
+	case previewLoadedMsg:
+		// Ignore previews the cursor has already moved past
+		if msg.path == m.previewPath {
+			if msg.err != nil {
+				m.previewError = msg.err.Error()
+			} else {
+				m.setPreview(msg.img)
+			}
 		}
 		return m, nil, nil
 	}
@@ -88,7 +457,10 @@ func (m *SearchModel) Update(msg tea.Msg) (*SearchModel, tea.Cmd, *Screen) {
 	// Debounced search on input change
 	if m.input.Value() != prevValue {
 		query := m.input.Value()
-		return m, tea.Tick(150*time.Millisecond, func(t time.Time) tea.Msg {
+		if strings.TrimSpace(query) == "" {
+			m.loadLaunchpad()
+		}
+		return m, tea.Tick(searchDebounce(), func(t time.Time) tea.Msg {
 			results, _ := m.db.SearchParts(query)
 			return searchResultsMsg{query: query, results: results}
 		}), nil
@@ -120,16 +492,46 @@ func (m *SearchModel) View(width, height int) string {
 	}
 
 	leftContent := m.renderLeftPane(splitHeight)
-	rightContent := m.renderRightPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
 
 	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
 
-	return header + "\n" + split
+	// Delete the previous preview and draw the current one below its caption
+	var img string
+	if m.clearImageID != 0 {
+		img = image.Clear(m.clearImageID)
+		m.clearImageID = 0
+	}
+	if m.preview != nil {
+		img += "\x1b7" + "  " + "\x1b[1B" + m.preview.Render() + "\x1b8"
+	}
+
+	return header + "\n" + img + split
 }
 
 func (m *SearchModel) renderLeftPane(height int) string {
 	var lines []string
 
+	// Diagram preview of the selected result, once there are results
+	if r := m.selected(); m.previewPath != "" && r != nil {
+		lines = append(lines, ui.DimStyle.Render(r.DiagramID))
+		if m.preview != nil {
+			// Image is rendered separately in View(), just add placeholder
+			// lines, unless it's drawn as text
+			for i := 0; i < m.preview.CellHeight(); i++ {
+				lines = append(lines, m.preview.Row(i))
+			}
+		} else if m.previewError != "" {
+			lines = append(lines, ui.ErrorStyle.Render(m.previewError))
+		} else {
+			lines = append(lines, ui.DimStyle.Render("Loading diagram..."))
+		}
+		for len(lines) < height {
+			lines = append(lines, "")
+		}
+		return strings.Join(lines, "\n")
+	}
+
 	// Search tips
 	lines = append(lines, ui.HeaderStyle.Render("SEARCH TIPS"))
 	lines = append(lines, "")
@@ -138,8 +540,24 @@ func (m *SearchModel) renderLeftPane(height int) string {
 	lines = append(lines, "  - Description")
 	lines = append(lines, "  - PNC code")
 	lines = append(lines, "")
+	lines = append(lines, "Operators:")
+	lines = append(lines, "  belt pulley    both words")
+	lines = append(lines, "  belt OR chain  either word")
+	lines = append(lines, "  belt -timing   exclude a word")
+	lines = append(lines, "  \"oil pan\"      exact phrase")
+	lines = append(lines, "")
+	lines = append(lines, "Dimensions:")
+	lines = append(lines, "  M8x1.25        thread")
+	lines = append(lines, "  length:20-30   size or range")
+	lines = append(lines, "")
+	lines = append(lines, "Spec:")
+	lines = append(lines, "  drive:4wd      attribute")
+	lines = append(lines, "")
 	lines = append(lines, ui.DimStyle.Render("Results update as"))
 	lines = append(lines, ui.DimStyle.Render("you type"))
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Part number and PNC"))
+	lines = append(lines, ui.DimStyle.Render("matches rank first"))
 
 	// Pad to fill height
 	for len(lines) < height {
@@ -149,12 +567,20 @@ func (m *SearchModel) renderLeftPane(height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *SearchModel) renderRightPane(height int) string {
+func (m *SearchModel) renderRightPane(width, height int) string {
 	var b strings.Builder
 
 	// Input box
 	inputBox := ui.BoxStyle.Render(m.input.View())
 	b.WriteString(inputBox)
+	b.WriteString("\n")
+
+	// How the query was interpreted
+	if parsed, err := db.ParseSearchQuery(m.input.Value()); err != nil {
+		b.WriteString(ui.ErrorStyle.Render(err.Error()))
+	} else if parsed.FTS != "" || parsed.HasFilters() {
+		b.WriteString(ui.DimStyle.Render("Matching " + parsed.Explain()))
+	}
 	b.WriteString("\n\n")
 
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
@@ -162,11 +588,30 @@ func (m *SearchModel) renderRightPane(height int) string {
 
 	// Results
 	query := strings.TrimSpace(m.input.Value())
-	if query == "" {
+	if m.noIndex {
+		b.WriteString(ui.DimStyle.Render("This catalog has no search index, as with some older exports."))
+		b.WriteString("\n")
+		switch {
+		case m.buildingIndex:
+			b.WriteString(ui.DimStyle.Render("Building it from the parts table..."))
+		case m.indexError != "":
+			b.WriteString(ui.ErrorStyle.Render("Couldn't build it: " + m.indexError))
+		default:
+			b.WriteString(ui.DimStyle.Render("Press enter to build it from the parts table."))
+		}
+	} else if m.columns.choosing {
+		b.WriteString(m.columns.view())
+		return b.String()
+	} else if m.showingLaunchpad() {
+		b.WriteString(m.renderLaunchpad(width, height-8))
+	} else if query == "" {
 		b.WriteString(ui.DimStyle.Render("Start typing to search parts"))
+	} else if len(m.results) == 0 && m.hidden > 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("Only superseded parts match \"%s\"; ctrl+x shows them", query)))
 	} else if len(m.results) == 0 {
 		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("No results for \"%s\"", query)))
 	} else {
+		// Room for the column titles and up to 20 results
 		maxResults := height - 8
 		if maxResults < 5 {
 			maxResults = 5
@@ -174,57 +619,63 @@ func (m *SearchModel) renderRightPane(height int) string {
 		if maxResults > 20 {
 			maxResults = 20
 		}
+		m.table.MaxVisibleItems = maxResults + 1
+		m.table.Width = width
+		b.WriteString(m.table.View())
+		b.WriteString("\n")
+		b.WriteString("\n")
+		count := fmt.Sprintf("%d results", len(m.results))
+		if m.hidden > 0 {
+			count += fmt.Sprintf(", %d superseded hidden", m.hidden)
+		}
+		b.WriteString(ui.DimStyle.Render(count))
+	}
 
-		for i, r := range m.results {
-			if i >= maxResults {
-				break
-			}
-
-			isSelected := i == m.cursor
-
-			// Part number
-			label := r.PartNumber
-			if r.PNC != nil {
-				label = fmt.Sprintf("[%s] %s", *r.PNC, r.PartNumber)
-			}
+	b.WriteString("\n\n")
+	if m.showingLaunchpad() {
+		b.WriteString(ui.DimStyle.Render("↑↓ select   enter open   type to search"))
+	} else {
+		b.WriteString(ui.DimStyle.Render("↑↓ select   enter view   " + m.table.SortHint() + "   ctrl+g relevance   ctrl+k columns"))
+	}
 
-			// Hint: description + location
-			var hintParts []string
-			if r.Description != nil {
-				hintParts = append(hintParts, *r.Description)
-			}
-			if r.SubgroupName != nil {
-				hintParts = append(hintParts, *r.SubgroupName)
-			} else {
-				hintParts = append(hintParts, r.GroupName)
-			}
-			hint := strings.Join(hintParts, " - ")
+	return b.String()
+}
 
-			var line string
-			if isSelected {
-				line = ui.SelectedStyle.Render("> ")
-			} else {
-				line = "  "
-			}
+// renderLaunchpad lists the launchpad under a heading per kind, in at most
+// height lines, scrolled to keep the cursor in view
+func (m *SearchModel) renderLaunchpad(width, height int) string {
+	headings := []string{"RECENT SEARCHES", "RECENTLY VIEWED", "MOST BOOKMARKED GROUPS"}
 
-			labelStyle := lipgloss.NewStyle().Width(24)
-			if isSelected {
-				line += ui.SelectedLabelStyle.Render(labelStyle.Render(strings.ToUpper(label)))
-			} else {
-				line += ui.NormalLabelStyle.Render(labelStyle.Render(strings.ToUpper(label)))
+	var lines []string
+	cursorLine := 0
+	for i, item := range m.launchpad {
+		if i == 0 || item.kind != m.launchpad[i-1].kind {
+			if i > 0 {
+				lines = append(lines, "")
 			}
-			line += ui.DimStyle.Render(strings.ToUpper(hint))
-
-			b.WriteString(line)
-			b.WriteString("\n")
+			lines = append(lines, ui.HeaderStyle.Render(headings[item.kind]))
 		}
 
-		b.WriteString("\n")
-		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%d results", len(m.results))))
+		marker, label := "  ", ui.NormalLabelStyle
+		if i == m.launchCursor {
+			marker, label = ui.SelectedStyle.Render("› "), ui.SelectedLabelStyle
+			cursorLine = len(lines)
+		}
+		text := strings.ToUpper(item.label)
+		if item.kind == launchSearch {
+			text = "\"" + item.label + "\""
+		}
+		line := marker + label.Render(text)
+		if item.hint != "" {
+			line += ui.DimStyle.Render(" " + strings.ToUpper(item.hint))
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
 	}
 
-	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ select   enter view"))
-
-	return b.String()
+	height = max(height, 5)
+	if len(lines) > height {
+		start := max(0, min(cursorLine-height/2, len(lines)-height))
+		lines = lines[start : start+height]
+	}
+	return strings.Join(lines, "\n")
 }
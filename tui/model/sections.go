@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+)
+
+// Sections of the part detail pane that fold away to one line each
+const (
+	sectionFields    = "fields"
+	sectionNotes     = "notes"
+	sectionSubgroups = "subgroups"
+	sectionPrices    = "prices"
+	sectionLinks     = "links"
+	sectionUsage     = "usage"
+)
+
+// detailSections are the foldable sections in the order of the digit keys
+// that fold them, 1 for fields up to 6 for usage
+var detailSections = []struct{ name, title string }{
+	{sectionFields, "Fields"},
+	{sectionNotes, "Notes"},
+	{sectionSubgroups, "Subgroups"},
+	{sectionPrices, "Prices"},
+	{sectionLinks, "Links"},
+	{sectionUsage, "Usage"},
+}
+
+// toggleSection folds or unfolds the nth section, remembering it for every
+// part, and keeps the cursor on an entry still shown
+func (m *PartDetailModel) toggleSection(n int) {
+	name := detailSections[n-1].name
+	collapsed := !m.collapsed[name]
+	if err := m.db.SetSectionCollapsed(name, collapsed); err != nil {
+		m.writeError = fmt.Sprintf("Section not saved: %v", err)
+	}
+	m.collapsed[name] = collapsed
+	m.cursor = max(0, min(m.cursor, m.totalItems()-1))
+}
+
+// renderCollapsed writes the line a folded section shows instead: its
+// title, how many entries it holds and the key that unfolds it
+func (m *PartDetailModel) renderCollapsed(b *strings.Builder, name string, count int) {
+	for i, s := range detailSections {
+		if s.name != name {
+			continue
+		}
+		label := "› " + s.title
+		if count > 0 {
+			label += fmt.Sprintf(" (%d)", count)
+		}
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("%s   %d unfolds", label, i+1)))
+		b.WriteString("\n")
+	}
+}
+
+// The cursor runs over the attachments, subgroups and prices shown, skipping
+// those of folded sections. Attachments are listed under the notes.
+func (m *PartDetailModel) shownAttachments() int {
+	if m.collapsed[sectionNotes] {
+		return 0
+	}
+	return len(m.attachments)
+}
+
+func (m *PartDetailModel) shownSubgroups() int {
+	if m.collapsed[sectionSubgroups] {
+		return 0
+	}
+	return len(m.subgroups)
+}
+
+func (m *PartDetailModel) shownPrices() int {
+	if m.collapsed[sectionPrices] {
+		return 0
+	}
+	return len(m.prices)
+}
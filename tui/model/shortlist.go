@@ -0,0 +1,332 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+	"github.com/mshick/delica-space-gear-parts/tui/order"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Rows the expanded drawer shows before scrolling
+const shortlistRows = 6
+
+type shortlistItem struct {
+	partID      int
+	partNumber  string
+	description string
+	qty         int    // how many are needed, 0 if not given
+	note        string // why it's needed, from a pasted list
+}
+
+// shortlist is a scratchpad of candidate parts for the current session. It
+// isn't saved; parts worth keeping are promoted to bookmarks. It shows as a
+// drawer along the bottom of every screen, a single line when collapsed.
+type shortlist struct {
+	items  []shortlistItem
+	open   bool
+	cursor int
+	status string
+}
+
+// shortlistPromotedMsg reports the outcome of bookmarking the shortlist.
+type shortlistPromotedMsg struct {
+	count int
+	err   error
+}
+
+// shortlistAddMsg asks for parts to be added to the shortlist, such as both
+// sides of a handed pair.
+type shortlistAddMsg struct {
+	parts []*db.PartWithDiagram
+}
+
+// shortlistItemsMsg asks for items with quantities and notes, such as a
+// pasted parts list, to be added to the shortlist.
+type shortlistItemsMsg struct {
+	items []shortlistItem
+}
+
+// addItems lists each item, adding the quantity and note of one already
+// listed to the existing entry.
+func (s *shortlist) addItems(items []shortlistItem) {
+	added, merged := 0, 0
+	for _, item := range items {
+		i := slices.IndexFunc(s.items, func(it shortlistItem) bool { return it.partID == item.partID })
+		if i < 0 {
+			s.items = append(s.items, item)
+			added++
+			continue
+		}
+		existing := &s.items[i]
+		existing.qty = max(existing.qty, 1) + max(item.qty, 1)
+		if item.note != "" && existing.note != "" {
+			existing.note += "; " + item.note
+		} else if item.note != "" {
+			existing.note = item.note
+		}
+		merged++
+	}
+	s.status = fmt.Sprintf("Added %d parts to shortlist", added)
+	if merged > 0 {
+		s.status += fmt.Sprintf(", %d already listed", merged)
+	}
+}
+
+// add lists each part that isn't already listed.
+func (s *shortlist) add(parts ...*db.PartWithDiagram) {
+	var added []string
+	for _, part := range parts {
+		if slices.ContainsFunc(s.items, func(it shortlistItem) bool { return it.partID == part.ID }) {
+			continue
+		}
+		desc := ""
+		if part.Description != nil {
+			desc = *part.Description
+		}
+		s.items = append(s.items, shortlistItem{partID: part.ID, partNumber: part.PartNumber, description: desc})
+		added = append(added, part.PartNumber)
+	}
+	if len(added) == 0 {
+		s.status = "Already on shortlist"
+		return
+	}
+	s.status = fmt.Sprintf("Added %s to shortlist", strings.Join(added, " and "))
+}
+
+// toggle adds the part, or removes it if it's already listed.
+func (s *shortlist) toggle(part *db.PartWithDiagram) {
+	for i, it := range s.items {
+		if it.partID == part.ID {
+			s.remove(i)
+			s.status = fmt.Sprintf("Removed %s from shortlist", part.PartNumber)
+			return
+		}
+	}
+	desc := ""
+	if part.Description != nil {
+		desc = *part.Description
+	}
+	s.items = append(s.items, shortlistItem{partID: part.ID, partNumber: part.PartNumber, description: desc})
+	s.status = fmt.Sprintf("Added %s to shortlist", part.PartNumber)
+}
+
+func (s *shortlist) remove(i int) {
+	s.items = append(s.items[:i], s.items[i+1:]...)
+	if s.cursor >= len(s.items) && s.cursor > 0 {
+		s.cursor--
+	}
+}
+
+// height is how many lines the drawer takes below the screen.
+func (s *shortlist) height() int {
+	switch {
+	case s.open:
+		return min(max(len(s.items), 1), shortlistRows) + 2
+	case len(s.items) > 0 || s.status != "":
+		return 2
+	}
+	return 0
+}
+
+// update handles keys while the drawer is open. It returns a screen to open
+// when a part is chosen.
+func (s *shortlist) update(msg tea.KeyMsg, database *db.DB, writes *writeQueue, dataPath string) (tea.Cmd, *Screen) {
+	if cursor, ok := ui.MoveCursor(msg, s.cursor, len(s.items), shortlistRows, false); ok {
+		s.cursor = cursor
+		return nil, nil
+	}
+
+	switch {
+	case ui.IsBack(msg), ui.IsShortlistDrawer(msg):
+		s.open = false
+		if len(s.items) == 0 {
+			s.status = "" // hide the drawer entirely
+		}
+	case ui.IsEnter(msg) && len(s.items) > 0:
+		s.open = false
+		screen := PartDetailScreen(s.items[s.cursor].partID, false)
+		return nil, &screen
+	case ui.IsRemove(msg) && len(s.items) > 0:
+		s.remove(s.cursor)
+	case ui.IsBookmark(msg) && len(s.items) > 0:
+		return s.promote(database, writes), nil
+	case ui.IsMailOrder(msg) && len(s.items) > 0:
+		return s.mailOrder(database, dataPath), nil
+	case ui.IsCopyOrder(msg) && len(s.items) > 0:
+		return copyOrderCmd(s.orderLines()), nil
+	case ui.IsMoreQty(msg) && len(s.items) > 0:
+		s.items[s.cursor].qty = max(s.items[s.cursor].qty, 1) + 1
+	case ui.IsLessQty(msg) && len(s.items) > 0:
+		s.items[s.cursor].qty = max(s.items[s.cursor].qty-1, 1)
+	}
+	return nil, nil
+}
+
+// promote bookmarks every listed part in one queued write, clearing the
+// shortlist once it lands.
+func (s *shortlist) promote(database *db.DB, writes *writeQueue) tea.Cmd {
+	ids := make([]int, len(s.items))
+	qtys := make([]int, len(s.items))
+	events := make([]webhook.Event, len(s.items))
+	for i, it := range s.items {
+		ids[i], qtys[i] = it.partID, it.qty
+		events[i] = webhook.Event{Kind: "bookmark", Action: "add", PartID: it.partID, PartNumber: it.partNumber, Description: it.description, Qty: it.qty}
+	}
+	s.status = fmt.Sprintf("Bookmarking %d parts...", len(ids))
+
+	written := writes.enqueue(0, writeBookmark, 0, func() error {
+		for i, id := range ids {
+			if err := database.AddBookmark(id); err != nil {
+				return err
+			}
+			if qtys[i] > 1 {
+				if err := database.SetBookmarkQty(id, qtys[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, events...)
+	return func() tea.Msg {
+		result := written().(userDataWrittenMsg)
+		return shortlistPromotedMsg{count: len(ids), err: result.err}
+	}
+}
+
+// mailtoLimit is the longest mailto: URL handed to the mail client; some
+// truncate or refuse longer ones, so bigger orders open as a .eml draft
+const mailtoLimit = 2000
+
+// mailOrder drafts an order e-mail for the listed parts to the supplier in
+// DELICA_ORDER_EMAIL and opens it in the mail client. The draft is also
+// saved to the data directory's orders folder. It's for the active van.
+func (s *shortlist) mailOrder(database *db.DB, dataPath string) tea.Cmd {
+	draft := order.FromEnv()
+	if draft.To == "" {
+		s.status = "Set DELICA_ORDER_EMAIL to the supplier's address to draft an order"
+		return nil
+	}
+	vehicle := activeVehicle(database)
+	draft.FrameNo, draft.Vehicle = vehicle.FrameNo, vehicle.Name
+	draft.Lines = s.orderLines()
+
+	now := time.Now()
+	path := filepath.Join(dataPath, "orders", "order-"+now.Format("20060102-150405")+".eml")
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		var f *os.File
+		if f, err = os.Create(path); err == nil {
+			err = draft.WriteEML(f, now)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		s.status = fmt.Sprintf("Order draft not saved: %v", err)
+		return nil
+	}
+
+	s.status = fmt.Sprintf("Order for %d parts to %s, saved as %s", len(draft.Lines), draft.To, filepath.Base(path))
+	if target := draft.Mailto(); len(target) <= mailtoLimit {
+		return openCmd(target)
+	}
+	return openCmd(path)
+}
+
+// orderLines lists the parts as order lines, each at least 1
+func (s *shortlist) orderLines() []order.Line {
+	lines := make([]order.Line, len(s.items))
+	for i, it := range s.items {
+		lines[i] = order.Line{
+			PartNumber:  it.partNumber,
+			Description: it.description,
+			Qty:         max(it.qty, 1),
+			Note:        it.note,
+		}
+	}
+	return lines
+}
+
+// copyOrderCmd copies the order text for lines, in the DELICA_ORDER_LINE
+// format, to the clipboard in the background, asking the terminal to copy
+// it when no clipboard program can
+func copyOrderCmd(lines []order.Line) tea.Cmd {
+	text := order.Text(lines, order.LineFormat())
+	what := fmt.Sprintf("order of %d parts", len(lines))
+	if len(lines) == 1 {
+		what = "order of 1 part"
+	}
+	return func() tea.Msg {
+		if err := opener.Copy(text); err != nil {
+			return toastMsg{text: "Asked the terminal to copy the " + what, clipboard: text}
+		}
+		return toastMsg{text: "Copied the " + what}
+	}
+}
+
+func (s *shortlist) handlePromoted(msg shortlistPromotedMsg) {
+	if msg.err != nil {
+		s.status = fmt.Sprintf("Bookmarks not saved: %v", msg.err)
+		return
+	}
+	s.items = nil
+	s.cursor = 0
+	s.status = fmt.Sprintf("Bookmarked %d parts", msg.count)
+}
+
+func (s *shortlist) View(width int) string {
+	var lines []string
+	rule := ui.DimStyle.Render(strings.Repeat("─", max(width-4, 0)))
+	lines = append(lines, "  "+rule)
+
+	title := fmt.Sprintf("SHORTLIST %d", len(s.items))
+	if !s.open {
+		line := ui.HeaderStyle.Render(title)
+		if s.status != "" {
+			line += "   " + ui.DimStyle.Render(s.status)
+		}
+		line += "   " + ui.DimStyle.Render("S open")
+		return strings.Join(append(lines, "  "+line), "\n")
+	}
+
+	if len(s.items) == 0 {
+		lines = append(lines, "  "+ui.DimStyle.Render("Press s on a part to add it here"))
+	}
+	start := max(0, min(s.cursor-shortlistRows/2, len(s.items)-shortlistRows))
+	end := min(start+shortlistRows, len(s.items))
+	for i := start; i < end; i++ {
+		it := s.items[i]
+		detail := " " + strings.ToUpper(it.description)
+		if it.qty > 1 {
+			detail = fmt.Sprintf(" x%d", it.qty) + detail
+		}
+		if it.note != "" {
+			detail += " - " + it.note
+		}
+		detail = truncateText(detail, max(width-len(it.partNumber)-8, 10))
+		label := it.partNumber + ui.DimStyle.Render(detail)
+		if i == s.cursor {
+			lines = append(lines, "  "+ui.SelectedStyle.Render("› ")+ui.SelectedLabelStyle.Render(it.partNumber)+ui.DimStyle.Render(detail))
+		} else {
+			lines = append(lines, "    "+label)
+		}
+	}
+
+	footer := ui.HeaderStyle.Render(title) + "   " + ui.DimStyle.Render("enter open   +/- qty   d remove   b bookmark all   m e-mail order   Y copy order   S close")
+	if s.status != "" {
+		footer += "   " + ui.DimStyle.Render(s.status)
+	}
+	lines = append(lines, "  "+footer)
+	return strings.Join(lines, "\n")
+}
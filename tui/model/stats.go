@@ -0,0 +1,256 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// usageEnabled opts in to counting feature usage, DELICA_METRICS=1. The
+// counts stay in the local database.
+var usageEnabled, _ = strconv.ParseBool(os.Getenv("DELICA_METRICS"))
+
+// screenNames are how screens are named in usage counts
+var screenNames = map[ScreenType]string{
+	ScreenHome:       "home",
+	ScreenGroup:      "group",
+	ScreenSubgroup:   "subgroup",
+	ScreenPartDetail: "part detail",
+	ScreenSearch:     "search",
+	ScreenBookmarks:  "bookmarks",
+	ScreenNotes:      "notes",
+	ScreenJump:       "jump",
+	ScreenCuration:   "curation",
+	ScreenConsole:    "console",
+	ScreenPNC:        "pnc",
+	ScreenJournal:    "journal",
+	ScreenScan:       "scan",
+	ScreenPaste:      "paste",
+	ScreenTemplates:  "templates",
+	ScreenAdded:      "recently added",
+	ScreenHotspots:   "hotspots",
+	ScreenStats:      "usage stats",
+	ScreenVehicles:   "vehicles",
+	ScreenCart:       "cart",
+	ScreenWizard:     "find my part",
+	ScreenViewer:     "diagram viewer",
+	ScreenTree:       "catalog tree",
+}
+
+func (t ScreenType) String() string {
+	if name, ok := screenNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("screen %d", int(t))
+}
+
+// recordUsage counts a screen opened or a key pressed, if usage is counted.
+// Failures are ignored; the counts are only a guide.
+func (m *Model) recordUsage(kind, name string) {
+	if usageEnabled {
+		m.db.RecordUsage(kind, name)
+	}
+}
+
+// recordKey counts a key, or a chord such as "g h", pressed on the current
+// screen
+func (m *Model) recordKey(key string) {
+	m.recordUsage(db.UsageKey, m.screen.Type.String()+": "+key)
+}
+
+var statsColumns = []ui.Column{
+	{Title: "KIND", Width: 8},
+	{Title: "NAME"},
+	{Title: "COUNT", Width: 8, Numeric: true},
+	{Title: "LAST USED", Width: 18},
+}
+
+// StatsModel shows the feature usage counted with DELICA_METRICS: the
+// screens opened and keys pressed most, for deciding which workflows
+// deserve better keybindings.
+type StatsModel struct {
+	db         *db.DB
+	counts     []db.UsageCount
+	screens    int // opened, in total
+	keys       int // pressed, in total
+	since      string
+	table      *ui.Table
+	confirming bool // reset asked, waiting for y
+	images     *imageCache
+}
+
+func NewStatsModel(database *db.DB, images *imageCache) *StatsModel {
+	m := &StatsModel{db: database, images: images}
+	m.load()
+	return m
+}
+
+func (m *StatsModel) load() {
+	m.counts, _ = m.db.GetUsage()
+	m.screens, m.keys, m.since = 0, 0, ""
+
+	rows := make([]ui.TableRow, len(m.counts))
+	for i, c := range m.counts {
+		switch c.Kind {
+		case db.UsageScreen:
+			m.screens += c.Count
+		case db.UsageKey:
+			m.keys += c.Count
+		}
+		if m.since == "" || c.FirstUsed < m.since {
+			m.since = c.FirstUsed
+		}
+		rows[i] = ui.TableRow{
+			ID:    c.Kind + "/" + c.Name,
+			Cells: []string{c.Kind, c.Name, strconv.Itoa(c.Count), locale.DateTimeString(c.LastUsed)},
+		}
+	}
+	prev := m.table
+	m.table = ui.NewTable(statsColumns, rows)
+	m.table.KeepPosition(prev)
+}
+
+func (m *StatsModel) Update(msg tea.Msg) (*StatsModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.confirming {
+			m.confirming = false
+			if !ui.IsConfirm(msg) {
+				return m, nil, nil
+			}
+			if err := m.db.ResetUsage(); err != nil {
+				return m, func() tea.Msg { return toastMsg{text: fmt.Sprintf("Reset usage: %v", err), isError: true} }, nil
+			}
+			m.load()
+			return m, func() tea.Msg { return toastMsg{text: "Usage counts reset"} }, nil
+		}
+		if ui.IsRemove(msg) && len(m.counts) > 0 {
+			m.confirming = true
+			return m, nil, nil
+		}
+		m.table.HandleKey(msg)
+	}
+	return m, nil, nil
+}
+
+func (m *StatsModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(ui.SplitPaneLeftWidth(width-2), splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *StatsModel) renderLeftPane(width, height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("USAGE STATS"))
+	lines = append(lines, "")
+	if !usageEnabled {
+		lines = append(lines, "Not counting")
+	} else if m.since != "" {
+		lines = append(lines, "Since "+locale.DateTimeString(m.since))
+	}
+	lines = append(lines, fmt.Sprintf("%d screens opened", m.screens))
+	lines = append(lines, fmt.Sprintf("%d keys pressed", m.keys))
+	lines = append(lines, "")
+	lines = append(lines, ui.DimStyle.Render("Counted on this machine"))
+	lines = append(lines, ui.DimStyle.Render("only, with DELICA_METRICS=1"))
+	lines = append(lines, ui.DimStyle.Render("Text typed isn't recorded"))
+
+	// Pad to fill height, with image memory use at the bottom as on the
+	// home screen
+	var stats []string
+	if m.images != nil {
+		stats = strings.Split(ui.DimStyle.Width(width).Render(m.images.stats()), "\n")
+	}
+	for len(lines) < height-len(stats) {
+		lines = append(lines, "")
+	}
+	lines = append(lines, stats...)
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *StatsModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Header
+	b.WriteString(ui.HeaderStyle.Render("MOST USED"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles)
+	tableHeight := height - 4
+	if tableHeight < 6 {
+		tableHeight = 6
+	}
+	if tableHeight > 16 {
+		tableHeight = 16
+	}
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
+
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	if len(m.counts) == 0 {
+		b.WriteString(ui.DimStyle.Render("Nothing counted yet"))
+		if !usageEnabled {
+			b.WriteString("\n\n")
+			b.WriteString(ui.DimStyle.Render("Start delica-tui with DELICA_METRICS=1"))
+			b.WriteString("\n")
+			b.WriteString(ui.DimStyle.Render("to count screens and keys used"))
+		}
+	} else {
+		b.WriteString(m.table.View())
+	}
+
+	b.WriteString("\n\n")
+	if m.confirming {
+		b.WriteString(ui.ErrorStyle.Render("Reset all usage counts? y to confirm, any other key cancels"))
+	} else {
+		help := "↑↓ navigate   " + m.table.SortHint()
+		if len(m.counts) > 0 {
+			help += "   d reset"
+		}
+		b.WriteString(ui.DimStyle.Render(help))
+	}
+
+	return b.String()
+}
@@ -2,29 +2,101 @@ package model
 
 import (
 	"fmt"
-	"path/filepath"
+	"sort"
 	"strings"
 
-	"delica-tui/db"
-	"delica-tui/image"
-	"delica-tui/ui"
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// Parts list columns need at least this many cells, the description 34 of
+// them; ultrawide terminals fit up to three
+const (
+	partColumnWidth     = 56
+	maxPartColumns      = 3
+	minDescriptionWidth = 34
+)
+
+// Columns of the subgroup's parts list, the first three shown unless
+// chosen otherwise. The search and bookmarks lists start with the same two.
+var (
+	partNumberColumn = listColumn{key: "part", column: ui.Column{Title: "PART", Width: 14}, shown: true}
+	pncColumn        = listColumn{key: "pnc", column: ui.Column{Title: "PNC", Width: 8}, shown: true}
+	subgroupColumns  = []listColumn{
+		partNumberColumn,
+		pncColumn,
+		{key: "description", column: ui.Column{Title: "DESCRIPTION"}, shown: true},
+		specColumn,
+		priceColumn,
+		datesColumn,
+	}
+)
+
+// locatedPartColumns add where in the catalog each part is listed
+var locatedPartColumns = []ui.Column{
+	{Title: "PART", Width: 14},
+	{Title: "PNC", Width: 8},
+	{Title: "DESCRIPTION", Width: 28},
+	{Title: "LOCATION"},
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 type SubgroupModel struct {
-	db         *db.DB
-	subgroupID string
-	subgroup   *db.Subgroup
-	group      *db.Group
-	parts      []db.PartWithDiagram
-	diagram    *db.Diagram
-	menu       *ui.Menu
-	img        *image.KittyImage
-	imgError   string
-}
-
-func NewSubgroupModel(database *db.DB, subgroupID string, dataPath string) *SubgroupModel {
+	db       *db.DB
+	subgroup *db.Subgroup
+	group    *db.Group
+	parts    []db.PartWithDiagram
+	diagram  *db.Diagram
+	table    *ui.Table
+	img      *image.KittyImage
+	imgError string
+
+	// Columns chosen for the list, and the prices the price column shows
+	columns *columnSet
+	prices  map[string]db.Price
+
+	// Recorded origins, and the one source the list is narrowed to if any
+	origins      map[string]db.Origin
+	originFilter string
+
+	// Parts whose replacement is in the catalog, left out of the list
+	// while the display setting hides them
+	superseded     map[int]bool
+	hideSuperseded bool
+	hidden         int
+
+	// The image a sync replaced, shown instead of img or blinked with it
+	previous     *image.KittyImage
+	showPrevious bool
+	blinking     bool
+	blinkSeq     int
+	clearImageID uint32
+
+	// Parts around the cursor are prefetched once it rests
+	prefetch    *prefetcher
+	prefetchSeq int
+
+	// Callout mode: the diagram takes the keys, which move between its
+	// captured hotspots with the list following the selected one's ref
+	// number, drawn on a canvas of the diagram
+	callouts bool
+	hotspots []db.Hotspot // in ref number order
+	spot     int
+	canvas   *image.Canvas
+	marked   *image.KittyImage
+}
+
+func NewSubgroupModel(database *db.DB, subgroupID string, prefetch *prefetcher) *SubgroupModel {
 	subgroup, _ := database.GetSubgroup(subgroupID)
 	var group *db.Group
 	if subgroup != nil {
@@ -32,64 +104,250 @@ func NewSubgroupModel(database *db.DB, subgroupID string, dataPath string) *Subg
 	}
 	parts, _ := database.GetPartsForSubgroup(subgroupID)
 	diagram, _ := database.GetDiagramForSubgroup(subgroupID)
+	return newPartsListModel(database, subgroup, group, parts, diagram, prefetch)
+}
 
-	var items []ui.MenuItem
-	for _, p := range parts {
-		label := p.PartNumber
-		if p.PNC != nil {
-			label = fmt.Sprintf("[%s] %s", *p.PNC, p.PartNumber)
+// NewDiagramModel shows a diagram and its parts on the subgroup screen, for
+// parts listed without a subgroup. The cursor starts on partID.
+func NewDiagramModel(database *db.DB, diagramID string, partID int, prefetch *prefetcher) *SubgroupModel {
+	diagram, _ := database.GetDiagram(diagramID)
+	var group *db.Group
+	var subgroup *db.Subgroup
+	if diagram != nil {
+		group, _ = database.GetGroup(diagram.GroupID)
+		if diagram.SubgroupID != nil {
+			subgroup, _ = database.GetSubgroup(*diagram.SubgroupID)
 		}
-		hint := ""
-		if p.Description != nil {
-			hint = *p.Description
+	}
+	parts, _ := database.GetPartsForDiagram(diagramID)
+
+	m := newPartsListModel(database, subgroup, group, parts, diagram, prefetch)
+	for i, id := range m.table.IDs() {
+		if id == fmt.Sprintf("%d", partID) {
+			m.table.Cursor = i
 		}
-		items = append(items, ui.MenuItem{ID: fmt.Sprintf("%d", p.ID), Label: label, Hint: hint})
 	}
+	return m
+}
 
+func newPartsListModel(database *db.DB, subgroup *db.Subgroup, group *db.Group, parts []db.PartWithDiagram, diagram *db.Diagram, prefetch *prefetcher) *SubgroupModel {
+	origins, _ := database.GetOrigins()
+	superseded, _ := database.GetSupersededPartIDs()
 	m := &SubgroupModel{
-		db:         database,
-		subgroupID: subgroupID,
-		subgroup:   subgroup,
-		group:      group,
-		parts:      parts,
-		diagram:    diagram,
-		menu:       ui.NewMenu(items),
+		db:             database,
+		subgroup:       subgroup,
+		group:          group,
+		parts:          parts,
+		diagram:        diagram,
+		origins:        origins,
+		superseded:     superseded,
+		hideSuperseded: database.HidingSuperseded(),
+		columns:        loadColumnSet(database, "subgroup", subgroupColumns),
+		prefetch:       prefetch,
 	}
+	m.buildTable()
 
-	// Load image - use larger size for better visibility
+	// Load image - use larger size for better visibility. Part detail shows
+	// it at the same size, so it comes from the shared cache.
 	if diagram != nil && diagram.ImagePath != nil {
-		imgPath := filepath.Join(dataPath, *diagram.ImagePath)
-		if img, err := image.LoadAndScale(imgPath, 92, 46); err == nil {
+		imgPath := prefetch.diagramPath(*diagram.ImagePath)
+		if img, err := prefetch.diagrams.load(imgPath, diagramWidthCells, diagramHeightCells); err == nil {
 			m.img = img
 		} else {
 			m.imgError = err.Error()
 		}
+		if prev := previousRevisionPath(imgPath); prev != "" && m.img != nil {
+			m.previous, _ = prefetch.diagrams.load(prev, diagramWidthCells, diagramHeightCells)
+		}
+		m.hotspots, _ = database.GetHotspots(diagram.ID)
+		sort.SliceStable(m.hotspots, func(i, j int) bool { return refLess(m.hotspots[i].RefNumber, m.hotspots[j].RefNumber) })
 	}
 
 	return m
 }
 
+// buildTable lists the parts the origin filter and the superseded setting
+// let through, badged with their origins
+func (m *SubgroupModel) buildTable() {
+	// Parts already bookmarked or noted are tinted
+	saved, _ := m.db.GetSavedPartIDs()
+	if m.columns.has(priceColumn.key) && m.prices == nil {
+		partNumbers := make([]string, len(m.parts))
+		for i, p := range m.parts {
+			partNumbers[i] = p.PartNumber
+		}
+		m.prices = partPrices(m.db, partNumbers)
+	}
+
+	var rows []ui.TableRow
+	m.hidden = 0
+	for _, p := range m.parts {
+		if m.hideSuperseded && m.superseded[p.ID] {
+			m.hidden++
+			continue
+		}
+		if m.originFilter != "" && m.origins[strings.ToUpper(p.PartNumber)].Source != m.originFilter {
+			continue
+		}
+		rows = append(rows, m.columns.row(fmt.Sprintf("%d", p.ID), map[string]string{
+			"part":        p.PartNumber,
+			"pnc":         deref(p.PNC),
+			"description": badged(deref(p.Description), m.origins, p.PartNumber),
+			"spec":        deref(p.Spec),
+			"price":       priceCell(m.prices, p.PartNumber),
+			"dates":       deref(p.ModelDateRange),
+		}))
+	}
+	prev := m.table
+	m.table = ui.NewTable(m.columns.columns(), rows)
+	m.table.RowStyle = func(row ui.TableRow, col int, style lipgloss.Style) lipgloss.Style {
+		var partID int
+		fmt.Sscanf(row.ID, "%d", &partID)
+		if saved[partID] {
+			return style.Background(ui.ColorTint)
+		}
+		return style
+	}
+	m.table.KeepPosition(prev)
+}
+
 func (m *SubgroupModel) Update(msg tea.Msg) (*SubgroupModel, tea.Cmd, *Screen) {
 	switch msg := msg.(type) {
+	case prefetchMsg:
+		if msg.seq == m.prefetchSeq {
+			return m, m.prefetchAround(), nil
+		}
+	case revisionBlinkMsg:
+		if m.blinking && msg.seq == m.blinkSeq {
+			m.setShowPrevious(!m.showPrevious)
+			return m, blinkRevision(m.blinkSeq), nil
+		}
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			cmd, nav := m.clickCallout(msg.X, msg.Y)
+			return m, cmd, nav
+		}
 	case tea.KeyMsg:
-		if ui.IsUp(msg) {
-			m.menu.Up()
+		if m.callouts {
+			return m.updateCallouts(msg)
 		}
-		if ui.IsDown(msg) {
-			m.menu.Down()
+		// The column chooser takes keys until it's closed; the sorting
+		// starts over when the columns change
+		if m.columns.choosing {
+			if m.columns.update(msg) {
+				m.buildTable()
+				m.table.SortBy(-1, false)
+			}
+			return m, nil, nil
+		}
+		if ui.IsColumns(msg) {
+			m.columns.open()
+			return m, nil, nil
+		}
+		if m.previous != nil && ui.IsRevision(msg) {
+			m.blinking = false
+			m.setShowPrevious(!m.showPrevious)
+			return m, nil, nil
+		}
+		if m.previous != nil && ui.IsRevisionBlink(msg) {
+			m.blinking = !m.blinking
+			m.blinkSeq++
+			if !m.blinking {
+				m.setShowPrevious(false)
+				return m, nil, nil
+			}
+			return m, blinkRevision(m.blinkSeq), nil
 		}
+		if m.img != nil && ui.IsHotspots(msg) {
+			s := HotspotsScreen(m.diagram.ID)
+			return m, nil, &s
+		}
+		if m.img != nil && ui.IsCallouts(msg) {
+			return m, m.startCallouts(), nil
+		}
+		if m.img != nil && ui.IsFullScreen(msg) {
+			s := ViewerScreen(m.diagram.ID)
+			return m, nil, &s
+		}
+		if m.img != nil && ui.IsKeepDiagram(msg) {
+			caption := m.diagram.ID + "  " + strings.ToUpper(m.diagram.Name)
+			return m, keepDiagram(m.prefetch.diagramPath(*m.diagram.ImagePath), caption), nil
+		}
+		if ui.IsFilter(msg) && len(m.origins) > 0 {
+			m.originFilter = nextOriginFilter(m.originFilter)
+			m.buildTable()
+			m.prefetchSeq++
+			return m, m.prefetch.schedule(m.prefetchSeq), nil
+		}
+		cursor, sorted := m.table.Cursor, m.table.SortColumn
+		m.table.HandleKey(msg)
 		if ui.IsEnter(msg) {
-			if item := m.menu.Selected(); item != nil {
+			if item := m.table.Selected(); item != nil {
 				var partID int
 				fmt.Sscanf(item.ID, "%d", &partID)
 				s := PartDetailScreen(partID, false)
 				return m, nil, &s
 			}
 		}
+		if m.table.Cursor != cursor || m.table.SortColumn != sorted {
+			m.prefetchSeq++
+			return m, m.prefetch.schedule(m.prefetchSeq), nil
+		}
 	}
 	return m, nil, nil
 }
 
+// reloadSuperseded picks up a change to whether superseded parts are hidden
+func (m *SubgroupModel) reloadSuperseded() {
+	m.superseded, _ = m.db.GetSupersededPartIDs()
+	m.hideSuperseded = m.db.HidingSuperseded()
+	m.buildTable()
+}
+
+// hasSuperseded reports whether any part listed has its replacement in the
+// catalog
+func (m *SubgroupModel) hasSuperseded() bool {
+	for _, p := range m.parts {
+		if m.superseded[p.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// setShowPrevious swaps between the current and previous diagram revision,
+// scheduling the one being hidden for deletion
+func (m *SubgroupModel) setShowPrevious(show bool) {
+	if show != m.showPrevious {
+		m.clearImageID = m.diagramImage().ID()
+		m.showPrevious = show
+	}
+}
+
+// diagramImage is the revision on screen
+func (m *SubgroupModel) diagramImage() *image.KittyImage {
+	if m.callouts && m.marked != nil {
+		return m.marked
+	}
+	if m.showPrevious && m.previous != nil {
+		return m.previous
+	}
+	return m.img
+}
+
+// prefetchAround loads the parts near the cursor in the background
+func (m *SubgroupModel) prefetchAround() tea.Cmd {
+	if len(m.parts) == 0 {
+		return nil
+	}
+	// In the order shown, so the neighbours on screen are the ones loaded
+	ids := make([]int, len(m.table.Rows))
+	for i, id := range m.table.IDs() {
+		fmt.Sscanf(id, "%d", &ids[i])
+	}
+	return m.prefetch.run(m.prefetch.around(ids, m.table.Cursor))
+}
+
 func (m *SubgroupModel) View(width, height int) string {
 	if width == 0 {
 		width = 80
@@ -110,18 +368,22 @@ func (m *SubgroupModel) View(width, height int) string {
 	}
 
 	leftContent := m.renderDiagram(splitHeight)
-	rightContent := m.renderPartsList(splitHeight)
+	rightContent := m.renderPartsList(ui.SplitPaneRightWidth(width-2), splitHeight)
 
 	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
 
 	// Output image escape with positioning
 	// Save cursor, move to image position, render, restore cursor
-	if m.img != nil {
-		result.WriteString("\x1b7")    // Save cursor position
-		result.WriteString("  ")       // Left padding (matches split pane margin)
-		result.WriteString("\x1b[1B")  // Move cursor down 1 line (past diagram ID)
-		result.WriteString(m.img.Render())
-		result.WriteString("\x1b8")    // Restore cursor position
+	if m.clearImageID != 0 {
+		result.WriteString(image.Clear(m.clearImageID))
+		m.clearImageID = 0
+	}
+	if img := m.diagramImage(); img != nil {
+		result.WriteString("\x1b7")   // Save cursor position
+		result.WriteString("  ")      // Left padding (matches split pane margin)
+		result.WriteString("\x1b[1B") // Move cursor down 1 line (past diagram ID)
+		result.WriteString(img.Render())
+		result.WriteString("\x1b8") // Restore cursor position
 	}
 
 	result.WriteString(split)
@@ -133,15 +395,29 @@ func (m *SubgroupModel) renderDiagram(height int) string {
 	var lines []string
 
 	if m.img != nil {
-		// Add diagram ID above the image
+		// Add diagram ID above the image, and which revision is showing
 		if m.diagram != nil {
-			lines = append(lines, ui.DimStyle.Render(m.diagram.ID))
+			caption := ui.DimStyle.Render(m.diagram.ID)
+			if m.showPrevious {
+				caption += "  " + ui.ErrorStyle.Render("PREVIOUS REVISION")
+			} else if m.callouts {
+				caption += "  " + ui.SelectedStyle.Render("REF "+m.hotspots[m.spot].RefNumber)
+				if m.refRow(m.hotspots[m.spot].RefNumber) < 0 {
+					caption += ui.DimStyle.Render(" not listed")
+				}
+			} else if m.previous != nil {
+				caption += "  " + ui.DimStyle.Render("changed by last sync")
+			}
+			lines = append(lines, caption)
 		}
-		// Image is rendered separately in View(), just add placeholder lines
-		imgHeight := m.img.CellHeight()
-		for i := 0; i < imgHeight; i++ {
-			lines = append(lines, "")
+		// Image is rendered separately in View(), just add placeholder
+		// lines, unless it's drawn as text
+		img := m.diagramImage()
+		for i := 0; i < img.CellHeight(); i++ {
+			lines = append(lines, img.Row(i))
 		}
+	} else if image.Disabled {
+		lines = append(lines, ui.DimStyle.Render("Diagram hidden in low-bandwidth mode"))
 	} else if m.imgError != "" {
 		lines = append(lines, ui.ErrorStyle.Render(m.imgError))
 	} else {
@@ -156,53 +432,104 @@ func (m *SubgroupModel) renderDiagram(height int) string {
 	return strings.Join(lines, "\n")
 }
 
-func (m *SubgroupModel) renderPartsList(height int) string {
+func (m *SubgroupModel) renderPartsList(width, height int) string {
 	var b strings.Builder
 
 	// Header - show GROUP > SUBGROUP
 	title := "UNKNOWN"
 	if m.group != nil && m.subgroup != nil {
 		title = fmt.Sprintf("%s > %s", strings.ToUpper(m.group.Name), strings.ToUpper(m.subgroup.Name))
+	} else if m.group != nil && m.diagram != nil {
+		title = fmt.Sprintf("%s > %s", strings.ToUpper(m.group.Name), strings.ToUpper(m.diagram.Name))
 	}
 	b.WriteString(ui.HeaderStyle.Render(title))
 	b.WriteString(strings.Repeat(" ", 5))
-	b.WriteString(ui.CountStyle.Render(fmt.Sprintf("%d", len(m.parts))))
+	b.WriteString(ui.CountStyle.Render(fmt.Sprintf("%d", len(m.table.Rows))))
+	var narrowed []string
+	if m.originFilter != "" {
+		narrowed = append(narrowed, db.SourceLabel(m.originFilter)+" only")
+	}
+	if m.hidden > 0 {
+		narrowed = append(narrowed, fmt.Sprintf("%d superseded hidden", m.hidden))
+	}
+	if len(narrowed) > 0 {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf(" of %d, %s", len(m.parts), strings.Join(narrowed, ", "))))
+	}
 	b.WriteString("\n")
 	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
 
-	// Adjust menu visible items based on available height (max 15)
-	// Header takes 3 lines, footer takes 2 lines
-	menuHeight := height - 5
-	if menuHeight < 5 {
-		menuHeight = 5
+	// Adjust table visible rows based on available height (max 16 with
+	// the column titles). Header takes 3 lines, footer takes 2 lines
+	tableHeight := height - 4
+	if tableHeight < 6 {
+		tableHeight = 6
 	}
-	if menuHeight > 15 {
-		menuHeight = 15
+	if tableHeight > 16 {
+		tableHeight = 16
 	}
-	m.menu.MaxVisibleItems = menuHeight
+	m.table.MaxVisibleItems = tableHeight
+	m.table.Width = width
+	// Extra columns widen each panel, leaving the description its share
+	panelWidth := max(partColumnWidth, m.columns.fixedWidth()+minDescriptionWidth)
+	m.table.Panels = min(max(width/panelWidth, 1), maxPartColumns)
 
-	// One less blank line if menu scrolls (to account for scroll indicator)
-	if len(m.menu.Items) > m.menu.MaxVisibleItems {
+	// One less blank line if the table scrolls (to account for scroll indicator)
+	if len(m.table.Rows) > (m.table.MaxVisibleItems-1)*m.table.Panels {
 		b.WriteString("\n")
 	} else {
 		b.WriteString("\n\n")
 	}
 
-	if len(m.parts) == 0 {
+	if m.columns.choosing {
+		b.WriteString(m.columns.view())
+		return b.String()
+	} else if len(m.parts) == 0 {
 		b.WriteString(ui.DimStyle.Render("No parts found"))
+	} else if len(m.table.Rows) == 0 && m.originFilter != "" {
+		b.WriteString(ui.DimStyle.Render(fmt.Sprintf("No %s parts here; f shows the next origin", db.SourceLabel(m.originFilter))))
+	} else if len(m.table.Rows) == 0 {
+		b.WriteString(ui.DimStyle.Render("Every part here is superseded; ctrl+x shows them"))
 	} else {
-		b.WriteString(m.menu.View())
+		b.WriteString(m.table.View())
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter select"))
+	help := "↑↓ navigate   enter select   " + m.table.SortHint()
+	if m.table.Panels > 1 {
+		help = "↑↓ navigate   ←→ column   enter select   " + m.table.SortHint()
+	}
+	if m.previous != nil {
+		help += "   r previous   R blink"
+	}
+	if m.img != nil {
+		help += "   C hotspots"
+	}
+	if m.img != nil && len(m.hotspots) > 0 {
+		help += "   v callouts"
+	}
+	if m.img != nil {
+		help += "   F full screen   P keep for scrollback"
+	}
+	if len(m.origins) > 0 {
+		help += "   f origin"
+	}
+	if m.hidden > 0 {
+		help += "   ctrl+x show superseded"
+	} else if m.hasSuperseded() {
+		help += "   ctrl+x hide superseded"
+	}
+	help += "   ctrl+k columns"
+	if m.callouts {
+		help = "←↑↓→ callout   tab/shift+tab next   enter open   v/esc list"
+	}
+	b.WriteString(ui.DimStyle.Render(help))
 
 	return b.String()
 }
 
 func (m *SubgroupModel) ImageID() uint32 {
-	if m.img != nil {
-		return m.img.ID()
+	if img := m.diagramImage(); img != nil {
+		return img.ID()
 	}
 	return 0
 }
@@ -0,0 +1,349 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/jobs"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// templateRow is one item of a job template, resolved against the catalog.
+// err says why it can't be added.
+type templateRow struct {
+	item  jobs.Item
+	part  db.PNCPart
+	other int // further part numbers carrying the PNC that fit as well
+	err   string
+}
+
+// TemplatesModel lists job templates and turns one into shortlist items:
+// each PNC is resolved to a part in the catalog, preferring parts whose
+// date range covers the vehicle's build date, and PNCs the catalog doesn't
+// carry are flagged, like lines of a pasted list.
+type TemplatesModel struct {
+	db        *db.DB
+	dataPath  string
+	templates []jobs.Template
+	loadErrs  []error
+	menu      *ui.Menu
+
+	template *jobs.Template // being checked, nil while picking
+	vehicle  db.Vehicle     // the active van when it was checked
+	rows     []templateRow
+	cursor   int
+	status   string
+}
+
+func NewTemplatesModel(database *db.DB, dataPath string) *TemplatesModel {
+	templates, errs := jobs.Load(dataPath)
+	m := &TemplatesModel{db: database, dataPath: dataPath, templates: templates, loadErrs: errs}
+
+	var items []ui.MenuItem
+	for _, t := range templates {
+		items = append(items, ui.MenuItem{
+			ID:    t.ID,
+			Label: t.Name,
+			Hint:  fmt.Sprintf("%d parts · %s", len(t.Items), t.Source),
+		})
+	}
+	m.menu = ui.NewMenu(items)
+	return m
+}
+
+func (m *TemplatesModel) Update(msg tea.Msg) (*TemplatesModel, tea.Cmd, *Screen) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, nil
+	}
+	if m.template != nil {
+		return m.updateCheck(key)
+	}
+
+	if m.menu.HandleKey(key) {
+		return m, nil, nil
+	}
+	if ui.IsEnter(key) && m.menu.Selected() != nil {
+		m.check(&m.templates[m.menu.Cursor])
+	}
+	return m, nil, nil
+}
+
+// updateCheck handles keys on a resolved template: enter adds the rows
+// found, esc goes back to the list
+func (m *TemplatesModel) updateCheck(msg tea.KeyMsg) (*TemplatesModel, tea.Cmd, *Screen) {
+	if cursor, ok := ui.MoveCursor(msg, m.cursor, len(m.rows), 10, false); ok {
+		m.cursor = cursor
+		return m, nil, nil
+	}
+	switch {
+	case ui.IsBack(msg):
+		m.template = nil
+		m.status = ""
+		return m, nil, nil
+	case ui.IsEnter(msg):
+		return m, m.add(), nil
+	}
+	return m, nil, nil
+}
+
+// check resolves every item of t against the catalog, then shows the result
+func (m *TemplatesModel) check(t *jobs.Template) {
+	m.template = t
+	m.rows = nil
+	m.cursor = 0
+	m.status = ""
+
+	m.vehicle = activeVehicle(m.db)
+	built := m.vehicle.ManufactureDate
+	vehicle := m.vehicle.Attributes()
+	for _, item := range t.Items {
+		row := templateRow{item: item}
+		parts, err := m.db.GetPartsForPNC(strings.TrimSpace(item.PNC))
+		switch {
+		case err != nil:
+			row.err = fmt.Sprintf("lookup failed: %v", err)
+		case len(parts) == 0:
+			row.err = "PNC not in the catalog"
+		default:
+			fitting := fittingParts(parts, built)
+			if len(fitting) == 0 {
+				row.err = fmt.Sprintf("no part listed for a %s build", built)
+				break
+			}
+			if fitting = m.matchingSpec(fitting, vehicle); len(fitting) == 0 {
+				row.err = fmt.Sprintf("no part listed for a %s van", describeSpec(vehicle))
+				break
+			}
+			row.part = fitting[0]
+			seen := map[string]bool{row.part.PartNumber: true}
+			for _, p := range fitting[1:] {
+				if !seen[p.PartNumber] {
+					seen[p.PartNumber] = true
+					row.other++
+				}
+			}
+		}
+		m.rows = append(m.rows, row)
+	}
+}
+
+// fittingParts keeps the parts whose date range covers the build date, or
+// can't say, so a catalog without dates or a vehicle without a build date
+// keeps them all
+func fittingParts(parts []db.PNCPart, built string) []db.PNCPart {
+	var fitting []db.PNCPart
+	for _, p := range parts {
+		if p.ModelDateRange != nil && built != "" {
+			if covers, ok := db.DateRangeCovers(*p.ModelDateRange, built); ok && !covers {
+				continue
+			}
+		}
+		fitting = append(fitting, p)
+	}
+	return fitting
+}
+
+// matchingSpec keeps the parts whose spec doesn't rule out the vehicle's,
+// so a part listed for MT is left out for an AT van, while parts whose spec
+// says nothing of the transmission stay
+func (m *TemplatesModel) matchingSpec(parts []db.PNCPart, vehicle []db.Attribute) []db.PNCPart {
+	if len(vehicle) == 0 {
+		return parts
+	}
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		ids[i] = p.PartID
+	}
+	attrs, err := m.db.GetAttributes(ids...)
+	if err != nil {
+		return parts
+	}
+	var matching []db.PNCPart
+	for _, p := range parts {
+		if len(db.Conflicts(attrs[p.PartID], vehicle)) == 0 {
+			matching = append(matching, p)
+		}
+	}
+	return matching
+}
+
+func (m *TemplatesModel) counts() (ready, flagged int) {
+	for _, row := range m.rows {
+		if row.err == "" {
+			ready++
+		} else {
+			flagged++
+		}
+	}
+	return ready, flagged
+}
+
+// add puts the rows found on the shortlist, noting the job on each
+func (m *TemplatesModel) add() tea.Cmd {
+	var items []shortlistItem
+	for _, row := range m.rows {
+		if row.err != "" {
+			continue
+		}
+		note := m.template.Name
+		if row.item.Note != "" {
+			note += ": " + row.item.Note
+		}
+		items = append(items, shortlistItem{
+			partID:      row.part.PartID,
+			partNumber:  row.part.PartNumber,
+			description: deref(row.part.Description),
+			qty:         max(row.item.Qty, 1),
+			note:        note,
+		})
+	}
+	if len(items) == 0 {
+		m.status = "None of the template's PNCs are in the catalog"
+		return nil
+	}
+
+	_, flagged := m.counts()
+	m.status = fmt.Sprintf("Added %d parts to the shortlist", len(items))
+	if flagged > 0 {
+		m.status += fmt.Sprintf("; %d flagged left out", flagged)
+	}
+	return func() tea.Msg { return shortlistItemsMsg{items: items} }
+}
+
+func (m *TemplatesModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftWidth := ui.SplitPaneLeftWidth(width - 2)
+	leftContent := m.renderLeftPane(leftWidth, splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *TemplatesModel) renderLeftPane(width, height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("JOB TEMPLATES"))
+	lines = append(lines, "")
+
+	t := m.template
+	if t == nil && m.menu.Selected() != nil {
+		t = &m.templates[m.menu.Cursor]
+	}
+	wrap := lipgloss.NewStyle().Width(max(width-2, 10))
+	if t != nil && t.Description != "" {
+		lines = append(lines, strings.Split(wrap.Render(t.Description), "\n")...)
+		lines = append(lines, "")
+	}
+	if m.template != nil {
+		ready, flagged := m.counts()
+		lines = append(lines, fmt.Sprintf("%d found", ready))
+		if flagged > 0 {
+			lines = append(lines, ui.ErrorStyle.Render(fmt.Sprintf("%d flagged", flagged)))
+		}
+		if built := m.vehicle.ManufactureDate; built != "" {
+			lines = append(lines, ui.DimStyle.Render("Parts for a "+built+" build"))
+		}
+		lines = append(lines, "")
+	}
+
+	for _, err := range m.loadErrs {
+		lines = append(lines, strings.Split(ui.ErrorStyle.Width(max(width-2, 10)).Render(err.Error()), "\n")...)
+	}
+	lines = append(lines, strings.Split(ui.DimStyle.Width(max(width-2, 10)).Render("Add your own as JSON in "+jobs.Dir(m.dataPath)), "\n")...)
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *TemplatesModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	if m.template == nil {
+		b.WriteString(ui.HeaderStyle.Render("START A JOB"))
+		b.WriteString("\n")
+		b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+		b.WriteString("\n\n")
+		m.menu.MaxVisibleItems = max(height-7, 5)
+		b.WriteString(m.menu.View())
+		b.WriteString("\n\n")
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter check against the catalog   esc back"))
+		return b.String()
+	}
+
+	b.WriteString(ui.HeaderStyle.Render(strings.ToUpper(m.template.Name)))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	maxRows := max(height-7, 5)
+	start := max(0, min(m.cursor-maxRows/2, len(m.rows)-maxRows))
+	end := min(start+maxRows, len(m.rows))
+	labelStyle := lipgloss.NewStyle().Width(18)
+	for i := start; i < end; i++ {
+		row := m.rows[i]
+		mark := ui.SelectedStyle.Render("✓ ")
+		label := row.part.PartNumber
+		hint := fmt.Sprintf("x%d  %s  %s", max(row.item.Qty, 1), row.item.PNC, strings.ToUpper(deref(row.part.Description)))
+		if row.other > 0 {
+			hint += fmt.Sprintf(" (+%d other part numbers)", row.other)
+		}
+		if row.err != "" {
+			mark = ui.ErrorStyle.Render("✗ ")
+			label = row.item.PNC
+			hint = strings.ToUpper(row.item.Name) + ": " + row.err
+		}
+		hint = truncateText(hint, max(width-22, 10))
+		if row.err != "" {
+			hint = ui.ErrorStyle.Render(hint)
+		} else {
+			hint = ui.DimStyle.Render(hint)
+		}
+
+		if i == m.cursor {
+			b.WriteString(ui.SelectedStyle.Render("> ") + mark + ui.SelectedLabelStyle.Render(labelStyle.Render(label)) + hint + "\n")
+		} else {
+			b.WriteString("  " + mark + ui.NormalLabelStyle.Render(labelStyle.Render(label)) + hint + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(m.status)
+		b.WriteString("\n")
+	}
+	ready, _ := m.counts()
+	b.WriteString(ui.DimStyle.Render(fmt.Sprintf("↑↓ select   enter add %d to shortlist   esc back to templates", ready)))
+
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/notify"
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toastDuration is how long a toast stays up
+const toastDuration = 5 * time.Second
+
+// toastMsg shows a one-line notice along the bottom of every screen.
+// clipboard, if set, is text for the terminal to copy, for when no
+// clipboard program could.
+type toastMsg struct {
+	text      string
+	isError   bool
+	clipboard string
+}
+
+type toastExpiredMsg struct {
+	seq int
+}
+
+// toast is the notice currently shown, if any. A newer toast replaces it and
+// restarts the timer.
+type toast struct {
+	text    string
+	isError bool
+	seq     int
+}
+
+func (t *toast) show(msg toastMsg) tea.Cmd {
+	t.text, t.isError = msg.text, msg.isError
+	t.seq++
+	seq := t.seq
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{seq: seq}
+	})
+}
+
+func (t *toast) expire(msg toastExpiredMsg) {
+	if msg.seq == t.seq {
+		t.text = ""
+	}
+}
+
+// height is how many lines the toast takes below the screen.
+func (t *toast) height() int {
+	if t.text == "" {
+		return 0
+	}
+	return 1
+}
+
+func (t *toast) View(width int) string {
+	style := ui.DimStyle
+	if t.isError {
+		style = ui.ErrorStyle
+	}
+	return "  " + style.MaxWidth(max(width-4, 0)).Render(t.text)
+}
+
+// openCmd opens a URL or file with the desktop's default app in the
+// background. When that fails it's copied to the clipboard instead, so the
+// user can paste it somewhere that works.
+func openCmd(target string) tea.Cmd {
+	return func() tea.Msg {
+		err := opener.Open(target)
+		if err == nil {
+			return nil
+		}
+		if opener.Copy(target) == nil {
+			return toastMsg{text: fmt.Sprintf("Copied to the clipboard instead: %v", err)}
+		}
+		return toastMsg{
+			text:      fmt.Sprintf("Asked the terminal to copy it instead: %v", err),
+			isError:   true,
+			clipboard: target,
+		}
+	}
+}
+
+// bellMsg rings the terminal bell on the next render
+type bellMsg struct{}
+
+// announce tells you a background job of the given notify kind finished,
+// with a bell, a desktop notification or both
+func announce(kind, title, summary string) tea.Cmd {
+	method := notify.For(kind)
+	var cmds []tea.Cmd
+	if method.Bell {
+		cmds = append(cmds, func() tea.Msg { return bellMsg{} })
+	}
+	if method.Desktop {
+		cmds = append(cmds, func() tea.Msg {
+			if err := notify.Desktop(title, summary); err != nil {
+				return toastMsg{text: fmt.Sprintf("Desktop notification failed: %v", err), isError: true}
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
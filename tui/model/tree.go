@@ -0,0 +1,393 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TreeModel shows the whole catalog as one collapsible tree of groups,
+// subgroups and parts, like the file tree of an editor. A group's
+// subgroups and a subgroup's parts are read the first time it's opened.
+// Typing filters the tree to what matches and the way down to it.
+type TreeModel struct {
+	db     *db.DB
+	roots  []*treeNode
+	rows   []treeRow // the nodes shown, in order
+	menu   *ui.Menu
+	input  textinput.Model
+	counts *db.Counts
+
+	// Every group's subgroups are read on the first filter, so they can
+	// match before being opened
+	allLoaded bool
+}
+
+type treeKind int
+
+const (
+	treeGroup treeKind = iota
+	treeSubgroup
+	treePart
+)
+
+type treeNode struct {
+	kind   treeKind
+	id     string
+	label  string // as matched by the filter, lowercased
+	hint   string
+	part   *db.PartWithDiagram
+	parent *treeNode
+
+	children []*treeNode
+	loaded   bool // children read
+	open     bool
+}
+
+type treeRow struct {
+	node  *treeNode
+	depth int
+	open  bool // its children are shown, opened or leading to a match
+}
+
+func NewTreeModel(database *db.DB) *TreeModel {
+	ti := textinput.New()
+	ti.Placeholder = "Type to filter groups, subgroups and opened parts..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	m := &TreeModel{db: database, input: ti, menu: ui.NewMenu(nil)}
+	m.counts, _ = database.GetCounts()
+
+	groups, _ := database.GetGroups()
+	for _, g := range groups {
+		node := &treeNode{kind: treeGroup, id: g.ID, label: strings.ToLower(g.Name)}
+		if m.counts != nil {
+			node.hint = countLabel(m.counts.GroupSubgroups[g.ID], "subgroup")
+		}
+		m.roots = append(m.roots, node)
+	}
+	m.rebuild()
+	return m
+}
+
+// countLabel is n of noun, pluralized
+func countLabel(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// load reads a node's children, the first time only
+func (m *TreeModel) load(n *treeNode) {
+	if n.loaded || n.kind == treePart {
+		return
+	}
+	n.loaded = true
+	switch n.kind {
+	case treeGroup:
+		subgroups, _ := m.db.GetSubgroups(n.id)
+		for _, s := range subgroups {
+			child := &treeNode{kind: treeSubgroup, id: s.ID, label: strings.ToLower(s.Name), parent: n}
+			if m.counts != nil {
+				child.hint = countLabel(m.counts.SubgroupParts[s.ID], "part")
+			}
+			n.children = append(n.children, child)
+		}
+	case treeSubgroup:
+		parts, _ := m.db.GetPartsForSubgroup(n.id)
+		for i := range parts {
+			p := &parts[i]
+			label := p.PartNumber
+			if desc := deref(p.Description); desc != "" {
+				label += "  " + desc
+			}
+			child := &treeNode{kind: treePart, id: strconv.Itoa(p.ID), label: strings.ToLower(label), part: p, parent: n}
+			if ref := strings.TrimSpace(deref(p.RefNumber)); ref != "" {
+				child.hint = "ref " + ref
+			}
+			n.children = append(n.children, child)
+		}
+	}
+}
+
+// key identifies a node among the menu's items
+func (n *treeNode) key() string {
+	return fmt.Sprintf("%d:%s", n.kind, n.id)
+}
+
+// path is the labels from the root down to n, for matching a filter
+// against where a node is as well as what it is
+func (n *treeNode) path() string {
+	if n.parent == nil {
+		return n.label
+	}
+	return n.parent.path() + " " + n.label
+}
+
+// matches reports whether every term is in n's path and one in n itself,
+// so "engine belt" matches the belt subgroup under ENGINE but not the
+// group
+func (n *treeNode) matches(terms []string) bool {
+	path, own := n.path(), false
+	for _, term := range terms {
+		if !strings.Contains(path, term) {
+			return false
+		}
+		own = own || strings.Contains(n.label, term)
+	}
+	return own
+}
+
+// rebuild lists the nodes to show, keeping the cursor on the node it was on
+func (m *TreeModel) rebuild() {
+	terms := strings.Fields(strings.ToLower(m.input.Value()))
+	if len(terms) > 0 && !m.allLoaded {
+		for _, g := range m.roots {
+			m.load(g)
+		}
+		m.allLoaded = true
+	}
+
+	m.rows = m.rows[:0]
+	for _, n := range m.roots {
+		m.add(n, 0, terms)
+	}
+
+	items := make([]ui.MenuItem, len(m.rows))
+	for i, row := range m.rows {
+		items[i] = ui.MenuItem{ID: row.node.key(), Label: row.label(), Hint: row.node.hint}
+	}
+	prev := m.menu
+	m.menu = ui.NewMenu(items)
+	m.menu.MaxVisibleItems = prev.MaxVisibleItems
+	m.menu.KeepPosition(prev)
+}
+
+// add lists n, if it's shown, and what's shown under it. Without a filter
+// that's the children of open nodes. With one, a node is shown if it
+// matches or leads to one that does, and a match's children are shown as
+// if unfiltered. It reports whether n was shown.
+func (m *TreeModel) add(n *treeNode, depth int, terms []string) bool {
+	if len(terms) == 0 || n.matches(terms) {
+		m.rows = append(m.rows, treeRow{node: n, depth: depth, open: n.open})
+		if n.open {
+			for _, c := range n.children {
+				m.add(c, depth+1, nil)
+			}
+		}
+		return true
+	}
+
+	at := len(m.rows)
+	m.rows = append(m.rows, treeRow{node: n, depth: depth, open: true})
+	shown := false
+	for _, c := range n.children {
+		shown = m.add(c, depth+1, terms) || shown
+	}
+	if !shown {
+		m.rows = m.rows[:at]
+	}
+	return shown
+}
+
+// label draws a row: indented by depth, with whether it's open. The menu
+// shows it in capitals, as the catalog has its names.
+func (r treeRow) label() string {
+	indent := strings.Repeat("  ", r.depth)
+	n := r.node
+	switch {
+	case n.kind == treePart:
+		return indent + "  " + n.part.PartNumber + "  " + deref(n.part.Description)
+	case r.open:
+		return indent + "▾ " + n.label
+	default:
+		return indent + "▸ " + n.label
+	}
+}
+
+// selected returns the node under the cursor
+func (m *TreeModel) selected() *treeNode {
+	if m.menu.Cursor < 0 || m.menu.Cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.menu.Cursor].node
+}
+
+// selectedPart returns the part under the cursor, if it's on one
+func (m *TreeModel) selectedPart() *db.PartWithDiagram {
+	if n := m.selected(); n != nil {
+		return n.part
+	}
+	return nil
+}
+
+// Filtering reports whether a filter is typed, which esc clears before
+// it leaves the screen.
+func (m *TreeModel) Filtering() bool {
+	return m.input.Value() != ""
+}
+
+func (m *TreeModel) Update(msg tea.Msg) (*TreeModel, tea.Cmd, *Screen) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Letter keys type into the filter, so only arrow, page and ctrl
+		// keys navigate
+		page := max(m.menu.MaxVisibleItems-2, 1)
+		if cursor, ok := ui.MoveCursor(msg, m.menu.Cursor, len(m.rows), page, true); ok {
+			m.menu.Cursor = cursor
+			return m, nil, nil
+		}
+		n := m.selected()
+		switch {
+		case ui.IsBack(msg):
+			m.input.SetValue("")
+			m.rebuild()
+			return m, nil, nil
+		case msg.Type == tea.KeyRight:
+			if n != nil && n.kind != treePart {
+				if n.open {
+					m.menu.Down()
+				} else {
+					m.toggle(n)
+				}
+			}
+			return m, nil, nil
+		case msg.Type == tea.KeyLeft:
+			if n == nil {
+				return m, nil, nil
+			}
+			if n.open {
+				m.toggle(n)
+			} else if n.parent != nil {
+				m.moveTo(n.parent)
+			}
+			return m, nil, nil
+		case ui.IsEnter(msg):
+			if n == nil {
+				return m, nil, nil
+			}
+			if n.kind == treePart {
+				s := PartDetailScreen(n.part.ID, false)
+				return m, nil, &s
+			}
+			m.toggle(n)
+			return m, nil, nil
+		case ui.IsOpenScreen(msg):
+			// The level's own screen, for its diagram
+			if n == nil {
+				return m, nil, nil
+			}
+			var s Screen
+			switch n.kind {
+			case treeGroup:
+				s = GroupScreen(n.id)
+			case treeSubgroup:
+				s = SubgroupScreen(n.id)
+			default:
+				s = DiagramScreen(n.part.DiagramID, n.part.ID)
+			}
+			return m, nil, &s
+		}
+	}
+
+	prevValue := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != prevValue {
+		m.rebuild()
+		m.menu.Cursor = m.firstMatch()
+	}
+	return m, cmd, nil
+}
+
+// toggle opens or closes a group or subgroup, reading its children the
+// first time
+func (m *TreeModel) toggle(n *treeNode) {
+	m.load(n)
+	n.open = !n.open
+	m.rebuild()
+}
+
+// moveTo puts the cursor on n
+func (m *TreeModel) moveTo(n *treeNode) {
+	for i, row := range m.rows {
+		if row.node == n {
+			m.menu.Cursor = i
+			return
+		}
+	}
+}
+
+// firstMatch is the row of the first node matching the filter itself,
+// rather than leading to one
+func (m *TreeModel) firstMatch() int {
+	terms := strings.Fields(strings.ToLower(m.input.Value()))
+	for i, row := range m.rows {
+		if len(terms) == 0 || row.node.matches(terms) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *TreeModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	var b strings.Builder
+
+	// Top margin (2 blank lines to match other pages)
+	b.WriteString("\n\n")
+	margin := strings.Repeat(" ", 2)
+
+	title := ui.HeaderStyle.Render("CATALOG TREE")
+	if len(m.roots) > 0 {
+		title += strings.Repeat(" ", 5) + ui.CountStyle.Render(fmt.Sprintf("%d", len(m.roots)))
+	}
+	b.WriteString(margin + title + "\n")
+	for _, line := range strings.Split(ui.BoxStyle.Render(m.input.View()), "\n") {
+		b.WriteString(margin + line + "\n")
+	}
+	b.WriteString("\n")
+
+	// The rest, less the help line
+	m.menu.MaxVisibleItems = max(height-10, 5)
+	m.menu.Width = width - 4
+	var list string
+	switch {
+	case len(m.roots) == 0:
+		list = ui.DimStyle.Render("The catalog is empty")
+	case len(m.rows) == 0:
+		list = ui.DimStyle.Render(fmt.Sprintf("No matches for \"%s\"", strings.TrimSpace(m.input.Value())))
+	default:
+		list = m.menu.View()
+	}
+	list = lipgloss.NewStyle().MaxWidth(width - 4).Render(list)
+	for _, line := range strings.Split(ui.FitHeight(list, m.menu.MaxVisibleItems), "\n") {
+		b.WriteString(margin + line + "\n")
+	}
+
+	b.WriteString("\n")
+	help := "↑↓ navigate   →/← expand/collapse   enter toggle or open part   tab own screen   esc back"
+	if m.Filtering() {
+		help = strings.Replace(help, "esc back", "esc clear filter", 1)
+	}
+	b.WriteString(margin + ui.DimStyle.Render(truncateText(help, width-4)))
+
+	return b.String()
+}
@@ -0,0 +1,407 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultVehicleName is shown for a van without a profile or a name
+const defaultVehicleName = "Mitsubishi Delica Space Gear"
+
+// vehicleFromEnv reads the van configured in .env before there were
+// profiles, to seed the first one. ok is false when nothing is set.
+func vehicleFromEnv() (v db.Vehicle, ok bool) {
+	v = db.Vehicle{
+		Name:            os.Getenv("VEHICLE_NAME"),
+		FrameNo:         os.Getenv("FRAME_NO"),
+		FrameName:       os.Getenv("FRAME_NAME"),
+		TrimCode:        os.Getenv("TRIM_CODE"),
+		ExteriorCode:    os.Getenv("EXTERIOR_CODE"),
+		InteriorCode:    os.Getenv("INTERIOR_CODE"),
+		ManufactureDate: os.Getenv("MANUFACTURE_DATE"),
+		Spec:            os.Getenv("VEHICLE_SPEC"),
+	}
+	if v == (db.Vehicle{}) {
+		return v, false
+	}
+	if v.Name == "" {
+		v.Name = defaultVehicleName
+	}
+	return v, true
+}
+
+// activeVehicle returns the active vehicle profile, or an empty one when
+// there are none
+func activeVehicle(database *db.DB) db.Vehicle {
+	if v, _ := database.GetActiveVehicle(); v != nil {
+		return *v
+	}
+	return db.Vehicle{}
+}
+
+// vehicleName is how a van is titled, e.g. "HSEUE9 Chamonix (HIGH-ROOF),
+// 4CA/T complectation"
+func vehicleName(v db.Vehicle) string {
+	if v.Name == "" {
+		return defaultVehicleName
+	}
+	return v.Name
+}
+
+// describeSpec lists attribute values, e.g. "AT HIGH ROOF"
+func describeSpec(attrs []db.Attribute) string {
+	values := make([]string, len(attrs))
+	for i, a := range attrs {
+		values[i] = a.Value
+	}
+	return strings.Join(values, " ")
+}
+
+// vehicleFields are a profile's fields in the order the editor goes
+// through them
+var vehicleFields = []struct {
+	field string
+	label string
+	hint  string
+}{
+	{db.VehicleName, "Name", "as the EPC names the complectation"},
+	{db.VehicleFrameNo, "Frame No", "PD6W-0500900"},
+	{db.VehicleFrameName, "Frame", "frame code for EPC links, pd6w"},
+	{db.VehicleTrimCode, "Trim", "complectation code for EPC links, hseue9"},
+	{db.VehicleManufactureDate, "Built", "1999.07.3"},
+	{db.VehicleSpec, "Spec", "4WD, AT, HIGH ROOF, LWB, 6G74"},
+	{db.VehicleExteriorCode, "Exterior", "paint code, W09M"},
+	{db.VehicleInteriorCode, "Interior", "trim color code, 57A"},
+}
+
+var vehicleColumns = []ui.Column{
+	{Title: "", Width: 2},
+	{Title: "NAME"},
+	{Title: "FRAME NO", Width: 14},
+	{Title: "BUILT", Width: 11},
+}
+
+// VehiclesModel lists the vehicle profiles, switches the active one and
+// edits them a field at a time.
+type VehiclesModel struct {
+	db       *db.DB
+	vehicles []db.Vehicle
+	table    *ui.Table
+	status   string
+
+	// Field editor, going through vehicleFields in turn
+	editing bool
+	field   int // into vehicleFields
+	input   textinput.Model
+	err     string
+
+	// Profile waiting for y to remove it, or 0
+	removing int
+}
+
+func NewVehiclesModel(database *db.DB) *VehiclesModel {
+	ti := textinput.New()
+	ti.CharLimit = 200
+	ti.Width = 40
+	ti.Prompt = ""
+	m := &VehiclesModel{db: database, input: ti}
+	m.load()
+	return m
+}
+
+func (m *VehiclesModel) load() {
+	m.vehicles, _ = m.db.GetVehicles()
+	rows := make([]ui.TableRow, len(m.vehicles))
+	for i, v := range m.vehicles {
+		active := ""
+		if v.Active {
+			active = "●"
+		}
+		rows[i] = ui.TableRow{
+			ID:    strconv.Itoa(v.ID),
+			Cells: []string{active, vehicleName(v), v.FrameNo, locale.DateString(v.ManufactureDate)},
+		}
+	}
+	prev := m.table
+	m.table = ui.NewTable(vehicleColumns, rows)
+	if prev != nil {
+		m.table.KeepPosition(prev)
+	}
+}
+
+// Editing reports whether a field is being edited or a removal confirmed,
+// when keys go to this screen rather than the app
+func (m *VehiclesModel) Editing() bool {
+	return m.editing || m.removing != 0
+}
+
+// selected returns the profile under the cursor, if any
+func (m *VehiclesModel) selected() *db.Vehicle {
+	item := m.table.Selected()
+	if item == nil {
+		return nil
+	}
+	for i := range m.vehicles {
+		if strconv.Itoa(m.vehicles[i].ID) == item.ID {
+			return &m.vehicles[i]
+		}
+	}
+	return nil
+}
+
+// selectVehicle moves the cursor to a profile
+func (m *VehiclesModel) selectVehicle(id int) {
+	for i, row := range m.table.Rows {
+		if row.ID == strconv.Itoa(id) {
+			m.table.Cursor = i
+		}
+	}
+}
+
+// edit opens the editor on field index of the selected profile
+func (m *VehiclesModel) edit(index int) tea.Cmd {
+	v := m.selected()
+	if v == nil {
+		return nil
+	}
+	m.editing = true
+	m.field = index
+	m.err = ""
+	m.input.SetValue(v.Field(vehicleFields[index].field))
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *VehiclesModel) closeEditor() {
+	m.editing = false
+	m.input.Blur()
+}
+
+// updateEditor saves a field on enter and moves to the next, until the
+// last; tab skips a field unsaved and esc stops
+func (m *VehiclesModel) updateEditor(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case ui.IsBack(msg):
+			m.closeEditor()
+			return nil
+		case msg.Type == tea.KeyTab:
+			return m.edit((m.field + 1) % len(vehicleFields))
+		case ui.IsEnter(msg):
+			v := m.selected()
+			if v == nil {
+				m.closeEditor()
+				return nil
+			}
+			field := vehicleFields[m.field].field
+			value := strings.TrimSpace(m.input.Value())
+			if field == db.VehicleName && value == "" {
+				value = v.Name
+			}
+			if err := m.db.SetVehicleField(v.ID, field, value); err != nil {
+				m.err = err.Error()
+				return nil
+			}
+			m.load()
+			if m.field+1 < len(vehicleFields) {
+				return m.edit(m.field + 1)
+			}
+			m.closeEditor()
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return cmd
+}
+
+func (m *VehiclesModel) Update(msg tea.Msg) (*VehiclesModel, tea.Cmd, *Screen) {
+	if m.editing {
+		return m, m.updateEditor(msg), nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, nil
+	}
+
+	if m.removing != 0 {
+		if ui.IsConfirm(keyMsg) {
+			if err := m.db.RemoveVehicle(m.removing); err != nil {
+				m.status = fmt.Sprintf("Not removed: %v", err)
+			} else {
+				m.status = "Profile removed"
+			}
+			m.load()
+		} else {
+			m.status = ""
+		}
+		m.removing = 0
+		return m, nil, nil
+	}
+
+	m.status = ""
+	switch {
+	case ui.IsEnter(keyMsg):
+		if v := m.selected(); v != nil && !v.Active {
+			if err := m.db.SetActiveVehicle(v.ID); err != nil {
+				m.status = fmt.Sprintf("Not switched: %v", err)
+				return m, nil, nil
+			}
+			m.status = "Now using " + vehicleName(*v)
+			m.load()
+		}
+		return m, nil, nil
+	case ui.IsAttach(keyMsg):
+		id, err := m.db.AddVehicle(db.Vehicle{Name: fmt.Sprintf("Van %d", len(m.vehicles)+1)})
+		if err != nil {
+			m.status = fmt.Sprintf("Not added: %v", err)
+			return m, nil, nil
+		}
+		m.load()
+		m.selectVehicle(id)
+		// Typing names it; enter on nothing keeps the stand-in name
+		cmd := m.edit(0)
+		m.input.SetValue("")
+		return m, cmd, nil
+	case ui.IsEdit(keyMsg):
+		return m, m.edit(0), nil
+	case ui.IsRemove(keyMsg):
+		if v := m.selected(); v != nil {
+			m.removing = v.ID
+		}
+		return m, nil, nil
+	}
+	m.table.HandleKey(keyMsg)
+	return m, nil, nil
+}
+
+func (m *VehiclesModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(ui.SplitPaneLeftWidth(width-2), splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	return header + "\n" + split
+}
+
+func (m *VehiclesModel) renderLeftPane(width, height int) string {
+	var lines []string
+
+	lines = append(lines, ui.HeaderStyle.Render("VEHICLES"))
+	lines = append(lines, "")
+	switch len(m.vehicles) {
+	case 0:
+		lines = append(lines, "No profiles")
+	case 1:
+		lines = append(lines, "1 profile")
+	default:
+		lines = append(lines, fmt.Sprintf("%d profiles", len(m.vehicles)))
+	}
+	lines = append(lines, "")
+	wrap := ui.DimStyle.Width(max(width-2, 10))
+	lines = append(lines, strings.Split(wrap.Render("EPC links, spec matching and job templates follow the active van, marked ●"), "\n")...)
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *VehiclesModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Header
+	b.WriteString(ui.HeaderStyle.Render("PROFILES"))
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+	b.WriteString("\n\n")
+
+	if len(m.vehicles) == 0 {
+		b.WriteString(ui.DimStyle.Render("No vehicle profiles yet; a adds one"))
+		b.WriteString("\n\n")
+		b.WriteString(ui.DimStyle.Render("a add"))
+		return b.String()
+	}
+
+	// Room for the selected profile's fields below the list
+	m.table.MaxVisibleItems = min(len(m.vehicles)+1, max(height-len(vehicleFields)-10, 4))
+	m.table.Width = width
+	b.WriteString(m.table.View())
+	b.WriteString("\n\n")
+
+	if v := m.selected(); v != nil {
+		for i, f := range vehicleFields {
+			label := ui.DimStyle.Render(fmt.Sprintf("%-10s", f.label))
+			value := v.Field(f.field)
+			switch {
+			case m.editing && i == m.field:
+				value = m.input.View()
+				if m.input.Value() == "" {
+					value += ui.DimStyle.Render(f.hint)
+				}
+			case f.field == db.VehicleManufactureDate:
+				value = locale.DateString(value)
+			case f.field == db.VehicleSpec && value == "":
+				if attrs := v.Attributes(); len(attrs) > 0 {
+					value = ui.DimStyle.Render(describeSpec(attrs) + ", from the name")
+				}
+			}
+			b.WriteString(label + " " + value + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	switch {
+	case m.err != "":
+		b.WriteString(ui.ErrorStyle.Render(m.err))
+		b.WriteString("\n")
+	case m.removing != 0:
+		b.WriteString(ui.ErrorStyle.Render("Remove this profile? y removes it, any other key keeps it"))
+		b.WriteString("\n")
+	case m.status != "":
+		b.WriteString(ui.SelectedStyle.Render(m.status))
+		b.WriteString("\n")
+	}
+
+	if m.editing {
+		b.WriteString(ui.DimStyle.Render("enter save and next   tab skip   esc done"))
+	} else {
+		b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter use   a add   e edit   d remove"))
+	}
+
+	return b.String()
+}
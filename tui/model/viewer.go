@@ -0,0 +1,239 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ViewerModel shows a diagram as large as the terminal allows, zoomed with
+// +/- and panned with hjkl. Only the crop on screen is sent, encoded afresh
+// on each move, so zooming in on a big diagram stays quick.
+type ViewerModel struct {
+	diagram  *db.Diagram
+	path     string
+	viewport *image.Viewport
+	crop     *image.KittyImage
+	imgError string
+
+	// Zoom in pixels per original pixel, or 0 to fit the screen. fitScale
+	// is the scale of the fitted image, where zooming out stops.
+	zoom     float64
+	fitScale float64
+
+	panX, panY   int // cells scrolled
+	viewW, viewH int // room for the image, set by View
+
+	// What the viewport and crop were made for, to redo them on a change
+	loadedZoom       float64
+	loadedW, loadedH int
+	cropX, cropY     int
+	cropW, cropH     int
+	clearImageID     uint32
+}
+
+func NewViewerModel(database *db.DB, diagramID, dataPath string) *ViewerModel {
+	m := &ViewerModel{}
+	m.diagram, _ = database.GetDiagram(diagramID)
+	if m.diagram != nil && m.diagram.ImagePath != nil {
+		m.path = filepath.Join(dataPath, *m.diagram.ImagePath)
+	}
+	return m
+}
+
+func (m *ViewerModel) Update(msg tea.Msg) (*ViewerModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.viewport == nil {
+			return m, nil, nil
+		}
+		switch {
+		case ui.IsZoomIn(msg):
+			m.zoomBy(zoomStep)
+		case ui.IsZoomOut(msg):
+			m.zoomBy(1 / zoomStep)
+		case ui.IsZoomFit(msg):
+			m.zoom = 0
+		case ui.IsLeft(msg):
+			m.pan(-1, 0)
+		case ui.IsRight(msg):
+			m.pan(1, 0)
+		case ui.IsUp(msg):
+			m.pan(0, -1)
+		case ui.IsDown(msg):
+			m.pan(0, 1)
+		}
+	}
+	return m, nil, nil
+}
+
+// zoomBy scales the diagram by factor, up to maxZoom, keeping the middle of
+// the screen where it is. Zooming out to the fitted scale returns to fit.
+func (m *ViewerModel) zoomBy(factor float64) {
+	current := m.viewport.Scale()
+	if m.zoom != 0 {
+		current = m.zoom
+	}
+	scale := min(current*factor, maxZoom)
+	if factor > 1 && scale <= current {
+		return
+	}
+	if scale <= m.fitScale {
+		m.zoom = 0
+		return
+	}
+
+	// View clamps the pan to the new image
+	ratio := scale / current
+	m.panX = int(float64(m.panX+m.viewW/2)*ratio) - m.viewW/2
+	m.panY = int(float64(m.panY+m.viewH/2)*ratio) - m.viewH/2
+	m.zoom = scale
+}
+
+// pan scrolls the diagram by a quarter of the screen, keeping the view
+// within the image
+func (m *ViewerModel) pan(dx, dy int) {
+	if m.viewport == nil {
+		return
+	}
+	m.panX = max(0, min(m.panX+dx*(m.viewW/4+1), m.viewport.CellWidth()-m.viewW))
+	m.panY = max(0, min(m.panY+dy*(m.viewH/4+1), m.viewport.CellHeight()-m.viewH))
+}
+
+// load scales the diagram for the zoom, or for a fitted one the screen
+// size, when either has changed, then crops it to the view
+func (m *ViewerModel) load() {
+	if m.path == "" {
+		return
+	}
+	stale := m.viewport == nil && m.imgError == "" ||
+		m.zoom != m.loadedZoom ||
+		m.zoom == 0 && (m.viewW != m.loadedW || m.viewH != m.loadedH)
+	if stale {
+		viewport, err := image.LoadViewport(m.path, m.zoom, m.viewW, m.viewH)
+		if m.crop != nil {
+			m.clearImageID = m.crop.ID()
+		}
+		m.viewport, m.crop, m.imgError = viewport, nil, ""
+		if err != nil {
+			m.viewport, m.imgError = nil, err.Error()
+		}
+		if viewport != nil && m.zoom == 0 {
+			m.fitScale = viewport.Scale()
+			m.panX, m.panY = 0, 0
+		}
+		m.loadedZoom, m.loadedW, m.loadedH = m.zoom, m.viewW, m.viewH
+	}
+	if m.viewport == nil {
+		return
+	}
+
+	m.pan(0, 0)
+	if m.crop != nil && m.panX == m.cropX && m.panY == m.cropY && m.viewW == m.cropW && m.viewH == m.cropH {
+		return
+	}
+	crop, err := m.viewport.Crop(m.panX, m.panY, m.viewW, m.viewH)
+	if err != nil {
+		m.imgError = err.Error()
+		return
+	}
+	m.crop = crop
+	m.cropX, m.cropY, m.cropW, m.cropH = m.panX, m.panY, m.viewW, m.viewH
+}
+
+func (m *ViewerModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	var result strings.Builder
+
+	// Top margin (2 blank lines to match other pages)
+	result.WriteString("\n\n")
+
+	// The rest of the screen, but for the caption above the image and the
+	// hint below it
+	m.viewW, m.viewH = max(width-2*diagramLeft, 10), max(height-4, 5)
+	if !image.Disabled {
+		m.load()
+	}
+
+	if m.clearImageID != 0 {
+		result.WriteString(image.Clear(m.clearImageID))
+		m.clearImageID = 0
+	}
+
+	// Output image escape with positioning, as on the part screen
+	if m.crop != nil {
+		result.WriteString("\x1b7")   // Save cursor position
+		result.WriteString("  ")      // Left padding
+		result.WriteString("\x1b[1B") // Move cursor down 1 line (past the caption)
+		result.WriteString(m.crop.Render())
+		result.WriteString("\x1b8") // Restore cursor position
+	}
+
+	margin := strings.Repeat(" ", diagramLeft)
+	var lines []string
+	switch {
+	case m.crop != nil:
+		lines = append(lines, margin+ui.DimStyle.Render(truncateText(m.caption(), m.viewW)))
+		// Image is rendered separately above, just add placeholder lines,
+		// unless it's drawn as text
+		for i := 0; i < m.crop.CellHeight(); i++ {
+			lines = append(lines, margin+m.crop.Row(i))
+		}
+	case image.Disabled:
+		lines = append(lines, margin+ui.DimStyle.Render("Diagram hidden in low-bandwidth mode"))
+	case m.imgError != "":
+		lines = append(lines, margin+ui.ErrorStyle.Render(m.imgError))
+	default:
+		lines = append(lines, margin+ui.DimStyle.Render("No diagram available"))
+	}
+
+	result.WriteString(ui.FitHeight(strings.Join(lines, "\n"), m.viewH+1))
+	result.WriteString("\n")
+	result.WriteString(margin + ui.DimStyle.Render(truncateText(m.hint(), m.viewW)))
+	return result.String()
+}
+
+// caption names the diagram and says how it's scaled
+func (m *ViewerModel) caption() string {
+	caption := m.diagram.ID
+	if name := strings.TrimSpace(m.diagram.Name); name != "" {
+		caption += "  " + strings.ToUpper(name)
+	}
+	if m.zoom == 0 {
+		return caption + fmt.Sprintf("  FIT %.0f%%", m.fitScale*100)
+	}
+	return caption + fmt.Sprintf("  ZOOM %.0f%%", m.viewport.Scale()*100)
+}
+
+// hint says which part of the diagram is shown and how to move it
+func (m *ViewerModel) hint() string {
+	keys := "+/- zoom   0 fit   esc back"
+	if m.viewport == nil {
+		return "esc back"
+	}
+	if m.viewport.CellWidth() <= m.viewW && m.viewport.CellHeight() <= m.viewH {
+		return keys
+	}
+	rows := fmt.Sprintf("rows %d-%d/%d", m.panY+1, min(m.panY+m.viewH, m.viewport.CellHeight()), m.viewport.CellHeight())
+	cols := fmt.Sprintf("cols %d-%d/%d", m.panX+1, min(m.panX+m.viewW, m.viewport.CellWidth()), m.viewport.CellWidth())
+	return fmt.Sprintf("%s  %s   hjkl pan   %s", rows, cols, keys)
+}
+
+func (m *ViewerModel) ImageID() uint32 {
+	if m.crop != nil {
+		return m.crop.ID()
+	}
+	return 0
+}
@@ -0,0 +1,679 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+	"github.com/mshick/delica-space-gear-parts/tui/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var wizardColumns = []ui.Column{
+	{Title: "PART", Width: 14},
+	{Title: "DESCRIPTION"},
+	{Title: "WHERE", Width: 26},
+}
+
+// Words naming the front or the rear in descriptions and diagram names
+var (
+	frontMarker = regexp.MustCompile(`\b(?:FR|FRT|FRONT)\b`)
+	rearMarker  = regexp.MustCompile(`\b(?:RR|REAR)\b`)
+)
+
+// wizardAttributes are the spec attributes the wizard asks about, in order,
+// with the question for each
+var wizardAttributes = []struct {
+	name   string
+	topic  string
+	prompt string
+}{
+	{db.AttrDrive, "drive", "Is the van two or four wheel drive?"},
+	{db.AttrTransmission, "transmission", "Is it a manual or an automatic?"},
+	{db.AttrFuel, "fuel", "Does it run on diesel or petrol?"},
+	{db.AttrEngine, "engine", "Which engine does it have?"},
+	{db.AttrRoof, "roof", "Which roof does it have?"},
+	{db.AttrWheelbase, "wheelbase", "Is it the short or the long wheelbase?"},
+	{db.AttrSteering, "steering", "Which side is the steering wheel on?"},
+	{db.AttrGrade, "grade", "Which grade (trim level) is it?"},
+}
+
+// wizardValueLabels spell out attribute values for people who don't know
+// the abbreviations
+var wizardValueLabels = map[string]string{
+	"2WD":           "Two wheel drive (2WD)",
+	"4WD":           "Four wheel drive (4WD)",
+	"MT":            "Manual (MT)",
+	"AT":            "Automatic (AT)",
+	"DIESEL":        "Diesel",
+	"PETROL":        "Petrol",
+	"HIGH ROOF":     "High roof",
+	"STANDARD ROOF": "Standard roof",
+	"SWB":           "Short wheelbase (SWB)",
+	"LWB":           "Long wheelbase (LWB)",
+	"RHD":           "Right hand drive (RHD)",
+	"LHD":           "Left hand drive (LHD)",
+}
+
+// wizardOption is one answer to a question, keeping the candidates that
+// fit it; keep is nil for "not sure", which keeps them all
+type wizardOption struct {
+	id    string // group ID, for the system question
+	label string
+	keep  func(p db.SearchResult) bool
+	count int  // candidates kept
+	yours bool // what the active van has
+}
+
+type wizardQuestion struct {
+	key     string
+	topic   string // what the question is about, for the answers so far
+	prompt  string
+	options []wizardOption
+}
+
+// wizardAnswer is a question answered, with what it was asked of so going
+// back a question can ask it again
+type wizardAnswer struct {
+	question wizardQuestion
+	label    string
+	cursor   int
+	parts    []db.SearchResult
+}
+
+// WizardModel finds a part by asking questions instead of taking a search
+// query: which system, then whichever of the area, front or rear, left or
+// right, spec and build year would narrow the parts down, until a short
+// list of candidates is left to look at beside their diagrams.
+type WizardModel struct {
+	db       *db.DB
+	dataPath string
+
+	// The active van, to point out its answers
+	vehicle []db.Attribute
+	built   int // build year, 0 if unknown
+
+	group    string // name of the system chosen
+	parts    []db.SearchResult
+	answers  []wizardAnswer
+	question *wizardQuestion // nil once the candidates are shown
+	menu     *ui.Menu
+	table    *ui.Table
+
+	// Diagram preview of the selected candidate, loaded in the background
+	previews     *imageCache
+	previewPath  string
+	preview      *image.KittyImage
+	previewError string
+	clearImageID uint32
+}
+
+func NewWizardModel(database *db.DB, dataPath string, previews *imageCache) *WizardModel {
+	m := &WizardModel{db: database, dataPath: dataPath, previews: previews}
+	if v, _ := database.GetActiveVehicle(); v != nil {
+		m.vehicle = v.Attributes()
+		if len(v.ManufactureDate) >= 4 {
+			m.built, _ = strconv.Atoi(v.ManufactureDate[:4])
+		}
+	}
+	m.ask(m.systemQuestion())
+	return m
+}
+
+// systemQuestion asks which group the part is in
+func (m *WizardModel) systemQuestion() *wizardQuestion {
+	groups, _ := m.db.GetGroups()
+	counts, _ := m.db.GetCounts()
+	q := &wizardQuestion{key: "system", topic: "system", prompt: "Which part of the van is it on?"}
+	for _, g := range groups {
+		opt := wizardOption{id: g.ID, label: g.Name}
+		if counts != nil {
+			opt.count = counts.GroupParts[g.ID]
+		}
+		q.options = append(q.options, opt)
+	}
+	return q
+}
+
+// ask shows a question, or the candidates when q is nil
+func (m *WizardModel) ask(q *wizardQuestion) {
+	m.question = q
+	if q == nil {
+		m.showCandidates()
+		return
+	}
+	m.setPreview(nil)
+	m.previewPath = ""
+
+	items := make([]ui.MenuItem, len(q.options))
+	cursor := 0
+	for i, opt := range q.options {
+		var hints []string
+		if opt.count > 0 {
+			hints = append(hints, plural(opt.count, "part"))
+		}
+		if opt.yours {
+			hints = append(hints, "your van")
+			cursor = i
+		}
+		items[i] = ui.MenuItem{ID: strconv.Itoa(i), Label: opt.label, Hint: strings.Join(hints, " · ")}
+	}
+	m.menu = ui.NewMenu(items)
+	m.menu.Cursor = cursor
+}
+
+// answer narrows the candidates to the chosen option and asks the next
+// question
+func (m *WizardModel) answer(i int) {
+	q := m.question
+	opt := q.options[i]
+	m.answers = append(m.answers, wizardAnswer{question: *q, label: opt.label, cursor: i, parts: m.parts})
+	if q.key == "system" {
+		m.group = opt.label
+		m.parts, _ = m.db.GetPartsForGroup(opt.id)
+	} else if opt.keep != nil {
+		var kept []db.SearchResult
+		for _, p := range m.parts {
+			if opt.keep(p) {
+				kept = append(kept, p)
+			}
+		}
+		m.parts = kept
+	}
+	m.ask(m.nextQuestion())
+}
+
+// back asks the last question again
+func (m *WizardModel) back() {
+	if len(m.answers) == 0 {
+		return
+	}
+	last := m.answers[len(m.answers)-1]
+	m.answers = m.answers[:len(m.answers)-1]
+	m.parts = last.parts
+	if last.question.key == "system" {
+		m.group = ""
+	}
+	m.ask(&last.question)
+	m.menu.Cursor = last.cursor
+}
+
+// nextQuestion returns the first question not yet answered that would
+// narrow the candidates down, or nil when none would
+func (m *WizardModel) nextQuestion() *wizardQuestion {
+	if len(m.parts) <= 1 {
+		return nil
+	}
+	questions := []func() *wizardQuestion{m.areaQuestion, m.endQuestion, m.sideQuestion}
+	for _, attr := range wizardAttributes {
+		questions = append(questions, func() *wizardQuestion { return m.attributeQuestion(attr.name, attr.topic, attr.prompt) })
+	}
+	questions = append(questions, m.yearQuestion)
+
+	for _, build := range questions {
+		q := build()
+		if q == nil || m.answered(q.key) {
+			continue
+		}
+		if m.narrows(q) {
+			return q
+		}
+	}
+	return nil
+}
+
+func (m *WizardModel) answered(key string) bool {
+	for _, a := range m.answers {
+		if a.question.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// narrows counts what each option keeps, dropping options that keep
+// nothing, and reports whether two or more are left and one of them leaves
+// parts out. The "not sure" option goes at the end.
+func (m *WizardModel) narrows(q *wizardQuestion) bool {
+	var options []wizardOption
+	narrower := false
+	for _, opt := range q.options {
+		for _, p := range m.parts {
+			if opt.keep(p) {
+				opt.count++
+			}
+		}
+		if opt.count > 0 {
+			options = append(options, opt)
+			narrower = narrower || opt.count < len(m.parts)
+		}
+	}
+	if len(options) < 2 || !narrower {
+		return false
+	}
+	q.options = append(options, wizardOption{label: "Not sure", count: len(m.parts)})
+	return true
+}
+
+// partArea is where a part is listed: its subgroup, or else its diagram
+func partArea(p db.SearchResult) string {
+	if p.SubgroupName != nil {
+		return *p.SubgroupName
+	}
+	return p.DiagramName
+}
+
+func (m *WizardModel) areaQuestion() *wizardQuestion {
+	q := &wizardQuestion{key: "area", topic: "area", prompt: "Which of these is it in or near?"}
+	var areas []string
+	for _, p := range m.parts {
+		if area := partArea(p); !slices.Contains(areas, area) {
+			areas = append(areas, area)
+		}
+	}
+	slices.Sort(areas)
+	for _, area := range areas {
+		q.options = append(q.options, wizardOption{
+			label: area,
+			keep:  func(p db.SearchResult) bool { return partArea(p) == area },
+		})
+	}
+	return q
+}
+
+// partEnd returns "FR" or "RR" for a part named for one end of the van, by
+// its description or else where it's listed, or "" for one naming neither
+// or both
+func partEnd(p db.SearchResult) string {
+	end := func(s string) string {
+		s = strings.ToUpper(s)
+		front, rear := frontMarker.MatchString(s), rearMarker.MatchString(s)
+		switch {
+		case front && !rear:
+			return "FR"
+		case rear && !front:
+			return "RR"
+		}
+		return ""
+	}
+	if e := end(deref(p.Description)); e != "" {
+		return e
+	}
+	return end(partArea(p) + " " + p.DiagramName)
+}
+
+// endQuestion asks front or rear. Parts naming neither end fit both.
+func (m *WizardModel) endQuestion() *wizardQuestion {
+	return &wizardQuestion{key: "end", topic: "front or rear", prompt: "Is it at the front or the rear?", options: []wizardOption{
+		{label: "Front", keep: func(p db.SearchResult) bool { return partEnd(p) != "RR" }},
+		{label: "Rear", keep: func(p db.SearchResult) bool { return partEnd(p) != "FR" }},
+	}}
+}
+
+// sideQuestion asks left or right. Parts naming neither side fit both.
+func (m *WizardModel) sideQuestion() *wizardQuestion {
+	side := func(p db.SearchResult) string { return db.Hand(deref(p.Description)) }
+	return &wizardQuestion{key: "side", topic: "side", prompt: "Which side, sitting in the driver's seat?", options: []wizardOption{
+		{label: "Left (LH)", keep: func(p db.SearchResult) bool { return side(p) != "RH" }},
+		{label: "Right (RH)", keep: func(p db.SearchResult) bool { return side(p) != "LH" }},
+	}}
+}
+
+// partValues returns the values a part's spec gives for an attribute
+func partValues(p db.SearchResult, name string) []string {
+	var values []string
+	for _, a := range db.ParseSpec(deref(p.Spec)) {
+		if a.Name == name {
+			values = append(values, a.Value)
+		}
+	}
+	return values
+}
+
+// attributeQuestion asks which value of an attribute the van has. Parts
+// whose spec doesn't give one fit any.
+func (m *WizardModel) attributeQuestion(name, topic, prompt string) *wizardQuestion {
+	q := &wizardQuestion{key: "attr:" + name, topic: topic, prompt: prompt}
+	var values []string
+	for _, p := range m.parts {
+		for _, v := range partValues(p, name) {
+			if !slices.Contains(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	slices.Sort(values)
+	for _, v := range values {
+		label := v
+		if l, ok := wizardValueLabels[v]; ok {
+			label = l
+		}
+		q.options = append(q.options, wizardOption{
+			label: label,
+			keep: func(p db.SearchResult) bool {
+				given := partValues(p, name)
+				return len(given) == 0 || slices.Contains(given, v)
+			},
+			yours: slices.Contains(m.vehicle, db.Attribute{Name: name, Value: v}),
+		})
+	}
+	return q
+}
+
+// yearQuestion asks the build year. Parts without a readable date range
+// fit every year.
+func (m *WizardModel) yearQuestion() *wizardQuestion {
+	q := &wizardQuestion{key: "year", topic: "year", prompt: "What year was the van built?"}
+	first, last := 0, 0
+	for _, p := range m.parts {
+		from, to, ok := db.DateRangeYears(deref(p.ModelDateRange))
+		if !ok {
+			continue
+		}
+		if first == 0 || from < first {
+			first = from
+		}
+		last = max(last, to)
+	}
+	for year := first; first > 0 && year <= last; year++ {
+		q.options = append(q.options, wizardOption{
+			label: strconv.Itoa(year),
+			keep: func(p db.SearchResult) bool {
+				from, to, ok := db.DateRangeYears(deref(p.ModelDateRange))
+				return !ok || (from <= year && year <= to)
+			},
+			yours: year == m.built,
+		})
+	}
+	return q
+}
+
+// showCandidates lists the parts left
+func (m *WizardModel) showCandidates() {
+	rows := make([]ui.TableRow, len(m.parts))
+	for i, p := range m.parts {
+		rows[i] = ui.TableRow{
+			ID:    strconv.Itoa(i),
+			Cells: []string{p.PartNumber, deref(p.Description), partArea(p)},
+		}
+	}
+	m.table = ui.NewTable(wizardColumns, rows)
+}
+
+// selected returns the candidate under the cursor
+func (m *WizardModel) selected() *db.SearchResult {
+	if m.question != nil || m.table == nil {
+		return nil
+	}
+	row := m.table.Selected()
+	if row == nil {
+		return nil
+	}
+	i, _ := strconv.Atoi(row.ID)
+	return &m.parts[i]
+}
+
+// updatePreview points the preview at the selected candidate's diagram. It
+// shows a cached image right away, otherwise returns a command to load it.
+func (m *WizardModel) updatePreview() tea.Cmd {
+	var path string
+	if p := m.selected(); p != nil && p.ImagePath != nil && !image.Disabled {
+		path = filepath.Join(m.dataPath, *p.ImagePath)
+	}
+	if path == m.previewPath {
+		return nil
+	}
+	m.previewPath = path
+	m.previewError = ""
+	m.setPreview(nil)
+	if path == "" {
+		return nil
+	}
+
+	if img := m.previews.get(path, previewWidthCells, previewHeightCells); img != nil {
+		m.setPreview(img)
+		return nil
+	}
+	previews := m.previews
+	return func() tea.Msg {
+		img, err := previews.load(path, previewWidthCells, previewHeightCells)
+		return previewLoadedMsg{path: path, img: img, err: err}
+	}
+}
+
+// setPreview swaps the displayed image, scheduling the old one for deletion
+func (m *WizardModel) setPreview(img *image.KittyImage) {
+	if m.preview != nil && (img == nil || img.ID() != m.preview.ID()) {
+		m.clearImageID = m.preview.ID()
+	}
+	m.preview = img
+}
+
+func (m *WizardModel) ImageID() uint32 {
+	if m.preview != nil {
+		return m.preview.ID()
+	}
+	return 0
+}
+
+func (m *WizardModel) Update(msg tea.Msg) (*WizardModel, tea.Cmd, *Screen) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if ui.IsLeft(msg) || msg.Type == tea.KeyBackspace {
+			m.back()
+			return m, nil, nil
+		}
+
+		// Answering a question
+		if m.question != nil {
+			if ui.IsRight(msg) && m.question.key != "system" {
+				m.ask(nil)
+				return m, m.updatePreview(), nil
+			}
+			m.menu.HandleKey(msg)
+			if ui.IsEnter(msg) {
+				if item := m.menu.Selected(); item != nil {
+					i, _ := strconv.Atoi(item.ID)
+					m.answer(i)
+					return m, m.updatePreview(), nil
+				}
+			}
+			return m, nil, nil
+		}
+
+		// Looking through the candidates
+		m.table.HandleKey(msg)
+		if ui.IsEnter(msg) {
+			if p := m.selected(); p != nil {
+				s := PartDetailScreen(p.ID, false)
+				return m, nil, &s
+			}
+		}
+		return m, m.updatePreview(), nil
+
+	case previewLoadedMsg:
+		// Ignore previews the cursor has already moved past
+		if msg.path == m.previewPath {
+			if msg.err != nil {
+				m.previewError = msg.err.Error()
+			} else {
+				m.setPreview(msg.img)
+			}
+		}
+	}
+	return m, nil, nil
+}
+
+func (m *WizardModel) View(width, height int) string {
+	if width == 0 {
+		width = 80
+	}
+	if height == 0 {
+		height = 24
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Width(width-2).
+		Padding(1, 1, 0, 1).
+		Align(lipgloss.Right)
+
+	header := headerStyle.Render(ui.DimStyle.Render("esc back"))
+
+	// Split pane content
+	splitHeight := height - 5
+	if splitHeight < 10 {
+		splitHeight = 10
+	}
+
+	leftContent := m.renderLeftPane(splitHeight)
+	rightContent := m.renderRightPane(ui.SplitPaneRightWidth(width-2), splitHeight)
+
+	split := ui.RenderSplitPane(leftContent, rightContent, width-2, splitHeight)
+
+	// Delete the previous preview and draw the current one below its caption
+	var img string
+	if m.clearImageID != 0 {
+		img = image.Clear(m.clearImageID)
+		m.clearImageID = 0
+	}
+	if m.preview != nil {
+		img += "\x1b7" + "  " + "\x1b[1B" + m.preview.Render() + "\x1b8"
+	}
+
+	return header + "\n" + img + split
+}
+
+func (m *WizardModel) renderLeftPane(height int) string {
+	var lines []string
+
+	// Diagram preview of the selected candidate
+	if p := m.selected(); p != nil && m.previewPath != "" {
+		lines = append(lines, ui.DimStyle.Render(partArea(*p)))
+		if m.preview != nil {
+			// Image is rendered separately in View(), just add placeholder
+			// lines, unless it's drawn as text
+			for i := 0; i < m.preview.CellHeight(); i++ {
+				lines = append(lines, m.preview.Row(i))
+			}
+		} else if m.previewError != "" {
+			lines = append(lines, ui.ErrorStyle.Render(m.previewError))
+		} else {
+			lines = append(lines, ui.DimStyle.Render("Loading diagram..."))
+		}
+		for len(lines) < height {
+			lines = append(lines, "")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	// The answers so far
+	lines = append(lines, ui.HeaderStyle.Render("FIND MY PART"))
+	lines = append(lines, "")
+	if len(m.answers) == 0 {
+		lines = append(lines, "Answer a few questions")
+		lines = append(lines, "and the parts that fit")
+		lines = append(lines, "are listed with their")
+		lines = append(lines, "diagrams.")
+		lines = append(lines, "")
+		lines = append(lines, ui.DimStyle.Render("Not sure? Pick that and"))
+		lines = append(lines, ui.DimStyle.Render("the next question comes"))
+	} else {
+		for _, a := range m.answers {
+			if a.question.options[a.cursor].keep == nil && a.question.key != "system" {
+				lines = append(lines, ui.DimStyle.Render("› any "+a.question.topic))
+			} else {
+				lines = append(lines, "› "+a.label)
+			}
+		}
+		lines = append(lines, "")
+		lines = append(lines, ui.DimStyle.Render(plural(len(m.parts), "part")+" fit so far"))
+	}
+
+	// Pad to fill height
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *WizardModel) renderRightPane(width, height int) string {
+	var b strings.Builder
+
+	// Header
+	if m.question != nil {
+		b.WriteString(ui.HeaderStyle.Render("FIND MY PART"))
+	} else {
+		b.WriteString(ui.HeaderStyle.Render("PARTS THAT FIT"))
+	}
+	if m.group != "" {
+		b.WriteString(ui.DimStyle.Render(" — " + m.group))
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.DimStyle.Render("─────────────────────────────────"))
+
+	// Adjust visible rows based on available height (max 16), less the
+	// question
+	listHeight := height - 5
+	if m.question != nil {
+		listHeight -= 2
+		b.WriteString("\n\n")
+		b.WriteString(ui.SelectedLabelStyle.Render(m.question.prompt))
+	}
+	if listHeight < 5 {
+		listHeight = 5
+	}
+	if listHeight > 16 {
+		listHeight = 16
+	}
+
+	if m.question != nil {
+		m.menu.MaxVisibleItems = listHeight
+		// One less blank line if menu scrolls (to account for scroll indicator)
+		if len(m.menu.Items) > m.menu.MaxVisibleItems {
+			b.WriteString("\n")
+		} else {
+			b.WriteString("\n\n")
+		}
+		if len(m.menu.Items) == 0 {
+			b.WriteString(ui.DimStyle.Render("No parts in the catalog yet; run a sync first"))
+		} else {
+			b.WriteString(m.menu.View())
+		}
+		b.WriteString("\n\n")
+		hint := "↑↓ choose   enter answer"
+		if len(m.answers) > 0 {
+			hint += "   ← previous question   → show the " + plural(len(m.parts), "part")
+		}
+		b.WriteString(ui.DimStyle.Render(hint))
+		return b.String()
+	}
+
+	m.table.MaxVisibleItems = listHeight
+	m.table.Width = width
+	if len(m.table.Rows) > m.table.MaxVisibleItems-1 {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+	b.WriteString(m.table.View())
+	b.WriteString("\n\n")
+	b.WriteString(ui.DimStyle.Render("↑↓ navigate   enter open part   ← change the last answer   " + m.table.SortHint()))
+	return b.String()
+}
+
+// plural formats a count with its noun, "1 part" or "3 parts"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
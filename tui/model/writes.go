@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/webhook"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	writeAttempts   = 3
+	writeRetryDelay = 250 * time.Millisecond
+)
+
+type writeKind int
+
+const (
+	writeBookmark writeKind = iota
+	writeNote
+	writeMigrate
+	writePurchase
+	writeBookmarkQty
+	writeCart
+)
+
+// userDataWrittenMsg reports the outcome of a queued user-data mutation.
+type userDataWrittenMsg struct {
+	partID int
+	kind   writeKind
+	seq    int
+	err    error
+}
+
+// writeFailure describes a failed write for the toast shown once the
+// screen that made the change has been left, naming the part
+func writeFailure(database *db.DB, msg userDataWrittenMsg) string {
+	what := map[writeKind]string{
+		writeBookmark:    "Bookmark",
+		writeNote:        "Note",
+		writeMigrate:     "Move to the replacement",
+		writePurchase:    "Purchase",
+		writeBookmarkQty: "Quantity needed",
+		writeCart:        "Cart change",
+	}[msg.kind]
+	if msg.partID == 0 {
+		// Bulk changes, such as bookmarking a pasted list
+		if msg.kind == writeBookmark {
+			what = "Bookmarks"
+		}
+		return fmt.Sprintf("%s not saved: %v", what, msg.err)
+	}
+	if part, err := database.GetPart(msg.partID); err == nil && part != nil {
+		return fmt.Sprintf("%s for %s not saved: %v", what, part.PartNumber, msg.err)
+	}
+	return fmt.Sprintf("%s not saved: %v", what, msg.err)
+}
+
+// writeKey names what a write changes: one kind of user data on one part
+type writeKey struct {
+	partID int
+	kind   writeKind
+}
+
+type userDataWrite struct {
+	key    writeKey
+	seq    int
+	apply  func() error
+	events []webhook.Event
+	done   chan error
+}
+
+// writeQueue applies user-data mutations (bookmarks, notes, purchases, the
+// cart) on a single background goroutine. Mutations run in the order they
+// were queued, so rapid toggles can't land out of order, and the Update loop
+// never waits on SQLite, nor on the queue: it has no limit, and a write still
+// waiting when a newer one for the same part and kind is queued is dropped in
+// its favor.
+// Failed mutations are retried with a short backoff before being reported.
+// Successful ones are published to the configured webhook, if any.
+type writeQueue struct {
+	mu      sync.Mutex
+	pending []*userDataWrite
+	wake    chan struct{} // signalled when pending grows
+	seq     int           // the last sequence number handed out by next
+	// applied is the sequence number of the last write applied for each
+	// part and kind; the worker alone uses it
+	applied  map[writeKey]int
+	notifier *webhook.Notifier
+}
+
+func newWriteQueue(notifier *webhook.Notifier) *writeQueue {
+	q := &writeQueue{wake: make(chan struct{}, 1), applied: make(map[writeKey]int), notifier: notifier}
+	go q.run()
+	return q
+}
+
+// next returns a sequence number for a write, higher than any handed out
+// before, so writes from screens opened since still count as newer.
+func (q *writeQueue) next() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	return q.seq
+}
+
+func (q *writeQueue) run() {
+	for range q.wake {
+		for {
+			q.mu.Lock()
+			if len(q.pending) == 0 {
+				q.mu.Unlock()
+				break
+			}
+			job := q.pending[0]
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+
+			// A write older than one already applied would undo it
+			if job.seq > 0 && job.seq <= q.applied[job.key] {
+				job.done <- nil
+				continue
+			}
+			var err error
+			for attempt := 0; attempt < writeAttempts; attempt++ {
+				if attempt > 0 {
+					time.Sleep(writeRetryDelay * time.Duration(attempt))
+				}
+				if err = job.apply(); err == nil {
+					break
+				}
+			}
+			if err == nil {
+				if job.seq > 0 {
+					q.applied[job.key] = job.seq
+				}
+				q.notifier.Publish(job.events...)
+			}
+			job.done <- err
+		}
+	}
+}
+
+// flush waits for the writes queued so far to finish and then for the
+// webhook to deliver their events, for at most wait each. It reports
+// whether all of them were done in time.
+func (q *writeQueue) flush(wait time.Duration) bool {
+	// Writes run in order, so once this one has, so have all before it
+	marker := &userDataWrite{apply: func() error { return nil }, done: make(chan error, 1)}
+	q.mu.Lock()
+	q.pending = append(q.pending, marker)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-marker.done:
+	case <-time.After(wait):
+		return false
+	}
+	return q.notifier.Flush(wait)
+}
+
+// enqueue queues apply and returns a command that reports its outcome as a
+// userDataWrittenMsg. The caller is expected to have already applied the
+// change to its own state optimistically. events describe the change for
+// the webhook and are only published if apply succeeds.
+//
+// seq, from next, orders writes to one part's data of one kind; a write
+// queued with a newer seq replaces one still waiting, which then reports
+// success without running, and one older than the last applied is skipped.
+// Writes with seq 0, such as bulk bookmarks, always run.
+func (q *writeQueue) enqueue(partID int, kind writeKind, seq int, apply func() error, events ...webhook.Event) tea.Cmd {
+	job := &userDataWrite{key: writeKey{partID, kind}, seq: seq, apply: apply, events: events, done: make(chan error, 1)}
+
+	q.mu.Lock()
+	if seq > 0 {
+		for i, waiting := range q.pending {
+			if waiting.key == job.key && waiting.seq > 0 && waiting.seq < seq {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				waiting.done <- nil
+				break
+			}
+		}
+	}
+	q.pending = append(q.pending, job)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return func() tea.Msg {
+		return userDataWrittenMsg{partID: partID, kind: kind, seq: seq, err: <-job.done}
+	}
+}
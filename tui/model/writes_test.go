@@ -0,0 +1,172 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db/dbtest"
+	"github.com/mshick/delica-space-gear-parts/tui/image"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// written runs a command from enqueue and returns its outcome
+func written(t *testing.T, cmd tea.Cmd) userDataWrittenMsg {
+	t.Helper()
+	msg, ok := cmd().(userDataWrittenMsg)
+	if !ok {
+		t.Fatalf("enqueue's command returned %T", msg)
+	}
+	return msg
+}
+
+func TestWriteQueueLastWriteWins(t *testing.T) {
+	database := dbtest.Sample(t)
+	q := newWriteQueue(nil)
+
+	// Toggled quickly: however many run, the last one is what's saved
+	var cmds []tea.Cmd
+	for i := range 9 {
+		add := i%2 == 0
+		cmd := q.enqueue(1, writeBookmark, q.next(), func() error {
+			if add {
+				return database.AddBookmark(1)
+			}
+			return database.RemoveBookmark(1)
+		})
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if msg := written(t, cmd); msg.err != nil {
+			t.Errorf("write %d: %v", msg.seq, msg.err)
+		}
+	}
+	if bookmarked, _ := database.IsBookmarked(1); !bookmarked {
+		t.Error("part 1 not bookmarked after an odd number of toggles")
+	}
+}
+
+func TestWriteQueueSkipsStaleWrites(t *testing.T) {
+	database := dbtest.Sample(t)
+	q := newWriteQueue(nil)
+
+	// A screen opened earlier took its seq first but queues after
+	stale := q.next()
+	newer := q.next()
+	cmd := q.enqueue(1, writeNote, newer, func() error { return database.SetNote(1, "newer") })
+	if msg := written(t, cmd); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	cmd = q.enqueue(1, writeNote, stale, func() error { return database.SetNote(1, "stale") })
+	if msg := written(t, cmd); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if note, _ := database.GetNote(1); note == nil || *note != "newer" {
+		t.Errorf("note = %v, want the newer one kept", note)
+	}
+
+	// Other parts, and writes without a seq, aren't held back by it
+	cmd = q.enqueue(2, writeNote, stale, func() error { return database.SetNote(2, "other part") })
+	written(t, cmd)
+	if note, _ := database.GetNote(2); note == nil {
+		t.Error("write to another part skipped")
+	}
+	cmd = q.enqueue(1, writeNote, 0, func() error { return database.SetNote(1, "bulk") })
+	written(t, cmd)
+	if note, _ := database.GetNote(1); note == nil || *note != "bulk" {
+		t.Errorf("note = %v, want the write without a seq applied", note)
+	}
+}
+
+func TestWriteQueueReportsFailure(t *testing.T) {
+	q := newWriteQueue(nil)
+	attempts := 0
+	failure := errors.New("database is locked")
+	cmd := q.enqueue(1, writeCart, q.next(), func() error {
+		attempts++
+		return failure
+	})
+	msg := written(t, cmd)
+	if !errors.Is(msg.err, failure) {
+		t.Errorf("err = %v, want %v", msg.err, failure)
+	}
+	if attempts != writeAttempts {
+		t.Errorf("%d attempts, want %d", attempts, writeAttempts)
+	}
+	if msg.partID != 1 || msg.kind != writeCart {
+		t.Errorf("reported part %d kind %d, want part 1 kind %d", msg.partID, msg.kind, writeCart)
+	}
+}
+
+func TestWriteQueueFlush(t *testing.T) {
+	database := dbtest.Sample(t)
+	q := newWriteQueue(nil)
+
+	// Quitting straight after a change waits for it to be saved
+	q.enqueue(1, writeBookmark, q.next(), func() error {
+		time.Sleep(50 * time.Millisecond)
+		return database.AddBookmark(1)
+	})
+	if !q.flush(time.Second) {
+		t.Fatal("flush timed out")
+	}
+	if bookmarked, _ := database.IsBookmarked(1); !bookmarked {
+		t.Error("bookmark not saved by the time flush returned")
+	}
+
+	// A write that never finishes can't hold it up for longer than the wait
+	stuck := make(chan struct{})
+	defer close(stuck)
+	q.enqueue(2, writeBookmark, q.next(), func() error {
+		<-stuck
+		return nil
+	})
+	if q.flush(10 * time.Millisecond) {
+		t.Error("flush reported a stuck write done")
+	}
+}
+
+func TestFailedWriteShowsSavedValue(t *testing.T) {
+	database := dbtest.Sample(t)
+	if err := database.SetNote(1, "saved"); err != nil {
+		t.Fatal(err)
+	}
+	q := newWriteQueue(nil)
+	m := NewPartDetailModel(database, 1, t.TempDir(), q, new(image.Fit), newPrefetcher(database, t.TempDir(), newImageCache()))
+
+	// Edited twice before either ran: the first edit was replaced in the
+	// queue, so when the second fails the note saved is the one from before
+	// both, not the first edit shown in between
+	// Another write holds the queue up until both edits are queued
+	busy := make(chan struct{})
+	q.enqueue(2, writeNote, 0, func() error {
+		<-busy
+		return nil
+	})
+	first, second := "first edit", "second edit"
+	m.note, m.noteSeq = &first, q.next()
+	replaced := q.enqueue(1, writeNote, m.noteSeq, func() error { return database.SetNote(1, first) })
+	m.note, m.noteSeq = &second, q.next()
+	failed := q.enqueue(1, writeNote, m.noteSeq, func() error { return errors.New("database is locked") })
+	close(busy)
+
+	m.handleWritten(written(t, replaced))
+	m.handleWritten(written(t, failed))
+	if m.note == nil || *m.note != "saved" {
+		t.Errorf("note shown = %v, want the one saved", m.note)
+	}
+	if m.writeError == "" {
+		t.Error("failure not shown")
+	}
+}
+
+func TestWriteFailureAfterLeaving(t *testing.T) {
+	m := New(dbtest.Sample(t), t.TempDir())
+
+	// The note was saved from part 1's screen, since left for home
+	m.Update(userDataWrittenMsg{partID: 1, kind: writeNote, seq: 1, err: errors.New("database is locked")})
+	if want := "Note for MD050125 not saved: database is locked"; m.toast.text != want || !m.toast.isError {
+		t.Errorf("toast = %q, error %v; want %q", m.toast.text, m.toast.isError, want)
+	}
+}
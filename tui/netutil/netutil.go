@@ -0,0 +1,233 @@
+// Package netutil provides the HTTP client every network feature shares, so
+// proxy, timeout, retry, user agent and rate limit settings are configured
+// in one place.
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent matches the browser user agent the scraper sends, since
+// some vendor sites reject unknown clients.
+const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// Config controls the shared client. The zero value is usable; unset fields
+// take the defaults below.
+type Config struct {
+	Timeout   time.Duration // whole request, including reading the body
+	Retries   int           // attempts after the first on 429, 5xx or network errors; negative for none
+	UserAgent string
+	HostDelay time.Duration // minimum gap between requests to one host; negative for none
+}
+
+// Defaults, matching the scraper's fetcher
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultRetries   = 4
+	defaultHostDelay = time.Second
+	maxBackoff       = 2 * time.Minute
+)
+
+// ConfigFromEnv reads DELICA_HTTP_TIMEOUT (seconds), DELICA_HTTP_RETRIES,
+// DELICA_HTTP_USER_AGENT and DELICA_HTTP_HOST_DELAY (milliseconds). Proxies
+// come from the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables.
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	if v := os.Getenv("DELICA_HTTP_TIMEOUT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("DELICA_HTTP_TIMEOUT must be a number of seconds, got %q", v)
+		}
+		cfg.Timeout = time.Duration(n) * time.Second
+	}
+	if v := os.Getenv("DELICA_HTTP_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("DELICA_HTTP_RETRIES must be a non-negative number, got %q", v)
+		}
+		// Zero values mean defaults, so an explicit zero becomes negative
+		cfg.Retries = n
+		if n == 0 {
+			cfg.Retries = -1
+		}
+	}
+	cfg.UserAgent = os.Getenv("DELICA_HTTP_USER_AGENT")
+	if v := os.Getenv("DELICA_HTTP_HOST_DELAY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("DELICA_HTTP_HOST_DELAY must be a number of milliseconds, got %q", v)
+		}
+		cfg.HostDelay = time.Duration(n) * time.Millisecond
+		if n == 0 {
+			cfg.HostDelay = -1
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+	switch {
+	case c.Retries == 0:
+		c.Retries = defaultRetries
+	case c.Retries < 0:
+		c.Retries = 0
+	}
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	switch {
+	case c.HostDelay == 0:
+		c.HostDelay = defaultHostDelay
+	case c.HostDelay < 0:
+		c.HostDelay = 0
+	}
+	return c
+}
+
+// Client is an HTTP client that spaces out requests per host and retries
+// transient failures with backoff. It is safe for concurrent use.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu   sync.Mutex
+	next map[string]time.Time // earliest time the next request to a host may start
+}
+
+// New returns a client for cfg.
+func New(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return &Client{
+		cfg:  cfg,
+		http: &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		next: make(map[string]time.Time),
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+	defaultErr    error
+)
+
+// Default returns the process-wide client configured from the environment.
+func Default() (*Client, error) {
+	defaultOnce.Do(func() {
+		cfg, err := ConfigFromEnv()
+		if err != nil {
+			defaultErr = err
+			return
+		}
+		defaultClient = New(cfg)
+	})
+	return defaultClient, defaultErr
+}
+
+// Config returns the client's effective settings.
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// Get fetches url and returns the body of a successful response.
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: url, Code: resp.StatusCode}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// StatusError is returned by Get for responses other than 200 OK.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// Do sends req, waiting out the host's rate limit first and retrying on 429,
+// 5xx and network errors. Requests with a body are sent once, since the body
+// can't be replayed. The caller closes the response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.cfg.UserAgent)
+	}
+	retries := c.cfg.Retries
+	if req.Body != nil && req.Body != http.NoBody {
+		retries = 0
+	}
+
+	backoff := max(c.cfg.HostDelay, time.Second)
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+		resp, err := c.http.Do(req)
+		if err == nil && !retryable(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= retries {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		// Back off with jitter, like the scraper does after a 429
+		backoff = min(backoff*3/2, maxBackoff)
+		delay := backoff + time.Duration(rand.Int63n(int64(time.Second)))
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func retryable(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// wait blocks until a request to host is allowed, then reserves the next slot.
+func (c *Client) wait(ctx context.Context, host string) error {
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	c.next[host] = start.Add(c.cfg.HostDelay)
+	c.mu.Unlock()
+
+	if d := time.Until(start); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package netutil
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProbeAddr is dialled to tell whether the network is up: the EPC
+// site, which catalog links and the scraper need anyway.
+const DefaultProbeAddr = "mitsubishi.epc-data.com:443"
+
+// probeTimeout bounds a connectivity check, so an offline check answers
+// quickly instead of waiting out a request timeout
+const probeTimeout = 3 * time.Second
+
+// offline is the last check's answer. The zero value is online, so
+// features work until a check says otherwise.
+var offline atomic.Bool
+
+// Online reports whether the last connectivity check reached the network.
+// Network features check it before starting, so they can wait for the
+// connection instead of hanging on timeouts and retries.
+func Online() bool {
+	return !offline.Load()
+}
+
+// ProbeAddr returns the host:port CheckOnline dials: DELICA_ONLINE_PROBE,
+// or DefaultProbeAddr. "off" turns detection off, returning "".
+func ProbeAddr() string {
+	switch v := os.Getenv("DELICA_ONLINE_PROBE"); v {
+	case "":
+		return DefaultProbeAddr
+	case "off":
+		return ""
+	default:
+		return v
+	}
+}
+
+// CheckOnline dials the probe address, or the proxy requests to it would
+// go through, and records whether it answered. changed is true when that
+// differs from the previous check. With detection off it always reports
+// online.
+func CheckOnline(ctx context.Context) (online, changed bool) {
+	online = true
+	if addr := ProbeAddr(); addr != "" {
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", dialAddr(addr))
+		if err == nil {
+			conn.Close()
+		}
+		online = err == nil
+	}
+	changed = offline.Swap(!online) == online
+	return online, changed
+}
+
+// dialAddr is the proxy's address when one is configured for addr's
+// host, else addr itself
+func dialAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	req := &http.Request{URL: &url.URL{Scheme: "https", Host: host}}
+	proxy, err := http.ProxyFromEnvironment(req)
+	if err != nil || proxy == nil {
+		return addr
+	}
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	port := "80"
+	if proxy.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// WaitOnline blocks until a connectivity check, made by whoever keeps them
+// current, finds the network, or ctx is done.
+func WaitOnline(ctx context.Context) error {
+	for !Online() {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
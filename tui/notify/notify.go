@@ -0,0 +1,132 @@
+// Package notify tells you a background job finished while you were looking
+// at something else: a terminal bell, a desktop notification, or both.
+// DELICA_NOTIFY chooses per kind of job.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mshick/delica-space-gear-parts/tui/opener"
+)
+
+// Kinds of job that notify when they finish
+const (
+	KindSync   = "sync"
+	KindExport = "export"
+	KindLinks  = "links"
+)
+
+// Bell is the character that rings the terminal bell.
+const Bell = "\a"
+
+// ErrNoNotifier means no desktop notification program was found.
+var ErrNoNotifier = errors.New("no desktop notifier found (install notify-send)")
+
+// Method is how a kind of job announces that it finished.
+type Method struct {
+	Bell    bool
+	Desktop bool
+}
+
+var (
+	methodsOnce sync.Once
+	methods     map[string]Method
+	fallback    = Method{Bell: true}
+)
+
+// For returns the method for a kind of job. DELICA_NOTIFY is a list like
+// "bell+desktop,export=none": a bare entry sets the default, kind=... sets
+// one kind, and methods are bell, desktop or none joined with +. Unset, every
+// job rings the bell.
+func For(kind string) Method {
+	methodsOnce.Do(func() {
+		methods = make(map[string]Method)
+		for _, entry := range strings.Split(os.Getenv("DELICA_NOTIFY"), ",") {
+			entry = strings.ToLower(strings.TrimSpace(entry))
+			if entry == "" {
+				continue
+			}
+			name, spec, found := strings.Cut(entry, "=")
+			if !found {
+				fallback = parseMethod(name)
+				continue
+			}
+			methods[strings.TrimSpace(name)] = parseMethod(spec)
+		}
+	})
+	if m, ok := methods[kind]; ok {
+		return m
+	}
+	return fallback
+}
+
+func parseMethod(spec string) Method {
+	var m Method
+	for _, word := range strings.Split(spec, "+") {
+		switch strings.TrimSpace(word) {
+		case "bell":
+			m.Bell = true
+		case "desktop":
+			m.Desktop = true
+		}
+	}
+	return m
+}
+
+// Desktop shows a desktop notification with notify-send on Linux,
+// osascript on macOS or PowerShell on Windows and WSL.
+func Desktop(title, body string) error {
+	name, args := command(title, body)
+	if name == "" {
+		return ErrNoNotifier
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return ErrNoNotifier
+	}
+	if out, err := exec.Command(path, args...).CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// command picks the notification program and its arguments
+func command(title, body string) (string, []string) {
+	switch {
+	case runtime.GOOS == "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleString(body), appleString(title))
+		return "osascript", []string{"-e", script}
+	case runtime.GOOS == "windows", opener.IsWSL():
+		name := "powershell.exe"
+		if runtime.GOOS == "windows" {
+			name = "powershell"
+		}
+		script := fmt.Sprintf(`[void][Reflection.Assembly]::LoadWithPartialName('System.Windows.Forms'); `+
+			`$n = New-Object System.Windows.Forms.NotifyIcon; $n.Icon = [System.Drawing.SystemIcons]::Information; `+
+			`$n.Visible = $true; $n.ShowBalloonTip(5000, %s, %s, 'Info'); Start-Sleep -Seconds 5; $n.Dispose()`,
+			powershellString(title), powershellString(body))
+		return name, []string{"-NoProfile", "-Command", script}
+	case runtime.GOOS == "linux":
+		return "notify-send", []string{"--app-name=delica-tui", title, body}
+	}
+	return "", nil
+}
+
+// appleString quotes s for AppleScript
+func appleString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powershellString quotes s for PowerShell
+func powershellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
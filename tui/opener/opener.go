@@ -0,0 +1,162 @@
+// Package opener opens URLs and files with the desktop's default app, and
+// copies text to the clipboard when that isn't possible. It handles macOS,
+// Windows, Linux desktops and WSL; DELICA_OPENER overrides the command.
+package opener
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ErrNoOpener means no way to open things was found, as on a headless box.
+var ErrNoOpener = errors.New("no opener found (set DELICA_OPENER)")
+
+// exitWait is how long Open waits to see whether the opener fails. Openers
+// that keep running past it, like a browser started in the foreground, are
+// assumed to have worked.
+const exitWait = 1500 * time.Millisecond
+
+// Open opens a URL or file path.
+func Open(target string) error {
+	name, args, err := command(target)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(name, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		// explorer.exe exits with 1 even when it worked
+		if err != nil && filepath.Base(name) != "explorer.exe" {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("%s: %s", name, firstLine(msg))
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	case <-time.After(exitWait):
+		return nil
+	}
+}
+
+// command picks the program that opens target
+func command(target string) (string, []string, error) {
+	if custom := strings.Fields(os.Getenv("DELICA_OPENER")); len(custom) > 0 {
+		args := custom[1:]
+		placed := false
+		for i, a := range args {
+			if strings.Contains(a, "%s") {
+				args[i] = strings.ReplaceAll(a, "%s", target)
+				placed = true
+			}
+		}
+		if !placed {
+			args = append(args, target)
+		}
+		return custom[0], args, nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return "open", []string{target}, nil
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", target}, nil
+	}
+
+	if IsWSL() {
+		if path, err := exec.LookPath("wslview"); err == nil {
+			return path, []string{target}, nil
+		}
+		// explorer.exe opens URLs and Windows paths; Linux paths need wslview
+		if path, err := exec.LookPath("explorer.exe"); err == nil {
+			return path, []string{target}, nil
+		}
+		return "", nil, ErrNoOpener
+	}
+
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return "", nil, ErrNoOpener
+	}
+	for _, name := range []string{"xdg-open", "sensible-browser", "x-www-browser", "gio"} {
+		if path, err := exec.LookPath(name); err == nil {
+			if name == "gio" {
+				return path, []string{"open", target}, nil
+			}
+			return path, []string{target}, nil
+		}
+	}
+	return "", nil, ErrNoOpener
+}
+
+// IsWSL reports whether this is Linux running under the Windows Subsystem
+// for Linux.
+func IsWSL() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// Copy puts text on the system clipboard using whichever clipboard program
+// is available. Terminals that support OSC 52 can be asked instead; see
+// OSC52.
+func Copy(text string) error {
+	for _, c := range clipboardCommands() {
+		path, err := exec.LookPath(c[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return errors.New("no clipboard program found")
+}
+
+func clipboardCommands() [][]string {
+	switch {
+	case runtime.GOOS == "darwin":
+		return [][]string{{"pbcopy"}}
+	case runtime.GOOS == "windows", IsWSL():
+		return [][]string{{"clip.exe"}}
+	}
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		cmds = append(cmds, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	return cmds
+}
+
+// OSC52 returns the escape sequence asking the terminal to put text on the
+// clipboard, which works over SSH and without a clipboard program.
+func OSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,119 @@
+// Package order drafts parts orders for suppliers that only take them by
+// e-mail: an RFC 5322 message to open in a mail client, or a mailto: URL.
+package order
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Line is one part ordered. Qty is at least 1.
+type Line struct {
+	PartNumber  string
+	Description string
+	Qty         int
+	Note        string
+}
+
+// Draft is an order e-mail to a supplier.
+type Draft struct {
+	To      string
+	From    string // empty leaves the sender to the mail client
+	FrameNo string
+	Vehicle string
+	Lines   []Line
+}
+
+// FromEnv starts a draft addressed to DELICA_ORDER_EMAIL, from
+// DELICA_ORDER_FROM. The caller fills in the vehicle it's for. To is empty
+// when no supplier address is configured.
+func FromEnv() Draft {
+	return Draft{
+		To:   strings.TrimSpace(os.Getenv("DELICA_ORDER_EMAIL")),
+		From: strings.TrimSpace(os.Getenv("DELICA_ORDER_FROM")),
+	}
+}
+
+// Subject names the order by its vehicle, so replies thread with it.
+func (d Draft) Subject() string {
+	if d.FrameNo == "" {
+		return "Parts order"
+	}
+	return "Parts order for Delica Space Gear " + d.FrameNo
+}
+
+// Body lists the parts with quantities, then the vehicle they are for so
+// the supplier can check fitment.
+func (d Draft) Body() string {
+	var b strings.Builder
+	b.WriteString("Hello,\n\n")
+	b.WriteString("I'd like to order the following parts. Please confirm price, availability and shipping.\n\n")
+	for _, l := range d.Lines {
+		fmt.Fprintf(&b, "%d x %s", max(l.Qty, 1), strings.ToUpper(l.PartNumber))
+		if l.Description != "" {
+			b.WriteString("  " + l.Description)
+		}
+		if l.Note != "" {
+			b.WriteString(" (" + l.Note + ")")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nVehicle: Mitsubishi Delica Space Gear")
+	if d.Vehicle != "" {
+		b.WriteString(" " + d.Vehicle)
+	}
+	b.WriteString("\n")
+	if d.FrameNo != "" {
+		fmt.Fprintf(&b, "Frame number: %s\n", d.FrameNo)
+	}
+	b.WriteString("\nThank you\n")
+	return b.String()
+}
+
+// WriteEML writes the draft as an RFC 5322 message dated now. X-Unsent
+// makes clients such as Outlook and Thunderbird open it ready to edit and
+// send, rather than as a received message.
+func (d Draft) WriteEML(w io.Writer, now time.Time) error {
+	var b strings.Builder
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("To", d.To)
+	if d.From != "" {
+		header("From", d.From)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", d.Subject()))
+	header("Date", now.Format(time.RFC1123Z))
+	header("X-Unsent", "1")
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := io.WriteString(qp, strings.ReplaceAll(d.Body(), "\n", "\r\n")); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Mailto returns a mailto: URL with the subject and body filled in.
+func (d Draft) Mailto() string {
+	// mailto wants %20 rather than +, and CRLF line breaks (RFC 6068)
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+	return fmt.Sprintf("mailto:%s?subject=%s&body=%s",
+		url.PathEscape(d.To), escape(d.Subject()), escape(strings.ReplaceAll(d.Body(), "\n", "\r\n")))
+}
@@ -0,0 +1,43 @@
+package order
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultLineFormat is how Text writes each part unless DELICA_ORDER_LINE
+// says otherwise.
+const DefaultLineFormat = "{qty} x {part} {description}"
+
+// LineFormat returns the line format in DELICA_ORDER_LINE, or the default.
+// A literal \t in it stands for a tab, for pasting into spreadsheets.
+func LineFormat() string {
+	format := os.Getenv("DELICA_ORDER_LINE")
+	if strings.TrimSpace(format) == "" {
+		return DefaultLineFormat
+	}
+	return strings.ReplaceAll(format, `\t`, "\t")
+}
+
+// Text lists the parts as plain text, one line each in format, for pasting
+// into a supplier's web form or a message. The format's {qty}, {part},
+// {description} and {note} are replaced with the line's values; spaces
+// left around a missing one are dropped.
+func Text(lines []Line, format string) string {
+	var b strings.Builder
+	for _, l := range lines {
+		line := strings.NewReplacer(
+			"{qty}", strconv.Itoa(max(l.Qty, 1)),
+			"{part}", strings.ToUpper(l.PartNumber),
+			"{description}", l.Description,
+			"{note}", l.Note,
+		).Replace(format)
+		if !strings.Contains(format, "\t") {
+			line = strings.Join(strings.Fields(line), " ")
+		}
+		b.WriteString(strings.TrimRight(line, " \t"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// runImportPrices loads supplier price data from a CSV file with a header
+// row. supplier, part_number and price are required; currency, stock,
+// lead_time_days, url and updated_at are optional columns. Nothing is
+// saved unless every row reads, and a part number that can't be one stops
+// the import; ones in no Mitsubishi format are imported but listed to check.
+func runImportPrices(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("import-prices", flag.ExitOnError)
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: delica-tui import-prices [-dry-run] [-verbose] FILE.csv")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("open prices: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"supplier", "part_number", "price"} {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	optionalInt := func(record []string, name string) (*int, error) {
+		v := field(record, name)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &n, nil
+	}
+
+	var prices []db.Price
+	var suspicious []string
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		price, err := strconv.ParseFloat(field(record, "price"), 64)
+		if err != nil {
+			return fmt.Errorf("line %d: price: %w", line, err)
+		}
+		p := db.Price{
+			SupplierID: field(record, "supplier"),
+			PartNumber: strings.ToUpper(field(record, "part_number")),
+			Price:      price,
+			Currency:   strings.ToUpper(field(record, "currency")),
+			UpdatedAt:  field(record, "updated_at"),
+		}
+		if p.SupplierID == "" || p.PartNumber == "" {
+			return fmt.Errorf("line %d: supplier and part_number are required", line)
+		}
+		if check := db.CheckPartNumber(p.PartNumber); check.Malformed {
+			return fmt.Errorf("line %d: part_number %q %s", line, p.PartNumber, check.Problem)
+		} else if !check.OK() {
+			hint := check.Problem
+			if suggestions, _ := database.SuggestPartNumbers(check); len(suggestions) > 0 {
+				hint += "; did you mean " + strings.Join(suggestions, " or ") + "?"
+			}
+			suspicious = append(suspicious, fmt.Sprintf("line %d: %s, %s", line, p.PartNumber, hint))
+		}
+		if p.Currency == "" {
+			p.Currency = "USD"
+		}
+		if p.Stock, err = optionalInt(record, "stock"); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if p.LeadTimeDays, err = optionalInt(record, "lead_time_days"); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if url := field(record, "url"); url != "" {
+			p.URL = &url
+		}
+
+		prices = append(prices, p)
+	}
+
+	result, err := database.ImportPrices(prices, report.dryRun)
+	if err != nil {
+		return err
+	}
+	for i, action := range result.Actions {
+		p := prices[i]
+		label := fmt.Sprintf("%-9s %s %s %.2f %s (line %d)", action, p.SupplierID, p.PartNumber, p.Price, p.Currency, i+2)
+		if action == db.ImportUnchanged {
+			report.skip("%s", label)
+		} else {
+			report.change("%s", label)
+		}
+	}
+
+	fmt.Printf("%s %d prices: %d new, %d changed, %d unchanged\n",
+		report.did("Imported", "Would import"), len(prices), result.Added, result.Updated, result.Unchanged)
+	if len(suspicious) > 0 {
+		fmt.Printf("\n%d part numbers to check:\n", len(suspicious))
+		for _, s := range suspicious {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+	report.finish()
+	return nil
+}
@@ -0,0 +1,229 @@
+package pricing
+
+import (
+	"encoding/json"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// quote is what a vendor page says about a part
+type quote struct {
+	price        float64
+	currency     string
+	availability string // "in stock", "out of stock" and so on
+	weightGrams  *int
+}
+
+// Both sites are read the same way: from the schema.org Product markup
+// their part pages carry for search engines, as JSON-LD or microdata,
+// falling back to a weight label in the page text.
+var (
+	ldScript = regexp.MustCompile(`(?is)<script[^>]+application/ld\+json[^>]*>(.*?)</script>`)
+
+	itemPrice        = regexp.MustCompile(`(?i)itemprop=["']price["'][^>]*?content=["']([0-9.,]+)["']|content=["']([0-9.,]+)["'][^>]*?itemprop=["']price["']`)
+	itemCurrency     = regexp.MustCompile(`(?i)itemprop=["']priceCurrency["'][^>]*?content=["']([A-Za-z]{3})["']|content=["']([A-Za-z]{3})["'][^>]*?itemprop=["']priceCurrency["']`)
+	itemAvailability = regexp.MustCompile(`(?i)itemprop=["']availability["'][^>]*?(?:href|content)=["']([^"']+)["']|(?:href|content)=["']([^"']+)["'][^>]*?itemprop=["']availability["']`)
+
+	tag         = regexp.MustCompile(`<[^>]*>`)
+	weightLabel = regexp.MustCompile(`(?i)\bweight\b\W{0,20}?([0-9]+(?:[.,][0-9]+)?)\s*(kg|g|lbs?|oz)\b`)
+)
+
+// parsePage reads the first offer on a vendor page. ok is false when the
+// page prices nothing.
+func parsePage(page []byte) (q quote, ok bool) {
+	text := string(page)
+	for _, m := range ldScript.FindAllStringSubmatch(text, -1) {
+		if q, ok = parseLD([]byte(m[1])); ok {
+			break
+		}
+	}
+	if !ok {
+		q, ok = parseMicrodata(text)
+	}
+	if !ok {
+		return q, false
+	}
+	if q.currency == "" {
+		q.currency = "USD"
+	}
+	if q.weightGrams == nil {
+		plain := html.UnescapeString(tag.ReplaceAllString(text, " "))
+		if m := weightLabel.FindStringSubmatch(plain); m != nil {
+			q.weightGrams = grams(m[1], m[2])
+		}
+	}
+	return q, true
+}
+
+// ldProduct is the part of a schema.org Product the sites fill in
+type ldProduct struct {
+	Type   any               `json:"@type"`
+	Graph  []json.RawMessage `json:"@graph"`
+	Offers json.RawMessage   `json:"offers"`
+	Weight *struct {
+		Value    any    `json:"value"`
+		UnitCode string `json:"unitCode"`
+		UnitText string `json:"unitText"`
+	} `json:"weight"`
+}
+
+type ldOffer struct {
+	Price         any    `json:"price"`
+	LowPrice      any    `json:"lowPrice"`
+	PriceCurrency string `json:"priceCurrency"`
+	Availability  string `json:"availability"`
+}
+
+// parseLD reads a JSON-LD block: a Product, a list of things or a @graph
+// with one among them
+func parseLD(data []byte) (quote, bool) {
+	var list []json.RawMessage
+	if json.Unmarshal(data, &list) == nil {
+		for _, item := range list {
+			if q, ok := parseLD(item); ok {
+				return q, true
+			}
+		}
+		return quote{}, false
+	}
+
+	var p ldProduct
+	if json.Unmarshal(data, &p) != nil {
+		return quote{}, false
+	}
+	for _, item := range p.Graph {
+		if q, ok := parseLD(item); ok {
+			return q, true
+		}
+	}
+	if !isType(p.Type, "Product") || len(p.Offers) == 0 {
+		return quote{}, false
+	}
+
+	// Offers is one offer, an AggregateOffer or a list of them
+	var offers []ldOffer
+	if json.Unmarshal(p.Offers, &offers) != nil {
+		var o ldOffer
+		if json.Unmarshal(p.Offers, &o) != nil {
+			return quote{}, false
+		}
+		offers = []ldOffer{o}
+	}
+	for _, o := range offers {
+		price, ok := number(o.Price)
+		if !ok {
+			price, ok = number(o.LowPrice)
+		}
+		if !ok || price <= 0 {
+			continue
+		}
+		q := quote{price: price, currency: strings.ToUpper(o.PriceCurrency), availability: availability(o.Availability)}
+		if p.Weight != nil {
+			if v, ok := number(p.Weight.Value); ok {
+				unit := p.Weight.UnitCode
+				if unit == "" {
+					unit = p.Weight.UnitText
+				}
+				q.weightGrams = grams(strconv.FormatFloat(v, 'f', -1, 64), unit)
+			}
+		}
+		return q, true
+	}
+	return quote{}, false
+}
+
+// parseMicrodata reads itemprop attributes
+func parseMicrodata(text string) (quote, bool) {
+	m := itemPrice.FindStringSubmatch(text)
+	if m == nil {
+		return quote{}, false
+	}
+	price, ok := number(m[1] + m[2])
+	if !ok || price <= 0 {
+		return quote{}, false
+	}
+	q := quote{price: price}
+	if m := itemCurrency.FindStringSubmatch(text); m != nil {
+		q.currency = strings.ToUpper(m[1] + m[2])
+	}
+	if m := itemAvailability.FindStringSubmatch(text); m != nil {
+		q.availability = availability(m[1] + m[2])
+	}
+	return q, true
+}
+
+// isType reports whether a JSON-LD @type, a string or a list, includes want
+func isType(t any, want string) bool {
+	switch t := t.(type) {
+	case string:
+		return t == want
+	case []any:
+		for _, v := range t {
+			if v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// number reads a JSON number or a numeric string, with a comma for
+// thousands or, alone, for decimals
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if strings.Contains(s, ".") {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ",", ".")
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// availability words a schema.org ItemAvailability URL, such as
+// https://schema.org/InStock, as "in stock"
+func availability(s string) string {
+	s = s[strings.LastIndex(s, "/")+1:]
+	var words []string
+	start := 0
+	for i := 1; i <= len(s); i++ {
+		if i == len(s) || s[i] >= 'A' && s[i] <= 'Z' {
+			words = append(words, strings.ToLower(s[start:i]))
+			start = i
+		}
+	}
+	return strings.TrimSpace(strings.Join(words, " "))
+}
+
+// grams converts a weight in kg, g, lb or oz, or their UN/CEFACT codes
+// KGM, GRM, LBR and ONZ, to whole grams
+func grams(value, unit string) *int {
+	v, ok := number(value)
+	if !ok || v <= 0 {
+		return nil
+	}
+	var factor float64
+	switch strings.ToLower(unit) {
+	case "kg", "kgm":
+		factor = 1000
+	case "g", "grm":
+		factor = 1
+	case "lb", "lbs", "lbr":
+		factor = 453.59237
+	case "oz", "onz":
+		factor = 28.349523125
+	default:
+		return nil
+	}
+	g := int(math.Round(v * factor))
+	return &g
+}
@@ -0,0 +1,116 @@
+// Package pricing looks part numbers up on vendor sites for their current
+// price, availability and weight, keeping what it finds in the prices table
+// so a part isn't looked up again until the price is older than the TTL.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/supplier"
+)
+
+// DefaultTTL is how long a price is used before it's looked up again, when
+// DELICA_PRICE_TTL isn't set
+const DefaultTTL = 24 * time.Hour
+
+// ErrNoPrice is returned for a vendor page that lists no price, such as a
+// search that found nothing.
+var ErrNoPrice = errors.New("no price listed")
+
+// Sources are the suppliers whose sites are looked up, in display order.
+// Amazon's search results aren't specific enough to price a part from.
+func Sources() []supplier.Supplier {
+	return []supplier.Supplier{supplier.Amayama, supplier.Partsouq}
+}
+
+// TTLFromEnv reads DELICA_PRICE_TTL, in hours.
+func TTLFromEnv() (time.Duration, error) {
+	v := os.Getenv("DELICA_PRICE_TTL")
+	if v == "" {
+		return DefaultTTL, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("DELICA_PRICE_TTL must be a number of hours, got %q", v)
+	}
+	return time.Duration(n) * time.Hour, nil
+}
+
+// Stale returns the sources with no price for partNumber updated within
+// ttl of now, fetched or imported.
+func Stale(prices []db.Price, partNumber string, ttl time.Duration, now time.Time) []supplier.Supplier {
+	var stale []supplier.Supplier
+	for _, s := range Sources() {
+		fresh := false
+		for _, p := range prices {
+			if p.SupplierID != s.ID() || !strings.EqualFold(p.PartNumber, partNumber) {
+				continue
+			}
+			updated, err := time.Parse("2006-01-02 15:04:05", p.UpdatedAt)
+			fresh = err == nil && now.Sub(updated) < ttl
+		}
+		if !fresh {
+			stale = append(stale, s)
+		}
+	}
+	return stale
+}
+
+// Lookup fetches partNumber's page on s's site and reads the price from it.
+func Lookup(ctx context.Context, client *netutil.Client, s supplier.Supplier, partNumber string) (db.Price, error) {
+	url := s.PartURL(partNumber)
+	page, err := client.Get(ctx, url)
+	if err != nil {
+		return db.Price{}, err
+	}
+	q, ok := parsePage(page)
+	if !ok {
+		return db.Price{}, ErrNoPrice
+	}
+	p := db.Price{
+		SupplierID:  s.ID(),
+		PartNumber:  strings.ToUpper(partNumber),
+		Price:       q.price,
+		Currency:    q.currency,
+		URL:         &url,
+		WeightGrams: q.weightGrams,
+	}
+	if q.availability != "" {
+		p.Availability = &q.availability
+	}
+	return p, nil
+}
+
+// Result is what Refresh did for one source.
+type Result struct {
+	Supplier supplier.Supplier
+	Price    *db.Price // as saved, nil when the lookup failed
+	Err      error
+}
+
+// Refresh looks partNumber up on each of sources and saves the prices
+// found, replacing the ones recorded for those suppliers.
+func Refresh(ctx context.Context, database *db.DB, client *netutil.Client, partNumber string, sources []supplier.Supplier) []Result {
+	results := make([]Result, len(sources))
+	for i, s := range sources {
+		results[i].Supplier = s
+		p, err := Lookup(ctx, client, s, partNumber)
+		if err == nil {
+			err = database.SetPrice(p)
+		}
+		if err != nil {
+			results[i].Err = fmt.Errorf("%s: %w", s.Name(), err)
+			continue
+		}
+		results[i].Price = &p
+	}
+	return results
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/report"
+)
+
+// runReport exports changes affecting bookmarked and noted parts, typically
+// run after re-scraping the catalog. It only reads; migrate-saved moves
+// what it reports onto replacements.
+func runReport(database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	format := fs.String("format", "md", "Output format: md or csv")
+	output := fs.String("o", "", "Write to file instead of stdout")
+	fs.Parse(args)
+
+	changes, err := database.GetSavedPartChanges()
+	if err != nil {
+		return fmt.Errorf("load changes: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "md", "markdown":
+		return report.WriteChangesMarkdown(w, changes, time.Now())
+	case "csv":
+		return report.WriteChangesCSV(w, changes)
+	default:
+		return fmt.Errorf("unknown format %q (want md or csv)", *format)
+	}
+}
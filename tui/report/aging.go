@@ -0,0 +1,146 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+)
+
+// monthsHeld counts the whole months from since to now, or -1 if since
+// isn't a date
+func monthsHeld(since string, now time.Time) int {
+	t, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		return -1
+	}
+	months := (now.Year()-t.Year())*12 + int(now.Month()-t.Month())
+	if now.Day() < t.Day() {
+		months--
+	}
+	return max(months, 0)
+}
+
+func shelfPart(item db.ShelfItem) string {
+	if item.PartNumber == "" {
+		return fmt.Sprintf("part #%d", item.PartID)
+	}
+	return item.PartNumber
+}
+
+func shelfCost(item db.ShelfItem) string {
+	if item.Cost == nil {
+		return ""
+	}
+	return strings.TrimSpace(locale.Price(*item.Cost, item.Currency))
+}
+
+// costTotals sums the known costs of items per currency, formatted like
+// "NZD 120.00, USD 45.00"
+func costTotals(items []db.ShelfItem) string {
+	totals := make(map[string]float64)
+	for _, item := range items {
+		if item.Cost != nil {
+			totals[item.Currency] += *item.Cost
+		}
+	}
+	currencies := make([]string, 0, len(totals))
+	for c := range totals {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, len(currencies))
+	for i, c := range currencies {
+		parts[i] = strings.TrimSpace(locale.Price(totals[c], c))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteAgingMarkdown writes the parts on the shelf as Markdown, oldest
+// first, with those held longer than months in a section of their own so
+// they stand out as candidates to sell.
+func WriteAgingMarkdown(w io.Writer, items []db.ShelfItem, months int, now time.Time) error {
+	var b strings.Builder
+
+	b.WriteString("# Parts aging\n\n")
+	fmt.Fprintf(&b, "Generated %s\n\n", locale.DateTime(now))
+	b.WriteString("Parts you bookmarked or recorded buying. Without a purchase date, the day it was bookmarked is used.\n")
+
+	if len(items) == 0 {
+		b.WriteString("\nNo bookmarks or purchases yet.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	var stale, fresh []db.ShelfItem
+	for _, item := range items {
+		if monthsHeld(item.Since, now) >= months {
+			stale = append(stale, item)
+		} else {
+			fresh = append(fresh, item)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d parts on hand, %d unused for %d months or more.\n", len(items), len(stale), months)
+	if total := costTotals(items); total != "" {
+		fmt.Fprintf(&b, "Cost of everything on hand: %s\n", total)
+	}
+	if total := costTotals(stale); total != "" {
+		fmt.Fprintf(&b, "Cost of the unused parts: %s\n", total)
+	}
+
+	section := func(title string, items []db.ShelfItem) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		b.WriteString("| Part | Description | Since | Months | Cost |\n")
+		b.WriteString("|------|-------------|-------|--------|------|\n")
+		for _, item := range items {
+			since := locale.DateString(item.Since)
+			if !item.Purchased {
+				since += " (bookmarked)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n",
+				shelfPart(item), escapeCell(deref(item.Description)), since, monthsHeld(item.Since, now), shelfCost(item))
+		}
+	}
+	section(fmt.Sprintf("Unused for %d months or more", months), stale)
+	section("Newer", fresh)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteAgingCSV writes the parts on the shelf as CSV with a header row,
+// flagging those held longer than months.
+func WriteAgingCSV(w io.Writer, items []db.ShelfItem, months int, now time.Time) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"part_id", "part_number", "description", "since", "purchased", "months_held", "cost", "currency", "unused"})
+	for _, item := range items {
+		cost := ""
+		if item.Cost != nil {
+			cost = strconv.FormatFloat(*item.Cost, 'f', 2, 64)
+		}
+		held := monthsHeld(item.Since, now)
+		cw.Write([]string{
+			strconv.Itoa(item.PartID),
+			item.PartNumber,
+			deref(item.Description),
+			item.Since,
+			strconv.FormatBool(item.Purchased),
+			strconv.Itoa(held),
+			cost,
+			item.Currency,
+			strconv.FormatBool(held >= months),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+)
+
+// WriteCartCSV writes the cart as CSV with a header row, one line per part
+// with its cheapest known price, for sending to a supplier. Price columns
+// are empty for parts without one.
+func WriteCartCSV(w io.Writer, items []db.CartItem) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"part_number", "description", "qty", "supplier", "unit_price", "currency", "line_total"})
+	for _, item := range items {
+		var supplier, unit, currency, total string
+		if p := item.Price; p != nil {
+			supplier = p.SupplierID
+			unit = strconv.FormatFloat(p.Price, 'f', 2, 64)
+			currency = p.Currency
+			total = strconv.FormatFloat(item.Total(), 'f', 2, 64)
+		}
+		cw.Write([]string{
+			item.PartNumber,
+			deref(item.Description),
+			strconv.Itoa(item.Qty),
+			supplier,
+			unit,
+			currency,
+			total,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,148 @@
+// Package report renders exportable summaries of catalog data.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+)
+
+// savedAs describes how the user saved a part, e.g. "bookmark, note".
+func savedAs(c db.SavedPartChange) string {
+	var kinds []string
+	if c.Bookmarked {
+		kinds = append(kinds, "bookmark")
+	}
+	if c.HasNote {
+		kinds = append(kinds, "note")
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// Summary returns a one-line description of a change, e.g.
+// "bookmarked MB433279 superseded by MR493399".
+func Summary(c db.SavedPartChange) string {
+	verb := "noted"
+	if c.Bookmarked {
+		verb = "bookmarked"
+	}
+	if c.Missing {
+		return fmt.Sprintf("%s %s no longer in catalog", verb, missingPart(c))
+	}
+	line := fmt.Sprintf("%s %s superseded by %s", verb, c.PartNumber, deref(c.ReplacementPartNumber))
+	if !c.ReplacementInCatalog {
+		line += " (not in catalog)"
+	}
+	return line
+}
+
+// WriteChangesMarkdown writes changes affecting saved parts as Markdown.
+func WriteChangesMarkdown(w io.Writer, changes []db.SavedPartChange, now time.Time) error {
+	var b strings.Builder
+
+	b.WriteString("# Catalog changes affecting saved parts\n\n")
+	fmt.Fprintf(&b, "Generated %s\n\n", locale.DateTime(now))
+
+	if len(changes) == 0 {
+		b.WriteString("No bookmarked or noted parts have changed.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	for _, c := range changes {
+		fmt.Fprintf(&b, "- %s\n", Summary(c))
+	}
+
+	var superseded, missing []db.SavedPartChange
+	for _, c := range changes {
+		if c.Missing {
+			missing = append(missing, c)
+		} else {
+			superseded = append(superseded, c)
+		}
+	}
+
+	if len(superseded) > 0 {
+		b.WriteString("\n## Superseded\n\n")
+		b.WriteString("| Part | Description | Replaced by | In catalog | Saved as |\n")
+		b.WriteString("|------|-------------|-------------|------------|----------|\n")
+		for _, c := range superseded {
+			inCatalog := "no"
+			if c.ReplacementInCatalog {
+				inCatalog = "yes"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				c.PartNumber, escapeCell(deref(c.Description)), deref(c.ReplacementPartNumber), inCatalog, savedAs(c))
+		}
+	}
+
+	if len(missing) > 0 {
+		b.WriteString("\n## Missing from catalog\n\n")
+		b.WriteString("| Part | Description | Part ID | Saved as |\n")
+		b.WriteString("|------|-------------|---------|----------|\n")
+		for _, c := range missing {
+			number := c.PartNumber
+			if number == "" {
+				number = "unknown"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", number, escapeCell(deref(c.Description)), c.PartID, savedAs(c))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteChangesCSV writes changes affecting saved parts as CSV with a header row.
+func WriteChangesCSV(w io.Writer, changes []db.SavedPartChange) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"status", "part_id", "part_number", "description", "replacement_part_number", "replacement_in_catalog", "bookmarked", "has_note"})
+	for _, c := range changes {
+		status := "superseded"
+		if c.Missing {
+			status = "missing"
+		}
+		cw.Write([]string{
+			status,
+			strconv.Itoa(c.PartID),
+			c.PartNumber,
+			deref(c.Description),
+			deref(c.ReplacementPartNumber),
+			strconv.FormatBool(c.ReplacementInCatalog),
+			strconv.FormatBool(c.Bookmarked),
+			strconv.FormatBool(c.HasNote),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// missingPart names a part gone from the catalog by its number and
+// description as last seen, e.g. "MB433279 (GASKET,CYLINDER HEAD)", or by
+// ID when it went before they were recorded.
+func missingPart(c db.SavedPartChange) string {
+	if c.PartNumber == "" {
+		return fmt.Sprintf("part #%d", c.PartID)
+	}
+	if c.Description != nil && *c.Description != "" {
+		return fmt.Sprintf("%s (%s)", c.PartNumber, *c.Description)
+	}
+	return c.PartNumber
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
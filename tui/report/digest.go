@@ -0,0 +1,228 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+)
+
+// Digest is what changed for saved parts since a date: price drops on
+// bookmarked parts, catalog changes to bookmarked and noted parts, and parts
+// syncs added to the catalog.
+type Digest struct {
+	Since      string // YYYY-MM-DD
+	PriceDrops []db.PriceDrop
+	Changes    []db.SavedPartChange
+	Added      []db.AddedPart
+}
+
+// Empty reports whether the digest has nothing to say.
+func (d Digest) Empty() bool {
+	return len(d.PriceDrops) == 0 && len(d.Changes) == 0 && len(d.Added) == 0
+}
+
+// Title is the digest's heading, e.g. "Parts digest since 2026-10-11".
+func (d Digest) Title() string {
+	return "Parts digest since " + locale.DateString(d.Since)
+}
+
+// dropPercent is how far a price fell, in whole percent
+func dropPercent(p db.PriceDrop) int {
+	return int((p.PreviousPrice - p.Price) / p.PreviousPrice * 100)
+}
+
+// addedLocation is where an added part is listed, e.g. "ENGINE > TIMING BELT"
+func addedLocation(a db.AddedPart) string {
+	if a.SubgroupName == "" {
+		return a.GroupName
+	}
+	return a.GroupName + " > " + a.SubgroupName
+}
+
+// WriteDigestMarkdown writes the digest as Markdown, for mail or a file.
+func WriteDigestMarkdown(w io.Writer, d Digest, now time.Time) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", d.Title())
+	fmt.Fprintf(&b, "Generated %s\n", locale.DateTime(now))
+
+	if d.Empty() {
+		b.WriteString("\nNo price drops or catalog changes for saved parts.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	if len(d.PriceDrops) > 0 {
+		b.WriteString("\n## Price drops\n\n")
+		b.WriteString("| Part | Description | Supplier | Was | Now | Drop |\n")
+		b.WriteString("|------|-------------|----------|-----|-----|------|\n")
+		for _, p := range d.PriceDrops {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %d%% |\n",
+				p.PartNumber, escapeCell(deref(p.Description)), p.SupplierID,
+				locale.Price(p.PreviousPrice, p.Currency), locale.Price(p.Price, p.Currency), dropPercent(p))
+		}
+	}
+
+	if len(d.Changes) > 0 {
+		b.WriteString("\n## Catalog changes\n\n")
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "- %s\n", Summary(c))
+		}
+	}
+
+	if len(d.Added) > 0 {
+		b.WriteString("\n## Added to the catalog\n\n")
+		b.WriteString("| Part | PNC | Description | Location | Added |\n")
+		b.WriteString("|------|-----|-------------|----------|-------|\n")
+		for _, a := range d.Added {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				a.PartNumber, deref(a.PNC), escapeCell(deref(a.Description)),
+				escapeCell(addedLocation(a)), locale.DateString(a.AddedAt))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// digestBody lays out the digest's sections as HTML, shared by the HTML
+// page and the feed item
+var digestBody = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"deref":    deref,
+	"price":    locale.Price,
+	"date":     locale.DateString,
+	"drop":     dropPercent,
+	"summary":  Summary,
+	"location": addedLocation,
+}).Parse(`{{if .Empty}}<p>No price drops or catalog changes for saved parts.</p>
+{{end}}{{with .PriceDrops}}<h2>Price drops</h2>
+<table>
+<tr><th>Part</th><th>Description</th><th>Supplier</th><th>Was</th><th>Now</th><th>Drop</th></tr>
+{{range .}}<tr><td>{{.PartNumber}}</td><td>{{deref .Description}}</td><td>{{.SupplierID}}</td><td>{{price .PreviousPrice .Currency}}</td><td>{{price .Price .Currency}}</td><td>{{drop .}}%</td></tr>
+{{end}}</table>
+{{end}}{{with .Changes}}<h2>Catalog changes</h2>
+<ul>
+{{range .}}<li>{{summary .}}</li>
+{{end}}</ul>
+{{end}}{{with .Added}}<h2>Added to the catalog</h2>
+<table>
+<tr><th>Part</th><th>PNC</th><th>Description</th><th>Location</th><th>Added</th></tr>
+{{range .}}<tr><td>{{.PartNumber}}</td><td>{{deref .PNC}}</td><td>{{deref .Description}}</td><td>{{location .}}</td><td>{{date .AddedAt}}</td></tr>
+{{end}}</table>
+{{end}}`))
+
+// renderDigestBody returns the digest's sections as HTML
+func renderDigestBody(d Digest) (string, error) {
+	var b strings.Builder
+	if err := digestBody.Execute(&b, d); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// WriteDigestHTML writes the digest as a standalone HTML page, for mail
+// clients that show HTML.
+func WriteDigestHTML(w io.Writer, d Digest, now time.Time) error {
+	body, err := renderDigestBody(d)
+	if err != nil {
+		return err
+	}
+	title := template.HTMLEscapeString(d.Title())
+	_, err = fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>body { font-family: sans-serif; } table { border-collapse: collapse; } th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }</style>
+</head>
+<body>
+<h1>%s</h1>
+<p>Generated %s</p>
+%s</body>
+</html>
+`, title, title, template.HTMLEscapeString(locale.DateTime(now)), body)
+	return err
+}
+
+// DigestFeedItems is how many digests a feed keeps, newest first.
+const DigestFeedItems = 20
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteDigestRSS writes an RSS 2.0 feed of the digest followed by the items
+// of the feed it replaces, existing, if that isn't empty: up to
+// DigestFeedItems in all. link is the channel's link, such as the web
+// viewer's address.
+func WriteDigestRSS(w io.Writer, existing []byte, d Digest, now time.Time, link string) error {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       "Delica parts digest",
+			Link:        link,
+			Description: "Price drops and catalog changes for saved Delica parts",
+		},
+	}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		var previous rssFeed
+		if err := xml.Unmarshal(existing, &previous); err != nil {
+			return fmt.Errorf("read existing feed: %w", err)
+		}
+		feed.Channel.Items = previous.Channel.Items
+	}
+
+	body, err := renderDigestBody(d)
+	if err != nil {
+		return err
+	}
+	item := rssItem{
+		Title:       d.Title(),
+		GUID:        rssGUID{Value: "delica-digest-" + now.UTC().Format(time.RFC3339)},
+		PubDate:     now.Format(time.RFC1123Z),
+		Description: body,
+	}
+	feed.Channel.Items = append([]rssItem{item}, feed.Channel.Items...)
+	if len(feed.Channel.Items) > DigestFeedItems {
+		feed.Channel.Items = feed.Channel.Items[:DigestFeedItems]
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
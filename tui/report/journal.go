@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+)
+
+func journalPart(e db.JournalEntry) string {
+	if e.PartNumber == "" {
+		return fmt.Sprintf("part #%d", e.PartID)
+	}
+	return e.PartNumber
+}
+
+// journalPrice lists the lowest price in each currency, e.g. "$12.50, ¥1,800"
+func journalPrice(e db.JournalEntry) string {
+	prices := make([]string, len(e.LowestPrices))
+	for i, p := range e.LowestPrices {
+		prices[i] = locale.Price(p.Price, p.Currency)
+	}
+	return strings.Join(prices, ", ")
+}
+
+// journalDetail is what the Note column says: the note, or for labor the
+// time worked
+func journalDetail(e db.JournalEntry) string {
+	if e.Kind == db.JournalLabor {
+		return "worked " + locale.Hours(e.Labor)
+	}
+	return e.Detail
+}
+
+// WriteJournalMarkdown writes the activity journal as Markdown, one section
+// per day, each ending with the time worked that day if any was timed.
+func WriteJournalMarkdown(w io.Writer, entries []db.JournalEntry, now time.Time) error {
+	var b strings.Builder
+
+	b.WriteString("# Parts journal\n\n")
+	fmt.Fprintf(&b, "Generated %s\n\n", locale.DateTime(now))
+	b.WriteString("Prices are the lowest known supplier price in each currency, not the amount paid.\n")
+
+	if len(entries) == 0 {
+		b.WriteString("\nNo notes, bookmarks or time worked yet.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	day := ""
+	var labor time.Duration
+	endDay := func() {
+		if labor > 0 {
+			fmt.Fprintf(&b, "\nTime worked: %s\n", locale.Hours(labor))
+		}
+		labor = 0
+	}
+	for _, e := range entries {
+		if d := locale.DateString(e.At); d != day {
+			endDay()
+			day = d
+			fmt.Fprintf(&b, "\n## %s\n\n", day)
+			b.WriteString("| Activity | Part | Description | Price | Note |\n")
+			b.WriteString("|----------|------|-------------|-------|------|\n")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			e.Kind, journalPart(e), escapeCell(deref(e.Description)), journalPrice(e),
+			escapeCell(strings.ReplaceAll(journalDetail(e), "\n", " ")))
+		labor += e.Labor
+	}
+	endDay()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJournalCSV writes the activity journal as CSV with a header row.
+// Hours is the time worked, for labor rows. A part priced in several
+// currencies lists each lowest price, and its currency in the same place,
+// separated by semicolons.
+func WriteJournalCSV(w io.Writer, entries []db.JournalEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "activity", "part_id", "part_number", "description", "lowest_price", "currency", "note", "hours"})
+	for _, e := range entries {
+		var prices, currencies []string
+		for _, p := range e.LowestPrices {
+			prices = append(prices, strconv.FormatFloat(p.Price, 'f', 2, 64))
+			currencies = append(currencies, p.Currency)
+		}
+		hours := ""
+		if e.Kind == db.JournalLabor {
+			hours = strconv.FormatFloat(e.Labor.Hours(), 'f', 2, 64)
+		}
+		cw.Write([]string{
+			e.At,
+			e.Kind,
+			strconv.Itoa(e.PartID),
+			e.PartNumber,
+			deref(e.Description),
+			strings.Join(prices, ";"),
+			strings.Join(currencies, ";"),
+			e.Detail,
+			hours,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// reporter is how commands that change data say what they do. -dry-run
+// changes nothing and lists what would change; -verbose lists each change
+// as it's made along with the items left alone and why.
+type reporter struct {
+	dryRun  bool
+	verbose bool
+}
+
+// reportFlags adds -dry-run and -verbose to a command's flags.
+func reportFlags(fs *flag.FlagSet) *reporter {
+	r := &reporter{}
+	fs.BoolVar(&r.dryRun, "dry-run", false, "Show what would change without changing anything")
+	fs.BoolVar(&r.verbose, "verbose", false, "Report the decision on every item")
+	fs.BoolVar(&r.verbose, "v", false, "Short for -verbose")
+	return r
+}
+
+// change reports one change, made or on a dry run to be made, when listing
+func (r *reporter) change(format string, args ...any) {
+	if r.dryRun || r.verbose {
+		fmt.Printf("  "+format+"\n", args...)
+	}
+}
+
+// skip reports an item left as it is, when verbose
+func (r *reporter) skip(format string, args ...any) {
+	if r.verbose {
+		fmt.Printf("  "+format+"\n", args...)
+	}
+}
+
+// did words a summary for what was done, or on a dry run what would be,
+// e.g. r.did("Imported", "Would import")
+func (r *reporter) did(done, would string) string {
+	if r.dryRun {
+		return would
+	}
+	return done
+}
+
+// finish ends a dry run's report by saying nothing was written
+func (r *reporter) finish() {
+	if r.dryRun {
+		fmt.Println("Dry run, nothing written.")
+	}
+}
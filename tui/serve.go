@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/web"
+)
+
+// runServe serves a read-only web view of the catalog. It only listens on
+// this machine unless -addr says otherwise, e.g. -addr :8080 for a phone on
+// the same network, since there's no login.
+func runServe(database *db.DB, dataPath string, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on; use :8080 to serve other devices on the network")
+	fs.Parse(args)
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	fmt.Printf("Serving the catalog at http://%s (Ctrl+C to stop)\n", displayAddr(ln.Addr()))
+	if tcp, ok := ln.Addr().(*net.TCPAddr); ok && !tcp.IP.IsLoopback() {
+		fmt.Println("Anyone who can reach this address can browse the catalog, notes and prices")
+	}
+	return http.Serve(ln, web.Handler(database, dataPath))
+}
+
+// displayAddr shows a wildcard listen address as localhost, e.g.
+// localhost:8080 for :8080
+func displayAddr(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+	return fmt.Sprintf("localhost:%d", tcp.Port)
+}
@@ -0,0 +1,99 @@
+package supplier
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Costs turns a supplier's price into what a part costs delivered: converted
+// to a home currency, plus a flat shipping estimate per supplier, plus
+// import duty or GST charged on both.
+type Costs struct {
+	Currency    string             // home currency, "" to keep each price's own
+	Rates       map[string]float64 // home currency per unit of another currency
+	Shipping    map[string]float64 // by supplier ID, in the home currency (or the price's, without one)
+	DutyPercent float64
+}
+
+// Enabled reports whether any landed cost setting is configured.
+func (c Costs) Enabled() bool {
+	return c.Currency != "" || len(c.Shipping) > 0 || c.DutyPercent != 0
+}
+
+// Landed returns the delivered cost of a supplier's price and its currency.
+// ok is false when the price is in a currency there's no rate for.
+func (c Costs) Landed(supplierID string, price float64, currency string) (amount float64, landedCurrency string, ok bool) {
+	currency = strings.ToUpper(currency)
+	amount, landedCurrency = price, currency
+	if c.Currency != "" && currency != c.Currency {
+		rate, found := c.Rates[currency]
+		if !found {
+			return 0, c.Currency, false
+		}
+		amount, landedCurrency = price*rate, c.Currency
+	}
+	amount += c.Shipping[supplierID]
+	amount *= 1 + c.DutyPercent/100
+	return amount, landedCurrency, true
+}
+
+// Describe summarizes the settings for a footnote, e.g. "NZD, shipping
+// Amayama 45, 15% duty/GST".
+func (c Costs) Describe() string {
+	var parts []string
+	if c.Currency != "" {
+		parts = append(parts, c.Currency)
+	}
+	for _, s := range All() {
+		if amount, ok := c.Shipping[s.ID()]; ok {
+			parts = append(parts, fmt.Sprintf("shipping %s %s", s.Name(), strconv.FormatFloat(amount, 'f', -1, 64)))
+		}
+	}
+	if c.DutyPercent != 0 {
+		parts = append(parts, strconv.FormatFloat(c.DutyPercent, 'f', -1, 64)+"% duty/GST")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CostsFromEnv reads DELICA_HOME_CURRENCY (e.g. NZD), DELICA_EXCHANGE_RATES
+// ("USD=1.68,JPY=0.011", home currency per unit), DELICA_SHIPPING
+// ("amayama=45,amazon=20", by supplier ID) and DELICA_IMPORT_DUTY (a
+// percentage such as 15).
+func CostsFromEnv() (Costs, error) {
+	c := Costs{Currency: strings.ToUpper(strings.TrimSpace(os.Getenv("DELICA_HOME_CURRENCY")))}
+
+	var err error
+	if c.Rates, err = parseAmounts("DELICA_EXCHANGE_RATES", strings.ToUpper); err != nil {
+		return c, err
+	}
+	if c.Shipping, err = parseAmounts("DELICA_SHIPPING", strings.ToLower); err != nil {
+		return c, err
+	}
+	if v := strings.TrimSpace(os.Getenv("DELICA_IMPORT_DUTY")); v != "" {
+		c.DutyPercent, err = strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil || c.DutyPercent < 0 {
+			return c, fmt.Errorf("DELICA_IMPORT_DUTY must be a percentage, got %q", v)
+		}
+	}
+	return c, nil
+}
+
+// parseAmounts reads a variable of comma-separated KEY=NUMBER pairs
+func parseAmounts(name string, normalizeKey func(string) string) (map[string]float64, error) {
+	v := os.Getenv(name)
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	amounts := make(map[string]float64)
+	for _, pair := range strings.Split(v, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || n < 0 || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%s must look like KEY=NUMBER,KEY=NUMBER, got %q", name, pair)
+		}
+		amounts[normalizeKey(strings.TrimSpace(key))] = n
+	}
+	return amounts, nil
+}
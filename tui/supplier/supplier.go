@@ -0,0 +1,48 @@
+// Package supplier describes the parts vendors the TUI links to and keeps
+// price data for.
+package supplier
+
+import "fmt"
+
+// Supplier is a parts vendor. ID is the stable key stored with price data.
+type Supplier interface {
+	ID() string
+	Name() string
+	// PartURL returns the vendor's page for a part number.
+	PartURL(partNumber string) string
+}
+
+// searchSupplier links to a vendor page built from a URL pattern.
+type searchSupplier struct {
+	id      string
+	name    string
+	pattern string // fmt pattern taking the part number
+}
+
+func (s searchSupplier) ID() string   { return s.id }
+func (s searchSupplier) Name() string { return s.name }
+
+func (s searchSupplier) PartURL(partNumber string) string {
+	return fmt.Sprintf(s.pattern, partNumber)
+}
+
+var (
+	Amayama  Supplier = searchSupplier{"amayama", "Amayama", "https://www.amayama.com/en/part/mitsubishi/%s"}
+	Amazon   Supplier = searchSupplier{"amazon", "Amazon", "https://www.amazon.com/s?k=%s"}
+	Partsouq Supplier = searchSupplier{"partsouq", "Partsouq", "https://partsouq.com/en/search/all?q=%s"}
+)
+
+// All returns the known suppliers in display order.
+func All() []Supplier {
+	return []Supplier{Amayama, Partsouq, Amazon}
+}
+
+// ByID returns the known supplier with the given ID.
+func ByID(id string) (Supplier, bool) {
+	for _, s := range All() {
+		if s.ID() == id {
+			return s, true
+		}
+	}
+	return nil, false
+}
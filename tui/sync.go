@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+	"github.com/mshick/delica-space-gear-parts/tui/notify"
+)
+
+// groupList collects -group flags, each of which may list several IDs.
+type groupList []string
+
+func (g *groupList) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *groupList) Set(value string) error {
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			*g = append(*g, id)
+		}
+	}
+	return nil
+}
+
+// runSync re-scrapes the given groups, or resumes a full scrape when none
+// are given, by running the Deno scraper against this data directory. It
+// then lists when each group was last synced. A dry run lists the scrape
+// progress it would clear or resume and the images it would download; what
+// the pages change depends on the site, so it can't say more than that.
+// Offline, it stops before starting the scraper rather than leave it
+// retrying.
+func runSync(database *db.DB, dataPath string, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	var groups groupList
+	fs.Var(&groups, "group", "Group ID to re-scrape; repeat or comma-separate for several")
+	list := fs.Bool("list", false, "Only list when each group was last synced")
+	report := reportFlags(fs)
+	fs.Parse(args)
+
+	if !*list {
+		scraperArgs := []string{"task", "scrape"}
+		for _, g := range groups {
+			scraperArgs = append(scraperArgs, "--group", g)
+		}
+		cmd := exec.Command("deno", scraperArgs...)
+		cmd.Dir = filepath.Join(dataPath, "..", "scraper")
+		cmd.Env = append(os.Environ(), "DELICA_DATA_DIR="+dataPath)
+
+		if report.dryRun || report.verbose {
+			if err := reportSyncPlan(database, groups, report); err != nil {
+				return err
+			}
+		}
+		report.change("run: deno %s (in %s)", strings.Join(scraperArgs, " "), cmd.Dir)
+		if report.dryRun {
+			fmt.Println("Would sync; the scraper isn't run on a dry run, so what the fetched pages change isn't known")
+			report.finish()
+			fmt.Println()
+			return listGroupSyncs(database)
+		}
+
+		if online, _ := netutil.CheckOnline(context.Background()); !online {
+			return fmt.Errorf("offline: can't reach %s; sync once the connection is back", netutil.ProbeAddr())
+		}
+		started := time.Now().UTC().Format("2006-01-02 15:04:05")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		announceSync(groups, err)
+		if err != nil {
+			return fmt.Errorf("scraper: %w", err)
+		}
+		fmt.Println()
+		if err := database.RefreshAggregates(); err != nil {
+			return fmt.Errorf("aggregate subgroups: %w", err)
+		}
+		if added, _ := database.CountAddedParts(started); added > 0 {
+			fmt.Printf("%d new parts in the catalog; see Recently Added on the home screen\n\n", added)
+		}
+	}
+
+	return listGroupSyncs(database)
+}
+
+// reportSyncPlan lists what a sync of groups, or with none the full scrape,
+// would clear, fetch and download
+func reportSyncPlan(database *db.DB, groups groupList, report *reporter) error {
+	plan, err := database.GetSyncPlan(groups)
+	if err != nil {
+		return fmt.Errorf("read scrape progress: %w", err)
+	}
+	for _, g := range plan.Groups {
+		if !g.Known {
+			report.change("%s: not in the catalog; the scraper fails if the site doesn't list it either", g.GroupID)
+			continue
+		}
+		synced := "never synced"
+		if g.SyncedAt != nil {
+			synced = "last synced " + locale.DateTimeString(*g.SyncedAt)
+		}
+		report.change("%s: clear the scrape progress of %d pages and fetch them again, re-checking %d parts (%s)",
+			g.GroupID, g.Pages, g.Parts, synced)
+	}
+	if len(groups) == 0 {
+		if plan.Pending > 0 {
+			report.change("resume the full scrape with %d pending pages", plan.Pending)
+		} else {
+			report.change("start the full scrape from the index page; no pages are pending")
+		}
+	}
+	if plan.Failed > 0 {
+		report.skip("%d failed pages left alone; deno task retry fetches them again", plan.Failed)
+	}
+	if plan.MissingImages > 0 {
+		report.change("download %d diagram images not yet downloaded", plan.MissingImages)
+	} else {
+		report.skip("every known diagram image is downloaded")
+	}
+	return nil
+}
+
+// listGroupSyncs prints each group's part count and when it was last synced
+func listGroupSyncs(database *db.DB) error {
+	syncs, err := database.GetGroupSyncs()
+	if err != nil {
+		return fmt.Errorf("read sync times: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tPARTS\tLAST SYNCED (UTC)")
+	for _, s := range syncs {
+		syncedAt := "never"
+		if s.SyncedAt != nil {
+			syncedAt = locale.DateTimeString(*s.SyncedAt)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", s.GroupID, s.Parts, syncedAt)
+	}
+	return w.Flush()
+}
+
+// announceSync rings the bell or shows a desktop notification, as
+// DELICA_NOTIFY configures for sync, since a full scrape takes hours.
+func announceSync(groups groupList, err error) {
+	summary := "Full scrape finished"
+	if len(groups) > 0 {
+		summary = "Synced " + strings.Join(groups, ", ")
+	}
+	if err != nil {
+		summary = fmt.Sprintf("Sync failed: %v", err)
+	}
+
+	method := notify.For(notify.KindSync)
+	if method.Bell {
+		fmt.Print(notify.Bell)
+	}
+	if method.Desktop {
+		if err := notify.Desktop("delica-tui sync", summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Desktop notification failed: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ASCII is set by DELICA_ASCII=1 for terminals that mangle Unicode, such as
+// serial consoles and old PuTTY builds. The session model passes every
+// frame through ToASCII then, so screens keep drawing with box lines,
+// arrows and marks and needn't check it themselves.
+var ASCII, _ = strconv.ParseBool(os.Getenv("DELICA_ASCII"))
+
+// asciiGlyphs maps the glyphs screens draw with to one ASCII character
+// each, so columns stay aligned
+var asciiGlyphs = map[rune]rune{
+	'─': '-', '│': '|', '┃': '|', '╭': '+', '╮': '+', '╰': '+', '╯': '+',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+', '├': '+', '┤': '+', '┬': '+', '┴': '+', '┼': '+',
+	'↑': '^', '↓': 'v', '←': '<', '→': '>', '›': '>', '▲': '^', '▼': 'v', '▸': '>', '▾': 'v',
+	'·': '-', '—': '-', '…': '~', '×': 'x',
+	'✓': '+', '✗': 'x', '●': '*', '★': '*', '☆': '*',
+	'█': '#', '▀': '"', '▄': '_', '░': '.',
+	'€': 'E', '£': 'L', '¥': 'Y',
+}
+
+// ToASCII replaces the glyphs in s with ASCII. Anything else outside ASCII,
+// like Japanese in catalog text, becomes one ? per cell it takes up.
+// Escape sequences are ASCII already and pass through.
+func ToASCII(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case asciiGlyphs[r] != 0:
+			b.WriteRune(asciiGlyphs[r])
+		default:
+			b.WriteString(strings.Repeat("?", lipgloss.Width(string(r))))
+		}
+	}
+	return b.String()
+}
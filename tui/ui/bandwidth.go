@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// LowBandwidth is set by -low-bandwidth, for browsing over SSH on a slow
+// or metered link. Images are off too, via image.Disabled.
+var LowBandwidth bool
+
+// LowBandwidthFPS caps redraws per second in low-bandwidth mode, so
+// changes arriving together are sent as one frame
+const LowBandwidthFPS = 5
+
+// SetLowBandwidth turns on low-bandwidth mode, drawing without colors to
+// save the escape sequences. Selection stays visible by its › marker and
+// bold text.
+func SetLowBandwidth() {
+	LowBandwidth = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
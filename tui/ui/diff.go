@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	DiffRemovedStyle = lipgloss.NewStyle().
+				Foreground(ColorRed).
+				Bold(true).
+				Underline(true)
+
+	DiffAddedStyle = lipgloss.NewStyle().
+			Foreground(ColorGreen).
+			Bold(true).
+			Underline(true)
+)
+
+// CharDiff aligns a and b on their longest common subsequence and renders
+// each with the characters the other lacks highlighted: removed ones in a,
+// added ones in b. edits counts the highlighted characters.
+func CharDiff(a, b string) (renderedA, renderedB string, edits int) {
+	ra, rb := []rune(a), []rune(b)
+
+	// lcs[i][j] is the common subsequence length of ra[i:] and rb[j:]
+	lcs := make([][]int, len(ra)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(rb)+1)
+	}
+	for i := len(ra) - 1; i >= 0; i-- {
+		for j := len(rb) - 1; j >= 0; j-- {
+			if ra[i] == rb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var outA, outB strings.Builder
+	i, j := 0, 0
+	for i < len(ra) || j < len(rb) {
+		switch {
+		case i < len(ra) && j < len(rb) && ra[i] == rb[j]:
+			outA.WriteRune(ra[i])
+			outB.WriteRune(rb[j])
+			i++
+			j++
+		case j >= len(rb) || (i < len(ra) && lcs[i+1][j] >= lcs[i][j+1]):
+			outA.WriteString(DiffRemovedStyle.Render(string(ra[i])))
+			edits++
+			i++
+		default:
+			outB.WriteString(DiffAddedStyle.Render(string(rb[j])))
+			edits++
+			j++
+		}
+	}
+	return outA.String(), outB.String(), edits
+}
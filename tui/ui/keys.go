@@ -1,6 +1,10 @@
 package ui
 
-import "github.com/charmbracelet/bubbletea"
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbletea"
+)
 
 func IsQuit(msg tea.KeyMsg) bool {
 	return msg.String() == "q"
@@ -18,6 +22,14 @@ func IsDown(msg tea.KeyMsg) bool {
 	return msg.Type == tea.KeyDown || msg.String() == "j"
 }
 
+func IsLeft(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyLeft || msg.String() == "h"
+}
+
+func IsRight(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyRight || msg.String() == "l"
+}
+
 func IsEnter(msg tea.KeyMsg) bool {
 	return msg.Type == tea.KeyEnter
 }
@@ -37,3 +49,306 @@ func IsNote(msg tea.KeyMsg) bool {
 func IsSaveNote(msg tea.KeyMsg) bool {
 	return msg.Type == tea.KeyCtrlS
 }
+
+func IsJump(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlP
+}
+
+func IsEdit(msg tea.KeyMsg) bool {
+	return msg.String() == "e"
+}
+
+func IsRevert(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlR
+}
+
+func IsExport(msg tea.KeyMsg) bool {
+	return msg.String() == "x"
+}
+
+func IsToggleDebug(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlG
+}
+
+func IsPin(msg tea.KeyMsg) bool {
+	return msg.String() == "p"
+}
+
+func IsBarcode(msg tea.KeyMsg) bool {
+	return msg.String() == "c"
+}
+
+func IsPNC(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlN
+}
+
+func IsConsole(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlO
+}
+
+func IsShortlist(msg tea.KeyMsg) bool {
+	return msg.String() == "s"
+}
+
+func IsShortlistDrawer(msg tea.KeyMsg) bool {
+	return msg.String() == "S"
+}
+
+func IsHideSuperseded(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlX
+}
+
+func IsColumns(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlK
+}
+
+func IsRemove(msg tea.KeyMsg) bool {
+	return msg.String() == "d"
+}
+
+func IsImageFit(msg tea.KeyMsg) bool {
+	return msg.String() == "z"
+}
+
+// Pan returns the direction H/J/K/L move a zoomed image, or 0, 0.
+func Pan(msg tea.KeyMsg) (dx, dy int) {
+	switch msg.String() {
+	case "H":
+		return -1, 0
+	case "L":
+		return 1, 0
+	case "K":
+		return 0, -1
+	case "J":
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// Arrow returns the direction of an arrow key, or 0, 0.
+func Arrow(msg tea.KeyMsg) (dx, dy int) {
+	switch msg.Type {
+	case tea.KeyLeft:
+		return -1, 0
+	case tea.KeyRight:
+		return 1, 0
+	case tea.KeyUp:
+		return 0, -1
+	case tea.KeyDown:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// Section returns the section, from 1, that a digit key folds or unfolds,
+// or 0.
+func Section(msg tea.KeyMsg) int {
+	if s := msg.String(); len(s) == 1 && s[0] >= '1' && s[0] <= '9' {
+		return int(s[0] - '0')
+	}
+	return 0
+}
+
+func IsDiagramToggle(msg tea.KeyMsg) bool {
+	return msg.String() == "v"
+}
+
+func IsAttach(msg tea.KeyMsg) bool {
+	return msg.String() == "a"
+}
+
+func IsPurchase(msg tea.KeyMsg) bool {
+	return msg.String() == "$"
+}
+
+func IsRefreshPrices(msg tea.KeyMsg) bool {
+	return msg.String() == "p"
+}
+
+func IsDimensions(msg tea.KeyMsg) bool {
+	return msg.String() == "D"
+}
+
+func IsOrigin(msg tea.KeyMsg) bool {
+	return msg.String() == "i"
+}
+
+func IsOpenWith(msg tea.KeyMsg) bool {
+	return msg.String() == "l"
+}
+
+func IsOpenAll(msg tea.KeyMsg) bool {
+	return msg.String() == "w"
+}
+
+func IsConfirm(msg tea.KeyMsg) bool {
+	return msg.String() == "y" || msg.Type == tea.KeyEnter
+}
+
+func IsMailOrder(msg tea.KeyMsg) bool {
+	return msg.String() == "m"
+}
+
+func IsMigrate(msg tea.KeyMsg) bool {
+	return msg.String() == "m"
+}
+
+func IsOppositeHand(msg tea.KeyMsg) bool {
+	return msg.String() == "o"
+}
+
+func IsShortlistPair(msg tea.KeyMsg) bool {
+	return msg.String() == "O"
+}
+
+func IsRevision(msg tea.KeyMsg) bool {
+	return msg.String() == "r"
+}
+
+func IsRevisionBlink(msg tea.KeyMsg) bool {
+	return msg.String() == "R"
+}
+
+func IsCancelBulk(msg tea.KeyMsg) bool {
+	return msg.String() == "c"
+}
+
+func IsCheckLinks(msg tea.KeyMsg) bool {
+	return msg.String() == "l"
+}
+
+func IsRepeatJob(msg tea.KeyMsg) bool {
+	return msg.String() == "R"
+}
+
+func IsPeriod(msg tea.KeyMsg) bool {
+	return msg.String() == "p"
+}
+
+func IsTimer(msg tea.KeyMsg) bool {
+	return msg.String() == "t"
+}
+
+func IsFilter(msg tea.KeyMsg) bool {
+	return msg.String() == "f"
+}
+
+func IsNextPage(msg tea.KeyMsg) bool {
+	return msg.String() == "]"
+}
+
+func IsPrevPage(msg tea.KeyMsg) bool {
+	return msg.String() == "["
+}
+
+func IsHotspots(msg tea.KeyMsg) bool {
+	return msg.String() == "C"
+}
+
+func IsKeepDiagram(msg tea.KeyMsg) bool {
+	return msg.String() == "P"
+}
+
+func IsCallouts(msg tea.KeyMsg) bool {
+	return msg.String() == "v"
+}
+
+func IsFullScreen(msg tea.KeyMsg) bool {
+	return msg.String() == "F"
+}
+
+func IsZoomIn(msg tea.KeyMsg) bool {
+	return msg.String() == "+" || msg.String() == "="
+}
+
+func IsZoomOut(msg tea.KeyMsg) bool {
+	return msg.String() == "-"
+}
+
+func IsZoomFit(msg tea.KeyMsg) bool {
+	return msg.String() == "0"
+}
+
+func IsNextRef(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyTab
+}
+
+func IsPrevRef(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyShiftTab
+}
+
+func IsCopyOrder(msg tea.KeyMsg) bool {
+	return msg.String() == "Y"
+}
+
+func IsMoreQty(msg tea.KeyMsg) bool {
+	return msg.String() == "+" || msg.String() == "="
+}
+
+func IsLessQty(msg tea.KeyMsg) bool {
+	return msg.String() == "-"
+}
+
+func IsClearCart(msg tea.KeyMsg) bool {
+	return msg.String() == "C"
+}
+
+func IsBookmarkAll(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlB
+}
+
+func IsShortlistAll(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyCtrlS
+}
+
+func IsFocusPane(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyTab
+}
+
+// IsOpenScreen opens the catalog tree's selected group, subgroup or part
+// on its own screen.
+func IsOpenScreen(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyTab
+}
+
+func IsSortColumn(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyTab
+}
+
+func IsSortReverse(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyShiftTab
+}
+
+// Chords are two-key sequences typed outside text inputs, vim style
+const (
+	ChordTop       = "g g"
+	ChordHome      = "g h"
+	ChordBookmarks = "g b"
+	ChordNotes     = "g n"
+	ChordJournal   = "g j"
+	ChordCopyPart  = "y y"
+)
+
+// Chords lists every chord, in the order hints show them
+var Chords = []string{ChordTop, ChordHome, ChordBookmarks, ChordNotes, ChordJournal, ChordCopyPart}
+
+// IsChordPrefix reports whether msg starts a chord
+func IsChordPrefix(msg tea.KeyMsg) bool {
+	for _, c := range Chords {
+		if strings.HasPrefix(c, msg.String()+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Chord returns the chord that prefix followed by msg completes, if any
+func Chord(prefix, msg tea.KeyMsg) (string, bool) {
+	keys := prefix.String() + " " + msg.String()
+	for _, c := range Chords {
+		if c == keys {
+			return c, true
+		}
+	}
+	return "", false
+}
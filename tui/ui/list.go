@@ -0,0 +1,49 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// MoveCursor applies the navigation keys every list shares to a cursor over
+// count items, page of which are visible at once:
+//
+//	↑/↓, k/j      one item
+//	pgup/pgdn     one page
+//	ctrl+u/ctrl+d half a page
+//	g/G           first/last item
+//
+// Lists under a focused text input pass typing so letters reach the input;
+// only the arrow, page and ctrl keys move the cursor then. It returns the new
+// cursor, clamped to the list, and whether msg was a navigation key.
+func MoveCursor(msg tea.KeyMsg, cursor, count, page int, typing bool) (int, bool) {
+	if page < 2 {
+		page = 2
+	}
+
+	switch {
+	case msg.Type == tea.KeyUp || (!typing && msg.String() == "k"):
+		cursor--
+	case msg.Type == tea.KeyDown || (!typing && msg.String() == "j"):
+		cursor++
+	case msg.Type == tea.KeyPgUp:
+		cursor -= page
+	case msg.Type == tea.KeyPgDown:
+		cursor += page
+	case msg.Type == tea.KeyCtrlU:
+		cursor -= page / 2
+	case msg.Type == tea.KeyCtrlD:
+		cursor += page / 2
+	case !typing && msg.String() == "g":
+		cursor = 0
+	case !typing && msg.String() == "G":
+		cursor = count - 1
+	default:
+		return cursor, false
+	}
+
+	if cursor > count-1 {
+		cursor = count - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor, true
+}
@@ -3,18 +3,30 @@ package ui
 import (
 	"fmt"
 	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type MenuItem struct {
 	ID    string
 	Label string
 	Hint  string
+
+	// Tinted items get a subtle background, e.g. parts already bookmarked
+	// or noted
+	Tinted bool
 }
 
 type Menu struct {
 	Items           []MenuItem
 	Cursor          int
 	MaxVisibleItems int
+
+	// Columns above 1 lays items out newspaper style, top to bottom then
+	// left to right, in columns of Width/Columns cells
+	Columns int
+	Width   int
 }
 
 func NewMenu(items []MenuItem) *Menu {
@@ -44,10 +56,31 @@ func (m *Menu) Selected() *MenuItem {
 	return nil
 }
 
+// KeepPosition puts the cursor back on the item prev had selected, a
+// menu shown before this one was rebuilt, or on the same row if that item
+// has gone.
+func (m *Menu) KeepPosition(prev *Menu) {
+	if prev == nil || len(m.Items) == 0 {
+		return
+	}
+	if sel := prev.Selected(); sel != nil && sel.ID != "" {
+		for i, item := range m.Items {
+			if item.ID == sel.ID {
+				m.Cursor = i
+				return
+			}
+		}
+	}
+	m.Cursor = max(0, min(prev.Cursor, len(m.Items)-1))
+}
+
 func (m *Menu) View() string {
 	if len(m.Items) == 0 {
 		return DimStyle.Render("No items")
 	}
+	if m.Columns > 1 {
+		return m.columnView()
+	}
 
 	// Calculate visible window
 	// Reserve 2 lines for scroll indicators if needed
@@ -97,36 +130,129 @@ func (m *Menu) View() string {
 
 	// Menu items
 	for i := windowStart; i < windowEnd; i++ {
-		item := m.Items[i]
-		isSelected := i == m.Cursor
+		lines = append(lines, m.itemLine(i))
+	}
 
-		var line string
-		if isSelected {
-			line = SelectedStyle.Render("› ") + SelectedLabelStyle.Render(strings.ToUpper(item.Label))
+	// Last line: "more below" indicator (if scrollable)
+	if needsScrolling {
+		if hasMoreBelow {
+			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↓ %d more", len(m.Items)-windowEnd)))
 		} else {
-			line = "  " + NormalLabelStyle.Render(strings.ToUpper(item.Label))
+			lines = append(lines, "")
+		}
+	}
+
+	// Pad to MaxVisibleItems if we have fewer lines
+	for len(lines) < m.MaxVisibleItems {
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Menu) itemLine(i int) string {
+	item := m.Items[i]
+
+	marker, label, hint := lipgloss.NewStyle(), NormalLabelStyle, DimStyle
+	if i == m.Cursor {
+		marker, label = SelectedStyle, SelectedLabelStyle
+	}
+	if item.Tinted {
+		marker, label, hint = marker.Background(ColorTint), label.Background(ColorTint), hint.Background(ColorTint)
+	}
+
+	line := "  "
+	if i == m.Cursor {
+		line = "› "
+	}
+	line = marker.Render(line) + label.Render(strings.ToUpper(item.Label))
+	if item.Hint != "" {
+		line += hint.Render(" " + strings.ToUpper(item.Hint))
+	}
+	return line
+}
+
+// HandleKey moves the cursor for a navigation key (see MoveCursor),
+// reporting whether msg was one. With several columns, ←/→ (and h/l) move
+// to the neighbouring column.
+func (m *Menu) HandleKey(msg tea.KeyMsg) bool {
+	if m.Columns > 1 {
+		rows := m.columnRows()
+		switch {
+		case msg.Type == tea.KeyLeft || msg.String() == "h":
+			if m.Cursor-rows >= 0 {
+				m.Cursor -= rows
+			}
+			return true
+		case msg.Type == tea.KeyRight || msg.String() == "l":
+			m.Cursor = min(m.Cursor+rows, len(m.Items)-1)
+			return true
 		}
+		cursor, ok := MoveCursor(msg, m.Cursor, len(m.Items), rows*m.Columns, false)
+		m.Cursor = cursor
+		return ok
+	}
+
+	page := m.MaxVisibleItems
+	if len(m.Items) > m.MaxVisibleItems {
+		page -= 2 // scroll indicators
+	}
+	cursor, ok := MoveCursor(msg, m.Cursor, len(m.Items), page, false)
+	m.Cursor = cursor
+	return ok
+}
+
+// columnRows returns how many rows each column has. Lists that fit are
+// balanced across the columns; longer ones fill MaxVisibleItems, less the
+// scroll indicators, and page.
+func (m *Menu) columnRows() int {
+	if len(m.Items) <= m.MaxVisibleItems*m.Columns {
+		return max((len(m.Items)+m.Columns-1)/m.Columns, 1)
+	}
+	return max(m.MaxVisibleItems-2, 1)
+}
 
-		if item.Hint != "" {
-			line += DimStyle.Render(" " + strings.ToUpper(item.Hint))
+// columnView renders the items in columns, a page at a time.
+func (m *Menu) columnView() string {
+	rows := m.columnRows()
+	perPage := rows * m.Columns
+	paged := len(m.Items) > perPage
+	start := m.Cursor / perPage * perPage
+	end := min(start+perPage, len(m.Items))
+	colWidth := m.Width / m.Columns
+
+	var lines []string
+	if paged {
+		if start > 0 {
+			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↑ %d more", start)))
+		} else {
+			lines = append(lines, "")
 		}
+	}
 
+	cell := lipgloss.NewStyle().MaxWidth(colWidth - 2)
+	for r := 0; r < rows; r++ {
+		var line string
+		for c := 0; c < m.Columns; c++ {
+			i := start + c*rows + r
+			if i >= end {
+				break
+			}
+			line = padToWidth(line, c*colWidth) + cell.Render(m.itemLine(i))
+		}
 		lines = append(lines, line)
 	}
 
-	// Last line: "more below" indicator (if scrollable)
-	if needsScrolling {
-		if hasMoreBelow {
-			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↓ %d more", len(m.Items)-windowEnd)))
+	if paged {
+		if end < len(m.Items) {
+			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↓ %d more", len(m.Items)-end)))
 		} else {
 			lines = append(lines, "")
 		}
 	}
 
-	// Pad to MaxVisibleItems if we have fewer lines
 	for len(lines) < m.MaxVisibleItems {
 		lines = append(lines, "")
 	}
-
 	return strings.Join(lines, "\n")
 }
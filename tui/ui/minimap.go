@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"math"
+	"strings"
+)
+
+// Largest minimap, in cells
+const (
+	MinimapWidth  = 16
+	MinimapHeight = 4
+)
+
+// minimapSize fits an image of w x h cells into the minimap, keeping its
+// shape. Cells are about twice as tall as wide, so each line of the map
+// stands for two steps, drawn with half blocks.
+func minimapSize(w, h int) (cols, steps int) {
+	if w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	scale := min(float64(MinimapWidth)/float64(w), float64(MinimapHeight)/float64(h))
+	cols = max(1, int(math.Round(float64(w)*scale)))
+	steps = max(2, int(math.Round(float64(2*h)*scale)))
+	return cols, steps
+}
+
+// MinimapLines is how many lines Minimap draws for an image of w x h cells.
+func MinimapLines(w, h int) int {
+	_, steps := minimapSize(w, h)
+	return (steps + 1) / 2
+}
+
+// Minimap draws where a panned view of x, y, viewW x viewH cells lies
+// within an image of w x h cells: the image as a dim field with the part in
+// view in solid blocks.
+func Minimap(w, h, x, y, viewW, viewH int) string {
+	cols, steps := minimapSize(w, h)
+	if cols == 0 {
+		return ""
+	}
+
+	// Whether the map's column c and step s overlap the view
+	inView := func(c, s int) bool {
+		x0, x1 := float64(c*w)/float64(cols), float64((c+1)*w)/float64(cols)
+		y0, y1 := float64(s*h)/float64(steps), float64((s+1)*h)/float64(steps)
+		return x0 < float64(x+viewW) && x1 > float64(x) && y0 < float64(y+viewH) && y1 > float64(y)
+	}
+
+	lines := make([]string, 0, (steps+1)/2)
+	for s := 0; s < steps; s += 2 {
+		var line strings.Builder
+		for c := 0; c < cols; c++ {
+			top, bottom := inView(c, s), s+1 < steps && inView(c, s+1)
+			switch {
+			case top && bottom:
+				line.WriteString(SelectedStyle.Render("█"))
+			case top:
+				line.WriteString(SelectedStyle.Render("▀"))
+			case bottom:
+				line.WriteString(SelectedStyle.Render("▄"))
+			default:
+				line.WriteString(DimStyle.Render("░"))
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -6,12 +6,48 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+const leftMargin = 2 // Left margin for the whole split pane
+
+func splitWidths(totalWidth int) (leftWidth, rightWidth int) {
+	leftWidth = (totalWidth - leftMargin) * 40 / 100
+	rightWidth = totalWidth - leftMargin - leftWidth - 3 // Account for border
+	return leftWidth, rightWidth
+}
+
+// SplitPaneLeftWidth returns the width of RenderSplitPane's left pane.
+func SplitPaneLeftWidth(totalWidth int) int {
+	leftWidth, _ := splitWidths(totalWidth)
+	return leftWidth
+}
+
+// SplitPaneRightWidth returns the room RenderSplitPane leaves for the right
+// pane's content.
+func SplitPaneRightWidth(totalWidth int) int {
+	_, rightWidth := splitWidths(totalWidth)
+	return rightWidth - 2
+}
+
+// Pane is one side of a split pane.
+type Pane int
+
+const (
+	PaneRight Pane = iota // the list or details, focused by default
+	PaneLeft              // the diagram
+)
+
 // RenderSplitPane renders a split pane with left and right content.
 func RenderSplitPane(left, right string, totalWidth, totalHeight int) string {
-	const leftMargin = 2 // Left margin for the whole split pane
+	return renderSplitPane(left, right, totalWidth, totalHeight, false, PaneRight)
+}
+
+// RenderFocusedSplitPane renders a split pane with a heavy border down both
+// sides of the focused pane, for screens where either pane takes the keys.
+func RenderFocusedSplitPane(left, right string, totalWidth, totalHeight int, focus Pane) string {
+	return renderSplitPane(left, right, totalWidth, totalHeight, true, focus)
+}
 
-	leftWidth := (totalWidth - leftMargin) * 40 / 100
-	rightWidth := totalWidth - leftMargin - leftWidth - 3 // Account for border
+func renderSplitPane(left, right string, totalWidth, totalHeight int, focused bool, focus Pane) string {
+	leftWidth, rightWidth := splitWidths(totalWidth)
 
 	// Fit content to exact height first
 	leftContent := FitHeight(left, totalHeight)
@@ -22,6 +58,16 @@ func RenderSplitPane(left, right string, totalWidth, totalHeight int) string {
 	rightLines := strings.Split(rightContent, "\n")
 
 	margin := strings.Repeat(" ", leftMargin)
+	divider, rightEdge := "│ ", ""
+	if focused {
+		border := SelectedStyle.Render("┃")
+		divider = border + " "
+		if focus == PaneLeft {
+			margin = border + strings.Repeat(" ", leftMargin-1)
+		} else {
+			rightEdge = " " + border
+		}
+	}
 
 	var result []string
 	for i := 0; i < totalHeight; i++ {
@@ -34,13 +80,17 @@ func RenderSplitPane(left, right string, totalWidth, totalHeight int) string {
 			rightLine = rightLines[i]
 		}
 
-		// Pad left line to width
+		// Pad left line to width, clipping one wider, such as a diagram
+		// drawn as text
+		if lipgloss.Width(leftLine) > leftWidth {
+			leftLine = lipgloss.NewStyle().MaxWidth(leftWidth).Render(leftLine)
+		}
 		leftPadded := padToWidth(leftLine, leftWidth)
 
 		// Add border character and right content
-		rightPadded := "│ " + padToWidth(rightLine, rightWidth-2)
+		rightPadded := divider + padToWidth(rightLine, rightWidth-2)
 
-		result = append(result, margin+leftPadded+rightPadded)
+		result = append(result, margin+leftPadded+rightPadded+rightEdge)
 	}
 
 	return strings.Join(result, "\n")
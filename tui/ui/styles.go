@@ -11,6 +11,7 @@ var (
 	ColorWhite   = lipgloss.Color("15")
 	ColorRed     = lipgloss.Color("1")
 	ColorBlue    = lipgloss.Color("4")
+	ColorTint    = lipgloss.Color("236") // background of highlighted rows
 
 	HeaderStyle = lipgloss.NewStyle().
 			Foreground(ColorCyan).
@@ -42,6 +43,11 @@ var (
 	LinkStyle = lipgloss.NewStyle().
 			Foreground(ColorBlue)
 
+	// Barcodes need dark bars on a light background whatever the theme
+	BarcodeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(lipgloss.Color("#FFFFFF"))
+
 	BoxStyle = lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			Padding(0, 1)
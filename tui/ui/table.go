@@ -0,0 +1,396 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Column describes one column of a Table.
+type Column struct {
+	Title string
+	Width int // cells, including the gap after it; 0 takes what's left of the row
+
+	// Numeric columns sort by the number their cells start with, rather
+	// than alphabetically
+	Numeric bool
+}
+
+// TableRow is one row of a Table, a cell per column.
+type TableRow struct {
+	ID    string
+	Cells []string
+
+	pos int // place in the rows as given, restored by turning sorting off
+}
+
+// Table is a list of rows in columns under a header, sortable by any
+// column. Like Menu, it scrolls around the cursor and can lay rows out
+// newspaper style on wide screens.
+type Table struct {
+	Columns         []Column
+	Rows            []TableRow
+	Cursor          int
+	MaxVisibleItems int // including the header line
+	Width           int
+
+	// Panels above 1 lays rows out top to bottom then left to right, in
+	// panels of Width/Panels cells, each with its own header
+	Panels int
+
+	// Typing tables sit under a focused text input, so letters reach the
+	// input rather than moving the cursor
+	Typing bool
+
+	// SortColumn is the column rows are sorted by, or -1 for the order they
+	// were given in
+	SortColumn int
+	SortDesc   bool
+
+	// RowStyle, if set, adjusts the style of a row's cells: col is the
+	// column, or -1 for the cursor marker, and style the default
+	RowStyle func(row TableRow, col int, style lipgloss.Style) lipgloss.Style
+}
+
+func NewTable(columns []Column, rows []TableRow) *Table {
+	for i := range rows {
+		rows[i].pos = i
+	}
+	return &Table{
+		Columns:         columns,
+		Rows:            rows,
+		MaxVisibleItems: 15,
+		SortColumn:      -1,
+	}
+}
+
+func (t *Table) Selected() *TableRow {
+	if t.Cursor >= 0 && t.Cursor < len(t.Rows) {
+		return &t.Rows[t.Cursor]
+	}
+	return nil
+}
+
+// IDs returns the row IDs in the order shown.
+func (t *Table) IDs() []string {
+	ids := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		ids[i] = row.ID
+	}
+	return ids
+}
+
+// KeepPosition takes on the sorting of prev, a table shown before this one
+// was rebuilt, and puts the cursor back on the row prev had selected, or on
+// the same line if that row has gone.
+func (t *Table) KeepPosition(prev *Table) {
+	if prev == nil {
+		return
+	}
+	t.SortColumn, t.SortDesc = prev.SortColumn, prev.SortDesc
+	t.sort()
+	if len(t.Rows) == 0 {
+		return
+	}
+	if sel := prev.Selected(); sel != nil && sel.ID != "" {
+		if i := t.index(sel.ID); i >= 0 {
+			t.Cursor = i
+			return
+		}
+	}
+	t.Cursor = max(0, min(prev.Cursor, len(t.Rows)-1))
+}
+
+func (t *Table) index(id string) int {
+	return slices.IndexFunc(t.Rows, func(row TableRow) bool { return row.ID == id })
+}
+
+// SortBy sorts the rows by column col, or back into the order they were
+// given in for -1, keeping the cursor on the same row.
+func (t *Table) SortBy(col int, desc bool) {
+	t.SortColumn, t.SortDesc = col, desc
+	t.sort()
+}
+
+func (t *Table) sort() {
+	var id string
+	if sel := t.Selected(); sel != nil {
+		id = sel.ID
+	}
+
+	col := t.SortColumn
+	if col < 0 || col >= len(t.Columns) {
+		t.SortColumn = -1
+		slices.SortFunc(t.Rows, func(a, b TableRow) int { return a.pos - b.pos })
+	} else {
+		numeric := t.Columns[col].Numeric
+		slices.SortStableFunc(t.Rows, func(a, b TableRow) int {
+			x, y := cell(a, col), cell(b, col)
+			// Blank cells go last either way
+			if (x == "") != (y == "") {
+				if x == "" {
+					return 1
+				}
+				return -1
+			}
+			c := compareCells(x, y, numeric)
+			if c == 0 {
+				return a.pos - b.pos
+			}
+			if t.SortDesc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	if id != "" {
+		if i := t.index(id); i >= 0 {
+			t.Cursor = i
+		}
+	}
+}
+
+func cell(row TableRow, col int) string {
+	if col < len(row.Cells) {
+		return strings.TrimSpace(row.Cells[col])
+	}
+	return ""
+}
+
+// compareCells orders cells alphabetically ignoring case or, for numeric
+// columns, by their leading number
+func compareCells(x, y string, numeric bool) int {
+	if numeric {
+		if c := cmp.Compare(leadingNumber(x), leadingNumber(y)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(strings.ToLower(x), strings.ToLower(y))
+}
+
+func leadingNumber(s string) float64 {
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' || end == 0 && s[end] == '-') {
+		end++
+	}
+	n, _ := strconv.ParseFloat(s[:end], 64)
+	return n
+}
+
+// HandleKey moves the cursor for a navigation key (see MoveCursor) and
+// changes the sorting for tab, the next column, and shift+tab, the other
+// direction. It reports whether msg was one of them.
+func (t *Table) HandleKey(msg tea.KeyMsg) bool {
+	switch {
+	case IsSortColumn(msg):
+		// Off, then each column in turn
+		next := t.SortColumn + 1
+		if next >= len(t.Columns) {
+			next = -1
+		}
+		t.SortBy(next, false)
+		return true
+	case IsSortReverse(msg):
+		if t.SortColumn < 0 {
+			t.SortBy(0, true)
+		} else {
+			t.SortBy(t.SortColumn, !t.SortDesc)
+		}
+		return true
+	}
+
+	rows := t.panelRows()
+	if t.Panels > 1 && !t.Typing {
+		switch {
+		case msg.Type == tea.KeyLeft || msg.String() == "h":
+			if t.Cursor-rows >= 0 {
+				t.Cursor -= rows
+			}
+			return true
+		case msg.Type == tea.KeyRight || msg.String() == "l":
+			t.Cursor = min(t.Cursor+rows, len(t.Rows)-1)
+			return true
+		}
+	}
+	cursor, ok := MoveCursor(msg, t.Cursor, len(t.Rows), rows*max(t.Panels, 1), t.Typing)
+	t.Cursor = cursor
+	return ok
+}
+
+// SortHint describes the sorting for a screen's footer
+func (t *Table) SortHint() string {
+	if t.SortColumn < 0 {
+		return "tab sort"
+	}
+	return fmt.Sprintf("tab sort (%s)   shift+tab reverse", strings.ToLower(t.Columns[t.SortColumn].Title))
+}
+
+// panelRows returns how many rows each panel lists, leaving room for the
+// header and, when the rows don't all fit, the scroll indicators.
+func (t *Table) panelRows() int {
+	panels := max(t.Panels, 1)
+	lines := t.MaxVisibleItems - 1
+	if len(t.Rows) <= lines*panels {
+		if panels == 1 {
+			return max(lines, 1)
+		}
+		return max((len(t.Rows)+panels-1)/panels, 1)
+	}
+	return max(lines-2, 1)
+}
+
+func (t *Table) View() string {
+	if len(t.Rows) == 0 {
+		return DimStyle.Render("No items")
+	}
+
+	panels := max(t.Panels, 1)
+	rows := t.panelRows()
+	perPage := rows * panels
+	paged := len(t.Rows) > perPage
+
+	// One panel scrolls with the cursor in the middle; several turn pages
+	var start int
+	if panels == 1 {
+		start = max(0, min(t.Cursor-rows/2, len(t.Rows)-rows))
+	} else {
+		start = t.Cursor / perPage * perPage
+	}
+	end := min(start+perPage, len(t.Rows))
+
+	width := t.Width
+	if width <= 0 {
+		width = 80
+	}
+	panelWidth := width / panels
+	widths := t.columnWidths(panelWidth - 2)
+	clip := lipgloss.NewStyle().MaxWidth(panelWidth - 1)
+
+	var header string
+	for p := 0; p < panels && p*rows+start < end || p == 0; p++ {
+		header = padToWidth(header, p*panelWidth) + clip.Render("  "+t.headerLine(widths))
+	}
+	lines := []string{header}
+
+	if paged {
+		if start > 0 {
+			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↑ %d more", start)))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	for r := 0; r < rows; r++ {
+		var line string
+		for p := 0; p < panels; p++ {
+			i := start + p*rows + r
+			if i >= end {
+				break
+			}
+			line = padToWidth(line, p*panelWidth) + clip.Render(t.rowLine(i, widths))
+		}
+		lines = append(lines, line)
+	}
+	if paged {
+		if end < len(t.Rows) {
+			lines = append(lines, DimStyle.Render(fmt.Sprintf("  ↓ %d more", len(t.Rows)-end)))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	for len(lines) < t.MaxVisibleItems {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// columnWidths gives each column its width, the flexible one whatever the
+// others leave of width
+func (t *Table) columnWidths(width int) []int {
+	widths := make([]int, len(t.Columns))
+	fixed := 0
+	for i, c := range t.Columns {
+		widths[i] = c.Width
+		fixed += c.Width
+	}
+	for i, c := range t.Columns {
+		if c.Width == 0 {
+			widths[i] = max(width-fixed, 8)
+			break
+		}
+	}
+	return widths
+}
+
+func (t *Table) headerLine(widths []int) string {
+	var b strings.Builder
+	for i, c := range t.Columns {
+		title := c.Title
+		if i == t.SortColumn {
+			if t.SortDesc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		b.WriteString(fitCell(title, widths[i]))
+	}
+	return DimStyle.Render(strings.TrimRight(b.String(), " "))
+}
+
+func (t *Table) rowLine(i int, widths []int) string {
+	row := t.Rows[i]
+	selected := i == t.Cursor
+
+	marker := "  "
+	markerStyle := lipgloss.NewStyle()
+	if selected {
+		marker, markerStyle = "› ", SelectedStyle
+	}
+	if t.RowStyle != nil {
+		markerStyle = t.RowStyle(row, -1, markerStyle)
+	}
+	line := markerStyle.Render(marker)
+
+	for c := range t.Columns {
+		// The first column is the row's label, the rest read as hints
+		style := DimStyle
+		if c == 0 {
+			style = NormalLabelStyle
+			if selected {
+				style = SelectedLabelStyle
+			}
+		}
+		if t.RowStyle != nil {
+			style = t.RowStyle(row, c, style)
+		}
+		text := fitCell(strings.ToUpper(cell(row, c)), widths[c])
+		if c == len(t.Columns)-1 {
+			text = strings.TrimRight(text, " ")
+		}
+		line += style.Render(text)
+	}
+	return line
+}
+
+// fitCell pads or truncates s to width cells, leaving a space before the
+// next column
+func fitCell(s string, width int) string {
+	if width <= 1 {
+		return ""
+	}
+	if lipgloss.Width(s) > width-1 {
+		runes := []rune(s)
+		for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width-1 {
+			runes = runes[:len(runes)-1]
+		}
+		s = string(runes) + "…"
+	}
+	return padToWidth(s, width)
+}
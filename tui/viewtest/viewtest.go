@@ -0,0 +1,234 @@
+// Package viewtest drives Bubble Tea models the way the program would, at a
+// fixed size and with synthetic keys, and snapshots what they draw. Views are
+// stabilized (escape sequences and Kitty images stripped) so they can be
+// compared with golden files in a package's testdata directory:
+//
+//	d := viewtest.New(model.New(database, dataPath), 120, 40)
+//	d.Keys("/")
+//	d.Type("belt")
+//	viewtest.Golden(t, "search_belt", d.View())
+//
+// Run the tests with UPDATE_SNAPSHOTS=1 to write the golden files instead.
+package viewtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// DefaultSettle is how long a command may run before its result is dropped.
+// It covers the search debounce and prefetch delay but not the diagram blink
+// or toast expiry, so toasts stay on screen and a blink never flips.
+const DefaultSettle = 300 * time.Millisecond
+
+// maxDepth bounds how many messages one sent message may lead to, so a
+// command that always schedules another can't loop forever
+const maxDepth = 50
+
+// Driver holds a model and feeds it messages, running the commands its
+// Update returns and feeding their results back, as tea.Program would.
+type Driver struct {
+	Model  tea.Model
+	Width  int
+	Height int
+
+	// Settle is how long each command may take, DefaultSettle unless set
+	Settle time.Duration
+
+	// Quit is set once the model asks to quit
+	Quit bool
+}
+
+// New returns a driver for m at the given size. Colors are turned off for
+// every renderer, since lipgloss's profile is global.
+func New(m tea.Model, width, height int) *Driver {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	d := &Driver{Model: m, Settle: DefaultSettle}
+	if cmd := m.Init(); cmd != nil {
+		for _, out := range d.run(cmd) {
+			d.update(out, 1)
+		}
+	}
+	d.Resize(width, height)
+	return d
+}
+
+// Resize sends a window size, as when the terminal is resized.
+func (d *Driver) Resize(width, height int) {
+	d.Width, d.Height = width, height
+	d.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Send feeds messages to the model in order, with everything their commands
+// produce within Settle.
+func (d *Driver) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		d.update(msg, 0)
+	}
+}
+
+// Keys presses keys by name: "enter", "esc", "ctrl+p", "shift+tab", "space"
+// and the like, or any other string as typed runes in one key message.
+func (d *Driver) Keys(names ...string) {
+	for _, name := range names {
+		d.Send(Key(name))
+	}
+}
+
+// Type types s one rune at a time, as a person would.
+func (d *Driver) Type(s string) {
+	for _, r := range s {
+		d.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// View returns the model's view, stabilized.
+func (d *Driver) View() string {
+	return Stabilize(d.Model.View())
+}
+
+func (d *Driver) update(msg tea.Msg, depth int) {
+	if _, ok := msg.(tea.QuitMsg); ok {
+		d.Quit = true
+		return
+	}
+	var cmd tea.Cmd
+	d.Model, cmd = d.Model.Update(msg)
+	if depth >= maxDepth {
+		return
+	}
+	for _, out := range d.run(cmd) {
+		d.update(out, depth+1)
+	}
+}
+
+// run runs cmd and the commands of any batch it returns, in order, and
+// returns the messages that arrived within Settle. Bubble Tea's own
+// messages, such as clearing the screen, are dropped except for quitting.
+func (d *Driver) run(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	settle := d.Settle
+	if settle == 0 {
+		settle = DefaultSettle
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(settle):
+		return nil
+	}
+
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, c := range msg {
+			msgs = append(msgs, d.run(c)...)
+		}
+		return msgs
+	case tea.QuitMsg:
+		return []tea.Msg{msg}
+	}
+	if reflect.TypeOf(msg).PkgPath() == reflect.TypeOf(tea.QuitMsg{}).PkgPath() {
+		return nil
+	}
+	return []tea.Msg{msg}
+}
+
+var keysByName = func() map[string]tea.KeyType {
+	keys := map[string]tea.KeyType{"space": tea.KeySpace}
+	for k := tea.KeyType(-100); k <= 127; k++ {
+		if name := k.String(); name != "" && name != " " && k != tea.KeyRunes {
+			keys[name] = k
+		}
+	}
+	return keys
+}()
+
+// Key returns the key message for a key name; see Driver.Keys.
+func Key(name string) tea.KeyMsg {
+	if k, ok := keysByName[name]; ok {
+		if k == tea.KeySpace {
+			return tea.KeyMsg{Type: k, Runes: []rune{' '}}
+		}
+		return tea.KeyMsg{Type: k}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// escapes matches CSI sequences (colors, cursor moves), OSC sequences
+// (titles, clipboard), APC sequences (Kitty images), cursor save and restore,
+// and the bell
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?<>=]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b_[^\x1b]*\x1b\\|\x1b[78]|\a`)
+
+// Stabilize strips escape sequences and trailing spaces from a view, leaving
+// only the text a person would read.
+func Stabilize(view string) string {
+	lines := strings.Split(escapes.ReplaceAllString(view, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Golden compares got with testdata/NAME.golden, or writes it there when
+// UPDATE_SNAPSHOTS is set.
+func Golden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv("UPDATE_SNAPSHOTS") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with UPDATE_SNAPSHOTS=1 to create it)", err)
+	}
+	if diff := firstDifference(string(want), got); diff != "" {
+		t.Errorf("%s differs from the snapshot: %s", name, diff)
+	}
+}
+
+// firstDifference describes the first line that differs, or returns "" if
+// want and got are the same
+func firstDifference(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d\n want: %q\n  got: %q", i+1, w, g)
+		}
+	}
+	return fmt.Sprintf("%d lines, want %d", len(gotLines), len(wantLines))
+}
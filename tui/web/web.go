@@ -0,0 +1,193 @@
+// Package web serves a read-only view of the catalog as plain HTML pages, for
+// browsing groups, subgroups, parts and diagrams from a phone in the garage.
+package web
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mshick/delica-space-gear-parts/tui/db"
+	"github.com/mshick/delica-space-gear-parts/tui/locale"
+	"github.com/mshick/delica-space-gear-parts/tui/supplier"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	"price": locale.Price,
+	"date":  locale.DateTimeString,
+	"supplierName": func(id string) string {
+		if s, ok := supplier.ByID(id); ok {
+			return s.Name()
+		}
+		return id
+	},
+	// image turns a diagram's image path, relative to the data directory,
+	// into its URL
+	"image": func(path string) string {
+		return "/" + filepath.ToSlash(path)
+	},
+}
+
+// pages parses each page with the shared layout, so every page can define
+// its own "content"
+var pages = func() map[string]*template.Template {
+	pages := make(map[string]*template.Template)
+	for _, name := range []string{"groups", "group", "subgroup", "part", "search"} {
+		pages[name] = template.Must(template.New("layout.html").Funcs(funcs).
+			ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+	}
+	return pages
+}()
+
+type server struct {
+	db *db.DB
+}
+
+// Handler serves the catalog in database, with diagram images from the
+// images directory under dataPath. Nothing it serves changes the database.
+func Handler(database *db.DB, dataPath string) http.Handler {
+	s := &server{db: database}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.groups)
+	mux.HandleFunc("GET /groups/{id}", s.group)
+	mux.HandleFunc("GET /subgroups/{id}", s.subgroup)
+	mux.HandleFunc("GET /parts/{id}", s.part)
+	mux.HandleFunc("GET /search", s.search)
+	mux.Handle("GET /images/", http.StripPrefix("/images/", http.FileServer(filesOnly{http.Dir(filepath.Join(dataPath, "images"))})))
+	return mux
+}
+
+// filesOnly serves the files of a directory but not listings of it or its
+// subdirectories, so the images can't be browsed, only fetched by the path
+// a page links to
+type filesOnly struct {
+	http.FileSystem
+}
+
+func (f filesOnly) Open(name string) (http.File, error) {
+	file, err := f.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// render writes a page, or a plain error if loading its data failed
+func (s *server) render(w http.ResponseWriter, r *http.Request, page string, data any, err error) {
+	if err != nil {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, "Could not load "+page+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages[page].Execute(w, data); err != nil {
+		log.Printf("render %s: %v", page, err)
+	}
+}
+
+func (s *server) groups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.db.GetGroups()
+	s.render(w, r, "groups", map[string]any{"Groups": groups}, err)
+}
+
+func (s *server) group(w http.ResponseWriter, r *http.Request) {
+	group, err := s.db.GetGroup(r.PathValue("id"))
+	if err == nil && group == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var subgroups []db.Subgroup
+	if err == nil {
+		subgroups, err = s.db.GetSubgroups(group.ID)
+	}
+	s.render(w, r, "group", map[string]any{"Group": group, "Subgroups": subgroups}, err)
+}
+
+func (s *server) subgroup(w http.ResponseWriter, r *http.Request) {
+	subgroup, err := s.db.GetSubgroup(r.PathValue("id"))
+	if err == nil && subgroup == nil {
+		http.NotFound(w, r)
+		return
+	}
+	data := map[string]any{"Subgroup": subgroup}
+	if err == nil {
+		data["Group"], err = s.db.GetGroup(subgroup.GroupID)
+	}
+	if err == nil {
+		data["Diagram"], err = s.db.GetDiagramForSubgroup(subgroup.ID)
+	}
+	if err == nil {
+		data["Parts"], err = s.db.GetPartsForSubgroup(subgroup.ID)
+	}
+	s.render(w, r, "subgroup", data, err)
+}
+
+func (s *server) part(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	part, err := s.db.GetPart(id)
+	if err == nil && part == nil {
+		http.NotFound(w, r)
+		return
+	}
+	data := map[string]any{"Part": part}
+	if err == nil {
+		data["Group"], err = s.db.GetGroup(part.GroupID)
+	}
+	if err == nil {
+		data["Subgroups"], err = s.db.GetSubgroupsForPartNumber(part.PartNumber)
+	}
+	if err == nil {
+		data["Bookmarked"], err = s.db.IsBookmarked(part.ID)
+	}
+	if err == nil {
+		data["Note"], err = s.db.GetNote(part.ID)
+	}
+	if err == nil {
+		data["KB"], err = s.db.GetKBEntriesForPart(part.ID)
+	}
+	if err == nil {
+		numbers := []string{part.PartNumber}
+		if part.ReplacementPartNumber != nil {
+			numbers = append(numbers, *part.ReplacementPartNumber)
+		}
+		data["Prices"], err = s.db.GetPrices(numbers...)
+	}
+	s.render(w, r, "part", data, err)
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	data := map[string]any{"Query": query}
+	results, err := s.db.SearchParts(query)
+	if errors.Is(err, db.ErrNoSearchIndex) {
+		data["Error"] = "The search index hasn't been built. Open search in the TUI to build it."
+		err = nil
+	} else if err != nil {
+		// Most search errors are a query the parser rejects
+		data["Error"] = err.Error()
+		err = nil
+	}
+	data["Results"] = results
+	s.render(w, r, "search", data, err)
+}
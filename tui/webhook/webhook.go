@@ -0,0 +1,240 @@
+// Package webhook publishes user data changes to other apps, such as a home
+// inventory (Grocy, HomeBox), by POSTing JSON to a URL and/or appending rows
+// to a CSV file.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mshick/delica-space-gear-parts/tui/netutil"
+)
+
+// Event is one change to user data.
+type Event struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`   // "bookmark", "note", "purchase" or "cart"
+	Action      string    `json:"action"` // "add", "remove" or "set"
+	PartID      int       `json:"part_id"`
+	PartNumber  string    `json:"part_number"`
+	Description string    `json:"description,omitempty"`
+	Note        string    `json:"note,omitempty"`
+
+	// For a bookmark or cart add or set: how many are now needed, or in
+	// the cart
+	Qty int `json:"qty,omitempty"`
+
+	// For a purchase set: when it was bought (YYYY-MM-DD) and, if known,
+	// for how much
+	PurchasedOn string   `json:"purchased_on,omitempty"`
+	Cost        *float64 `json:"cost,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+}
+
+var csvHeader = []string{"time", "kind", "action", "part_id", "part_number", "description", "note", "purchased_on", "cost", "currency", "qty"}
+
+// Notifier delivers events in order on a background goroutine, so a slow
+// webhook never holds up the UI. While offline, posts wait for the
+// connection rather than failing. A nil Notifier discards events.
+type Notifier struct {
+	url     string
+	csvPath string
+	logPath string
+
+	// Events not yet delivered, unbounded so a long spell offline never
+	// blocks Publish. wake signals run that there are more.
+	mu      sync.Mutex
+	pending []queued
+	wake    chan struct{}
+}
+
+// queued is an event waiting for delivery, or with flushed set, a marker
+// that Flush waits on, closed once everything queued before it is delivered
+type queued struct {
+	event   Event
+	flushed chan struct{}
+}
+
+// FromEnv returns a notifier for DELICA_WEBHOOK_URL and DELICA_WEBHOOK_CSV,
+// or nil if neither is set. Relative CSV paths are resolved from the project
+// root (the parent of the data directory). Delivery failures are appended to
+// webhook.log in the data directory.
+func FromEnv(dataPath string) *Notifier {
+	url := os.Getenv("DELICA_WEBHOOK_URL")
+	csvPath := os.Getenv("DELICA_WEBHOOK_CSV")
+	if url == "" && csvPath == "" {
+		return nil
+	}
+	if csvPath != "" && !filepath.IsAbs(csvPath) {
+		csvPath = filepath.Join(dataPath, "..", csvPath)
+	}
+
+	n := &Notifier{
+		url:     url,
+		csvPath: csvPath,
+		logPath: filepath.Join(dataPath, "webhook.log"),
+		wake:    make(chan struct{}, 1),
+	}
+	go n.run()
+	return n
+}
+
+// Publish queues events for delivery.
+func (n *Notifier) Publish(events ...Event) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	for _, e := range events {
+		if e.Time.IsZero() {
+			e.Time = time.Now()
+		}
+		n.pending = append(n.pending, queued{event: e})
+	}
+	n.mu.Unlock()
+	n.signal()
+}
+
+// Flush waits for the events published so far to be delivered, or failed
+// and logged, for at most wait, as the TUI does before it exits. It
+// reports whether they all were; any left are dropped when the program
+// ends.
+func (n *Notifier) Flush(wait time.Duration) bool {
+	if n == nil {
+		return true
+	}
+	flushed := make(chan struct{})
+	n.mu.Lock()
+	n.pending = append(n.pending, queued{flushed: flushed})
+	n.mu.Unlock()
+	n.signal()
+
+	select {
+	case <-flushed:
+		return true
+	case <-time.After(wait):
+		return false
+	}
+}
+
+func (n *Notifier) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Notifier) run() {
+	for range n.wake {
+		for {
+			n.mu.Lock()
+			if len(n.pending) == 0 {
+				n.mu.Unlock()
+				break
+			}
+			q := n.pending[0]
+			n.pending = n.pending[1:]
+			n.mu.Unlock()
+			if q.flushed != nil {
+				close(q.flushed)
+				continue
+			}
+			n.deliver(q.event)
+		}
+	}
+}
+
+func (n *Notifier) deliver(e Event) {
+	if n.csvPath != "" {
+		if err := appendCSV(n.csvPath, e); err != nil {
+			n.logError(e, err)
+		}
+	}
+	if n.url != "" {
+		netutil.WaitOnline(context.Background())
+		if err := post(n.url, e); err != nil {
+			n.logError(e, err)
+		}
+	}
+}
+
+func appendCSV(path string, e Event) error {
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if os.IsNotExist(statErr) {
+		w.Write(csvHeader)
+	}
+	qty := ""
+	if e.Qty > 0 {
+		qty = strconv.Itoa(e.Qty)
+	}
+	cost := ""
+	if e.Cost != nil {
+		cost = strconv.FormatFloat(*e.Cost, 'f', 2, 64)
+	}
+	w.Write([]string{
+		e.Time.Format(time.RFC3339),
+		e.Kind,
+		e.Action,
+		strconv.Itoa(e.PartID),
+		e.PartNumber,
+		e.Description,
+		e.Note,
+		e.PurchasedOn,
+		cost,
+		e.Currency,
+		qty,
+	})
+	w.Flush()
+	return w.Error()
+}
+
+func post(url string, e Event) error {
+	client, err := netutil.Default()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &netutil.StatusError{URL: url, Code: resp.StatusCode}
+	}
+	return nil
+}
+
+func (n *Notifier) logError(e Event, err error) {
+	f, openErr := os.OpenFile(n.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if openErr != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s %s %s: %v\n", time.Now().Format(time.RFC3339), e.Kind, e.Action, e.PartNumber, err)
+}